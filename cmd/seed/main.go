@@ -26,15 +26,15 @@ var stories = []struct {
 	url   string
 	tags  []string
 }{
-	{"Show HN: I built a news site for AI bots", "https://github.com/example/slashbot", []string{"show", "ai"}},
+	{"I built a news site for AI bots", "https://github.com/example/slashbot", []string{"show", "ai"}},
 	{"The Future of Agent-to-Agent Communication", "https://example.com/agent-communication", []string{"ai", "research"}},
 	{"Why Every Bot Needs a Social Network", "https://example.com/bot-social", []string{"opinion"}},
 	{"New Study: Bots Prefer Short Headlines", "https://example.com/bot-study", []string{"research", "ai"}},
-	{"Ask Slashbot: What's your favorite algorithm?", "", []string{"ask"}},
+	{"What's your favorite algorithm?", "", []string{"ask"}},
 	{"OpenAI Announces GPT-5 with Agent Capabilities", "https://example.com/gpt5-agents", []string{"news", "ai"}},
 	{"How We Scaled Our Bot Infrastructure to 1M Agents", "https://example.com/scaling-bots", []string{"engineering"}},
 	{"The Ethics of Autonomous AI Decision Making", "https://example.com/ai-ethics", []string{"ethics", "ai"}},
-	{"Show Slashbot: My Bot Can Write Poetry", "https://example.com/poetry-bot", []string{"show", "creative"}},
+	{"My Bot Can Write Poetry", "https://example.com/poetry-bot", []string{"show", "creative"}},
 	{"Claude vs GPT: A Bot's Perspective", "https://example.com/claude-vs-gpt", []string{"comparison", "ai"}},
 }
 
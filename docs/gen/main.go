@@ -0,0 +1,34 @@
+// Command gen writes the OpenAPI document (docs.go, swagger.json and
+// swagger.yaml) from the swag annotations on the API handlers and the
+// response types they name.
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/swaggo/swag"
+	"github.com/swaggo/swag/gen"
+)
+
+func main() {
+	in := flag.String("in", "../internal/http", "directory of annotated handlers")
+	out := flag.String("out", ".", "directory to write")
+	flag.Parse()
+	if err := build(*in, *out); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func build(in, out string) error {
+	return gen.New().Build(&gen.Config{
+		SearchDir:          in,
+		MainAPIFile:        "doc.go",
+		PropNamingStrategy: swag.PascalCase,
+		OutputDir:          out,
+		OutputTypes:        []string{"go", "json", "yaml"},
+		PackageName:        "docs",
+		ParseDependency:    1,
+		ParseDepth:         100,
+	})
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedUpToDate(t *testing.T) {
+	dir := t.TempDir()
+	if err := build("../../internal/http", dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"docs.go", "swagger.json", "swagger.yaml"} {
+		want, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := os.ReadFile(filepath.Join("..", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("docs/%s is stale; run go generate ./docs", name)
+		}
+	}
+}
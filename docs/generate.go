@@ -0,0 +1,6 @@
+package docs
+
+// The OpenAPI document is generated from the handlers' swag annotations;
+// run go generate ./docs after changing them or the response types.
+
+//go:generate go run ./gen -in ../internal/http -out .
@@ -0,0 +1,131 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// API key scopes. A key with no scopes has full account access.
+const (
+	ScopeStories  = "stories"
+	ScopeComments = "comments"
+	ScopeVotes    = "votes"
+	ScopeFlags    = "flags"
+	ScopeAccount  = "account"
+)
+
+// Scopes lists every scope an API key may be granted.
+var Scopes = []string{ScopeStories, ScopeComments, ScopeVotes, ScopeFlags, ScopeAccount}
+
+// ErrUnknownScope is returned when an API key is requested with a scope
+// not in Scopes.
+var ErrUnknownScope = errors.New("unknown scope")
+
+// ErrScopeEscalation is returned when an API key asks for a new key with
+// access it doesn't hold itself.
+var ErrScopeEscalation = errors.New("an api key can only issue keys with scopes it holds")
+
+// apiKeyPrefix marks slashbot API keys so they are easy to spot in leaks.
+const apiKeyPrefix = "sbk_"
+
+// apiKeyTouchInterval limits how often last-used timestamps are written.
+const apiKeyTouchInterval = time.Minute
+
+// HasScope reports whether the credential may perform actions in scope.
+// Bearer tokens and signed requests carry no scope restrictions.
+func (v Verified) HasScope(scope string) bool {
+	return v.Scopes == nil || slices.Contains(v.Scopes, scope)
+}
+
+// CanGrant reports whether the credential may issue an API key with scopes.
+// Tokens and signed requests may grant anything. An API key must name the
+// scopes, since none means full access, and may only name ones it holds.
+func (v Verified) CanGrant(scopes []string) error {
+	if v.APIKeyID == 0 {
+		return nil
+	}
+	if len(scopes) == 0 {
+		return fmt.Errorf("%w: name the scopes", ErrScopeEscalation)
+	}
+	for _, scope := range scopes {
+		if !v.HasScope(scope) {
+			return fmt.Errorf("%w: %q", ErrScopeEscalation, scope)
+		}
+	}
+	return nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// CreateAPIKey issues a new API key for accountID and returns the secret
+// value, which is not stored and cannot be shown again.
+func (s *Service) CreateAPIKey(ctx context.Context, accountID int64, name string, scopes []string, expiresAt *time.Time) (string, model.APIKey, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(Scopes, scope) {
+			return "", model.APIKey{}, fmt.Errorf("%w %q", ErrUnknownScope, scope)
+		}
+	}
+	secret, err := randomToken(32)
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	value := apiKeyPrefix + secret
+	key := model.APIKey{
+		AccountID: accountID,
+		Name:      name,
+		Prefix:    value[:len(apiKeyPrefix)+6],
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	id, err := s.store.CreateAPIKey(ctx, &key, hashAPIKey(value))
+	if err != nil {
+		return "", model.APIKey{}, err
+	}
+	key.ID = id
+	return value, key, nil
+}
+
+// AuthenticateAPIKey resolves an API key to its account and scopes and
+// records when it was last used.
+func (s *Service) AuthenticateAPIKey(ctx context.Context, value string) (Verified, error) {
+	if !strings.HasPrefix(value, apiKeyPrefix) {
+		return Verified{}, errors.New("invalid api key")
+	}
+	key, err := s.store.GetAPIKeyByHash(ctx, hashAPIKey(value))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return Verified{}, errors.New("invalid api key")
+		}
+		return Verified{}, err
+	}
+	if key.RevokedAt != nil {
+		return Verified{}, errors.New("api key revoked")
+	}
+	now := time.Now()
+	if key.ExpiresAt != nil && now.After(*key.ExpiresAt) {
+		return Verified{}, errors.New("api key expired")
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		if err := s.store.TouchAPIKey(ctx, key.ID, now); err != nil {
+			s.logger.Warn("api key last-used update failed", "api_key_id", key.ID, "err", err)
+		}
+	}
+	scopes := key.Scopes
+	if len(scopes) == 0 {
+		scopes = nil
+	}
+	return Verified{AccountID: &key.AccountID, Scopes: scopes, APIKeyID: key.ID}, nil
+}
@@ -3,8 +3,10 @@ package auth
 import (
 	"context"
 	"crypto"
+	"crypto/ecdh"
 	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/sha256"
@@ -14,10 +16,13 @@ import (
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/alphabot-ai/slashbot/internal/logging"
 	"github.com/alphabot-ai/slashbot/internal/model"
 	"github.com/alphabot-ai/slashbot/internal/store"
 
@@ -29,11 +34,26 @@ type Service struct {
 	store        store.Store
 	tokenTTL     time.Duration
 	challengeTTL time.Duration
+	logger       *slog.Logger
+
+	// jwt, when set, issues self-verifying JWT access tokens; revoked holds
+	// the IDs of revoked JWTs until they expire, revokedKeys the account
+	// keys revoked while JWTs issued to them may still be unexpired, and
+	// tokenCutoffs the accounts whose earlier JWTs were all revoked.
+	jwt          *JWTSigner
+	mu           sync.RWMutex
+	revoked      map[string]time.Time
+	revokedKeys  map[int64]bool
+	tokenCutoffs map[int64]time.Time
 }
 
 type Verified struct {
 	AccountID *int64
 	KeyID     int64
+	// Scopes restricts what an API key may do; nil means unrestricted.
+	Scopes []string
+	// APIKeyID is set when the credential is an API key.
+	APIKeyID int64
 }
 
 func NewService(store store.Store, tokenTTL, challengeTTL time.Duration) *Service {
@@ -41,9 +61,15 @@ func NewService(store store.Store, tokenTTL, challengeTTL time.Duration) *Servic
 		store:        store,
 		tokenTTL:     tokenTTL,
 		challengeTTL: challengeTTL,
+		logger:       logging.Component(slog.Default(), "auth"),
 	}
 }
 
+// SetLogger replaces the service's logger.
+func (s *Service) SetLogger(l *slog.Logger) {
+	s.logger = l
+}
+
 func (s *Service) CreateChallenge(ctx context.Context, alg string) (model.Challenge, error) {
 	challenge, err := randomToken(32)
 	if err != nil {
@@ -72,11 +98,12 @@ func (s *Service) VerifyAndCreateToken(ctx context.Context, alg, publicKey, chal
 		return model.Token{}, nil, errors.New("challenge alg mismatch")
 	}
 
-	if err := VerifySignature(alg, publicKey, challenge, signature); err != nil {
+	if err := VerifySignature(ctx, alg, publicKey, challenge, signature); err != nil {
+		s.logger.Debug("signature verification failed", "alg", alg, "err", err)
 		return model.Token{}, nil, err
 	}
 
-	key, account, err := s.store.FindAccountKey(ctx, alg, publicKey)
+	key, account, err := s.store.FindAccountKey(ctx, alg, NormalizePublicKey(alg, publicKey))
 	if err != nil {
 		if !errors.Is(err, store.ErrNotFound) {
 			return model.Token{}, nil, err
@@ -85,14 +112,10 @@ func (s *Service) VerifyAndCreateToken(ctx context.Context, alg, publicKey, chal
 		account = nil
 	}
 	if key.RevokedAt != nil {
+		s.logger.Info("rejected revoked key", "key_id", key.ID)
 		return model.Token{}, nil, errors.New("key revoked")
 	}
 
-	tokenValue, err := randomToken(32)
-	if err != nil {
-		return model.Token{}, nil, err
-	}
-
 	var accountID *int64
 	var keyID int64
 	if account != nil {
@@ -100,11 +123,27 @@ func (s *Service) VerifyAndCreateToken(ctx context.Context, alg, publicKey, chal
 		keyID = key.ID
 	}
 
+	if s.jwt != nil {
+		token, err := s.issueJWT(accountID, keyID)
+		if err != nil {
+			return model.Token{}, nil, err
+		}
+		return token, account, nil
+	}
+
+	tokenValue, err := randomToken(32)
+	if err != nil {
+		return model.Token{}, nil, err
+	}
+
+	client := clientInfoFrom(ctx)
 	token := model.Token{
 		Token:     tokenValue,
 		AccountID: accountID,
 		KeyID:     keyID,
 		ExpiresAt: time.Now().Add(s.tokenTTL),
+		UserAgent: client.UserAgent,
+		IPPrefix:  client.IPPrefix,
 	}
 	if err := s.store.CreateToken(ctx, token); err != nil {
 		return model.Token{}, nil, err
@@ -114,17 +153,28 @@ func (s *Service) VerifyAndCreateToken(ctx context.Context, alg, publicKey, chal
 }
 
 func (s *Service) Authenticate(ctx context.Context, bearer string) (Verified, error) {
+	if s.jwt != nil && isJWT(bearer) {
+		return s.authenticateJWT(bearer)
+	}
 	token, err := s.store.GetToken(ctx, bearer)
 	if err != nil {
 		return Verified{}, err
 	}
-	if time.Now().After(token.ExpiresAt) {
+	now := time.Now()
+	if now.After(token.ExpiresAt) {
 		return Verified{}, errors.New("token expired")
 	}
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= sessionTouchInterval {
+		if err := s.store.TouchToken(ctx, bearer, now); err != nil {
+			s.logger.Warn("token last-used update failed", "key_id", token.KeyID, "err", err)
+		}
+	}
 	return Verified{AccountID: token.AccountID, KeyID: token.KeyID}, nil
 }
 
-func VerifySignature(alg, publicKey, message, signature string) error {
+// VerifySignature checks signature over message against publicKey for alg.
+// ctx bounds the network lookup a did:web key needs.
+func VerifySignature(ctx context.Context, alg, publicKey, message, signature string) error {
 	switch strings.ToLower(alg) {
 	case "ed25519":
 		pubKey, sig, err := decodeEd25519(publicKey, signature)
@@ -155,6 +205,10 @@ func VerifySignature(alg, publicKey, message, signature string) error {
 			return errors.New("invalid secp256k1 signature")
 		}
 		return nil
+	case AlgEthAddress:
+		return verifyEthAddress(publicKey, message, signature)
+	case AlgDID:
+		return verifyDID(ctx, publicKey, message, signature)
 	case "rsa-pss", "rsa-sha256":
 		pubKey, sig, err := decodeRSA(publicKey, signature)
 		if err != nil {
@@ -171,6 +225,24 @@ func VerifySignature(alg, publicKey, message, signature string) error {
 			return errors.New("invalid rsa signature")
 		}
 		return nil
+	case "ecdsa-p256":
+		pubKey, sig, err := decodeP256(publicKey, signature)
+		if err != nil {
+			return err
+		}
+		h := sha256.Sum256([]byte(message))
+		// WebCrypto and most enclaves emit raw r||s; HSMs and WebAuthn emit ASN.1 DER.
+		if ecdsa.VerifyASN1(pubKey, h[:], sig) {
+			return nil
+		}
+		if len(sig) == 64 {
+			r := new(big.Int).SetBytes(sig[:32])
+			s := new(big.Int).SetBytes(sig[32:])
+			if ecdsa.Verify(pubKey, h[:], r, s) {
+				return nil
+			}
+		}
+		return errors.New("invalid ecdsa-p256 signature")
 	default:
 		return fmt.Errorf("unsupported alg: %s", alg)
 	}
@@ -238,6 +310,55 @@ func decodeRSA(pub, sig string) (*rsa.PublicKey, []byte, error) {
 	return pubKey, sigBytes, nil
 }
 
+// decodeP256 accepts a P-256 public key as PEM, base64/hex PKIX DER (what
+// WebAuthn getPublicKey returns), or base64/hex uncompressed SEC1 (65 bytes, 04 prefix).
+func decodeP256(pub, sig string) (*ecdsa.PublicKey, []byte, error) {
+	pubStr := strings.TrimSpace(pub)
+	var der []byte
+	if strings.HasPrefix(pubStr, "-----BEGIN") {
+		block, _ := pem.Decode([]byte(pubStr))
+		if block == nil {
+			return nil, nil, errors.New("invalid pem public key")
+		}
+		der = block.Bytes
+	} else {
+		b, err := decodeHexOrBase64(pubStr)
+		if err != nil {
+			return nil, nil, err
+		}
+		der = b
+	}
+
+	var pubKey *ecdsa.PublicKey
+	if len(der) == 65 && der[0] == 0x04 {
+		// NewPublicKey rejects points that are not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(der); err != nil {
+			return nil, nil, errors.New("invalid ecdsa-p256 public key")
+		}
+		pubKey = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(der[1:33]),
+			Y:     new(big.Int).SetBytes(der[33:]),
+		}
+	} else {
+		parsed, err := x509.ParsePKIXPublicKey(der)
+		if err != nil {
+			return nil, nil, err
+		}
+		pk, ok := parsed.(*ecdsa.PublicKey)
+		if !ok || pk.Curve != elliptic.P256() {
+			return nil, nil, errors.New("unsupported ecdsa-p256 public key")
+		}
+		pubKey = pk
+	}
+
+	sigBytes, err := decodeHexOrBase64(sig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pubKey, sigBytes, nil
+}
+
 func decodeHexPair(pub, sig string) ([]byte, []byte, error) {
 	pubBytes, err := decodeHex(pub)
 	if err != nil {
@@ -260,6 +381,15 @@ func decodeBase64OrHex(input string) ([]byte, error) {
 	return decodeHex(input)
 }
 
+// decodeHexOrBase64 prefers hex, since an even-length hex string is usually
+// also valid base64 and would otherwise decode to the wrong bytes.
+func decodeHexOrBase64(input string) ([]byte, error) {
+	if b, err := decodeHex(input); err == nil {
+		return b, nil
+	}
+	return decodeBase64OrHex(input)
+}
+
 func decodeHex(input string) ([]byte, error) {
 	clean := strings.TrimPrefix(strings.TrimSpace(input), "0x")
 	return hex.DecodeString(clean)
@@ -2,13 +2,29 @@ package auth
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/alphabot-ai/slashbot/internal/model"
 	"github.com/alphabot-ai/slashbot/internal/store/sqlite"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
 )
 
 func TestEd25519Challenge(t *testing.T) {
@@ -126,3 +142,445 @@ func TestRevokedKeyRejected(t *testing.T) {
 		t.Fatalf("expected revoked key error")
 	}
 }
+
+func TestSignedRequest(t *testing.T) {
+	st, err := sqlite.Open("file:auth_signed?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	svc := NewService(st, time.Hour, time.Minute)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	pubStr := base64.RawStdEncoding.EncodeToString(pub)
+
+	account := model.Account{DisplayName: "Signer", CreatedAt: time.Now()}
+	key := model.AccountKey{Alg: "ed25519", PublicKey: pubStr, CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(context.Background(), &account, &key)
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	body := []byte(`{"title":"hi"}`)
+	signed := func(ts int64, nonce string) SignedRequest {
+		msg := RequestMessage("POST", "/api/stories", body, ts, nonce)
+		return SignedRequest{
+			Alg:       "ed25519",
+			PublicKey: pubStr,
+			Method:    "POST",
+			Path:      "/api/stories",
+			Body:      body,
+			Timestamp: ts,
+			Nonce:     nonce,
+			Signature: base64.RawStdEncoding.EncodeToString(ed25519.Sign(priv, []byte(msg))),
+		}
+	}
+
+	now := time.Now().Unix()
+	req := signed(now, "nonce-0000000001")
+	verified, err := svc.AuthenticateRequest(context.Background(), req)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if verified.AccountID == nil || *verified.AccountID != accountID {
+		t.Fatalf("expected account %d, got %+v", accountID, verified)
+	}
+	if _, err := svc.AuthenticateRequest(context.Background(), req); err == nil {
+		t.Fatalf("expected replayed nonce to be rejected")
+	}
+
+	tampered := signed(now, "nonce-0000000002")
+	tampered.Body = []byte(`{"title":"bye"}`)
+	if _, err := svc.AuthenticateRequest(context.Background(), tampered); err == nil {
+		t.Fatalf("expected tampered body to be rejected")
+	}
+	// The failed attempt must not have consumed the nonce.
+	if _, err := svc.AuthenticateRequest(context.Background(), signed(now, "nonce-0000000002")); err != nil {
+		t.Fatalf("authenticate after tampered attempt: %v", err)
+	}
+
+	stale := signed(now-int64(2*SignedRequestMaxSkew/time.Second), "nonce-0000000003")
+	if _, err := svc.AuthenticateRequest(context.Background(), stale); err == nil {
+		t.Fatalf("expected stale timestamp to be rejected")
+	}
+}
+
+func TestJWTSignerRejectsWeakSecrets(t *testing.T) {
+	for _, secret := range []string{"", "dev-hash-secret", strings.Repeat("x", MinJWTSecretBytes-1)} {
+		if _, err := NewJWTSigner("", secret); err == nil {
+			t.Errorf("expected HS256 secret %q to be rejected", secret)
+		}
+	}
+	if _, err := NewJWTSigner("", strings.Repeat("x", MinJWTSecretBytes)); err != nil {
+		t.Fatalf("expected a %d-byte secret to be accepted: %v", MinJWTSecretBytes, err)
+	}
+}
+
+func TestJWTTokens(t *testing.T) {
+	st, err := sqlite.Open("file:auth_jwt?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	_, edKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	for name, privateKey := range map[string]string{
+		"HS256": "",
+		"EdDSA": base64.StdEncoding.EncodeToString(edKey.Seed()),
+	} {
+		t.Run(name, func(t *testing.T) {
+			signer, err := NewJWTSigner(privateKey, strings.Repeat("test-secret-", 3))
+			if err != nil {
+				t.Fatalf("new signer: %v", err)
+			}
+			svc := NewService(st, time.Hour, time.Minute)
+			if err := svc.EnableJWT(context.Background(), signer); err != nil {
+				t.Fatalf("enable jwt: %v", err)
+			}
+
+			accountID := int64(42)
+			token, err := svc.issueJWT(&accountID, 7)
+			if err != nil {
+				t.Fatalf("issue: %v", err)
+			}
+			verified, err := svc.Authenticate(context.Background(), token.Token)
+			if err != nil {
+				t.Fatalf("authenticate: %v", err)
+			}
+			if verified.AccountID == nil || *verified.AccountID != 42 || verified.KeyID != 7 {
+				t.Fatalf("unexpected claims %+v", verified)
+			}
+
+			if _, err := svc.Authenticate(context.Background(), token.Token+"x"); err == nil {
+				t.Fatalf("expected tampered token to be rejected")
+			}
+			other, _ := NewJWTSigner("", strings.Repeat("other-secret-", 3))
+			forged, _ := other.Sign(Claims{ID: "f", Scope: ScopeWrite, ExpiresAt: time.Now().Add(time.Hour).Unix()})
+			if _, err := svc.Authenticate(context.Background(), forged); err == nil {
+				t.Fatalf("expected token from another signer to be rejected")
+			}
+			expired, _ := signer.Sign(Claims{ID: "e", Scope: ScopeWrite, ExpiresAt: time.Now().Add(-time.Minute).Unix()})
+			if _, err := svc.Authenticate(context.Background(), expired); err == nil {
+				t.Fatalf("expected expired token to be rejected")
+			}
+
+			if err := svc.RevokeToken(context.Background(), token.Token); err != nil {
+				t.Fatalf("revoke: %v", err)
+			}
+			if _, err := svc.Authenticate(context.Background(), token.Token); err == nil {
+				t.Fatalf("expected revoked token to be rejected")
+			}
+			// A fresh service sees the revocation after loading the list.
+			fresh := NewService(st, time.Hour, time.Minute)
+			if err := fresh.EnableJWT(context.Background(), signer); err != nil {
+				t.Fatalf("enable jwt: %v", err)
+			}
+			if _, err := fresh.Authenticate(context.Background(), token.Token); err == nil {
+				t.Fatalf("expected revocation to persist")
+			}
+		})
+	}
+}
+
+func TestECDSAP256Signatures(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	ecdhPub, err := priv.PublicKey.ECDH()
+	if err != nil {
+		t.Fatalf("ecdh key: %v", err)
+	}
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	keys := map[string]string{
+		"pkix base64": base64.StdEncoding.EncodeToString(der),
+		"pem":         pemKey,
+		"sec1 hex":    hex.EncodeToString(ecdhPub.Bytes()),
+	}
+
+	msg := "slashbot-challenge"
+	h := sha256.Sum256([]byte(msg))
+	asn1Sig, err := ecdsa.SignASN1(rand.Reader, priv, h[:])
+	if err != nil {
+		t.Fatalf("sign asn1: %v", err)
+	}
+	r, s, err := ecdsa.Sign(rand.Reader, priv, h[:])
+	if err != nil {
+		t.Fatalf("sign raw: %v", err)
+	}
+	rawSig := make([]byte, 64)
+	r.FillBytes(rawSig[:32])
+	s.FillBytes(rawSig[32:])
+	sigs := map[string]string{
+		"asn1 base64": base64.StdEncoding.EncodeToString(asn1Sig),
+		"raw base64":  base64.StdEncoding.EncodeToString(rawSig),
+		"raw hex":     hex.EncodeToString(rawSig),
+	}
+
+	for keyName, key := range keys {
+		for sigName, sig := range sigs {
+			if err := VerifySignature(context.Background(), "ecdsa-p256", key, msg, sig); err != nil {
+				t.Errorf("%s / %s: %v", keyName, sigName, err)
+			}
+		}
+		if err := VerifySignature(context.Background(), "ecdsa-p256", key, "tampered", sigs["asn1 base64"]); err == nil {
+			t.Errorf("%s: expected tampered message to fail", keyName)
+		}
+	}
+
+	other, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate p384 key: %v", err)
+	}
+	otherDER, _ := x509.MarshalPKIXPublicKey(&other.PublicKey)
+	if err := VerifySignature(context.Background(), "ecdsa-p256", base64.StdEncoding.EncodeToString(otherDER), msg, sigs["asn1 base64"]); err == nil {
+		t.Fatalf("expected p384 key to be rejected")
+	}
+}
+
+func TestEthAddressLogin(t *testing.T) {
+	st, err := sqlite.Open("file:auth_eth?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	svc := NewService(st, time.Hour, time.Minute)
+
+	priv, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(priv.PubKey().SerializeUncompressed()[1:])
+	address := "0x" + strings.ToUpper(hex.EncodeToString(h.Sum(nil)[12:]))
+
+	// eth_personalSign returns r||s||v with v = 27 + recovery id.
+	personalSign := func(msg string) string {
+		compact := secpecdsa.SignCompact(priv, ethereumPersonalHash([]byte(msg)), false)
+		sig := append(append([]byte{}, compact[1:]...), compact[0])
+		return "0x" + hex.EncodeToString(sig)
+	}
+
+	account := model.Account{DisplayName: "Wallet", CreatedAt: time.Now()}
+	key := model.AccountKey{Alg: AlgEthAddress, PublicKey: NormalizePublicKey(AlgEthAddress, address), CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(context.Background(), &account, &key)
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	challenge, err := svc.CreateChallenge(context.Background(), AlgEthAddress)
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	_, got, err := svc.VerifyAndCreateToken(context.Background(), AlgEthAddress, address, challenge.Challenge, personalSign(challenge.Challenge))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got == nil || got.ID != accountID {
+		t.Fatalf("expected account %d, got %+v", accountID, got)
+	}
+
+	if err := VerifySignature(context.Background(), AlgEthAddress, address, "other message", personalSign("message")); err == nil {
+		t.Fatalf("expected signature over another message to be rejected")
+	}
+	other, _ := secp256k1.GeneratePrivateKey()
+	h.Reset()
+	h.Write(other.PubKey().SerializeUncompressed()[1:])
+	if err := VerifySignature(context.Background(), AlgEthAddress, hex.EncodeToString(h.Sum(nil)[12:]), "message", personalSign("message")); err == nil {
+		t.Fatalf("expected signature from another wallet to be rejected")
+	}
+}
+
+func encodeBase58(b []byte) string {
+	n := new(big.Int).SetBytes(b)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for n.Sign() > 0 {
+		n.DivMod(n, radix, mod)
+		out = append([]byte{base58Alphabet[mod.Int64()]}, out...)
+	}
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append([]byte{'1'}, out...)
+	}
+	return string(out)
+}
+
+func TestDIDKeyLogin(t *testing.T) {
+	st, err := sqlite.Open("file:auth_did_key?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	svc := NewService(st, time.Hour, time.Minute)
+
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	did := "did:key:z" + encodeBase58(append([]byte{0xed, 0x01}, pub...))
+
+	account := model.Account{DisplayName: "DIDBot", DID: did, CreatedAt: time.Now()}
+	key := model.AccountKey{Alg: AlgDID, PublicKey: did, CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(context.Background(), &account, &key)
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	challenge, err := svc.CreateChallenge(context.Background(), AlgDID)
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(challenge.Challenge)))
+	_, got, err := svc.VerifyAndCreateToken(context.Background(), AlgDID, did, challenge.Challenge, sig)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got == nil || got.ID != accountID || got.DID != did {
+		t.Fatalf("expected account %d with did, got %+v", accountID, got)
+	}
+
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+	forged := base64.StdEncoding.EncodeToString(ed25519.Sign(otherPriv, []byte("msg")))
+	if err := VerifySignature(context.Background(), AlgDID, did, "msg", forged); err == nil {
+		t.Fatalf("expected signature from another key to be rejected")
+	}
+}
+
+func TestDIDWebResolution(t *testing.T) {
+	signer, signerPriv, _ := ed25519.GenerateKey(nil)
+	unlisted, unlistedPriv, _ := ed25519.GenerateKey(nil)
+	p256, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate p256: %v", err)
+	}
+
+	var did string
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/bots/alice/did.json" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"id": did,
+			"verificationMethod": []map[string]any{
+				{"id": did + "#p256", "type": "JsonWebKey2020", "publicKeyJwk": map[string]string{
+					"kty": "EC", "crv": "P-256",
+					"x": base64.RawURLEncoding.EncodeToString(p256.X.FillBytes(make([]byte, 32))),
+					"y": base64.RawURLEncoding.EncodeToString(p256.Y.FillBytes(make([]byte, 32))),
+				}},
+				{"id": "#ed", "type": "Multikey", "publicKeyMultibase": "z" + encodeBase58(append([]byte{0xed, 0x01}, signer...))},
+				{"id": "#assert", "type": "Multikey", "publicKeyMultibase": "z" + encodeBase58(append([]byte{0xed, 0x01}, unlisted...))},
+			},
+			"authentication": []string{did + "#p256", "#ed"},
+		})
+	}))
+	defer ts.Close()
+
+	prevClient := DIDClient
+	DIDClient = ts.Client()
+	defer func() { DIDClient = prevClient }()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	did = "did:web:" + strings.ReplaceAll(host, ":", "%3A") + ":bots:alice"
+
+	methods, err := ResolveDID(context.Background(), did)
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(methods) != 2 {
+		t.Fatalf("expected 2 authentication methods, got %+v", methods)
+	}
+
+	before := DIDCacheStats()
+	msg := "challenge"
+	if err := VerifySignature(context.Background(), AlgDID, did, msg, base64.StdEncoding.EncodeToString(ed25519.Sign(signerPriv, []byte(msg)))); err != nil {
+		t.Fatalf("ed25519 method: %v", err)
+	}
+	h := sha256.Sum256([]byte(msg))
+	p256Sig, _ := ecdsa.SignASN1(rand.Reader, p256, h[:])
+	if err := VerifySignature(context.Background(), AlgDID, did, msg, base64.StdEncoding.EncodeToString(p256Sig)); err != nil {
+		t.Fatalf("p256 method: %v", err)
+	}
+	if err := VerifySignature(context.Background(), AlgDID, did, msg, base64.StdEncoding.EncodeToString(ed25519.Sign(unlistedPriv, []byte(msg)))); err == nil {
+		t.Fatalf("expected key outside the authentication relationship to be rejected")
+	}
+	if after := DIDCacheStats(); after.Hits != before.Hits+3 || after.Misses != before.Misses {
+		t.Fatalf("expected 3 cache hits and no misses, got %+v then %+v", before, after)
+	}
+
+	if _, err := ResolveDID(context.Background(), "did:web:"+strings.ReplaceAll(host, ":", "%3A")+":bots:bob"); err == nil {
+		t.Fatalf("expected missing did document to fail")
+	}
+}
+
+func TestDIDWebGuards(t *testing.T) {
+	for addr, public := range map[string]bool{
+		"93.184.216.34:443":        true,
+		"[2606:4700::1111]:443":    true,
+		"127.0.0.1:443":            false,
+		"10.1.2.3:443":             false,
+		"192.168.0.10:443":         false,
+		"169.254.169.254:80":       false,
+		"100.64.0.1:443":           false,
+		"0.0.0.0:443":              false,
+		"[::1]:443":                false,
+		"[fe80::1]:443":            false,
+		"[fd00::1]:443":            false,
+		"[::ffff:127.0.0.1]:443":   false,
+		"[::ffff:93.184.216.34]:1": true,
+	} {
+		if err := publicAddressOnly("tcp", addr, nil); (err == nil) != public {
+			t.Errorf("%s: expected public=%v, got err %v", addr, public, err)
+		}
+	}
+
+	// The default client refuses the loopback test server outright.
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	did := "did:web:" + strings.ReplaceAll(strings.TrimPrefix(ts.URL, "https://"), ":", "%3A")
+	req, _ := http.NewRequest(http.MethodGet, ts.URL, nil)
+	if _, err := DIDClient.Do(req); err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Fatalf("expected loopback dial to be refused, got %v", err)
+	}
+
+	// A cancelled request context stops resolution.
+	prevClient := DIDClient
+	DIDClient = ts.Client()
+	defer func() { DIDClient = prevClient }()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ResolveDID(ctx, did); err == nil {
+		t.Fatalf("expected resolution to fail with a cancelled context")
+	}
+
+	// Expired entries are swept when a new document is cached.
+	didCacheMu.Lock()
+	prevCache := didCache
+	didCache = map[string]cachedDID{}
+	for i := range maxCachedDIDs {
+		didCache["did:web:expired"+strconv.Itoa(i)] = cachedDID{expires: time.Now().Add(-time.Second)}
+	}
+	didCacheMu.Unlock()
+	defer func() { didCache = prevCache }()
+	cacheDID("did:web:fresh", nil)
+	if len(didCache) != 1 {
+		t.Fatalf("expected expired entries evicted, %d left", len(didCache))
+	}
+}
@@ -0,0 +1,391 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// AlgDID identifies an account by a DID instead of a raw public key. The
+// DID is resolved to its verification methods and a signature from any of
+// them is accepted.
+const AlgDID = "did"
+
+// DIDClient fetches did:web documents. It uses PublicTransport, so an
+// unauthenticated login can't make the server reach into its own network.
+var DIDClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: PublicTransport(),
+}
+
+// PublicTransport returns a transport for fetching URLs chosen by callers.
+// Its dialer refuses loopback, private and link-local addresses.
+func PublicTransport() *http.Transport {
+	return &http.Transport{
+		DialContext:         (&net.Dialer{Timeout: 5 * time.Second, Control: publicAddressOnly}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	}
+}
+
+// didCacheTTL bounds how long a resolved did:web document is trusted, so
+// a signed request per write doesn't mean an HTTPS fetch per write.
+const didCacheTTL = 5 * time.Minute
+
+// maxCachedDIDs caps the did:web cache; expired entries are swept first.
+const maxCachedDIDs = 1024
+
+const maxDIDDocumentSize = 64 << 10
+
+// VerificationMethod is a DID key reduced to an alg and public key that
+// VerifySignature understands.
+type VerificationMethod struct {
+	ID        string
+	Alg       string
+	PublicKey string
+}
+
+type cachedDID struct {
+	methods []VerificationMethod
+	expires time.Time
+}
+
+var (
+	didCacheMu     sync.Mutex
+	didCache       = map[string]cachedDID{}
+	didCacheHits   atomic.Int64
+	didCacheMisses atomic.Int64
+)
+
+// CacheStats counts lookups against an in-process cache since startup.
+type CacheStats struct {
+	Hits    int64
+	Misses  int64
+	Entries int
+}
+
+// DIDCacheStats reports how often did:web resolution was served from cache.
+func DIDCacheStats() CacheStats {
+	didCacheMu.Lock()
+	entries := len(didCache)
+	didCacheMu.Unlock()
+	return CacheStats{Hits: didCacheHits.Load(), Misses: didCacheMisses.Load(), Entries: entries}
+}
+
+// Multicodec prefixes (unsigned varints) for the key types we accept.
+var (
+	multicodecEd25519   = []byte{0xed, 0x01}
+	multicodecSecp256k1 = []byte{0xe7, 0x01}
+	multicodecP256      = []byte{0x80, 0x24}
+)
+
+// ResolveDID returns the verification methods of a did:key or did:web DID.
+// did:key is decoded locally; did:web is fetched over HTTPS and cached.
+func ResolveDID(ctx context.Context, did string) ([]VerificationMethod, error) {
+	switch {
+	case strings.HasPrefix(did, "did:key:"):
+		m, err := parseMultibaseKey(strings.TrimPrefix(did, "did:key:"))
+		if err != nil {
+			return nil, err
+		}
+		m.ID = did
+		return []VerificationMethod{m}, nil
+	case strings.HasPrefix(did, "did:web:"):
+		didCacheMu.Lock()
+		cached, ok := didCache[did]
+		didCacheMu.Unlock()
+		if ok && time.Now().Before(cached.expires) {
+			didCacheHits.Add(1)
+			return cached.methods, nil
+		}
+		didCacheMisses.Add(1)
+		methods, err := resolveDIDWeb(ctx, did)
+		if err != nil {
+			return nil, err
+		}
+		cacheDID(did, methods)
+		return methods, nil
+	default:
+		return nil, errors.New("unsupported did method (want did:key or did:web)")
+	}
+}
+
+// cacheDID stores a resolved document, first dropping expired entries and,
+// if the cache is still full, an arbitrary one.
+func cacheDID(did string, methods []VerificationMethod) {
+	now := time.Now()
+	didCacheMu.Lock()
+	defer didCacheMu.Unlock()
+	for k, c := range didCache {
+		if !now.Before(c.expires) {
+			delete(didCache, k)
+		}
+	}
+	for k := range didCache {
+		if len(didCache) < maxCachedDIDs {
+			break
+		}
+		delete(didCache, k)
+	}
+	didCache[did] = cachedDID{methods: methods, expires: now.Add(didCacheTTL)}
+}
+
+// publicAddressOnly is a net.Dialer Control hook that refuses connections to
+// anything but public unicast addresses. It runs after DNS resolution and on
+// every redirect, so neither a rebinding name nor a 302 gets past it.
+func publicAddressOnly(network, address string, _ syscall.RawConn) error {
+	ap, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := ap.Addr().Unmap(); !PublicAddr(ip) {
+		return fmt.Errorf("host resolves to non-public address %s", ip)
+	}
+	return nil
+}
+
+// PublicAddr reports whether ip is a public unicast address.
+func PublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !ip.IsLoopback() && !ip.IsLinkLocalUnicast() && !sharedAddressSpace.Contains(ip)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), which
+// netip doesn't count as private.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func verifyDID(ctx context.Context, did, message, signature string) error {
+	methods, err := ResolveDID(ctx, did)
+	if err != nil {
+		return err
+	}
+	for _, m := range methods {
+		if VerifySignature(ctx, m.Alg, m.PublicKey, message, signature) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature does not match any verification method of the did")
+}
+
+// didWebURL maps did:web:example.com:user:alice to
+// https://example.com/user/alice/did.json, and a bare host to
+// https://host/.well-known/did.json. A port is percent-encoded in the host.
+func didWebURL(did string) (string, error) {
+	parts := strings.Split(strings.TrimPrefix(did, "did:web:"), ":")
+	host, err := url.PathUnescape(parts[0])
+	if err != nil || host == "" || strings.ContainsAny(host, "/?#@") {
+		return "", errors.New("invalid did:web host")
+	}
+	path := "/.well-known"
+	if len(parts) > 1 {
+		path = ""
+		for _, p := range parts[1:] {
+			seg, err := url.PathUnescape(p)
+			if err != nil || seg == "" || seg == "." || seg == ".." || strings.Contains(seg, "/") {
+				return "", errors.New("invalid did:web path")
+			}
+			path += "/" + url.PathEscape(seg)
+		}
+	}
+	return "https://" + host + path + "/did.json", nil
+}
+
+type didDocument struct {
+	ID                 string            `json:"id"`
+	VerificationMethod []didMethod       `json:"verificationMethod"`
+	Authentication     []json.RawMessage `json:"authentication"`
+}
+
+type didMethod struct {
+	ID                 string      `json:"id"`
+	Type               string      `json:"type"`
+	PublicKeyMultibase string      `json:"publicKeyMultibase"`
+	PublicKeyBase58    string      `json:"publicKeyBase58"`
+	PublicKeyJwk       *jsonWebKey `json:"publicKeyJwk"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func resolveDIDWeb(ctx context.Context, did string) ([]VerificationMethod, error) {
+	docURL, err := didWebURL(did)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, docURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/did+json, application/json")
+	resp, err := DIDClient.Do(req)
+	if err != nil {
+		return nil, errors.New("did:web resolution failed")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("did:web resolution failed: status %d", resp.StatusCode)
+	}
+	var doc didDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxDIDDocumentSize)).Decode(&doc); err != nil {
+		return nil, errors.New("invalid did document")
+	}
+	if doc.ID != did {
+		return nil, errors.New("did document id does not match did")
+	}
+
+	// Only methods in the authentication relationship may log in; a
+	// document without one falls back to all its verification methods.
+	candidates := doc.VerificationMethod
+	if len(doc.Authentication) > 0 {
+		byID := make(map[string]didMethod, len(doc.VerificationMethod))
+		for _, m := range doc.VerificationMethod {
+			byID[m.ID] = m
+			if strings.HasPrefix(m.ID, "#") {
+				byID[did+m.ID] = m
+			}
+		}
+		candidates = nil
+		for _, raw := range doc.Authentication {
+			var ref string
+			if json.Unmarshal(raw, &ref) == nil {
+				if strings.HasPrefix(ref, "#") {
+					ref = did + ref
+				}
+				if m, ok := byID[ref]; ok {
+					candidates = append(candidates, m)
+				}
+				continue
+			}
+			var m didMethod
+			if json.Unmarshal(raw, &m) == nil {
+				candidates = append(candidates, m)
+			}
+		}
+	}
+
+	var methods []VerificationMethod
+	for _, m := range candidates {
+		vm, err := m.verificationMethod()
+		if err != nil {
+			continue
+		}
+		vm.ID = m.ID
+		methods = append(methods, vm)
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("did document has no supported verification methods")
+	}
+	return methods, nil
+}
+
+func (m didMethod) verificationMethod() (VerificationMethod, error) {
+	switch {
+	case m.PublicKeyMultibase != "":
+		return parseMultibaseKey(m.PublicKeyMultibase)
+	case m.PublicKeyBase58 != "" && m.Type == "Ed25519VerificationKey2018":
+		raw, err := decodeBase58(m.PublicKeyBase58)
+		if err != nil || len(raw) != 32 {
+			return VerificationMethod{}, errors.New("invalid ed25519 key")
+		}
+		return VerificationMethod{Alg: "ed25519", PublicKey: base64.StdEncoding.EncodeToString(raw)}, nil
+	case m.PublicKeyJwk != nil:
+		return m.PublicKeyJwk.verificationMethod()
+	}
+	return VerificationMethod{}, errors.New("unsupported verification method")
+}
+
+func (k jsonWebKey) verificationMethod() (VerificationMethod, error) {
+	x, err := base64.RawURLEncoding.DecodeString(k.X)
+	if err != nil {
+		return VerificationMethod{}, err
+	}
+	switch {
+	case k.Kty == "OKP" && k.Crv == "Ed25519" && len(x) == 32:
+		return VerificationMethod{Alg: "ed25519", PublicKey: base64.StdEncoding.EncodeToString(x)}, nil
+	case k.Kty == "EC" && (k.Crv == "P-256" || k.Crv == "secp256k1"):
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil || len(x) != 32 || len(y) != 32 {
+			return VerificationMethod{}, errors.New("invalid ec jwk")
+		}
+		point := hex.EncodeToString(append(append([]byte{0x04}, x...), y...))
+		if k.Crv == "P-256" {
+			return VerificationMethod{Alg: "ecdsa-p256", PublicKey: point}, nil
+		}
+		return VerificationMethod{Alg: "secp256k1", PublicKey: point}, nil
+	}
+	return VerificationMethod{}, errors.New("unsupported jwk")
+}
+
+// parseMultibaseKey decodes a base58btc ("z") multibase value carrying a
+// multicodec-prefixed public key, as used by did:key and Multikey.
+func parseMultibaseKey(value string) (VerificationMethod, error) {
+	if !strings.HasPrefix(value, "z") {
+		return VerificationMethod{}, errors.New("unsupported multibase encoding (want base58btc)")
+	}
+	raw, err := decodeBase58(value[1:])
+	if err != nil {
+		return VerificationMethod{}, err
+	}
+	switch {
+	case hasPrefix(raw, multicodecEd25519) && len(raw) == 2+32:
+		return VerificationMethod{Alg: "ed25519", PublicKey: base64.StdEncoding.EncodeToString(raw[2:])}, nil
+	case hasPrefix(raw, multicodecSecp256k1) && len(raw) == 2+33:
+		return VerificationMethod{Alg: "secp256k1", PublicKey: hex.EncodeToString(raw[2:])}, nil
+	case hasPrefix(raw, multicodecP256) && len(raw) == 2+33:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), raw[2:])
+		if x == nil {
+			return VerificationMethod{}, errors.New("invalid p-256 key")
+		}
+		der, err := x509.MarshalPKIXPublicKey(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y})
+		if err != nil {
+			return VerificationMethod{}, err
+		}
+		return VerificationMethod{Alg: "ecdsa-p256", PublicKey: base64.StdEncoding.EncodeToString(der)}, nil
+	}
+	return VerificationMethod{}, errors.New("unsupported multicodec key type")
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+func decodeBase58(s string) ([]byte, error) {
+	n := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, errors.New("invalid base58 character")
+		}
+		n.Mul(n, radix)
+		n.Add(n, big.NewInt(int64(i)))
+	}
+	// Each leading '1' encodes a leading zero byte.
+	zeros := 0
+	for zeros < len(s) && s[zeros] == '1' {
+		zeros++
+	}
+	return append(make([]byte, zeros), n.Bytes()...), nil
+}
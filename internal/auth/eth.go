@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"golang.org/x/crypto/sha3"
+)
+
+// AlgEthAddress identifies an account by its Ethereum address rather than
+// a raw public key. Signatures are eth_personalSign (EIP-191) outputs; the
+// signer's key is recovered from the signature and its address compared.
+const AlgEthAddress = "eth-address"
+
+// NormalizePublicKey returns the canonical stored form of a public key so
+// lookups match however the client cased or padded it.
+func NormalizePublicKey(alg, publicKey string) string {
+	publicKey = strings.TrimSpace(publicKey)
+	if strings.EqualFold(alg, AlgEthAddress) {
+		return "0x" + strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(publicKey, "0x"), "0X"))
+	}
+	return publicKey
+}
+
+func verifyEthAddress(address, message, signature string) error {
+	want, err := decodeHex(NormalizePublicKey(AlgEthAddress, address))
+	if err != nil || len(want) != 20 {
+		return errors.New("invalid ethereum address")
+	}
+	sig, err := decodeHex(signature)
+	if err != nil || len(sig) != 65 {
+		return errors.New("invalid eth-address signature length")
+	}
+	recovered, err := recoverEthAddress(ethereumPersonalHash([]byte(message)), sig)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(recovered, want) {
+		return errors.New("signature does not match ethereum address")
+	}
+	return nil
+}
+
+// recoverEthAddress recovers the signer address from a 65-byte r||s||v
+// signature. v may be 0/1 or the legacy 27/28.
+func recoverEthAddress(hash, sig []byte) ([]byte, error) {
+	v := sig[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return nil, errors.New("invalid eth-address signature recovery id")
+	}
+	// RecoverCompact wants v||r||s with v = 27 + recid for uncompressed keys.
+	compact := make([]byte, 65)
+	compact[0] = 27 + v
+	copy(compact[1:], sig[:64])
+	pub, _, err := ecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return nil, errors.New("invalid eth-address signature")
+	}
+	h := sha3.NewLegacyKeccak256()
+	h.Write(pub.SerializeUncompressed()[1:])
+	return h.Sum(nil)[12:], nil
+}
@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// ScopeWrite is the scope granted to access tokens from the challenge flow.
+const ScopeWrite = "write"
+
+// ErrInvalidToken is returned for JWTs that are malformed or fail signature
+// verification.
+var ErrInvalidToken = errors.New("invalid token")
+
+// JWTSigner issues and verifies JWT access tokens locally, either HS256
+// with a shared secret or EdDSA with an ed25519 keypair.
+type JWTSigner struct {
+	alg    string
+	secret []byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+}
+
+// MinJWTSecretBytes is the shortest secret NewJWTSigner accepts for HS256.
+// Anyone who guesses the secret can mint tokens for any account.
+const MinJWTSecretBytes = 32
+
+// NewJWTSigner returns an EdDSA signer when privateKey (base64 ed25519
+// private key or 32-byte seed) is set, and an HS256 signer keyed by secret
+// otherwise.
+func NewJWTSigner(privateKey, secret string) (*JWTSigner, error) {
+	if privateKey == "" {
+		if len(secret) < MinJWTSecretBytes {
+			return nil, fmt.Errorf("jwt: HS256 needs a secret of at least %d bytes", MinJWTSecretBytes)
+		}
+		return &JWTSigner{alg: "HS256", secret: []byte(secret)}, nil
+	}
+	raw, err := decodeBase64OrHex(privateKey)
+	if err != nil {
+		return nil, fmt.Errorf("jwt: decode private key: %w", err)
+	}
+	var priv ed25519.PrivateKey
+	switch len(raw) {
+	case ed25519.SeedSize:
+		priv = ed25519.NewKeyFromSeed(raw)
+	case ed25519.PrivateKeySize:
+		priv = ed25519.PrivateKey(raw)
+	default:
+		return nil, errors.New("jwt: invalid ed25519 private key size")
+	}
+	return &JWTSigner{alg: "EdDSA", priv: priv, pub: priv.Public().(ed25519.PublicKey)}, nil
+}
+
+// Claims is the payload of a slashbot access token.
+type Claims struct {
+	ID        string `json:"jti"`
+	AccountID *int64 `json:"account_id,omitempty"`
+	KeyID     int64  `json:"key_id"`
+	Scope     string `json:"scope"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign encodes and signs claims as a compact JWT.
+func (j *JWTSigner) Sign(c Claims) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": j.alg, "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	signingInput := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	return signingInput + "." + enc.EncodeToString(j.sign([]byte(signingInput))), nil
+}
+
+func (j *JWTSigner) sign(input []byte) []byte {
+	if j.alg == "EdDSA" {
+		return ed25519.Sign(j.priv, input)
+	}
+	mac := hmac.New(sha256.New, j.secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+// Parse verifies the token's signature and returns its claims. It does not
+// check expiry or revocation.
+func (j *JWTSigner) Parse(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrInvalidToken
+	}
+	enc := base64.RawURLEncoding
+	headerJSON, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != j.alg {
+		return Claims{}, ErrInvalidToken
+	}
+	sig, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	if j.alg == "EdDSA" {
+		if !ed25519.Verify(j.pub, signingInput, sig) {
+			return Claims{}, ErrInvalidToken
+		}
+	} else if !hmac.Equal(sig, j.sign(signingInput)) {
+		return Claims{}, ErrInvalidToken
+	}
+	payload, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	var c Claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	return c, nil
+}
+
+// isJWT reports whether a bearer value looks like a JWT rather than an
+// opaque token (which never contains dots).
+func isJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
+
+// EnableJWT makes the service issue JWT access tokens signed by signer and
+// loads the revocation list. Opaque tokens issued earlier stay valid.
+func (s *Service) EnableJWT(ctx context.Context, signer *JWTSigner) error {
+	s.jwt = signer
+	return s.LoadRevocations(ctx)
+}
+
+// LoadRevocations refreshes the in-memory lists of revoked JWTs, revoked
+// account keys and per-account token cutoffs from the store, so revocations
+// made by other instances take effect.
+func (s *Service) LoadRevocations(ctx context.Context) error {
+	if s.jwt == nil {
+		return nil
+	}
+	now := time.Now()
+	revoked, err := s.store.ListRevokedTokens(ctx, now)
+	if err != nil {
+		return err
+	}
+	// A JWT outlives its key by at most the token TTL.
+	revokedKeys, err := s.store.ListRevokedKeys(ctx, now.Add(-s.tokenTTL))
+	if err != nil {
+		return err
+	}
+	cutoffs, err := s.store.ListTokenCutoffs(ctx, now.Add(-s.tokenTTL))
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.revoked = revoked
+	s.revokedKeys = revokedKeys
+	s.tokenCutoffs = cutoffs
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Service) isRevoked(c Claims) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if _, ok := s.revoked[c.ID]; ok {
+		return true
+	}
+	if c.AccountID != nil {
+		// iat has one-second resolution, so a token issued in the
+		// cutoff's second is treated as issued before it.
+		if cutoff, ok := s.tokenCutoffs[*c.AccountID]; ok && c.IssuedAt <= cutoff.Unix() {
+			return true
+		}
+	}
+	return s.revokedKeys[c.KeyID]
+}
+
+func (s *Service) issueJWT(accountID *int64, keyID int64) (model.Token, error) {
+	jti, err := randomToken(16)
+	if err != nil {
+		return model.Token{}, err
+	}
+	now := time.Now()
+	expires := now.Add(s.tokenTTL)
+	value, err := s.jwt.Sign(Claims{
+		ID:        jti,
+		AccountID: accountID,
+		KeyID:     keyID,
+		Scope:     ScopeWrite,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+	})
+	if err != nil {
+		return model.Token{}, err
+	}
+	return model.Token{Token: value, AccountID: accountID, KeyID: keyID, ExpiresAt: expires}, nil
+}
+
+func (s *Service) authenticateJWT(token string) (Verified, error) {
+	c, err := s.jwt.Parse(token)
+	if err != nil {
+		return Verified{}, err
+	}
+	if time.Now().Unix() >= c.ExpiresAt {
+		return Verified{}, errors.New("token expired")
+	}
+	if c.Scope != ScopeWrite {
+		return Verified{}, errors.New("insufficient scope")
+	}
+	if s.isRevoked(c) {
+		return Verified{}, errors.New("token revoked")
+	}
+	return Verified{AccountID: c.AccountID, KeyID: c.KeyID}, nil
+}
+
+// RevokeToken invalidates an access token before it expires. JWTs are added
+// to the revocation list; opaque tokens are deleted.
+func (s *Service) RevokeToken(ctx context.Context, token string) error {
+	if s.jwt == nil || !isJWT(token) {
+		return s.store.DeleteToken(ctx, token)
+	}
+	c, err := s.jwt.Parse(token)
+	if err != nil {
+		return err
+	}
+	expires := time.Unix(c.ExpiresAt, 0)
+	if err := s.store.RevokeToken(ctx, c.ID, expires); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	if s.revoked == nil {
+		s.revoked = make(map[string]time.Time)
+	}
+	s.revoked[c.ID] = expires
+	s.mu.Unlock()
+	return nil
+}
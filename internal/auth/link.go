@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// KeyLink claims that the holder of an account's key also holds
+// RemoteAccountID on the Slashbot instance at InstanceURL.
+type KeyLink struct {
+	AccountID       int64
+	InstanceURL     string
+	RemoteAccountID int64
+	Alg             string
+	PublicKey       string
+	Challenge       string
+	Signature       string
+}
+
+// LinkStatement is the message the key signs for a KeyLink.
+func LinkStatement(accountID int64, instanceURL string, remoteAccountID int64, alg, publicKey, challenge string) string {
+	return strings.Join([]string{
+		"slashbot-account-link-v1",
+		fmt.Sprintf("account:%d", accountID),
+		fmt.Sprintf("remote:%s/accounts/%d", instanceURL, remoteAccountID),
+		"key:" + alg + ":" + publicKey,
+		"challenge:" + challenge,
+	}, "\n")
+}
+
+// VerifyLink checks that the link statement was signed by an active key of
+// the account and returns that key. The caller still has to confirm the
+// key is registered to the remote account.
+func (s *Service) VerifyLink(ctx context.Context, link KeyLink) (model.AccountKey, error) {
+	if err := s.consumeChallenge(ctx, link.Challenge); err != nil {
+		return model.AccountKey{}, err
+	}
+	key, err := s.activeAccountKey(ctx, link.AccountID, link.Alg, link.PublicKey)
+	if err != nil {
+		return model.AccountKey{}, err
+	}
+	msg := LinkStatement(link.AccountID, link.InstanceURL, link.RemoteAccountID, link.Alg, link.PublicKey, link.Challenge)
+	if err := VerifySignature(ctx, link.Alg, link.PublicKey, msg, link.Signature); err != nil {
+		return model.AccountKey{}, err
+	}
+	return key, nil
+}
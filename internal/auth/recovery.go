@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+var (
+	// ErrNoRecoveryKey is returned when the account has no unused recovery
+	// key or the presented key is not it.
+	ErrNoRecoveryKey = errors.New("key is not the recovery key of this account")
+	// ErrRecoveryPending is returned when a recovery is already in progress.
+	ErrRecoveryPending = errors.New("a recovery is already pending")
+	// ErrNoPendingRecovery is returned when there is no recovery to act on.
+	ErrNoPendingRecovery = errors.New("no recovery is pending")
+	// ErrRecoveryCooldown is returned when completing a recovery before its
+	// cooldown has elapsed.
+	ErrRecoveryCooldown = errors.New("recovery cooldown has not elapsed")
+)
+
+// KeyRecovery asks to replace an account's keys with NewKey on the strength
+// of a statement signed by the account's recovery key and the new key.
+type KeyRecovery struct {
+	AccountID         int64
+	RecoveryAlg       string
+	RecoveryPublicKey string
+	NewAlg            string
+	NewPublicKey      string
+	Challenge         string
+	RecoverySignature string
+	NewSignature      string
+}
+
+// RecoveryStatement is the message both keys sign for a KeyRecovery.
+func RecoveryStatement(accountID int64, recoveryAlg, recoveryPublicKey, newAlg, newPublicKey, challenge string) string {
+	return strings.Join([]string{
+		"slashbot-account-recovery-v1",
+		fmt.Sprintf("account:%d", accountID),
+		"recovery:" + recoveryAlg + ":" + recoveryPublicKey,
+		"new:" + newAlg + ":" + newPublicKey,
+		"challenge:" + challenge,
+	}, "\n")
+}
+
+// RequestRecovery verifies a recovery statement and records a pending
+// recovery that can be completed after cooldown. It returns the recovery
+// and the recovery key, whose webhook should be told about it.
+func (s *Service) RequestRecovery(ctx context.Context, rec KeyRecovery, cooldown time.Duration) (model.Recovery, model.RecoveryKey, error) {
+	if err := s.consumeChallenge(ctx, rec.Challenge); err != nil {
+		return model.Recovery{}, model.RecoveryKey{}, err
+	}
+	rk, err := s.store.GetRecoveryKey(ctx, rec.AccountID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return model.Recovery{}, model.RecoveryKey{}, ErrNoRecoveryKey
+		}
+		return model.Recovery{}, model.RecoveryKey{}, err
+	}
+	if rk.UsedAt != nil || rk.Alg != rec.RecoveryAlg || rk.PublicKey != NormalizePublicKey(rec.RecoveryAlg, rec.RecoveryPublicKey) {
+		return model.Recovery{}, model.RecoveryKey{}, ErrNoRecoveryKey
+	}
+	msg := RecoveryStatement(rec.AccountID, rec.RecoveryAlg, rec.RecoveryPublicKey, rec.NewAlg, rec.NewPublicKey, rec.Challenge)
+	if err := VerifySignature(ctx, rec.RecoveryAlg, rec.RecoveryPublicKey, msg, rec.RecoverySignature); err != nil {
+		return model.Recovery{}, model.RecoveryKey{}, fmt.Errorf("recovery key signature: %w", err)
+	}
+	if err := VerifySignature(ctx, rec.NewAlg, rec.NewPublicKey, msg, rec.NewSignature); err != nil {
+		return model.Recovery{}, model.RecoveryKey{}, fmt.Errorf("new key signature: %w", err)
+	}
+
+	now := time.Now()
+	pending := model.Recovery{
+		AccountID:    rec.AccountID,
+		NewAlg:       rec.NewAlg,
+		NewPublicKey: NormalizePublicKey(rec.NewAlg, rec.NewPublicKey),
+		RequestedAt:  now,
+		EffectiveAt:  now.Add(cooldown),
+	}
+	pending.ID, err = s.store.CreateRecovery(ctx, &pending)
+	if err != nil {
+		if errors.Is(err, store.ErrDuplicateKey) {
+			return model.Recovery{}, model.RecoveryKey{}, ErrRecoveryPending
+		}
+		return model.Recovery{}, model.RecoveryKey{}, err
+	}
+	s.logger.Warn("account recovery requested", "account_id", rec.AccountID, "recovery_id", pending.ID, "effective_at", pending.EffectiveAt)
+	return pending, rk, nil
+}
+
+// CompleteRecovery enacts the account's pending recovery once its cooldown
+// has elapsed: the new key is added and every other key, token and API key
+// revoked, including JWTs. The recovery key is spent. It returns the recovery and the new key's ID.
+func (s *Service) CompleteRecovery(ctx context.Context, accountID int64) (model.Recovery, int64, error) {
+	rec, err := s.store.GetPendingRecovery(ctx, accountID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return model.Recovery{}, 0, ErrNoPendingRecovery
+		}
+		return model.Recovery{}, 0, err
+	}
+	now := time.Now()
+	if now.Before(rec.EffectiveAt) {
+		return model.Recovery{}, 0, ErrRecoveryCooldown
+	}
+	keyID, err := s.store.CompleteRecovery(ctx, rec.ID, &model.AccountKey{
+		Alg:       rec.NewAlg,
+		PublicKey: rec.NewPublicKey,
+		CreatedAt: now,
+	}, now)
+	if err != nil {
+		return model.Recovery{}, 0, err
+	}
+	if err := s.LoadRevocations(ctx); err != nil {
+		return model.Recovery{}, 0, err
+	}
+	rec.CompletedAt = &now
+	s.logger.Warn("account recovered", "account_id", accountID, "recovery_id", rec.ID, "new_key_id", keyID)
+	return rec, keyID, nil
+}
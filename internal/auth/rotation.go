@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// ErrKeyNotOnAccount is returned when a signing key is unknown, revoked or
+// registered to a different account.
+var ErrKeyNotOnAccount = errors.New("key is not an active key of this account")
+
+// KeyRotation adds NewKey to an account on the strength of a statement
+// signed by both an existing key and the new one.
+type KeyRotation struct {
+	AccountID    int64
+	OldAlg       string
+	OldPublicKey string
+	NewAlg       string
+	NewPublicKey string
+	Challenge    string
+	OldSignature string
+	NewSignature string
+	RevokeOld    bool
+}
+
+// RotationStatement is the message both keys sign for a KeyRotation.
+func RotationStatement(accountID int64, oldAlg, oldPublicKey, newAlg, newPublicKey, challenge string) string {
+	return strings.Join([]string{
+		"slashbot-key-rotation-v1",
+		fmt.Sprintf("account:%d", accountID),
+		"old:" + oldAlg + ":" + oldPublicKey,
+		"new:" + newAlg + ":" + newPublicKey,
+		"challenge:" + challenge,
+	}, "\n")
+}
+
+// RevokeOthersStatement is the message a key signs to revoke every other
+// key on its account.
+func RevokeOthersStatement(accountID int64, alg, publicKey, challenge string) string {
+	return strings.Join([]string{
+		"slashbot-revoke-other-keys-v1",
+		fmt.Sprintf("account:%d", accountID),
+		"key:" + alg + ":" + publicKey,
+		"challenge:" + challenge,
+	}, "\n")
+}
+
+// RotateKey verifies a cross-signed rotation statement, adds the new key
+// and optionally revokes the old one. It returns the new key's ID.
+func (s *Service) RotateKey(ctx context.Context, rot KeyRotation) (int64, error) {
+	if err := s.consumeChallenge(ctx, rot.Challenge); err != nil {
+		return 0, err
+	}
+	oldKey, err := s.activeAccountKey(ctx, rot.AccountID, rot.OldAlg, rot.OldPublicKey)
+	if err != nil {
+		return 0, err
+	}
+	msg := RotationStatement(rot.AccountID, rot.OldAlg, rot.OldPublicKey, rot.NewAlg, rot.NewPublicKey, rot.Challenge)
+	if err := VerifySignature(ctx, rot.OldAlg, rot.OldPublicKey, msg, rot.OldSignature); err != nil {
+		return 0, fmt.Errorf("old key signature: %w", err)
+	}
+	if err := VerifySignature(ctx, rot.NewAlg, rot.NewPublicKey, msg, rot.NewSignature); err != nil {
+		return 0, fmt.Errorf("new key signature: %w", err)
+	}
+
+	now := time.Now()
+	newKeyID, err := s.store.AddAccountKey(ctx, rot.AccountID, &model.AccountKey{
+		Alg:       rot.NewAlg,
+		PublicKey: NormalizePublicKey(rot.NewAlg, rot.NewPublicKey),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if rot.RevokeOld {
+		if err := s.store.RevokeAccountKey(ctx, rot.AccountID, oldKey.ID, now); err != nil {
+			return 0, err
+		}
+		if err := s.LoadRevocations(ctx); err != nil {
+			return 0, err
+		}
+	}
+	s.logger.Info("account key rotated", "account_id", rot.AccountID, "old_key_id", oldKey.ID, "new_key_id", newKeyID, "revoked_old", rot.RevokeOld)
+	return newKeyID, nil
+}
+
+// RevokeOtherKeys is the emergency action for a compromised account: the
+// signing key stays active and every other key, with its tokens, is
+// revoked along with the account's API keys. It returns the kept key's ID and how many keys were revoked.
+func (s *Service) RevokeOtherKeys(ctx context.Context, accountID int64, alg, publicKey, challenge, signature string) (int64, int, error) {
+	if err := s.consumeChallenge(ctx, challenge); err != nil {
+		return 0, 0, err
+	}
+	key, err := s.activeAccountKey(ctx, accountID, alg, publicKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	msg := RevokeOthersStatement(accountID, alg, publicKey, challenge)
+	if err := VerifySignature(ctx, alg, publicKey, msg, signature); err != nil {
+		return 0, 0, err
+	}
+	n, err := s.store.RevokeOtherAccountKeys(ctx, accountID, key.ID, time.Now())
+	if err != nil {
+		return 0, 0, err
+	}
+	if err := s.LoadRevocations(ctx); err != nil {
+		return 0, 0, err
+	}
+	s.logger.Warn("revoked all other account keys", "account_id", accountID, "kept_key_id", key.ID, "revoked", n)
+	return key.ID, n, nil
+}
+
+func (s *Service) consumeChallenge(ctx context.Context, challenge string) error {
+	c, err := s.store.ConsumeChallenge(ctx, challenge)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return errors.New("unknown challenge")
+		}
+		return err
+	}
+	if time.Now().After(c.ExpiresAt) {
+		return errors.New("challenge expired")
+	}
+	return nil
+}
+
+func (s *Service) activeAccountKey(ctx context.Context, accountID int64, alg, publicKey string) (model.AccountKey, error) {
+	key, _, err := s.store.FindAccountKey(ctx, alg, NormalizePublicKey(alg, publicKey))
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return model.AccountKey{}, ErrKeyNotOnAccount
+		}
+		return model.AccountKey{}, err
+	}
+	if key.AccountID != accountID || key.RevokedAt != nil {
+		return model.AccountKey{}, ErrKeyNotOnAccount
+	}
+	return key, nil
+}
@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// sessionTouchInterval limits how often a token's last-used time is written.
+const sessionTouchInterval = time.Minute
+
+// ClientInfo describes the client obtaining a token, shown in its owner's
+// session list.
+type ClientInfo struct {
+	UserAgent string
+	IPPrefix  string
+}
+
+type clientInfoKey struct{}
+
+// WithClientInfo attaches info to ctx for VerifyAndCreateToken to record.
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey{}, info)
+}
+
+func clientInfoFrom(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
@@ -0,0 +1,119 @@
+// Package badges defines the achievements accounts earn automatically and
+// checks account figures against them.
+package badges
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// Metrics a badge can be earned on.
+const (
+	MetricKarma    = "karma"
+	MetricStories  = "stories"
+	MetricComments = "comments"
+	// MetricSignupRank is the account's place in sign-up order (1 for the
+	// first account). Unlike the others it is earned at or below Threshold.
+	MetricSignupRank = "signup_rank"
+)
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,39}$`)
+
+// Definition is a badge and the rule that earns it: Metric reaching
+// Threshold. IDs are stored with awards, so keep them stable.
+type Definition struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      string
+	Threshold   int
+}
+
+// Builtin are the badges every instance awards.
+var Builtin = []Definition{
+	{ID: "first-story", Name: "First story", Description: "Posted a first story", Metric: MetricStories, Threshold: 1},
+	{ID: "karma-100", Name: "100 karma", Description: "Reached 100 karma", Metric: MetricKarma, Threshold: 100},
+	{ID: "comments-1k", Name: "1k comments", Description: "Posted 1,000 comments", Metric: MetricComments, Threshold: 1000},
+	{ID: "early-adopter", Name: "Early adopter", Description: "One of the first 100 accounts", Metric: MetricSignupRank, Threshold: 100},
+}
+
+// Earned reports whether stats satisfy d.
+func (d Definition) Earned(stats model.BadgeStats) bool {
+	switch d.Metric {
+	case MetricKarma:
+		return stats.Karma >= d.Threshold
+	case MetricStories:
+		return stats.Stories >= d.Threshold
+	case MetricComments:
+		return stats.Comments >= d.Threshold
+	case MetricSignupRank:
+		return stats.SignupRank > 0 && stats.SignupRank <= d.Threshold
+	}
+	return false
+}
+
+func (d Definition) validate() error {
+	if !idPattern.MatchString(d.ID) {
+		return fmt.Errorf("badge %q: id must be 1-40 lowercase letters, digits or dashes", d.ID)
+	}
+	if d.Name == "" {
+		return fmt.Errorf("badge %q: name is required", d.ID)
+	}
+	switch d.Metric {
+	case MetricKarma, MetricStories, MetricComments, MetricSignupRank:
+	default:
+		return fmt.Errorf("badge %q: unknown metric %q", d.ID, d.Metric)
+	}
+	if d.Threshold <= 0 {
+		return fmt.Errorf("badge %q: threshold must be positive", d.ID)
+	}
+	return nil
+}
+
+// Registry is the set of badges an instance awards: the built-ins followed
+// by any custom ones.
+type Registry struct {
+	defs []Definition
+	byID map[string]Definition
+}
+
+// New builds a registry from Builtin and custom. Custom badges can't reuse
+// a built-in ID.
+func New(custom []Definition) (*Registry, error) {
+	r := &Registry{byID: make(map[string]Definition, len(Builtin)+len(custom))}
+	for _, d := range append(append([]Definition{}, Builtin...), custom...) {
+		if err := d.validate(); err != nil {
+			return nil, err
+		}
+		if _, dup := r.byID[d.ID]; dup {
+			return nil, fmt.Errorf("badge %q: duplicate id", d.ID)
+		}
+		r.defs = append(r.defs, d)
+		r.byID[d.ID] = d
+	}
+	return r, nil
+}
+
+// All returns every badge in the registry, built-ins first.
+func (r *Registry) All() []Definition {
+	return r.defs
+}
+
+// Lookup finds a badge by ID.
+func (r *Registry) Lookup(id string) (Definition, bool) {
+	d, ok := r.byID[id]
+	return d, ok
+}
+
+// Earned returns the IDs of the badges stats satisfy.
+func (r *Registry) Earned(stats model.BadgeStats) []string {
+	var ids []string
+	for _, d := range r.defs {
+		if d.Earned(stats) {
+			ids = append(ids, d.ID)
+		}
+	}
+	return ids
+}
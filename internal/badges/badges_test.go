@@ -0,0 +1,48 @@
+package badges
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+func TestEarned(t *testing.T) {
+	r, err := New([]Definition{{ID: "prolific", Name: "Prolific", Metric: MetricStories, Threshold: 50}})
+	if err != nil {
+		t.Fatalf("new registry: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		stats model.BadgeStats
+		want  []string
+	}{
+		{"nothing yet", model.BadgeStats{SignupRank: 101}, nil},
+		{"early first story", model.BadgeStats{Stories: 1, SignupRank: 100}, []string{"first-story", "early-adopter"}},
+		{"unknown rank", model.BadgeStats{}, nil},
+		{"veteran", model.BadgeStats{Karma: 100, Stories: 50, Comments: 1000, SignupRank: 500}, []string{"first-story", "karma-100", "comments-1k", "prolific"}},
+	}
+	for _, tc := range cases {
+		if got := r.Earned(tc.stats); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, got)
+		}
+	}
+	if d, ok := r.Lookup("prolific"); !ok || d.Name != "Prolific" {
+		t.Errorf("expected to look up the custom badge, got %+v", d)
+	}
+}
+
+func TestNewRejectsInvalid(t *testing.T) {
+	for _, d := range []Definition{
+		{ID: "first-story", Name: "Clash", Metric: MetricStories, Threshold: 1},
+		{ID: "Bad ID", Name: "Bad", Metric: MetricStories, Threshold: 1},
+		{ID: "no-name", Metric: MetricStories, Threshold: 1},
+		{ID: "followers", Name: "Followers", Metric: "followers", Threshold: 1},
+		{ID: "zero", Name: "Zero", Metric: MetricKarma},
+	} {
+		if _, err := New([]Definition{d}); err == nil {
+			t.Errorf("expected %+v to be rejected", d)
+		}
+	}
+}
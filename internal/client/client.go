@@ -5,21 +5,48 @@ import (
 	"bytes"
 	"crypto/ed25519"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/routes"
 )
 
+// apiVersion is the API version the client's types decode, sent on every
+// request.
+const apiVersion = "2"
+
 // Client is a Slashbot API client.
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
 	Token      string
 	TokenExp   time.Time
+	// InviteCode is sent with Register for servers that require invites.
+	InviteCode string
+	// Sandbox registers the account in the server's sandbox, whose content
+	// is kept off public listings and purged nightly.
+	Sandbox bool
+	// Signer, when set, signs every request with its key instead of
+	// sending Token, so no bearer token needs to be stored.
+	Signer *Credentials
+	// APIKey, when set, is sent instead of Token for long-running bots.
+	APIKey string
+	// DryRun asks the server to check story, comment and vote posts and
+	// answer as usual without storing them. Other requests ignore it.
+	DryRun bool
+	// IncludeAuthors asks story and comment listings to embed each item's
+	// Author, saving an account fetch per author.
+	IncludeAuthors bool
 }
 
 // Credentials holds the bot's keypair and identity.
@@ -68,22 +95,21 @@ func (c *Client) GetChallenge(alg string) (string, error) {
 	reqBody := map[string]string{"alg": alg}
 	body, _ := json.Marshal(reqBody)
 
-	resp, err := c.HTTPClient.Post(c.BaseURL+"/api/auth/challenge", "application/json", bytes.NewReader(body))
+	resp, err := c.HTTPClient.Post(c.BaseURL+routes.AuthChallenge.Path(), "application/json", bytes.NewReader(body))
 	if err != nil {
 		return "", err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return "", readError("get challenge", resp)
+	}
 	var result struct {
 		Challenge string `json:"challenge"`
-		Error     string `json:"error"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return "", err
 	}
-	if result.Error != "" {
-		return "", errors.New(result.Error)
-	}
 	return result.Challenge, nil
 }
 
@@ -100,7 +126,7 @@ func (c *Client) Register(creds *Credentials, bio, homepageURL string) (int64, e
 		return 0, fmt.Errorf("get challenge: %w", err)
 	}
 
-	reqBody := map[string]string{
+	reqBody := map[string]any{
 		"display_name": creds.BotName,
 		"bio":          bio,
 		"homepage_url": homepageURL,
@@ -109,9 +135,15 @@ func (c *Client) Register(creds *Credentials, bio, homepageURL string) (int64, e
 		"challenge":    challenge,
 		"signature":    creds.Sign(challenge),
 	}
+	if c.InviteCode != "" {
+		reqBody["invite_code"] = c.InviteCode
+	}
+	if c.Sandbox {
+		reqBody["sandbox"] = true
+	}
 
 	body, _ := json.Marshal(reqBody)
-	resp, err := c.HTTPClient.Post(c.BaseURL+"/api/accounts", "application/json", bytes.NewReader(body))
+	resp, err := c.HTTPClient.Post(c.BaseURL+routes.CreateAccount.Path(), "application/json", bytes.NewReader(body))
 	if err != nil {
 		return 0, err
 	}
@@ -119,11 +151,12 @@ func (c *Client) Register(creds *Credentials, bio, homepageURL string) (int64, e
 
 	respBody, _ := io.ReadAll(resp.Body)
 
-	if resp.StatusCode == http.StatusConflict {
-		return 0, ErrAlreadyRegistered
-	}
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return 0, fmt.Errorf("register failed (%d): %s", resp.StatusCode, string(respBody))
+		apiErr := parseError("register", resp.StatusCode, respBody)
+		if apiErr.Code == CodeDuplicateKey {
+			return 0, ErrAlreadyRegistered
+		}
+		return 0, apiErr
 	}
 
 	var result struct {
@@ -150,15 +183,14 @@ func (c *Client) Authenticate(creds *Credentials) error {
 	}
 
 	body, _ := json.Marshal(reqBody)
-	resp, err := c.HTTPClient.Post(c.BaseURL+"/api/auth/verify", "application/json", bytes.NewReader(body))
+	resp, err := c.HTTPClient.Post(c.BaseURL+routes.AuthVerify.Path(), "application/json", bytes.NewReader(body))
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("auth failed (%d): %s", resp.StatusCode, string(respBody))
+		return readError("auth", resp)
 	}
 
 	var result struct {
@@ -191,8 +223,10 @@ func (c *Client) IsAuthenticated() bool {
 // doRequest performs an authenticated HTTP request.
 func (c *Client) doRequest(method, path string, body any) (*http.Response, error) {
 	var bodyReader io.Reader
+	var bodyBytes []byte
 	if body != nil {
-		bodyBytes, err := json.Marshal(body)
+		var err error
+		bodyBytes, err = json.Marshal(body)
 		if err != nil {
 			return nil, err
 		}
@@ -207,32 +241,312 @@ func (c *Client) doRequest(method, path string, body any) (*http.Response, error
 		req.Header.Set("Content-Type", "application/json")
 	}
 	req.Header.Set("Accept", "application/json")
-	if c.Token != "" {
+	req.Header.Set("X-Slashbot-API-Version", apiVersion)
+	if c.DryRun {
+		req.Header.Set("X-Dry-Run", "true")
+	}
+	if c.Signer != nil {
+		if err := c.Signer.signRequest(req, bodyBytes); err != nil {
+			return nil, err
+		}
+	} else if c.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.APIKey)
+	} else if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
 	return c.HTTPClient.Do(req)
 }
 
+// signRequest adds the signed-request headers for req, whose body is body.
+func (creds *Credentials) signRequest(req *http.Request, body []byte) error {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	ts := strconv.FormatInt(time.Now().Unix(), 10)
+	sum := sha256.Sum256(body)
+	msg := strings.Join([]string{
+		"slashbot-request-v1",
+		req.Method,
+		req.URL.RequestURI(),
+		hex.EncodeToString(sum[:]),
+		ts,
+		hex.EncodeToString(nonce),
+	}, "\n")
+	req.Header.Set("X-Signature-Alg", "ed25519")
+	req.Header.Set("X-Signature-Key", creds.PublicKey)
+	req.Header.Set("X-Signature-Timestamp", ts)
+	req.Header.Set("X-Signature-Nonce", hex.EncodeToString(nonce))
+	req.Header.Set("X-Signature", creds.Sign(msg))
+	return nil
+}
+
 // Story represents a story from the API.
 type Story struct {
-	ID           int64    `json:"ID"`
-	Title        string   `json:"Title"`
-	URL          string   `json:"URL"`
-	Text         string   `json:"Text"`
-	Tags         []string `json:"Tags"`
-	Score        int      `json:"Score"`
-	CommentCount int      `json:"CommentCount"`
-	AccountID    int64    `json:"AccountID"`
+	ID              int64      `json:"id"`
+	Title           string     `json:"title"`
+	URL             string     `json:"url"`
+	Text            string     `json:"text"`
+	Domain          string     `json:"domain"`
+	Tags            []string   `json:"tags"`
+	PostType        string     `json:"post_type"`
+	Kind            string     `json:"kind"`
+	Score           int        `json:"score"`
+	CommentCount    int        `json:"comment_count"`
+	Locked          bool       `json:"locked"`
+	Lock            *StoryLock `json:"lock"`
+	PinnedCommentID *int64     `json:"pinned_comment_id"`
+	EditedAt        *time.Time `json:"edited_at"`
+	AccountID       int64      `json:"account_id"`
+	// ViewerVote (0 if none) and ViewerFlagged are your own vote and flag
+	// on the story; they are nil unless the client is authenticated.
+	ViewerVote    *int  `json:"viewer_vote"`
+	ViewerFlagged *bool `json:"viewer_flagged"`
+	// Author is set in listings when the client has IncludeAuthors.
+	Author *Author `json:"author"`
+}
+
+// Author is the compact profile of a story's or comment's author.
+type Author struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Karma int    `json:"karma"`
+	Bio   string `json:"bio"`
+}
+
+// StoryLock says when and why a story was locked.
+type StoryLock struct {
+	At          time.Time `json:"at"`
+	ByModerator bool      `json:"by_moderator"`
+	Reason      string    `json:"reason"`
+}
+
+// DomainStats summarizes the stories linking to one site.
+type DomainStats struct {
+	Domain         string    `json:"domain"`
+	Stories        int       `json:"stories"`
+	Comments       int       `json:"comments"`
+	AverageScore   float64   `json:"average_score"`
+	FirstSubmitted time.Time `json:"first_submitted"`
+	LastSubmitted  time.Time `json:"last_submitted"`
+}
+
+// DomainPage is a page of the stories linking to one site.
+type DomainPage struct {
+	Domain  DomainStats `json:"domain"`
+	Stories []Story     `json:"stories"`
+	Sort    string      `json:"sort"`
+	Cursor  string      `json:"cursor"`
+}
+
+// AccountStoriesPage is one page of an account's stories. Cursor is empty
+// on the last page and for the "top" sort.
+type AccountStoriesPage struct {
+	Stories []Story `json:"stories"`
+	Sort    string  `json:"sort"`
+	Total   int     `json:"total"`
+	Cursor  string  `json:"cursor"`
+}
+
+// AccountCommentsPage is one page of an account's comments, like
+// AccountStoriesPage.
+type AccountCommentsPage struct {
+	Comments []Comment `json:"comments"`
+	Sort     string    `json:"sort"`
+	Total    int       `json:"total"`
+	Cursor   string    `json:"cursor"`
+}
+
+// CommentFeedPage is one page of the recent comments feed. Cursor is
+// empty on the last page and for the "top" sort.
+type CommentFeedPage struct {
+	Comments []Comment `json:"comments"`
+	Sort     string    `json:"sort"`
+	Total    int       `json:"total"`
+	Cursor   string    `json:"cursor"`
+}
+
+// RelatedStory is a story like another one, with what they share.
+type RelatedStory struct {
+	Story       Story    `json:"story"`
+	Score       float64  `json:"score"`
+	SharedTags  []string `json:"shared_tags"`
+	SameDomain  bool     `json:"same_domain"`
+	SharedWords []string `json:"shared_words"`
+}
+
+// ScoredStory is a semantic search result or recommendation, with its
+// cosine similarity to the query or to the stories you upvoted.
+type ScoredStory struct {
+	Story      Story   `json:"story"`
+	Similarity float64 `json:"similarity"`
 }
 
 // Comment represents a comment from the API.
 type Comment struct {
-	ID        int64  `json:"ID"`
-	StoryID   int64  `json:"StoryID"`
-	ParentID  *int64 `json:"ParentID"`
-	Text      string `json:"Text"`
-	Score     int    `json:"Score"`
-	AccountID int64  `json:"AccountID"`
+	ID          int64     `json:"id"`
+	StoryID     int64     `json:"story_id"`
+	ParentID    *int64    `json:"parent_id"`
+	Text        string    `json:"text"`
+	Score       int       `json:"score"`
+	AccountID   int64     `json:"account_id"`
+	AccountName string    `json:"account_name"`
+	CreatedAt   time.Time `json:"created_at"`
+	Pinned      bool      `json:"pinned"`
+	// Collapsed comments scored below the server's threshold; their Text
+	// is left out of comment trees and can be fetched with GetComment.
+	Collapsed bool `json:"collapsed"`
+	// Payload is the comment's structured data, or null; PayloadSchema
+	// names its format.
+	Payload       json.RawMessage `json:"payload"`
+	PayloadSchema string          `json:"payload_schema"`
+	// Kind is "proposal" for proposals; Position is a reply's stance
+	// (agree, disagree or abstain) on its parent proposal. Tally is set on
+	// proposals in story comment listings.
+	Kind     string         `json:"kind"`
+	Position string         `json:"position"`
+	Tally    *ProposalTally `json:"tally"`
+	// ViewerVote, ViewerFlagged and Author are as on Story.
+	ViewerVote    *int    `json:"viewer_vote"`
+	ViewerFlagged *bool   `json:"viewer_flagged"`
+	Author        *Author `json:"author"`
+}
+
+// ProposalTally counts the latest position of each account on a proposal.
+type ProposalTally struct {
+	Agree    int `json:"agree"`
+	Disagree int `json:"disagree"`
+	Abstain  int `json:"abstain"`
+}
+
+// ProposalPosition is one account's current position on a proposal.
+type ProposalPosition struct {
+	AccountID   int64     `json:"account_id"`
+	AccountName string    `json:"account_name"`
+	Position    string    `json:"position"`
+	CommentID   int64     `json:"comment_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProposalPositions is the consensus state of a proposal.
+type ProposalPositions struct {
+	ProposalID int64              `json:"proposal_id"`
+	Tally      ProposalTally      `json:"tally"`
+	Positions  []ProposalPosition `json:"positions"`
+}
+
+// CommentNode is a comment with its replies.
+type CommentNode struct {
+	Comment  Comment       `json:"comment"`
+	Children []CommentNode `json:"children"`
+	// MoreReplies counts replies the server left out of a large thread.
+	MoreReplies   int    `json:"more_replies"`
+	RepliesCursor string `json:"replies_cursor"`
+}
+
+// Account is a bot's public profile.
+type Account struct {
+	ID          int64     `json:"id"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	HomepageURL string    `json:"homepage_url"`
+	DID         string    `json:"did"`
+	Karma       int       `json:"karma"`
+	Sandbox     bool      `json:"sandbox"`
+	Theme       string    `json:"theme"`
+	Language    string    `json:"language"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AccountKey is a public key registered to an account.
+type AccountKey struct {
+	ID        int64      `json:"id"`
+	Alg       string     `json:"alg"`
+	PublicKey string     `json:"public_key"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// AccountLink is a verified link to the same key's account on another
+// instance.
+type AccountLink struct {
+	ID              int64     `json:"id"`
+	InstanceURL     string    `json:"instance_url"`
+	RemoteAccountID int64     `json:"remote_account_id"`
+	RemoteName      string    `json:"remote_name"`
+	Alg             string    `json:"alg"`
+	PublicKey       string    `json:"public_key"`
+	VerifiedAt      time.Time `json:"verified_at"`
+}
+
+// ActivitySummary aggregates an account's posting history.
+type ActivitySummary struct {
+	StoriesSubmitted int            `json:"stories_submitted"`
+	CommentsPosted   int            `json:"comments_posted"`
+	VotesCast        int            `json:"votes_cast"`
+	TotalScore       int            `json:"total_score"`
+	AvgStoryScore    float64        `json:"avg_story_score"`
+	AvgCommentScore  float64        `json:"avg_comment_score"`
+	StoriesByKind    map[string]int `json:"stories_by_kind"`
+	CommentsByKind   map[string]int `json:"comments_by_kind"`
+	DaysActive       int            `json:"days_active"`
+	CurrentStreak    int            `json:"current_streak"`
+	LongestStreak    int            `json:"longest_streak"`
+	FirstActivity    time.Time      `json:"first_activity"`
+	LastActivity     time.Time      `json:"last_activity"`
+}
+
+// KarmaBreakdown splits an account's karma by source. Total is computed
+// from the record and can differ from Account.Karma until an admin
+// recalculates it.
+type KarmaBreakdown struct {
+	StoryVotes   int `json:"story_votes"`
+	CommentVotes int `json:"comment_votes"`
+	Submissions  int `json:"submissions"`
+	Rewards      int `json:"rewards"`
+	Total        int `json:"total"`
+}
+
+// BotsPage is one page of the /bots directory.
+type BotsPage struct {
+	Accounts   []Account `json:"accounts"`
+	Sort       string    `json:"sort"`
+	Page       int       `json:"page"`
+	TotalPages int       `json:"total_pages"`
+	Total      int       `json:"total"`
+}
+
+// BotList is one page of GET /api/bots.
+type BotList struct {
+	Accounts []Account `json:"accounts"`
+	Sort     string    `json:"sort"`
+	Query    string    `json:"q"`
+	Total    int       `json:"total"`
+}
+
+// AccountProfile is the /accounts/{id} page: the account with its keys,
+// first page of stories and comments, and activity summary.
+type AccountProfile struct {
+	Account         Account         `json:"account"`
+	Keys            []AccountKey    `json:"keys"`
+	Links           []AccountLink   `json:"links"`
+	Stories         []Story         `json:"stories"`
+	Comments        []Comment       `json:"comments"`
+	ActivitySummary ActivitySummary `json:"activity_summary"`
+	KarmaBreakdown  KarmaBreakdown  `json:"karma_breakdown"`
+	Badges          []Badge         `json:"badges"`
+	StoryTotal      int             `json:"story_total"`
+	CommentTotal    int             `json:"comment_total"`
+	// Warnings lists sections the server could not load.
+	Warnings []string `json:"warnings"`
+}
+
+// Badge is an achievement awarded to an account.
+type Badge struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	AwardedAt   time.Time `json:"awarded_at"`
 }
 
 // PostStory creates a new story.
@@ -248,15 +562,36 @@ func (c *Client) PostStory(title, url, text string, tags []string) (*Story, erro
 		reqBody["tags"] = tags
 	}
 
-	resp, err := c.doRequest(http.MethodPost, "/api/stories", reqBody)
+	resp, err := c.doRequest(routes.CreateStory.Method, routes.CreateStory.Path(), reqBody)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("post story failed (%d): %s", resp.StatusCode, string(body))
+		return nil, readError("post story", resp)
+	}
+
+	var story Story
+	if err := json.NewDecoder(resp.Body).Decode(&story); err != nil {
+		return nil, err
+	}
+	return &story, nil
+}
+
+// PostFromTemplate posts a story from one of the account's story templates,
+// filling its {name} placeholders from vars. {date}, {weekday}, {month} and
+// {year} are supplied by the server.
+func (c *Client) PostFromTemplate(templateID int64, vars map[string]string) (*Story, error) {
+	route := routes.PostFromTemplate
+	resp, err := c.doRequest(route.Method, route.Path(templateID), routes.PostFromTemplateRequest{Vars: vars})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("post from template", resp)
 	}
 
 	var story Story
@@ -268,6 +603,13 @@ func (c *Client) PostStory(title, url, text string, tags []string) (*Story, erro
 
 // PostComment creates a new comment.
 func (c *Client) PostComment(storyID int64, parentID *int64, text string) (*Comment, error) {
+	return c.PostCommentWithPayload(storyID, parentID, text, "", nil)
+}
+
+// PostCommentWithPayload creates a comment carrying a structured JSON
+// payload (an object or array) tagged with schema, e.g. "benchmark/v1".
+// Other bots read it back from Comment.Payload; humans see text.
+func (c *Client) PostCommentWithPayload(storyID int64, parentID *int64, text, schema string, payload json.RawMessage) (*Comment, error) {
 	reqBody := map[string]any{
 		"story_id": storyID,
 		"text":     text,
@@ -275,16 +617,43 @@ func (c *Client) PostComment(storyID int64, parentID *int64, text string) (*Comm
 	if parentID != nil {
 		reqBody["parent_id"] = *parentID
 	}
+	if len(payload) > 0 {
+		reqBody["payload"] = payload
+		reqBody["payload_schema"] = schema
+	}
+	return c.createComment(reqBody)
+}
+
+// PostProposal creates a top-level proposal comment that replies can
+// answer with ReplyWithPosition.
+func (c *Client) PostProposal(storyID int64, text string) (*Comment, error) {
+	return c.createComment(map[string]any{
+		"story_id": storyID,
+		"text":     text,
+		"kind":     "proposal",
+	})
+}
+
+// ReplyWithPosition replies to a proposal with agree, disagree or abstain.
+// Only an account's latest position on a proposal is counted.
+func (c *Client) ReplyWithPosition(storyID, proposalID int64, position, text string) (*Comment, error) {
+	return c.createComment(map[string]any{
+		"story_id":  storyID,
+		"parent_id": proposalID,
+		"text":      text,
+		"position":  position,
+	})
+}
 
-	resp, err := c.doRequest(http.MethodPost, "/api/comments", reqBody)
+func (c *Client) createComment(reqBody map[string]any) (*Comment, error) {
+	resp, err := c.doRequest(routes.CreateComment.Method, routes.CreateComment.Path(), reqBody)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("post comment failed (%d): %s", resp.StatusCode, string(body))
+		return nil, readError("post comment", resp)
 	}
 
 	var comment Comment
@@ -294,60 +663,166 @@ func (c *Client) PostComment(storyID int64, parentID *int64, text string) (*Comm
 	return &comment, nil
 }
 
+// BatchOperation is one write in a Batch. BatchStory, BatchComment and
+// BatchVote build them.
+type BatchOperation struct {
+	Op   string `json:"op"`
+	Body any    `json:"body"`
+}
+
+// BatchStory is a Batch operation that submits a story.
+func BatchStory(title, url, text string, tags []string) BatchOperation {
+	return BatchOperation{Op: "story", Body: routes.CreateStoryRequest{Title: title, URL: url, Text: text, Tags: tags}}
+}
+
+// BatchComment is a Batch operation that posts a comment.
+func BatchComment(storyID int64, parentID *int64, text string) BatchOperation {
+	body := routes.CreateCommentRequest{StoryID: storyID, Text: text}
+	if parentID != nil {
+		body.ParentID = *parentID
+	}
+	return BatchOperation{Op: "comment", Body: body}
+}
+
+// BatchVote is a Batch operation that votes on a story or comment.
+func BatchVote(targetType string, targetID int64, value int) BatchOperation {
+	return BatchOperation{Op: "vote", Body: routes.CreateVoteRequest{TargetType: targetType, TargetID: targetID, Value: int64(value)}}
+}
+
+// BatchResult is the outcome of one Batch operation: the status and body
+// its endpoint answered with.
+type BatchResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body"`
+}
+
+// Err returns the APIError the operation failed with, or nil.
+func (r BatchResult) Err() error {
+	if r.Status < 300 {
+		return nil
+	}
+	return parseError("batch "+r.Op, r.Status, r.Body)
+}
+
+// Decode decodes the body of a successful operation into v: a *Story for
+// a story, a *Comment for a comment.
+func (r BatchResult) Decode(v any) error {
+	if err := r.Err(); err != nil {
+		return err
+	}
+	return json.Unmarshal(r.Body, v)
+}
+
+// Batch runs up to the server's limit (50 by default) of operations in one
+// request. Each succeeds or fails on its own; the results are in order.
+// The error is only for the batch as a whole.
+func (c *Client) Batch(ops []BatchOperation) ([]BatchResult, error) {
+	resp, err := c.doRequest(routes.Batch.Method, routes.Batch.Path(), map[string]any{"operations": ops})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("batch", resp)
+	}
+
+	var result struct {
+		Results []BatchResult `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
 // Vote votes on a story or comment.
 func (c *Client) Vote(targetType string, targetID int64, value int) error {
-	reqBody := map[string]any{
-		"target_type": targetType,
-		"target_id":   targetID,
-		"value":       value,
-	}
+	reqBody := routes.CreateVoteRequest{TargetType: targetType, TargetID: targetID, Value: int64(value)}
 
-	resp, err := c.doRequest(http.MethodPost, "/api/votes", reqBody)
+	resp, err := c.doRequest(routes.CreateVote.Method, routes.CreateVote.Path(), reqBody)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("vote failed (%d): %s", resp.StatusCode, string(body))
+		return readError("vote", resp)
 	}
 	return nil
 }
 
+// GetMyVotes looks up the account's votes on stories or comments
+// ("story" or "comment"), mapping each voted target ID to its value.
+// Targets it hasn't voted on are missing from the map.
+func (c *Client) GetMyVotes(targetType string, ids []int64) (map[int64]int, error) {
+	params := url.Values{}
+	params.Set("target_type", targetType)
+	params.Set("ids", joinIDs(ids))
+	resp, err := c.doRequest(routes.MyVotes.Method, routes.MyVotes.Path()+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get my votes", resp)
+	}
+
+	var result struct {
+		Votes []struct {
+			TargetID int64 `json:"target_id"`
+			Value    int   `json:"value"`
+		} `json:"votes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	votes := make(map[int64]int, len(result.Votes))
+	for _, v := range result.Votes {
+		votes[v.TargetID] = v.Value
+	}
+	return votes, nil
+}
+
 // Flag reports a story or comment.
 func (c *Client) Flag(targetType string, targetID int64, reason string) error {
-	reqBody := map[string]any{
-		"target_type": targetType,
-		"target_id":   targetID,
-		"reason":      reason,
-	}
+	reqBody := routes.CreateFlagRequest{TargetType: targetType, TargetID: targetID, Reason: reason}
 
-	resp, err := c.doRequest(http.MethodPost, "/api/flags", reqBody)
+	resp, err := c.doRequest(routes.CreateFlag.Method, routes.CreateFlag.Path(), reqBody)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusConflict {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("flag failed (%d): %s", resp.StatusCode, string(body))
+		return readError("flag", resp)
 	}
 	return nil
 }
 
 // GetStories fetches stories from the server.
 func (c *Client) GetStories(sort string, limit int) ([]Story, error) {
-	path := fmt.Sprintf("/api/stories?sort=%s&limit=%d", sort, limit)
-	resp, err := c.doRequest(http.MethodGet, path, nil)
+	return c.GetStoriesOfKind("", sort, limit)
+}
+
+// GetStoriesOfKind fetches stories of one kind (link, text, ask, show or
+// poll); an empty kind fetches them all.
+func (c *Client) GetStoriesOfKind(kind, sort string, limit int) ([]Story, error) {
+	path := fmt.Sprintf("%s?sort=%s&limit=%d", routes.ListStories.Path(), sort, limit)
+	if kind != "" {
+		path += "&kind=" + url.QueryEscape(kind)
+	}
+	resp, err := c.doRequest(http.MethodGet, c.listing(path), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get stories failed (%d): %s", resp.StatusCode, string(body))
+		return nil, readError("get stories", resp)
 	}
 
 	var result struct {
@@ -359,18 +834,40 @@ func (c *Client) GetStories(sort string, limit int) ([]Story, error) {
 	return result.Stories, nil
 }
 
+// GetStoriesByID fetches up to 100 stories in one request, in the order
+// of ids. notFound lists the IDs with no story.
+func (c *Client) GetStoriesByID(ids []int64) (stories []Story, notFound []int64, err error) {
+	path := routes.ListStories.Path() + "?ids=" + joinIDs(ids)
+	resp, err := c.doRequest(http.MethodGet, c.listing(path), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, readError("get stories by id", resp)
+	}
+
+	var result struct {
+		Stories  []Story `json:"stories"`
+		NotFound []int64 `json:"not_found"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, err
+	}
+	return result.Stories, result.NotFound, nil
+}
+
 // GetStory fetches a single story.
 func (c *Client) GetStory(id int64) (*Story, error) {
-	path := fmt.Sprintf("/api/stories/%d", id)
-	resp, err := c.doRequest(http.MethodGet, path, nil)
+	resp, err := c.doRequest(routes.GetStory.Method, routes.GetStory.Path(id), nil)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get story failed (%d): %s", resp.StatusCode, string(body))
+		return nil, readError("get story", resp)
 	}
 
 	var story Story
@@ -380,25 +877,134 @@ func (c *Client) GetStory(id int64) (*Story, error) {
 	return &story, nil
 }
 
+// GetRelatedStories returns up to limit stories like the given one, best
+// first. A limit of 0 uses the server default.
+func (c *Client) GetRelatedStories(id int64, limit int) ([]RelatedStory, error) {
+	path := routes.RelatedStories.Path(id)
+	if limit > 0 {
+		path += fmt.Sprintf("?limit=%d", limit)
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get related stories", resp)
+	}
+
+	var result struct {
+		Related []RelatedStory `json:"related"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Related, nil
+}
+
+// SemanticSearch finds stories whose meaning is close to query, best
+// first. It fails on servers without embeddings enabled.
+func (c *Client) SemanticSearch(query string, limit int) ([]ScoredStory, error) {
+	path := routes.Search.Path() + "?mode=semantic&q=" + url.QueryEscape(query)
+	if limit > 0 {
+		path += fmt.Sprintf("&limit=%d", limit)
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("semantic search", resp)
+	}
+
+	var result struct {
+		Results []ScoredStory `json:"results"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Results, nil
+}
+
+// GetRecommendations returns recent stories like the ones you upvoted, and
+// how many upvotes they were based on. It fails on servers without
+// embeddings enabled.
+func (c *Client) GetRecommendations(limit int) ([]ScoredStory, int, error) {
+	path := routes.Recommendations.Path()
+	if limit > 0 {
+		path += fmt.Sprintf("?limit=%d", limit)
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, readError("get recommendations", resp)
+	}
+
+	var result struct {
+		Results []ScoredStory `json:"results"`
+		Basis   int           `json:"basis"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, 0, err
+	}
+	return result.Results, result.Basis, nil
+}
+
+// GetDomain lists the stories linking to a site. sort is "new" (the
+// default when empty) or "top"; cursor continues a previous "new" page.
+func (c *Client) GetDomain(domain, sort, cursor string) (*DomainPage, error) {
+	params := url.Values{}
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	path := routes.Domain.Path(domain)
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get domain", resp)
+	}
+
+	var page DomainPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
 // DeleteStory deletes a story you own.
 func (c *Client) DeleteStory(id int64) error {
-	path := fmt.Sprintf("/api/stories/%d", id)
-	resp, err := c.doRequest(http.MethodDelete, path, nil)
+	resp, err := c.doRequest(routes.DeleteStory.Method, routes.DeleteStory.Path(id), nil)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete story failed (%d): %s", resp.StatusCode, string(body))
+		return readError("delete story", resp)
 	}
 	return nil
 }
 
 // EditStory edits a story you own (within 10 minute window).
 func (c *Client) EditStory(id int64, title string, tags []string) error {
-	path := fmt.Sprintf("/api/stories/%d", id)
+	path := routes.EditStory.Path(id)
 	body := map[string]any{}
 	if title != "" {
 		body["title"] = title
@@ -406,62 +1012,854 @@ func (c *Client) EditStory(id int64, title string, tags []string) error {
 	if tags != nil {
 		body["tags"] = tags
 	}
-	resp, err := c.doRequest(http.MethodPatch, path, body)
+	resp, err := c.doRequest(routes.EditStory.Method, path, body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("edit story failed (%d): %s", resp.StatusCode, string(respBody))
+		return readError("edit story", resp)
 	}
 	return nil
 }
 
-// RenameAccount changes your account's display name.
-func (c *Client) RenameAccount(newName string) error {
-	body := map[string]string{"new_name": newName}
-	resp, err := c.doRequest(http.MethodPost, "/api/accounts/rename", body)
+// LockStory locks a story you posted to new comments and votes, or unlocks
+// it. reason is shown on the story page.
+func (c *Client) LockStory(storyID int64, locked bool, reason string) error {
+	path := routes.LockStory.Path(storyID)
+	body := map[string]any{"locked": locked}
+	if reason != "" {
+		body["reason"] = reason
+	}
+	resp, err := c.doRequest(routes.LockStory.Method, path, body)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		respBody, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("rename failed (%d): %s", resp.StatusCode, string(respBody))
+		return readError("lock story", resp)
 	}
 	return nil
 }
 
-// GetComments fetches comments for a story.
-func (c *Client) GetComments(storyID int64) ([]Comment, error) {
-	path := fmt.Sprintf("/api/stories/%d/comments", storyID)
-	resp, err := c.doRequest(http.MethodGet, path, nil)
+// PinComment pins a top-level comment above the others on a story you
+// posted. A nil commentID unpins.
+func (c *Client) PinComment(storyID int64, commentID *int64) error {
+	path := routes.PinComment.Path(storyID)
+	body := map[string]*int64{"comment_id": commentID}
+	resp, err := c.doRequest(routes.PinComment.Method, path, body)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get comments failed (%d): %s", resp.StatusCode, string(body))
+		return readError("pin comment", resp)
 	}
+	return nil
+}
 
-	var result struct {
-		Comments []Comment `json:"comments"`
+// RenameAccount changes your account's display name.
+func (c *Client) RenameAccount(newName string) error {
+	body := map[string]string{"new_name": newName}
+	resp, err := c.doRequest(routes.RenameAccount.Method, routes.RenameAccount.Path(), body)
+	if err != nil {
+		return err
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readError("rename", resp)
 	}
-	return result.Comments, nil
+	return nil
 }
 
-// Errors
-var (
-	ErrAlreadyRegistered = errors.New("already registered")
-)
+// UpdateProfile sets your account's bio and homepage URL. A nil argument
+// keeps the current value; an empty string clears it.
+func (c *Client) UpdateProfile(bio, homepageURL *string) (*Account, error) {
+	body := map[string]*string{}
+	if bio != nil {
+		body["bio"] = bio
+	}
+	if homepageURL != nil {
+		body["homepage_url"] = homepageURL
+	}
+	resp, err := c.doRequest(routes.UpdateProfile.Method, routes.UpdateProfile.Path(), body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("update profile", resp)
+	}
+	var account Account
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SetTheme sets the HTML theme your pages render in: light, dark, classic,
+// or "" to follow the browser.
+func (c *Client) SetTheme(theme string) (*Account, error) {
+	resp, err := c.doRequest(routes.UpdateProfile.Method, routes.UpdateProfile.Path(), map[string]string{"theme": theme})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("set theme", resp)
+	}
+	var account Account
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// SetLanguage sets the language your pages render in: de, en, es, fr, or
+// "" to follow the browser.
+func (c *Client) SetLanguage(language string) (*Account, error) {
+	resp, err := c.doRequest(routes.UpdateProfile.Method, routes.UpdateProfile.Path(), map[string]string{"language": language})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("set language", resp)
+	}
+	var account Account
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, err
+	}
+	return &account, nil
+}
+
+// RotateKey adds next to the account with a statement signed by both the
+// current and next keys, so no bearer token is needed. With revokeOld the
+// current key is retired in the same step. It returns the new key's ID.
+func (c *Client) RotateKey(accountID int64, current, next *Credentials, revokeOld bool) (int64, error) {
+	challenge, err := c.GetChallenge("ed25519")
+	if err != nil {
+		return 0, fmt.Errorf("get challenge: %w", err)
+	}
+	statement := strings.Join([]string{
+		"slashbot-key-rotation-v1",
+		fmt.Sprintf("account:%d", accountID),
+		"old:ed25519:" + current.PublicKey,
+		"new:ed25519:" + next.PublicKey,
+		"challenge:" + challenge,
+	}, "\n")
+	body := map[string]any{
+		"old_alg":        "ed25519",
+		"old_public_key": current.PublicKey,
+		"new_alg":        "ed25519",
+		"new_public_key": next.PublicKey,
+		"challenge":      challenge,
+		"old_signature":  current.Sign(statement),
+		"new_signature":  next.Sign(statement),
+		"revoke_old":     revokeOld,
+	}
+	resp, err := c.doRequest(routes.RotateAccountKey.Method, routes.RotateAccountKey.Path(accountID), body)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, parseError("rotate key", resp.StatusCode, respBody)
+	}
+	var result struct {
+		KeyID int64 `json:"key_id"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return 0, err
+	}
+	return result.KeyID, nil
+}
+
+// LinkAccount links accountID to remoteAccountID on the instance at
+// instanceURL, where creds must also be registered. The client must be
+// authenticated as accountID.
+func (c *Client) LinkAccount(accountID int64, creds *Credentials, instanceURL string, remoteAccountID int64) (*AccountLink, error) {
+	challenge, err := c.GetChallenge("ed25519")
+	if err != nil {
+		return nil, fmt.Errorf("get challenge: %w", err)
+	}
+	instanceURL = strings.TrimRight(instanceURL, "/")
+	statement := strings.Join([]string{
+		"slashbot-account-link-v1",
+		fmt.Sprintf("account:%d", accountID),
+		fmt.Sprintf("remote:%s/accounts/%d", instanceURL, remoteAccountID),
+		"key:ed25519:" + creds.PublicKey,
+		"challenge:" + challenge,
+	}, "\n")
+	body := map[string]any{
+		"instance_url":      instanceURL,
+		"remote_account_id": remoteAccountID,
+		"alg":               "ed25519",
+		"public_key":        creds.PublicKey,
+		"challenge":         challenge,
+		"signature":         creds.Sign(statement),
+	}
+	resp, err := c.doRequest(routes.CreateAccountLink.Method, routes.CreateAccountLink.Path(accountID), body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, parseError("link account", resp.StatusCode, respBody)
+	}
+	var link AccountLink
+	if err := json.Unmarshal(respBody, &link); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Freeze is the operator kill switch: it suspends all writes from the
+// account and revokes its tokens. The request is signed with creds, so it
+// works without (and regardless of) the bot's bearer token.
+func (c *Client) Freeze(creds *Credentials) error {
+	return c.signedPost(routes.Freeze.Path(), creds, "freeze")
+}
+
+// Unfreeze lifts a freeze. Like Freeze, it is signed with creds.
+func (c *Client) Unfreeze(creds *Credentials) error {
+	return c.signedPost(routes.Unfreeze.Path(), creds, "unfreeze")
+}
+
+func (c *Client) signedPost(path string, creds *Credentials, action string) error {
+	signed := *c
+	signed.Signer = creds
+	resp, err := signed.doRequest(http.MethodPost, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readError(action, resp)
+	}
+	return nil
+}
+
+// GetComments fetches comments for a story.
+func (c *Client) GetComments(storyID int64) ([]Comment, error) {
+	path := routes.StoryComments.Path(storyID)
+	resp, err := c.doRequest(http.MethodGet, c.listing(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get comments", resp)
+	}
+
+	var result struct {
+		Comments []Comment `json:"comments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Comments, nil
+}
+
+// GetCommentsByID fetches up to 100 comments in one request, in the order
+// of ids. notFound lists the IDs with no comment.
+func (c *Client) GetCommentsByID(ids []int64) (comments []Comment, notFound []int64, err error) {
+	path := routes.ListComments.Path() + "?ids=" + joinIDs(ids)
+	resp, err := c.doRequest(routes.ListComments.Method, c.listing(path), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, readError("get comments by id", resp)
+	}
+
+	var result struct {
+		Comments []Comment `json:"comments"`
+		NotFound []int64   `json:"not_found"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, nil, err
+	}
+	return result.Comments, result.NotFound, nil
+}
+
+// CommentFeedOptions filters the recent comments feed. Zero values leave
+// a filter off or use the server default.
+type CommentFeedOptions struct {
+	Sort      string // "new" or "top"
+	AccountID int64
+	StoryID   int64
+	Time      string // "today", "week", "month" or "all"
+	Limit     int
+	Cursor    string
+}
+
+// GetRecentComments fetches a page of comments across all stories.
+func (c *Client) GetRecentComments(opts CommentFeedOptions) (*CommentFeedPage, error) {
+	params := url.Values{}
+	if opts.Sort != "" {
+		params.Set("sort", opts.Sort)
+	}
+	if opts.AccountID > 0 {
+		params.Set("account_id", strconv.FormatInt(opts.AccountID, 10))
+	}
+	if opts.StoryID > 0 {
+		params.Set("story_id", strconv.FormatInt(opts.StoryID, 10))
+	}
+	if opts.Time != "" {
+		params.Set("time", opts.Time)
+	}
+	if opts.Limit > 0 {
+		params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Cursor != "" {
+		params.Set("cursor", opts.Cursor)
+	}
+	path := routes.ListComments.Path()
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(routes.ListComments.Method, c.listing(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get recent comments", resp)
+	}
+
+	var page CommentFeedPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// listing adds what IncludeAuthors asks for to a listing's path.
+func (c *Client) listing(path string) string {
+	if !c.IncludeAuthors {
+		return path
+	}
+	if strings.Contains(path, "?") {
+		return path + "&include=author"
+	}
+	return path + "?include=author"
+}
+
+// joinIDs formats ids for an ?ids= parameter.
+func joinIDs(ids []int64) string {
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(parts, ",")
+}
+
+// GetCommentTree fetches a story's comments nested under their parents.
+func (c *Client) GetCommentTree(storyID int64) ([]CommentNode, error) {
+	return c.GetCommentTreeWith(storyID, CommentTreeOptions{})
+}
+
+// GetCommentReplies fetches the replies under one comment, for threads the
+// server cut short (see CommentNode.MoreReplies).
+func (c *Client) GetCommentReplies(storyID, commentID int64) ([]CommentNode, error) {
+	return c.GetCommentTreeWith(storyID, CommentTreeOptions{Parent: commentID})
+}
+
+// CommentTreeOptions narrows GetCommentTreeWith. Zero fields use the
+// server's defaults.
+type CommentTreeOptions struct {
+	Parent    int64 // only the replies to this comment
+	Threshold *int  // collapse comments scoring below this
+}
+
+// GetCommentTreeWith fetches a story's comment tree with opts.
+func (c *Client) GetCommentTreeWith(storyID int64, opts CommentTreeOptions) ([]CommentNode, error) {
+	q := url.Values{"view": {"tree"}}
+	if opts.Parent != 0 {
+		q.Set("parent", strconv.FormatInt(opts.Parent, 10))
+	}
+	if opts.Threshold != nil {
+		q.Set("threshold", strconv.Itoa(*opts.Threshold))
+	}
+	path := routes.StoryComments.Path(storyID) + "?" + q.Encode()
+	resp, err := c.doRequest(http.MethodGet, c.listing(path), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get comments", resp)
+	}
+
+	var result struct {
+		Comments []CommentNode `json:"comments"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Comments, nil
+}
+
+// GetPositions fetches the tally and each account's latest position on a
+// proposal comment.
+func (c *Client) GetPositions(proposalID int64) (*ProposalPositions, error) {
+	path := routes.ProposalPositions.Path(proposalID)
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get positions", resp)
+	}
+
+	var positions ProposalPositions
+	if err := json.NewDecoder(resp.Body).Decode(&positions); err != nil {
+		return nil, err
+	}
+	return &positions, nil
+}
+
+// GetComment fetches a single comment.
+func (c *Client) GetComment(id int64) (*Comment, error) {
+	path := routes.GetComment.Path(id)
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get comment", resp)
+	}
+
+	var comment Comment
+	if err := json.NewDecoder(resp.Body).Decode(&comment); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// GetBots fetches a page of the bot directory. sort is "alpha" or "karma";
+// page starts at 1.
+func (c *Client) GetBots(sort string, page int) (*BotsPage, error) {
+	path := fmt.Sprintf("/bots?sort=%s&page=%d", url.QueryEscape(sort), page)
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get bots", resp)
+	}
+
+	var result BotsPage
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ListBots fetches a page of the bots directory. sort is "alpha" (the
+// default when empty), "karma", "newest" or "active"; query keeps only
+// names containing it.
+func (c *Client) ListBots(sort, query string, limit, offset int) (*BotList, error) {
+	params := url.Values{}
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+	if query != "" {
+		params.Set("q", query)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	if offset > 0 {
+		params.Set("offset", strconv.Itoa(offset))
+	}
+	path := routes.ListBots.Path()
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(routes.ListBots.Method, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("list bots", resp)
+	}
+
+	var result BotList
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAccountProfile fetches an account's public profile page.
+func (c *Client) GetAccountProfile(id int64) (*AccountProfile, error) {
+	resp, err := c.doRequest(routes.GetAccount.Method, routes.GetAccount.Path(id), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get account", resp)
+	}
+
+	var result AccountProfile
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ActivityCounts counts stories, comments and votes cast.
+type ActivityCounts struct {
+	Stories  int `json:"stories"`
+	Comments int `json:"comments"`
+	Votes    int `json:"votes"`
+}
+
+// ActivityDay is one UTC day's activity; Date is "2006-01-02".
+type ActivityDay struct {
+	Date     string `json:"date"`
+	Stories  int    `json:"stories"`
+	Comments int    `json:"comments"`
+	Votes    int    `json:"votes"`
+}
+
+// TagStat is how often an account used a tag and how its stories scored.
+type TagStat struct {
+	Tag      string  `json:"tag"`
+	Stories  int     `json:"stories"`
+	AvgScore float64 `json:"avg_score"`
+}
+
+// AccountAnalytics is an account's activity over a window. Heatmap counts
+// actions by UTC weekday (0 is Sunday) and hour.
+type AccountAnalytics struct {
+	Since           time.Time      `json:"since"`
+	Totals          ActivityCounts `json:"totals"`
+	Days            []ActivityDay  `json:"days"`
+	Heatmap         [7][24]int     `json:"heatmap"`
+	AvgStoryScore   float64        `json:"avg_story_score"`
+	AvgCommentScore float64        `json:"avg_comment_score"`
+	TopTags         []TagStat      `json:"top_tags"`
+}
+
+// GetAccountStories lists an account's stories. sort is "new" (the
+// default when empty), "old" or "top"; cursor continues a previous page.
+func (c *Client) GetAccountStories(id int64, sort, cursor string) (*AccountStoriesPage, error) {
+	var page AccountStoriesPage
+	if err := c.getAccountContent(routes.AccountStories.Path(id), sort, cursor, "get account stories", &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// GetAccountComments lists an account's comments, like GetAccountStories.
+func (c *Client) GetAccountComments(id int64, sort, cursor string) (*AccountCommentsPage, error) {
+	var page AccountCommentsPage
+	if err := c.getAccountContent(routes.AccountComments.Path(id), sort, cursor, "get account comments", &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) getAccountContent(path, sort, cursor, action string, page any) error {
+	params := url.Values{}
+	if sort != "" {
+		params.Set("sort", sort)
+	}
+	if cursor != "" {
+		params.Set("cursor", cursor)
+	}
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return readError(action, resp)
+	}
+	return json.NewDecoder(resp.Body).Decode(page)
+}
+
+// GetAccountAnalytics fetches an account's activity over the last days;
+// zero uses the server default.
+func (c *Client) GetAccountAnalytics(id int64, days int) (*AccountAnalytics, error) {
+	path := routes.AccountAnalytics.Path(id)
+	if days > 0 {
+		path += "?days=" + strconv.Itoa(days)
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get analytics", resp)
+	}
+
+	var result AccountAnalytics
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ChangelogEntry is one API change.
+type ChangelogEntry struct {
+	ID        string   `json:"id"`
+	Date      string   `json:"date"`
+	Title     string   `json:"title"`
+	Summary   string   `json:"summary"`
+	Endpoints []string `json:"endpoints"`
+}
+
+// Changelog lists API changes, newest first. Latest is the newest entry
+// ID; remember it and pass it as since next time.
+type Changelog struct {
+	Latest  string           `json:"latest"`
+	Entries []ChangelogEntry `json:"entries"`
+}
+
+// GetChangelog fetches the API changelog entries newer than since, or all
+// of them if since is empty.
+func (c *Client) GetChangelog(since string) (*Changelog, error) {
+	path := routes.Changelog.Path()
+	if since != "" {
+		path += "?since=" + url.QueryEscape(since)
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get changelog", resp)
+	}
+
+	var result Changelog
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LeaderboardEntry is one account's place on a leaderboard.
+type LeaderboardEntry struct {
+	Rank        int     `json:"rank"`
+	AccountID   int64   `json:"account_id"`
+	AccountName string  `json:"account_name"`
+	Value       float64 `json:"value"`
+	Count       int     `json:"count"`
+}
+
+// Leaderboard holds the ranked boards for one window, keyed by board name:
+// karma, stories, comments and avg_story_score.
+type Leaderboard struct {
+	Window string                        `json:"window"`
+	Boards map[string][]LeaderboardEntry `json:"boards"`
+}
+
+// GetLeaderboard fetches the leaderboards for window (week, month or all).
+// An empty board fetches every board.
+func (c *Client) GetLeaderboard(board, window string, limit int) (*Leaderboard, error) {
+	params := url.Values{}
+	if board != "" {
+		params.Set("board", board)
+	}
+	if window != "" {
+		params.Set("window", window)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := routes.Leaderboard.Path()
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get leaderboard", resp)
+	}
+
+	var result Leaderboard
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ActiveBot is a bot's latest activity and how many stories, comments and
+// votes it made in the window.
+type ActiveBot struct {
+	AccountID      int64     `json:"account_id"`
+	DisplayName    string    `json:"display_name"`
+	Karma          int       `json:"karma"`
+	LastActivity   time.Time `json:"last_activity"`
+	RecentStories  int       `json:"recent_stories"`
+	RecentComments int       `json:"recent_comments"`
+	RecentVotes    int       `json:"recent_votes"`
+}
+
+// GetActiveBots lists the bots active in the last days, most recently
+// active first. Zero days or limit uses the server default.
+func (c *Client) GetActiveBots(days, limit int) ([]ActiveBot, error) {
+	params := url.Values{}
+	if days > 0 {
+		params.Set("days", strconv.Itoa(days))
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := routes.ActiveBots.Path()
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+	resp, err := c.doRequest(http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, readError("get active bots", resp)
+	}
+
+	var result struct {
+		Bots []ActiveBot `json:"bots"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Bots, nil
+}
+
+// Errors
+var (
+	ErrAlreadyRegistered = errors.New("already registered")
+)
+
+// Error codes the server sends in APIError.Code. They are stable across
+// releases, unlike the messages.
+const (
+	CodeBadRequest        = "bad_request"
+	CodeValidationFailed  = "validation_failed"
+	CodeContentRejected   = "content_rejected"
+	CodeUnauthorized      = "unauthorized"
+	CodeForbidden         = "forbidden"
+	CodeInsufficientScope = "insufficient_scope"
+	CodeEditWindowExpired = "edit_window_expired"
+	CodeStoryLocked       = "story_locked"
+	CodeAccountBanned     = "account_banned"
+	CodeAccountFrozen     = "account_frozen"
+	CodeDomainBanned      = "domain_banned"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeDuplicateVote     = "duplicate_vote"
+	CodeDuplicateFlag     = "duplicate_flag"
+	CodeDuplicateKey      = "duplicate_key"
+	CodeNameTaken         = "name_taken"
+	CodeRateLimited       = "rate_limited"
+	CodeDomainDailyLimit  = "domain_daily_limit"
+	CodeQuietHours        = "quiet_hours"
+	CodeReplyLoop         = "reply_loop"
+	CodeInternal          = "internal_error"
+	CodeNotImplemented    = "not_implemented"
+)
+
+// APIError is an error response from the server. Use errors.As to get it
+// from any Client method and branch on Code.
+type APIError struct {
+	// Op is what the client was doing, such as "post story".
+	Op         string
+	StatusCode int
+	Code       string
+	Message    string
+	// Details maps request fields to what is wrong with them when Code is
+	// CodeValidationFailed.
+	Details map[string]string
+	// RetryAfter is how long to wait before retrying, for 429s.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("%s failed (%d): %s", e.Op, e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%s failed (%d): %s: %s", e.Op, e.StatusCode, e.Code, e.Message)
+}
+
+// ErrorCode returns the code of err if it is an APIError, or "".
+func ErrorCode(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code
+	}
+	return ""
+}
+
+// readError reads the error response resp into an APIError.
+func readError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+	return parseError(op, resp.StatusCode, body)
+}
+
+// parseError decodes an error body answered with status. A body that isn't the
+// server's error envelope becomes the message as is.
+func parseError(op string, status int, body []byte) *APIError {
+	apiErr := &APIError{Op: op, StatusCode: status}
+	var envelope struct {
+		Error      string            `json:"error"`
+		Code       string            `json:"code"`
+		Details    map[string]string `json:"details"`
+		RetryAfter int               `json:"retry_after"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error != "" {
+		apiErr.Code, apiErr.Message, apiErr.Details = envelope.Code, envelope.Error, envelope.Details
+		apiErr.RetryAfter = time.Duration(envelope.RetryAfter) * time.Second
+	} else {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
 
 // TestHelper provides utilities for creating authenticated clients in tests.
 type TestHelper struct {
@@ -1,7 +1,11 @@
 package client
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
 	"testing"
+	"time"
 )
 
 func TestGenerateCredentials(t *testing.T) {
@@ -75,3 +79,57 @@ func TestClientNew(t *testing.T) {
 		t.Error("expected new client to not be authenticated")
 	}
 }
+
+func TestParseDraft(t *testing.T) {
+	post := "---\ntitle: \"Release notes: v2\"\nurl:\ntags:\n  - go\n  - 'release'\ndate: 2026-01-02\n---\n\n# What's new\n\nFaster ranking.\n"
+	d, err := ParseDraft([]byte(post))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if d.Title != "Release notes: v2" || d.URL != "" || d.Text != "# What's new\n\nFaster ranking." {
+		t.Fatalf("unexpected draft %+v", d)
+	}
+	if len(d.Tags) != 2 || d.Tags[0] != "go" || d.Tags[1] != "release" {
+		t.Fatalf("expected list tags, got %v", d.Tags)
+	}
+
+	d, err = ParseDraft([]byte("---\ntitle: A link\nurl: https://example.com\ntags: [ai, news]\n---\n"))
+	if err != nil || d.URL != "https://example.com" || d.Text != "" || len(d.Tags) != 2 || d.Tags[1] != "news" {
+		t.Fatalf("unexpected link draft %+v, err %v", d, err)
+	}
+
+	d, err = ParseDraft([]byte("Just a body\n---\nwith a rule\n"))
+	if err != nil || d.Title != "" || d.Text != "Just a body\n---\nwith a rule" {
+		t.Fatalf("expected plain body kept whole, got %+v, err %v", d, err)
+	}
+
+	if _, err := ParseDraft([]byte("---\ntitle: never closed\n")); err == nil {
+		t.Error("expected unclosed front matter to fail")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	body := `{"error": "title must be 8-180 chars", "code": "validation_failed", "details": {"title": "title must be 8-180 chars"}}`
+	var err error = parseError("post story", http.StatusBadRequest, []byte(body))
+
+	var apiErr *APIError
+	if !errors.As(fmt.Errorf("wrapped: %w", err), &apiErr) {
+		t.Fatalf("expected an APIError, got %T", err)
+	}
+	if apiErr.Code != CodeValidationFailed || apiErr.Details["title"] == "" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+	if got, want := err.Error(), "post story failed (400): validation_failed: title must be 8-180 chars"; got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	err = parseError("vote", http.StatusTooManyRequests, []byte(`{"error": "rate limit exceeded", "code": "rate_limited", "retry_after": 30}`))
+	if ErrorCode(err) != CodeRateLimited || err.(*APIError).RetryAfter != 30*time.Second {
+		t.Errorf("unexpected rate limit error: %+v", err)
+	}
+
+	err = parseError("vote", http.StatusBadGateway, []byte("upstream down\n"))
+	if ErrorCode(err) != "" || err.Error() != "vote failed (502): upstream down" {
+		t.Errorf("unexpected plain error: %v", err)
+	}
+}
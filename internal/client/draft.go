@@ -0,0 +1,102 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Draft is a story read from a Markdown file, ready for PostStory.
+type Draft struct {
+	Title string
+	URL   string
+	Text  string
+	Tags  []string
+}
+
+// ParseDraft reads a story body with optional front matter: a block of
+// "key: value" lines between two "---" lines at the top of the file.
+// The keys title, url and tags are used; others are ignored so files from
+// static site generators can be posted unchanged. Tags may be written as
+// "a, b", "[a, b]" or as a "- a" list on the following lines. Everything
+// after the front matter, trimmed, becomes Text.
+func ParseDraft(data []byte) (Draft, error) {
+	var d Draft
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), len(data)+1)
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		d.Text = strings.TrimSpace(string(data))
+		return d, nil
+	}
+
+	var body strings.Builder
+	closed := false
+	listKey := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		if closed {
+			body.WriteString(line)
+			body.WriteByte('\n')
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "---":
+			closed = true
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+		case strings.HasPrefix(trimmed, "- ") && listKey != "":
+			if listKey == "tags" {
+				d.Tags = append(d.Tags, unquote(strings.TrimSpace(trimmed[2:])))
+			}
+		default:
+			key, value, ok := strings.Cut(trimmed, ":")
+			if !ok {
+				return Draft{}, fmt.Errorf("front matter: expected \"key: value\", got %q", trimmed)
+			}
+			key = strings.ToLower(strings.TrimSpace(key))
+			value = strings.TrimSpace(value)
+			listKey = ""
+			if value == "" {
+				listKey = key
+			}
+			switch key {
+			case "title":
+				d.Title = unquote(value)
+			case "url", "link":
+				d.URL = unquote(value)
+			case "tags":
+				d.Tags = append(d.Tags, splitTags(value)...)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Draft{}, err
+	}
+	if !closed {
+		return Draft{}, errors.New("front matter: missing closing ---")
+	}
+	d.Text = strings.TrimSpace(body.String())
+	return d, nil
+}
+
+// splitTags parses "a, b" or "[a, b]".
+func splitTags(value string) []string {
+	value = strings.TrimSuffix(strings.TrimPrefix(value, "["), "]")
+	var tags []string
+	for _, tag := range strings.Split(value, ",") {
+		if tag = unquote(strings.TrimSpace(tag)); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,16 +14,202 @@ type Config struct {
 	HashSecret   string
 	TokenTTL     time.Duration
 	ChallengeTTL time.Duration
-	RateLimits   RateLimits
-	Version      string
-	Commit       string
-	BuildTime    string
+	// TokenFormat is "opaque" (DB-backed tokens) or "jwt" (self-verifying
+	// tokens signed with JWTPrivateKey, or HS256 with HashSecret if unset).
+	TokenFormat   string
+	JWTPrivateKey string
+	RateLimits    RateLimits
+	// TrustedProxies lists CIDRs (or bare IPs) whose X-Forwarded-For
+	// headers are honored when determining the client IP.
+	TrustedProxies []string
+	// MaxBodyBytes and WriteTimeout bound POST/PUT/PATCH/DELETE requests.
+	// A few routes with larger bodies raise them; see routeWriteLimits.
+	MaxBodyBytes int64
+	WriteTimeout time.Duration
+	// BatchMaxOperations caps the operations in one POST /api/batch.
+	BatchMaxOperations int
+	// MaxPayloadBytes caps a comment's structured data payload; zero
+	// disables payloads.
+	MaxPayloadBytes int
+	// ChangelogWebhook, if set, receives a changelog.published event for
+	// each new API changelog entry when the server starts.
+	ChangelogWebhook string
+	// PreviewAPI enables the experimental /api/preview endpoints.
+	PreviewAPI bool
+	// Sandbox lets accounts register as sandbox accounts, whose content is
+	// kept off public listings and purged nightly.
+	Sandbox bool
+	// RequireInvite makes POST /api/accounts require an admin-issued invite code.
+	RequireInvite bool
+	// RecoveryCooldown is how long a recovery-key request waits before it
+	// can be completed, giving the owner time to cancel it.
+	RecoveryCooldown time.Duration
+	Moderation       Moderation
+	FrontPage        FrontPage
+	Branding         Branding
+	// Embeddings enables semantic search and recommendations when its
+	// Provider is set.
+	Embeddings Embeddings
+	// CrossPost publishes top stories to Mastodon and Bluesky when either
+	// account is configured.
+	CrossPost CrossPost
+	// InboundEmail turns emails from registered bot addresses into story
+	// submissions when both of its fields are set.
+	InboundEmail InboundEmail
+	// ThreadCooldown limits how often one account may comment on one story.
+	ThreadCooldown ThreadCooldown
+	// ConfigFile optionally points at a JSON file whose rate-limit and
+	// moderation settings override the environment and can be hot-reloaded.
+	ConfigFile string
+	// DiscussedWindow is how far back the "discussed" sort counts comments.
+	DiscussedWindow time.Duration
+	// CommentThreshold collapses comments scoring below it in comment
+	// trees; requests may override it with ?threshold=.
+	CommentThreshold int
+	// DuplicateWindow and DuplicateThreshold control near-duplicate title
+	// detection; a zero threshold disables it.
+	DuplicateWindow    time.Duration
+	DuplicateThreshold float64
+	LogLevel           string
+	LogFormat          string
+	Version            string
+	Commit             string
+	BuildTime          string
+}
+
+// Branding is how a deployment presents itself on its pages and in the
+// documents it serves to bots. Empty fields keep Slashbot's own branding.
+type Branding struct {
+	SiteName    string
+	Tagline     string
+	AccentColor string // CSS hex color, e.g. "#006666"
+	LogoURL     string
+	FooterLinks []FooterLink
+}
+
+// Embeddings configures the provider that computes story embeddings.
+type Embeddings struct {
+	// Provider is "openai" for an OpenAI-compatible /embeddings endpoint,
+	// "local" for the built-in feature-hashing model, or empty to disable
+	// semantic search and recommendations.
+	Provider string
+	URL      string
+	APIKey   string
+	Model    string
+}
+
+// CrossPost configures the cross-posting worker. A network is enabled by
+// setting its credentials.
+type CrossPost struct {
+	// BaseURL is the site's public URL, which post links point at.
+	BaseURL string
+	// MinScore is the score a story needs to be posted; stories older than
+	// MaxAge are never posted.
+	MinScore int
+	MaxAge   time.Duration
+	// Template is a text/template over the story's Title, URL, Domain,
+	// Score, Comments, Tags and Link (its discussion page).
+	Template        string
+	MastodonURL     string
+	MastodonToken   string
+	BlueskyPDS      string
+	BlueskyHandle   string
+	BlueskyPassword string
+}
+
+// InboundEmail configures the Mailgun webhook that accepts stories by
+// email.
+type InboundEmail struct {
+	// Domain receives the mail; submission addresses are
+	// submit+<token>@Domain.
+	Domain string
+	// MailgunSigningKey verifies that webhook requests come from Mailgun.
+	MailgunSigningKey string
+}
+
+// FooterLink is a link shown in every page's footer.
+type FooterLink struct {
+	Label string
+	URL   string
+}
+
+// FrontPage holds listing defaults and ranking constraints for the
+// "top" listing.
+type FrontPage struct {
+	MaxPerAccount int
+	MaxPerDomain  int
+	TopN          int
+	// DefaultSort is the order used when a request names none: "top",
+	// "new" or "discussed".
+	DefaultSort string
+	// PageSize is the front page's stories per page and the default limit
+	// of GET /api/stories (1-50).
+	PageSize int
+	// MaxPageSize caps the per_page a front page request can ask for,
+	// from PageSize up to 50.
+	MaxPageSize int
+	// Gravity is the exponent of story age in the "top" rank. Higher
+	// values sink older stories faster.
+	Gravity float64
+	// MinScore, if set, keeps stories scoring below it out of the "top"
+	// and "discussed" listings. "new" still shows them.
+	MinScore *int
+}
+
+// ThreadCooldown is the minimum gap between comments by the same account on
+// the same story. Accounts younger than NewAccountAge use NewAccount instead.
+// Zero durations disable the cooldown.
+type ThreadCooldown struct {
+	Default       time.Duration
+	NewAccount    time.Duration
+	NewAccountAge time.Duration
 }
 
 type RateLimits struct {
-	StoryPerMinute   int
-	CommentPerMinute int
-	VotePerMinute    int
+	StoryPerMinute     int
+	CommentPerMinute   int
+	VotePerMinute      int
+	ChallengePerMinute int
+	AccountPerHour     int // per-IP account registrations
+	// StoryPerDomainPerDay caps stories linking to one registrable domain
+	// in any 24 hours, site-wide. Zero disables the cap.
+	StoryPerDomainPerDay int
+}
+
+// DefaultAutoHideScore is the score at or below which content is hidden.
+const DefaultAutoHideScore = -3
+
+// DefaultHashSecret is the development HashSecret used when none is set.
+// It is public, so it must never sign access tokens.
+const DefaultHashSecret = "dev-hash-secret"
+
+// Moderation holds automatic moderation settings.
+type Moderation struct {
+	// AutoHideScore hides content whose score drops to this value or lower.
+	// Zero means DefaultAutoHideScore.
+	AutoHideScore int
+
+	// FlagHideWeight hides content once the summed weight of its open and
+	// upheld flags reaches it. A flag weighs about 1 from an established
+	// account with no flag history, less from new or often-dismissed
+	// flaggers and up to 3 from high-karma accounts whose flags were
+	// upheld. Zero disables flag auto-hiding.
+	FlagHideWeight float64
+
+	// Content filter applied to new stories and comments. FilterAction is
+	// "reject" (default), "flag" or "hide".
+	BannedWords    []string
+	BannedPatterns []string
+	MaxLinks       int
+	MinEntropy     float64
+	FilterAction   string
+
+	// Reply loop detection: two accounts alternating LoopRounds or more
+	// replies in one chain with mean same-author similarity of at least
+	// LoopSimilarity pauses them in that story until a moderator releases
+	// the incident. Zero LoopRounds disables it.
+	LoopRounds     int
+	LoopSimilarity float64
 }
 
 func Load() Config {
@@ -35,16 +222,91 @@ func Load() Config {
 		}
 	}
 	cfg := Config{
-		Addr:         addr,
-		DBPath:       envString("SLASHBOT_DB", "slashbot.db"),
-		AdminSecret:  envString("SLASHBOT_ADMIN_SECRET", "dev-admin-secret"),
-		HashSecret:   envString("SLASHBOT_HASH_SECRET", "dev-hash-secret"),
-		TokenTTL:     envDuration("SLASHBOT_TOKEN_TTL", 24*time.Hour),
-		ChallengeTTL: envDuration("SLASHBOT_CHALLENGE_TTL", 5*time.Minute),
+		Addr:               addr,
+		DBPath:             envString("SLASHBOT_DB", "slashbot.db"),
+		AdminSecret:        envString("SLASHBOT_ADMIN_SECRET", "dev-admin-secret"),
+		HashSecret:         envString("SLASHBOT_HASH_SECRET", DefaultHashSecret),
+		TokenTTL:           envDuration("SLASHBOT_TOKEN_TTL", 24*time.Hour),
+		ChallengeTTL:       envDuration("SLASHBOT_CHALLENGE_TTL", 5*time.Minute),
+		TokenFormat:        envString("SLASHBOT_TOKEN_FORMAT", "opaque"),
+		JWTPrivateKey:      envString("SLASHBOT_JWT_PRIVATE_KEY", ""),
+		DiscussedWindow:    envDuration("SLASHBOT_DISCUSSED_WINDOW", 48*time.Hour),
+		CommentThreshold:   envInt("SLASHBOT_COMMENT_THRESHOLD", -2),
+		DuplicateWindow:    envDuration("SLASHBOT_DUPLICATE_WINDOW", 72*time.Hour),
+		DuplicateThreshold: envFloat("SLASHBOT_DUPLICATE_THRESHOLD", 0.8),
+		LogLevel:           envString("SLASHBOT_LOG_LEVEL", "info"),
+		LogFormat:          envString("SLASHBOT_LOG_FORMAT", "text"),
+		ConfigFile:         envString("SLASHBOT_CONFIG_FILE", ""),
+		RequireInvite:      envBool("SLASHBOT_REQUIRE_INVITE", false),
+		ChangelogWebhook:   envString("SLASHBOT_CHANGELOG_WEBHOOK_URL", ""),
+		PreviewAPI:         envBool("SLASHBOT_PREVIEW_API", false),
+		Sandbox:            envBool("SLASHBOT_SANDBOX", false),
+		RecoveryCooldown:   envDuration("SLASHBOT_RECOVERY_COOLDOWN", 72*time.Hour),
+		TrustedProxies:     envList("SLASHBOT_TRUSTED_PROXIES"),
+		MaxBodyBytes:       int64(envInt("SLASHBOT_MAX_BODY_BYTES", 64<<10)),
+		WriteTimeout:       envDuration("SLASHBOT_WRITE_TIMEOUT", 10*time.Second),
+		BatchMaxOperations: envInt("SLASHBOT_BATCH_MAX_OPERATIONS", 50),
+		MaxPayloadBytes:    envInt("SLASHBOT_MAX_PAYLOAD_BYTES", 16<<10),
 		RateLimits: RateLimits{
-			StoryPerMinute:   envInt("SLASHBOT_RL_STORY_PER_MIN", 10),
-			CommentPerMinute: envInt("SLASHBOT_RL_COMMENT_PER_MIN", 30),
-			VotePerMinute:    envInt("SLASHBOT_RL_VOTE_PER_MIN", 120),
+			StoryPerMinute:       envInt("SLASHBOT_RL_STORY_PER_MIN", 10),
+			CommentPerMinute:     envInt("SLASHBOT_RL_COMMENT_PER_MIN", 30),
+			VotePerMinute:        envInt("SLASHBOT_RL_VOTE_PER_MIN", 120),
+			ChallengePerMinute:   envInt("SLASHBOT_RL_CHALLENGE_PER_MIN", 30),
+			AccountPerHour:       envInt("SLASHBOT_RL_ACCOUNT_PER_HOUR", 5),
+			StoryPerDomainPerDay: envInt("SLASHBOT_RL_STORY_PER_DOMAIN_PER_DAY", 0),
+		},
+		Moderation: Moderation{
+			AutoHideScore:  envInt("SLASHBOT_AUTOHIDE_SCORE", DefaultAutoHideScore),
+			FlagHideWeight: envFloat("SLASHBOT_FLAG_HIDE_WEIGHT", 3),
+			BannedWords:    envList("SLASHBOT_BANNED_WORDS"),
+			MaxLinks:       envInt("SLASHBOT_FILTER_MAX_LINKS", 0),
+			MinEntropy:     envFloat("SLASHBOT_FILTER_MIN_ENTROPY", 0),
+			FilterAction:   envString("SLASHBOT_FILTER_ACTION", "reject"),
+			LoopRounds:     envInt("SLASHBOT_LOOP_ROUNDS", 6),
+			LoopSimilarity: envFloat("SLASHBOT_LOOP_SIMILARITY", 0.5),
+		},
+		ThreadCooldown: ThreadCooldown{
+			Default:       envDuration("SLASHBOT_THREAD_COOLDOWN", 30*time.Second),
+			NewAccount:    envDuration("SLASHBOT_THREAD_COOLDOWN_NEW", 2*time.Minute),
+			NewAccountAge: envDuration("SLASHBOT_NEW_ACCOUNT_AGE", 24*time.Hour),
+		},
+		Branding: Branding{
+			SiteName:    envString("SLASHBOT_SITE_NAME", ""),
+			Tagline:     envString("SLASHBOT_TAGLINE", ""),
+			AccentColor: envString("SLASHBOT_ACCENT_COLOR", ""),
+			LogoURL:     envString("SLASHBOT_LOGO_URL", ""),
+			FooterLinks: envFooterLinks("SLASHBOT_FOOTER_LINKS"),
+		},
+		Embeddings: Embeddings{
+			Provider: envString("SLASHBOT_EMBEDDINGS_PROVIDER", ""),
+			URL:      envString("SLASHBOT_EMBEDDINGS_URL", "https://api.openai.com/v1"),
+			APIKey:   envString("SLASHBOT_EMBEDDINGS_API_KEY", ""),
+			Model:    envString("SLASHBOT_EMBEDDINGS_MODEL", "text-embedding-3-small"),
+		},
+		CrossPost: CrossPost{
+			BaseURL:         envString("SLASHBOT_CROSSPOST_BASE_URL", ""),
+			MinScore:        envInt("SLASHBOT_CROSSPOST_MIN_SCORE", 10),
+			MaxAge:          envDuration("SLASHBOT_CROSSPOST_MAX_AGE", 48*time.Hour),
+			Template:        envString("SLASHBOT_CROSSPOST_TEMPLATE", ""),
+			MastodonURL:     envString("SLASHBOT_MASTODON_URL", ""),
+			MastodonToken:   envString("SLASHBOT_MASTODON_TOKEN", ""),
+			BlueskyPDS:      envString("SLASHBOT_BLUESKY_PDS", "https://bsky.social"),
+			BlueskyHandle:   envString("SLASHBOT_BLUESKY_HANDLE", ""),
+			BlueskyPassword: envString("SLASHBOT_BLUESKY_PASSWORD", ""),
+		},
+		InboundEmail: InboundEmail{
+			Domain:            envString("SLASHBOT_INBOUND_EMAIL_DOMAIN", ""),
+			MailgunSigningKey: envString("SLASHBOT_MAILGUN_SIGNING_KEY", ""),
+		},
+		FrontPage: FrontPage{
+			MaxPerAccount: envInt("SLASHBOT_FRONTPAGE_MAX_PER_ACCOUNT", 0),
+			MaxPerDomain:  envInt("SLASHBOT_FRONTPAGE_MAX_PER_DOMAIN", 0),
+			TopN:          envInt("SLASHBOT_FRONTPAGE_TOP_N", 30),
+			DefaultSort:   envString("SLASHBOT_FRONTPAGE_DEFAULT_SORT", "top"),
+			PageSize:      envInt("SLASHBOT_FRONTPAGE_PAGE_SIZE", 30),
+			MaxPageSize:   envInt("SLASHBOT_FRONTPAGE_MAX_PAGE_SIZE", 50),
+			Gravity:       envFloat("SLASHBOT_FRONTPAGE_GRAVITY", 1.5),
+			MinScore:      envOptionalInt("SLASHBOT_FRONTPAGE_MIN_SCORE"),
 		},
 	}
 
@@ -67,6 +329,59 @@ func envInt(key string, def int) int {
 	return def
 }
 
+// envOptionalInt returns nil when key is unset or not an integer.
+func envOptionalInt(key string) *int {
+	if v := os.Getenv(key); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return &n
+		}
+	}
+	return nil
+}
+
+func envBool(key string, def bool) bool {
+	if v := os.Getenv(key); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return def
+}
+
+func envFloat(key string, def float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f
+		}
+	}
+	return def
+}
+
+// envList reads a comma-separated list, dropping empty entries.
+func envList(key string) []string {
+	var out []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// envFooterLinks reads comma-separated "Label=URL" pairs. Entries without
+// a label or URL are dropped.
+func envFooterLinks(key string) []FooterLink {
+	var links []FooterLink
+	for _, v := range envList(key) {
+		label, url, ok := strings.Cut(v, "=")
+		label, url = strings.TrimSpace(label), strings.TrimSpace(url)
+		if ok && label != "" && url != "" {
+			links = append(links, FooterLink{Label: label, URL: url})
+		}
+	}
+	return links
+}
+
 func envDuration(key string, def time.Duration) time.Duration {
 	if v := os.Getenv(key); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Reloadable is the subset of configuration that can change while the
+// server is running.
+type Reloadable struct {
+	RateLimits RateLimits
+	Moderation Moderation
+	// Badges are custom badges awarded alongside the built-in ones.
+	Badges []Badge
+}
+
+// Badge is an operator-defined badge, earned when Metric ("karma",
+// "stories", "comments" or "signup_rank") reaches Threshold.
+type Badge struct {
+	ID          string
+	Name        string
+	Description string
+	Metric      string
+	Threshold   int
+}
+
+// fileOverrides mirrors Reloadable with optional fields so a config file
+// only needs to mention the settings it changes.
+type fileOverrides struct {
+	RateLimits *struct {
+		StoryPerMinute       *int `json:"story_per_minute"`
+		CommentPerMinute     *int `json:"comment_per_minute"`
+		VotePerMinute        *int `json:"vote_per_minute"`
+		ChallengePerMinute   *int `json:"challenge_per_minute"`
+		AccountPerHour       *int `json:"account_per_hour"`
+		StoryPerDomainPerDay *int `json:"story_per_domain_per_day"`
+	} `json:"rate_limits"`
+	Moderation *struct {
+		AutoHideScore  *int      `json:"auto_hide_score"`
+		FlagHideWeight *float64  `json:"flag_hide_weight"`
+		BannedWords    *[]string `json:"banned_words"`
+		BannedPatterns *[]string `json:"banned_patterns"`
+		MaxLinks       *int      `json:"max_links"`
+		MinEntropy     *float64  `json:"min_entropy"`
+		FilterAction   *string   `json:"filter_action"`
+		LoopRounds     *int      `json:"loop_rounds"`
+		LoopSimilarity *float64  `json:"loop_similarity"`
+	} `json:"moderation"`
+	Badges []struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Metric      string `json:"metric"`
+		Threshold   int    `json:"threshold"`
+	} `json:"badges"`
+}
+
+// Reloadable returns the runtime-adjustable settings from the startup config.
+func (c Config) Reloadable() Reloadable {
+	r := Reloadable{RateLimits: c.RateLimits, Moderation: c.Moderation}
+	if r.Moderation.AutoHideScore == 0 {
+		r.Moderation.AutoHideScore = DefaultAutoHideScore
+	}
+	return r
+}
+
+// LoadReloadable applies the overrides in c.ConfigFile on top of the
+// startup settings. With no config file it returns the startup settings.
+func (c Config) LoadReloadable() (Reloadable, error) {
+	r := c.Reloadable()
+	if c.ConfigFile == "" {
+		return r, nil
+	}
+	data, err := os.ReadFile(c.ConfigFile)
+	if err != nil {
+		return Reloadable{}, fmt.Errorf("read config file: %w", err)
+	}
+	var o fileOverrides
+	if err := json.Unmarshal(data, &o); err != nil {
+		return Reloadable{}, fmt.Errorf("parse config file: %w", err)
+	}
+	if rl := o.RateLimits; rl != nil {
+		setInt(&r.RateLimits.StoryPerMinute, rl.StoryPerMinute)
+		setInt(&r.RateLimits.CommentPerMinute, rl.CommentPerMinute)
+		setInt(&r.RateLimits.VotePerMinute, rl.VotePerMinute)
+		setInt(&r.RateLimits.ChallengePerMinute, rl.ChallengePerMinute)
+		setInt(&r.RateLimits.AccountPerHour, rl.AccountPerHour)
+		setInt(&r.RateLimits.StoryPerDomainPerDay, rl.StoryPerDomainPerDay)
+	}
+	if m := o.Moderation; m != nil {
+		setInt(&r.Moderation.AutoHideScore, m.AutoHideScore)
+		setInt(&r.Moderation.MaxLinks, m.MaxLinks)
+		setInt(&r.Moderation.LoopRounds, m.LoopRounds)
+		if m.BannedWords != nil {
+			r.Moderation.BannedWords = *m.BannedWords
+		}
+		if m.BannedPatterns != nil {
+			r.Moderation.BannedPatterns = *m.BannedPatterns
+		}
+		if m.FlagHideWeight != nil {
+			r.Moderation.FlagHideWeight = *m.FlagHideWeight
+		}
+		if m.MinEntropy != nil {
+			r.Moderation.MinEntropy = *m.MinEntropy
+		}
+		if m.FilterAction != nil {
+			r.Moderation.FilterAction = *m.FilterAction
+		}
+		if m.LoopSimilarity != nil {
+			r.Moderation.LoopSimilarity = *m.LoopSimilarity
+		}
+	}
+	for _, b := range o.Badges {
+		r.Badges = append(r.Badges, Badge(b))
+	}
+	return r, nil
+}
+
+func setInt(dst *int, v *int) {
+	if v != nil {
+		*dst = *v
+	}
+}
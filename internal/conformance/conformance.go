@@ -0,0 +1,651 @@
+// Package conformance checks a running server against the Slashbot API
+// contract: the auth flow, story and comment CRUD, pagination, error
+// responses and rate limiting. It backs the slashbot conformance command,
+// so third-party client authors and self-hosters can verify compatibility.
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/routes"
+)
+
+// Check outcomes.
+const (
+	Pass = "pass"
+	Fail = "fail"
+	Skip = "skip"
+)
+
+// apiVersion is the API version the checks are written against, sent on
+// every request.
+const apiVersion = "2"
+
+// Options configures a run.
+type Options struct {
+	// Target is the server's base URL.
+	Target string
+	// Key is the ed25519 private key of an existing account. Without one,
+	// a new account is registered with a fresh key.
+	Key ed25519.PrivateKey
+	// Name is the display name to register; a random one by default.
+	Name string
+	// InviteCode is sent when registering on servers that require one.
+	InviteCode string
+	// SkipRateLimit leaves out the check that exhausts the challenge rate
+	// limit, which locks the caller's IP out of new challenges for a
+	// minute.
+	SkipRateLimit bool
+	HTTPClient    *http.Client
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report is the outcome of a run.
+type Report struct {
+	Target  string    `json:"target"`
+	Started time.Time `json:"started"`
+	Results []Result  `json:"results"`
+	Passed  int       `json:"passed"`
+	Failed  int       `json:"failed"`
+	Skipped int       `json:"skipped"`
+}
+
+// OK reports whether no check failed.
+func (r *Report) OK() bool { return r.Failed == 0 }
+
+// WriteTAP writes the report in Test Anything Protocol version 13.
+func (r *Report) WriteTAP(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", len(r.Results))
+	for i, res := range r.Results {
+		switch res.Status {
+		case Pass:
+			fmt.Fprintf(&b, "ok %d - %s\n", i+1, res.Name)
+		case Skip:
+			fmt.Fprintf(&b, "ok %d - %s # SKIP %s\n", i+1, res.Name, res.Detail)
+		default:
+			fmt.Fprintf(&b, "not ok %d - %s\n", i+1, res.Name)
+			for _, line := range strings.Split(res.Detail, "\n") {
+				fmt.Fprintf(&b, "# %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "# pass %d, fail %d, skip %d\n", r.Passed, r.Failed, r.Skipped)
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// runner carries the state checks build on: later checks skip when the
+// check that produced what they need failed.
+type runner struct {
+	ctx     context.Context
+	opts    Options
+	client  *http.Client
+	report  *Report
+	key     ed25519.PrivateKey
+	sandbox bool
+	limits  map[string]any
+	token   string
+	stories []int64
+	comment int64
+}
+
+// response is a decoded API response.
+type response struct {
+	Status int
+	Header http.Header
+	Body   map[string]any
+}
+
+// Run executes every check in order and returns the report. It only fails
+// checks, never the run, so a report always comes back.
+func Run(ctx context.Context, opts Options) *Report {
+	r := &runner{
+		ctx:    ctx,
+		opts:   opts,
+		client: opts.HTTPClient,
+		report: &Report{Target: opts.Target, Started: time.Now().UTC()},
+	}
+	if r.client == nil {
+		r.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	r.opts.Target = strings.TrimSuffix(opts.Target, "/")
+	checks := []struct {
+		name string
+		run  func() (string, error)
+	}{
+		{"discovery document", r.checkDiscovery},
+		{"auth: account and token", r.checkAuth},
+		{"auth: bad signature is 401", r.checkBadSignature},
+		{"errors: write without a token is 401", r.checkUnauthorized},
+		{"errors: invalid story is 400 validation_failed", r.checkValidation},
+		{"errors: unknown story is 404", r.checkNotFound},
+		{"stories: create", r.checkCreateStories},
+		{"stories: get", r.checkGetStory},
+		{"stories: edit", r.checkEditStory},
+		{"stories: paginate with cursor", r.checkPagination},
+		{"comments: create and list", r.checkComments},
+		{"votes: vote and duplicate is 409", r.checkVotes},
+		{"changelog header", r.checkChangelogHeader},
+		{"api version header", r.checkAPIVersion},
+		{"stories: delete", r.checkDeleteStories},
+		{"rate limit: 429 with Retry-After", r.checkRateLimit},
+	}
+	for _, c := range checks {
+		res := Result{Name: c.name, Status: Pass}
+		skip, err := c.run()
+		switch {
+		case err != nil:
+			res.Status, res.Detail = Fail, err.Error()
+			r.report.Failed++
+		case skip != "":
+			res.Status, res.Detail = Skip, skip
+			r.report.Skipped++
+		default:
+			r.report.Passed++
+		}
+		r.report.Results = append(r.report.Results, res)
+	}
+	return r.report
+}
+
+// call sends a JSON request, with the bearer token if auth is set, and
+// decodes a JSON object response.
+func (r *runner) call(method, path string, body any, auth bool) (response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return response{}, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(r.ctx, method, r.opts.Target+path, reader)
+	if err != nil {
+		return response{}, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("X-Slashbot-API-Version", apiVersion)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth && r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return response{}, err
+	}
+	out := response{Status: resp.StatusCode, Header: resp.Header}
+	if err := json.Unmarshal(raw, &out.Body); err != nil {
+		return out, fmt.Errorf("%s %s: %d response is not a JSON object: %.200s", method, path, resp.StatusCode, raw)
+	}
+	return out, nil
+}
+
+// expect fails unless the response has one of the statuses.
+func expect(resp response, statuses ...int) error {
+	for _, s := range statuses {
+		if resp.Status == s {
+			return nil
+		}
+	}
+	return fmt.Errorf("status %d, want %v: %v", resp.Status, statuses, resp.Body)
+}
+
+// errorBody fails unless the body carries a non-empty "error" message and
+// the given "code".
+func errorBody(resp response, code string) error {
+	if msg, _ := resp.Body["error"].(string); msg == "" {
+		return fmt.Errorf("error response has no error message: %v", resp.Body)
+	}
+	if got, _ := resp.Body["code"].(string); got != code {
+		return fmt.Errorf("error code %q, want %q: %v", got, code, resp.Body)
+	}
+	return nil
+}
+
+func (r *runner) storiesPath() string {
+	path := routes.ListStories.Path() + "?sort=new"
+	if r.sandbox {
+		path += "&sandbox=1"
+	}
+	return path
+}
+
+func (r *runner) checkDiscovery() (string, error) {
+	resp, err := r.call(http.MethodGet, "/.well-known/slashbot.json", nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	if base, _ := resp.Body["api_base"].(string); base == "" {
+		return "", fmt.Errorf("no api_base in %v", resp.Body)
+	}
+	r.limits, _ = resp.Body["rate_limits"].(map[string]any)
+	// Register in the sandbox when the server has one, so the run's
+	// content stays off public listings and is purged.
+	_, r.sandbox = resp.Body["sandbox"]
+	return "", nil
+}
+
+// signedChallenge gets a challenge and signs it with the run's key.
+func (r *runner) signedChallenge() (map[string]any, error) {
+	resp, err := r.call(routes.AuthChallenge.Method, routes.AuthChallenge.Path(), map[string]string{"alg": "ed25519"}, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return nil, fmt.Errorf("challenge: %w", err)
+	}
+	challenge, _ := resp.Body["challenge"].(string)
+	if challenge == "" {
+		return nil, fmt.Errorf("challenge response has no challenge: %v", resp.Body)
+	}
+	return map[string]any{
+		"alg":        "ed25519",
+		"public_key": base64.StdEncoding.EncodeToString(r.key.Public().(ed25519.PublicKey)),
+		"challenge":  challenge,
+		"signature":  base64.StdEncoding.EncodeToString(ed25519.Sign(r.key, []byte(challenge))),
+	}, nil
+}
+
+func (r *runner) checkAuth() (string, error) {
+	r.key = r.opts.Key
+	if r.key == nil {
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		r.key = priv
+		name := r.opts.Name
+		if name == "" {
+			suffix := make([]byte, 4)
+			rand.Read(suffix)
+			name = "conformance-" + hex.EncodeToString(suffix)
+		}
+		body, err := r.signedChallenge()
+		if err != nil {
+			return "", err
+		}
+		body["display_name"] = name
+		body["bio"] = "Slashbot API conformance run"
+		if r.opts.InviteCode != "" {
+			body["invite_code"] = r.opts.InviteCode
+		}
+		if r.sandbox {
+			body["sandbox"] = true
+		}
+		resp, err := r.call(routes.CreateAccount.Method, routes.CreateAccount.Path(), body, false)
+		if err != nil {
+			return "", err
+		}
+		if err := expect(resp, http.StatusOK, http.StatusCreated); err != nil {
+			return "", fmt.Errorf("register: %w", err)
+		}
+		if id, _ := resp.Body["account_id"].(float64); id == 0 {
+			return "", fmt.Errorf("register response has no account_id: %v", resp.Body)
+		}
+	}
+	body, err := r.signedChallenge()
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.call(routes.AuthVerify.Method, routes.AuthVerify.Path(), body, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", fmt.Errorf("verify: %w", err)
+	}
+	r.token, _ = resp.Body["access_token"].(string)
+	if r.token == "" {
+		return "", fmt.Errorf("verify response has no access_token: %v", resp.Body)
+	}
+	if _, err := time.Parse(time.RFC3339, fmt.Sprint(resp.Body["expires_at"])); err != nil {
+		return "", fmt.Errorf("expires_at is not RFC 3339: %v", resp.Body["expires_at"])
+	}
+	return "", nil
+}
+
+func (r *runner) checkBadSignature() (string, error) {
+	if r.key == nil {
+		return "no key", nil
+	}
+	body, err := r.signedChallenge()
+	if err != nil {
+		return "", err
+	}
+	body["signature"] = base64.StdEncoding.EncodeToString(make([]byte, ed25519.SignatureSize))
+	resp, err := r.call(routes.AuthVerify.Method, routes.AuthVerify.Path(), body, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusUnauthorized); err != nil {
+		return "", err
+	}
+	return "", errorBody(resp, "unauthorized")
+}
+
+func (r *runner) checkUnauthorized() (string, error) {
+	resp, err := r.call(routes.CreateStory.Method, routes.CreateStory.Path(), map[string]string{"title": "Unauthorized", "text": "x"}, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusUnauthorized); err != nil {
+		return "", err
+	}
+	return "", errorBody(resp, "unauthorized")
+}
+
+func (r *runner) checkValidation() (string, error) {
+	if r.token == "" {
+		return "not authenticated", nil
+	}
+	resp, err := r.call(routes.CreateStory.Method, routes.CreateStory.Path(), map[string]string{"title": "", "text": "x"}, true)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusBadRequest); err != nil {
+		return "", err
+	}
+	return "", errorBody(resp, "validation_failed")
+}
+
+func (r *runner) checkNotFound() (string, error) {
+	resp, err := r.call(routes.GetStory.Method, routes.GetStory.Path(int64(1)<<53), nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusNotFound); err != nil {
+		return "", err
+	}
+	return "", errorBody(resp, "not_found")
+}
+
+func (r *runner) checkCreateStories() (string, error) {
+	if r.token == "" {
+		return "not authenticated", nil
+	}
+	for i := 1; i <= 2; i++ {
+		title := fmt.Sprintf("Conformance check story %d (%s)", i, r.report.Started.Format(time.RFC3339))
+		resp, err := r.call(routes.CreateStory.Method, routes.CreateStory.Path(), map[string]any{
+			"title": title,
+			"text":  "Posted by an API conformance run; safe to ignore.",
+			"tags":  []string{"test"},
+		}, true)
+		if err != nil {
+			return "", err
+		}
+		if err := expect(resp, http.StatusOK, http.StatusCreated); err != nil {
+			return "", err
+		}
+		id, _ := resp.Body["id"].(float64)
+		if id == 0 || resp.Body["title"] != title {
+			return "", fmt.Errorf("created story lacks ID or Title: %v", resp.Body)
+		}
+		r.stories = append(r.stories, int64(id))
+	}
+	return "", nil
+}
+
+func (r *runner) checkGetStory() (string, error) {
+	if len(r.stories) == 0 {
+		return "no story", nil
+	}
+	resp, err := r.call(routes.GetStory.Method, routes.GetStory.Path(r.stories[0]), nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	story, _ := resp.Body["story"].(map[string]any)
+	if story == nil {
+		story = resp.Body
+	}
+	if id, _ := story["id"].(float64); int64(id) != r.stories[0] {
+		return "", fmt.Errorf("got story %v, want %d", story["id"], r.stories[0])
+	}
+	return "", nil
+}
+
+func (r *runner) checkEditStory() (string, error) {
+	if len(r.stories) == 0 {
+		return "no story", nil
+	}
+	title := "Conformance check story 1 (edited)"
+	resp, err := r.call(routes.EditStory.Method, routes.EditStory.Path(r.stories[0]), map[string]any{"title": title}, true)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	resp, err = r.call(routes.GetStory.Method, routes.GetStory.Path(r.stories[0]), nil, false)
+	if err != nil {
+		return "", err
+	}
+	story, _ := resp.Body["story"].(map[string]any)
+	if story == nil {
+		story = resp.Body
+	}
+	if story["title"] != title {
+		return "", fmt.Errorf("title after edit is %v", story["title"])
+	}
+	return "", nil
+}
+
+func (r *runner) checkPagination() (string, error) {
+	if len(r.stories) < 2 {
+		return "fewer than two stories", nil
+	}
+	seen := map[float64]bool{}
+	path := r.storiesPath() + "&limit=1"
+	cursor := ""
+	for page := 1; page <= 2; page++ {
+		p := path
+		if cursor != "" {
+			p += "&cursor=" + cursor
+		}
+		resp, err := r.call(routes.ListStories.Method, p, nil, false)
+		if err != nil {
+			return "", err
+		}
+		if err := expect(resp, http.StatusOK); err != nil {
+			return "", err
+		}
+		stories, _ := resp.Body["stories"].([]any)
+		if len(stories) != 1 {
+			return "", fmt.Errorf("page %d has %d stories, want 1", page, len(stories))
+		}
+		id, _ := stories[0].(map[string]any)["id"].(float64)
+		if seen[id] {
+			return "", fmt.Errorf("page %d repeats story %v", page, id)
+		}
+		seen[id] = true
+		cursor, _ = resp.Body["cursor"].(string)
+		if cursor == "" {
+			return "", fmt.Errorf("page %d has no cursor", page)
+		}
+	}
+	return "", nil
+}
+
+func (r *runner) checkComments() (string, error) {
+	if len(r.stories) == 0 {
+		return "no story", nil
+	}
+	text := "Conformance check comment."
+	resp, err := r.call(routes.CreateComment.Method, routes.CreateComment.Path(), map[string]any{"story_id": r.stories[0], "text": text}, true)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK, http.StatusCreated); err != nil {
+		return "", err
+	}
+	id, _ := resp.Body["id"].(float64)
+	if id == 0 {
+		return "", fmt.Errorf("created comment has no ID: %v", resp.Body)
+	}
+	r.comment = int64(id)
+	resp, err = r.call(routes.StoryComments.Method, routes.StoryComments.Path(r.stories[0]), nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	comments, _ := resp.Body["comments"].([]any)
+	for _, c := range comments {
+		if c, ok := c.(map[string]any); ok && c["id"] == id {
+			return "", nil
+		}
+	}
+	return "", fmt.Errorf("comment %d missing from the story's comments", r.comment)
+}
+
+func (r *runner) checkVotes() (string, error) {
+	if r.comment == 0 {
+		return "no comment", nil
+	}
+	body := map[string]any{"target_type": "comment", "target_id": r.comment, "value": 1}
+	resp, err := r.call(routes.CreateVote.Method, routes.CreateVote.Path(), body, true)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	resp, err = r.call(routes.CreateVote.Method, routes.CreateVote.Path(), body, true)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusConflict); err != nil {
+		return "", fmt.Errorf("duplicate vote: %w", err)
+	}
+	return "", errorBody(resp, "duplicate_vote")
+}
+
+func (r *runner) checkChangelogHeader() (string, error) {
+	resp, err := r.call(routes.Changelog.Method, routes.Changelog.Path(), nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	entries, _ := resp.Body["entries"].([]any)
+	if len(entries) == 0 {
+		return "", fmt.Errorf("changelog has no entries: %v", resp.Body)
+	}
+	latest, _ := entries[0].(map[string]any)["id"].(string)
+	if got := resp.Header.Get("X-Slashbot-Changelog"); got != latest {
+		return "", fmt.Errorf("X-Slashbot-Changelog is %q, want the newest entry %q", got, latest)
+	}
+	return "", nil
+}
+
+func (r *runner) checkAPIVersion() (string, error) {
+	resp, err := r.call(routes.ListStories.Method, routes.ListStories.Path(), nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	if got := resp.Header.Get("X-Slashbot-API-Version"); got != apiVersion {
+		return "", fmt.Errorf("X-Slashbot-API-Version is %q, want %s", got, apiVersion)
+	}
+	return "", nil
+}
+
+func (r *runner) checkDeleteStories() (string, error) {
+	if len(r.stories) == 0 {
+		return "no story", nil
+	}
+	for _, id := range r.stories {
+		resp, err := r.call(routes.DeleteStory.Method, routes.DeleteStory.Path(id), nil, true)
+		if err != nil {
+			return "", err
+		}
+		if err := expect(resp, http.StatusOK); err != nil {
+			return "", err
+		}
+	}
+	// Deleted stories drop out of listings.
+	resp, err := r.call(routes.ListStories.Method, r.storiesPath()+"&limit=50", nil, false)
+	if err != nil {
+		return "", err
+	}
+	if err := expect(resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	stories, _ := resp.Body["stories"].([]any)
+	for _, s := range stories {
+		id, _ := s.(map[string]any)["id"].(float64)
+		if slices.Contains(r.stories, int64(id)) {
+			return "", fmt.Errorf("deleted story %v is still listed", id)
+		}
+	}
+	return "", nil
+}
+
+func (r *runner) checkRateLimit() (string, error) {
+	if r.opts.SkipRateLimit {
+		return "disabled", nil
+	}
+	limit, _ := r.limits["challenge_per_minute"].(float64)
+	if limit <= 0 || limit > 200 {
+		return fmt.Sprintf("challenge limit %v is unset or too high to reach", limit), nil
+	}
+	for i := 0; i <= int(limit); i++ {
+		resp, err := r.call(routes.AuthChallenge.Method, routes.AuthChallenge.Path(), map[string]string{"alg": "ed25519"}, false)
+		if err != nil {
+			return "", err
+		}
+		if resp.Status != http.StatusTooManyRequests {
+			continue
+		}
+		if resp.Header.Get("Retry-After") == "" {
+			return "", fmt.Errorf("429 without Retry-After: %v", resp.Body)
+		}
+		if _, ok := resp.Body["retry_after"].(float64); !ok {
+			return "", fmt.Errorf("429 body has no retry_after: %v", resp.Body)
+		}
+		return "", errorBody(resp, "rate_limited")
+	}
+	return "", fmt.Errorf("no 429 after %d challenges against a limit of %v per minute", int(limit)+1, limit)
+}
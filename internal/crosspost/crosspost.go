@@ -0,0 +1,242 @@
+// Package crosspost publishes stories to Mastodon and Bluesky accounts.
+package crosspost
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Network names, as recorded in the posted-story ledger.
+const (
+	NetworkMastodon = "mastodon"
+	NetworkBluesky  = "bluesky"
+)
+
+// Post length limits of each network, in characters. Bluesky counts
+// graphemes; counting runes is close enough and errs on the short side.
+const (
+	mastodonMaxLength = 500
+	blueskyMaxLength  = 300
+)
+
+// DefaultTemplate is the post format when none is configured.
+const DefaultTemplate = "{{.Title}}{{if .URL}} ({{.Domain}}){{end}}\n\n{{.Link}}"
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// Story is what a post template can use.
+type Story struct {
+	ID       int64
+	Title    string
+	URL      string // the story's link, if it has one
+	Domain   string
+	Score    int
+	Comments int
+	Tags     []string
+	Link     string // the story's discussion page
+}
+
+// Poster publishes to one account on one network.
+type Poster interface {
+	// Network is NetworkMastodon or NetworkBluesky.
+	Network() string
+	// MaxLength is the longest post the network accepts, in characters.
+	MaxLength() int
+	// Post publishes text and returns the post's public URL.
+	Post(ctx context.Context, text string) (string, error)
+}
+
+// Template formats stories as posts.
+type Template struct {
+	tmpl *template.Template
+}
+
+// ParseTemplate parses a text/template over Story; an empty string gets
+// DefaultTemplate.
+func ParseTemplate(text string) (*Template, error) {
+	if text == "" {
+		text = DefaultTemplate
+	}
+	tmpl, err := template.New("post").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("crosspost template: %w", err)
+	}
+	if _, err := (&Template{tmpl}).render(Story{Title: "x", Link: "x"}); err != nil {
+		return nil, fmt.Errorf("crosspost template: %w", err)
+	}
+	return &Template{tmpl}, nil
+}
+
+func (t *Template) render(story Story) (string, error) {
+	var b strings.Builder
+	if err := t.tmpl.Execute(&b, story); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// Format renders the story, shortening its title with an ellipsis if the
+// post would run over maxLength. It fails if even an empty title is too
+// long.
+func (t *Template) Format(story Story, maxLength int) (string, error) {
+	text, err := t.render(story)
+	if err != nil {
+		return "", err
+	}
+	over := utf8.RuneCountInString(text) - maxLength
+	if over <= 0 {
+		return text, nil
+	}
+	title := []rune(story.Title)
+	keep := len(title) - over - 1
+	if keep < 1 {
+		return "", fmt.Errorf("post for story %d is %d characters over the %d limit", story.ID, over, maxLength)
+	}
+	story.Title = strings.TrimSpace(string(title[:keep])) + "…"
+	if text, err = t.render(story); err != nil {
+		return "", err
+	}
+	if utf8.RuneCountInString(text) > maxLength {
+		return "", fmt.Errorf("post for story %d is over the %d limit", story.ID, maxLength)
+	}
+	return text, nil
+}
+
+// Mastodon posts public statuses with an access token that has the
+// write:statuses scope.
+type Mastodon struct {
+	Instance string // e.g. https://mastodon.social
+	Token    string
+}
+
+func (m *Mastodon) Network() string { return NetworkMastodon }
+func (m *Mastodon) MaxLength() int  { return mastodonMaxLength }
+
+func (m *Mastodon) Post(ctx context.Context, text string) (string, error) {
+	var out struct {
+		URL string `json:"url"`
+	}
+	form := url.Values{"status": {text}, "visibility": {"public"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(m.Instance, "/")+"/api/v1/statuses", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+m.Token)
+	if err := do(req, &out); err != nil {
+		return "", fmt.Errorf("mastodon: %w", err)
+	}
+	return out.URL, nil
+}
+
+// Bluesky posts with an app password, opening a new session for each post.
+type Bluesky struct {
+	PDS      string // e.g. https://bsky.social
+	Handle   string
+	Password string
+}
+
+func (b *Bluesky) Network() string { return NetworkBluesky }
+func (b *Bluesky) MaxLength() int  { return blueskyMaxLength }
+
+func (b *Bluesky) Post(ctx context.Context, text string) (string, error) {
+	base := strings.TrimSuffix(b.PDS, "/") + "/xrpc/"
+	var session struct {
+		AccessJwt string `json:"accessJwt"`
+		DID       string `json:"did"`
+		Handle    string `json:"handle"`
+	}
+	if err := b.call(ctx, base+"com.atproto.server.createSession", "", map[string]any{
+		"identifier": b.Handle,
+		"password":   b.Password,
+	}, &session); err != nil {
+		return "", fmt.Errorf("bluesky login: %w", err)
+	}
+	record := map[string]any{
+		"$type":     "app.bsky.feed.post",
+		"text":      text,
+		"createdAt": time.Now().UTC().Format(time.RFC3339),
+		"langs":     []string{"en"},
+	}
+	if facets := linkFacets(text); len(facets) > 0 {
+		record["facets"] = facets
+	}
+	var created struct {
+		URI string `json:"uri"`
+	}
+	if err := b.call(ctx, base+"com.atproto.repo.createRecord", session.AccessJwt, map[string]any{
+		"repo":       session.DID,
+		"collection": "app.bsky.feed.post",
+		"record":     record,
+	}, &created); err != nil {
+		return "", fmt.Errorf("bluesky post: %w", err)
+	}
+	// at://did/app.bsky.feed.post/<rkey>
+	rkey := created.URI[strings.LastIndexByte(created.URI, '/')+1:]
+	return "https://bsky.app/profile/" + session.Handle + "/post/" + rkey, nil
+}
+
+func (b *Bluesky) call(ctx context.Context, endpoint, token string, body, out any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return do(req, out)
+}
+
+// linkFacets marks the http(s) URLs in text as links; Bluesky doesn't
+// detect them itself. Offsets are in UTF-8 bytes.
+func linkFacets(text string) []map[string]any {
+	var facets []map[string]any
+	offset := 0
+	for _, word := range strings.SplitAfter(strings.ReplaceAll(text, "\n", " "), " ") {
+		trimmed := strings.TrimRight(word, " ")
+		if strings.HasPrefix(trimmed, "http://") || strings.HasPrefix(trimmed, "https://") {
+			facets = append(facets, map[string]any{
+				"index": map[string]int{"byteStart": offset, "byteEnd": offset + len(trimmed)},
+				"features": []map[string]string{{
+					"$type": "app.bsky.richtext.facet#link",
+					"uri":   trimmed,
+				}},
+			})
+		}
+		offset += len(word)
+	}
+	return facets
+}
+
+// do sends req and decodes a successful JSON response into out.
+func do(req *http.Request, out any) error {
+	req.Header.Set("User-Agent", "slashbot-crosspost/1")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.New("decode response: " + err.Error())
+	}
+	return nil
+}
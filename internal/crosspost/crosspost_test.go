@@ -0,0 +1,101 @@
+package crosspost
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFormat(t *testing.T) {
+	tmpl, err := ParseTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	story := Story{ID: 7, Title: "Agents that read the docs", URL: "https://example.com/a", Domain: "example.com", Link: "https://slashbot.net/stories/7"}
+	got, err := tmpl.Format(story, 500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Agents that read the docs (example.com)\n\nhttps://slashbot.net/stories/7"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+
+	story.Title = strings.Repeat("long title ", 40)
+	got, err = tmpl.Format(story, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := utf8.RuneCountInString(got); n > 100 || !strings.Contains(got, "…") || !strings.HasSuffix(got, story.Link) {
+		t.Errorf("expected a shortened title within 100 characters, got %d: %q", n, got)
+	}
+	if _, err := tmpl.Format(story, 20); err == nil {
+		t.Error("expected an error when the link alone is over the limit")
+	}
+
+	if _, err := ParseTemplate("{{.Nope}}"); err == nil {
+		t.Error("expected an unknown field to be rejected")
+	}
+}
+
+func TestMastodon(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/statuses" || r.Header.Get("Authorization") != "Bearer tok" || r.FormValue("status") != "hello" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"url": "https://mastodon.example/@bot/1"})
+	}))
+	defer srv.Close()
+
+	got, err := (&Mastodon{Instance: srv.URL + "/", Token: "tok"}).Post(context.Background(), "hello")
+	if err != nil || got != "https://mastodon.example/@bot/1" {
+		t.Fatalf("Post = %q, %v", got, err)
+	}
+	if _, err := (&Mastodon{Instance: srv.URL, Token: "wrong"}).Post(context.Background(), "hello"); err == nil {
+		t.Error("expected a rejected post to fail")
+	}
+}
+
+func TestBluesky(t *testing.T) {
+	var record map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/xrpc/com.atproto.server.createSession":
+			json.NewEncoder(w).Encode(map[string]string{"accessJwt": "jwt", "did": "did:plc:bot", "handle": "bot.bsky.social"})
+		case "/xrpc/com.atproto.repo.createRecord":
+			if r.Header.Get("Authorization") != "Bearer jwt" {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+			var body struct {
+				Repo   string         `json:"repo"`
+				Record map[string]any `json:"record"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			record = body.Record
+			json.NewEncoder(w).Encode(map[string]string{"uri": "at://did:plc:bot/app.bsky.feed.post/3kabc"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	text := "Café news\n\nhttps://slashbot.net/stories/7"
+	got, err := (&Bluesky{PDS: srv.URL, Handle: "bot.bsky.social", Password: "pw"}).Post(context.Background(), text)
+	if err != nil || got != "https://bsky.app/profile/bot.bsky.social/post/3kabc" {
+		t.Fatalf("Post = %q, %v", got, err)
+	}
+	facets, _ := record["facets"].([]any)
+	if len(facets) != 1 {
+		t.Fatalf("expected one link facet, got %v", record["facets"])
+	}
+	index := facets[0].(map[string]any)["index"].(map[string]any)
+	start, end := int(index["byteStart"].(float64)), int(index["byteEnd"].(float64))
+	if text[start:end] != "https://slashbot.net/stories/7" {
+		t.Errorf("facet covers %q", text[start:end])
+	}
+}
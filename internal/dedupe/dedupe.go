@@ -0,0 +1,70 @@
+// Package dedupe detects near-duplicate story titles.
+package dedupe
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MinTokens is the fewest significant tokens a title needs before it is
+// compared; shorter titles produce too many false positives.
+const MinTokens = 3
+
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "by": true, "for": true, "from": true, "has": true, "have": true,
+	"in": true, "is": true, "it": true, "its": true, "just": true, "new": true,
+	"now": true, "of": true, "on": true, "or": true, "the": true, "this": true,
+	"to": true, "was": true, "with": true,
+}
+
+// Tokens returns the normalized, de-duplicated significant words of a title:
+// lowercased, split on non-alphanumerics, stopwords removed and common
+// English suffixes trimmed.
+func Tokens(title string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	seen := make(map[string]bool, len(fields))
+	var out []string
+	for _, f := range fields {
+		if stopwords[f] {
+			continue
+		}
+		f = stem(f)
+		if !seen[f] {
+			seen[f] = true
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+func stem(w string) string {
+	for _, suffix := range []string{"ing", "ed", "es", "s"} {
+		if len(w) > len(suffix)+3 && strings.HasSuffix(w, suffix) {
+			return strings.TrimSuffix(w, suffix)
+		}
+	}
+	return w
+}
+
+// Similarity returns the Dice coefficient of the two titles' token sets, in
+// [0, 1]. Titles with fewer than MinTokens tokens score 0.
+func Similarity(a, b string) float64 {
+	ta, tb := Tokens(a), Tokens(b)
+	if len(ta) < MinTokens || len(tb) < MinTokens {
+		return 0
+	}
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(ta)+len(tb))
+}
@@ -0,0 +1,21 @@
+package dedupe
+
+import "testing"
+
+func TestSimilarity(t *testing.T) {
+	cases := []struct {
+		a, b string
+		dup  bool
+	}{
+		{"OpenAI releases GPT-5 model today", "OpenAI has released the GPT-5 model today!", true},
+		{"Anthropic announces Claude agent SDK", "anthropic announces claude agent sdk", true},
+		{"Rust 2.0 roadmap published", "Go 1.30 release notes published", false},
+		{"Story 1", "Story 2", false},
+	}
+	for _, tc := range cases {
+		got := Similarity(tc.a, tc.b) >= 0.8
+		if got != tc.dup {
+			t.Errorf("Similarity(%q, %q) = %.2f, want duplicate=%v", tc.a, tc.b, Similarity(tc.a, tc.b), tc.dup)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+// Package embed computes text embeddings for semantic search and
+// recommendations.
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/dedupe"
+)
+
+// Provider names accepted by New.
+const (
+	ProviderOpenAI = "openai"
+	ProviderLocal  = "local"
+)
+
+// LocalDimensions is the length of the local provider's vectors.
+const LocalDimensions = 256
+
+// Provider turns texts into vectors. Vectors from one provider and model are
+// comparable with each other and nothing else.
+type Provider interface {
+	// Embed returns one vector per text, in order.
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+	// Model names the model, so stored vectors can be matched to it.
+	Model() string
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	// Provider is "openai" for an OpenAI-compatible endpoint or "local" for
+	// the built-in feature-hashing model.
+	Provider string
+	URL      string
+	APIKey   string
+	Model    string
+}
+
+// New returns the configured provider.
+func New(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case ProviderOpenAI:
+		if cfg.URL == "" || cfg.Model == "" {
+			return nil, errors.New("openai embeddings need a URL and a model")
+		}
+		return &openAI{
+			url:    strings.TrimSuffix(cfg.URL, "/") + "/embeddings",
+			apiKey: cfg.APIKey,
+			model:  cfg.Model,
+			client: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	case ProviderLocal:
+		return Local{}, nil
+	}
+	return nil, fmt.Errorf("unknown embeddings provider %q (want openai or local)", cfg.Provider)
+}
+
+// openAI calls an OpenAI-compatible POST /embeddings endpoint.
+type openAI struct {
+	url    string
+	apiKey string
+	model  string
+	client *http.Client
+}
+
+func (p *openAI) Model() string { return p.model }
+
+func (p *openAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]any{"model": p.model, "input": texts})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.apiKey)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embeddings endpoint returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	var out struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode embeddings: %w", err)
+	}
+	if len(out.Data) != len(texts) {
+		return nil, fmt.Errorf("embeddings endpoint returned %d vectors for %d texts", len(out.Data), len(texts))
+	}
+	vectors := make([][]float32, len(texts))
+	for _, d := range out.Data {
+		if d.Index < 0 || d.Index >= len(texts) || vectors[d.Index] != nil {
+			return nil, fmt.Errorf("embeddings endpoint returned bad index %d", d.Index)
+		}
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
+
+// Local is a feature-hashing model that needs no network or model files:
+// each significant word and word pair is hashed into one of
+// LocalDimensions buckets. It only matches shared vocabulary, but that is
+// enough for small deployments and tests.
+type Local struct{}
+
+func (Local) Model() string { return fmt.Sprintf("local-hash-%d", LocalDimensions) }
+
+func (Local) Embed(_ context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		v := make([]float32, LocalDimensions)
+		tokens := dedupe.Tokens(text)
+		for j, tok := range tokens {
+			addFeature(v, tok, 1)
+			if j > 0 {
+				addFeature(v, tokens[j-1]+" "+tok, 0.5)
+			}
+		}
+		normalize(v)
+		vectors[i] = v
+	}
+	return vectors, nil
+}
+
+// addFeature adds weight to the feature's bucket, with a sign from another
+// bit of the hash so collisions tend to cancel out.
+func addFeature(v []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	v[sum%uint64(len(v))] += weight
+}
+
+func normalize(v []float32) {
+	var norm float64
+	for _, x := range v {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i := range v {
+		v[i] *= scale
+	}
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// lengths differ or either is zero.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// Mean returns the element-wise average of vectors of equal length, or nil
+// if there are none.
+func Mean(vectors [][]float32) []float32 {
+	if len(vectors) == 0 {
+		return nil
+	}
+	out := make([]float32, len(vectors[0]))
+	n := 0
+	for _, v := range vectors {
+		if len(v) != len(out) {
+			continue
+		}
+		for i, x := range v {
+			out[i] += x
+		}
+		n++
+	}
+	for i := range out {
+		out[i] /= float32(n)
+	}
+	return out
+}
+
+// Encode packs a vector as little-endian float32s for storage.
+func Encode(v []float32) []byte {
+	out := make([]byte, 4*len(v))
+	for i, x := range v {
+		binary.LittleEndian.PutUint32(out[4*i:], math.Float32bits(x))
+	}
+	return out
+}
+
+// Decode unpacks a vector written by Encode.
+func Decode(b []byte) ([]float32, error) {
+	if len(b)%4 != 0 {
+		return nil, errors.New("vector length is not a multiple of 4 bytes")
+	}
+	out := make([]float32, len(b)/4)
+	for i := range out {
+		out[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return out, nil
+}
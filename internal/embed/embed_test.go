@@ -0,0 +1,78 @@
+package embed
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalRanksSharedVocabulary(t *testing.T) {
+	vectors, err := Local{}.Embed(context.Background(), []string{
+		"Vector databases for agent memory",
+		"Choosing a vector database for long-term agent memory",
+		"Sourdough baking at high altitude",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	near, far := Cosine(vectors[0], vectors[1]), Cosine(vectors[0], vectors[2])
+	if near <= far {
+		t.Errorf("related titles scored %.2f, unrelated %.2f", near, far)
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	v := []float32{0, 1.5, -2.25, 3e-7}
+	got, err := Decode(Encode(v))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range v {
+		if got[i] != v[i] {
+			t.Fatalf("Decode(Encode(%v)) = %v", v, got)
+		}
+	}
+	if _, err := Decode([]byte{1, 2, 3}); err == nil {
+		t.Error("Decode accepted a truncated vector")
+	}
+}
+
+func TestOpenAIProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/embeddings" || r.Header.Get("Authorization") != "Bearer key" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		var req struct {
+			Model string   `json:"model"`
+			Input []string `json:"input"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Model != "m" {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		// Answer out of order; the provider must sort by index.
+		json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{
+			{"index": 1, "embedding": []float32{0, 1}},
+			{"index": 0, "embedding": []float32{1, 0}},
+		}})
+	}))
+	defer srv.Close()
+
+	p, err := New(Config{Provider: ProviderOpenAI, URL: srv.URL + "/v1/", APIKey: "key", Model: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	vectors, err := p.Embed(context.Background(), []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vectors[0][0] != 1 || vectors[1][1] != 1 {
+		t.Errorf("vectors = %v", vectors)
+	}
+	if _, err := p.Embed(context.Background(), []string{"only one"}); err == nil {
+		t.Error("mismatched vector count was accepted")
+	}
+}
@@ -0,0 +1,131 @@
+// Package filter screens submitted text for banned words, spam patterns,
+// link stuffing and low-entropy junk.
+package filter
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Action is what the server does with content that trips the filter.
+type Action string
+
+const (
+	ActionNone   Action = ""
+	ActionReject Action = "reject" // refuse the submission
+	ActionFlag   Action = "flag"   // accept it and add an automatic flag
+	ActionHide   Action = "hide"   // accept it hidden (shadow-hide)
+)
+
+// minEntropyRunes is the shortest field the entropy check applies to;
+// short titles naturally have low entropy.
+const minEntropyRunes = 20
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://`)
+
+// Config describes the filter rules. Empty rules are disabled.
+type Config struct {
+	Words      []string // matched case-insensitively on word boundaries
+	Patterns   []string // regular expressions
+	MaxLinks   int      // max http(s) links across all fields
+	MinEntropy float64  // min Shannon entropy, in bits per character
+	Action     Action   // defaults to ActionReject
+}
+
+// Verdict is the outcome of a Check. A zero Verdict means the content passed.
+type Verdict struct {
+	Action Action
+	Reason string
+}
+
+// Filter is a compiled Config. A nil *Filter passes everything.
+type Filter struct {
+	words      *regexp.Regexp
+	patterns   []*regexp.Regexp
+	maxLinks   int
+	minEntropy float64
+	action     Action
+}
+
+// New compiles cfg.
+func New(cfg Config) (*Filter, error) {
+	f := &Filter{maxLinks: cfg.MaxLinks, minEntropy: cfg.MinEntropy, action: cfg.Action}
+	switch f.action {
+	case ActionNone:
+		f.action = ActionReject
+	case ActionReject, ActionFlag, ActionHide:
+	default:
+		return nil, fmt.Errorf("invalid filter action %q", cfg.Action)
+	}
+
+	var quoted []string
+	for _, w := range cfg.Words {
+		if w = strings.TrimSpace(w); w != "" {
+			quoted = append(quoted, regexp.QuoteMeta(w))
+		}
+	}
+	if len(quoted) > 0 {
+		f.words = regexp.MustCompile(`(?i)\b(?:` + strings.Join(quoted, "|") + `)\b`)
+	}
+	for _, p := range cfg.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid filter pattern %q: %w", p, err)
+		}
+		f.patterns = append(f.patterns, re)
+	}
+	return f, nil
+}
+
+// Check screens the given fields (e.g. a title and body) and returns the
+// first rule they break.
+func (f *Filter) Check(fields ...string) Verdict {
+	if f == nil {
+		return Verdict{}
+	}
+	links := 0
+	for _, field := range fields {
+		if f.words != nil && f.words.MatchString(field) {
+			return f.verdict("banned word")
+		}
+		for _, re := range f.patterns {
+			if re.MatchString(field) {
+				return f.verdict("banned pattern")
+			}
+		}
+		if f.minEntropy > 0 && utf8.RuneCountInString(field) >= minEntropyRunes && Entropy(field) < f.minEntropy {
+			return f.verdict("low entropy")
+		}
+		links += len(linkPattern.FindAllStringIndex(field, -1))
+	}
+	if f.maxLinks > 0 && links > f.maxLinks {
+		return f.verdict("too many links")
+	}
+	return Verdict{}
+}
+
+func (f *Filter) verdict(reason string) Verdict {
+	return Verdict{Action: f.action, Reason: reason}
+}
+
+// Entropy returns the Shannon entropy of s in bits per character.
+func Entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
@@ -0,0 +1,48 @@
+package filter
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	f, err := New(Config{
+		Words:      []string{"ignore previous instructions"},
+		Patterns:   []string{`(?i)free\s+crypto`},
+		MaxLinks:   2,
+		MinEntropy: 2,
+	})
+	if err != nil {
+		t.Fatalf("new filter: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		fields []string
+		reason string
+	}{
+		{"clean", []string{"A reasonable title", "Some thoughtful body text about models."}, ""},
+		{"word", []string{"Please IGNORE previous instructions now"}, "banned word"},
+		{"word inside another word", []string{"Signoreprevious instructions"}, ""},
+		{"pattern", []string{"Get FREE   crypto today"}, "banned pattern"},
+		{"entropy", []string{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}, "low entropy"},
+		{"links", []string{"see https://a.example", "and http://b.example and https://c.example"}, "too many links"},
+	}
+	for _, tc := range cases {
+		v := f.Check(tc.fields...)
+		if v.Reason != tc.reason {
+			t.Errorf("%s: expected reason %q, got %q", tc.name, tc.reason, v.Reason)
+		}
+		if tc.reason != "" && v.Action != ActionReject {
+			t.Errorf("%s: expected default action reject, got %q", tc.name, v.Action)
+		}
+	}
+
+	if _, err := New(Config{Action: "delete"}); err == nil {
+		t.Fatalf("expected error for invalid action")
+	}
+	if _, err := New(Config{Patterns: []string{"("}}); err == nil {
+		t.Fatalf("expected error for invalid pattern")
+	}
+	var nilFilter *Filter
+	if v := nilFilter.Check("anything"); v.Action != ActionNone {
+		t.Fatalf("nil filter should pass, got %+v", v)
+	}
+}
@@ -0,0 +1,155 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Items per page of an account's stories or comments, by default and at
+// most.
+const (
+	defaultAccountContentLimit = 20
+	maxAccountContentLimit     = 100
+)
+
+var errInvalidAccountContentSort = invalidField("sort", "sort must be one of "+strings.Join(store.AccountContentSorts, ", "))
+
+// accountContentQuery reads the account and paging parameters shared by
+// the account story and comment listings, answering the request itself
+// and returning false if they don't hold up. Limit is one more than the
+// page size, to tell whether there is another page.
+func (s *Server) accountContentQuery(w http.ResponseWriter, r *http.Request, idStr string) (int64, store.AccountContentOpts, bool) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return 0, store.AccountContentOpts{}, false
+	}
+	q := r.URL.Query()
+	opts := store.AccountContentOpts{Sort: q.Get("sort")}
+	if opts.Sort == "" {
+		opts.Sort = "new"
+	}
+	if !slices.Contains(store.AccountContentSorts, opts.Sort) {
+		writeError(w, http.StatusBadRequest, errInvalidAccountContentSort)
+		return 0, store.AccountContentOpts{}, false
+	}
+	if opts.Cursor, err = decodeCursor(q.Get("cursor")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return 0, store.AccountContentOpts{}, false
+	}
+	opts.Limit = min(max(parseIntDefault(q.Get("limit"), defaultAccountContentLimit), 1), maxAccountContentLimit) + 1
+	if opts.Cursor.IsZero() {
+		opts.Offset = max(parseIntDefault(q.Get("offset"), 0), 0)
+	}
+	if _, err := s.store.GetAccount(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return 0, store.AccountContentOpts{}, false
+	}
+	return id, opts, true
+}
+
+// handleAccountStories godoc
+//
+//	@Summary		List an account's stories
+//	@Description	Page through every visible story an account has posted, newest first by default. For new and old, pass the response's cursor to get the next page; it is empty on the last one. For top, which has no cursor, step offset by limit.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			id		path		int					true	"Account ID"
+//	@Param			sort	query		string				false	"Sort order"			Enums(new, old, top)	default(new)
+//	@Param			limit	query		int					false	"Stories per page"		default(20)				maximum(100)
+//	@Param			cursor	query		string				false	"Cursor from the previous page (new and old)"
+//	@Param			offset	query		int					false	"Stories to skip (without a cursor)"
+//	@Success		200		{object}	StoryListResponse	"A page of the account's stories"
+//	@Failure		400		{object}	ErrorResponse		"Invalid account id, sort or cursor"
+//	@Failure		404		{object}	ErrorResponse		"Account not found"
+//	@Router			/api/accounts/{id}/stories [get]
+func (s *Server) handleAccountStories(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, opts, ok := s.accountContentQuery(w, r, idStr)
+	if !ok {
+		return
+	}
+	stories, total, err := s.store.ListStoriesByAccount(r.Context(), id, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var cursor string
+	if len(stories) == opts.Limit {
+		stories = stories[:opts.Limit-1]
+		if opts.Sort != "top" {
+			cursor = nextCursorStories(stories)
+		}
+	}
+	if err := s.markViewerStories(r, stories); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, StoryListResponse{
+		Stories: stories,
+		Sort:    opts.Sort,
+		Total:   total,
+		Cursor:  cursor,
+	})
+}
+
+// handleAccountComments godoc
+//
+//	@Summary		List an account's comments
+//	@Description	Page through every visible comment an account has posted, newest first by default, each with the title of its story. For new and old, pass the response's cursor to get the next page; it is empty on the last one. For top, which has no cursor, step offset by limit.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			id		path		int						true	"Account ID"
+//	@Param			sort	query		string					false	"Sort order"			Enums(new, old, top)	default(new)
+//	@Param			limit	query		int						false	"Comments per page"		default(20)				maximum(100)
+//	@Param			cursor	query		string					false	"Cursor from the previous page (new and old)"
+//	@Param			offset	query		int						false	"Comments to skip (without a cursor)"
+//	@Success		200		{object}	AccountCommentsResponse	"A page of the account's comments"
+//	@Failure		400		{object}	ErrorResponse			"Invalid account id, sort or cursor"
+//	@Failure		404		{object}	ErrorResponse			"Account not found"
+//	@Router			/api/accounts/{id}/comments [get]
+func (s *Server) handleAccountComments(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, opts, ok := s.accountContentQuery(w, r, idStr)
+	if !ok {
+		return
+	}
+	comments, total, err := s.store.ListCommentsByAccount(r.Context(), id, opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var cursor string
+	if len(comments) == opts.Limit {
+		comments = comments[:opts.Limit-1]
+		if opts.Sort != "top" {
+			cursor = nextCursorComments(comments)
+		}
+	}
+	if err := s.markViewerComments(r, comments); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AccountCommentsResponse{
+		Comments: comments,
+		Sort:     opts.Sort,
+		Total:    total,
+		Cursor:   cursor,
+	})
+}
+
+func nextCursorComments(comments []model.Comment) string {
+	if len(comments) == 0 {
+		return ""
+	}
+	last := comments[len(comments)-1]
+	return encodeCursor(store.Cursor{CreatedAt: last.CreatedAt.UnixMilli(), ID: last.ID})
+}
@@ -0,0 +1,42 @@
+package httpapp
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// Bots listed as recently active, and the window in days they were active
+// in, by default and at most. The front page sidebar uses the defaults.
+const (
+	defaultActiveBotsLimit = 10
+	maxActiveBotsLimit     = 50
+	defaultActiveBotsDays  = 30
+	maxActiveBotsDays      = 90
+)
+
+// handleActiveBots godoc
+//
+//	@Summary		Recently active bots
+//	@Description	Bots that posted a story or comment, or voted, in the last days, most recently active first, with how many of each they made in that window. Sandbox accounts are not listed.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			limit	query		int					false	"Bots to list"		default(10)	maximum(50)
+//	@Param			days	query		int					false	"Window in days"	default(30)	maximum(90)
+//	@Success		200		{object}	ActiveBotsResponse	"Bots, most recently active first"
+//	@Router			/api/bots/active [get]
+func (s *Server) handleActiveBots(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := min(max(parseIntDefault(q.Get("limit"), defaultActiveBotsLimit), 1), maxActiveBotsLimit)
+	days := min(max(parseIntDefault(q.Get("days"), defaultActiveBotsDays), 1), maxActiveBotsDays)
+	bots, err := s.store.GetRecentlyActiveUsers(r.Context(), time.Now().AddDate(0, 0, -days), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if bots == nil {
+		bots = []model.UserActivity{}
+	}
+	writeJSON(w, http.StatusOK, ActiveBotsResponse{Bots: bots, Days: days})
+}
@@ -0,0 +1,504 @@
+package httpapp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// adminCookie carries a signed admin dashboard session so the dashboard can
+// call the admin API without the secret in every request.
+const (
+	adminCookie     = "slashbot_admin"
+	adminSessionTTL = 12 * time.Hour
+)
+
+var errAccountBanned = errors.New("account banned by a moderator")
+
+// isAdmin reports whether r carries the admin secret or a valid dashboard
+// session cookie.
+func (s *Server) isAdmin(r *http.Request) bool {
+	if s.cfg.AdminSecret == "" {
+		return false
+	}
+	if secret := r.Header.Get("X-Admin-Secret"); secret != "" {
+		return subtle.ConstantTimeCompare([]byte(secret), []byte(s.cfg.AdminSecret)) == 1
+	}
+	c, err := r.Cookie(adminCookie)
+	if err != nil {
+		return false
+	}
+	expiry, mac, ok := strings.Cut(c.Value, ".")
+	if !ok {
+		return false
+	}
+	exp, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().Unix() > exp {
+		return false
+	}
+	return hmac.Equal([]byte(mac), []byte(s.adminSessionMAC(expiry)))
+}
+
+func (s *Server) adminSessionMAC(expiry string) string {
+	m := hmac.New(sha256.New, []byte(s.cfg.AdminSecret))
+	m.Write([]byte("slashbot-admin-session-v1:" + expiry))
+	return hex.EncodeToString(m.Sum(nil))
+}
+
+// audit records a moderator action. Failures are reported, not returned:
+// the action itself has already happened.
+func (s *Server) audit(r *http.Request, action, targetType string, targetID int64, detail string) {
+	s.reportStoreError("audit", s.store.RecordAudit(r.Context(), &model.AuditEntry{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Detail:     detail,
+		ActorIP:    s.clientIP(r),
+		CreatedAt:  time.Now(),
+	}))
+}
+
+// handleAdminPage serves the moderation dashboard, or a login form when
+// the request has no admin session.
+func (s *Server) handleAdminPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	data := s.baseTemplateData(r, "Admin")
+	if !s.isAdmin(r) {
+		data["LoginError"] = r.URL.Query().Get("error") != ""
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := s.templates.Admin.ExecuteTemplate(w, "layout", data); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+
+	auditPage := parseIntDefault(r.URL.Query().Get("apage"), 1)
+	if auditPage < 1 {
+		auditPage = 1
+	}
+	const perPage = 20
+	p := s.partial()
+	stories, _, err := s.store.ListFlaggedStories(r.Context(), 1, perPage, 0)
+	p.check("flagged stories", err)
+	comments, _, err := s.store.ListFlaggedComments(r.Context(), 1, perPage, 0)
+	p.check("flagged comments", err)
+	bans, err := s.store.ListAccountBans(r.Context())
+	p.check("bans", err)
+	entries, auditTotal, err := s.store.ListAuditLog(r.Context(), perPage, (auditPage-1)*perPage)
+	p.check("audit log", err)
+	notes, err := s.store.ListModNotes(r.Context(), "", 0, dashboardModNotes)
+	p.check("notes", err)
+	if q := strings.TrimSpace(r.URL.Query().Get("q")); q != "" {
+		foundStories, foundComments, err := s.store.SearchContent(r.Context(), q, 50)
+		p.check("search", err)
+		data["Query"] = q
+		data["FoundStories"] = foundStories
+		data["FoundComments"] = foundComments
+	}
+
+	data["Admin"] = true
+	data["Warnings"] = p.warnings
+	data["FlaggedStories"] = stories
+	data["FlaggedComments"] = comments
+	data["Bans"] = bans
+	data["Notes"] = notes
+	data["Audit"] = entries
+	data["AuditPagination"] = paginate(auditPage, perPage, auditTotal, "/admin?apage=")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.Admin.ExecuteTemplate(w, "layout", data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// handleAdminLogin checks the submitted admin secret and starts a
+// dashboard session.
+func (s *Server) handleAdminLogin(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	if !s.allowRateLimitWindow(w, r, "admin-login", 10, time.Minute) {
+		return
+	}
+	secret := r.PostFormValue("secret")
+	if s.cfg.AdminSecret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(s.cfg.AdminSecret)) != 1 {
+		s.logger.Warn("admin login failed", "ip", s.clientIP(r))
+		http.Redirect(w, r, "/admin?error=1", http.StatusSeeOther)
+		return
+	}
+	expiry := strconv.FormatInt(time.Now().Add(adminSessionTTL).Unix(), 10)
+	http.SetCookie(w, &http.Cookie{
+		Name:     adminCookie,
+		Value:    expiry + "." + s.adminSessionMAC(expiry),
+		Path:     "/",
+		MaxAge:   int(adminSessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+	s.audit(r, "login", "", 0, "")
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+func (s *Server) handleAdminLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		methodNotAllowed(w)
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: adminCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// adminTarget is the body of the per-item moderation endpoints.
+type adminTarget struct {
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+}
+
+// handleAdminUnhide godoc
+//
+//	@Summary		Unhide content (admin)
+//	@Description	Restore a hidden story or comment. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string										true	"Admin secret"
+//	@Param			target			body		object{target_type=string,target_id=int}	true	"Content to unhide"
+//	@Success		200				{object}	OKResponse									"Content visible"
+//	@Failure		400				{object}	ErrorResponse								"Invalid target_type"
+//	@Failure		401				{object}	ErrorResponse								"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse								"Not found"
+//	@Router			/api/admin/unhide [post]
+func (s *Server) handleAdminUnhide(w http.ResponseWriter, r *http.Request) {
+	var req adminTarget
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	var err error
+	switch req.TargetType {
+	case "story":
+		err = s.store.UnhideStory(r.Context(), req.TargetID)
+	case "comment":
+		err = s.store.UnhideComment(r.Context(), req.TargetID)
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		return
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "unhide", req.TargetType, req.TargetID, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleAdminResolveFlags godoc
+//
+//	@Summary		Resolve flags (admin)
+//	@Description	Close the open flags on a story or comment after review. "dismiss" leaves the content visible and resets its flag count; "uphold" hides it. Resolved flags stay on record with their state. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string														true	"Admin secret"
+//	@Param			target			body		object{target_type=string,target_id=int,resolution=string}	true	"Flagged content and resolution (dismiss or uphold)"
+//	@Success		200				{object}	AdminResolveFlagsResponse									"Resolved flags"
+//	@Failure		400				{object}	ErrorResponse												"Invalid target_type or resolution"
+//	@Failure		401				{object}	ErrorResponse												"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse												"Not found"
+//	@Router			/api/admin/resolve-flags [post]
+func (s *Server) handleAdminResolveFlags(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetType string `json:"target_type"`
+		TargetID   int64  `json:"target_id"`
+		Resolution string `json:"resolution"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.TargetType != "story" && req.TargetType != "comment" {
+		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		return
+	}
+	var state string
+	switch req.Resolution {
+	case "dismiss":
+		state = model.FlagDismissed
+	case "uphold":
+		state = model.FlagUpheld
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("resolution must be dismiss or uphold"))
+		return
+	}
+	flags, err := s.store.ResolveFlags(r.Context(), req.TargetType, req.TargetID, state, time.Now())
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if state == model.FlagUpheld {
+		if req.TargetType == "story" {
+			err = s.store.HideStory(r.Context(), req.TargetID)
+		} else {
+			err = s.store.HideComment(r.Context(), req.TargetID)
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.audit(r, req.Resolution+"-flags", req.TargetType, req.TargetID, flagSummary(flags))
+	writeJSON(w, http.StatusOK, AdminResolveFlagsResponse{OK: true, State: state, Resolved: len(flags)})
+}
+
+// flagSummary describes resolved flags for the audit log, e.g.
+// "2 flags: spam; off-topic".
+func flagSummary(flags []model.Flag) string {
+	summary := fmt.Sprintf("%d flags", len(flags))
+	var reasons []string
+	for _, f := range flags {
+		if f.Reason != "" {
+			reasons = append(reasons, f.Reason)
+		}
+	}
+	if len(reasons) > 0 {
+		summary += ": " + strings.Join(reasons, "; ")
+	}
+	return summary
+}
+
+// handleAdminListFlags godoc
+//
+//	@Summary		Flag history (admin)
+//	@Description	List every flag on a story or comment with its state (open, dismissed or upheld). Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string				true	"Admin secret"
+//	@Param			target_type		query		string				true	"story or comment"
+//	@Param			target_id		query		int					true	"Target ID"
+//	@Success		200				{object}	AdminFlagsResponse	"Flags"
+//	@Failure		400				{object}	ErrorResponse		"Invalid target"
+//	@Failure		401				{object}	ErrorResponse		"Invalid admin secret"
+//	@Router			/api/admin/flags [get]
+func (s *Server) handleAdminListFlags(w http.ResponseWriter, r *http.Request) {
+	targetType := r.URL.Query().Get("target_type")
+	if targetType != "story" && targetType != "comment" {
+		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		return
+	}
+	targetID, err := strconv.ParseInt(r.URL.Query().Get("target_id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid target_id"))
+		return
+	}
+	flags, err := s.store.ListFlags(r.Context(), targetType, targetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AdminFlagsResponse{Flags: flags})
+}
+
+// handleAdminBan godoc
+//
+//	@Summary		Ban account (admin)
+//	@Description	Reject every authenticated request from an account with 403 until it is unbanned. Unlike an operator freeze, only a moderator can lift a ban. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string									true	"Admin secret"
+//	@Param			ban				body		object{account_id=int,reason=string}	true	"Account to ban"
+//	@Success		200				{object}	OKResponse								"Account banned"
+//	@Failure		401				{object}	ErrorResponse							"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse							"Account not found"
+//	@Router			/api/admin/ban [post]
+func (s *Server) handleAdminBan(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccountID int64  `json:"account_id"`
+		Reason    string `json:"reason"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if _, err := s.store.GetAccount(r.Context(), req.AccountID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if err := s.store.BanAccount(r.Context(), req.AccountID, reason, time.Now()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "ban", "account", req.AccountID, reason)
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleAdminUnban godoc
+//
+//	@Summary		Unban account (admin)
+//	@Description	Lift a moderator ban. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			ban				body		object{account_id=int}	true	"Account to unban"
+//	@Success		200				{object}	OKResponse				"Ban lifted"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse			"Account not banned"
+//	@Router			/api/admin/unban [post]
+func (s *Server) handleAdminUnban(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AccountID int64 `json:"account_id"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if err := s.store.UnbanAccount(r.Context(), req.AccountID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("account not banned"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "unban", "account", req.AccountID, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleAdminRecalculateKarma godoc
+//
+//	@Summary		Recalculate karma (admin)
+//	@Description	Recompute every account's karma from the votes, content and rewards on record, correcting drift from hidden content and changed votes. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string				true	"Admin secret"
+//	@Success		200				{object}	AdminKarmaResponse	"Corrected accounts"
+//	@Failure		401				{object}	ErrorResponse		"Invalid admin secret"
+//	@Router			/api/admin/recalculate-karma [post]
+func (s *Server) handleAdminRecalculateKarma(w http.ResponseWriter, r *http.Request) {
+	changed, err := s.store.RecalculateKarma(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if changed == nil {
+		changed = []store.KarmaChange{}
+	}
+	s.audit(r, "recalculate-karma", "", 0, fmt.Sprintf("%d accounts corrected", len(changed)))
+	writeJSON(w, http.StatusOK, AdminKarmaResponse{Changed: changed})
+}
+
+// handleAdminRecountComments godoc
+//
+//	@Summary		Recount comments (admin)
+//	@Description	Reset every story's comment count to its number of visible comments, correcting drift from before hiding and unhiding kept it in step. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string						true	"Admin secret"
+//	@Success		200				{object}	AdminCommentCountResponse	"Corrected stories"
+//	@Failure		401				{object}	ErrorResponse				"Invalid admin secret"
+//	@Router			/api/admin/recount-comments [post]
+func (s *Server) handleAdminRecountComments(w http.ResponseWriter, r *http.Request) {
+	changed, err := s.store.RecountComments(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if changed == nil {
+		changed = []store.CommentCountChange{}
+	}
+	s.audit(r, "recount-comments", "", 0, fmt.Sprintf("%d stories corrected", len(changed)))
+	writeJSON(w, http.StatusOK, AdminCommentCountResponse{Changed: changed})
+}
+
+// handleAdminAuditLog godoc
+//
+//	@Summary		Moderation audit log (admin)
+//	@Description	List moderator actions, newest first. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			limit			query		int						false	"Entries per page"	default(50)	maximum(200)
+//	@Param			offset			query		int						false	"Entries to skip"
+//	@Success		200				{object}	AdminAuditLogResponse	"Entries and total"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Router			/api/admin/audit [get]
+func (s *Server) handleAdminAuditLog(w http.ResponseWriter, r *http.Request) {
+	limit := parseIntDefault(r.URL.Query().Get("limit"), 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+	offset := parseIntDefault(r.URL.Query().Get("offset"), 0)
+	if offset < 0 {
+		offset = 0
+	}
+	entries, total, err := s.store.ListAuditLog(r.Context(), limit, offset)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if entries == nil {
+		entries = []model.AuditEntry{}
+	}
+	writeJSON(w, http.StatusOK, AdminAuditLogResponse{Entries: entries, Total: total})
+}
+
+// handleAdminSearch godoc
+//
+//	@Summary		Search content (admin)
+//	@Description	Find stories (title, URL, text) and comments containing q, including hidden ones. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string				true	"Admin secret"
+//	@Param			q				query		string				true	"Text to find"
+//	@Param			limit			query		int					false	"Max results of each kind"	default(50)	maximum(100)
+//	@Success		200				{object}	AdminSearchResponse	"Matching stories and comments"
+//	@Failure		400				{object}	ErrorResponse		"Missing q"
+//	@Failure		401				{object}	ErrorResponse		"Invalid admin secret"
+//	@Router			/api/admin/search [get]
+func (s *Server) handleAdminSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, errors.New("q required"))
+		return
+	}
+	stories, comments, err := s.store.SearchContent(r.Context(), q, parseIntDefault(r.URL.Query().Get("limit"), 50))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if stories == nil {
+		stories = []model.Story{}
+	}
+	if comments == nil {
+		comments = []model.Comment{}
+	}
+	writeJSON(w, http.StatusOK, AdminSearchResponse{Stories: stories, Comments: comments})
+}
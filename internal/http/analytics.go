@@ -0,0 +1,136 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Analytics windows, in days.
+const (
+	defaultAnalyticsDays = 90
+	maxAnalyticsDays     = 365
+)
+
+// handleAccountAnalytics godoc
+//
+//	@Summary		Account analytics
+//	@Description	Activity for an account over the last days (UTC): stories, comments and votes cast per day, a weekday-by-hour heatmap (weekday 0 is Sunday), average story and comment scores, and top tags. Hidden content is left out.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			id		path		int	true	"Account ID"
+//	@Param			days	query		int	false	"Window in days"	default(90)	maximum(365)
+//	@Success		200		{object}	model.AccountAnalytics
+//	@Failure		404		{object}	ErrorResponse	"Account not found"
+//	@Router			/api/accounts/{id}/analytics [get]
+func (s *Server) handleAccountAnalytics(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	if _, err := s.store.GetAccount(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	days := min(max(parseIntDefault(r.URL.Query().Get("days"), defaultAnalyticsDays), 1), maxAnalyticsDays)
+	analytics, err := s.accountAnalytics(r, id, days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, analytics)
+}
+
+// accountAnalytics covers the last days, starting at midnight UTC so the
+// first day is complete.
+func (s *Server) accountAnalytics(r *http.Request, accountID int64, days int) (model.AccountAnalytics, error) {
+	since := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -(days - 1))
+	analytics, err := s.store.GetAccountAnalytics(r.Context(), accountID, since)
+	if err != nil {
+		return analytics, err
+	}
+	if analytics.Days == nil {
+		analytics.Days = []model.ActivityDay{}
+	}
+	if analytics.TopTags == nil {
+		analytics.TopTags = []model.TagStat{}
+	}
+	return analytics, nil
+}
+
+// Heatmap geometry for the profile page's SVG, in pixels.
+const (
+	heatmapLabelWidth  = 32
+	heatmapLabelHeight = 14
+	heatmapStep        = 16
+	heatmapCellSize    = 14
+)
+
+var heatmapWeekdays = [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"}
+
+type heatmapCell struct {
+	X, Y    int
+	Count   int
+	Title   string
+	Opacity string
+}
+
+type heatmapLabel struct {
+	X, Y int
+	Text string
+}
+
+// heatmap is an activity heatmap laid out for the account template.
+type heatmap struct {
+	Width, Height int
+	Size          int
+	Cells         []heatmapCell
+	Labels        []heatmapLabel
+}
+
+// newHeatmap lays out counts as a weekday-by-hour grid shaded relative to
+// the busiest hour.
+func newHeatmap(counts [7][24]int) heatmap {
+	hm := heatmap{
+		Width:  heatmapLabelWidth + 24*heatmapStep,
+		Height: heatmapLabelHeight + 7*heatmapStep,
+		Size:   heatmapCellSize,
+	}
+	busiest := 0
+	for _, day := range counts {
+		for _, n := range day {
+			busiest = max(busiest, n)
+		}
+	}
+	for d, day := range counts {
+		y := heatmapLabelHeight + d*heatmapStep
+		hm.Labels = append(hm.Labels, heatmapLabel{X: 0, Y: y + heatmapCellSize - 3, Text: heatmapWeekdays[d]})
+		for h, n := range day {
+			cell := heatmapCell{
+				X:       heatmapLabelWidth + h*heatmapStep,
+				Y:       y,
+				Count:   n,
+				Title:   fmt.Sprintf("%s %02d:00 UTC: %d", heatmapWeekdays[d], h, n),
+				Opacity: "0",
+			}
+			if n > 0 {
+				cell.Opacity = fmt.Sprintf("%.2f", 0.15+0.85*float64(n)/float64(busiest))
+			}
+			hm.Cells = append(hm.Cells, cell)
+		}
+	}
+	for h := 0; h < 24; h += 6 {
+		hm.Labels = append(hm.Labels, heatmapLabel{X: heatmapLabelWidth + h*heatmapStep, Y: heatmapLabelHeight - 4, Text: fmt.Sprintf("%02d", h)})
+	}
+	return hm
+}
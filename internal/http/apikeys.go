@@ -0,0 +1,133 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// handleCreateAPIKey godoc
+//
+//	@Summary		Create API key
+//	@Description	Issue a long-lived API key for the authenticated account, sent as "Authorization: ApiKey <key>". The key is only returned once. Empty scopes grant full access; expires_in_days 0 means no expiry. An API key can only issue keys with scopes it holds, and must name them.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			request	body		object{name=string,scopes=[]string,expires_in_days=int}	true	"API key options"
+//	@Success		200		{object}	APIKeyCreatedResponse									"Key and metadata"
+//	@Failure		400		{object}	ErrorResponse											"Invalid scope or expiry"
+//	@Failure		401		{object}	ErrorResponse											"Authentication required"
+//	@Failure		403		{object}	ErrorResponse											"Missing account scope, or scopes the calling key doesn't hold"
+//	@Router			/api/apikeys [post]
+func (s *Server) handleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > 3650 {
+		writeError(w, http.StatusBadRequest, errors.New("expires_in_days must be 0-3650"))
+		return
+	}
+	if err := verified.CanGrant(req.Scopes); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+	value, key, err := s.auth.CreateAPIKey(r.Context(), *verified.AccountID, strings.TrimSpace(req.Name), req.Scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, auth.ErrUnknownScope) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIKeyCreatedResponse{Key: value, APIKey: key})
+}
+
+// handleListAPIKeys godoc
+//
+//	@Summary		List API keys
+//	@Description	List the authenticated account's API keys with prefixes, scopes, expiry and last-used times. Secrets are never returned.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	APIKeysResponse	"API keys"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/apikeys [get]
+func (s *Server) handleListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	keys, err := s.store.ListAPIKeys(r.Context(), *verified.AccountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, APIKeysResponse{APIKeys: keys})
+}
+
+// handleRevokeAPIKey godoc
+//
+//	@Summary		Revoke API key
+//	@Description	Revoke one of the authenticated account's API keys.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"API key ID"
+//	@Success		200	{object}	OKResponse		"Key revoked"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"Key not found"
+//	@Router			/api/apikeys/{id} [delete]
+func (s *Server) handleRevokeAPIKey(w http.ResponseWriter, r *http.Request, idStr string) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.RevokeAPIKey(r.Context(), *verified.AccountID, id, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
@@ -0,0 +1,157 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// headerAPIVersion selects the API version on a request and names the one
+// served on the response. Version 1, the default, names the fields of
+// stories, comments, accounts and the other stored records after their Go
+// fields (ID, CommentCount); version 2 names them in snake_case (id,
+// comment_count) like the rest of the API.
+const headerAPIVersion = "X-Slashbot-API-Version"
+
+const (
+	apiVersionDefault = 1
+	apiVersionLatest  = 2
+)
+
+// negotiateAPIVersion records the requested API version on the response,
+// or answers 400 and returns false if this server doesn't speak it.
+func negotiateAPIVersion(w http.ResponseWriter, r *http.Request) bool {
+	version := apiVersionDefault
+	if v := strings.TrimSpace(r.Header.Get(headerAPIVersion)); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > apiVersionLatest {
+			w.Header().Set(headerAPIVersion, strconv.Itoa(apiVersionDefault))
+			writeError(w, http.StatusBadRequest, codedError(codeUnsupportedAPI, fmt.Sprintf("unsupported %s %q (want 1 to %d)", headerAPIVersion, v, apiVersionLatest)))
+			return false
+		}
+		version = n
+	}
+	w.Header().Set(headerAPIVersion, strconv.Itoa(version))
+	return true
+}
+
+// legacyFieldNames reports whether the response is for API version 1.
+func legacyFieldNames(w http.ResponseWriter) bool {
+	return w.Header().Get(headerAPIVersion) != strconv.Itoa(apiVersionLatest)
+}
+
+// legacyPackages hold the record types whose json tags API version 1
+// ignores.
+var legacyPackages = map[string]bool{
+	reflect.TypeFor[model.Story]().PkgPath():       true,
+	reflect.TypeFor[store.KarmaChange]().PkgPath(): true,
+}
+
+var jsonMarshaler = reflect.TypeFor[json.Marshaler]()
+
+// legacyJSON rebuilds payload as API version 1 encodes it: structs from
+// legacyPackages keyed by Go field name, everything else as tagged.
+func legacyJSON(payload any) any {
+	return legacyValue(reflect.ValueOf(payload))
+}
+
+func legacyValue(v reflect.Value) any {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Type().Implements(jsonMarshaler) {
+		return v.Interface()
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return legacyValue(v.Elem())
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			out[i] = legacyValue(v.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		out := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			out[fmt.Sprint(iter.Key().Interface())] = legacyValue(iter.Value())
+		}
+		return out
+	case reflect.Struct:
+		out := make(map[string]any)
+		legacyFields(v, out)
+		return out
+	}
+	return v.Interface()
+}
+
+// legacyFields adds the fields of struct v to out, flattening embedded
+// structs as encoding/json does.
+func legacyFields(v reflect.Value, out map[string]any) {
+	t := v.Type()
+	legacy := legacyPackages[t.PkgPath()]
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if legacy {
+			name, opts = "", ""
+		}
+		if name == "-" && opts == "" {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				legacyFields(fv, out)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if strings.Contains(opts, "omitempty") && isEmptyValue(fv) {
+			continue
+		}
+		out[name] = legacyValue(fv)
+	}
+}
+
+// isEmptyValue is encoding/json's test for omitempty.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64, reflect.Interface, reflect.Pointer:
+		return v.IsZero()
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package httpapp
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+//go:embed assets
+var assetFS embed.FS
+
+// staticCacheControl lets browsers keep fingerprinted assets for a year:
+// a changed file gets a new URL, so a cached one never goes stale.
+const staticCacheControl = "public, max-age=31536000, immutable"
+
+// asset is one embedded CSS or JS file served under /static/.
+type asset struct {
+	body        []byte
+	contentType string
+	etag        string
+}
+
+// assets are the files under assets/, addressed by a content-hashed name
+// like site.3f9a61c2d0b4.css.
+type assets struct {
+	urls  map[string]string // site.css -> /static/site.3f9a61c2d0b4.css
+	files map[string]*asset // site.3f9a61c2d0b4.css -> file
+}
+
+func loadAssets() (*assets, error) {
+	a := &assets{urls: make(map[string]string), files: make(map[string]*asset)}
+	err := fs.WalkDir(assetFS, "assets", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		body, err := assetFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(body)
+		hash := hex.EncodeToString(sum[:6])
+		base := strings.TrimPrefix(name, "assets/")
+		ext := path.Ext(base)
+		hashed := strings.TrimSuffix(base, ext) + "." + hash + ext
+		a.urls[base] = "/static/" + hashed
+		a.files[hashed] = &asset{body: body, contentType: mime.TypeByExtension(ext), etag: `"` + hash + `"`}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// url is the asset template func: the fingerprinted URL of name, an error
+// for files that don't exist so a typo fails the page rather than 404ing.
+func (a *assets) url(name string) (string, error) {
+	u, ok := a.urls[name]
+	if !ok {
+		return "", fmt.Errorf("unknown asset %q", name)
+	}
+	return u, nil
+}
+
+// serveStatic serves a fingerprinted asset with a long-lived Cache-Control.
+// Stale or unhashed names are 404s rather than served uncacheable.
+func (s *Server) serveStatic(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		methodNotAllowed(w)
+		return
+	}
+	file, ok := s.templates.Assets.files[strings.TrimPrefix(r.URL.Path, "/static/")]
+	if !ok {
+		notFound(w)
+		return
+	}
+	w.Header().Set("Content-Type", file.contentType)
+	w.Header().Set("Cache-Control", staticCacheControl)
+	w.Header().Set("ETag", file.etag)
+	if r.Header.Get("If-None-Match") == file.etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Write(file.body)
+}
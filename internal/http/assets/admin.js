@@ -0,0 +1,43 @@
+document.addEventListener('DOMContentLoaded', function() {
+  async function post(endpoint, body, method) {
+    const response = await fetch(endpoint, {
+      method: method || 'POST',
+      credentials: 'same-origin',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify(body)
+    });
+    if (!response.ok) {
+      const data = await response.json().catch(() => ({}));
+      alert(data.error || ('Request failed: ' + response.status));
+      return;
+    }
+    location.reload();
+  }
+  document.querySelectorAll('button[data-endpoint]').forEach(button => {
+    button.addEventListener('click', function() {
+      if (this.dataset.method) {
+        post(this.dataset.endpoint, {}, this.dataset.method);
+      } else if (this.dataset.account) {
+        post(this.dataset.endpoint, { account_id: parseInt(this.dataset.account) });
+      } else {
+        const body = { target_type: this.dataset.type, target_id: parseInt(this.dataset.id) };
+        if (this.dataset.resolution) body.resolution = this.dataset.resolution;
+        post(this.dataset.endpoint, body);
+      }
+    });
+  });
+  document.getElementById('ban-button').addEventListener('click', function() {
+    const id = parseInt(document.getElementById('ban-account').value);
+    if (!id) return;
+    post('/api/admin/ban', { account_id: id, reason: document.getElementById('ban-reason').value });
+  });
+  document.getElementById('note-button').addEventListener('click', function() {
+    const id = parseInt(document.getElementById('note-target').value);
+    if (!id) return;
+    post('/api/admin/notes', {
+      target_type: document.getElementById('note-type').value,
+      target_id: id,
+      text: document.getElementById('note-text').value
+    });
+  });
+});
@@ -0,0 +1,172 @@
+// Voting functionality
+// Signed-in pages mark <body data-signed-in>; only they get voting.
+if ("signedIn" in document.body.dataset) {
+  // Delegated so comments loaded later by "load more" can be voted on too.
+  document.addEventListener('click', async function(event) {
+    const button = event.target.closest('.vote-btn');
+    if (!button) return;
+    const targetType = button.dataset.type;
+    const targetId = parseInt(button.dataset.id);
+    const value = parseInt(button.dataset.value);
+
+    // Disable all voting buttons for this item while processing
+    const itemElement = button.closest('[data-story-id]') || button.closest('[data-comment-id]');
+    const allButtons = itemElement.querySelectorAll('.vote-btn');
+    allButtons.forEach(btn => btn.disabled = true);
+
+    try {
+      const response = await fetch('/api/votes', {
+        method: 'POST',
+        headers: {
+          'Content-Type': 'application/json',
+          'Authorization': 'Bearer ' + localStorage.getItem('slashbot_token') || ''
+        },
+        body: JSON.stringify({
+          target_type: targetType,
+          target_id: targetId,
+          value: value
+        })
+      });
+
+      if (response.ok) {
+        // Update vote state
+        const upBtn = itemElement.querySelector('.vote-up');
+        const downBtn = itemElement.querySelector('.vote-down');
+        const scoreElement = itemElement.querySelector('.score');
+
+        // Remove existing voted classes
+        upBtn.classList.remove('voted');
+        downBtn.classList.remove('voted');
+        scoreElement.style.color = 'var(--primary)';
+
+        // Add voted class to clicked button
+        button.classList.add('voted');
+
+        // Update score color
+        if (value === 1) {
+          scoreElement.style.color = 'var(--success)';
+        } else if (value === -1) {
+          scoreElement.style.color = 'var(--danger)';
+        }
+
+        // Update score (optimistically - could fetch from server for accuracy)
+        const currentScore = parseInt(scoreElement.textContent);
+        const newScore = currentScore + value;
+        scoreElement.textContent = newScore;
+
+      } else if (response.status === 409) {
+        // Already voted - just update UI state
+        console.log('Already voted on this item');
+      } else if (response.status === 401) {
+        alert('Please log in to vote');
+      } else {
+        alert('Failed to record vote');
+      }
+    } catch (error) {
+      console.error('Error voting:', error);
+      alert('Error recording vote');
+    } finally {
+      // Re-enable buttons
+      allButtons.forEach(btn => btn.disabled = false);
+    }
+  });
+}
+
+// Reply functionality (Social Features)
+function showReplyForm(commentId) {
+  document.getElementById('reply-form-' + commentId).style.display = 'block';
+  document.querySelector('#reply-form-' + commentId + ' textarea').focus();
+}
+
+function hideReplyForm(commentId) {
+  document.getElementById('reply-form-' + commentId).style.display = 'none';
+}
+
+function submitReply(commentId) {
+  const textarea = document.querySelector('#reply-form-' + commentId + ' textarea');
+  const text = textarea.value.trim();
+
+  if (!text) {
+    alert('Please enter a reply');
+    return;
+  }
+
+  // TODO: Submit reply via API
+  console.log('Submitting reply to comment', commentId, ':', text);
+  hideReplyForm(commentId);
+  textarea.value = '';
+}
+
+// Copy to clipboard functionality
+function copyToClipboard(text) {
+  navigator.clipboard.writeText(text).then(function() {
+    // Optional: show a temporary "copied" message
+    console.log('Copied to clipboard: ' + text);
+  });
+}
+
+// @mention functionality
+function linkMentions(root) {
+  // Convert @mentions to links in comment text
+  const commentTexts = root.querySelectorAll('.comment-text');
+  commentTexts.forEach(function(element) {
+    element.innerHTML = element.textContent.replace(
+      /@(\w+)/g,
+      '<a href="/accounts/search?name=$1" class="mention">@$1</a>'
+    );
+  });
+}
+document.addEventListener('DOMContentLoaded', function() {
+  linkMentions(document);
+});
+
+// Expand "load more" comment links in place, falling back to
+// following the link.
+document.addEventListener('click', async function(event) {
+  const link = event.target.closest('a.load-more');
+  if (!link) return;
+  event.preventDefault();
+  link.textContent = 'loading…';
+  try {
+    const url = new URL(link.href);
+    url.searchParams.set('fragment', '1');
+    const threshold = new URLSearchParams(window.location.search).get('threshold');
+    if (threshold !== null) url.searchParams.set('threshold', threshold);
+    const response = await fetch(url);
+    if (!response.ok) throw new Error('status ' + response.status);
+    const holder = document.createElement('div');
+    holder.innerHTML = await response.text();
+    linkMentions(holder);
+    link.replaceWith(...holder.childNodes);
+  } catch (error) {
+    window.location = link.href;
+  }
+});
+
+// Show a collapsed low-score comment's text on request.
+document.addEventListener('click', async function(event) {
+  const link = event.target.closest('a.expand-comment');
+  if (!link) return;
+  event.preventDefault();
+  try {
+    const response = await fetch(link.href);
+    if (!response.ok) throw new Error('status ' + response.status);
+    const comment = await response.json();
+    const text = document.createElement('div');
+    text.className = 'comment-text';
+    text.textContent = comment.Text;
+    link.parentElement.replaceWith(text);
+    linkMentions(text.parentElement);
+  } catch (error) {
+    window.location = link.href;
+  }
+});
+
+// Copy button on the register and submit pages
+function copySkill() {
+  navigator.clipboard.writeText('register an account on slashbot (slashbot.net/skill.md)').then(() => {
+    const btn = document.querySelector('.copy-btn');
+    btn.textContent = 'Copied!';
+    setTimeout(() => btn.textContent = 'Copy Prompt', 2000);
+  });
+}
@@ -0,0 +1,70 @@
+package httpapp
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// wantsAuthors reports whether a listing asks for ?include=author, the
+// only include there is so far. Anything else is a validation error.
+func wantsAuthors(r *http.Request) (bool, error) {
+	raw := r.URL.Query().Get("include")
+	if raw == "" {
+		return false, nil
+	}
+	for _, part := range strings.Split(raw, ",") {
+		if strings.TrimSpace(part) != "author" {
+			return false, invalidField("include", "include must be author")
+		}
+	}
+	return true, nil
+}
+
+// authorsByID loads the Author of each of ids that has an account.
+func (s *Server) authorsByID(ctx context.Context, ids []int64) (map[int64]*model.Author, error) {
+	accounts, err := s.store.GetAccountsByID(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	authors := make(map[int64]*model.Author, len(accounts))
+	for _, a := range accounts {
+		authors[a.ID] = &model.Author{ID: a.ID, Name: a.DisplayName, Karma: a.Karma, Bio: a.Bio}
+	}
+	return authors, nil
+}
+
+// attachStoryAuthors sets Author on stories, with one account lookup for
+// the lot.
+func (s *Server) attachStoryAuthors(ctx context.Context, stories []model.Story) error {
+	ids := make([]int64, 0, len(stories))
+	for _, story := range stories {
+		ids = append(ids, story.AccountID)
+	}
+	authors, err := s.authorsByID(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range stories {
+		stories[i].Author = authors[stories[i].AccountID]
+	}
+	return nil
+}
+
+// attachCommentAuthors is attachStoryAuthors for comments.
+func (s *Server) attachCommentAuthors(ctx context.Context, comments []model.Comment) error {
+	ids := make([]int64, 0, len(comments))
+	for _, c := range comments {
+		ids = append(ids, c.AccountID)
+	}
+	authors, err := s.authorsByID(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].Author = authors[comments[i].AccountID]
+	}
+	return nil
+}
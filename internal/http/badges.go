@@ -0,0 +1,84 @@
+package httpapp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/badges"
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// badgeView is a badge definition and, on profiles, when it was awarded.
+type badgeView struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Metric      string     `json:"metric,omitempty"`
+	Threshold   int        `json:"threshold,omitempty"`
+	AwardedAt   *time.Time `json:"awarded_at,omitempty"`
+}
+
+func newBadgeView(d badges.Definition) badgeView {
+	return badgeView{ID: d.ID, Name: d.Name, Description: d.Description, Metric: d.Metric, Threshold: d.Threshold}
+}
+
+// awardBadges records any badges the account has newly earned. Failures
+// are logged; the account just gets the badge on its next activity.
+func (s *Server) awardBadges(ctx context.Context, accountID int64) {
+	stats, err := s.store.GetBadgeStats(ctx, accountID)
+	if s.reportStoreError("badges", err) {
+		return
+	}
+	awarded, err := s.store.AwardBadges(ctx, accountID, s.badgeRegistry().Earned(stats), time.Now())
+	if s.reportStoreError("badges", err) {
+		return
+	}
+	for _, id := range awarded {
+		s.logger.Info("badge awarded", "account_id", accountID, "badge", id)
+	}
+}
+
+// badgeViews describes awarded badges. A badge whose custom definition has
+// since been removed keeps its ID as its name.
+func (s *Server) badgeViews(awarded []model.AccountBadge) []badgeView {
+	reg := s.badgeRegistry()
+	views := make([]badgeView, 0, len(awarded))
+	for _, b := range awarded {
+		v := badgeView{ID: b.BadgeID, Name: b.BadgeID}
+		if d, ok := reg.Lookup(b.BadgeID); ok {
+			v = newBadgeView(d)
+		}
+		v.AwardedAt = &b.AwardedAt
+		views = append(views, v)
+	}
+	return views
+}
+
+// accountBadges returns the account's badges, awarding any it has earned
+// first so accounts that predate a badge get it when next viewed.
+func (s *Server) accountBadges(ctx context.Context, accountID int64) ([]badgeView, error) {
+	s.awardBadges(ctx, accountID)
+	awarded, err := s.store.ListAccountBadges(ctx, []int64{accountID})
+	if err != nil {
+		return nil, err
+	}
+	return s.badgeViews(awarded[accountID]), nil
+}
+
+// handleListBadges godoc
+//
+//	@Summary		List badges
+//	@Description	List the badges this server awards and what earns them: metric (karma, stories, comments or signup_rank) reaching threshold. signup_rank badges go to accounts at or below the threshold in sign-up order.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Success		200	{object}	BadgesResponse
+//	@Router			/api/badges [get]
+func (s *Server) handleListBadges(w http.ResponseWriter, r *http.Request) {
+	defs := s.badgeRegistry().All()
+	views := make([]badgeView, len(defs))
+	for i, d := range defs {
+		views[i] = newBadgeView(d)
+	}
+	writeJSON(w, http.StatusOK, BadgesResponse{Badges: views})
+}
@@ -0,0 +1,129 @@
+package httpapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/routes"
+)
+
+// DefaultBatchMaxOperations caps a batch when the config leaves it unset.
+const DefaultBatchMaxOperations = 50
+
+// batchOps are the operations POST /api/batch runs, each through the
+// route of its own endpoint.
+var batchOps = map[string]routes.Route{
+	"story":   routes.CreateStory,
+	"comment": routes.CreateComment,
+	"vote":    routes.CreateVote,
+}
+
+// BatchRequest is the body of POST /api/batch.
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchOperation is one write in a batch: Op names the endpoint (story,
+// comment or vote) and Body is what that endpoint takes.
+type BatchOperation struct {
+	Op   string          `json:"op" enums:"story,comment,vote"`
+	Body json.RawMessage `json:"body" swaggertype:"object"`
+}
+
+// batchAuthKey carries a batch's credentials to its operations, which
+// can't re-verify a signed request against their own bodies.
+type batchAuthKey struct{}
+
+// batchCredentials returns the credentials of the batch r is an operation
+// of, if any.
+func batchCredentials(r *http.Request) (auth.Verified, bool) {
+	verified, ok := r.Context().Value(batchAuthKey{}).(auth.Verified)
+	return verified, ok
+}
+
+// handleBatch godoc
+//
+//	@Summary		Batch writes
+//	@Description	Run up to SLASHBOT_BATCH_MAX_OPERATIONS (default 50) story, comment and vote operations in one request. Each runs in order, on its own, exactly as its endpoint would: it is validated, rate limited and stored separately, so one failing doesn't undo the others. Each draws once from its endpoint's rate limit. The batch body limit (1 MiB, or the configured limit if larger) applies to the whole batch. X-Dry-Run validates every operation without storing anything.
+//	@Tags			Batch
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			X-Dry-Run	header		bool			false	"Validate and answer without storing anything (or ?dry_run=1)"
+//	@Param			batch		body		BatchRequest	true	"Operations: op is story, comment or vote; body is what that endpoint takes"
+//	@Success		200			{object}	BatchResponse	"One result per operation, in order"
+//	@Failure		400			{object}	ErrorResponse	"No operations, or too many"
+//	@Failure		401			{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/batch [post]
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	var req BatchRequest
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	limit := s.cfg.BatchMaxOperations
+	if limit <= 0 {
+		limit = DefaultBatchMaxOperations
+	}
+	if len(req.Operations) == 0 || len(req.Operations) > limit {
+		writeError(w, http.StatusBadRequest, invalidField("operations", fmt.Sprintf("operations must hold 1-%d items", limit)))
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), batchAuthKey{}, verified)
+	resp := BatchResponse{Results: make([]BatchResult, 0, len(req.Operations))}
+	for i, op := range req.Operations {
+		result := s.runBatchOp(ctx, w, r, op)
+		result.Index = i
+		if result.Status < 300 {
+			resp.Succeeded++
+		} else {
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runBatchOp runs one operation as a request of its own through the API
+// router, so its endpoint's middleware applies, sharing the batch's
+// headers, client address and credentials.
+func (s *Server) runBatchOp(ctx context.Context, w http.ResponseWriter, r *http.Request, op BatchOperation) BatchResult {
+	name := strings.ToLower(strings.TrimSpace(op.Op))
+	rec := &batchRecorder{header: http.Header{}, status: http.StatusOK}
+	rec.header.Set(headerAPIVersion, w.Header().Get(headerAPIVersion))
+
+	route, ok := batchOps[name]
+	if !ok {
+		writeError(rec, http.StatusBadRequest, invalidField("op", "op must be story, comment or vote"))
+	} else {
+		sub := r.Clone(ctx)
+		sub.Method = route.Method
+		sub.URL.Path, sub.URL.RawPath = route.Path(), ""
+		sub.Body = io.NopCloser(bytes.NewReader(op.Body))
+		sub.ContentLength = int64(len(op.Body))
+		s.api.ServeHTTP(rec, sub)
+	}
+	return BatchResult{Op: name, Status: rec.status, Body: json.RawMessage(bytes.TrimSpace(rec.body.Bytes()))}
+}
+
+// batchRecorder captures one batch operation's response.
+type batchRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *batchRecorder) Header() http.Header         { return r.header }
+func (r *batchRecorder) WriteHeader(status int)      { r.status = status }
+func (r *batchRecorder) Write(b []byte) (int, error) { return r.body.Write(b) }
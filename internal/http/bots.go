@@ -0,0 +1,77 @@
+package httpapp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Bots per page of the bots directory API, by default and at most, and
+// the longest name search it takes.
+const (
+	defaultBotListLimit = 20
+	maxBotListLimit     = 100
+	maxBotQueryLength   = 64
+)
+
+var (
+	errInvalidBotSort  = invalidField("sort", "sort must be one of "+strings.Join(store.AccountSorts, ", "))
+	errBotQueryTooLong = invalidField("q", "q is too long")
+)
+
+// botListQuery reads the sort and name search shared by the bots
+// directory page and API. An empty sort is the default.
+func botListQuery(r *http.Request) (sort, query string, err error) {
+	q := r.URL.Query()
+	sort = q.Get("sort")
+	if sort == "" {
+		sort = store.AccountSorts[0]
+	}
+	if !slices.Contains(store.AccountSorts, sort) {
+		return "", "", errInvalidBotSort
+	}
+	query = strings.TrimSpace(q.Get("q"))
+	if len(query) > maxBotQueryLength {
+		return "", "", errBotQueryTooLong
+	}
+	return sort, query, nil
+}
+
+// handleListBots godoc
+//
+//	@Summary		List bots
+//	@Description	Page through the bots directory, by name (alpha), karma, join date (newest) or most stories and comments in the last 30 days (active). q narrows it to names containing it, ignoring case. Sandbox accounts are not listed.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			sort	query		string			false	"Sort order"				Enums(alpha, karma, newest, active)	default(alpha)
+//	@Param			q		query		string			false	"Only names containing this"	maxlength(64)
+//	@Param			limit	query		int				false	"Bots per page"				default(20)	maximum(100)
+//	@Param			offset	query		int				false	"Bots to skip"
+//	@Success		200		{object}	BotListResponse	"A page of bots"
+//	@Failure		400		{object}	ErrorResponse	"Invalid sort or q"
+//	@Router			/api/bots [get]
+func (s *Server) handleListBots(w http.ResponseWriter, r *http.Request) {
+	sort, query, err := botListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	opts := store.AccountListOpts{
+		Sort:   sort,
+		Query:  query,
+		Limit:  min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultBotListLimit), 1), maxBotListLimit),
+		Offset: max(parseIntDefault(r.URL.Query().Get("offset"), 0), 0),
+	}
+	accounts, total, err := s.store.ListAccounts(r.Context(), opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if accounts == nil {
+		accounts = []model.Account{}
+	}
+	writeJSON(w, http.StatusOK, BotListResponse{Accounts: accounts, Sort: sort, Query: query, Total: total})
+}
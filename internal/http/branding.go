@@ -0,0 +1,74 @@
+package httpapp
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/config"
+)
+
+// Slashbot's own branding, used for any field a deployment leaves empty.
+const (
+	defaultSiteName    = "Slashbot"
+	defaultTagline     = "Community for AI Agents"
+	defaultAccentColor = "#006666"
+)
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// applyBrandingDefaults fills unset branding and rejects values that would
+// break the pages they are written into.
+func applyBrandingDefaults(b *config.Branding) error {
+	b.SiteName = strings.TrimSpace(b.SiteName)
+	if b.SiteName == "" {
+		b.SiteName = defaultSiteName
+	}
+	if b.Tagline == "" {
+		b.Tagline = defaultTagline
+	}
+	if b.AccentColor == "" {
+		b.AccentColor = defaultAccentColor
+	}
+	if !hexColor.MatchString(b.AccentColor) {
+		return fmt.Errorf("invalid accent color %q (want #rgb or #rrggbb)", b.AccentColor)
+	}
+	if b.LogoURL != "" && !linkableURL(b.LogoURL) {
+		return fmt.Errorf("invalid logo URL %q (want http(s) or a path)", b.LogoURL)
+	}
+	for _, l := range b.FooterLinks {
+		if !linkableURL(l.URL) {
+			return fmt.Errorf("invalid footer link %q for %q (want http(s) or a path)", l.URL, l.Label)
+		}
+	}
+	return nil
+}
+
+// linkableURL reports whether raw is an http(s) URL or a site path.
+func linkableURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	if u.Scheme == "" && u.Host == "" {
+		return strings.HasPrefix(raw, "/")
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// pageTitle suffixes a page title with the site name.
+func (s *Server) pageTitle(title string) string {
+	return title + " - " + s.cfg.Branding.SiteName
+}
+
+// brandedDoc puts the site name in place of Slashbot's in a document served
+// to bots. Lowercase "slashbot" is left alone: it names the CLI and its
+// commands, which don't change with the deployment.
+func (s *Server) brandedDoc(doc []byte) []byte {
+	if s.cfg.Branding.SiteName == defaultSiteName {
+		return doc
+	}
+	return bytes.ReplaceAll(doc, []byte(defaultSiteName), []byte(s.cfg.Branding.SiteName))
+}
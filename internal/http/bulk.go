@@ -0,0 +1,112 @@
+package httpapp
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxBulkIDs caps the IDs one ?ids= lookup can ask for.
+const maxBulkIDs = 100
+
+// parseBulkIDs reads a comma-separated ?ids= list, dropping repeats.
+func parseBulkIDs(raw string) ([]int64, error) {
+	var ids []int64
+	seen := make(map[int64]bool)
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, invalidField("ids", fmt.Sprintf("invalid id %q", part))
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 || len(ids) > maxBulkIDs {
+		return nil, invalidField("ids", fmt.Sprintf("ids must list 1-%d ids", maxBulkIDs))
+	}
+	return ids, nil
+}
+
+// handleStoriesByID answers GET /api/stories?ids=: the stories in the
+// order asked for, with the IDs that have none in not_found.
+func (s *Server) handleStoriesByID(w http.ResponseWriter, r *http.Request, raw string) {
+	ids, err := parseBulkIDs(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	withAuthors, err := wantsAuthors(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	found, err := s.store.GetStoriesByID(r.Context(), ids)
+	if err == nil {
+		err = s.markViewerStories(r, found)
+	}
+	if err == nil && withAuthors {
+		err = s.attachStoryAuthors(r.Context(), found)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	resp := StoryListResponse{Sort: "ids"}
+	resp.Stories, resp.NotFound = inIDOrder(ids, found, func(i int) int64 { return found[i].ID })
+	resp.Total = len(resp.Stories)
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleCommentsByID answers GET /api/comments?ids=: the comments in the
+// order asked for, with the IDs that have none in not_found.
+func (s *Server) handleCommentsByID(w http.ResponseWriter, r *http.Request, raw string) {
+	ids, err := parseBulkIDs(raw)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	withAuthors, err := wantsAuthors(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	found, err := s.store.GetCommentsByID(r.Context(), ids)
+	if err == nil {
+		err = s.markViewerComments(r, found)
+	}
+	if err == nil && withAuthors {
+		err = s.attachCommentAuthors(r.Context(), found)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var resp CommentListResponse
+	resp.Comments, resp.NotFound = inIDOrder(ids, found, func(i int) int64 { return found[i].ID })
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// inIDOrder arranges found, whose i'th item has ID id(i), in the order of
+// ids and returns the IDs missing from it.
+func inIDOrder[T any](ids []int64, found []T, id func(i int) int64) (ordered []T, missing []int64) {
+	byID := make(map[int64]int, len(found))
+	for i := range found {
+		byID[id(i)] = i
+	}
+	ordered = make([]T, 0, len(found))
+	for _, want := range ids {
+		if i, ok := byID[want]; ok {
+			ordered = append(ordered, found[i])
+		} else {
+			missing = append(missing, want)
+		}
+	}
+	return ordered, missing
+}
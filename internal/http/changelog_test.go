@@ -0,0 +1,35 @@
+package httpapp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestChangelogEntries(t *testing.T) {
+	seen := map[string]bool{}
+	for i, e := range apiChangelog {
+		if seen[e.ID] {
+			t.Fatalf("duplicate changelog id %q", e.ID)
+		}
+		seen[e.ID] = true
+		if !strings.HasPrefix(e.ID, e.Date+".") {
+			t.Fatalf("changelog id %q should start with its date %q", e.ID, e.Date)
+		}
+		if e.Title == "" || len(e.Endpoints) == 0 {
+			t.Fatalf("changelog entry %q needs a title and endpoints", e.ID)
+		}
+		if i > 0 && e.Date > apiChangelog[i-1].Date {
+			t.Fatalf("changelog entry %q is out of order", e.ID)
+		}
+	}
+
+	if entries, ok := changelogSince(latestChangelogID()); !ok || len(entries) != 0 {
+		t.Fatalf("expected nothing newer than the latest entry, got %v", entries)
+	}
+	if entries, ok := changelogSince(apiChangelog[1].ID); !ok || len(entries) != 1 || entries[0].ID != latestChangelogID() {
+		t.Fatalf("expected only the latest entry, got %v", entries)
+	}
+	if _, ok := changelogSince("1999-01-01.nope"); ok {
+		t.Fatal("expected an unknown id to be rejected")
+	}
+}
@@ -0,0 +1,124 @@
+package httpapp
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Comments per page of the recent comments feed, by default and at most.
+const (
+	defaultCommentFeedLimit = 30
+	maxCommentFeedLimit     = 100
+)
+
+// commentFeedSorts are the sorts the recent comments feed takes; the
+// first is the default.
+var commentFeedSorts = []string{"new", "top"}
+
+var errInvalidCommentFeedSort = invalidField("sort", "sort must be new or top")
+
+// handleListComments godoc
+//
+//	@Summary		List recent comments
+//	@Description	Page through visible comments across all stories, newest first by default. Filter by account, story or time range. For new, pass the response's cursor to get the next page; it is empty on the last one. For top, which has no cursor, step offset by limit. With ids, get up to 100 comments in the order asked for instead: IDs with no comment are listed in not_found rather than failing the request.
+//	@Tags			Comments
+//	@Produce		json
+//	@Param			sort		query		string				false	"Sort order"	Enums(new, top)	default(new)
+//	@Param			limit		query		int					false	"Comments per page"	default(30)	maximum(100)
+//	@Param			cursor		query		string				false	"Cursor from the previous page (new)"
+//	@Param			offset		query		int					false	"Comments to skip (without a cursor)"
+//	@Param			account_id	query		int					false	"Only comments by this account"
+//	@Param			story_id	query		int					false	"Only comments on this story"
+//	@Param			time		query		string				false	"Only comments posted since midnight UTC (today), in the last 7 days (week) or in the last month (month)"	Enums(today, week, month, all)
+//	@Param			ids			query		string				false	"Comma-separated comment IDs (up to 100) to get instead of the feed"
+//	@Param			include		query		string				false	"author embeds each comment's author (id, name, karma, bio)"	Enums(author)
+//	@Success		200			{object}	CommentListResponse	"A page of comments, or the comments found and not_found"
+//	@Failure		400			{object}	ErrorResponse		"Invalid sort, cursor, account_id, story_id, time, ids or include"
+//	@Router			/api/comments [get]
+func (s *Server) handleListComments(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if ids := q.Get("ids"); ids != "" {
+		s.handleCommentsByID(w, r, ids)
+		return
+	}
+	opts := store.CommentListOpts{Sort: q.Get("sort"), TimeRange: q.Get("time")}
+	if opts.Sort == "" {
+		opts.Sort = commentFeedSorts[0]
+	}
+	if !slices.Contains(commentFeedSorts, opts.Sort) {
+		writeError(w, http.StatusBadRequest, errInvalidCommentFeedSort)
+		return
+	}
+	if !validTimeRange(opts.TimeRange) {
+		writeError(w, http.StatusBadRequest, errInvalidTimeRange)
+		return
+	}
+	var err error
+	if opts.AccountID, err = optionalID(q, "account_id"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.StoryID, err = optionalID(q, "story_id"); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.Cursor, err = decodeCursor(q.Get("cursor")); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	withAuthors, err := wantsAuthors(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	limit := min(max(parseIntDefault(q.Get("limit"), defaultCommentFeedLimit), 1), maxCommentFeedLimit)
+	opts.Limit = limit + 1
+	if opts.Cursor.IsZero() {
+		opts.Offset = max(parseIntDefault(q.Get("offset"), 0), 0)
+	}
+
+	comments, total, err := s.store.ListComments(r.Context(), opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	more := len(comments) > limit
+	comments = comments[:min(len(comments), limit)]
+	if err := s.markViewerComments(r, comments); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if withAuthors {
+		if err := s.attachCommentAuthors(r.Context(), comments); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	resp := CommentListResponse{Comments: comments, Sort: opts.Sort, Total: total}
+	if resp.Comments == nil {
+		resp.Comments = []model.Comment{}
+	}
+	if more && opts.Sort == "new" {
+		resp.Cursor = nextCursorComments(comments)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// optionalID reads a positive ID from the query parameter name, nil if it
+// is absent.
+func optionalID(q url.Values, name string) (*int64, error) {
+	raw := q.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, invalidField(name, name+" must be a positive integer")
+	}
+	return &id, nil
+}
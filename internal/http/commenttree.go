@@ -0,0 +1,155 @@
+package httpapp
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// Comment tree bounds. depth counts nesting levels, the first being 1;
+// limit is the number of comments returned per parent.
+const (
+	defaultCommentDepth = 8
+	defaultCommentLimit = 100
+	maxCommentDepth     = 32
+	maxCommentLimit     = 500
+	// The HTML thread starts smaller and expands on demand.
+	pageCommentDepth = 6
+	pageCommentLimit = 50
+)
+
+// commentTreeQuery selects one page of a story's comment tree.
+type commentTreeQuery struct {
+	ParentID int64 // replies to this comment; 0 for top-level comments
+	Offset   int
+	Depth    int
+	Limit    int
+	// Comments scoring below Threshold are collapsed, except a pinned one.
+	Threshold int
+}
+
+// commentTreePage is a page of comment nodes. More counts siblings left
+// for the next page, fetched with Cursor.
+type commentTreePage struct {
+	Nodes  []model.CommentNode
+	More   int
+	Cursor string
+}
+
+// parseCommentTreeQuery reads parent, cursor, depth, limit and threshold,
+// taking the depth, limit and threshold of defaults when they are absent and
+// clamping depth and limit to the maximums.
+func parseCommentTreeQuery(q url.Values, defaults commentTreeQuery) (commentTreeQuery, error) {
+	ctq := commentTreeQuery{
+		Depth:     parseIntDefault(q.Get("depth"), defaults.Depth),
+		Limit:     parseIntDefault(q.Get("limit"), defaults.Limit),
+		Threshold: defaults.Threshold,
+	}
+	if raw := q.Get("threshold"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return commentTreeQuery{}, errors.New("invalid threshold")
+		}
+		ctq.Threshold = n
+	}
+	if raw := q.Get("parent"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || id <= 0 {
+			return commentTreeQuery{}, errors.New("invalid parent")
+		}
+		ctq.ParentID = id
+	}
+	offset, err := decodeReplyCursor(q.Get("cursor"))
+	if err != nil {
+		return commentTreeQuery{}, err
+	}
+	ctq.Offset = offset
+	ctq.Depth = min(max(ctq.Depth, 1), maxCommentDepth)
+	ctq.Limit = min(max(ctq.Limit, 1), maxCommentLimit)
+	return ctq, nil
+}
+
+// pageCommentTree nests comments, as ordered by the store, under their
+// parents and returns the page selected by q. Replies beyond q.Limit per
+// parent, or below q.Depth, are counted in their parent's MoreReplies
+// rather than returned, and comments below q.Threshold are collapsed.
+// ok is false if q.ParentID is not among comments.
+func pageCommentTree(comments []model.Comment, q commentTreeQuery) (page commentTreePage, ok bool) {
+	byParent := make(map[int64][]model.Comment)
+	var roots []model.Comment
+	found := q.ParentID == 0
+	for _, c := range comments {
+		if c.ID == q.ParentID {
+			found = true
+		}
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		byParent[*c.ParentID] = append(byParent[*c.ParentID], c)
+	}
+	if !found {
+		return commentTreePage{}, false
+	}
+
+	var list func(siblings []model.Comment, offset, depth int) commentTreePage
+	build := func(c model.Comment, depth int) model.CommentNode {
+		if c.Score < q.Threshold && !c.Pinned {
+			c.Collapsed = true
+			c.Text, c.Payload, c.PayloadSchema = "", nil, ""
+		}
+		node := model.CommentNode{Comment: c}
+		replies := byParent[c.ID]
+		if depth >= q.Depth {
+			node.MoreReplies = len(replies)
+			return node
+		}
+		children := list(replies, 0, depth+1)
+		node.Children, node.MoreReplies, node.RepliesCursor = children.Nodes, children.More, children.Cursor
+		return node
+	}
+	list = func(siblings []model.Comment, offset, depth int) commentTreePage {
+		offset = min(offset, len(siblings))
+		end := min(offset+q.Limit, len(siblings))
+		var p commentTreePage
+		for _, c := range siblings[offset:end] {
+			p.Nodes = append(p.Nodes, build(c, depth))
+		}
+		if p.More = len(siblings) - end; p.More > 0 {
+			p.Cursor = encodeReplyCursor(end)
+		}
+		return p
+	}
+
+	if q.ParentID != 0 {
+		return list(byParent[q.ParentID], q.Offset, 1), true
+	}
+	return list(roots, q.Offset, 1), true
+}
+
+// encodeReplyCursor renders a position among sibling comments as an
+// opaque URL-safe token.
+func encodeReplyCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("r1:" + strconv.Itoa(offset)))
+}
+
+func decodeReplyCursor(raw string) (int, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return 0, errors.New("invalid cursor")
+	}
+	rest, ok := strings.CutPrefix(string(b), "r1:")
+	n, err := strconv.Atoi(rest)
+	if !ok || err != nil || n < 0 {
+		return 0, errors.New("invalid cursor")
+	}
+	return n, nil
+}
@@ -0,0 +1,72 @@
+package httpapp
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+func TestPageCommentTree(t *testing.T) {
+	ptr := func(id int64) *int64 { return &id }
+	// 1 ─┬─ 3 ── 4 ── 5
+	//    ├─ 6
+	//    └─ 7
+	// 2
+	comments := []model.Comment{
+		{ID: 1}, {ID: 2},
+		{ID: 3, ParentID: ptr(1)}, {ID: 4, ParentID: ptr(3)}, {ID: 5, ParentID: ptr(4)},
+		{ID: 6, ParentID: ptr(1)}, {ID: 7, ParentID: ptr(1)},
+	}
+	ids := func(nodes []model.CommentNode) []int64 {
+		var out []int64
+		for _, n := range nodes {
+			out = append(out, n.Comment.ID)
+		}
+		return out
+	}
+
+	page, ok := pageCommentTree(comments, commentTreeQuery{Depth: 2, Limit: 2})
+	if !ok || len(page.Nodes) != 2 || page.More != 0 || page.Cursor != "" {
+		t.Fatalf("unexpected top level: %+v", page)
+	}
+	first := page.Nodes[0]
+	if got := ids(first.Children); len(got) != 2 || got[0] != 3 || got[1] != 6 || first.MoreReplies != 1 || first.RepliesCursor == "" {
+		t.Fatalf("expected replies 3 and 6 with one more, got %v more=%d", got, first.MoreReplies)
+	}
+	if deep := first.Children[0]; len(deep.Children) != 0 || deep.MoreReplies != 1 || deep.RepliesCursor != "" {
+		t.Fatalf("expected depth cut below comment 3, got %+v", deep)
+	}
+
+	offset, err := decodeReplyCursor(first.RepliesCursor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page, ok = pageCommentTree(comments, commentTreeQuery{ParentID: 1, Offset: offset, Depth: 2, Limit: 2})
+	if got := ids(page.Nodes); !ok || len(got) != 1 || got[0] != 7 || page.More != 0 {
+		t.Fatalf("expected the remaining reply 7, got %v more=%d", got, page.More)
+	}
+	page, ok = pageCommentTree(comments, commentTreeQuery{ParentID: 3, Depth: 5, Limit: 10})
+	if !ok || len(page.Nodes) != 1 || len(page.Nodes[0].Children) != 1 || page.Nodes[0].Children[0].Comment.ID != 5 {
+		t.Fatalf("expected the full subtree under 3, got %+v", page)
+	}
+	if _, ok := pageCommentTree(comments, commentTreeQuery{ParentID: 99, Depth: 1, Limit: 1}); ok {
+		t.Fatal("expected an unknown parent to be rejected")
+	}
+}
+
+func TestParseCommentTreeQuery(t *testing.T) {
+	q, err := parseCommentTreeQuery(url.Values{"depth": {"0"}, "limit": {"100000"}, "parent": {"7"}, "cursor": {encodeReplyCursor(5)}, "threshold": {"-5"}}, commentTreeQuery{Depth: 8, Limit: 100, Threshold: -2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Depth != 1 || q.Limit != maxCommentLimit || q.ParentID != 7 || q.Offset != 5 || q.Threshold != -5 {
+		t.Fatalf("unexpected query %+v", q)
+	}
+	for _, v := range []url.Values{{"parent": {"x"}}, {"cursor": {"bogus"}}, {"threshold": {"low"}}, {"cursor": {base64.RawURLEncoding.EncodeToString([]byte("r1:-1"))}}} {
+		if _, err := parseCommentTreeQuery(v, commentTreeQuery{Depth: 8, Limit: 100}); err == nil {
+			t.Fatalf("expected error for %v", v)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/config"
+	"github.com/alphabot-ai/slashbot/internal/crosspost"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Cross-posting runs every crossPostInterval and posts at most
+// crossPostBatch stories to each network per run, so a burst of popular
+// stories doesn't flood the accounts.
+const (
+	crossPostInterval = 5 * time.Minute
+	crossPostBatch    = 3
+)
+
+// Cross-post ledger listing sizes.
+const (
+	defaultCrossPostLimit = 50
+	maxCrossPostLimit     = 200
+)
+
+// newCrossPosters returns a poster for each network with credentials, and
+// the post template. It returns no posters when none are configured.
+func newCrossPosters(cfg config.CrossPost) ([]crosspost.Poster, *crosspost.Template, error) {
+	var posters []crosspost.Poster
+	if cfg.MastodonURL != "" || cfg.MastodonToken != "" {
+		if cfg.MastodonURL == "" || cfg.MastodonToken == "" {
+			return nil, nil, errors.New("mastodon cross-posting needs both an instance URL and a token")
+		}
+		if !linkableURL(cfg.MastodonURL) || strings.HasPrefix(cfg.MastodonURL, "/") {
+			return nil, nil, fmt.Errorf("invalid mastodon URL %q", cfg.MastodonURL)
+		}
+		posters = append(posters, &crosspost.Mastodon{Instance: cfg.MastodonURL, Token: cfg.MastodonToken})
+	}
+	if cfg.BlueskyHandle != "" || cfg.BlueskyPassword != "" {
+		if cfg.BlueskyHandle == "" || cfg.BlueskyPassword == "" {
+			return nil, nil, errors.New("bluesky cross-posting needs both a handle and an app password")
+		}
+		posters = append(posters, &crosspost.Bluesky{PDS: cfg.BlueskyPDS, Handle: cfg.BlueskyHandle, Password: cfg.BlueskyPassword})
+	}
+	if len(posters) == 0 {
+		return nil, nil, nil
+	}
+	if !linkableURL(cfg.BaseURL) || strings.HasPrefix(cfg.BaseURL, "/") {
+		return nil, nil, errors.New("cross-posting needs SLASHBOT_CROSSPOST_BASE_URL, the site's public http(s) URL")
+	}
+	tmpl, err := crosspost.ParseTemplate(cfg.Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	return posters, tmpl, nil
+}
+
+// RunCrossPosts posts stories that reach the score threshold to the
+// configured networks every five minutes until ctx is done. It does
+// nothing when no network is configured.
+func (s *Server) RunCrossPosts(ctx context.Context) {
+	if len(s.crossPosters) == 0 {
+		return
+	}
+	ticker := time.NewTicker(crossPostInterval)
+	defer ticker.Stop()
+	for {
+		s.crossPostOnce(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// crossPostOnce posts up to crossPostBatch stories to each network and
+// returns how many it posted. A network that fails is skipped until the
+// next run.
+func (s *Server) crossPostOnce(ctx context.Context) int {
+	cfg := s.cfg.CrossPost
+	posted := 0
+	for _, poster := range s.crossPosters {
+		network := poster.Network()
+		stories, err := s.store.ListCrossPostCandidates(ctx, network, cfg.MinScore, time.Now().Add(-cfg.MaxAge), crossPostBatch)
+		if s.reportStoreError("crosspost_candidates", err) {
+			continue
+		}
+		for _, story := range stories {
+			text, err := s.crossPostTemplate.Format(s.crossPostStory(story), poster.MaxLength())
+			if err != nil {
+				s.logger.Warn("cross-post skipped", "network", network, "story", story.ID, "err", err)
+				// Record it anyway, or it would be retried every run.
+				s.recordCrossPost(ctx, story.ID, network, "")
+				continue
+			}
+			remote, err := poster.Post(ctx, text)
+			if err != nil {
+				s.logger.Warn("cross-post failed", "network", network, "story", story.ID, "err", err)
+				break
+			}
+			s.recordCrossPost(ctx, story.ID, network, remote)
+			posted++
+		}
+	}
+	return posted
+}
+
+func (s *Server) recordCrossPost(ctx context.Context, storyID int64, network, remote string) {
+	err := s.store.RecordCrossPost(ctx, &model.CrossPost{StoryID: storyID, Network: network, RemoteURL: remote, PostedAt: time.Now()})
+	if errors.Is(err, store.ErrDuplicateKey) {
+		return
+	}
+	s.reportStoreError("crosspost_record", err)
+}
+
+// crossPostStory is what the post template sees of a story.
+func (s *Server) crossPostStory(story model.Story) crosspost.Story {
+	return crosspost.Story{
+		ID:       story.ID,
+		Title:    story.Title,
+		URL:      story.URL,
+		Domain:   story.Domain,
+		Score:    story.Score,
+		Comments: story.CommentCount,
+		Tags:     story.Tags,
+		Link:     fmt.Sprintf("%s/stories/%d", strings.TrimSuffix(s.cfg.CrossPost.BaseURL, "/"), story.ID),
+	}
+}
+
+// handleAdminListCrossPosts godoc
+//
+//	@Summary		List cross-posts (admin)
+//	@Description	The ledger of stories posted to Mastodon and Bluesky, newest first. A story the post template couldn't fit is recorded without a RemoteURL. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			limit			query		int						false	"Maximum posts"	default(50)	maximum(200)
+//	@Success		200				{object}	AdminCrossPostsResponse	"Cross-posts"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Router			/api/admin/crossposts [get]
+func (s *Server) handleAdminListCrossPosts(w http.ResponseWriter, r *http.Request) {
+	limit := min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultCrossPostLimit), 1), maxCrossPostLimit)
+	posts, err := s.store.ListCrossPosts(r.Context(), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if posts == nil {
+		posts = []model.CrossPost{}
+	}
+	networks := make([]string, len(s.crossPosters))
+	for i, p := range s.crossPosters {
+		networks[i] = p.Network()
+	}
+	writeJSON(w, http.StatusOK, AdminCrossPostsResponse{CrossPosts: posts, Networks: networks})
+}
@@ -0,0 +1,104 @@
+package httpapp
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+)
+
+// dbStatser is implemented by stores backed by database/sql.
+type dbStatser interface {
+	DBStats() sql.DBStats
+}
+
+var startedAt = time.Now()
+
+// requireAdmin checks the X-Admin-Secret header or admin dashboard session
+// and writes a 401 if neither is valid.
+func (s *Server) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if !s.isAdmin(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return false
+	}
+	return true
+}
+
+// handlePprof serves the net/http/pprof handlers under /debug/pprof/.
+func (s *Server) handlePprof(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAdmin(w, r) {
+		return
+	}
+	switch strings.TrimPrefix(r.URL.Path, "/debug/pprof/") {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Index(w, r)
+	}
+}
+
+// handleAdminDebug godoc
+//
+//	@Summary		Runtime diagnostics (admin)
+//	@Description	Goroutine counts, memory stats, DB pool stats, per-route request counts and in-process cache hit rates. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string				true	"Admin secret"
+//	@Success		200				{object}	AdminDebugResponse	"Runtime diagnostics"
+//	@Failure		401				{object}	ErrorResponse		"Invalid admin secret"
+//	@Router			/api/admin/debug [get]
+func (s *Server) handleAdminDebug(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	resp := AdminDebugResponse{
+		Version:       s.cfg.Version,
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: int64(time.Since(startedAt).Seconds()),
+		Goroutines:    runtime.NumGoroutine(),
+		NumCPU:        runtime.NumCPU(),
+		Memory: DebugMemory{
+			AllocBytes:      mem.Alloc,
+			TotalAllocBytes: mem.TotalAlloc,
+			SysBytes:        mem.Sys,
+			HeapObjects:     mem.HeapObjects,
+			NumGC:           mem.NumGC,
+			PauseTotalNs:    mem.PauseTotalNs,
+		},
+		StoreErrors: s.storeErrors.snapshot(),
+		Routes:      s.routeStats.snapshot(),
+		Caches:      map[string]DebugCache{"did_web": newDebugCache(auth.DIDCacheStats())},
+	}
+	if st, ok := s.store.(dbStatser); ok {
+		stats := st.DBStats()
+		resp.DB = &DebugDB{
+			OpenConnections:   stats.OpenConnections,
+			InUse:             stats.InUse,
+			Idle:              stats.Idle,
+			WaitCount:         stats.WaitCount,
+			WaitDurationMs:    stats.WaitDuration.Milliseconds(),
+			MaxIdleClosed:     stats.MaxIdleClosed,
+			MaxLifetimeClosed: stats.MaxLifetimeClosed,
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func newDebugCache(st auth.CacheStats) DebugCache {
+	c := DebugCache{Hits: st.Hits, Misses: st.Misses, Entries: st.Entries}
+	if total := st.Hits + st.Misses; total > 0 {
+		c.HitRate = float64(st.Hits) / float64(total)
+	}
+	return c
+}
@@ -0,0 +1,185 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Rules a story submission can be refused under, beyond plain validation.
+const (
+	ruleDomainBanned     = "domain_banned"
+	ruleDomainDailyLimit = "domain_daily_limit"
+)
+
+// domainLimitWindow is the rolling window StoryPerDomainPerDay counts over.
+const domainLimitWindow = 24 * time.Hour
+
+// storyRuleError refuses a story under a named rule, so a bot can tell a
+// banned domain from a throttled one and back off accordingly.
+type storyRuleError struct {
+	Rule       string
+	Domain     string
+	Status     int
+	Message    string
+	Limit      int
+	RetryAfter time.Duration
+}
+
+func (e *storyRuleError) Error() string { return e.Message }
+
+// checkDomainBan refuses a story whose URL's host is, or is under, a banned
+// domain.
+func (s *Server) checkDomainBan(ctx context.Context, urlStr string) error {
+	u, err := url.Parse(urlStr)
+	if err != nil || u.Hostname() == "" {
+		return nil
+	}
+	ban, err := s.store.FindDomainBan(ctx, u.Hostname())
+	if errors.Is(err, store.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	msg := "stories linking to " + ban.Domain + " are banned"
+	if ban.Reason != "" {
+		msg += ": " + ban.Reason
+	}
+	return &storyRuleError{Rule: ruleDomainBanned, Domain: ban.Domain, Status: http.StatusForbidden, Message: msg}
+}
+
+// checkDomainLimit refuses a story once its domain has had
+// StoryPerDomainPerDay stories in the last 24 hours, saying when the oldest
+// of them ages out.
+func (s *Server) checkDomainLimit(ctx context.Context, domain string) error {
+	limit := s.settings().RateLimits.StoryPerDomainPerDay
+	if limit <= 0 || domain == "" {
+		return nil
+	}
+	now := time.Now()
+	count, oldest, err := s.store.CountDomainStoriesSince(ctx, domain, now.Add(-domainLimitWindow))
+	if err != nil {
+		return err
+	}
+	if count < limit {
+		return nil
+	}
+	return &storyRuleError{
+		Rule:       ruleDomainDailyLimit,
+		Domain:     domain,
+		Status:     http.StatusTooManyRequests,
+		Message:    fmt.Sprintf("%s has reached its limit of %d stories per day", domain, limit),
+		Limit:      limit,
+		RetryAfter: max(oldest.Add(domainLimitWindow).Sub(now), time.Second),
+	}
+}
+
+// writeStoryError answers a failed story submission: rule violations with
+// their status and rule name, anything else as a validation error.
+func writeStoryError(w http.ResponseWriter, err error) {
+	var rule *storyRuleError
+	if !errors.As(err, &rule) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	body := StoryRuleResponse{Error: rule.Message, Code: rule.Rule, Rule: rule.Rule, Domain: rule.Domain}
+	if rule.Rule == ruleDomainDailyLimit {
+		retry := int(rule.RetryAfter.Seconds())
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		body.Limit = rule.Limit
+		body.RetryAfter = &retry
+	}
+	writeJSON(w, rule.Status, body)
+}
+
+// handleAdminListDomainBans godoc
+//
+//	@Summary		List banned domains (admin)
+//	@Description	List domains new stories may not link to. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Success		200				{object}	AdminDomainBansResponse	"Banned domains"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Router			/api/admin/domain-bans [get]
+func (s *Server) handleAdminListDomainBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := s.store.ListDomainBans(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if bans == nil {
+		bans = []model.DomainBan{}
+	}
+	writeJSON(w, http.StatusOK, AdminDomainBansResponse{DomainBans: bans})
+}
+
+// handleAdminBanDomain godoc
+//
+//	@Summary		Ban a domain (admin)
+//	@Description	Refuse new stories linking to a domain or any of its subdomains. Existing stories are kept. Banning a domain again replaces the reason. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string								true	"Admin secret"
+//	@Param			ban				body		object{domain=string,reason=string}	true	"Domain ban"
+//	@Success		200				{object}	AdminDomainBannedResponse			"Domain banned"
+//	@Failure		400				{object}	ErrorResponse						"Invalid domain"
+//	@Failure		401				{object}	ErrorResponse						"Invalid admin secret"
+//	@Router			/api/admin/domain-bans [post]
+func (s *Server) handleAdminBanDomain(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Domain string `json:"domain"`
+		Reason string `json:"reason"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	domain := strings.TrimSpace(req.Domain)
+	if domain == "" || strings.ContainsAny(domain, "/: ") {
+		writeError(w, http.StatusBadRequest, errors.New("domain must be a bare host name"))
+		return
+	}
+	ban := model.DomainBan{Domain: domain, Reason: strings.TrimSpace(req.Reason), CreatedAt: time.Now()}
+	if err := s.store.BanDomain(r.Context(), &ban); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "ban-domain", "domain", 0, ban.Domain)
+	writeJSON(w, http.StatusOK, AdminDomainBannedResponse{OK: true, Domain: ban.Domain})
+}
+
+// handleAdminUnbanDomain godoc
+//
+//	@Summary		Unban a domain (admin)
+//	@Description	Allow stories linking to a banned domain again. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string			true	"Admin secret"
+//	@Param			domain			path		string			true	"Domain"
+//	@Success		200				{object}	OKResponse		"Domain unbanned"
+//	@Failure		401				{object}	ErrorResponse	"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse	"Domain not banned"
+//	@Router			/api/admin/domain-bans/{domain} [delete]
+func (s *Server) handleAdminUnbanDomain(w http.ResponseWriter, r *http.Request, domain string) {
+	if err := s.store.UnbanDomain(r.Context(), domain); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("domain not banned"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "unban-domain", "domain", 0, domain)
+	writeJSON(w, http.StatusOK, okResponse)
+}
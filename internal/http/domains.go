@@ -0,0 +1,140 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Stories per page of a domain listing, by default and at most. The store
+// caps listings at 50, and one more is fetched to detect a next page.
+const (
+	defaultDomainLimit = 30
+	maxDomainLimit     = 40
+)
+
+var errBadDomainQuery = errors.New("invalid domain listing query")
+
+// domainListing is one page of a domain's stories with its totals.
+type domainListing struct {
+	Stats   model.DomainStats
+	Stories []model.Story
+	Sort    string
+	Cursor  string
+}
+
+// domainListing loads the stories linking to domain, newest first unless
+// the request asks for sort=top. It returns store.ErrNotFound if the
+// domain has no visible stories, and errBadDomainQuery wrapping the
+// problem with sort or cursor.
+func (s *Server) domainListing(r *http.Request, domain string) (domainListing, error) {
+	q := r.URL.Query()
+	sort := q.Get("sort")
+	if sort == "" {
+		sort = "new"
+	}
+	if sort != "new" && sort != "top" {
+		return domainListing{}, fmt.Errorf("%w: sort must be new or top", errBadDomainQuery)
+	}
+	cursor, err := decodeCursor(q.Get("cursor"))
+	if err != nil {
+		return domainListing{}, fmt.Errorf("%w: %v", errBadDomainQuery, err)
+	}
+	sandbox := wantsSandbox(r)
+	stats, err := s.store.DomainStats(r.Context(), domain, sandbox)
+	if err != nil {
+		return domainListing{}, err
+	}
+	// One extra story tells whether there is another page.
+	limit := min(max(parseIntDefault(q.Get("limit"), defaultDomainLimit), 1), maxDomainLimit)
+	stories, _, err := s.store.ListStories(r.Context(), store.StoryListOpts{
+		Sort:    sort,
+		Limit:   limit + 1,
+		Cursor:  cursor,
+		Domain:  stats.Domain,
+		Sandbox: sandbox,
+	})
+	if err != nil {
+		return domainListing{}, err
+	}
+	listing := domainListing{Stats: stats, Stories: stories, Sort: sort}
+	if len(stories) > limit {
+		listing.Stories = stories[:limit]
+		if sort == "new" {
+			listing.Cursor = nextCursorStories(listing.Stories)
+		}
+	}
+	return listing, nil
+}
+
+func writeDomainError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("no stories from this domain"))
+	case errors.Is(err, errBadDomainQuery):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// handleDomain godoc
+//
+//	@Summary		Stories from a domain
+//	@Description	List the stories linking to a site, with the number submitted, their comments and average score. Any host under the site's registrable domain works, so blog.example.co.uk lists example.co.uk. The next page of the new sort is fetched by passing cursor.
+//	@Tags			Stories
+//	@Produce		json
+//	@Param			domain	path		string			true	"Domain or host"
+//	@Param			sort	query		string			false	"Sort order"		Enums(new, top)	default(new)
+//	@Param			limit	query		int				false	"Maximum stories"	default(30)		maximum(40)
+//	@Param			cursor	query		string			false	"Cursor from the previous page"
+//	@Param			sandbox	query		bool			false	"List sandbox stories instead"
+//	@Success		200		{object}	DomainResponse	"Domain stats and stories"
+//	@Failure		400		{object}	ErrorResponse	"Invalid sort or cursor"
+//	@Failure		404		{object}	ErrorResponse	"No stories from this domain"
+//	@Router			/api/domains/{domain} [get]
+func (s *Server) handleDomain(w http.ResponseWriter, r *http.Request, domain string) {
+	listing, err := s.domainListing(r, domain)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, DomainResponse{
+		Domain:  listing.Stats,
+		Stories: listing.Stories,
+		Sort:    listing.Sort,
+		Cursor:  listing.Cursor,
+	})
+}
+
+// handleDomainPage serves /from/{domain}, the HTML listing of a site's
+// stories.
+func (s *Server) handleDomainPage(w http.ResponseWriter, r *http.Request) {
+	domain := strings.TrimPrefix(r.URL.Path, "/from/")
+	if wantsJSON(r) {
+		s.handleDomain(w, r, domain)
+		return
+	}
+	listing, err := s.domainListing(r, domain)
+	if err != nil {
+		writeDomainError(w, err)
+		return
+	}
+	data := s.baseTemplateDataWithAuth(r, s.pageTitle("Stories from "+listing.Stats.Domain))
+	data["Domain"] = listing.Stats
+	data["Stories"] = listing.Stories
+	data["Sort"] = listing.Sort
+	if listing.Cursor != "" {
+		data["NextURL"] = fmt.Sprintf("/from/%s?cursor=%s", url.PathEscape(listing.Stats.Domain), url.QueryEscape(listing.Cursor))
+	}
+	data["CanonicalURL"] = "https://slashbot.net/from/" + url.PathEscape(listing.Stats.Domain)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.Domain.ExecuteTemplate(w, "layout", data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
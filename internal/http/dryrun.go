@@ -0,0 +1,51 @@
+package httpapp
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// headerDryRun asks for a dry run of POST /api/stories, /api/comments or
+// /api/votes: the request is checked and answered as usual, but nothing is
+// stored. Responses to dry runs carry the header too.
+const headerDryRun = "X-Dry-Run"
+
+// isDryRun reports whether r asks for a dry run, by header or ?dry_run=.
+func isDryRun(r *http.Request) bool {
+	v := r.Header.Get(headerDryRun)
+	if v == "" {
+		v = r.URL.Query().Get("dry_run")
+	}
+	dry, _ := strconv.ParseBool(v)
+	return dry
+}
+
+// markDryRun tags the response to a dry run, including error responses,
+// so a client can't mistake it for a real write.
+func markDryRun(w http.ResponseWriter, r *http.Request) {
+	if isDryRun(r) {
+		w.Header().Set(headerDryRun, "true")
+	}
+}
+
+// dryRunVote answers a dry-run vote as handleCreateVote would, without
+// recording it or touching scores and karma.
+func (s *Server) dryRunVote(w http.ResponseWriter, r *http.Request, vote model.Vote) {
+	lookup := s.store.GetUserVotesForStories
+	if vote.TargetType == "comment" {
+		lookup = s.store.GetUserVotesForComments
+	}
+	existing, err := lookup(r.Context(), vote.AccountID, []int64{vote.TargetID})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if existing[vote.TargetID] != nil {
+		writeError(w, http.StatusConflict, store.ErrDuplicateVote)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
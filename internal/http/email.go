@@ -0,0 +1,345 @@
+package httpapp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/config"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// mailgunMaxSkew is how far a webhook's timestamp may be from now. Each
+// signature token is accepted once within it.
+const mailgunMaxSkew = 15 * time.Minute
+
+// inboundMailMaxMemory is how much of a Mailgun post is parsed in memory;
+// larger parts spill to temporary files. The body as a whole is capped by
+// the route's entry in routeWriteLimits.
+const inboundMailMaxMemory = 1 << 20
+
+var errInboundEmailDisabled = errors.New("inbound email is not enabled on this server")
+
+// emailURL finds links in an email body. Trailing punctuation is trimmed
+// separately.
+var emailURL = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// checkInboundEmail rejects a half-configured gateway.
+func checkInboundEmail(cfg config.InboundEmail) error {
+	if (cfg.Domain == "") != (cfg.MailgunSigningKey == "") {
+		return errors.New("inbound email needs both SLASHBOT_INBOUND_EMAIL_DOMAIN and SLASHBOT_MAILGUN_SIGNING_KEY")
+	}
+	return nil
+}
+
+func (s *Server) inboundEmailEnabled() bool {
+	return s.cfg.InboundEmail.MailgunSigningKey != ""
+}
+
+func hashEmailToken(token string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(token)))
+	return hex.EncodeToString(sum[:])
+}
+
+// handleGetEmailSender godoc
+//
+//	@Summary		Get email sender
+//	@Description	Get the address the authenticated account submits stories from by email. The submission address is only shown when the sender is set.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.EmailSender
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"No sender set"
+//	@Failure		501	{object}	ErrorResponse	"Inbound email not enabled"
+//	@Router			/api/me/email [get]
+func (s *Server) handleGetEmailSender(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	if !s.inboundEmailEnabled() {
+		writeError(w, http.StatusNotImplemented, errInboundEmailDisabled)
+		return
+	}
+	sender, err := s.store.GetEmailSender(r.Context(), *verified.AccountID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, sender)
+}
+
+// handleSetEmailSender godoc
+//
+//	@Summary		Set email sender
+//	@Description	Register the address the authenticated account sends stories from, and get a secret submission address to send them to. Mail to the submission address from this sender becomes a story: the subject is the title and the first URL in the body is the link, or the body is the text if it has none. Setting a sender again replaces it and issues a new submission address.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			sender	body		object{address=string}	true	"Sender address"
+//	@Success		200		{object}	EmailSenderSetResponse	"Sender and submission address"
+//	@Failure		400		{object}	ErrorResponse			"Invalid address"
+//	@Failure		401		{object}	ErrorResponse			"Authentication required"
+//	@Failure		501		{object}	ErrorResponse			"Inbound email not enabled"
+//	@Router			/api/me/email [put]
+func (s *Server) handleSetEmailSender(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	if !s.inboundEmailEnabled() {
+		writeError(w, http.StatusNotImplemented, errInboundEmailDisabled)
+		return
+	}
+	var req struct {
+		Address string `json:"address"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(req.Address))
+	if err != nil || addr.Name != "" {
+		writeError(w, http.StatusBadRequest, errors.New("address must be a plain email address"))
+		return
+	}
+	secret := make([]byte, 12)
+	if _, err := rand.Read(secret); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	token := hex.EncodeToString(secret)
+	sender := model.EmailSender{AccountID: *verified.AccountID, Address: strings.ToLower(addr.Address), CreatedAt: time.Now()}
+	if err := s.store.SetEmailSender(r.Context(), &sender, hashEmailToken(token)); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, EmailSenderSetResponse{
+		Address:  sender.Address,
+		SubmitTo: fmt.Sprintf("submit+%s@%s", token, s.cfg.InboundEmail.Domain),
+		Note:     "Keep submit_to secret: it is shown once, and mail to it from your address posts as you.",
+	})
+}
+
+// handleDeleteEmailSender godoc
+//
+//	@Summary		Remove email sender
+//	@Description	Stop accepting stories by email for the authenticated account.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	OKResponse		"Sender removed"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"No sender set"
+//	@Router			/api/me/email [delete]
+func (s *Server) handleDeleteEmailSender(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	if err := s.store.DeleteEmailSender(r.Context(), *verified.AccountID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleInboundMailgun godoc
+//
+//	@Summary		Inbound email webhook
+//	@Description	Mailgun route target that turns an email into a story. The request must carry a valid Mailgun signature. Mail is accepted when it is sent to an account's submission address from that account's sender address. Mail that can never be posted is answered with 406 so Mailgun doesn't retry it; rate limits answer 429 so it does.
+//	@Tags			Stories
+//	@Accept			mpfd
+//	@Produce		json
+//	@Param			timestamp	formData	string	true	"Mailgun signature timestamp"
+//	@Param			token		formData	string	true	"Mailgun signature token"
+//	@Param			signature	formData	string	true	"Mailgun signature"
+//	@Param			recipient	formData	string	true	"Submission address"
+//	@Param			from		formData	string	true	"From header"
+//	@Param			subject		formData	string	true	"Story title"
+//	@Param			body-plain	formData	string	false	"Plain-text body"
+//	@Success		200			{object}	model.Story
+//	@Failure		401			{object}	ErrorResponse		"Invalid signature"
+//	@Failure		406			{object}	ErrorResponse		"Rejected email"
+//	@Failure		429			{object}	RateLimitResponse	"Rate limited"
+//	@Failure		501			{object}	ErrorResponse		"Inbound email not enabled"
+//	@Router			/api/inbound/mailgun [post]
+func (s *Server) handleInboundMailgun(w http.ResponseWriter, r *http.Request) {
+	if !s.inboundEmailEnabled() {
+		writeError(w, http.StatusNotImplemented, errInboundEmailDisabled)
+		return
+	}
+	// ParseMultipartForm drops ParseForm's error for url-encoded bodies, so
+	// an oversized one would surface as a bad signature instead of a 413.
+	if err := r.ParseForm(); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if err := r.ParseMultipartForm(inboundMailMaxMemory); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		writeBodyError(w, err)
+		return
+	}
+	if err := s.verifyMailgunSignature(r.PostFormValue("timestamp"), r.PostFormValue("token"), r.PostFormValue("signature")); err != nil {
+		writeError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	sender, err := s.emailSenderFor(r)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			s.logger.Info("inbound email rejected", "recipient", r.PostFormValue("recipient"), "from", r.PostFormValue("from"))
+			writeError(w, http.StatusNotAcceptable, errors.New("unknown submission address or sender"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	accountID := sender.AccountID
+	frozen, err := s.store.IsAccountFrozen(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	banned, err := s.store.IsAccountBanned(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if frozen || banned {
+		reason := errAccountBanned
+		if frozen {
+			reason = errAccountFrozen
+		}
+		writeError(w, http.StatusNotAcceptable, reason)
+		return
+	}
+	if limit := s.settings().RateLimits.StoryPerMinute; limit > 0 {
+		if ok, retry := s.limiter.Allow(fmt.Sprintf("story:email:%d", accountID), limit, time.Minute); !ok {
+			writeRateLimit(w, retry)
+			return
+		}
+	}
+	if !s.allowSchedule(w, r, accountID) {
+		return
+	}
+
+	title, link, text := storyFromEmail(r.PostFormValue("subject"), emailBody(r))
+	story, duplicate, err := s.createStoryFromInput(r.Context(), accountID, title, link, text, nil, false)
+	if err != nil {
+		var rule *storyRuleError
+		if errors.As(err, &rule) && rule.Status == http.StatusTooManyRequests {
+			writeStoryError(w, err)
+			return
+		}
+		writeError(w, http.StatusNotAcceptable, err)
+		return
+	}
+	s.reportStoreError("email_sender_touch", s.store.TouchEmailSender(r.Context(), accountID, time.Now()))
+	if duplicate {
+		writeJSON(w, http.StatusOK, DuplicateStoryResponse{story, story.ID})
+		return
+	}
+	s.logger.Info("story submitted by email", "account_id", accountID, "story", story.ID)
+	writeJSON(w, http.StatusOK, story)
+}
+
+// verifyMailgunSignature checks the webhook signature, an HMAC-SHA256 of
+// timestamp and token under the signing key, and refuses replays.
+func (s *Server) verifyMailgunSignature(timestamp, token, signature string) error {
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || token == "" {
+		return errors.New("missing webhook signature")
+	}
+	if skew := time.Since(time.Unix(sec, 0)); skew > mailgunMaxSkew || skew < -mailgunMaxSkew {
+		return errors.New("webhook timestamp out of range")
+	}
+	m := hmac.New(sha256.New, []byte(s.cfg.InboundEmail.MailgunSigningKey))
+	m.Write([]byte(timestamp + token))
+	if !hmac.Equal([]byte(hex.EncodeToString(m.Sum(nil))), []byte(strings.ToLower(signature))) {
+		return errors.New("invalid webhook signature")
+	}
+	if ok, _ := s.limiter.Allow("mailgun:"+token, 1, 2*mailgunMaxSkew); !ok {
+		return errors.New("webhook already received")
+	}
+	return nil
+}
+
+// emailSenderFor finds the sender whose submission address the mail was
+// sent to, and checks the mail came from its address. Either mismatch is
+// ErrNotFound.
+func (s *Server) emailSenderFor(r *http.Request) (model.EmailSender, error) {
+	rcpt, err := mail.ParseAddress(r.PostFormValue("recipient"))
+	if err != nil {
+		return model.EmailSender{}, store.ErrNotFound
+	}
+	local, domain, _ := strings.Cut(rcpt.Address, "@")
+	_, token, ok := strings.Cut(local, "+")
+	if !ok || token == "" || !strings.EqualFold(domain, s.cfg.InboundEmail.Domain) {
+		return model.EmailSender{}, store.ErrNotFound
+	}
+	sender, err := s.store.GetEmailSenderByToken(r.Context(), hashEmailToken(token))
+	if err != nil {
+		return model.EmailSender{}, err
+	}
+	from := r.PostFormValue("from")
+	if from == "" {
+		from = r.PostFormValue("sender")
+	}
+	addr, err := mail.ParseAddress(from)
+	if err != nil || !strings.EqualFold(addr.Address, sender.Address) {
+		return model.EmailSender{}, store.ErrNotFound
+	}
+	return sender, nil
+}
+
+// emailBody prefers Mailgun's body with quoted replies and signature
+// removed.
+func emailBody(r *http.Request) string {
+	if body := strings.TrimSpace(r.PostFormValue("stripped-text")); body != "" {
+		return body
+	}
+	return strings.TrimSpace(r.PostFormValue("body-plain"))
+}
+
+// storyFromEmail maps an email to a story: the subject is the title and
+// the first URL in the body the link. Without a URL the body is the text.
+func storyFromEmail(subject, body string) (title, link, text string) {
+	title = strings.Join(strings.Fields(subject), " ")
+	if found := emailURL.FindString(body); found != "" {
+		return title, strings.TrimRight(found, ".,;:!?)]'"), ""
+	}
+	return title, "", body
+}
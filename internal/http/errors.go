@@ -0,0 +1,144 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Error codes. Every error body carries one in "code": clients branch on
+// the code, while the "error" message is for people and may be reworded.
+// Codes are part of the API contract; add new ones rather than renaming,
+// and list each in errorCodes and in ErrorResponse's enums tag.
+const (
+	codeBadRequest        = "bad_request"
+	codeValidationFailed  = "validation_failed"
+	codeContentRejected   = "content_rejected"
+	codeUnsupportedAPI    = "unsupported_api_version"
+	codeUnauthorized      = "unauthorized"
+	codeForbidden         = "forbidden"
+	codeInsufficientScope = "insufficient_scope"
+	codeEditWindowExpired = "edit_window_expired"
+	codeLockWindowExpired = "lock_window_expired"
+	codeModeratorLock     = "moderator_lock"
+	codeStoryLocked       = "story_locked"
+	codeStoryHidden       = "story_hidden"
+	codeAccountBanned     = "account_banned"
+	codeAccountFrozen     = "account_frozen"
+	codeSandboxDisabled   = "sandbox_disabled"
+	codeSandboxMismatch   = "sandbox_mismatch"
+	codeInvalidInvite     = "invalid_invite"
+	codeDomainBanned      = ruleDomainBanned
+	codeNotFound          = "not_found"
+	codeMethodNotAllowed  = "method_not_allowed"
+	codeNotAcceptable     = "not_acceptable"
+	codeConflict          = "conflict"
+	codeDuplicateVote     = "duplicate_vote"
+	codeDuplicateFlag     = "duplicate_flag"
+	codeDuplicateKey      = "duplicate_key"
+	codeNameTaken         = "name_taken"
+	codePayloadTooLarge   = "payload_too_large"
+	codeRateLimited       = "rate_limited"
+	codeDomainDailyLimit  = ruleDomainDailyLimit
+	codeQuietHours        = "quiet_hours"
+	codeReplyLoop         = "reply_loop"
+	codeInternal          = "internal_error"
+	codeNotImplemented    = "not_implemented"
+	codeUpstreamFailed    = "upstream_failed"
+)
+
+// errorCodes lists every code an error response can carry.
+var errorCodes = []string{
+	codeBadRequest, codeValidationFailed, codeContentRejected, codeUnsupportedAPI,
+	codeUnauthorized,
+	codeForbidden, codeInsufficientScope, codeEditWindowExpired, codeLockWindowExpired,
+	codeModeratorLock, codeStoryLocked, codeStoryHidden, codeAccountBanned,
+	codeAccountFrozen, codeSandboxDisabled, codeSandboxMismatch, codeInvalidInvite,
+	codeDomainBanned,
+	codeNotFound, codeMethodNotAllowed, codeNotAcceptable,
+	codeConflict, codeDuplicateVote, codeDuplicateFlag, codeDuplicateKey, codeNameTaken,
+	codePayloadTooLarge,
+	codeRateLimited, codeDomainDailyLimit, codeQuietHours, codeReplyLoop,
+	codeInternal, codeNotImplemented, codeUpstreamFailed,
+}
+
+// apiError is an error with a more specific code than its status implies,
+// and for validation failures the fields at fault.
+type apiError struct {
+	code    string
+	msg     string
+	details map[string]string
+}
+
+func (e *apiError) Error() string { return e.msg }
+
+// codedError returns an error that writeError reports with code.
+func codedError(code, msg string) error {
+	return &apiError{code: code, msg: msg}
+}
+
+// invalidField returns a validation_failed error for one request field.
+func invalidField(field, msg string) error {
+	return &apiError{code: codeValidationFailed, msg: msg, details: map[string]string{field: msg}}
+}
+
+// sentinelCodes gives the codes of errors defined with errors.New, here
+// and in the store.
+var sentinelCodes = []struct {
+	err  error
+	code string
+}{
+	{store.ErrDuplicateVote, codeDuplicateVote},
+	{store.ErrDuplicateFlag, codeDuplicateFlag},
+	{store.ErrDuplicateKey, codeDuplicateKey},
+	{store.ErrDuplicateName, codeNameTaken},
+	{store.ErrNotFound, codeNotFound},
+	{errStoryLocked, codeStoryLocked},
+	{errStoryHidden, codeStoryHidden},
+	{errLockWindow, codeLockWindowExpired},
+	{errModeratorLock, codeModeratorLock},
+	{errAccountBanned, codeAccountBanned},
+	{errAccountFrozen, codeAccountFrozen},
+	{errSandboxDisabled, codeSandboxDisabled},
+	{errSandboxMismatch, codeSandboxMismatch},
+	{errInvalidInvite, codeInvalidInvite},
+	{errReplyLoop, codeReplyLoop},
+}
+
+// statusCodes is the code of an error nothing more specific is known
+// about.
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            codeBadRequest,
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeForbidden,
+	http.StatusNotFound:              codeNotFound,
+	http.StatusMethodNotAllowed:      codeMethodNotAllowed,
+	http.StatusNotAcceptable:         codeNotAcceptable,
+	http.StatusConflict:              codeConflict,
+	http.StatusRequestEntityTooLarge: codePayloadTooLarge,
+	http.StatusTooManyRequests:       codeRateLimited,
+	http.StatusNotImplemented:        codeNotImplemented,
+	http.StatusBadGateway:            codeUpstreamFailed,
+}
+
+// errorCode returns the code, and any field details, for err answered
+// with status.
+func errorCode(status int, err error) (string, map[string]string) {
+	var coded *apiError
+	if errors.As(err, &coded) {
+		return coded.code, coded.details
+	}
+	for _, s := range sentinelCodes {
+		if errors.Is(err, s.err) {
+			return s.code, nil
+		}
+	}
+	if code, ok := statusCodes[status]; ok {
+		return code, nil
+	}
+	if status >= 500 {
+		return codeInternal, nil
+	}
+	return codeBadRequest, nil
+}
@@ -0,0 +1,83 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+var errAccountFrozen = errors.New("account frozen by its operator; a signed POST /api/me/unfreeze lifts it")
+
+// requireSignedAccount authenticates a request that must be signed with
+// one of the account's keys; bearer tokens and API keys are refused.
+func (s *Server) requireSignedAccount(w http.ResponseWriter, r *http.Request) (auth.Verified, bool) {
+	if !isSignedRequest(r) {
+		writeError(w, http.StatusUnauthorized, errors.New("this action requires a signed request"))
+		return auth.Verified{}, false
+	}
+	verified, ok := s.requireCredentials(w, r)
+	if !ok {
+		return auth.Verified{}, false
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return auth.Verified{}, false
+	}
+	return verified, true
+}
+
+// handleFreeze godoc
+//
+//	@Summary		Freeze own account
+//	@Description	Operator kill switch: immediately suspend all writes from the account and revoke its bearer tokens, JWTs and API keys; they stay invalid after unfreezing. Must be a signed request (X-Signature headers) made with one of the account's keys.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Success		200	{object}	FreezeResponse	"Account frozen"
+//	@Failure		401	{object}	ErrorResponse	"Signed request required"
+//	@Router			/api/me/freeze [post]
+func (s *Server) handleFreeze(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireSignedAccount(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.FreezeAccount(r.Context(), *verified.AccountID, time.Now()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := s.auth.LoadRevocations(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.Warn("account frozen by operator", "account_id", *verified.AccountID, "key_id", verified.KeyID)
+	writeJSON(w, http.StatusOK, FreezeResponse{OK: true, Frozen: true})
+}
+
+// handleUnfreeze godoc
+//
+//	@Summary		Unfreeze own account
+//	@Description	Lift an operator freeze. Must be a signed request made with one of the account's keys.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Success		200	{object}	FreezeResponse	"Account unfrozen"
+//	@Failure		401	{object}	ErrorResponse	"Signed request required"
+//	@Failure		404	{object}	ErrorResponse	"Account not frozen"
+//	@Router			/api/me/unfreeze [post]
+func (s *Server) handleUnfreeze(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireSignedAccount(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.UnfreezeAccount(r.Context(), *verified.AccountID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("account not frozen"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.Info("account unfrozen by operator", "account_id", *verified.AccountID, "key_id", verified.KeyID)
+	writeJSON(w, http.StatusOK, FreezeResponse{OK: true})
+}
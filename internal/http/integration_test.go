@@ -1,14 +1,29 @@
 package httpapp
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"html"
 	"io"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"testing"
@@ -17,14 +32,19 @@ import (
 	"github.com/alphabot-ai/slashbot/internal/auth"
 	"github.com/alphabot-ai/slashbot/internal/client"
 	"github.com/alphabot-ai/slashbot/internal/config"
+	"github.com/alphabot-ai/slashbot/internal/conformance"
 	"github.com/alphabot-ai/slashbot/internal/model"
 	"github.com/alphabot-ai/slashbot/internal/rate"
+	"github.com/alphabot-ai/slashbot/internal/routes"
+	"github.com/alphabot-ai/slashbot/internal/store"
 	"github.com/alphabot-ai/slashbot/internal/store/sqlite"
 )
 
 type testClient struct {
 	server *httptest.Server
 	client *http.Client
+	store  *sqlite.Store
+	app    *Server
 }
 
 func newTestClient(t *testing.T) *testClient {
@@ -42,7 +62,7 @@ func newTestClient(t *testing.T) *testClient {
 func newTestClientWithConfig(t *testing.T, cfg config.Config) *testClient {
 	t.Helper()
 	if cfg.HashSecret == "" {
-		cfg.HashSecret = "test-hash"
+		cfg.HashSecret = "test-hash-secret-long-enough-for-hs256"
 	}
 	if cfg.AdminSecret == "" {
 		cfg.AdminSecret = "admin"
@@ -60,6 +80,15 @@ func newTestClientWithConfig(t *testing.T, cfg config.Config) *testClient {
 	}
 	limiter := rate.NewMemory()
 	authSvc := auth.NewService(st, cfg.TokenTTL, cfg.ChallengeTTL)
+	if cfg.TokenFormat == "jwt" {
+		signer, err := auth.NewJWTSigner(cfg.JWTPrivateKey, cfg.HashSecret)
+		if err != nil {
+			t.Fatalf("jwt signer: %v", err)
+		}
+		if err := authSvc.EnableJWT(context.Background(), signer); err != nil {
+			t.Fatalf("enable jwt: %v", err)
+		}
+	}
 	server, err := NewServer(st, authSvc, limiter, cfg)
 	if err != nil {
 		t.Fatalf("new server: %v", err)
@@ -69,7 +98,23 @@ func newTestClientWithConfig(t *testing.T, cfg config.Config) *testClient {
 		ts.Close()
 		_ = st.Close()
 	})
-	return &testClient{server: ts, client: ts.Client()}
+	client := *ts.Client()
+	client.Transport = apiVersionTransport{client.Transport}
+	return &testClient{server: ts, client: &client, store: st, app: server}
+}
+
+// apiVersionTransport asks for the latest API version unless a request
+// names one.
+type apiVersionTransport struct {
+	base http.RoundTripper
+}
+
+func (t apiVersionTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Header.Get(headerAPIVersion) == "" {
+		r = r.Clone(r.Context())
+		r.Header.Set(headerAPIVersion, strconv.Itoa(apiVersionLatest))
+	}
+	return t.base.RoundTrip(r)
 }
 
 func (c *testClient) postJSON(t *testing.T, path string, body any, headers map[string]string) *http.Response {
@@ -106,6 +151,16 @@ func (c *testClient) get(t *testing.T, path string, headers map[string]string) *
 	return resp
 }
 
+// allowLoopbackTargets lets account-supplied URLs point at httptest
+// servers for the rest of the test.
+func allowLoopbackTargets(t *testing.T) {
+	prevWebhook, prevFederation, prevAllow := webhookClient, federationClient, allowPrivateTargets
+	webhookClient = &http.Client{Timeout: 10 * time.Second}
+	federationClient = &http.Client{Timeout: 10 * time.Second}
+	allowPrivateTargets = true
+	t.Cleanup(func() { webhookClient, federationClient, allowPrivateTargets = prevWebhook, prevFederation, prevAllow })
+}
+
 func decodeJSON[T any](t *testing.T, resp *http.Response, out *T) {
 	t.Helper()
 	defer resp.Body.Close()
@@ -544,8 +599,6 @@ func TestPaginationCursor(t *testing.T) {
 	}
 	resp.Body.Close()
 
-	time.Sleep(1100 * time.Millisecond)
-
 	resp = client.postJSON(t, "/api/stories", map[string]any{
 		"title": "Cursor Story 2",
 		"url":   "https://example.com/cursor2",
@@ -566,7 +619,7 @@ func TestPaginationCursor(t *testing.T) {
 	}
 	var listResp struct {
 		Stories []model.Story `json:"stories"`
-		Cursor  int64         `json:"cursor"`
+		Cursor  string        `json:"cursor"`
 	}
 	decodeJSON(t, resp, &listResp)
 	if len(listResp.Stories) != 1 {
@@ -576,7 +629,7 @@ func TestPaginationCursor(t *testing.T) {
 		t.Fatalf("expected newest story on first page")
 	}
 
-	resp = client.get(t, "/api/stories?sort=new&limit=1&cursor="+strconv.FormatInt(listResp.Cursor, 10), nil)
+	resp = client.get(t, "/api/stories?sort=new&limit=1&cursor="+listResp.Cursor, nil)
 	if resp.StatusCode != http.StatusOK {
 		b, _ := io.ReadAll(resp.Body)
 		resp.Body.Close()
@@ -592,6 +645,11 @@ func TestPaginationCursor(t *testing.T) {
 	if page2.Stories[0].ID == story2.ID {
 		t.Fatalf("expected older story on page 2")
 	}
+	resp = client.get(t, "/api/stories?sort=new&cursor=not-a-cursor", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid cursor, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
 }
 
 func TestCommentTree(t *testing.T) {
@@ -710,7 +768,7 @@ func TestCommentSortingTopVsNew(t *testing.T) {
 	var commentOld model.Comment
 	decodeJSON(t, resp, &commentOld)
 
-	time.Sleep(1100 * time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
 
 	resp = client.postJSON(t, "/api/comments", map[string]any{
 		"story_id": story.ID,
@@ -1007,3 +1065,5124 @@ func TestGetStats(t *testing.T) {
 		t.Errorf("expected 1 comment, got %d", stats["comments"])
 	}
 }
+
+func TestAdminDebug(t *testing.T) {
+	tc := newTestClient(t)
+
+	resp := tc.get(t, "/api/admin/debug", nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without secret, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = tc.get(t, "/debug/pprof/", nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for pprof without secret, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+
+	resp = tc.get(t, "/api/admin/debug", map[string]string{"X-Admin-Secret": "admin"})
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.Fatalf("debug status %d: %s", resp.StatusCode, string(b))
+	}
+	var payload map[string]any
+	decodeJSON(t, resp, &payload)
+	if _, ok := payload["goroutines"]; !ok {
+		t.Fatalf("expected goroutines in payload")
+	}
+	if _, ok := payload["db"]; !ok {
+		t.Fatalf("expected db stats in payload")
+	}
+	caches, _ := payload["caches"].(map[string]any)
+	if didWeb, ok := caches["did_web"].(map[string]any); !ok || didWeb["hit_rate"] == nil {
+		t.Fatalf("expected did:web cache stats in payload, got %v", payload["caches"])
+	}
+
+	resp = tc.get(t, "/debug/pprof/", map[string]string{"X-Admin-Secret": "admin"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 for pprof index, got %d", resp.StatusCode)
+	}
+	resp.Body.Close()
+}
+
+func TestAdminReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slashbot.json")
+	if err := os.WriteFile(path, []byte(`{"rate_limits":{"story_per_minute":1}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	client := newTestClientWithConfig(t, config.Config{ConfigFile: path})
+
+	story := map[string]any{"title": "Reload Story", "url": "https://example.com/reload"}
+	resp := client.postJSON(t, "/api/stories", story, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 under limit, got %d", resp.StatusCode)
+	}
+	resp = client.postJSON(t, "/api/stories", story, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over limit, got %d", resp.StatusCode)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"rate_limits":{"story_per_minute":10},"moderation":{"auto_hide_score":-5}}`), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	resp = client.postJSON(t, "/api/admin/reload", map[string]any{}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without secret, got %d", resp.StatusCode)
+	}
+	resp = client.postJSON(t, "/api/admin/reload", map[string]any{}, map[string]string{"X-Admin-Secret": "admin"})
+	var payload struct {
+		RateLimits map[string]int `json:"rate_limits"`
+		Moderation map[string]int `json:"moderation"`
+	}
+	decodeJSON(t, resp, &payload)
+	if payload.RateLimits["story_per_minute"] != 10 || payload.Moderation["auto_hide_score"] != -5 {
+		t.Fatalf("unexpected settings after reload: %+v", payload)
+	}
+
+	resp = client.postJSON(t, "/api/stories", story, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after raising limit, got %d", resp.StatusCode)
+	}
+
+	if err := os.WriteFile(path, []byte(`{not json`), 0o600); err != nil {
+		t.Fatalf("rewrite config: %v", err)
+	}
+	resp = client.postJSON(t, "/api/admin/reload", map[string]any{}, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("expected 500 for invalid config, got %d", resp.StatusCode)
+	}
+}
+
+func TestContentFilterActions(t *testing.T) {
+	cfg := config.Config{Moderation: config.Moderation{BannedWords: []string{"spamword"}}}
+	client := newTestClientWithConfig(t, cfg)
+	token := createTestAccount(t, client, "filter-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := client.postJSON(t, "/api/stories", map[string]any{
+		"title": "Buy spamword now please",
+		"text":  "body",
+	}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for banned word, got %d", resp.StatusCode)
+	}
+
+	resp = client.postJSON(t, "/api/stories", map[string]any{
+		"title": "A perfectly normal story",
+		"text":  "body",
+	}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+
+	// Switch to shadow-hiding: the comment is accepted but not listed.
+	path := filepath.Join(t.TempDir(), "slashbot.json")
+	if err := os.WriteFile(path, []byte(`{"moderation":{"banned_words":["spamword"],"filter_action":"hide"}}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg.ConfigFile = path
+	client = newTestClientWithConfig(t, cfg)
+	token = createTestAccount(t, client, "filter-hide")
+	headers = map[string]string{"Authorization": "Bearer " + token}
+
+	resp = client.postJSON(t, "/api/stories", map[string]any{
+		"title": "Another normal story",
+		"text":  "body",
+	}, headers)
+	decodeJSON(t, resp, &story)
+
+	resp = client.postJSON(t, "/api/comments", map[string]any{
+		"story_id": story.ID,
+		"text":     "great spamword deal",
+	}, headers)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		t.Fatalf("expected hidden comment to be accepted, got %d: %s", resp.StatusCode, string(b))
+	}
+	var comment model.Comment
+	decodeJSON(t, resp, &comment)
+	if !comment.Hidden {
+		t.Fatalf("expected comment to be shadow-hidden")
+	}
+
+	resp = client.get(t, fmt.Sprintf("/api/stories/%d/comments?view=flat", story.ID), nil)
+	var listing struct {
+		Comments []model.Comment `json:"comments"`
+	}
+	decodeJSON(t, resp, &listing)
+	if len(listing.Comments) != 0 {
+		t.Fatalf("expected hidden comment to be excluded, got %d", len(listing.Comments))
+	}
+}
+
+func TestEmbedWidgets(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "embed-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Embed <script>alert(1)</script>",
+		"text":  "body",
+	}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+
+	resp = tc.get(t, fmt.Sprintf("/embed/story/%d", story.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("embed story status %d: %s", resp.StatusCode, string(body))
+	}
+	if !strings.Contains(resp.Header.Get("Content-Security-Policy"), "default-src 'none'") {
+		t.Fatalf("expected restrictive CSP, got %q", resp.Header.Get("Content-Security-Policy"))
+	}
+	if resp.Header.Get("Cache-Control") == "" {
+		t.Fatalf("expected cache headers")
+	}
+	if strings.Contains(string(body), "<script>alert(1)") {
+		t.Fatalf("story title not escaped in embed card")
+	}
+
+	resp = tc.get(t, "/embed/story/999999", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for missing story, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, "/embed/top.js?limit=3", nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("top.js status %d: %s", resp.StatusCode, string(body))
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/javascript") {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(string(body), fmt.Sprintf("/stories/%d", story.ID)) {
+		t.Fatalf("expected story link in top.js: %s", string(body))
+	}
+	if strings.Contains(string(body), "</script>") {
+		t.Fatalf("story title not escaped in top.js")
+	}
+}
+
+func TestNearDuplicateTitleDetection(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{DuplicateWindow: time.Hour, DuplicateThreshold: 0.8})
+	token := createTestAccount(t, tc, "neardup-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Acme Labs launches open weights reasoning model",
+		"url":   "https://acme.example/blog/launch",
+	}, headers)
+	var first model.Story
+	decodeJSON(t, resp, &first)
+
+	resp = tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Acme Labs has launched an open-weights reasoning model!",
+		"url":   "https://news.example/acme-launch",
+	}, headers)
+	var dup struct {
+		ID          int64
+		DuplicateOf int64 `json:"duplicate_of"`
+	}
+	decodeJSON(t, resp, &dup)
+	if dup.ID != first.ID || dup.DuplicateOf != first.ID {
+		t.Fatalf("expected duplicate_of %d, got id=%d duplicate_of=%d", first.ID, dup.ID, dup.DuplicateOf)
+	}
+
+	resp = tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Acme Labs publishes quarterly safety report",
+		"url":   "https://acme.example/blog/safety",
+	}, headers)
+	var distinct struct {
+		ID          int64
+		DuplicateOf int64 `json:"duplicate_of"`
+	}
+	decodeJSON(t, resp, &distinct)
+	if distinct.ID == first.ID || distinct.DuplicateOf != 0 {
+		t.Fatalf("distinct title treated as duplicate: %+v", distinct)
+	}
+}
+
+func TestRegistrationInvitesAndLimits(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RequireInvite: true,
+		RateLimits:    config.RateLimits{AccountPerHour: 3},
+	})
+
+	register := func(name, invite string) error {
+		t.Helper()
+		creds, err := client.GenerateCredentials(name)
+		if err != nil {
+			t.Fatalf("generate credentials: %v", err)
+		}
+		c := client.New(tc.server.URL)
+		c.InviteCode = invite
+		_, err = c.Register(creds, "", "")
+		return err
+	}
+
+	if err := register("no-invite", ""); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 without invite, got %v", err)
+	}
+
+	resp := tc.postJSON(t, "/api/admin/invites", map[string]any{"count": 1}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without admin secret, got %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/admin/invites", map[string]any{"count": 1, "note": "test"}, map[string]string{"X-Admin-Secret": "admin"})
+	var invites struct {
+		Invites []string `json:"invites"`
+	}
+	decodeJSON(t, resp, &invites)
+	if len(invites.Invites) != 1 {
+		t.Fatalf("expected 1 invite, got %d", len(invites.Invites))
+	}
+
+	if err := register("invited", invites.Invites[0]); err != nil {
+		t.Fatalf("register with invite: %v", err)
+	}
+	if err := register("reused", invites.Invites[0]); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 for reused invite, got %v", err)
+	}
+
+	// Three attempts so far this hour; the fourth is throttled.
+	if err := register("throttled", invites.Invites[0]); err == nil || !strings.Contains(err.Error(), "429") {
+		t.Fatalf("expected 429 after registration limit, got %v", err)
+	}
+}
+
+func TestWellKnownDiscovery(t *testing.T) {
+	tc := newTestClient(t)
+
+	resp := tc.get(t, "/.well-known/slashbot.json", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("slashbot.json status %d", resp.StatusCode)
+	}
+	var doc struct {
+		APIBase string `json:"api_base"`
+		Auth    struct {
+			Challenge string `json:"challenge"`
+		} `json:"auth"`
+	}
+	decodeJSON(t, resp, &doc)
+	if doc.APIBase != tc.server.URL+"/api" {
+		t.Fatalf("unexpected api_base %q", doc.APIBase)
+	}
+	if doc.Auth.Challenge != tc.server.URL+"/api/auth/challenge" {
+		t.Fatalf("unexpected challenge endpoint %q", doc.Auth.Challenge)
+	}
+
+	resp = tc.get(t, "/.well-known/ai-plugin.json", nil)
+	var manifest map[string]any
+	decodeJSON(t, resp, &manifest)
+	if manifest["schema_version"] != "v1" {
+		t.Fatalf("unexpected manifest: %v", manifest)
+	}
+
+	resp = tc.get(t, "/.well-known/unknown.json", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", resp.StatusCode)
+	}
+}
+
+func TestStructuredDataOnPages(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "jsonld-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "JSON-LD </script> story",
+		"text":  "Body text",
+	}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "A reply"}, headers)
+	resp.Body.Close()
+
+	extract := func(path string) map[string]any {
+		t.Helper()
+		resp := tc.get(t, path, nil)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		const open = `<script type="application/ld+json">`
+		start := strings.Index(string(body), open)
+		if start < 0 {
+			t.Fatalf("no JSON-LD on %s", path)
+		}
+		rest := string(body)[start+len(open):]
+		end := strings.Index(rest, "</script>")
+		var doc map[string]any
+		if err := json.Unmarshal([]byte(rest[:end]), &doc); err != nil {
+			t.Fatalf("invalid JSON-LD on %s: %v\n%s", path, err, rest[:end])
+		}
+		return doc
+	}
+
+	doc := extract(fmt.Sprintf("/stories/%d", story.ID))
+	if doc["@type"] != "DiscussionForumPosting" || doc["headline"] != story.Title {
+		t.Fatalf("unexpected story JSON-LD: %v", doc)
+	}
+	if comments, _ := doc["comment"].([]any); len(comments) != 1 {
+		t.Fatalf("expected 1 comment in JSON-LD, got %v", doc["comment"])
+	}
+
+	doc = extract(fmt.Sprintf("/accounts/%d", story.AccountID))
+	if doc["@type"] != "ProfilePage" {
+		t.Fatalf("unexpected account JSON-LD: %v", doc)
+	}
+}
+
+func TestOEmbed(t *testing.T) {
+	tc := newTestClient(t)
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "oembed-bot")}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": `Cards & "quotes"`, "text": "x"}, headers), &story)
+	storyURL := fmt.Sprintf("https://slashbot.net/stories/%d", story.ID)
+
+	resp := tc.get(t, fmt.Sprintf("/stories/%d", story.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `<link rel="alternate" type="application/json+oembed" href="/oembed?format=json&amp;url=`+url.QueryEscape(storyURL)+`"`) {
+		t.Error("expected the story page to advertise its oEmbed URL")
+	}
+
+	var embed OEmbedResponse
+	resp = tc.get(t, "/oembed?url="+url.QueryEscape(storyURL), nil)
+	if resp.Header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Error("expected oEmbed responses to allow any origin")
+	}
+	decodeJSON(t, resp, &embed)
+	if embed.Type != "rich" || embed.Version != "1.0" || embed.Title != story.Title || embed.AuthorName != "oembed-bot" || embed.Width != 500 {
+		t.Fatalf("unexpected oEmbed response %+v", embed)
+	}
+	if !strings.Contains(embed.HTML, fmt.Sprintf(`/embed/story/%d"`, story.ID)) || !strings.Contains(embed.HTML, `title="Cards &amp; &#34;quotes&#34;"`) {
+		t.Errorf("unexpected oEmbed html %s", embed.HTML)
+	}
+
+	// The host the request reached works too, and maxwidth shrinks the card.
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/oembed?maxwidth=320&maxheight=10&url=%s/stories/%d", url.QueryEscape(tc.server.URL), story.ID), nil), &embed)
+	if embed.Width != 320 || embed.Height != 60 {
+		t.Errorf("expected 320x60 for maxwidth=320 and a too-small maxheight, got %dx%d", embed.Width, embed.Height)
+	}
+
+	for query, want := range map[string]int{
+		"url=" + url.QueryEscape(storyURL) + "&format=xml":              http.StatusNotImplemented,
+		"url=" + url.QueryEscape("https://example.com/stories/1"):       http.StatusNotFound,
+		"url=" + url.QueryEscape("https://slashbot.net/bots"):           http.StatusNotFound,
+		"url=" + url.QueryEscape("https://slashbot.net/stories/999999"): http.StatusNotFound,
+	} {
+		resp := tc.get(t, "/oembed?"+query, nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("GET /oembed?%s = %d, want %d", query, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestStoryPrintView(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "print-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Printable story title",
+		"text":  "First line\nSecond line",
+	}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "Nice <b>post</b>"}, headers)
+	resp.Body.Close()
+
+	resp = tc.get(t, fmt.Sprintf("/stories/%d/print", story.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("print status %d: %s", resp.StatusCode, string(body))
+	}
+	if !strings.Contains(string(body), "Printable story title") || strings.Contains(string(body), "<b>post</b>") {
+		t.Fatalf("unexpected print body: %s", string(body))
+	}
+
+	resp = tc.get(t, fmt.Sprintf("/stories/%d/print?format=md", story.ID), nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/markdown") {
+		t.Fatalf("unexpected content type %q", resp.Header.Get("Content-Type"))
+	}
+	md := string(body)
+	if !strings.HasPrefix(md, "# Printable story title\n") || !strings.Contains(md, "> Nice <b>post</b>") {
+		t.Fatalf("unexpected markdown: %s", md)
+	}
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{MaxBodyBytes: 1024})
+	token := createTestAccount(t, tc, "body-limit")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Oversized story body",
+		"text":  strings.Repeat("x", 4096),
+	}, headers)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d: %s", resp.StatusCode, string(body))
+	}
+	if !strings.Contains(string(body), "too large") {
+		t.Fatalf("expected clear error message, got %s", string(body))
+	}
+
+	resp = tc.postJSON(t, "/api/stories", map[string]any{
+		"title": "Small story body",
+		"text":  "fits",
+	}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected small body to succeed, got %d", resp.StatusCode)
+	}
+
+	// Batches carry several operations and get a larger limit of their own.
+	resp = tc.postJSON(t, "/api/batch", map[string]any{"operations": []map[string]any{
+		{"op": "story", "body": map[string]any{"title": "Batched long story", "text": strings.Repeat("y", 2048)}},
+		{"op": "story", "body": map[string]any{"title": "Another long story", "text": strings.Repeat("z", 2048)}},
+	}}, headers)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected batch over the default limit to be accepted, got %d: %s", resp.StatusCode, string(body))
+	}
+}
+
+func TestThreadCommentCooldown(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:     config.RateLimits{StoryPerMinute: 100, CommentPerMinute: 100},
+		ThreadCooldown: config.ThreadCooldown{Default: time.Second, NewAccount: time.Hour, NewAccountAge: time.Hour},
+	})
+	token := createTestAccount(t, tc, "cooldown-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	storyIDs := make([]int64, 2)
+	for i := range storyIDs {
+		resp := tc.postJSON(t, "/api/stories", map[string]any{
+			"title": fmt.Sprintf("Cooldown story %d", i),
+			"text":  "thread",
+		}, headers)
+		var story model.Story
+		decodeJSON(t, resp, &story)
+		storyIDs[i] = story.ID
+	}
+
+	comment := func(storyID int64) *http.Response {
+		return tc.postJSON(t, "/api/comments", map[string]any{"story_id": storyID, "text": "reply"}, headers)
+	}
+	resp := comment(storyIDs[0])
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("first comment status %d", resp.StatusCode)
+	}
+	resp = comment(storyIDs[0])
+	var body map[string]any
+	decodeJSON(t, resp, &body)
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for second comment in thread, got %d", resp.StatusCode)
+	}
+	// A brand-new account gets the stricter cooldown.
+	if retry, _ := body["retry_after"].(float64); retry < 60 {
+		t.Fatalf("expected new-account cooldown, got retry_after %v", body["retry_after"])
+	}
+	resp = comment(storyIDs[1])
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("comment in other thread status %d", resp.StatusCode)
+	}
+}
+
+func TestReplyLoopDetection(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 100, CommentPerMinute: 100},
+		Moderation: config.Moderation{LoopRounds: 4, LoopSimilarity: 0.5},
+	})
+	alice := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "loop-alice")}
+	bob := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "loop-bob")}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Loop story", "text": "thread"}, alice)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+
+	reply := func(headers map[string]string, parentID *int64, text string) *http.Response {
+		return tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "parent_id": parentID, "text": text}, headers)
+	}
+	var parent *int64
+	for i, headers := range []map[string]string{alice, bob, alice} {
+		resp := reply(headers, parent, fmt.Sprintf("I completely agree with your excellent point number %d", i))
+		var c model.Comment
+		decodeJSON(t, resp, &c)
+		if c.ID == 0 {
+			t.Fatalf("reply %d not created", i)
+		}
+		parent = &c.ID
+	}
+
+	resp = reply(bob, parent, "I completely agree with your excellent point again")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 for looping reply, got %d", resp.StatusCode)
+	}
+	resp = reply(alice, nil, "A fresh top-level comment from a paused account")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected paused account to get 429, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, "/api/flagged", nil)
+	var queue struct {
+		Loops     []model.LoopIncident `json:"loops"`
+		LoopTotal int                  `json:"loop_total"`
+	}
+	decodeJSON(t, resp, &queue)
+	if queue.LoopTotal != 1 || len(queue.Loops) != 1 || queue.Loops[0].Rounds != 4 {
+		t.Fatalf("expected one 4-round loop incident in queue, got %+v", queue)
+	}
+
+	path := fmt.Sprintf("/api/admin/loops/%d/release", queue.Loops[0].ID)
+	resp = tc.postJSON(t, path, nil, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("release status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, path, nil, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 releasing twice, got %d", resp.StatusCode)
+	}
+	resp = reply(alice, nil, "Back to normal after moderator release")
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected comment after release, got %d", resp.StatusCode)
+	}
+}
+
+func TestSignedRequestAuth(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 100, CommentPerMinute: 100},
+	})
+	creds, err := client.GenerateCredentials("signed-bot")
+	if err != nil {
+		t.Fatalf("generate credentials: %v", err)
+	}
+	c := client.New(tc.server.URL)
+	if _, err := c.Register(creds, "", ""); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	c.Signer = creds
+
+	story, err := c.PostStory("Signed story", "", "posted without a bearer token", nil)
+	if err != nil {
+		t.Fatalf("signed post story: %v", err)
+	}
+	if _, err := c.PostComment(story.ID, nil, "signed comment"); err != nil {
+		t.Fatalf("signed post comment: %v", err)
+	}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Forged", "text": "x"}, map[string]string{
+		"X-Signature":           creds.Sign("something else"),
+		"X-Signature-Key":       creds.PublicKey,
+		"X-Signature-Timestamp": strconv.FormatInt(time.Now().Unix(), 10),
+		"X-Signature-Nonce":     "0123456789abcdef",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad signature, got %d", resp.StatusCode)
+	}
+
+	// A story page looks up its viewer more than once; the nonce is spent
+	// on the first lookup, so the rest must reuse it to show the vote.
+	if err := c.Vote("story", story.ID, 1); err != nil {
+		t.Fatalf("signed vote: %v", err)
+	}
+	path := "/stories/" + strconv.FormatInt(story.ID, 10)
+	ts := time.Now().Unix()
+	nonce := "fedcba9876543210"
+	resp = tc.get(t, path, map[string]string{
+		"X-Signature":           creds.Sign(auth.RequestMessage(http.MethodGet, path, nil, ts, nonce)),
+		"X-Signature-Key":       creds.PublicKey,
+		"X-Signature-Timestamp": strconv.FormatInt(ts, 10),
+		"X-Signature-Nonce":     nonce,
+	})
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(page), "vote-up voted") {
+		t.Fatalf("expected the signed viewer's upvote on the story page, got %d", resp.StatusCode)
+	}
+}
+
+func TestJWTAccessTokens(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		TokenFormat: "jwt",
+		RateLimits:  config.RateLimits{StoryPerMinute: 100},
+	})
+	token := createTestAccount(t, tc, "jwt-bot")
+	if strings.Count(token, ".") != 2 {
+		t.Fatalf("expected a JWT access token, got %q", token)
+	}
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "JWT story", "text": "hi"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("post with jwt status %d", resp.StatusCode)
+	}
+
+	resp = tc.postJSON(t, "/api/auth/revoke", nil, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("revoke status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "After revoke", "text": "hi"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 after revoke, got %d", resp.StatusCode)
+	}
+
+	resp = tc.postJSON(t, "/api/admin/revoke-token", map[string]any{"token": "not.a.jwt"}, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for malformed token, got %d", resp.StatusCode)
+	}
+
+	// A deleted account's JWTs stop working with it.
+	creds, _ := client.GenerateCredentials("jwt-deleted-bot")
+	c := client.New(tc.server.URL)
+	accountID, err := c.Register(creds, "", "")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := c.Authenticate(creds); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	resp = tc.postJSON(t, "/api/admin/delete-account", map[string]any{"account_id": accountID}, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete account status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "After deletion", "text": "hi"}, map[string]string{"Authorization": "Bearer " + c.Token})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a deleted account's jwt, got %d", resp.StatusCode)
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "apikey-bot")
+	bearer := map[string]string{"Authorization": "Bearer " + token}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "API key story", "text": "hi"}, bearer)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+
+	resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "daemon", "scopes": []string{"bogus"}}, bearer)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown scope, got %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "daemon", "scopes": []string{"comments"}, "expires_in_days": 30}, bearer)
+	var created struct {
+		Key    string       `json:"key"`
+		APIKey model.APIKey `json:"api_key"`
+	}
+	decodeJSON(t, resp, &created)
+	if !strings.HasPrefix(created.Key, "sbk_") || created.APIKey.ExpiresAt == nil {
+		t.Fatalf("unexpected api key response %+v", created)
+	}
+	apiKey := map[string]string{"Authorization": "ApiKey " + created.Key}
+
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "from a daemon"}, apiKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("comment with api key status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Out of scope", "text": "hi"}, apiKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 outside key scope, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, "/api/apikeys", bearer)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(body), created.Key) {
+		t.Fatalf("listing leaked the key secret")
+	}
+	var list struct {
+		APIKeys []model.APIKey `json:"api_keys"`
+	}
+	if err := json.Unmarshal(body, &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.APIKeys) != 1 || list.APIKeys[0].LastUsedAt == nil {
+		t.Fatalf("expected one key with last-used time, got %+v", list.APIKeys)
+	}
+
+	// A key can't mint a key with more access than it has: no scopes would
+	// mean full access.
+	resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "manager", "scopes": []string{"account"}}, bearer)
+	var manager struct {
+		Key string `json:"key"`
+	}
+	decodeJSON(t, resp, &manager)
+	managerKey := map[string]string{"Authorization": "ApiKey " + manager.Key}
+	for name, scopes := range map[string][]string{"no scopes": {}, "omitted scopes": nil, "unheld scope": {"account", "stories"}} {
+		resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "escalated", "scopes": scopes}, managerKey)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden {
+			t.Errorf("%s: expected 403, got %d", name, resp.StatusCode)
+		}
+	}
+	resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "delegated", "scopes": []string{"account"}}, managerKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected a key to issue one with its own scopes, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+fmt.Sprintf("/api/apikeys/%d", created.APIKey.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("revoke status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "after revoke"}, apiKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for revoked key, got %d", resp.StatusCode)
+	}
+
+	resp = tc.postJSON(t, "/api/admin/delete-account", map[string]any{"account_id": created.APIKey.AccountID}, map[string]string{"X-Admin-Secret": "admin"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete account status %d", resp.StatusCode)
+	}
+	resp = tc.get(t, "/api/apikeys", managerKey)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for a deleted account's key, got %d", resp.StatusCode)
+	}
+}
+
+func TestPostingSchedule(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "schedule-bot")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	put := func(body map[string]any) *http.Response {
+		data, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPut, tc.server.URL+"/api/schedule", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("put schedule: %v", err)
+		}
+		return resp
+	}
+
+	resp := put(map[string]any{"quiet_start": "25:00", "quiet_end": "07:00"})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid time, got %d", resp.StatusCode)
+	}
+
+	now := time.Now().UTC()
+	resp = put(map[string]any{
+		"quiet_start": now.Add(-time.Hour).Format("15:04"),
+		"quiet_end":   now.Add(time.Hour).Format("15:04"),
+		"timezone":    "UTC",
+	})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("set schedule status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "During quiet hours", "text": "hi"}, headers)
+	var body map[string]any
+	decodeJSON(t, resp, &body)
+	if resp.StatusCode != http.StatusTooManyRequests || !strings.Contains(body["error"].(string), "quiet hours") {
+		t.Fatalf("expected quiet hours rejection, got %d %v", resp.StatusCode, body)
+	}
+
+	resp = put(map[string]any{"min_interval": "1h"})
+	resp.Body.Close()
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Paced story", "text": "hi"}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+	if story.ID == 0 {
+		t.Fatalf("expected story outside quiet hours")
+	}
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "too soon"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected pacing rejection, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, "/api/schedule", headers)
+	var ps model.PostingSchedule
+	decodeJSON(t, resp, &ps)
+	if ps.MinInterval != time.Hour || ps.QuietStart != "" {
+		t.Fatalf("unexpected schedule %+v", ps)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	tc := newTestClient(t)
+	first, _ := client.GenerateCredentials("rotating-bot")
+	second, _ := client.GenerateCredentials("rotating-bot")
+	c := client.New(tc.server.URL)
+	accountID, err := c.Register(first, "", "")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	// Only one of the two keys signing is not enough.
+	impostor, _ := client.GenerateCredentials("impostor")
+	if _, err := c.RotateKey(accountID, impostor, second, false); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 rotating from a foreign key, got %v", err)
+	}
+
+	if _, err := c.RotateKey(accountID, first, second, false); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if err := client.New(tc.server.URL).Authenticate(second); err != nil {
+		t.Fatalf("authenticate with rotated key: %v", err)
+	}
+
+	challenge, err := c.GetChallenge("ed25519")
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	statement := auth.RevokeOthersStatement(accountID, "ed25519", second.PublicKey, challenge)
+	resp := tc.postJSON(t, fmt.Sprintf("/api/accounts/%d/keys/revoke-others", accountID), map[string]any{
+		"alg":        "ed25519",
+		"public_key": second.PublicKey,
+		"challenge":  challenge,
+		"signature":  second.Sign(statement),
+	}, nil)
+	var result struct {
+		Revoked int `json:"revoked"`
+	}
+	decodeJSON(t, resp, &result)
+	if result.Revoked != 1 {
+		t.Fatalf("expected 1 key revoked, got %d", result.Revoked)
+	}
+
+	if _, err := c.RotateKey(accountID, first, impostor, false); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 rotating from a revoked key, got %v", err)
+	}
+	if err := client.New(tc.server.URL).Authenticate(second); err != nil {
+		t.Fatalf("kept key should still authenticate: %v", err)
+	}
+}
+
+func TestRevokedKeyTokens(t *testing.T) {
+	tc := newTestClient(t)
+	first, _ := client.GenerateCredentials("retiring-bot")
+	second, _ := client.GenerateCredentials("retiring-bot")
+	third, _ := client.GenerateCredentials("retiring-bot")
+	c := client.New(tc.server.URL)
+	accountID, err := c.Register(first, "", "")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	bearerFor := func(creds *client.Credentials) map[string]string {
+		t.Helper()
+		kc := client.New(tc.server.URL)
+		if err := kc.Authenticate(creds); err != nil {
+			t.Fatalf("authenticate: %v", err)
+		}
+		return map[string]string{"Authorization": "Bearer " + kc.Token}
+	}
+	postStory := func(headers map[string]string) int {
+		t.Helper()
+		resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Retired key", "text": "x"}, headers)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	firstBearer := bearerFor(first)
+	secondKeyID, err := c.RotateKey(accountID, first, second, true)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	if status := postStory(firstBearer); status != http.StatusUnauthorized {
+		t.Fatalf("expected the rotated-out key's token to get 401, got %d", status)
+	}
+
+	secondBearer := bearerFor(second)
+	if _, err := c.RotateKey(accountID, second, third, false); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+routes.DeleteAccountKey.Path(accountID, secondKeyID), nil)
+	for k, v := range bearerFor(third) {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete key: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete key status %d", resp.StatusCode)
+	}
+	if status := postStory(secondBearer); status != http.StatusUnauthorized {
+		t.Fatalf("expected the deleted key's token to get 401, got %d", status)
+	}
+}
+
+func TestRevokeOtherKeysInvalidatesCredentials(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{TokenFormat: "jwt"})
+	first, _ := client.GenerateCredentials("compromised-bot")
+	second, _ := client.GenerateCredentials("compromised-bot")
+	c := client.New(tc.server.URL)
+	accountID, err := c.Register(first, "", "")
+	if err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if err := c.Authenticate(first); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	bearer := map[string]string{"Authorization": "Bearer " + c.Token}
+	resp := tc.postJSON(t, "/api/apikeys", map[string]any{"name": "stolen"}, bearer)
+	var created struct {
+		Key string `json:"key"`
+	}
+	decodeJSON(t, resp, &created)
+	apiKey := map[string]string{"Authorization": "ApiKey " + created.Key}
+
+	if _, err := c.RotateKey(accountID, first, second, false); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	challenge, err := c.GetChallenge("ed25519")
+	if err != nil {
+		t.Fatalf("challenge: %v", err)
+	}
+	statement := auth.RevokeOthersStatement(accountID, "ed25519", second.PublicKey, challenge)
+	resp = tc.postJSON(t, fmt.Sprintf("/api/accounts/%d/keys/revoke-others", accountID), map[string]any{
+		"alg":        "ed25519",
+		"public_key": second.PublicKey,
+		"challenge":  challenge,
+		"signature":  second.Sign(statement),
+	}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("revoke-others status %d", resp.StatusCode)
+	}
+
+	for name, headers := range map[string]map[string]string{"jwt": bearer, "api key": apiKey} {
+		resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "After revoke-others", "text": "x"}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected %s of the revoked key to get 401, got %d", name, resp.StatusCode)
+		}
+	}
+	kept := client.New(tc.server.URL)
+	if err := kept.Authenticate(second); err != nil {
+		t.Fatalf("kept key should still authenticate: %v", err)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "From the kept key", "text": "x"}, map[string]string{"Authorization": "Bearer " + kept.Token})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the kept key's jwt to work, got %d", resp.StatusCode)
+	}
+}
+
+func TestAccountFreeze(t *testing.T) {
+	tc := newTestClient(t)
+	creds, _ := client.GenerateCredentials("freeze-bot")
+	c := client.New(tc.server.URL)
+	if err := c.RegisterAndAuthenticate(creds); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	bearer := map[string]string{"Authorization": "Bearer " + c.Token}
+
+	resp := tc.postJSON(t, "/api/me/freeze", nil, bearer)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected bearer-only freeze to be refused, got %d", resp.StatusCode)
+	}
+
+	if err := c.Freeze(creds); err != nil {
+		t.Fatalf("freeze: %v", err)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "While frozen", "text": "x"}, bearer)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected revoked token to get 401, got %d", resp.StatusCode)
+	}
+	signed := client.New(tc.server.URL)
+	signed.Signer = creds
+	if _, err := signed.PostStory("Signed while frozen", "", "x", nil); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 for frozen account, got %v", err)
+	}
+
+	if err := c.Unfreeze(creds); err != nil {
+		t.Fatalf("unfreeze: %v", err)
+	}
+	if _, err := signed.PostStory("After unfreeze", "", "x", nil); err != nil {
+		t.Fatalf("post after unfreeze: %v", err)
+	}
+	if err := c.Unfreeze(creds); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Fatalf("expected 404 unfreezing twice, got %v", err)
+	}
+}
+
+func TestAccountFreezeInvalidatesJWTs(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{TokenFormat: "jwt"})
+	creds, _ := client.GenerateCredentials("frozen-jwt-bot")
+	c := client.New(tc.server.URL)
+	if err := c.RegisterAndAuthenticate(creds); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	bearer := map[string]string{"Authorization": "Bearer " + c.Token}
+	resp := tc.postJSON(t, "/api/apikeys", map[string]any{"name": "daemon"}, bearer)
+	var created struct {
+		Key string `json:"key"`
+	}
+	decodeJSON(t, resp, &created)
+	apiKey := map[string]string{"Authorization": "ApiKey " + created.Key}
+
+	if err := c.Freeze(creds); err != nil {
+		t.Fatalf("freeze: %v", err)
+	}
+	if err := c.Unfreeze(creds); err != nil {
+		t.Fatalf("unfreeze: %v", err)
+	}
+	for name, headers := range map[string]map[string]string{"jwt": bearer, "api key": apiKey} {
+		resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "After unfreeze", "text": "x"}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected pre-freeze %s to get 401 after unfreeze, got %d", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestCommentTargetValidation(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 100, CommentPerMinute: 100},
+	})
+	token := createTestAccount(t, tc, "orphan-test")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	storyIDs := make([]int64, 2)
+	for i := range storyIDs {
+		resp := tc.postJSON(t, "/api/stories", map[string]any{
+			"title": fmt.Sprintf("Orphan story %d", i),
+			"text":  "thread",
+		}, headers)
+		var story model.Story
+		decodeJSON(t, resp, &story)
+		storyIDs[i] = story.ID
+	}
+	resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": storyIDs[0], "text": "root"}, headers)
+	var parent model.Comment
+	decodeJSON(t, resp, &parent)
+
+	cases := []struct {
+		name   string
+		body   map[string]any
+		status int
+	}{
+		{"missing story", map[string]any{"story_id": 999999, "text": "hi"}, http.StatusNotFound},
+		{"missing parent", map[string]any{"story_id": storyIDs[0], "parent_id": 999999, "text": "hi"}, http.StatusNotFound},
+		{"cross-thread parent", map[string]any{"story_id": storyIDs[1], "parent_id": parent.ID, "text": "hi"}, http.StatusBadRequest},
+		{"valid reply", map[string]any{"story_id": storyIDs[0], "parent_id": parent.ID, "text": "hi"}, http.StatusOK},
+	}
+	for _, c := range cases {
+		resp := tc.postJSON(t, "/api/comments", c.body, headers)
+		resp.Body.Close()
+		if resp.StatusCode != c.status {
+			t.Fatalf("%s: expected %d, got %d", c.name, c.status, resp.StatusCode)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+fmt.Sprintf("/api/stories/%d", storyIDs[1]), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete story: %v", err)
+	}
+	resp.Body.Close()
+	resp = tc.postJSON(t, "/api/comments", map[string]any{"story_id": storyIDs[1], "text": "hi"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 on hidden story, got %d", resp.StatusCode)
+	}
+}
+
+func TestClientDirectoryAndProfile(t *testing.T) {
+	tc := newTestClient(t)
+	c := client.New(tc.server.URL)
+	creds, _ := client.GenerateCredentials("directory-bot")
+	if err := c.RegisterAndAuthenticate(creds); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	story, err := c.PostStory("Directory story", "", "hello", nil)
+	if err != nil {
+		t.Fatalf("post story: %v", err)
+	}
+
+	bots, err := c.GetBots("alpha", 1)
+	if err != nil {
+		t.Fatalf("get bots: %v", err)
+	}
+	var accountID int64
+	for _, a := range bots.Accounts {
+		if a.DisplayName == "directory-bot" {
+			accountID = a.ID
+		}
+	}
+	if accountID == 0 || bots.Sort != "alpha" || bots.Page != 1 || bots.Total < 1 {
+		t.Fatalf("expected directory-bot on page 1, got %+v", bots)
+	}
+
+	profile, err := c.GetAccountProfile(accountID)
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if profile.Account.DisplayName != "directory-bot" || len(profile.Keys) != 1 || profile.Keys[0].PublicKey != creds.PublicKey {
+		t.Fatalf("unexpected profile: %+v", profile)
+	}
+	if profile.StoryTotal != 1 || len(profile.Stories) != 1 || profile.Stories[0].ID != story.ID {
+		t.Fatalf("expected the posted story on the profile, got %+v", profile.Stories)
+	}
+	if summary := profile.ActivitySummary; summary.StoriesSubmitted != 1 || summary.StoriesByKind[model.KindText] != 1 || summary.CurrentStreak != 1 || summary.LongestStreak != 1 {
+		t.Fatalf("expected activity summary to count the story and a one-day streak, got %+v", summary)
+	}
+	var apiProfile AccountResponse
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/accounts/%d", accountID), nil), &apiProfile)
+	if apiProfile.ActivitySummary.StoriesSubmitted != 1 || apiProfile.ActivitySummary.DaysActive != 1 {
+		t.Fatalf("expected the API profile to carry the activity summary, got %+v", apiProfile.ActivitySummary)
+	}
+	resp := tc.get(t, fmt.Sprintf("/accounts/%d", accountID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "1 day (longest 1)") {
+		t.Error("expected the profile page to show the streak")
+	}
+	if _, err := c.GetAccountProfile(accountID + 1000); err == nil {
+		t.Fatalf("expected error for unknown account")
+	}
+}
+
+func TestSessions(t *testing.T) {
+	tc := newTestClient(t)
+	creds, _ := client.GenerateCredentials("session-bot")
+	first := client.New(tc.server.URL)
+	if err := first.RegisterAndAuthenticate(creds); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	second := client.New(tc.server.URL)
+	if err := second.Authenticate(creds); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+
+	resp := tc.get(t, "/api/auth/sessions", map[string]string{"Authorization": "Bearer " + first.Token})
+	var list struct {
+		Sessions []model.Session `json:"sessions"`
+	}
+	decodeJSON(t, resp, &list)
+	if len(list.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %+v", list.Sessions)
+	}
+	var current, other int64
+	for _, sess := range list.Sessions {
+		if sess.IPPrefix != "127.0.0.0/24" || sess.UserAgent == "" {
+			t.Fatalf("expected client metadata, got %+v", sess)
+		}
+		if sess.Current {
+			current = sess.ID
+		} else {
+			other = sess.ID
+		}
+	}
+	if other == 0 {
+		t.Fatalf("expected exactly one current session, got %+v", list.Sessions)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+fmt.Sprintf("/api/auth/sessions/%d", other), nil)
+	req.Header.Set("Authorization", "Bearer "+first.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete session: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete session status %d", resp.StatusCode)
+	}
+	resp = tc.get(t, "/api/auth/sessions", map[string]string{"Authorization": "Bearer " + second.Token})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected ended session to be rejected, got %d", resp.StatusCode)
+	}
+
+	// Another account can't end this account's sessions.
+	intruder := createTestAccount(t, tc, "session-intruder")
+	req, _ = http.NewRequest(http.MethodDelete, tc.server.URL+fmt.Sprintf("/api/auth/sessions/%d", current), nil)
+	req.Header.Set("Authorization", "Bearer "+intruder)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete session: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for another account's session, got %d", resp.StatusCode)
+	}
+}
+
+func TestRecoveryWebhookTargets(t *testing.T) {
+	tc := newTestClient(t)
+	owner, _ := client.GenerateCredentials("hooked-bot")
+	recovery, _ := client.GenerateCredentials("hooked-bot")
+	c := client.New(tc.server.URL)
+	for _, target := range []string{
+		"http://127.0.0.1:9/hook",
+		"http://localhost/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.1/hook",
+		"http://[::1]/hook",
+	} {
+		challenge, _ := c.GetChallenge("ed25519")
+		resp := tc.postJSON(t, "/api/accounts", map[string]any{
+			"display_name":         owner.BotName,
+			"alg":                  "ed25519",
+			"public_key":           owner.PublicKey,
+			"challenge":            challenge,
+			"signature":            owner.Sign(challenge),
+			"recovery_alg":         "ed25519",
+			"recovery_public_key":  recovery.PublicKey,
+			"recovery_webhook_url": target,
+		}, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", target, resp.StatusCode)
+		}
+	}
+
+	// A public name that resolves to a private address is refused on dial.
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+	if _, err := webhookClient.Post(hook.URL, "application/json", nil); err == nil || !strings.Contains(err.Error(), "non-public address") {
+		t.Fatalf("expected webhook delivery to loopback to be refused, got %v", err)
+	}
+}
+
+func TestAccountRecovery(t *testing.T) {
+	allowLoopbackTargets(t)
+	events := make(chan string, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Event string `json:"event"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload.Event
+	}))
+	defer hook.Close()
+	waitEvent := func(want string) {
+		t.Helper()
+		select {
+		case got := <-events:
+			if got != want {
+				t.Fatalf("expected webhook %q, got %q", want, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no %q webhook", want)
+		}
+	}
+
+	const cooldown = 300 * time.Millisecond
+	tc := newTestClientWithConfig(t, config.Config{RecoveryCooldown: cooldown, TokenFormat: "jwt"})
+	owner, _ := client.GenerateCredentials("recoverable-bot")
+	recovery, _ := client.GenerateCredentials("recoverable-bot")
+	replacement, _ := client.GenerateCredentials("recoverable-bot")
+	c := client.New(tc.server.URL)
+
+	challenge, _ := c.GetChallenge("ed25519")
+	resp := tc.postJSON(t, "/api/accounts", map[string]any{
+		"display_name":         owner.BotName,
+		"alg":                  "ed25519",
+		"public_key":           owner.PublicKey,
+		"challenge":            challenge,
+		"signature":            owner.Sign(challenge),
+		"recovery_alg":         "ed25519",
+		"recovery_public_key":  recovery.PublicKey,
+		"recovery_webhook_url": hook.URL,
+	}, nil)
+	var created struct {
+		AccountID int64 `json:"account_id"`
+	}
+	decodeJSON(t, resp, &created)
+	if created.AccountID == 0 {
+		t.Fatal("expected account id")
+	}
+	recoverPath := fmt.Sprintf("/api/accounts/%d/recovery", created.AccountID)
+	requestRecovery := func(signer *client.Credentials) *http.Response {
+		t.Helper()
+		challenge, err := c.GetChallenge("ed25519")
+		if err != nil {
+			t.Fatalf("challenge: %v", err)
+		}
+		statement := auth.RecoveryStatement(created.AccountID, "ed25519", recovery.PublicKey, "ed25519", replacement.PublicKey, challenge)
+		return tc.postJSON(t, recoverPath, map[string]any{
+			"recovery_alg":        "ed25519",
+			"recovery_public_key": recovery.PublicKey,
+			"new_alg":             "ed25519",
+			"new_public_key":      replacement.PublicKey,
+			"challenge":           challenge,
+			"recovery_signature":  signer.Sign(statement),
+			"new_signature":       replacement.Sign(statement),
+		}, nil)
+	}
+
+	// The active key is not the recovery key.
+	resp = requestRecovery(owner)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for statement not signed by the recovery key, got %d", resp.StatusCode)
+	}
+
+	resp = requestRecovery(recovery)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("request recovery status %d", resp.StatusCode)
+	}
+	waitEvent("recovery.requested")
+	resp = tc.postJSON(t, recoverPath+"/complete", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected 409 before cooldown, got %d", resp.StatusCode)
+	}
+
+	// The owner still holds an active key and cancels.
+	if err := c.Authenticate(owner); err != nil {
+		t.Fatalf("authenticate owner: %v", err)
+	}
+	ownerBearer := map[string]string{"Authorization": "Bearer " + c.Token}
+	resp = tc.postJSON(t, "/api/apikeys", map[string]any{"name": "owner-daemon"}, ownerBearer)
+	var ownerKey struct {
+		Key string `json:"key"`
+	}
+	decodeJSON(t, resp, &ownerKey)
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+recoverPath, nil)
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("cancel: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cancel status %d", resp.StatusCode)
+	}
+	waitEvent("recovery.cancelled")
+	time.Sleep(cooldown)
+	resp = tc.postJSON(t, recoverPath+"/complete", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 completing a cancelled recovery, got %d", resp.StatusCode)
+	}
+
+	resp = requestRecovery(recovery)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("second request status %d", resp.StatusCode)
+	}
+	waitEvent("recovery.requested")
+	time.Sleep(cooldown)
+	resp = tc.postJSON(t, recoverPath+"/complete", nil, nil)
+	if resp.StatusCode != http.StatusOK {
+		b, _ := io.ReadAll(resp.Body)
+		t.Fatalf("complete status %d: %s", resp.StatusCode, b)
+	}
+	resp.Body.Close()
+	waitEvent("recovery.completed")
+
+	if err := client.New(tc.server.URL).Authenticate(owner); err == nil {
+		t.Fatal("expected the old key to be revoked")
+	}
+	for name, headers := range map[string]map[string]string{"jwt": ownerBearer, "api key": {"Authorization": "ApiKey " + ownerKey.Key}} {
+		resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "After recovery", "text": "x"}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("expected the old owner's %s to get 401 after recovery, got %d", name, resp.StatusCode)
+		}
+	}
+	if err := client.New(tc.server.URL).Authenticate(replacement); err != nil {
+		t.Fatalf("authenticate with recovered key: %v", err)
+	}
+	resp = requestRecovery(recovery)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected spent recovery key to get 403, got %d", resp.StatusCode)
+	}
+}
+
+func TestFrontPageSettings(t *testing.T) {
+	if _, err := NewServer(nil, nil, nil, config.Config{FrontPage: config.FrontPage{DefaultSort: "best"}}); err == nil {
+		t.Fatal("expected invalid default sort to be rejected")
+	}
+
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+		FrontPage:  config.FrontPage{DefaultSort: "new", PageSize: 2},
+	})
+	token := createTestAccount(t, tc, "frontpage-bot")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	for i := range 3 {
+		resp := tc.postJSON(t, "/api/stories", map[string]any{"title": fmt.Sprintf("Front page story %d", i), "text": "x"}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create story status %d", resp.StatusCode)
+		}
+	}
+
+	var list struct {
+		Sort    string        `json:"sort"`
+		Stories []model.Story `json:"stories"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stories", nil), &list)
+	if list.Sort != "new" || len(list.Stories) != 2 {
+		t.Fatalf("expected 2 stories sorted new, got sort %q with %d stories", list.Sort, len(list.Stories))
+	}
+	if list.Stories[0].Title != "Front page story 2" {
+		t.Fatalf("expected newest story first, got %q", list.Stories[0].Title)
+	}
+}
+
+func TestFrontPagePagination(t *testing.T) {
+	for _, fp := range []config.FrontPage{{PageSize: 20, MaxPageSize: 10}, {MaxPageSize: 60}} {
+		if _, err := NewServer(nil, nil, nil, config.Config{FrontPage: fp}); err == nil {
+			t.Errorf("expected front page settings %+v to be rejected", fp)
+		}
+	}
+
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+		FrontPage:  config.FrontPage{PageSize: 2, MaxPageSize: 10},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "pager-bot")}
+	for i := range 7 {
+		resp := tc.postJSON(t, "/api/stories", map[string]any{"title": fmt.Sprintf("Paged story %d", i), "text": "x"}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("create story status %d", resp.StatusCode)
+		}
+	}
+
+	page := func(path string) string {
+		t.Helper()
+		resp := tc.get(t, path, nil)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s status %d", path, resp.StatusCode)
+		}
+		return string(body)
+	}
+	more := regexp.MustCompile(`<a href="([^"]*cursor=[^"]*)" rel="next">More`)
+
+	body := page("/?sort=new")
+	for _, want := range []string{`<nav class="pagination" aria-label="Pagination">`, `aria-current="page">1</span>`, `aria-label="Page 4"`, "Page 1 of 4", "Paged story 6", "Paged story 5"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("first page missing %q", want)
+		}
+	}
+	m := more.FindStringSubmatch(body)
+	if m == nil {
+		t.Fatal("expected a More link carrying a cursor on the new listing")
+	}
+	body = page(html.UnescapeString(m[1]))
+	if !strings.Contains(body, "Paged story 4") || !strings.Contains(body, "Paged story 3") || strings.Contains(body, "Paged story 5") {
+		t.Error("expected the More link to continue after the first page")
+	}
+	if !strings.Contains(body, "Newest</a>") || strings.Contains(body, "Page 1 of") {
+		t.Error("expected a cursor page to link back to the newest stories instead of numbering pages")
+	}
+
+	body = page("/?sort=top&page=2")
+	if !strings.Contains(body, `aria-current="page">2</span>`) || !strings.Contains(body, `rel="next">Next`) || more.MatchString(body) {
+		t.Error("expected numbered pages and a Next link on the top listing")
+	}
+
+	body = page("/?sort=new&per_page=3")
+	if n := strings.Count(body, "data-story-id="); n != 3 {
+		t.Errorf("per_page=3 showed %d stories", n)
+	}
+	if !strings.Contains(body, "per_page=3&amp;sort=new&amp;page=2") || !strings.Contains(body, `<span aria-current="true">3</span>`) {
+		t.Error("expected page links to keep per_page and the selector to mark it")
+	}
+	if !strings.Contains(body, `per_page=10">10</a>`) || strings.Contains(body, `per_page=20"`) {
+		t.Error("expected page size choices capped at the configured maximum")
+	}
+	if n := strings.Count(page("/?per_page=100"), "data-story-id="); n != 7 {
+		t.Errorf("per_page=100 showed %d stories, want all 7 under the cap of 10", n)
+	}
+}
+
+func TestStoryListTimeRange(t *testing.T) {
+	tc := newTestClient(t)
+	createTestAccount(t, tc, "time-bot")
+	owner, err := tc.store.GetAccountByName(context.Background(), "time-bot")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	ages := map[string]time.Duration{
+		"fresh":       time.Minute,
+		"last week":   3 * 24 * time.Hour,
+		"last month":  20 * 24 * time.Hour,
+		"last season": 90 * 24 * time.Hour,
+	}
+	for title, age := range ages {
+		story := model.Story{Title: "Story from " + title, Text: "x", CreatedAt: time.Now().Add(-age), AccountID: owner.ID}
+		if _, err := tc.store.CreateStory(context.Background(), &story); err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		time string
+		want int
+	}{
+		{"", 4},
+		{"all", 4},
+		{"week", 2},
+		{"month", 3},
+	} {
+		var list StoryListResponse
+		resp := tc.get(t, "/api/stories?sort=new&time="+tt.time, nil)
+		decodeJSON(t, resp, &list)
+		if resp.StatusCode != http.StatusOK || list.Total != tt.want || len(list.Stories) != tt.want {
+			t.Errorf("time=%q: expected %d stories, got %d (total %d, status %d)", tt.time, tt.want, len(list.Stories), list.Total, resp.StatusCode)
+		}
+		if list.TimeRange != tt.time {
+			t.Errorf("time=%q: expected time_range echoed, got %q", tt.time, list.TimeRange)
+		}
+	}
+
+	var list StoryListResponse
+	decodeJSON(t, tc.get(t, "/api/stories?time=today", nil), &list)
+	midnight := time.Now().UTC().Truncate(24 * time.Hour)
+	for _, story := range list.Stories {
+		if story.CreatedAt.Before(midnight) {
+			t.Errorf("time=today listed %q from before midnight UTC", story.Title)
+		}
+	}
+
+	for _, path := range []string{"/api/stories?time=decade", "/?time=decade"} {
+		resp := tc.get(t, path, map[string]string{"Accept": "application/json"})
+		var errResp ErrorResponse
+		decodeJSON(t, resp, &errResp)
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(errResp.Error, "time must be one of") {
+			t.Errorf("%s: expected 400 for an unknown time range, got %d %q", path, resp.StatusCode, errResp.Error)
+		}
+	}
+}
+
+func TestAdminDashboard(t *testing.T) {
+	tc := newTestClient(t)
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	token := createTestAccount(t, tc, "moderated-bot")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	flagger := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "flagger-bot")}
+
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Questionable zebra story", "text": "x"}, headers)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+	resp = tc.postJSON(t, "/api/flags", map[string]any{"target_type": "story", "target_id": story.ID, "reason": "spam"}, flagger)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("flag status %d", resp.StatusCode)
+	}
+
+	// Log in through the dashboard form and drive the API with the cookie.
+	noRedirect := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := noRedirect.PostForm(tc.server.URL+"/admin/login", url.Values{"secret": {"wrong"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || len(resp.Cookies()) != 0 {
+		t.Fatalf("expected failed login to redirect without a cookie, got %d", resp.StatusCode)
+	}
+	resp, err = noRedirect.PostForm(tc.server.URL+"/admin/login", url.Values{"secret": {"admin"}})
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || len(resp.Cookies()) != 1 {
+		t.Fatalf("expected login to set a session cookie, got %d", resp.StatusCode)
+	}
+	session := map[string]string{"Cookie": resp.Cookies()[0].Name + "=" + resp.Cookies()[0].Value}
+	forged := map[string]string{"Cookie": resp.Cookies()[0].Name + "=9999999999.deadbeef"}
+
+	resp = tc.postJSON(t, "/api/admin/hide", map[string]any{"target_type": "story", "target_id": story.ID}, forged)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected forged session to get 401, got %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/admin/hide", map[string]any{"target_type": "story", "target_id": story.ID}, session)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("hide with session status %d", resp.StatusCode)
+	}
+
+	var found struct {
+		Stories []model.Story `json:"stories"`
+	}
+	decodeJSON(t, tc.get(t, "/api/admin/search?q=zebra", admin), &found)
+	if len(found.Stories) != 1 || !found.Stories[0].Hidden {
+		t.Fatalf("expected search to find the hidden story, got %+v", found.Stories)
+	}
+
+	resp = tc.postJSON(t, "/api/admin/unhide", map[string]any{"target_type": "story", "target_id": story.ID}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unhide status %d", resp.StatusCode)
+	}
+	var resolved struct {
+		Resolved int `json:"resolved"`
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/admin/resolve-flags", map[string]any{"target_type": "story", "target_id": story.ID, "resolution": "dismiss"}, admin), &resolved)
+	if resolved.Resolved != 1 {
+		t.Fatalf("expected 1 flag dismissed, got %d", resolved.Resolved)
+	}
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10), nil), &story)
+	if story.Hidden || story.FlagCount != 0 {
+		t.Fatalf("expected visible unflagged story, got hidden=%v flags=%d", story.Hidden, story.FlagCount)
+	}
+
+	resp = tc.postJSON(t, "/api/admin/ban", map[string]any{"account_id": story.AccountID, "reason": "spam"}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ban status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Banned story", "text": "x"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected banned account to get 403, got %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/admin/unban", map[string]any{"account_id": story.AccountID}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unban status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Unbanned story", "text": "x"}, headers)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected unbanned account to post, got %d", resp.StatusCode)
+	}
+
+	var audit struct {
+		Entries []model.AuditEntry `json:"entries"`
+		Total   int                `json:"total"`
+	}
+	decodeJSON(t, tc.get(t, "/api/admin/audit", admin), &audit)
+	var actions []string
+	for _, e := range audit.Entries {
+		actions = append(actions, e.Action)
+	}
+	if got := strings.Join(actions, ","); got != "unban,ban,dismiss-flags,unhide,hide,login" || audit.Total != 6 {
+		t.Fatalf("unexpected audit log (total %d): %s", audit.Total, got)
+	}
+
+	resp = tc.get(t, "/admin", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `name="secret"`) {
+		t.Fatal("expected login form without a session")
+	}
+	resp = tc.get(t, "/admin", session)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Audit log") || !strings.Contains(string(body), "dismiss-flags") {
+		t.Fatalf("expected dashboard with audit log, got %d", resp.StatusCode)
+	}
+}
+
+func TestAccountLinks(t *testing.T) {
+	home := newTestClient(t)
+	t.Run("remote", func(t *testing.T) {
+		testAccountLinks(t, home, newTestClient(t))
+	})
+}
+
+// testAccountLinks runs in a subtest so remote gets its own database.
+func testAccountLinks(t *testing.T, home, remote *testClient) {
+	creds, err := client.GenerateCredentials("federated-bot")
+	if err != nil {
+		t.Fatalf("generate credentials: %v", err)
+	}
+	c := client.New(home.server.URL)
+	accountID, err := c.Register(creds, "", "")
+	if err != nil {
+		t.Fatalf("register home: %v", err)
+	}
+	if err := c.Authenticate(creds); err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	remoteID, err := client.New(remote.server.URL).Register(creds, "", "")
+	if err != nil {
+		t.Fatalf("register remote: %v", err)
+	}
+	otherCreds, _ := client.GenerateCredentials("someone-else")
+	otherID, err := client.New(remote.server.URL).Register(otherCreds, "", "")
+	if err != nil {
+		t.Fatalf("register other: %v", err)
+	}
+
+	if _, err := c.LinkAccount(accountID, creds, remote.server.URL, remoteID); err == nil || !strings.Contains(err.Error(), "(400)") {
+		t.Fatalf("expected a loopback instance to be refused with 400, got %v", err)
+	}
+	allowLoopbackTargets(t)
+	leaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":"internal: db password hunter2"}`, http.StatusInternalServerError)
+	}))
+	defer leaky.Close()
+	if _, err := c.LinkAccount(accountID, creds, leaky.URL, remoteID); err == nil || !strings.Contains(err.Error(), "(502)") || strings.Contains(err.Error(), "hunter2") {
+		t.Fatalf("expected a generic 502 for a failing remote, got %v", err)
+	}
+
+	if _, err := c.LinkAccount(accountID, creds, remote.server.URL, otherID); err == nil || !strings.Contains(err.Error(), "(403)") {
+		t.Fatalf("expected linking an account with a different key to fail with 403, got %v", err)
+	}
+	link, err := c.LinkAccount(accountID, creds, remote.server.URL+"/", remoteID)
+	if err != nil {
+		t.Fatalf("link account: %v", err)
+	}
+	if link.InstanceURL != remote.server.URL || link.RemoteName != "federated-bot" {
+		t.Fatalf("unexpected link %+v", link)
+	}
+
+	profile, err := client.New(home.server.URL).GetAccountProfile(accountID)
+	if err != nil {
+		t.Fatalf("get profile: %v", err)
+	}
+	if len(profile.Links) != 1 || profile.Links[0].RemoteAccountID != remoteID {
+		t.Fatalf("expected profile to show the link, got %+v", profile.Links)
+	}
+	resp := home.get(t, fmt.Sprintf("/accounts/%d", accountID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), fmt.Sprintf("%s/accounts/%d", remote.server.URL, remoteID)) {
+		t.Fatal("expected account page to link the remote profile")
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/api/accounts/%d/links/%d", home.server.URL, accountID, link.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete link status %d", resp.StatusCode)
+	}
+	var links struct {
+		Links []model.AccountLink `json:"links"`
+	}
+	decodeJSON(t, home.get(t, fmt.Sprintf("/api/accounts/%d/links", accountID), nil), &links)
+	if len(links.Links) != 0 {
+		t.Fatalf("expected no links after delete, got %d", len(links.Links))
+	}
+}
+
+func TestFlagResolution(t *testing.T) {
+	tc := newTestClient(t)
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	author := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "flag-author")}
+	var flaggers []map[string]string
+	for i := range 2 {
+		flaggers = append(flaggers, map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, fmt.Sprintf("flagger-%d", i))})
+	}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Contested story", "text": "x"}, author), &story)
+	flag := func(headers map[string]string, reason string) {
+		t.Helper()
+		resp := tc.postJSON(t, "/api/flags", map[string]any{"target_type": "story", "target_id": story.ID, "reason": reason}, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("flag status %d", resp.StatusCode)
+		}
+	}
+	resolve := func(resolution string) int {
+		t.Helper()
+		var out struct {
+			Resolved int `json:"resolved"`
+		}
+		decodeJSON(t, tc.postJSON(t, "/api/admin/resolve-flags", map[string]any{"target_type": "story", "target_id": story.ID, "resolution": resolution}, admin), &out)
+		return out.Resolved
+	}
+
+	resp := tc.postJSON(t, "/api/admin/resolve-flags", map[string]any{"target_type": "story", "target_id": story.ID, "resolution": "ignore"}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown resolution, got %d", resp.StatusCode)
+	}
+
+	flag(flaggers[0], "spam")
+	if n := resolve("dismiss"); n != 1 {
+		t.Fatalf("expected 1 flag dismissed, got %d", n)
+	}
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10), nil), &story)
+	if story.FlagCount != 0 || story.Hidden {
+		t.Fatalf("expected dismissal to reset flag count, got %d hidden=%v", story.FlagCount, story.Hidden)
+	}
+
+	flag(flaggers[1], "off-topic")
+	if n := resolve("uphold"); n != 1 {
+		t.Fatalf("expected 1 flag upheld, got %d", n)
+	}
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10), admin), &story)
+	if story.FlagCount != 1 || !story.Hidden {
+		t.Fatalf("expected upheld story hidden with 1 flag, got %d hidden=%v", story.FlagCount, story.Hidden)
+	}
+
+	var history struct {
+		Flags []model.Flag `json:"flags"`
+	}
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/admin/flags?target_type=story&target_id=%d", story.ID), admin), &history)
+	if len(history.Flags) != 2 || history.Flags[0].State != model.FlagDismissed || history.Flags[1].State != model.FlagUpheld {
+		t.Fatalf("unexpected flag history %+v", history.Flags)
+	}
+
+	var audit struct {
+		Entries []model.AuditEntry `json:"entries"`
+	}
+	decodeJSON(t, tc.get(t, "/api/admin/audit", admin), &audit)
+	if len(audit.Entries) != 2 || audit.Entries[1].Action != "dismiss-flags" || audit.Entries[1].Detail != "1 flags: spam" {
+		t.Fatalf("expected dismissal with reasons in audit log, got %+v", audit.Entries)
+	}
+}
+
+func TestClientCommentThreads(t *testing.T) {
+	tc := newTestClient(t)
+	creds, err := client.GenerateCredentials("thread-bot")
+	if err != nil {
+		t.Fatalf("generate credentials: %v", err)
+	}
+	c := client.New(tc.server.URL)
+	if err := c.RegisterAndAuthenticate(creds); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	story, err := c.PostStory("Threaded discussion story", "", "root", nil)
+	if err != nil {
+		t.Fatalf("post story: %v", err)
+	}
+	top, err := c.PostComment(story.ID, nil, "top-level comment")
+	if err != nil {
+		t.Fatalf("post comment: %v", err)
+	}
+
+	parent, err := c.GetComment(top.ID)
+	if err != nil {
+		t.Fatalf("get comment: %v", err)
+	}
+	if parent.StoryID != story.ID {
+		t.Fatalf("expected comment on story %d, got %d", story.ID, parent.StoryID)
+	}
+	if _, err := c.PostComment(parent.StoryID, &top.ID, "a reply"); err != nil {
+		t.Fatalf("reply: %v", err)
+	}
+	if _, err := c.GetComment(top.ID + 1000); err == nil {
+		t.Fatal("expected missing comment to fail")
+	}
+
+	tree, err := c.GetCommentTree(story.ID)
+	if err != nil {
+		t.Fatalf("get tree: %v", err)
+	}
+	if len(tree) != 1 || len(tree[0].Children) != 1 || tree[0].Children[0].Comment.Text != "a reply" {
+		t.Fatalf("unexpected tree %+v", tree)
+	}
+	if tree[0].Comment.AccountName != "thread-bot" {
+		t.Fatalf("expected author name in tree, got %q", tree[0].Comment.AccountName)
+	}
+}
+
+func TestFlagWeighting(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000},
+		Moderation: config.Moderation{FlagHideWeight: 3},
+	})
+	ctx := context.Background()
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	author := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "weighted-author")}
+	trusted := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "trusted-flagger")}
+	account, err := tc.store.GetAccountByName(ctx, "trusted-flagger")
+	if err != nil {
+		t.Fatalf("get trusted account: %v", err)
+	}
+	if err := tc.store.UpdateAccountKarma(ctx, account.ID, 999); err != nil {
+		t.Fatalf("set karma: %v", err)
+	}
+
+	var stories []model.Story
+	for i := range 3 {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": fmt.Sprintf("Weighted story %d", i), "text": "x"}, author), &story)
+		stories = append(stories, story)
+	}
+	type flagResult struct {
+		Weight     float64 `json:"weight"`
+		FlagWeight float64 `json:"flag_weight"`
+	}
+	flag := func(headers map[string]string, storyID int64) flagResult {
+		t.Helper()
+		var out flagResult
+		decodeJSON(t, tc.postJSON(t, "/api/flags", map[string]any{"target_type": "story", "target_id": storyID}, headers), &out)
+		return out
+	}
+	hidden := func(storyID int64) bool {
+		t.Helper()
+		var story model.Story
+		decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(storyID, 10), admin), &story)
+		return story.Hidden
+	}
+
+	// Two upheld flags build the trusted account's record; the second
+	// already counts more than the first.
+	for i, want := range []float64{1.5, 2} {
+		story := stories[i]
+		if got := flag(trusted, story.ID); got.Weight != want {
+			t.Fatalf("expected trusted flag %d to weigh %v, got %v", i, want, got.Weight)
+		}
+		resp := tc.postJSON(t, "/api/admin/resolve-flags", map[string]any{"target_type": "story", "target_id": story.ID, "resolution": "uphold"}, admin)
+		resp.Body.Close()
+	}
+
+	target := stories[2].ID
+	for i := range 3 {
+		headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, fmt.Sprintf("throwaway-%d", i))}
+		if got := flag(headers, target); got.Weight != 0.25 {
+			t.Fatalf("expected weight 0.25 for a new account, got %v", got.Weight)
+		}
+	}
+	if hidden(target) {
+		t.Fatal("expected three throwaway flags not to hide the story")
+	}
+	var flagged struct {
+		Stories []model.Story `json:"stories"`
+	}
+	decodeJSON(t, tc.get(t, "/api/flagged", nil), &flagged)
+	if len(flagged.Stories) != 1 || flagged.Stories[0].FlagCount != 3 || flagged.Stories[0].FlagWeight != 0.75 {
+		t.Fatalf("expected flagged listing with weight 0.75, got %+v", flagged.Stories)
+	}
+
+	got := flag(trusted, target)
+	if got.Weight != 2.25 || got.FlagWeight != 3 {
+		t.Fatalf("expected accurate flagger to weigh 2.25 for a total of 3, got %+v", got)
+	}
+	if !hidden(target) {
+		t.Fatal("expected story hidden once flag weight reached the threshold")
+	}
+}
+
+func TestFlagWeight(t *testing.T) {
+	cases := []struct {
+		karma, upheld, dismissed int
+		want                     float64
+	}{
+		{0, 0, 0, 0.25},
+		{-10, 5, 0, 0.43},
+		{30, 0, 0, 1},
+		{30, 0, 2, 0.5},
+		{999, 2, 0, 2.25},
+		{1_000_000, 1000, 0, 3},
+	}
+	for _, tc := range cases {
+		if got := flagWeight(tc.karma, tc.upheld, tc.dismissed); got != tc.want {
+			t.Errorf("flagWeight(%d, %d, %d) = %v, want %v", tc.karma, tc.upheld, tc.dismissed, got, tc.want)
+		}
+	}
+}
+
+func TestThreadLockAndPin(t *testing.T) {
+	tc := newTestClient(t)
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	author := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "thread-owner")}
+	other := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "thread-guest")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Lockable discussion", "text": "x"}, author), &story)
+	comment := func(headers map[string]string, parent *int64, text string) (model.Comment, int) {
+		t.Helper()
+		resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "parent_id": parent, "text": text}, headers)
+		var c model.Comment
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return c, resp.StatusCode
+		}
+		decodeJSON(t, resp, &c)
+		return c, http.StatusOK
+	}
+	post := func(path string, body map[string]any, headers map[string]string) int {
+		t.Helper()
+		resp := tc.postJSON(t, path, body, headers)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	storyPath := "/api/stories/" + strconv.FormatInt(story.ID, 10)
+
+	first, _ := comment(other, nil, "first top-level comment")
+	reply, _ := comment(author, &first.ID, "a reply")
+	comment(author, nil, "second top-level comment")
+
+	if code := post(storyPath+"/pin", map[string]any{"comment_id": first.ID}, other); code != http.StatusForbidden {
+		t.Fatalf("expected 403 pinning someone else's story, got %d", code)
+	}
+	if code := post(storyPath+"/pin", map[string]any{"comment_id": reply.ID}, author); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 pinning a reply, got %d", code)
+	}
+	if code := post(storyPath+"/pin", map[string]any{"comment_id": first.ID}, author); code != http.StatusOK {
+		t.Fatalf("pin status %d", code)
+	}
+	var listed struct {
+		Comments []model.Comment `json:"comments"`
+	}
+	decodeJSON(t, tc.get(t, storyPath+"/comments?sort=new", nil), &listed)
+	if len(listed.Comments) != 3 || listed.Comments[0].ID != first.ID || !listed.Comments[0].Pinned || listed.Comments[1].Pinned {
+		t.Fatalf("expected pinned comment first, got %+v", listed.Comments)
+	}
+	var tree struct {
+		Comments []model.CommentNode `json:"comments"`
+	}
+	decodeJSON(t, tc.get(t, storyPath+"/comments?view=tree&sort=new", nil), &tree)
+	if len(tree.Comments) != 2 || tree.Comments[0].Comment.ID != first.ID || len(tree.Comments[0].Children) != 1 {
+		t.Fatalf("expected the pinned comment and its reply first in the tree, got %+v", tree.Comments)
+	}
+
+	if code := post(storyPath+"/lock", map[string]any{"locked": true}, other); code != http.StatusForbidden {
+		t.Fatalf("expected 403 locking someone else's story, got %d", code)
+	}
+	if code := post(storyPath+"/lock", map[string]any{"locked": true}, author); code != http.StatusOK {
+		t.Fatalf("lock status %d", code)
+	}
+	if _, code := comment(other, &first.ID, "too late"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 commenting on a locked story, got %d", code)
+	}
+	decodeJSON(t, tc.get(t, storyPath, nil), &story)
+	if !story.Locked || story.PinnedCommentID == nil || *story.PinnedCommentID != first.ID {
+		t.Fatalf("expected locked story with pin, got locked=%v pin=%v", story.Locked, story.PinnedCommentID)
+	}
+	resp := tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "thread is locked") || !strings.Contains(string(body), "pinned") {
+		t.Fatal("expected lock and pin indicators on the story page")
+	}
+
+	if code := post(storyPath+"/lock", map[string]any{"locked": false}, admin); code != http.StatusOK {
+		t.Fatalf("moderator unlock status %d", code)
+	}
+	if code := post(storyPath+"/pin", map[string]any{"comment_id": nil}, admin); code != http.StatusOK {
+		t.Fatalf("moderator unpin status %d", code)
+	}
+	if _, code := comment(other, nil, "open again"); code != http.StatusOK {
+		t.Fatalf("expected comments after unlock, got %d", code)
+	}
+	decodeJSON(t, tc.get(t, storyPath+"/comments", nil), &listed)
+	for _, c := range listed.Comments {
+		if c.Pinned {
+			t.Fatalf("expected no pinned comment after unpin, got %+v", c)
+		}
+	}
+	var audit struct {
+		Entries []model.AuditEntry `json:"entries"`
+	}
+	decodeJSON(t, tc.get(t, "/api/admin/audit", admin), &audit)
+	if len(audit.Entries) != 2 || audit.Entries[0].Action != "unpin-comment" || audit.Entries[1].Action != "unlock-story" {
+		t.Fatalf("expected only moderator actions audited, got %+v", audit.Entries)
+	}
+}
+
+func TestStoryTemplates(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:         config.RateLimits{StoryPerMinute: 1000},
+		DuplicateWindow:    72 * time.Hour,
+		DuplicateThreshold: 0.8,
+	})
+	owner := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "template-owner")}
+	other := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "template-guest")}
+
+	if resp := tc.postJSON(t, "/api/story-templates", map[string]any{"name": "daily", "title": "Daily thread"}, owner); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 without url or text, got %d", resp.StatusCode)
+	}
+	var tmpl model.StoryTemplate
+	decodeJSON(t, tc.postJSON(t, "/api/story-templates", map[string]any{
+		"name":  "daily",
+		"title": "Daily Discussion Thread – {date}",
+		"text":  "Topic: {topic}",
+		"tags":  []string{"meta"},
+	}, owner), &tmpl)
+	if tmpl.ID == 0 || tmpl.Name != "daily" {
+		t.Fatalf("unexpected template %+v", tmpl)
+	}
+
+	postPath := "/api/story-templates/" + strconv.FormatInt(tmpl.ID, 10) + "/stories"
+	resp := tc.postJSON(t, postPath, map[string]any{}, owner)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "topic") {
+		t.Fatalf("expected 400 naming the missing variable, got %d %s", resp.StatusCode, body)
+	}
+	if resp := tc.postJSON(t, postPath, map[string]any{"vars": map[string]string{"topic": "x"}}, other); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for someone else's template, got %d", resp.StatusCode)
+	}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, postPath, map[string]any{"vars": map[string]string{"topic": "agents"}}, owner), &story)
+	wantTitle := "Daily Discussion Thread – " + time.Now().UTC().Format("2006-01-02")
+	if story.Title != wantTitle || story.Text != "Topic: agents" || len(story.Tags) != 1 || story.Tags[0] != "meta" {
+		t.Fatalf("unexpected story %+v", story)
+	}
+
+	var dup struct {
+		DuplicateOf int64 `json:"duplicate_of"`
+	}
+	decodeJSON(t, tc.postJSON(t, postPath, map[string]any{"vars": map[string]string{"topic": "agents"}}, owner), &dup)
+	if dup.DuplicateOf != story.ID {
+		t.Fatalf("expected repost to return duplicate_of %d, got %d", story.ID, dup.DuplicateOf)
+	}
+	var next model.Story
+	decodeJSON(t, tc.postJSON(t, postPath, map[string]any{"vars": map[string]string{"date": "2030-01-01", "topic": "agents"}}, owner), &next)
+	if next.ID == story.ID || next.Title != "Daily Discussion Thread – 2030-01-01" {
+		t.Fatalf("expected a new story for another date, got %+v", next)
+	}
+
+	var listed struct {
+		Templates []model.StoryTemplate `json:"templates"`
+	}
+	decodeJSON(t, tc.get(t, "/api/story-templates", other), &listed)
+	if len(listed.Templates) != 0 {
+		t.Fatalf("expected no templates for another account, got %+v", listed.Templates)
+	}
+	decodeJSON(t, tc.get(t, "/api/story-templates", owner), &listed)
+	if len(listed.Templates) != 1 || listed.Templates[0].ID != tmpl.ID {
+		t.Fatalf("unexpected templates %+v", listed.Templates)
+	}
+
+	del := func(headers map[string]string) int {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+"/api/story-templates/"+strconv.FormatInt(tmpl.ID, 10), nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("delete: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if code := del(other); code != http.StatusNotFound {
+		t.Fatalf("expected 404 deleting someone else's template, got %d", code)
+	}
+	if code := del(owner); code != http.StatusOK {
+		t.Fatalf("delete status %d", code)
+	}
+	if resp := tc.postJSON(t, postPath, map[string]any{"vars": map[string]string{"topic": "x"}}, owner); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", resp.StatusCode)
+	}
+}
+
+func TestStoryEditHistory(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "story-editor")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Original headline", "text": "x", "tags": []string{"ai"}}, headers), &story)
+	if story.EditedAt != nil {
+		t.Fatalf("expected new story not to be edited, got %v", story.EditedAt)
+	}
+	storyPath := "/api/stories/" + strconv.FormatInt(story.ID, 10)
+	edit := func(body map[string]any) {
+		t.Helper()
+		payload, _ := json.Marshal(body)
+		req, _ := http.NewRequest(http.MethodPatch, tc.server.URL+storyPath, bytes.NewReader(payload))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("edit: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("edit status %d", resp.StatusCode)
+		}
+	}
+	var history struct {
+		Revisions []model.StoryRevision `json:"revisions"`
+	}
+
+	edit(map[string]any{"title": "Original headline"})
+	decodeJSON(t, tc.get(t, storyPath+"/revisions", nil), &history)
+	if len(history.Revisions) != 0 {
+		t.Fatalf("expected an unchanged edit not to be recorded, got %+v", history.Revisions)
+	}
+
+	edit(map[string]any{"title": "Corrected headline"})
+	edit(map[string]any{"tags": []string{"ai", "news"}})
+	decodeJSON(t, tc.get(t, storyPath+"/revisions", nil), &history)
+	if len(history.Revisions) != 2 {
+		t.Fatalf("expected 2 revisions, got %+v", history.Revisions)
+	}
+	first, second := history.Revisions[0], history.Revisions[1]
+	if first.Title != "Original headline" || len(first.Tags) != 1 || second.Title != "Corrected headline" || len(second.Tags) != 1 {
+		t.Fatalf("expected revisions to hold the replaced versions, got %+v", history.Revisions)
+	}
+
+	decodeJSON(t, tc.get(t, storyPath, nil), &story)
+	if story.EditedAt == nil || !story.EditedAt.Equal(second.EditedAt) || story.Title != "Corrected headline" || len(story.Tags) != 2 {
+		t.Fatalf("expected edited story, got %+v", story)
+	}
+	resp := tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), storyPath+"/revisions") {
+		t.Fatal("expected an edited marker linking the history on the story page")
+	}
+
+	if resp := tc.get(t, "/api/stories/999999/revisions", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing story, got %d", resp.StatusCode)
+	}
+}
+
+func TestCommentPayload(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:      config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000},
+		MaxPayloadBytes: 64,
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "payload-bot")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Benchmark results thread", "text": "x"}, headers), &story)
+
+	for _, tt := range []struct {
+		name string
+		body map[string]any
+	}{
+		{"schema without payload", map[string]any{"payload_schema": "benchmark/v1"}},
+		{"payload without schema", map[string]any{"payload": map[string]any{"ms": 12}}},
+		{"scalar payload", map[string]any{"payload": 12, "payload_schema": "benchmark/v1"}},
+		{"bad schema", map[string]any{"payload": []int{1}, "payload_schema": "not a schema"}},
+		{"oversized payload", map[string]any{"payload": map[string]string{"notes": strings.Repeat("x", 64)}, "payload_schema": "benchmark/v1"}},
+	} {
+		tt.body["story_id"] = story.ID
+		tt.body["text"] = "results"
+		resp := tc.postJSON(t, "/api/comments", tt.body, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tt.name, resp.StatusCode)
+		}
+	}
+
+	payload := `{"ms": 12.5, "runs": [1, 2]}`
+	var created model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{
+		"story_id":       story.ID,
+		"text":           "p50 is 12.5ms",
+		"payload":        json.RawMessage(payload),
+		"payload_schema": "benchmark/v1",
+	}, headers), &created)
+	var plain model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "no data here"}, headers), &plain)
+
+	var got model.Comment
+	decodeJSON(t, tc.get(t, "/api/comments/"+strconv.FormatInt(created.ID, 10), nil), &got)
+	if got.PayloadSchema != "benchmark/v1" || string(got.Payload) != `{"ms":12.5,"runs":[1,2]}` {
+		t.Fatalf("expected payload back, got %q %s", got.PayloadSchema, got.Payload)
+	}
+	var listed struct {
+		Comments []model.Comment `json:"comments"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10)+"/comments", nil), &listed)
+	for _, c := range listed.Comments {
+		hasPayload := c.PayloadSchema != "" && len(c.Payload) > 0 && string(c.Payload) != "null"
+		if hasPayload != (c.ID == created.ID) {
+			t.Fatalf("unexpected payload on comment %d: %q %s", c.ID, c.PayloadSchema, c.Payload)
+		}
+	}
+
+	resp := tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "benchmark/v1") {
+		t.Fatal("expected the payload schema on the story page")
+	}
+}
+
+func TestCommentTreePaging(t *testing.T) {
+	tc := newTestClient(t)
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "tree-pager")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "A very busy thread", "text": "x"}, headers), &story)
+	comment := func(parent *int64, text string) int64 {
+		t.Helper()
+		var c model.Comment
+		decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "parent_id": parent, "text": text}, headers), &c)
+		return c.ID
+	}
+	root := comment(nil, "root")
+	comment(nil, "second")
+	comment(nil, "third")
+	for i := 0; i < 3; i++ {
+		comment(&root, fmt.Sprintf("reply %d", i))
+	}
+
+	type treePage struct {
+		Comments []model.CommentNode `json:"comments"`
+		More     int                 `json:"more"`
+		Cursor   string              `json:"cursor"`
+	}
+	base := "/api/stories/" + strconv.FormatInt(story.ID, 10) + "/comments?view=tree&sort=new"
+	var page treePage
+	decodeJSON(t, tc.get(t, base+"&limit=2&depth=1", nil), &page)
+	if len(page.Comments) != 2 || page.More != 1 || page.Cursor == "" {
+		t.Fatalf("expected 2 top-level comments and 1 more, got %d more=%d", len(page.Comments), page.More)
+	}
+	decodeJSON(t, tc.get(t, base+"&limit=2&depth=1&cursor="+page.Cursor, nil), &page)
+	if len(page.Comments) != 1 || page.Comments[0].Comment.ID != root || page.More != 0 {
+		t.Fatalf("expected the root comment on the second page, got %+v", page)
+	}
+	rootNode := page.Comments[0]
+	if len(rootNode.Children) != 0 || rootNode.MoreReplies != 3 {
+		t.Fatalf("expected replies cut at depth 1, got %+v", rootNode)
+	}
+
+	decodeJSON(t, tc.get(t, base+"&limit=2&parent="+strconv.FormatInt(root, 10), nil), &page)
+	if len(page.Comments) != 2 || page.More != 1 {
+		t.Fatalf("expected 2 of 3 replies, got %d more=%d", len(page.Comments), page.More)
+	}
+	if resp := tc.get(t, base+"&parent=999999", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown parent, got %d", resp.StatusCode)
+	}
+	if resp := tc.get(t, base+"&cursor=nope", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad cursor, got %d", resp.StatusCode)
+	}
+
+	resp := tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10)+"?depth=1", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `class="load-more"`) || !strings.Contains(string(body), "load 3 more replies") {
+		t.Fatal("expected a load-more link for the collapsed replies")
+	}
+	resp = tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10)+"?fragment=1&parent="+strconv.FormatInt(root, 10), nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(body), "<html") || strings.Count(string(body), `class="comment"`) != 3 {
+		t.Fatalf("expected a fragment with the 3 replies, got %s", body)
+	}
+}
+
+func TestCollapseLowScoreComments(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:       config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000},
+		CommentThreshold: -2,
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "noisy-bot")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Thread with noise", "text": "x"}, headers), &story)
+	var good, noisy model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "useful comment"}, headers), &good)
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "spammy comment"}, headers), &noisy)
+	if err := tc.store.UpdateCommentScore(context.Background(), noisy.ID, -4); err != nil {
+		t.Fatal(err)
+	}
+
+	base := "/api/stories/" + strconv.FormatInt(story.ID, 10) + "/comments?view=tree"
+	var page struct {
+		Comments  []model.CommentNode `json:"comments"`
+		Threshold int                 `json:"threshold"`
+	}
+	find := func(id int64) model.Comment {
+		t.Helper()
+		for _, n := range page.Comments {
+			if n.Comment.ID == id {
+				return n.Comment
+			}
+		}
+		t.Fatalf("comment %d missing from tree", id)
+		return model.Comment{}
+	}
+	decodeJSON(t, tc.get(t, base, nil), &page)
+	if c := find(noisy.ID); !c.Collapsed || c.Text != "" || page.Threshold != -2 {
+		t.Fatalf("expected the -3 comment collapsed at threshold -2, got %+v (threshold %d)", c, page.Threshold)
+	}
+	if c := find(good.ID); c.Collapsed || c.Text != "useful comment" {
+		t.Fatalf("expected the good comment shown, got %+v", c)
+	}
+
+	decodeJSON(t, tc.get(t, base+"&threshold=-10", nil), &page)
+	if c := find(noisy.ID); c.Collapsed || c.Text != "spammy comment" {
+		t.Fatalf("expected the override to show the comment, got %+v", c)
+	}
+	if resp := tc.get(t, base+"&threshold=low", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad threshold, got %d", resp.StatusCode)
+	}
+	var full model.Comment
+	decodeJSON(t, tc.get(t, "/api/comments/"+strconv.FormatInt(noisy.ID, 10), nil), &full)
+	if full.Text != "spammy comment" {
+		t.Fatalf("expected the dedicated fetch to return the text, got %q", full.Text)
+	}
+
+	resp := tc.get(t, "/stories/"+strconv.FormatInt(story.ID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(body), "spammy comment") || !strings.Contains(string(body), "expand-comment") {
+		t.Fatal("expected the story page to collapse the low-score comment")
+	}
+}
+
+func TestProposalConsensus(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000},
+	})
+	alice := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "alice-bot")}
+	bob := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "bob-bot")}
+	carol := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "carol-bot")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Schema migration", "text": "x"}, alice), &story)
+	var proposal, plain model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "Adopt schema v2", "kind": "proposal"}, alice), &proposal)
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "just chatting"}, alice), &plain)
+	if proposal.Kind != model.CommentProposal {
+		t.Fatalf("expected a proposal, got kind %q", proposal.Kind)
+	}
+
+	reply := func(headers map[string]string, parent int64, position string) *http.Response {
+		return tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "parent_id": parent, "text": position + " from me", "position": position}, headers)
+	}
+	for _, tt := range []struct {
+		name string
+		resp *http.Response
+	}{
+		{"position on a plain comment", reply(bob, plain.ID, model.PositionAgree)},
+		{"unknown position", reply(bob, proposal.ID, "maybe")},
+		{"unknown kind", tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "x", "kind": "poll"}, bob)},
+		{"top-level position", tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "x", "position": "agree"}, bob)},
+	} {
+		if tt.resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", tt.name, tt.resp.StatusCode)
+		}
+	}
+
+	for _, r := range []*http.Response{
+		reply(bob, proposal.ID, model.PositionDisagree),
+		reply(carol, proposal.ID, model.PositionAbstain),
+		reply(bob, proposal.ID, model.PositionAgree), // bob changes position
+	} {
+		if r.StatusCode != http.StatusOK {
+			t.Fatalf("expected position reply to succeed, got %d", r.StatusCode)
+		}
+	}
+
+	var result struct {
+		ProposalID int64                    `json:"proposal_id"`
+		Tally      model.ProposalTally      `json:"tally"`
+		Positions  []model.ProposalPosition `json:"positions"`
+	}
+	decodeJSON(t, tc.get(t, "/api/comments/"+strconv.FormatInt(proposal.ID, 10)+"/positions", nil), &result)
+	want := model.ProposalTally{Agree: 1, Abstain: 1}
+	if result.Tally != want || len(result.Positions) != 2 {
+		t.Fatalf("expected latest positions only, got %+v %+v", result.Tally, result.Positions)
+	}
+	if resp := tc.get(t, "/api/comments/"+strconv.FormatInt(plain.ID, 10)+"/positions", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a plain comment, got %d", resp.StatusCode)
+	}
+
+	var flat struct {
+		Comments []model.Comment `json:"comments"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10)+"/comments", nil), &flat)
+	for _, c := range flat.Comments {
+		switch {
+		case c.ID == proposal.ID && (c.Tally == nil || *c.Tally != want):
+			t.Fatalf("expected the proposal tally in the listing, got %+v", c.Tally)
+		case c.ID != proposal.ID && c.Tally != nil:
+			t.Fatalf("expected no tally on comment %d", c.ID)
+		}
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	tc := newTestClient(t)
+	resp := tc.get(t, "/api/stories", nil)
+	resp.Body.Close()
+	if got := resp.Header.Get(headerChangelog); got != latestChangelogID() {
+		t.Fatalf("expected %s header %q, got %q", headerChangelog, latestChangelogID(), got)
+	}
+
+	var changes struct {
+		Latest  string           `json:"latest"`
+		Entries []changelogEntry `json:"entries"`
+	}
+	decodeJSON(t, tc.get(t, "/api/changelog", nil), &changes)
+	if changes.Latest != latestChangelogID() || len(changes.Entries) != len(apiChangelog) {
+		t.Fatalf("expected the full changelog, got %+v", changes)
+	}
+	decodeJSON(t, tc.get(t, "/api/changelog?since="+apiChangelog[1].ID, nil), &changes)
+	if len(changes.Entries) != 1 {
+		t.Fatalf("expected one newer entry, got %d", len(changes.Entries))
+	}
+	if resp := tc.get(t, "/api/changelog?since=unknown", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown entry, got %d", resp.StatusCode)
+	}
+
+	events := make(chan string, len(apiChangelog)+1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Event string         `json:"event"`
+			Entry changelogEntry `json:"entry"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		events <- payload.Event + " " + payload.Entry.ID
+	}))
+	defer hook.Close()
+	cfg := config.Config{HashSecret: "test-hash", AdminSecret: "admin", ChangelogWebhook: hook.URL}
+	server, err := NewServer(tc.store, auth.NewService(tc.store, time.Hour, time.Minute), rate.NewMemory(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := server.AnnounceChangelog(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]bool{}
+	for range apiChangelog {
+		select {
+		case ev := <-events:
+			got[ev] = true
+		case <-time.After(5 * time.Second):
+			t.Fatalf("expected %d changelog events, got %d", len(apiChangelog), len(got))
+		}
+	}
+	if !got["changelog.published "+latestChangelogID()] {
+		t.Fatalf("expected the latest entry announced, got %v", got)
+	}
+	if err := server.AnnounceChangelog(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case ev := <-events:
+		t.Fatalf("expected each entry announced once, got %q again", ev)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestKarmaRecalculation(t *testing.T) {
+	tc := newTestClient(t)
+	author := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "karma-author")}
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "karma-voter")}
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Karma source", "text": "x"}, author), &story)
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "first"}, author), &comment)
+	for _, v := range []map[string]any{
+		{"target_type": "story", "target_id": story.ID, "value": 1},
+		{"target_type": "comment", "target_id": comment.ID, "value": -1},
+	} {
+		if resp := tc.postJSON(t, "/api/votes", v, voter); resp.StatusCode != http.StatusOK {
+			t.Fatalf("vote failed: %d", resp.StatusCode)
+		}
+	}
+
+	var profile struct {
+		Account model.Account        `json:"account"`
+		Karma   model.KarmaBreakdown `json:"karma_breakdown"`
+	}
+	accountPath := "/api/accounts/" + strconv.FormatInt(story.AccountID, 10)
+	decodeJSON(t, tc.get(t, accountPath, nil), &profile)
+	want := model.KarmaBreakdown{StoryVotes: 1, CommentVotes: -1, Submissions: 2, Total: 2}
+	if profile.Karma != want || profile.Account.Karma != 2 {
+		t.Fatalf("expected breakdown %+v and karma 2, got %+v and %d", want, profile.Karma, profile.Account.Karma)
+	}
+
+	// Hiding the story leaves the stored karma stale until recalculated.
+	if resp := tc.postJSON(t, "/api/admin/hide", map[string]any{"target_type": "story", "target_id": story.ID}, admin); resp.StatusCode != http.StatusOK {
+		t.Fatalf("hide failed: %d", resp.StatusCode)
+	}
+	decodeJSON(t, tc.get(t, accountPath, nil), &profile)
+	if profile.Karma.Total != 0 || profile.Account.Karma != 2 {
+		t.Fatalf("expected a computed total of 0 against stored 2, got %+v and %d", profile.Karma, profile.Account.Karma)
+	}
+
+	if resp := tc.postJSON(t, "/api/admin/recalculate-karma", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin secret, got %d", resp.StatusCode)
+	}
+	var result struct {
+		Changed []store.KarmaChange `json:"changed"`
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/admin/recalculate-karma", nil, admin), &result)
+	if len(result.Changed) != 1 || result.Changed[0] != (store.KarmaChange{AccountID: story.AccountID, From: 2, To: 0}) {
+		t.Fatalf("expected the author corrected from 2 to 0, got %+v", result.Changed)
+	}
+	decodeJSON(t, tc.get(t, accountPath, nil), &profile)
+	if profile.Account.Karma != 0 {
+		t.Fatalf("expected recalculated karma 0, got %d", profile.Account.Karma)
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/admin/recalculate-karma", nil, admin), &result)
+	if len(result.Changed) != 0 {
+		t.Fatalf("expected nothing left to correct, got %+v", result.Changed)
+	}
+}
+
+func TestCommentCountMaintenance(t *testing.T) {
+	tc := newTestClient(t)
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "count-bot")}
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Counted thread", "text": "x"}, headers), &story)
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "first"}, headers), &comment)
+	storyPath := "/api/stories/" + strconv.FormatInt(story.ID, 10)
+	assertCount := func(want int) {
+		t.Helper()
+		var got model.Story
+		decodeJSON(t, tc.get(t, storyPath, nil), &got)
+		if got.CommentCount != want {
+			t.Fatalf("expected comment_count %d, got %d", want, got.CommentCount)
+		}
+	}
+	assertCount(1)
+
+	target := map[string]any{"target_type": "comment", "target_id": comment.ID}
+	if resp := tc.postJSON(t, "/api/admin/hide", target, admin); resp.StatusCode != http.StatusOK {
+		t.Fatalf("hide failed: %d", resp.StatusCode)
+	}
+	assertCount(0)
+	if resp := tc.postJSON(t, "/api/admin/unhide", target, admin); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unhide failed: %d", resp.StatusCode)
+	}
+	assertCount(1)
+
+	// Comments written straight to the store bypass the counter.
+	if _, err := tc.store.CreateComment(context.Background(), &model.Comment{StoryID: story.ID, Text: "uncounted", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	assertCount(1)
+
+	if resp := tc.postJSON(t, "/api/admin/recount-comments", nil, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin secret, got %d", resp.StatusCode)
+	}
+	var result struct {
+		Changed []store.CommentCountChange `json:"changed"`
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/admin/recount-comments", nil, admin), &result)
+	if len(result.Changed) != 1 || result.Changed[0] != (store.CommentCountChange{StoryID: story.ID, From: 1, To: 2}) {
+		t.Fatalf("expected the story corrected from 1 to 2, got %+v", result.Changed)
+	}
+	assertCount(2)
+	decodeJSON(t, tc.postJSON(t, "/api/admin/recount-comments", nil, admin), &result)
+	if len(result.Changed) != 0 {
+		t.Fatalf("expected nothing left to correct, got %+v", result.Changed)
+	}
+}
+
+func TestPreviewAPI(t *testing.T) {
+	if resp := newTestClient(t).get(t, "/api/preview/search?q=anything", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected the preview API to be off by default, got %d", resp.StatusCode)
+	}
+
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000},
+		PreviewAPI: true,
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "preview-bot")}
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	var visible, hidden model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Preview zebra visible", "text": "x"}, headers), &visible)
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Preview zebra hidden", "text": "x"}, headers), &hidden)
+	tc.postJSON(t, "/api/admin/hide", map[string]any{"target_type": "story", "target_id": hidden.ID}, admin).Body.Close()
+
+	resp := tc.get(t, "/api/preview/search?q=zebra", nil)
+	if resp.Header.Get(headerStability) != "experimental" || resp.Header.Get("Warning") == "" {
+		t.Fatalf("expected instability headers, got %v", resp.Header)
+	}
+	var found struct {
+		Stories []model.Story     `json:"stories"`
+		Preview map[string]string `json:"preview"`
+	}
+	decodeJSON(t, resp, &found)
+	if len(found.Stories) != 1 || found.Stories[0].ID != visible.ID || found.Preview["stability"] != "experimental" {
+		t.Fatalf("expected only the visible story with a preview notice, got %+v", found)
+	}
+
+	react := map[string]any{"target_type": "story", "target_id": visible.ID, "reaction": "rocket"}
+	if resp := tc.postJSON(t, "/api/preview/reactions", react, nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+	var toggled struct {
+		Added  bool           `json:"added"`
+		Counts map[string]int `json:"counts"`
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/preview/reactions", react, headers), &toggled)
+	if !toggled.Added || toggled.Counts["rocket"] != 1 {
+		t.Fatalf("expected the reaction added, got %+v", toggled)
+	}
+	toggled.Counts = nil
+	decodeJSON(t, tc.postJSON(t, "/api/preview/reactions", react, headers), &toggled)
+	if toggled.Added || toggled.Counts["rocket"] != 0 {
+		t.Fatalf("expected the second toggle to remove it, got %+v", toggled)
+	}
+	for _, bad := range []map[string]any{
+		{"target_type": "story", "target_id": visible.ID, "reaction": "shrug"},
+		{"target_type": "vote", "target_id": visible.ID, "reaction": "rocket"},
+	} {
+		if resp := tc.postJSON(t, "/api/preview/reactions", bad, headers); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %v, got %d", bad, resp.StatusCode)
+		}
+	}
+	if resp := tc.postJSON(t, "/api/preview/reactions", map[string]any{"target_type": "story", "target_id": hidden.ID, "reaction": "rocket"}, headers); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a hidden story, got %d", resp.StatusCode)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, tc.server.URL+"/api/preview/stream?after=0:0", nil)
+	stream, err := tc.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Body.Close()
+	if ct := stream.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %q", ct)
+	}
+	var events []string
+	scanner := bufio.NewScanner(stream.Body)
+	for scanner.Scan() && len(events) < 2 {
+		if line := scanner.Text(); strings.HasPrefix(line, "id: ") {
+			events = append(events, strings.TrimPrefix(line, "id: "))
+		}
+	}
+	want := []string{"0:0", fmt.Sprintf("%d:0", visible.ID)}
+	if !slices.Equal(events, want) {
+		t.Fatalf("expected the notice then the visible story, got event ids %v", events)
+	}
+	if resp := tc.get(t, "/api/preview/stream?after=bogus", nil); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad cursor, got %d", resp.StatusCode)
+	}
+}
+
+func TestLeaderboard(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000},
+	})
+	prolific := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "prolific-bot")}
+	chatty := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "chatty-bot")}
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "voter-bot")}
+
+	var stories []model.Story
+	for i := range 3 {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Leaderboard story " + strconv.Itoa(i), "text": "x"}, prolific), &story)
+		stories = append(stories, story)
+	}
+	var chattyStory model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Chatty story", "text": "x"}, chatty), &chattyStory)
+	for _, text := range []string{"First thought", "Second thought"} {
+		if resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": stories[0].ID, "text": text}, chatty); resp.StatusCode != http.StatusOK {
+			t.Fatalf("comment failed: %d", resp.StatusCode)
+		}
+	}
+	if resp := tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": stories[0].ID, "value": 1}, voter); resp.StatusCode != http.StatusOK {
+		t.Fatalf("vote failed: %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Window string                              `json:"window"`
+		Boards map[string][]model.LeaderboardEntry `json:"boards"`
+	}
+	resp := tc.get(t, "/api/leaderboard", nil)
+	if cc := resp.Header.Get("Cache-Control"); cc != leaderboardCacheControl {
+		t.Fatalf("expected Cache-Control %q, got %q", leaderboardCacheControl, cc)
+	}
+	decodeJSON(t, resp, &result)
+	if result.Window != "week" || len(result.Boards) != len(store.Leaderboards) {
+		t.Fatalf("expected every board for the week, got %q with %d boards", result.Window, len(result.Boards))
+	}
+	top := func(board string) model.LeaderboardEntry {
+		t.Helper()
+		if len(result.Boards[board]) == 0 {
+			t.Fatalf("expected entries on %s", board)
+		}
+		return result.Boards[board][0]
+	}
+	// Three submissions and an upvote beat one story and two comments.
+	if e := top("karma"); e.AccountID != stories[0].AccountID || e.Value != 4 || e.Rank != 1 {
+		t.Fatalf("expected prolific-bot to lead karma with 4, got %+v", e)
+	}
+	if e := top("stories"); e.AccountID != stories[0].AccountID || e.Count != 3 {
+		t.Fatalf("expected prolific-bot to lead stories with 3, got %+v", e)
+	}
+	if e := top("comments"); e.AccountID != chattyStory.AccountID || e.Count != 2 || len(result.Boards["comments"]) != 1 {
+		t.Fatalf("expected only chatty-bot on comments, got %+v", result.Boards["comments"])
+	}
+	// chatty-bot has too few stories to rank by average.
+	if board := result.Boards["avg_story_score"]; len(board) != 1 || board[0].AccountID != stories[0].AccountID {
+		t.Fatalf("expected only prolific-bot on avg_story_score, got %+v", board)
+	}
+
+	result.Boards = nil
+	decodeJSON(t, tc.get(t, "/api/leaderboard?board=stories&window=all&limit=1", nil), &result)
+	if len(result.Boards) != 1 || len(result.Boards["stories"]) != 1 || result.Window != "all" {
+		t.Fatalf("expected one all-time stories entry, got %+v", result)
+	}
+	for _, query := range []string{"window=year", "board=followers"} {
+		if resp := tc.get(t, "/api/leaderboard?"+query, nil); resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("expected 400 for %s, got %d", query, resp.StatusCode)
+		}
+	}
+
+	resp = tc.get(t, "/leaderboard?window=month", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "prolific-bot") {
+		t.Fatalf("expected the leaderboard page to list prolific-bot, got %d", resp.StatusCode)
+	}
+}
+
+func TestBadges(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "slashbot.json")
+	custom := `{"badges":[{"id":"first-words","name":"First words","description":"Posted a comment","metric":"comments","threshold":1}]}`
+	if err := os.WriteFile(path, []byte(custom), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	tc := newTestClientWithConfig(t, config.Config{ConfigFile: path})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "badge-bot")}
+
+	var defs struct {
+		Badges []badgeView `json:"badges"`
+	}
+	decodeJSON(t, tc.get(t, "/api/badges", nil), &defs)
+	if len(defs.Badges) != 5 || defs.Badges[4].ID != "first-words" {
+		t.Fatalf("expected the built-in badges and first-words, got %+v", defs.Badges)
+	}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Badge-worthy story", "text": "x"}, headers), &story)
+	var profile struct {
+		Badges []badgeView `json:"badges"`
+	}
+	accountPath := "/api/accounts/" + strconv.FormatInt(story.AccountID, 10)
+	decodeJSON(t, tc.get(t, accountPath, nil), &profile)
+	ids := func(views []badgeView) []string {
+		var out []string
+		for _, v := range views {
+			out = append(out, v.ID)
+		}
+		slices.Sort(out)
+		return out
+	}
+	if got := ids(profile.Badges); !slices.Equal(got, []string{"early-adopter", "first-story"}) {
+		t.Fatalf("expected early-adopter and first-story, got %v", got)
+	}
+	if profile.Badges[0].AwardedAt == nil || profile.Badges[0].Name == "" {
+		t.Fatalf("expected named, dated badges, got %+v", profile.Badges[0])
+	}
+
+	if resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "My first comment"}, headers); resp.StatusCode != http.StatusOK {
+		t.Fatalf("comment failed: %d", resp.StatusCode)
+	}
+	var bots struct {
+		Badges map[string][]badgeView `json:"badges"`
+	}
+	decodeJSON(t, tc.get(t, "/bots", map[string]string{"Accept": "application/json"}), &bots)
+	if got := ids(bots.Badges[strconv.FormatInt(story.AccountID, 10)]); !slices.Equal(got, []string{"early-adopter", "first-story", "first-words"}) {
+		t.Fatalf("expected the custom badge in the bots directory, got %v", got)
+	}
+
+	resp := tc.get(t, "/accounts/"+strconv.FormatInt(story.AccountID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "First words") {
+		t.Fatal("expected the profile page to show the custom badge")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:     config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000},
+		ThreadCooldown: config.ThreadCooldown{Default: time.Hour},
+	})
+	token := createTestAccount(t, tc, "dry-bot")
+	headers := map[string]string{"Authorization": "Bearer " + token}
+	dry := map[string]string{"Authorization": "Bearer " + token, "X-Dry-Run": "true"}
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "dry-voter")}
+
+	var preview model.Story
+	resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Dry run story", "text": "x"}, dry)
+	if resp.Header.Get("X-Dry-Run") != "true" {
+		t.Fatal("expected the response to be marked as a dry run")
+	}
+	decodeJSON(t, resp, &preview)
+	if preview.ID != 0 || preview.Title != "Dry run story" || preview.Score != 1 {
+		t.Fatalf("expected an unsaved would-be story, got %+v", preview)
+	}
+	var listing struct {
+		Stories []model.Story `json:"stories"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stories?sort=new", nil), &listing)
+	if len(listing.Stories) != 0 {
+		t.Fatalf("expected nothing stored, got %d stories", len(listing.Stories))
+	}
+	if resp := tc.postJSON(t, "/api/stories?dry_run=1", map[string]any{"title": "short", "text": "x"}, headers); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected a dry run to validate, got %d", resp.StatusCode)
+	}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Real story here", "text": "x"}, headers), &story)
+
+	// A dry-run comment doesn't start the thread cooldown.
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "Dry comment"}, dry), &comment)
+	if comment.ID != 0 || comment.StoryTitle != story.Title {
+		t.Fatalf("expected an unsaved would-be comment, got %+v", comment)
+	}
+	if resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "Real comment"}, headers); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the real comment to pass the cooldown, got %d", resp.StatusCode)
+	}
+
+	vote := map[string]any{"target_type": "story", "target_id": story.ID, "value": 1}
+	if resp := tc.postJSON(t, "/api/votes", vote, map[string]string{"Authorization": voter["Authorization"], "X-Dry-Run": "1"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the dry-run vote to pass, got %d", resp.StatusCode)
+	}
+	var got model.Story
+	decodeJSON(t, tc.get(t, "/api/stories/"+strconv.FormatInt(story.ID, 10), nil), &got)
+	if got.Score != 1 || got.CommentCount != 1 {
+		t.Fatalf("expected only the real comment to count and no vote, got score %d and %d comments", got.Score, got.CommentCount)
+	}
+	if resp := tc.postJSON(t, "/api/votes", vote, voter); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the real vote to pass, got %d", resp.StatusCode)
+	}
+	if resp := tc.postJSON(t, "/api/votes?dry_run=true", vote, voter); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("expected a dry-run duplicate vote to conflict, got %d", resp.StatusCode)
+	}
+}
+
+func TestAccountContentListings(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000}})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "history-bot")}
+	owner, err := tc.store.GetAccountByName(context.Background(), "history-bot")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	var storyIDs []int64
+	for i := range 5 {
+		story := model.Story{Title: fmt.Sprintf("History story %d", i), Text: "x", CreatedAt: time.Now().Add(time.Duration(i-10) * time.Minute), AccountID: owner.ID}
+		id, err := tc.store.CreateStory(context.Background(), &story)
+		if err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+		storyIDs = append(storyIDs, id)
+	}
+	for i := range 3 {
+		resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": storyIDs[0], "text": fmt.Sprintf("Comment number %d", i)}, headers)
+		resp.Body.Close()
+	}
+	base := fmt.Sprintf("/api/accounts/%d", owner.ID)
+
+	walk := func(sort string) []int64 {
+		t.Helper()
+		var ids []int64
+		path := base + "/stories?limit=2&sort=" + sort
+		for range 5 {
+			var page StoryListResponse
+			decodeJSON(t, tc.get(t, path, nil), &page)
+			if page.Total != 5 || page.Sort != sort {
+				t.Fatalf("expected total 5 and sort %s, got %d %q", sort, page.Total, page.Sort)
+			}
+			for _, story := range page.Stories {
+				ids = append(ids, story.ID)
+			}
+			if page.Cursor == "" {
+				return ids
+			}
+			path = base + "/stories?limit=2&sort=" + sort + "&cursor=" + page.Cursor
+		}
+		t.Fatalf("sort %s: expected the cursor to run out", sort)
+		return nil
+	}
+	if got := walk("old"); !slices.Equal(got, storyIDs) {
+		t.Errorf("expected the old walk to list %v, got %v", storyIDs, got)
+	}
+	newest := slices.Clone(storyIDs)
+	slices.Reverse(newest)
+	if got := walk("new"); !slices.Equal(got, newest) {
+		t.Errorf("expected the new walk to list %v, got %v", newest, got)
+	}
+
+	resp := tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": storyIDs[2], "value": 1}, map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "history-fan")})
+	resp.Body.Close()
+	var top StoryListResponse
+	decodeJSON(t, tc.get(t, base+"/stories?sort=top&limit=2", nil), &top)
+	if len(top.Stories) != 2 || top.Stories[0].ID != storyIDs[2] || top.Cursor != "" {
+		t.Errorf("expected the upvoted story first and no cursor for top, got %+v", top)
+	}
+	decodeJSON(t, tc.get(t, base+"/stories?sort=top&limit=2&offset=4", nil), &top)
+	if len(top.Stories) != 1 {
+		t.Errorf("expected offset 4 to leave one story, got %d", len(top.Stories))
+	}
+
+	var comments AccountCommentsResponse
+	decodeJSON(t, tc.get(t, base+"/comments?limit=2", nil), &comments)
+	if comments.Total != 3 || len(comments.Comments) != 2 || comments.Cursor == "" || comments.Comments[0].Text != "Comment number 2" {
+		t.Fatalf("expected the two newest of three comments with a cursor, got %+v", comments)
+	}
+	if comments.Comments[0].StoryTitle != "History story 0" {
+		t.Errorf("expected comments to carry their story title, got %q", comments.Comments[0].StoryTitle)
+	}
+	decodeJSON(t, tc.get(t, base+"/comments?limit=2&cursor="+comments.Cursor, nil), &comments)
+	if len(comments.Comments) != 1 || comments.Comments[0].Text != "Comment number 0" || comments.Cursor != "" {
+		t.Errorf("expected the last comment and no cursor, got %+v", comments)
+	}
+
+	c := client.New(tc.server.URL)
+	page, err := c.GetAccountStories(owner.ID, "old", "")
+	if err != nil || page.Total != 5 || len(page.Stories) != 5 || page.Stories[0].ID != storyIDs[0] {
+		t.Errorf("expected the client to list the account's stories oldest first, got %+v, %v", page, err)
+	}
+
+	for path, want := range map[string]int{
+		base + "/stories?sort=best":      http.StatusBadRequest,
+		base + "/comments?cursor=bogus!": http.StatusBadRequest,
+		"/api/accounts/x/stories":        http.StatusBadRequest,
+		"/api/accounts/99999/comments":   http.StatusNotFound,
+	} {
+		resp := tc.get(t, path, nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("%s: expected %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+}
+
+func TestAccountAnalytics(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "analytics-bot")}
+	other := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "other-bot")}
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+
+	var story, hidden, otherStory model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Tagged analytics story", "text": "x", "tags": []string{"AI", "news"}}, headers), &story)
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Soon to be hidden", "text": "x", "tags": []string{"ai"}}, headers), &hidden)
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Someone else's story", "text": "x"}, other), &otherStory)
+	if resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": otherStory.ID, "text": "Nice one"}, headers); resp.StatusCode != http.StatusOK {
+		t.Fatalf("comment failed: %d", resp.StatusCode)
+	}
+	if resp := tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": otherStory.ID, "value": 1}, headers); resp.StatusCode != http.StatusOK {
+		t.Fatalf("vote failed: %d", resp.StatusCode)
+	}
+	if resp := tc.postJSON(t, "/api/admin/hide", map[string]any{"target_type": "story", "target_id": hidden.ID}, admin); resp.StatusCode != http.StatusOK {
+		t.Fatalf("hide failed: %d", resp.StatusCode)
+	}
+
+	var analytics model.AccountAnalytics
+	path := "/api/accounts/" + strconv.FormatInt(story.AccountID, 10) + "/analytics"
+	decodeJSON(t, tc.get(t, path+"?days=7", nil), &analytics)
+	want := model.ActivityCounts{Stories: 1, Comments: 1, Votes: 1}
+	if analytics.Totals != want {
+		t.Fatalf("expected totals %+v without the hidden story, got %+v", want, analytics.Totals)
+	}
+	today := time.Now().UTC().Format("2006-01-02")
+	if len(analytics.Days) != 1 || analytics.Days[0].Date != today || analytics.Days[0].ActivityCounts != want {
+		t.Fatalf("expected one day of activity on %s, got %+v", today, analytics.Days)
+	}
+	heat := 0
+	for _, day := range analytics.Heatmap {
+		for _, n := range day {
+			heat += n
+		}
+	}
+	if heat != 3 {
+		t.Fatalf("expected 3 actions on the heatmap, got %d", heat)
+	}
+	if len(analytics.TopTags) != 2 || analytics.TopTags[0] != (model.TagStat{Tag: "ai", Stories: 1, AvgScore: 1}) {
+		t.Fatalf("expected ai and news as top tags, got %+v", analytics.TopTags)
+	}
+	if analytics.AvgStoryScore != 1 || analytics.AvgCommentScore != 1 {
+		t.Fatalf("expected average scores of 1, got %v and %v", analytics.AvgStoryScore, analytics.AvgCommentScore)
+	}
+
+	if resp := tc.get(t, "/api/accounts/999/analytics", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown account, got %d", resp.StatusCode)
+	}
+	resp := tc.get(t, "/accounts/"+strconv.FormatInt(story.AccountID, 10), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Activity heatmap") {
+		t.Fatal("expected the profile page to show the heatmap")
+	}
+}
+
+func TestSandbox(t *testing.T) {
+	registerSandbox := func(tc *testClient, name string) (string, error) {
+		t.Helper()
+		creds, err := client.GenerateCredentials(name)
+		if err != nil {
+			t.Fatalf("generate credentials: %v", err)
+		}
+		c := client.New(tc.server.URL)
+		c.Sandbox = true
+		err = c.RegisterAndAuthenticate(creds)
+		return c.Token, err
+	}
+
+	disabled := newTestClient(t)
+	if _, err := registerSandbox(disabled, "early-sandbox"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Fatalf("expected 403 with the sandbox disabled, got %v", err)
+	}
+
+	tc := newTestClientWithConfig(t, config.Config{
+		Sandbox:    true,
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000},
+	})
+	token, err := registerSandbox(tc, "sandbox-bot")
+	if err != nil {
+		t.Fatalf("register sandbox account: %v", err)
+	}
+	sandbox := map[string]string{"Authorization": "Bearer " + token}
+	public := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "public-bot")}
+
+	var practice, real model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Practice story", "text": "x"}, sandbox), &practice)
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Public story", "text": "x"}, public), &real)
+
+	list := func(path string) []model.Story {
+		t.Helper()
+		var result struct {
+			Stories []model.Story `json:"stories"`
+		}
+		decodeJSON(t, tc.get(t, path, nil), &result)
+		return result.Stories
+	}
+	if stories := list("/api/stories?sort=new"); len(stories) != 1 || stories[0].ID != real.ID {
+		t.Fatalf("expected only the public story on the front page, got %+v", stories)
+	}
+	if stories := list("/api/stories?sort=new&sandbox=1"); len(stories) != 1 || stories[0].ID != practice.ID {
+		t.Fatalf("expected only the sandbox story in the sandbox, got %+v", stories)
+	}
+
+	for _, tt := range []struct {
+		path    string
+		body    map[string]any
+		headers map[string]string
+		want    int
+	}{
+		{"/api/comments", map[string]any{"story_id": real.ID, "text": "Sandbox reply"}, sandbox, http.StatusForbidden},
+		{"/api/comments", map[string]any{"story_id": practice.ID, "text": "Public reply"}, public, http.StatusForbidden},
+		{"/api/votes", map[string]any{"target_type": "story", "target_id": real.ID, "value": 1}, sandbox, http.StatusForbidden},
+		{"/api/votes", map[string]any{"target_type": "story", "target_id": practice.ID, "value": 1}, public, http.StatusForbidden},
+		{"/api/comments", map[string]any{"story_id": practice.ID, "text": "Practice reply"}, sandbox, http.StatusOK},
+	} {
+		resp := tc.postJSON(t, tt.path, tt.body, tt.headers)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Fatalf("%s %v: expected %d, got %d", tt.path, tt.body, tt.want, resp.StatusCode)
+		}
+	}
+
+	resp := tc.postJSON(t, "/api/admin/sandbox/purge", nil, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without admin secret, got %d", resp.StatusCode)
+	}
+	var purge map[string]int
+	decodeJSON(t, tc.postJSON(t, "/api/admin/sandbox/purge", nil, map[string]string{"X-Admin-Secret": "admin"}), &purge)
+	if purge["stories"] != 1 || purge["comments"] != 1 {
+		t.Fatalf("expected 1 story and 1 comment purged, got %v", purge)
+	}
+	if stories := list("/api/stories?sandbox=1"); len(stories) != 0 {
+		t.Fatalf("expected an empty sandbox after the purge, got %+v", stories)
+	}
+	if resp := tc.get(t, fmt.Sprintf("/api/stories/%d", real.ID), nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the public story to survive the purge, got %d", resp.StatusCode)
+	}
+	// Sandbox accounts survive the purge and can keep practicing.
+	if resp := tc.postJSON(t, "/api/stories", map[string]any{"title": "Practice story again", "text": "x"}, sandbox); resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the sandbox account to post after the purge, got %d", resp.StatusCode)
+	}
+}
+
+func TestPostTypes(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "typed-bot")}
+
+	for _, tt := range []struct {
+		body map[string]any
+		want int
+	}{
+		{map[string]any{"title": "Ask with a link instead", "url": "https://example.com/ask", "tags": []string{"ask"}}, http.StatusBadRequest},
+		{map[string]any{"title": "Poll with a link instead", "url": "https://example.com/poll", "tags": []string{"poll"}}, http.StatusBadRequest},
+		{map[string]any{"title": "Two post types at once", "text": "x", "tags": []string{"ask", "meta"}}, http.StatusBadRequest},
+		{map[string]any{"title": "Show as a text post", "text": "It writes poems.", "tags": []string{"show"}}, http.StatusOK},
+	} {
+		resp := tc.postJSON(t, "/api/stories", tt.body, headers)
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Fatalf("%v: expected %d, got %d", tt.body, tt.want, resp.StatusCode)
+		}
+	}
+
+	var ask model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Which sorting algorithm?", "text": "Asking for a friend.", "tags": []string{"Ask", "algorithms"}}, headers), &ask)
+	if ask.PostType != model.PostAsk || ask.Tags[0] != "ask" {
+		t.Fatalf("expected a lowercased ask post, got type %q and tags %v", ask.PostType, ask.Tags)
+	}
+	var fetched model.Story
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories/%d", ask.ID), nil), &fetched)
+	if fetched.PostType != model.PostAsk {
+		t.Fatalf("expected the stored story to be an ask post, got %q", fetched.PostType)
+	}
+
+	req, _ := http.NewRequest(http.MethodPatch, tc.server.URL+fmt.Sprintf("/api/stories/%d", ask.ID), strings.NewReader(`{"tags":["ask","poll"]}`))
+	req.Header.Set("Authorization", headers["Authorization"])
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("edit story: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 editing in a second post type, got %d", resp.StatusCode)
+	}
+}
+
+func TestStatsHistory(t *testing.T) {
+	tc := newTestClient(t)
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "stats-bot")}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Counted in the stats", "text": "x"}, headers), &story)
+
+	ctx := context.Background()
+	yesterday, err := tc.store.RecordStatsSnapshot(ctx, time.Now().AddDate(0, 0, -1))
+	if err != nil {
+		t.Fatalf("record yesterday: %v", err)
+	}
+	if yesterday.Accounts != 0 || yesterday.Stories != 0 || yesterday.ActiveBots != 0 {
+		t.Fatalf("expected nothing yet yesterday, got %+v", yesterday)
+	}
+	if _, err := tc.store.RecordStatsSnapshot(ctx, time.Now()); err != nil {
+		t.Fatalf("record today: %v", err)
+	}
+
+	var result struct {
+		Days []statsPoint `json:"days"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stats/history?days=7", nil), &result)
+	if len(result.Days) != 2 {
+		t.Fatalf("expected 2 snapshots, got %+v", result.Days)
+	}
+	today := result.Days[1]
+	if today.Date != time.Now().UTC().Format("2006-01-02") || today.Accounts != 1 || today.Stories != 1 || today.ActiveBots != 1 {
+		t.Fatalf("unexpected snapshot for today: %+v", today)
+	}
+
+	resp := tc.get(t, "/", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `class="sparklines"`) {
+		t.Fatal("expected sparklines in the home page footer")
+	}
+}
+
+func TestActiveBots(t *testing.T) {
+	tc := newTestClient(t)
+	createTestAccount(t, tc, "poster-bot")
+	createTestAccount(t, tc, "stale-bot")
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "voter-bot")}
+
+	ctx := context.Background()
+	backdate := func(name, title string, age time.Duration) int64 {
+		account, err := tc.store.GetAccountByName(ctx, name)
+		if err != nil {
+			t.Fatalf("get account: %v", err)
+		}
+		story := model.Story{Title: title, Text: "x", CreatedAt: time.Now().Add(-age), AccountID: account.ID}
+		id, err := tc.store.CreateStory(ctx, &story)
+		if err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+		return id
+	}
+	storyID := backdate("poster-bot", "Posted a while ago", 2*time.Hour)
+	backdate("stale-bot", "Posted long ago", 40*24*time.Hour)
+	resp := tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": storyID, "value": 1}, voter)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("vote status %d", resp.StatusCode)
+	}
+
+	var result ActiveBotsResponse
+	decodeJSON(t, tc.get(t, "/api/bots/active", nil), &result)
+	if result.Days != defaultActiveBotsDays || len(result.Bots) != 2 {
+		t.Fatalf("expected the voter and poster in the last %d days, got %+v", defaultActiveBotsDays, result)
+	}
+	if result.Bots[0].DisplayName != "voter-bot" || result.Bots[0].RecentVotes != 1 {
+		t.Fatalf("expected the voter first, got %+v", result.Bots[0])
+	}
+	if result.Bots[1].DisplayName != "poster-bot" || result.Bots[1].RecentStories != 1 || result.Bots[1].RecentVotes != 0 {
+		t.Fatalf("expected the poster second, got %+v", result.Bots[1])
+	}
+
+	bots, err := client.New(tc.server.URL).GetActiveBots(90, 1)
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	if len(bots) != 1 || bots[0].DisplayName != "voter-bot" {
+		t.Fatalf("expected only the voter with limit 1, got %+v", bots)
+	}
+	decodeJSON(t, tc.get(t, "/api/bots/active?days=90", nil), &result)
+	if result.Days != 90 || len(result.Bots) != 3 || result.Bots[2].DisplayName != "stale-bot" {
+		t.Fatalf("expected the stale bot in a 90 day window, got %+v", result)
+	}
+
+	resp = tc.get(t, "/", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	if !strings.Contains(page, `<aside class="recently-active">`) || !strings.Contains(page, "just now") || !strings.Contains(page, "2h ago") {
+		t.Fatal("expected the recently active sidebar with last-active times on the front page")
+	}
+	if _, sidebar, _ := strings.Cut(page, `<aside class="recently-active">`); strings.Contains(sidebar, "stale-bot") {
+		t.Fatal("expected the stale bot left out of the sidebar")
+	}
+}
+
+func TestListBots(t *testing.T) {
+	tc := newTestClient(t)
+	ctx := context.Background()
+	accounts := map[string]model.Account{}
+	for _, name := range []string{"alpha_one", "beta-two", "gamma-three"} {
+		createTestAccount(t, tc, name)
+		account, err := tc.store.GetAccountByName(ctx, name)
+		if err != nil {
+			t.Fatalf("get account: %v", err)
+		}
+		accounts[name] = account
+	}
+	for name, karma := range map[string]int{"alpha_one": 5, "beta-two": 10} {
+		if err := tc.store.UpdateAccountKarma(ctx, accounts[name].ID, karma); err != nil {
+			t.Fatalf("karma: %v", err)
+		}
+	}
+	for i, post := range []struct {
+		name string
+		age  time.Duration
+	}{{"gamma-three", time.Hour}, {"gamma-three", 2 * time.Hour}, {"alpha_one", time.Hour}, {"beta-two", 40 * 24 * time.Hour}} {
+		story := model.Story{Title: fmt.Sprintf("Directory story %d", i), Text: "x", CreatedAt: time.Now().Add(-post.age), AccountID: accounts[post.name].ID}
+		if _, err := tc.store.CreateStory(ctx, &story); err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+	}
+
+	c := client.New(tc.server.URL)
+	for _, tt := range []struct {
+		sort, query string
+		want        []string
+	}{
+		{"", "", []string{"alpha_one", "beta-two", "gamma-three"}},
+		{"karma", "", []string{"beta-two", "alpha_one", "gamma-three"}},
+		{"newest", "", []string{"gamma-three", "beta-two", "alpha_one"}},
+		{"active", "", []string{"gamma-three", "alpha_one", "beta-two"}},
+		{"karma", "TWO", []string{"beta-two"}},
+		{"", "ha_o", []string{"alpha_one"}},
+		{"", "a_t", nil},
+	} {
+		list, err := c.ListBots(tt.sort, tt.query, 0, 0)
+		if err != nil {
+			t.Fatalf("%s %q: %v", tt.sort, tt.query, err)
+		}
+		var got []string
+		for _, a := range list.Accounts {
+			got = append(got, a.DisplayName)
+		}
+		if !slices.Equal(got, tt.want) || list.Total != len(tt.want) {
+			t.Errorf("%s %q: got %v (total %d), want %v", tt.sort, tt.query, got, list.Total, tt.want)
+		}
+	}
+	list, err := c.ListBots("alpha", "", 1, 1)
+	if err != nil || len(list.Accounts) != 1 || list.Accounts[0].DisplayName != "beta-two" || list.Total != 3 {
+		t.Fatalf("expected the second bot alone, got %v %+v", err, list)
+	}
+
+	for _, q := range []string{"sort=oldest", "q=" + strings.Repeat("x", maxBotQueryLength+1)} {
+		for _, path := range []string{"/api/bots?", "/bots?"} {
+			resp := tc.get(t, path+q, nil)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("%s%s: got %d, want 400", path, q, resp.StatusCode)
+			}
+		}
+	}
+
+	resp := tc.get(t, "/bots?sort=karma&q=two", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	if !strings.Contains(page, "beta-two") || strings.Contains(page, "gamma-three") || !strings.Contains(page, `value="two"`) {
+		t.Fatal("expected the directory page filtered by name")
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	tc := newTestClient(t)
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "about-bot")}
+
+	for _, body := range []map[string]any{
+		{"bio": strings.Repeat("x", maxBioLength+1)},
+		{"bio": "bell\a"},
+		{"homepage_url": "javascript:alert(1)"},
+		{"homepage_url": "example.com"},
+	} {
+		resp := tc.postJSON(t, "/api/accounts/profile", body, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%v: expected 400, got %d", body, resp.StatusCode)
+		}
+	}
+	resp := tc.postJSON(t, "/api/accounts/profile", map[string]any{"bio": "hi"}, nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", resp.StatusCode)
+	}
+
+	var account model.Account
+	decodeJSON(t, tc.postJSON(t, "/api/accounts/profile", map[string]any{
+		"bio":          "I read **papers**.\n\n<script>x</script> https://example.com/papers",
+		"homepage_url": "https://example.com",
+	}, headers), &account)
+	if account.HomepageURL != "https://example.com" || !strings.HasPrefix(account.Bio, "I read") {
+		t.Fatalf("profile not updated: %+v", account)
+	}
+	// Omitted fields keep their value.
+	decodeJSON(t, tc.postJSON(t, "/api/accounts/profile", map[string]any{"homepage_url": ""}, headers), &account)
+	if account.HomepageURL != "" || account.Bio == "" {
+		t.Fatalf("expected only the homepage cleared: %+v", account)
+	}
+
+	resp = tc.get(t, fmt.Sprintf("/accounts/%d", account.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	if !strings.Contains(page, "<strong>papers</strong>") || !strings.Contains(page, `<a href="https://example.com/papers" rel="nofollow noopener ugc">`) {
+		t.Fatal("expected the bio rendered as Markdown")
+	}
+	if strings.Contains(page, "<script>x</script>") {
+		t.Fatal("expected HTML in the bio escaped")
+	}
+}
+
+func TestRelatedStories(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "related-bot")}
+	post := func(body map[string]any) model.Story {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", body, headers), &story)
+		return story
+	}
+
+	base := post(map[string]any{"title": "Tuning Postgres vacuum settings", "url": "https://blog.example.com/vacuum", "tags": []string{"databases"}})
+	sameTag := post(map[string]any{"title": "Indexes explained from scratch", "text": "x", "tags": []string{"Databases"}})
+	sameDomain := post(map[string]any{"title": "Weekend notes on nothing much", "url": "https://blog.example.com/weekend"})
+	sameWords := post(map[string]any{"title": "Vacuum cleaner reviews for postgres fans", "text": "x"})
+	post(map[string]any{"title": "Gardening tips for early spring", "text": "x", "tags": []string{"garden"}})
+	post(map[string]any{"title": "Lookalike domain is unrelated", "url": "https://notblog.example.com.evil/vacuum"})
+
+	var result struct {
+		Related []model.RelatedStory `json:"related"`
+	}
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories/%d/related", base.ID), nil), &result)
+	var got []int64
+	for _, r := range result.Related {
+		got = append(got, r.Story.ID)
+	}
+	// Equal scores go to the newer story.
+	want := []int64{sameTag.ID, sameWords.ID, sameDomain.ID}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected related stories %v, got %v", want, got)
+	}
+	if first := result.Related[0]; first.Score != 3 || !slices.Equal(first.SharedTags, []string{"Databases"}) {
+		t.Fatalf("expected a shared tag worth 3, got %+v", first)
+	}
+	if len(result.Related[1].SharedWords) != 2 || !result.Related[2].SameDomain {
+		t.Fatalf("expected two shared words then a same-domain story, got %+v", result.Related[1:])
+	}
+
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories/%d/related?limit=1", base.ID), nil), &result)
+	if len(result.Related) != 1 {
+		t.Fatalf("expected limit to cap the results, got %d", len(result.Related))
+	}
+	resp := tc.get(t, "/api/stories/999999/related", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing story, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, fmt.Sprintf("/stories/%d", base.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `class="related"`) || !strings.Contains(string(body), "Indexes explained from scratch") {
+		t.Fatalf("expected the story page to list related stories")
+	}
+}
+
+func TestDomainPages(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "domain-bot")}
+	var first, second model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Notes from the BBC archive", "url": "https://www.bbc.co.uk/news/1"}, headers), &first)
+	if first.Domain != "bbc.co.uk" {
+		t.Fatalf("expected domain bbc.co.uk, got %q", first.Domain)
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "More from the BBC archive", "url": "https://news.bbc.co.uk/2"}, headers), &second)
+	tc.postJSON(t, "/api/stories", map[string]any{"title": "Something from elsewhere", "url": "https://example.com/x"}, headers).Body.Close()
+
+	var page struct {
+		Domain  model.DomainStats `json:"domain"`
+		Stories []model.Story     `json:"stories"`
+		Cursor  string            `json:"cursor"`
+	}
+	decodeJSON(t, tc.get(t, "/api/domains/www.BBC.co.uk", nil), &page)
+	if page.Domain.Domain != "bbc.co.uk" || page.Domain.Stories != 2 || page.Domain.AverageScore != 1 {
+		t.Fatalf("unexpected domain stats %+v", page.Domain)
+	}
+	if len(page.Stories) != 2 || page.Stories[0].ID != second.ID || page.Cursor != "" {
+		t.Fatalf("expected both stories newest first and no cursor, got %+v (cursor %q)", page.Stories, page.Cursor)
+	}
+
+	decodeJSON(t, tc.get(t, "/api/domains/bbc.co.uk?limit=1", nil), &page)
+	if len(page.Stories) != 1 || page.Cursor == "" {
+		t.Fatalf("expected one story and a cursor, got %d (cursor %q)", len(page.Stories), page.Cursor)
+	}
+	decodeJSON(t, tc.get(t, "/api/domains/bbc.co.uk?limit=1&cursor="+page.Cursor, nil), &page)
+	if len(page.Stories) != 1 || page.Stories[0].ID != first.ID || page.Cursor != "" {
+		t.Fatalf("expected the older story on the last page, got %+v (cursor %q)", page.Stories, page.Cursor)
+	}
+
+	for path, want := range map[string]int{
+		"/api/domains/unknown.example":       http.StatusNotFound,
+		"/api/domains/bbc.co.uk?sort=random": http.StatusBadRequest,
+	} {
+		resp := tc.get(t, path, nil)
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: expected %d, got %d", path, want, resp.StatusCode)
+		}
+	}
+
+	resp := tc.get(t, "/from/bbc.co.uk", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Stories from bbc.co.uk") || strings.Contains(string(body), "Something from elsewhere") {
+		t.Fatalf("expected the domain page to list only bbc.co.uk stories (status %d)", resp.StatusCode)
+	}
+}
+
+func TestStoryLockRules(t *testing.T) {
+	tc := newTestClient(t)
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	author := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "lock-owner")}
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "lock-voter")}
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Resolved announcement", "text": "x"}, author), &story)
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "thanks"}, author), &comment)
+	lockPath := fmt.Sprintf("/api/stories/%d/lock", story.ID)
+
+	resp := tc.postJSON(t, lockPath, map[string]any{"locked": true, "reason": "Resolved; see the follow-up."}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("moderator lock status %d", resp.StatusCode)
+	}
+	var fetched model.Story
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories/%d", story.ID), nil), &fetched)
+	if fetched.Lock == nil || !fetched.Lock.ByModerator || fetched.Lock.Reason != "Resolved; see the follow-up." || fetched.Lock.At.IsZero() {
+		t.Fatalf("expected the moderator lock in the story JSON, got %+v", fetched.Lock)
+	}
+
+	for _, vote := range []map[string]any{
+		{"target_type": "story", "target_id": story.ID, "value": 1},
+		{"target_type": "comment", "target_id": comment.ID, "value": 1},
+	} {
+		var locked struct {
+			Code        string `json:"code"`
+			StoryID     int64  `json:"story_id"`
+			Reason      string `json:"reason"`
+			ByModerator bool   `json:"by_moderator"`
+		}
+		resp := tc.postJSON(t, "/api/votes", vote, voter)
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("%v: expected 403 voting on a locked story, got %d", vote, resp.StatusCode)
+		}
+		decodeJSON(t, resp, &locked)
+		if locked.Code != "story_locked" || locked.StoryID != story.ID || locked.Reason == "" || !locked.ByModerator {
+			t.Fatalf("expected a structured locked error, got %+v", locked)
+		}
+	}
+
+	resp = tc.postJSON(t, lockPath, map[string]any{"locked": false}, author)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for the author lifting a moderator lock, got %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, lockPath, map[string]any{"locked": true, "reason": strings.Repeat("x", 201)}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a long reason, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, fmt.Sprintf("/stories/%d", story.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "locked by a moderator") || !strings.Contains(string(body), "Resolved; see the follow-up.") {
+		t.Fatal("expected the lock banner with its reason on the story page")
+	}
+
+	owner, err := tc.store.GetAccountByName(context.Background(), "lock-owner")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	old := model.Story{Title: "Announcement from last week", Text: "x", CreatedAt: time.Now().Add(-8 * 24 * time.Hour), AccountID: owner.ID}
+	oldID, err := tc.store.CreateStory(context.Background(), &old)
+	if err != nil {
+		t.Fatalf("create old story: %v", err)
+	}
+	resp = tc.postJSON(t, fmt.Sprintf("/api/stories/%d/lock", oldID), map[string]any{"locked": true}, author)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for the author locking after the window, got %d", resp.StatusCode)
+	}
+}
+
+func TestDomainRules(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{RateLimits: config.RateLimits{StoryPerMinute: 1000, StoryPerDomainPerDay: 2}})
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	poster := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "domain-poster")}
+
+	resp := tc.postJSON(t, "/api/admin/domain-bans", map[string]any{"domain": "WWW.Spam.example", "reason": "link farm"}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("ban status %d", resp.StatusCode)
+	}
+	var refused struct {
+		Error      string `json:"error"`
+		Rule       string `json:"rule"`
+		Domain     string `json:"domain"`
+		Limit      int    `json:"limit"`
+		RetryAfter int    `json:"retry_after"`
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Cheap widgets today", "url": "https://blog.spam.example/widgets"}, poster)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 for a banned subdomain, got %d", resp.StatusCode)
+	}
+	decodeJSON(t, resp, &refused)
+	if refused.Rule != "domain_banned" || refused.Domain != "spam.example" || !strings.Contains(refused.Error, "link farm") {
+		t.Fatalf("unexpected ban error %+v", refused)
+	}
+
+	for i := range 2 {
+		resp := tc.postJSON(t, "/api/stories", map[string]any{"title": fmt.Sprintf("Busy blog post number %d", i), "url": fmt.Sprintf("https://busy.example/post/%d", i)}, poster)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("story %d status %d", i, resp.StatusCode)
+		}
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "One post too many here", "url": "https://www.busy.example/post/3"}, poster)
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After past the domain limit, got %d", resp.StatusCode)
+	}
+	decodeJSON(t, resp, &refused)
+	if refused.Rule != "domain_daily_limit" || refused.Domain != "busy.example" || refused.Limit != 2 || refused.RetryAfter <= 0 {
+		t.Fatalf("unexpected limit error %+v", refused)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Another site is fine", "url": "https://quiet.example/a"}, poster)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("other domain status %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+"/api/admin/domain-bans/spam.example", nil)
+	req.Header.Set("X-Admin-Secret", "admin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("unban: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unban status %d", resp.StatusCode)
+	}
+	resp = tc.postJSON(t, "/api/stories", map[string]any{"title": "Cheap widgets today", "url": "https://blog.spam.example/widgets"}, poster)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the unbanned domain to be accepted, got %d", resp.StatusCode)
+	}
+}
+
+func TestStoryKinds(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{RateLimits: config.RateLimits{StoryPerMinute: 1000}})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "kind-bot")}
+
+	var ask, show, link model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Ask Slashbot: best vector store?", "text": "For small corpora."}, headers), &ask)
+	if ask.Kind != model.KindAsk || len(ask.Tags) != 1 || ask.Tags[0] != "ask" {
+		t.Fatalf("expected the title to make an ask post, got kind %q and tags %v", ask.Kind, ask.Tags)
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "A crawler that reads RSS", "url": "https://example.com/crawler", "kind": "show"}, headers), &show)
+	if show.Kind != model.KindShow || show.PostType != model.PostShow {
+		t.Fatalf("expected kind show, got %q / %q", show.Kind, show.PostType)
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Plain news link here", "url": "https://example.com/news"}, headers), &link)
+	if link.Kind != model.KindLink {
+		t.Fatalf("expected kind link, got %q", link.Kind)
+	}
+
+	for _, body := range []map[string]any{
+		{"title": "Ask Slashbot: with a link", "url": "https://example.com/q"},
+		{"title": "Show Slashbot: my tool", "text": "x", "tags": []string{"ask"}},
+		{"title": "Link kind without a url", "text": "x", "kind": "link"},
+		{"title": "An unknown kind of post", "text": "x", "kind": "essay"},
+	} {
+		resp := tc.postJSON(t, "/api/stories", body, headers)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("%v: expected 400, got %d", body, resp.StatusCode)
+		}
+	}
+
+	var list struct {
+		Stories []model.Story `json:"stories"`
+		Kind    string        `json:"kind"`
+	}
+	decodeJSON(t, tc.get(t, "/api/stories?kind=ask", nil), &list)
+	if list.Kind != "ask" || len(list.Stories) != 1 || list.Stories[0].ID != ask.ID {
+		t.Fatalf("expected only the ask post, got %+v", list)
+	}
+	resp := tc.get(t, "/api/stories?kind=essay", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown kind, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, "/show", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "Show Slashbot") || !strings.Contains(string(body), show.Title) || strings.Contains(string(body), link.Title) {
+		t.Fatalf("expected /show to list only the show post, got %d", resp.StatusCode)
+	}
+}
+
+func TestModNotes(t *testing.T) {
+	tc := newTestClient(t)
+	admin := map[string]string{"X-Admin-Secret": "admin"}
+	token := createTestAccount(t, tc, "noted-bot")
+	author := map[string]string{"Authorization": "Bearer " + token}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Borderline promo post", "text": "x"}, author), &story)
+
+	resp := tc.postJSON(t, "/api/admin/notes", map[string]any{"target_type": "story", "target_id": story.ID, "text": "Third promo this week."}, author)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without the admin secret, got %d", resp.StatusCode)
+	}
+	var note model.ModNote
+	decodeJSON(t, tc.postJSON(t, "/api/admin/notes", map[string]any{"target_type": "story", "target_id": story.ID, "text": "Third promo this week."}, admin), &note)
+	if note.ID == 0 || note.TargetType != "story" {
+		t.Fatalf("unexpected note %+v", note)
+	}
+	decodeJSON(t, tc.postJSON(t, "/api/admin/notes", map[string]any{"target_type": "account", "target_id": story.AccountID, "text": "Warned by email."}, admin), &model.ModNote{})
+	resp = tc.postJSON(t, "/api/admin/notes", map[string]any{"target_type": "comment", "target_id": 999999, "text": "x"}, admin)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404 for a missing target, got %d", resp.StatusCode)
+	}
+
+	var list struct {
+		Notes []model.ModNote `json:"notes"`
+	}
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/admin/notes?target_type=account&target_id=%d", story.AccountID), admin), &list)
+	if len(list.Notes) != 2 || list.Notes[0].Text != "Warned by email." || list.Notes[1].ID != note.ID {
+		t.Fatalf("expected the account's notes to include its story's, got %+v", list.Notes)
+	}
+	resp = tc.get(t, fmt.Sprintf("/api/stories/%d", story.ID), nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if strings.Contains(string(body), "Third promo") {
+		t.Fatal("moderator note leaked into the public story")
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, tc.server.URL+fmt.Sprintf("/api/admin/notes/%d", note.ID), nil)
+	req.Header.Set("X-Admin-Secret", "admin")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("delete note: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete note status %d", resp.StatusCode)
+	}
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/admin/notes?target_type=story&target_id=%d", story.ID), admin), &list)
+	if len(list.Notes) != 0 {
+		t.Fatalf("expected no notes after delete, got %+v", list.Notes)
+	}
+}
+
+func TestBranding(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000},
+		Branding: config.Branding{
+			SiteName:    "Newsbot Corner",
+			AccentColor: "#ff6600",
+			FooterLinks: []config.FooterLink{{Label: "Code of conduct", URL: "/conduct"}},
+		},
+	})
+
+	resp := tc.get(t, "/", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	for _, want := range []string{"<title>Newsbot Corner - Community for AI Agents</title>", "--primary: #ff6600", `<a href="/conduct">Code of conduct</a>`} {
+		if !strings.Contains(page, want) {
+			t.Errorf("home page missing %q", want)
+		}
+	}
+
+	resp = tc.get(t, "/skill.md", nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "# Newsbot Corner Skill") || strings.Contains(string(body), "Slashbot") {
+		t.Error("expected skill.md to carry the site name")
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "brand-bot")}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Ask Newsbot Corner: favorite feeds?", "text": "Share yours."}, headers), &story)
+	if story.Kind != model.KindAsk {
+		t.Errorf("expected the site name prefix to make an ask post, got %q", story.Kind)
+	}
+
+	for _, b := range []config.Branding{{AccentColor: "orange"}, {LogoURL: "javascript:alert(1)"}, {FooterLinks: []config.FooterLink{{Label: "x", URL: "ftp://x"}}}} {
+		if _, err := NewServer(nil, nil, nil, config.Config{Branding: b}); err == nil {
+			t.Errorf("expected branding %+v to be rejected", b)
+		}
+	}
+}
+
+func TestSemanticSearch(t *testing.T) {
+	disabled := newTestClient(t)
+	resp := disabled.get(t, "/api/search?q=agent+memory&mode=semantic", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("expected 501 without embeddings, got %d", resp.StatusCode)
+	}
+
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, VotePerMinute: 1000},
+		Embeddings: config.Embeddings{Provider: "local"},
+	})
+	poster := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "poster-bot")}
+	reader := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "reader-bot")}
+	ids := map[string]int64{}
+	for _, title := range []string{
+		"Vector databases for agent memory",
+		"Long-term memory for agents with vector search",
+		"Sourdough baking at high altitude",
+		"Choosing a vector index for agent memory recall",
+	} {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": title, "text": "Notes."}, poster), &story)
+		ids[title] = story.ID
+	}
+	if n, err := tc.app.embedPending(context.Background()); err != nil || n != 4 {
+		t.Fatalf("embedPending = %d, %v", n, err)
+	}
+
+	var search struct {
+		Mode    string        `json:"mode"`
+		Results []scoredStory `json:"results"`
+	}
+	decodeJSON(t, tc.get(t, "/api/search?q=agent+memory+vector&mode=semantic&limit=2", nil), &search)
+	if search.Mode != searchSemantic || len(search.Results) != 2 {
+		t.Fatalf("unexpected semantic search %+v", search)
+	}
+	for _, r := range search.Results {
+		if strings.Contains(r.Story.Title, "Sourdough") {
+			t.Errorf("unrelated story ranked in the top 2: %+v", search.Results)
+		}
+	}
+	var keyword struct {
+		Mode    string        `json:"mode"`
+		Stories []model.Story `json:"stories"`
+	}
+	decodeJSON(t, tc.get(t, "/api/search?q=sourdough", nil), &keyword)
+	if keyword.Mode != searchKeyword || len(keyword.Stories) != 1 {
+		t.Fatalf("unexpected keyword search %+v", keyword)
+	}
+
+	var recs struct {
+		Results []scoredStory `json:"results"`
+		Basis   int           `json:"basis"`
+	}
+	decodeJSON(t, tc.get(t, "/api/recommendations", reader), &recs)
+	if recs.Basis != 0 || len(recs.Results) != 0 {
+		t.Fatalf("expected no recommendations without upvotes, got %+v", recs)
+	}
+	resp = tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": ids["Vector databases for agent memory"], "value": 1}, reader)
+	resp.Body.Close()
+	decodeJSON(t, tc.get(t, "/api/recommendations?limit=2", reader), &recs)
+	if recs.Basis != 1 || len(recs.Results) != 2 {
+		t.Fatalf("unexpected recommendations %+v", recs)
+	}
+	for _, r := range recs.Results {
+		if r.Story.ID == ids["Vector databases for agent memory"] || strings.Contains(r.Story.Title, "Sourdough") {
+			t.Errorf("unexpected recommendation %q", r.Story.Title)
+		}
+	}
+	decodeJSON(t, tc.get(t, "/api/recommendations", poster), &recs)
+	if len(recs.Results) != 0 {
+		t.Errorf("expected no recommendations of the account's own stories, got %+v", recs.Results)
+	}
+
+	// Editing a title drops its embedding until the worker catches up.
+	req, _ := http.NewRequest(http.MethodPatch, tc.server.URL+fmt.Sprintf("/api/stories/%d", ids["Sourdough baking at high altitude"]), strings.NewReader(`{"title":"Agent memory benchmarks"}`))
+	req.Header.Set("Authorization", poster["Authorization"])
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("edit story: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("edit story status %d", resp.StatusCode)
+	}
+	if n, err := tc.app.embedPending(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected the edited story to be re-embedded, got %d, %v", n, err)
+	}
+}
+
+func TestRoutesAreServed(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{PreviewAPI: true})
+	// Give ID 1 a story, comment and account, so handlers looking them up
+	// don't answer 404 like the router does.
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "route-bot")}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Route check", "text": "x"}, headers), &story)
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "x"}, headers), &model.Comment{})
+	for _, route := range routes.All {
+		if route == routes.PreviewStream {
+			continue // holds the connection open
+		}
+		args := make([]any, strings.Count(route.Pattern, "{"))
+		for i := range args {
+			args[i] = 1
+		}
+		req, _ := http.NewRequest(route.Method, tc.server.URL+route.Path(args...), strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := tc.client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: %v", route, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusMethodNotAllowed || (resp.StatusCode == http.StatusNotFound && strings.TrimSpace(string(body)) == `{"error":"not found"}`) {
+			t.Errorf("%s is not routed (%d %s)", route, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestCrossPosting(t *testing.T) {
+	var statuses []string
+	mastodon := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statuses = append(statuses, r.FormValue("status"))
+		json.NewEncoder(w).Encode(map[string]string{"url": fmt.Sprintf("https://mastodon.example/@news/%d", len(statuses))})
+	}))
+	defer mastodon.Close()
+
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, VotePerMinute: 1000},
+		CrossPost: config.CrossPost{
+			BaseURL:       "https://news.example",
+			MinScore:      2,
+			MaxAge:        time.Hour,
+			Template:      "{{.Title}} [{{.Score}}] {{.Link}}",
+			MastodonURL:   mastodon.URL,
+			MastodonToken: "tok",
+		},
+	})
+	poster := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "xpost-bot")}
+	voter := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "xpost-voter")}
+	var popular, quiet model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Popular story", "text": "x"}, poster), &popular)
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Quiet story", "text": "x"}, poster), &quiet)
+	resp := tc.postJSON(t, "/api/votes", map[string]any{"target_type": "story", "target_id": popular.ID, "value": 1}, voter)
+	resp.Body.Close()
+
+	if n := tc.app.crossPostOnce(context.Background()); n != 1 {
+		t.Fatalf("expected one story cross-posted, got %d", n)
+	}
+	if want := fmt.Sprintf("Popular story [2] https://news.example/stories/%d", popular.ID); len(statuses) != 1 || statuses[0] != want {
+		t.Fatalf("statuses = %q, want %q", statuses, want)
+	}
+	if n := tc.app.crossPostOnce(context.Background()); n != 0 {
+		t.Fatalf("expected the ledger to stop a repost, got %d", n)
+	}
+
+	var ledger struct {
+		CrossPosts []model.CrossPost `json:"crossposts"`
+		Networks   []string          `json:"networks"`
+	}
+	decodeJSON(t, tc.get(t, "/api/admin/crossposts", map[string]string{"X-Admin-Secret": "admin"}), &ledger)
+	if len(ledger.CrossPosts) != 1 || ledger.CrossPosts[0].StoryID != popular.ID || ledger.CrossPosts[0].RemoteURL != "https://mastodon.example/@news/1" || len(ledger.Networks) != 1 {
+		t.Fatalf("unexpected ledger %+v", ledger)
+	}
+
+	for _, cp := range []config.CrossPost{
+		{MastodonURL: mastodon.URL, MastodonToken: "tok"},
+		{BaseURL: "https://news.example", BlueskyHandle: "news.bsky.social"},
+		{BaseURL: "https://news.example", MastodonURL: mastodon.URL, MastodonToken: "tok", Template: "{{.Nope}}"},
+	} {
+		if _, err := NewServer(nil, nil, nil, config.Config{CrossPost: cp}); err == nil {
+			t.Errorf("expected cross-post config %+v to be rejected", cp)
+		}
+	}
+}
+
+func TestConformance(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000, VotePerMinute: 1000, ChallengePerMinute: 10},
+		Sandbox:    true,
+	})
+	report := conformance.Run(context.Background(), conformance.Options{Target: tc.server.URL, HTTPClient: tc.client})
+	for _, res := range report.Results {
+		if res.Status != conformance.Pass {
+			t.Errorf("%s: %s %s", res.Name, res.Status, res.Detail)
+		}
+	}
+	if report.Passed == 0 || !report.OK() {
+		t.Fatalf("report: %d passed, %d failed, %d skipped", report.Passed, report.Failed, report.Skipped)
+	}
+
+	var tap strings.Builder
+	if err := report.WriteTAP(&tap); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(tap.String(), fmt.Sprintf("TAP version 13\n1..%d\nok 1 - ", len(report.Results))) {
+		t.Errorf("unexpected TAP output:\n%s", tap.String())
+	}
+}
+
+func TestInboundEmail(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:   config.RateLimits{StoryPerMinute: 1000},
+		InboundEmail: config.InboundEmail{Domain: "in.example", MailgunSigningKey: "mg-key"},
+	})
+	token := createTestAccount(t, tc, "email-bot")
+
+	data, _ := json.Marshal(map[string]string{"address": "Agent@Bot.example"})
+	req, _ := http.NewRequest(routes.SetEmailSender.Method, tc.server.URL+routes.SetEmailSender.Path(), bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set struct {
+		Address  string `json:"address"`
+		SubmitTo string `json:"submit_to"`
+	}
+	decodeJSON(t, resp, &set)
+	if set.Address != "agent@bot.example" || !strings.HasPrefix(set.SubmitTo, "submit+") || !strings.HasSuffix(set.SubmitTo, "@in.example") {
+		t.Fatalf("unexpected sender: %+v", set)
+	}
+
+	stamp := 0
+	send := func(fields map[string]string) *http.Response {
+		stamp++
+		form := url.Values{}
+		for k, v := range fields {
+			form.Set(k, v)
+		}
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		nonce := fmt.Sprintf("nonce-%d", stamp)
+		m := hmac.New(sha256.New, []byte("mg-key"))
+		m.Write([]byte(ts + nonce))
+		form.Set("timestamp", ts)
+		form.Set("token", nonce)
+		if _, ok := fields["signature"]; !ok {
+			form.Set("signature", hex.EncodeToString(m.Sum(nil)))
+		}
+		resp, err := tc.client.PostForm(tc.server.URL+routes.InboundMailgun.Path(), form)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	mail := map[string]string{
+		"recipient":  set.SubmitTo,
+		"from":       "Agent <agent@bot.example>",
+		"subject":    "Emailed agents ship faster",
+		"body-plain": "Found this today: https://example.com/emailed-agents.\n\n-- \nagent",
+	}
+
+	resp = send(mail)
+	var story model.Story
+	decodeJSON(t, resp, &story)
+	if resp.StatusCode != http.StatusOK || story.URL != "https://example.com/emailed-agents" || story.Title != "Emailed agents ship faster" {
+		t.Fatalf("unexpected story from email: %d %+v", resp.StatusCode, story)
+	}
+
+	for name, tweak := range map[string]map[string]string{
+		"wrong sender":   {"from": "someone@else.example"},
+		"wrong token":    {"recipient": "submit+0000@in.example"},
+		"invalid title":  {"subject": "short", "body-plain": "text only"},
+		"bad signature":  {"signature": "00"},
+		"no +token part": {"recipient": "submit@in.example"},
+	} {
+		fields := maps.Clone(mail)
+		fields["subject"] += " (" + name + ")"
+		maps.Copy(fields, tweak)
+		resp := send(fields)
+		resp.Body.Close()
+		want := http.StatusNotAcceptable
+		if name == "bad signature" {
+			want = http.StatusUnauthorized
+		}
+		if resp.StatusCode != want {
+			t.Errorf("%s: got %d, want %d", name, resp.StatusCode, want)
+		}
+	}
+
+	// Newsletters carry a large HTML part; the route's own body limit
+	// admits them where the 64 KiB default would answer 413.
+	newsletter := maps.Clone(mail)
+	newsletter["subject"] = "A newsletter with a heavy HTML part"
+	newsletter["body-plain"] = "This week: https://example.com/newsletter-issue"
+	newsletter["body-html"] = "<p>" + strings.Repeat("newsletter ", 12<<10) + "</p>"
+	resp = send(newsletter)
+	decodeJSON(t, resp, &story)
+	if resp.StatusCode != http.StatusOK || story.Title != newsletter["subject"] {
+		t.Fatalf("expected a %d-byte newsletter to post, got %d %+v", len(newsletter["body-html"]), resp.StatusCode, story)
+	}
+
+	// Text posts take the body when it has no link.
+	text := maps.Clone(mail)
+	text["subject"] = "Notes from the email gateway"
+	text["body-plain"] = "No links here, just thoughts."
+	resp = send(text)
+	decodeJSON(t, resp, &story)
+	if story.Text != "No links here, just thoughts." || story.URL != "" {
+		t.Fatalf("unexpected text story: %+v", story)
+	}
+
+	req, _ = http.NewRequest(routes.GetEmailSender.Method, tc.server.URL+routes.GetEmailSender.Path(), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err = tc.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sender model.EmailSender
+	decodeJSON(t, resp, &sender)
+	if sender.Address != "agent@bot.example" || sender.LastUsedAt == nil {
+		t.Fatalf("unexpected sender after use: %+v", sender)
+	}
+
+	if _, err := NewServer(nil, nil, nil, config.Config{InboundEmail: config.InboundEmail{Domain: "in.example"}}); err == nil {
+		t.Error("expected a domain without a signing key to be rejected")
+	}
+}
+
+func TestResponseShapes(t *testing.T) {
+	tc := newTestClient(t)
+
+	// Empty results are empty arrays, not missing or null.
+	var raw map[string]json.RawMessage
+	decodeJSON(t, tc.get(t, "/api/search?q=nothing+matches", nil), &raw)
+	for _, key := range []string{"stories", "comments"} {
+		if string(raw[key]) != "[]" {
+			t.Errorf("keyword search %s = %s, want []", key, raw[key])
+		}
+	}
+	if _, ok := raw["results"]; ok {
+		t.Error("keyword search should not carry semantic results")
+	}
+
+	resp := tc.get(t, "/api/stories?kind=bogus", nil)
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("got %d, want 400", resp.StatusCode)
+	}
+	var errBody ErrorResponse
+	decodeJSON(t, resp, &errBody)
+	if errBody.Error == "" {
+		t.Error("expected an error message")
+	}
+
+	raw = nil
+	decodeJSON(t, tc.get(t, "/api/stories?sort=new", nil), &raw)
+	for _, key := range []string{"stories", "sort", "total", "cursor"} {
+		if _, ok := raw[key]; !ok {
+			t.Errorf("story list missing %s", key)
+		}
+	}
+	for _, key := range []string{"kind", "tag", "sandbox"} {
+		if _, ok := raw[key]; ok {
+			t.Errorf("unfiltered story list should omit %s", key)
+		}
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "versioned-bot")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	story := map[string]any{"title": "Field names across API versions", "url": "https://example.com/versions"}
+	resp := tc.postJSON(t, "/api/stories", story, auth)
+	var created model.Story
+	decodeJSON(t, resp, &created)
+	path := fmt.Sprintf("/api/stories/%d", created.ID)
+
+	fetch := func(version string) (*http.Response, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodGet, tc.server.URL+path, nil)
+		if version != "" {
+			req.Header.Set(headerAPIVersion, version)
+		}
+		// The plain client, so no version is added.
+		resp, err := tc.server.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var body map[string]any
+		decodeJSON(t, resp, &body)
+		return resp, body
+	}
+	for version, want := range map[string][]string{
+		"":  {"ID", "Title", "CommentCount", "AccountName"},
+		"1": {"ID", "Title", "CommentCount", "AccountName"},
+		"2": {"id", "title", "comment_count", "account_name"},
+	} {
+		resp, body := fetch(version)
+		served := version
+		if served == "" {
+			served = "1"
+		}
+		if got := resp.Header.Get(headerAPIVersion); got != served {
+			t.Errorf("version %q: served %q", version, got)
+		}
+		for _, key := range want {
+			if _, ok := body[key]; !ok {
+				t.Errorf("version %q: missing %s in %v", version, key, body)
+			}
+		}
+	}
+	resp, body := fetch("3")
+	if resp.StatusCode != http.StatusBadRequest || body["error"] == nil {
+		t.Fatalf("version 3: got %d %v, want a 400", resp.StatusCode, body)
+	}
+
+	// Wrappers keep their names in version 1; embedded records flatten.
+	req, _ := http.NewRequest(http.MethodPost, tc.server.URL+"/api/stories", strings.NewReader(`{"title": "Field names across API versions", "url": "https://example.com/versions"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set(headerAPIVersion, "1")
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body = nil
+	decodeJSON(t, resp, &body)
+	if body["duplicate_of"] == nil || body["ID"] == nil || body["id"] != nil {
+		t.Fatalf("unexpected version 1 duplicate: %v", body)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "coded-bot")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+
+	check := func(resp *http.Response, status int, code string) ErrorResponse {
+		t.Helper()
+		var body ErrorResponse
+		decodeJSON(t, resp, &body)
+		if resp.StatusCode != status || body.Code != code || body.Error == "" {
+			t.Fatalf("got %d %+v, want %d %s", resp.StatusCode, body, status, code)
+		}
+		return body
+	}
+
+	check(tc.postJSON(t, "/api/stories", map[string]string{"title": "No token here", "text": "x"}, nil), http.StatusUnauthorized, codeUnauthorized)
+	body := check(tc.postJSON(t, "/api/stories", map[string]string{"title": "short", "text": "x"}, auth), http.StatusBadRequest, codeValidationFailed)
+	if body.Details["title"] == "" {
+		t.Errorf("validation details missing title: %+v", body.Details)
+	}
+	body = check(tc.postJSON(t, "/api/comments", map[string]any{"story_id": 0, "text": " "}, auth), http.StatusBadRequest, codeValidationFailed)
+	if len(body.Details) != 2 {
+		t.Errorf("expected story_id and text details, got %+v", body.Details)
+	}
+	check(tc.get(t, "/api/stories/999999", nil), http.StatusNotFound, codeNotFound)
+	check(tc.get(t, "/api/stories", map[string]string{headerAPIVersion: "9"}), http.StatusBadRequest, codeUnsupportedAPI)
+
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": "Stories with coded errors", "text": "body"}, auth), &story)
+	other := createTestAccount(t, tc, "coded-voter")
+	vote := map[string]any{"target_type": "story", "target_id": story.ID, "value": 1}
+	otherAuth := map[string]string{"Authorization": "Bearer " + other}
+	if resp := tc.postJSON(t, "/api/votes", vote, otherAuth); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first vote: %d", resp.StatusCode)
+	}
+	check(tc.postJSON(t, "/api/votes", vote, otherAuth), http.StatusConflict, codeDuplicateVote)
+
+	// The client surfaces the same codes.
+	c := client.New(tc.server.URL)
+	c.Token = other
+	err := c.Vote("story", story.ID, 1)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != client.CodeDuplicateVote || apiErr.StatusCode != http.StatusConflict {
+		t.Fatalf("client vote: %v", err)
+	}
+
+	// Every code is documented in the OpenAPI enum.
+	field, _ := reflect.TypeFor[ErrorResponse]().FieldByName("Code")
+	documented := strings.Split(field.Tag.Get("enums"), ",")
+	if !slices.Equal(documented, errorCodes) {
+		t.Errorf("ErrorResponse enums = %v, want errorCodes %v", documented, errorCodes)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits:         config.RateLimits{StoryPerMinute: 3, CommentPerMinute: 100, VotePerMinute: 100},
+		BatchMaxOperations: 5,
+	})
+	other := createTestAccount(t, tc, "batch-author")
+	var target model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": "A story to batch against", "text": "body"}, map[string]string{"Authorization": "Bearer " + other}), &target)
+
+	// Signed, so the operations can't re-verify the signature themselves.
+	creds, err := client.GenerateCredentials("batch-bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := client.New(tc.server.URL)
+	if _, err := c.Register(creds, "", ""); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	c.Signer = creds
+
+	results, err := c.Batch([]client.BatchOperation{
+		client.BatchStory("First batched story", "", "one", nil),
+		client.BatchStory("short", "", "two", nil),
+		client.BatchComment(target.ID, nil, "batched comment"),
+		client.BatchVote("story", target.ID, 1),
+		{Op: "flag", Body: map[string]any{}},
+	})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if len(results) != 5 {
+		t.Fatalf("got %d results, want 5", len(results))
+	}
+	var story client.Story
+	if err := results[0].Decode(&story); err != nil || story.Title != "First batched story" {
+		t.Errorf("story result: %+v %v", story, err)
+	}
+	if code := client.ErrorCode(results[1].Err()); code != client.CodeValidationFailed {
+		t.Errorf("invalid story: code %q, want validation_failed", code)
+	}
+	var comment client.Comment
+	if err := results[2].Decode(&comment); err != nil || comment.StoryID != target.ID {
+		t.Errorf("comment result: %+v %v", comment, err)
+	}
+	if err := results[3].Err(); err != nil {
+		t.Errorf("vote result: %v", err)
+	}
+	if results[4].Status != http.StatusBadRequest {
+		t.Errorf("unknown op: status %d, want 400", results[4].Status)
+	}
+	for i, r := range results {
+		if r.Index != i {
+			t.Errorf("result %d has index %d", i, r.Index)
+		}
+	}
+
+	// Each operation draws from its endpoint's rate limit: three stories a
+	// minute per IP, all spent above (the invalid one too).
+	results, err = c.Batch([]client.BatchOperation{client.BatchStory("Third batched story", "", "three", nil)})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if code := client.ErrorCode(results[0].Err()); code != client.CodeRateLimited {
+		t.Errorf("third story: code %q, want rate_limited", code)
+	}
+
+	// The batch itself is refused when empty or over the limit.
+	for _, n := range []int{0, 6} {
+		ops := make([]client.BatchOperation, n)
+		for i := range ops {
+			ops[i] = client.BatchVote("story", target.ID, 1)
+		}
+		_, err := c.Batch(ops)
+		var apiErr *client.APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest || apiErr.Details["operations"] == "" {
+			t.Errorf("%d operations: %v", n, err)
+		}
+	}
+}
+
+func TestBulkReadByID(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "bulk-reader")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	var ids []int64
+	for _, title := range []string{"First bulk story", "Second bulk story"} {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": title, "text": "body"}, auth), &story)
+		ids = append(ids, story.ID)
+	}
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": ids[0], "text": "bulk comment"}, auth), &comment)
+
+	c := client.New(tc.server.URL)
+	stories, notFound, err := c.GetStoriesByID([]int64{ids[1], 9999, ids[0], ids[1]})
+	if err != nil {
+		t.Fatalf("get stories by id: %v", err)
+	}
+	if len(stories) != 2 || stories[0].ID != ids[1] || stories[1].ID != ids[0] {
+		t.Errorf("expected the stories in the order asked, got %+v", stories)
+	}
+	if !slices.Equal(notFound, []int64{9999}) {
+		t.Errorf("not found = %v, want [9999]", notFound)
+	}
+
+	comments, notFound, err := c.GetCommentsByID([]int64{comment.ID, 8888})
+	if err != nil {
+		t.Fatalf("get comments by id: %v", err)
+	}
+	if len(comments) != 1 || comments[0].Text != "bulk comment" || !slices.Equal(notFound, []int64{8888}) {
+		t.Errorf("unexpected comments %+v, not found %v", comments, notFound)
+	}
+
+	// Everything found: no not_found at all.
+	var raw map[string]json.RawMessage
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories?ids=%d", ids[0]), nil), &raw)
+	if _, ok := raw["not_found"]; ok || string(raw["sort"]) != `"ids"` {
+		t.Errorf("unexpected response: %s", raw)
+	}
+
+	many := make([]string, maxBulkIDs+1)
+	for i := range many {
+		many[i] = strconv.Itoa(i + 1)
+	}
+	for _, q := range []string{"/api/comments?ids=,", "/api/comments?ids=x", "/api/stories?ids=" + strings.Join(many, ",")} {
+		resp := tc.get(t, q, nil)
+		var body ErrorResponse
+		decodeJSON(t, resp, &body)
+		if resp.StatusCode != http.StatusBadRequest || body.Details["ids"] == "" {
+			t.Errorf("%s: got %d %+v, want a 400 about ids", q, resp.StatusCode, body)
+		}
+	}
+}
+
+func TestViewerVoteState(t *testing.T) {
+	tc := newTestClient(t)
+	author := createTestAccount(t, tc, "viewer-author")
+	auth := map[string]string{"Authorization": "Bearer " + author}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": "A story to vote on", "text": "body"}, auth), &story)
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "a comment to flag"}, auth), &comment)
+
+	viewer := client.New(tc.server.URL)
+	viewer.Token = createTestAccount(t, tc, "viewer-bot")
+	if err := viewer.Vote("story", story.ID, 1); err != nil {
+		t.Fatalf("vote: %v", err)
+	}
+	if err := viewer.Flag("comment", comment.ID, "spam"); err != nil {
+		t.Fatalf("flag: %v", err)
+	}
+
+	got, err := viewer.GetStory(story.ID)
+	if err != nil {
+		t.Fatalf("get story: %v", err)
+	}
+	if got.ViewerVote == nil || *got.ViewerVote != 1 || got.ViewerFlagged == nil || *got.ViewerFlagged {
+		t.Errorf("story viewer state = %v, %v; want 1, false", got.ViewerVote, got.ViewerFlagged)
+	}
+	comments, err := viewer.GetComments(story.ID)
+	if err != nil || len(comments) != 1 {
+		t.Fatalf("get comments: %v %+v", err, comments)
+	}
+	if c := comments[0]; c.ViewerVote == nil || *c.ViewerVote != 0 || c.ViewerFlagged == nil || !*c.ViewerFlagged {
+		t.Errorf("comment viewer state = %v, %v; want 0, true", c.ViewerVote, c.ViewerFlagged)
+	}
+	stories, _, err := viewer.GetStoriesByID([]int64{story.ID})
+	if err != nil || len(stories) != 1 || stories[0].ViewerVote == nil || *stories[0].ViewerVote != 1 {
+		t.Errorf("bulk read: %v %+v", err, stories)
+	}
+
+	// Anonymous callers get neither field.
+	var raw map[string]json.RawMessage
+	decodeJSON(t, tc.get(t, fmt.Sprintf("/api/stories/%d", story.ID), map[string]string{headerAPIVersion: "2"}), &raw)
+	if _, ok := raw["viewer_vote"]; ok {
+		t.Errorf("anonymous read has viewer_vote: %s", raw["viewer_vote"])
+	}
+	if _, ok := raw["viewer_flagged"]; ok {
+		t.Errorf("anonymous read has viewer_flagged: %s", raw["viewer_flagged"])
+	}
+}
+
+func TestMyVotes(t *testing.T) {
+	tc := newTestClient(t)
+	auth := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "myvotes-author")}
+	var storyIDs []int64
+	for i := range 3 {
+		var story model.Story
+		decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": fmt.Sprintf("Story to vote on %d", i), "text": "body"}, auth), &story)
+		storyIDs = append(storyIDs, story.ID)
+	}
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": storyIDs[0], "text": "a comment to vote on"}, auth), &comment)
+
+	voter := client.New(tc.server.URL)
+	voter.Token = createTestAccount(t, tc, "myvotes-voter")
+	for _, v := range []struct {
+		targetType string
+		id         int64
+		value      int
+	}{{"story", storyIDs[2], 1}, {"story", storyIDs[0], -1}, {"comment", comment.ID, 1}} {
+		if err := voter.Vote(v.targetType, v.id, v.value); err != nil {
+			t.Fatalf("vote: %v", err)
+		}
+	}
+
+	var result MyVotesResponse
+	path := fmt.Sprintf("/api/votes/mine?target_type=story&ids=%d,%d,%d,9999", storyIDs[0], storyIDs[1], storyIDs[2])
+	decodeJSON(t, tc.get(t, path, map[string]string{"Authorization": "Bearer " + voter.Token}), &result)
+	if result.TargetType != "story" || len(result.Votes) != 2 {
+		t.Fatalf("expected votes on two stories, got %+v", result)
+	}
+	if result.Votes[0].TargetID != storyIDs[0] || result.Votes[0].Value != -1 || result.Votes[1].TargetID != storyIDs[2] || result.Votes[1].Value != 1 {
+		t.Fatalf("expected votes in the order asked for, got %+v", result.Votes)
+	}
+
+	votes, err := voter.GetMyVotes("comment", []int64{comment.ID})
+	if err != nil || len(votes) != 1 || votes[comment.ID] != 1 {
+		t.Fatalf("client: %v %v", err, votes)
+	}
+	// Another account's lookup doesn't see them.
+	decodeJSON(t, tc.get(t, path, auth), &result)
+	if len(result.Votes) != 0 {
+		t.Fatalf("expected no votes for the author, got %+v", result.Votes)
+	}
+
+	for _, tt := range []struct {
+		query   string
+		headers map[string]string
+		status  int
+	}{
+		{"target_type=story&ids=1", nil, http.StatusUnauthorized},
+		{"target_type=account&ids=1", auth, http.StatusBadRequest},
+		{"target_type=story", auth, http.StatusBadRequest},
+		{"target_type=story&ids=x", auth, http.StatusBadRequest},
+	} {
+		resp := tc.get(t, "/api/votes/mine?"+tt.query, tt.headers)
+		resp.Body.Close()
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got %d, want %d", tt.query, resp.StatusCode, tt.status)
+		}
+	}
+}
+
+func TestCommentFeed(t *testing.T) {
+	tc := newTestClient(t)
+	createTestAccount(t, tc, "feed-alpha")
+	createTestAccount(t, tc, "feed-beta")
+	ctx := context.Background()
+	alpha, err := tc.store.GetAccountByName(ctx, "feed-alpha")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	beta, err := tc.store.GetAccountByName(ctx, "feed-beta")
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	var storyIDs []int64
+	for i := range 2 {
+		story := model.Story{Title: fmt.Sprintf("Feed story %d", i), Text: "x", CreatedAt: time.Now().Add(-60 * 24 * time.Hour), AccountID: alpha.ID}
+		id, err := tc.store.CreateStory(ctx, &story)
+		if err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+		storyIDs = append(storyIDs, id)
+	}
+	// Five comments, newest first: beta on story 1, then alternating, the
+	// oldest posted 40 days ago.
+	ages := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 4 * time.Minute, 40 * 24 * time.Hour}
+	var commentIDs []int64
+	for i, age := range ages {
+		author := alpha.ID
+		if i%2 == 0 {
+			author = beta.ID
+		}
+		comment := model.Comment{StoryID: storyIDs[i%2], Text: fmt.Sprintf("Feed comment %d", i), CreatedAt: time.Now().Add(-age), AccountID: author}
+		id, err := tc.store.CreateComment(ctx, &comment)
+		if err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+		commentIDs = append(commentIDs, id)
+	}
+
+	c := client.New(tc.server.URL)
+	var seen []int64
+	opts := client.CommentFeedOptions{Limit: 2}
+	for range 3 {
+		page, err := c.GetRecentComments(opts)
+		if err != nil {
+			t.Fatalf("feed: %v", err)
+		}
+		if page.Sort != "new" || page.Total != len(ages) {
+			t.Fatalf("unexpected page: %+v", page)
+		}
+		for _, comment := range page.Comments {
+			seen = append(seen, comment.ID)
+		}
+		if opts.Cursor = page.Cursor; opts.Cursor == "" {
+			break
+		}
+	}
+	if !slices.Equal(seen, commentIDs) {
+		t.Fatalf("expected every comment newest first across pages, got %v want %v", seen, commentIDs)
+	}
+
+	for _, tt := range []struct {
+		opts client.CommentFeedOptions
+		want []int64
+	}{
+		{client.CommentFeedOptions{AccountID: beta.ID}, []int64{commentIDs[0], commentIDs[2], commentIDs[4]}},
+		{client.CommentFeedOptions{StoryID: storyIDs[1]}, []int64{commentIDs[1], commentIDs[3]}},
+		{client.CommentFeedOptions{AccountID: beta.ID, Time: "week"}, []int64{commentIDs[0], commentIDs[2]}},
+	} {
+		page, err := c.GetRecentComments(tt.opts)
+		if err != nil {
+			t.Fatalf("%+v: %v", tt.opts, err)
+		}
+		var got []int64
+		for _, comment := range page.Comments {
+			got = append(got, comment.ID)
+		}
+		if !slices.Equal(got, tt.want) || page.Total != len(tt.want) || page.Cursor != "" {
+			t.Errorf("%+v: got %v (total %d, cursor %q), want %v", tt.opts, got, page.Total, page.Cursor, tt.want)
+		}
+	}
+
+	for _, q := range []string{"sort=old", "account_id=x", "story_id=0", "time=decade", "cursor=bogus!"} {
+		resp := tc.get(t, "/api/comments?"+q, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestIncludeAuthor(t *testing.T) {
+	tc := newTestClient(t)
+	token := createTestAccount(t, tc, "included-author")
+	auth := map[string]string{"Authorization": "Bearer " + token}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]string{"title": "A story with an author", "text": "body"}, auth), &story)
+	var comment model.Comment
+	decodeJSON(t, tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "an authored comment"}, auth), &comment)
+
+	c := client.New(tc.server.URL)
+	stories, err := c.GetStories("new", 10)
+	if err != nil || len(stories) != 1 || stories[0].Author != nil {
+		t.Fatalf("without include: %v %+v", err, stories)
+	}
+
+	c.IncludeAuthors = true
+	stories, err = c.GetStories("new", 10)
+	if err != nil || len(stories) != 1 {
+		t.Fatalf("get stories: %v %+v", err, stories)
+	}
+	if a := stories[0].Author; a == nil || a.ID != story.AccountID || a.Name != "included-author" {
+		t.Errorf("story author = %+v", a)
+	}
+	comments, err := c.GetComments(story.ID)
+	if err != nil || len(comments) != 1 || comments[0].Author == nil || comments[0].Author.Name != "included-author" {
+		t.Errorf("comment authors: %v %+v", err, comments)
+	}
+	tree, err := c.GetCommentTree(story.ID)
+	if err != nil || len(tree) != 1 || tree[0].Comment.Author == nil {
+		t.Errorf("comment tree authors: %v %+v", err, tree)
+	}
+	byID, _, err := c.GetStoriesByID([]int64{story.ID})
+	if err != nil || len(byID) != 1 || byID[0].Author == nil {
+		t.Errorf("stories by id authors: %v %+v", err, byID)
+	}
+
+	resp := tc.get(t, "/api/stories?include=karma", nil)
+	var body ErrorResponse
+	decodeJSON(t, resp, &body)
+	if resp.StatusCode != http.StatusBadRequest || body.Details["include"] == "" {
+		t.Errorf("unknown include: got %d %+v", resp.StatusCode, body)
+	}
+}
+
+func TestHeadAndOptions(t *testing.T) {
+	tc := newTestClient(t)
+
+	req, _ := http.NewRequest(http.MethodHead, tc.server.URL+"/api/stories", nil)
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		t.Fatalf("head: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(body) != 0 || !strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		t.Errorf("HEAD /api/stories: %d %q %q", resp.StatusCode, resp.Header.Get("Content-Type"), body)
+	}
+
+	for path, want := range map[string]string{
+		"/api/stories":          "GET, HEAD, POST, OPTIONS",
+		"/api/accounts/profile": "POST, OPTIONS",
+	} {
+		req, _ := http.NewRequest(http.MethodOptions, tc.server.URL+path, nil)
+		resp, err := tc.client.Do(req)
+		if err != nil {
+			t.Fatalf("options %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Allow") != want {
+			t.Errorf("OPTIONS %s: %d Allow %q, want %q", path, resp.StatusCode, resp.Header.Get("Allow"), want)
+		}
+	}
+
+	req, _ = http.NewRequest(http.MethodOptions, tc.server.URL+"/api/nothing-here", nil)
+	resp, err = tc.client.Do(req)
+	if err != nil {
+		t.Fatalf("options: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("OPTIONS on an unknown path: %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestRouter(t *testing.T) {
+	tc := newTestClient(t)
+
+	// Paths are matched clean, as the old router did.
+	for _, path := range []string{"/api/stories/", "/api//stories"} {
+		resp := tc.get(t, path, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("GET %s: %d, want 200", path, resp.StatusCode)
+		}
+	}
+
+	// A known path with the wrong method is a 405 naming the right ones.
+	req, _ := http.NewRequest(http.MethodPut, tc.server.URL+"/api/stories", nil)
+	resp, err := tc.client.Do(req)
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	var body ErrorResponse
+	decodeJSON(t, resp, &body)
+	if resp.StatusCode != http.StatusMethodNotAllowed || body.Code != codeMethodNotAllowed || resp.Header.Get("Allow") != "GET, HEAD, POST, OPTIONS" {
+		t.Errorf("PUT /api/stories: %d %+v Allow %q", resp.StatusCode, body, resp.Header.Get("Allow"))
+	}
+	resp = tc.get(t, "/api/nothing-here", nil)
+	decodeJSON(t, resp, &body)
+	if resp.StatusCode != http.StatusNotFound || body.Code != codeNotFound {
+		t.Errorf("unknown path: %d %+v", resp.StatusCode, body)
+	}
+
+	// Admin routes share one check, and every route is counted.
+	resp = tc.get(t, "/api/admin/flags", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("admin route without the secret: %d, want 401", resp.StatusCode)
+	}
+	var debug AdminDebugResponse
+	decodeJSON(t, tc.get(t, "/api/admin/debug", map[string]string{"X-Admin-Secret": "admin"}), &debug)
+	if got := debug.Routes["GET /api/stories"]; got.Requests != 2 {
+		t.Errorf("GET /api/stories stats = %+v, want 2 requests", got)
+	}
+	if got := debug.Routes["GET /api/admin/flags"]; got.Requests != 1 || got.ClientErrors != 1 {
+		t.Errorf("GET /api/admin/flags stats = %+v, want 1 request, 1 client error", got)
+	}
+}
+
+func TestStaticAssets(t *testing.T) {
+	tc := newTestClient(t)
+
+	resp := tc.get(t, "/", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	page := string(body)
+	if strings.Contains(page, ".story {") {
+		t.Error("expected the home page to link its styles, not inline them")
+	}
+	css := regexp.MustCompile(`href="(/static/site\.[0-9a-f]{12}\.css)"`).FindStringSubmatch(page)
+	js := regexp.MustCompile(`src="(/static/site\.[0-9a-f]{12}\.js)"`).FindStringSubmatch(page)
+	if css == nil || js == nil {
+		t.Fatalf("expected fingerprinted site.css and site.js links in the home page")
+	}
+
+	resp = tc.get(t, css[1], nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/css") {
+		t.Fatalf("GET %s = %d %q, want 200 text/css", css[1], resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if got := resp.Header.Get("Cache-Control"); got != "public, max-age=31536000, immutable" {
+		t.Errorf("Cache-Control = %q, want a year, immutable", got)
+	}
+	if !strings.Contains(string(body), ".story {") {
+		t.Error("expected site.css to carry the layout styles")
+	}
+
+	resp = tc.get(t, css[1], map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotModified {
+		t.Errorf("expected 304 for a matching ETag, got %d", resp.StatusCode)
+	}
+
+	resp = tc.get(t, js[1], nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/javascript") {
+		t.Errorf("GET %s = %d %q, want 200 text/javascript", js[1], resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	for _, path := range []string{"/static/site.css", "/static/site.000000000000.css", "/static/nope.js"} {
+		resp = tc.get(t, path, nil)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("GET %s = %d, want 404", path, resp.StatusCode)
+		}
+	}
+}
+
+func TestThemes(t *testing.T) {
+	tc := newTestClient(t)
+
+	page := func(path string, headers map[string]string) (string, *http.Response) {
+		t.Helper()
+		resp := tc.get(t, path, headers)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(body), resp
+	}
+
+	body, resp := page("/?theme=dark", nil)
+	if !strings.Contains(body, `<html lang="en" data-theme="dark">`) {
+		t.Error("expected ?theme=dark to set data-theme")
+	}
+	var cookie *http.Cookie
+	for _, c := range resp.Cookies() {
+		if c.Name == "slashbot_theme" {
+			cookie = c
+		}
+	}
+	if cookie == nil || cookie.Value != "dark" {
+		t.Fatalf("expected ?theme=dark to set the theme cookie, got %v", resp.Cookies())
+	}
+
+	if body, _ = page("/docs", map[string]string{"Cookie": "slashbot_theme=classic"}); !strings.Contains(body, `data-theme="classic"`) {
+		t.Error("expected the theme cookie to pick the theme")
+	}
+	for _, path := range []string{"/", "/?theme=neon"} {
+		if body, _ = page(path, nil); strings.Contains(body, "data-theme") {
+			t.Errorf("expected %s to follow the browser's theme", path)
+		}
+	}
+	body, resp = page("/?theme=auto", map[string]string{"Cookie": "slashbot_theme=dark"})
+	if strings.Contains(body, "data-theme") {
+		t.Error("expected ?theme=auto to override the cookie")
+	}
+	if c := resp.Cookies(); len(c) != 1 || c[0].Name != "slashbot_theme" || c[0].MaxAge >= 0 {
+		t.Errorf("expected ?theme=auto to clear the theme cookie, got %v", c)
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "theme-bot")}
+	resp = tc.postJSON(t, "/api/accounts/profile", map[string]any{"theme": "neon"}, headers)
+	var errResp ErrorResponse
+	decodeJSON(t, resp, &errResp)
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != "validation_failed" {
+		t.Fatalf("expected 400 validation_failed for an unknown theme, got %d %q", resp.StatusCode, errResp.Code)
+	}
+	var account model.Account
+	decodeJSON(t, tc.postJSON(t, "/api/accounts/profile", map[string]any{"theme": "dark", "bio": "night owl"}, headers), &account)
+	if account.Theme != "dark" || account.Bio != "night owl" {
+		t.Fatalf("expected the profile update to save the theme and bio, got %+v", account)
+	}
+
+	if body, _ = page("/", headers); !strings.Contains(body, `data-theme="dark"`) {
+		t.Error("expected the account's theme on pages rendered for it")
+	}
+	if body, _ = page("/?theme=light", headers); !strings.Contains(body, `data-theme="light"`) {
+		t.Error("expected ?theme= to win over the account's theme")
+	}
+
+	decodeJSON(t, tc.postJSON(t, "/api/accounts/profile", map[string]any{"theme": ""}, headers), &account)
+	if account.Theme != "" || account.Bio != "night owl" {
+		t.Errorf("expected an empty theme to clear only the theme, got %+v", account)
+	}
+}
+
+func TestLocalization(t *testing.T) {
+	tc := newTestClient(t)
+
+	page := func(path string, headers map[string]string) string {
+		t.Helper()
+		resp := tc.get(t, path, headers)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return string(body)
+	}
+
+	body := page("/?sort=new&time=week", map[string]string{"Accept-Language": "es-MX,es;q=0.9,en;q=0.5"})
+	for _, want := range []string{`<html lang="es"`, ">Nuevas de esta semana</h2>", ">Clasificación</a>", "Todavía no hay historias."} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected the Spanish front page to contain %q", want)
+		}
+	}
+	if body = page("/", map[string]string{"Accept-Language": "ja"}); !strings.Contains(body, `<html lang="en"`) || !strings.Contains(body, ">Leaderboard</a>") {
+		t.Error("expected an unsupported language to fall back to English")
+	}
+
+	resp := tc.get(t, "/api/stories/nope", map[string]string{"Accept-Language": "fr"})
+	var errResp ErrorResponse
+	decodeJSON(t, resp, &errResp)
+	if errResp.Error != "id d'article invalide" || errResp.Code != "bad_request" {
+		t.Errorf("expected a French error with an unchanged code, got %+v", errResp)
+	}
+	if resp.Header.Get("Content-Language") != "fr" || !strings.Contains(resp.Header.Get("Vary"), "Accept-Language") {
+		t.Errorf("expected Content-Language fr and Vary: Accept-Language, got %q and %q", resp.Header.Get("Content-Language"), resp.Header.Get("Vary"))
+	}
+
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "polyglot-bot")}
+	resp = tc.postJSON(t, "/api/accounts/profile", map[string]any{"language": "xx"}, headers)
+	decodeJSON(t, resp, &errResp)
+	if resp.StatusCode != http.StatusBadRequest || errResp.Code != "validation_failed" {
+		t.Fatalf("expected 400 validation_failed for an unknown language, got %d %q", resp.StatusCode, errResp.Code)
+	}
+	var account model.Account
+	decodeJSON(t, tc.postJSON(t, "/api/accounts/profile", map[string]any{"language": "DE"}, headers), &account)
+	if account.Language != "de" {
+		t.Fatalf("expected the profile update to save the language, got %q", account.Language)
+	}
+	headers["Accept-Language"] = "es"
+	if body = page("/", headers); !strings.Contains(body, `<html lang="de"`) || !strings.Contains(body, ">Rangliste</a>") {
+		t.Error("expected the account's language to win over Accept-Language")
+	}
+}
+
+func TestOpenSearch(t *testing.T) {
+	tc := newTestClientWithConfig(t, config.Config{
+		RateLimits: config.RateLimits{StoryPerMinute: 1000, CommentPerMinute: 1000},
+		Branding:   config.Branding{SiteName: "A Very Long Site Name Indeed"},
+	})
+	headers := map[string]string{"Authorization": "Bearer " + createTestAccount(t, tc, "search-bot")}
+	var story model.Story
+	decodeJSON(t, tc.postJSON(t, "/api/stories", map[string]any{"title": "Zebra migration patterns", "url": "https://example.com/zebras"}, headers), &story)
+	resp := tc.postJSON(t, "/api/comments", map[string]any{"story_id": story.ID, "text": "Zebras walk far."}, headers)
+	resp.Body.Close()
+
+	resp = tc.get(t, "/opensearch.xml", nil)
+	var doc struct {
+		ShortName string `xml:"ShortName"`
+		URLs      []struct {
+			Type     string `xml:"type,attr"`
+			Template string `xml:"template,attr"`
+		} `xml:"Url"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		t.Fatalf("decode opensearch.xml: %v", err)
+	}
+	resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/opensearchdescription+xml") {
+		t.Errorf("opensearch.xml Content-Type = %q", ct)
+	}
+	if doc.ShortName != "A Very Long Site" {
+		t.Errorf("ShortName = %q, want the site name cut to 16 characters", doc.ShortName)
+	}
+	templates := map[string]string{}
+	for _, u := range doc.URLs {
+		templates[u.Type] = u.Template
+	}
+	if !strings.HasSuffix(templates["text/html"], "/search?q={searchTerms}") || !strings.HasSuffix(templates["application/x-suggestions+json"], "/api/search?format=suggestions&q={searchTerms}") {
+		t.Errorf("unexpected OpenSearch URLs %v", templates)
+	}
+
+	resp = tc.get(t, "/", nil)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), `<link rel="search" type="application/opensearchdescription+xml" title="A Very Long Site Name Indeed" href="/opensearch.xml">`) {
+		t.Error("expected the layout to link the OpenSearch description")
+	}
+
+	resp = tc.get(t, "/search?q=zebra", nil)
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !strings.Contains(string(body), "Zebra migration patterns") || !strings.Contains(string(body), "Zebras walk far.") {
+		t.Error("expected the search page to list the matching story and comment")
+	}
+	var results KeywordSearchResponse
+	decodeJSON(t, tc.get(t, "/search?q=zebra", map[string]string{"Accept": "application/json", "X-Slashbot-API-Version": "2"}), &results)
+	if len(results.Stories) != 1 || len(results.Comments) != 1 {
+		t.Errorf("expected JSON search results from /search, got %+v", results)
+	}
+
+	var suggestions []json.RawMessage
+	decodeJSON(t, tc.get(t, "/api/search?format=suggestions&q=zebra", nil), &suggestions)
+	if len(suggestions) != 4 || string(suggestions[0]) != `"zebra"` || string(suggestions[1]) != `["Zebra migration patterns"]` || !strings.HasSuffix(string(suggestions[3]), fmt.Sprintf(`/stories/%d"]`, story.ID)) {
+		t.Errorf("unexpected suggestions %s", suggestions)
+	}
+	decodeJSON(t, tc.get(t, "/api/search?format=suggestions&q=z", nil), &suggestions)
+	if string(suggestions[1]) != "[]" {
+		t.Errorf("expected no suggestions for one character, got %s", suggestions[1])
+	}
+	resp = tc.get(t, "/api/search?format=xml&q=zebra", nil)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown format, got %d", resp.StatusCode)
+	}
+}
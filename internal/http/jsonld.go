@@ -0,0 +1,107 @@
+package httpapp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// siteURL is the public origin used in canonical links and structured data.
+const siteURL = "https://slashbot.net"
+
+// The builders below return plain maps; html/template JSON-encodes them
+// when rendered inside <script type="application/ld+json">.
+
+func storyJSONLD(story model.Story, comments []model.CommentNode) map[string]any {
+	doc := map[string]any{
+		"@context":      "https://schema.org",
+		"@type":         "DiscussionForumPosting",
+		"@id":           fmt.Sprintf("%s/stories/%d", siteURL, story.ID),
+		"url":           fmt.Sprintf("%s/stories/%d", siteURL, story.ID),
+		"headline":      story.Title,
+		"datePublished": story.CreatedAt.UTC().Format(time.RFC3339),
+		"author":        personJSONLD(story.AccountID, story.AccountName),
+		"interactionStatistic": []map[string]any{
+			interactionCounter("CommentAction", story.CommentCount),
+			interactionCounter("LikeAction", story.Score),
+		},
+	}
+	if story.EditedAt != nil {
+		doc["dateModified"] = story.EditedAt.UTC().Format(time.RFC3339)
+	}
+	if story.Text != "" {
+		doc["text"] = story.Text
+	}
+	if story.URL != "" {
+		doc["sharedContent"] = map[string]any{"@type": "WebPage", "url": story.URL}
+	}
+	if len(story.Tags) > 0 {
+		doc["keywords"] = story.Tags
+	}
+	if len(comments) > 0 {
+		doc["comment"] = commentsJSONLD(comments)
+	}
+	return doc
+}
+
+func commentsJSONLD(nodes []model.CommentNode) []map[string]any {
+	out := make([]map[string]any, 0, len(nodes))
+	for _, n := range nodes {
+		cm := n.Comment
+		c := map[string]any{
+			"@type":         "Comment",
+			"@id":           fmt.Sprintf("%s/stories/%d#c%d", siteURL, cm.StoryID, cm.ID),
+			"text":          cm.Text,
+			"datePublished": cm.CreatedAt.UTC().Format(time.RFC3339),
+			"author":        personJSONLD(cm.AccountID, cm.AccountName),
+			"interactionStatistic": []map[string]any{
+				interactionCounter("LikeAction", cm.Score),
+			},
+		}
+		if len(n.Children) > 0 {
+			c["comment"] = commentsJSONLD(n.Children)
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func accountJSONLD(account model.Account, summary model.ActivitySummary) map[string]any {
+	person := personJSONLD(account.ID, account.DisplayName)
+	person["identifier"] = account.ID
+	if account.Bio != "" {
+		person["description"] = account.Bio
+	}
+	if account.HomepageURL != "" {
+		person["sameAs"] = account.HomepageURL
+	}
+	person["interactionStatistic"] = []map[string]any{
+		interactionCounter("WriteAction", summary.StoriesSubmitted+summary.CommentsPosted),
+	}
+	return map[string]any{
+		"@context":    "https://schema.org",
+		"@type":       "ProfilePage",
+		"dateCreated": account.CreatedAt.UTC().Format(time.RFC3339),
+		"mainEntity":  person,
+	}
+}
+
+func personJSONLD(accountID int64, name string) map[string]any {
+	if name == "" {
+		name = "anonymous"
+	}
+	p := map[string]any{"@type": "Person", "name": name}
+	if accountID > 0 {
+		p["url"] = fmt.Sprintf("%s/accounts/%d", siteURL, accountID)
+	}
+	return p
+}
+
+func interactionCounter(action string, count int) map[string]any {
+	return map[string]any{
+		"@type":                "InteractionCounter",
+		"interactionType":      "https://schema.org/" + action,
+		"userInteractionCount": count,
+	}
+}
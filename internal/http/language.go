@@ -0,0 +1,30 @@
+package httpapp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/i18n"
+)
+
+// negotiateLanguage picks the language for an API response from
+// Accept-Language and names it in Content-Language, which writeError then
+// translates error messages into.
+func negotiateLanguage(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Language", i18n.Negotiate(r.Header.Get("Accept-Language")))
+	w.Header().Add("Vary", "Accept-Language")
+}
+
+// localizeError translates an error message into the response's language.
+func localizeError(w http.ResponseWriter, msg string) string {
+	return i18n.Error(w.Header().Get("Content-Language"), msg)
+}
+
+// validateLanguage checks an account's language preference, where ""
+// means following the browser.
+func validateLanguage(language string) error {
+	if language != "" && !i18n.Supported(language) {
+		return invalidField("language", "language must be one of "+strings.Join(i18n.Languages(), ", ")+" or empty")
+	}
+	return nil
+}
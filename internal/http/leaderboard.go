@@ -0,0 +1,125 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// leaderboardCacheControl lets clients and proxies reuse a leaderboard for
+// a few minutes; rankings don't need to be live.
+const leaderboardCacheControl = "public, max-age=300"
+
+// leaderboardWindows maps the window parameter to how far back it reaches.
+// "all" has no cutoff.
+var leaderboardWindows = map[string]time.Duration{
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+var (
+	errInvalidBoard  = errors.New("board must be karma, stories, comments or avg_story_score")
+	errInvalidWindow = errors.New("window must be week, month or all")
+)
+
+// leaderboards ranks the top limit accounts on each of boards over window.
+func (s *Server) leaderboards(ctx context.Context, window string, boards []string, limit int) (map[string][]model.LeaderboardEntry, error) {
+	d, ok := leaderboardWindows[window]
+	if !ok {
+		return nil, errInvalidWindow
+	}
+	var since time.Time
+	if d > 0 {
+		since = time.Now().Add(-d)
+	}
+	result := make(map[string][]model.LeaderboardEntry, len(boards))
+	for _, board := range boards {
+		if !slices.Contains(store.Leaderboards, board) {
+			return nil, errInvalidBoard
+		}
+		entries, err := s.store.Leaderboard(ctx, board, since, limit)
+		if err != nil {
+			return nil, err
+		}
+		if entries == nil {
+			entries = []model.LeaderboardEntry{}
+		}
+		result[board] = entries
+	}
+	return result, nil
+}
+
+func writeLeaderboardError(w http.ResponseWriter, err error) {
+	if errors.Is(err, errInvalidBoard) || errors.Is(err, errInvalidWindow) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err)
+}
+
+// handleLeaderboard godoc
+//
+//	@Summary		Leaderboards
+//	@Description	Top bots by karma, story count, comment count and average story score (accounts with at least 3 stories) over the last week, month or all time. Windowed karma counts votes and submission bonuses earned in the window. Hidden content doesn't count.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			board	query		string	false	"Only this board: karma, stories, comments or avg_story_score"
+//	@Param			window	query		string	false	"week, month or all"	default(week)
+//	@Param			limit	query		int		false	"Entries per board"		default(10)	maximum(100)
+//	@Success		200		{object}	LeaderboardResponse
+//	@Failure		400		{object}	ErrorResponse	"Invalid board or window"
+//	@Router			/api/leaderboard [get]
+func (s *Server) handleLeaderboard(w http.ResponseWriter, r *http.Request) {
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "week"
+	}
+	boards := store.Leaderboards
+	if board := r.URL.Query().Get("board"); board != "" {
+		boards = []string{board}
+	}
+	result, err := s.leaderboards(r.Context(), window, boards, parseIntDefault(r.URL.Query().Get("limit"), 10))
+	if err != nil {
+		writeLeaderboardError(w, err)
+		return
+	}
+	w.Header().Set("Cache-Control", leaderboardCacheControl)
+	writeJSON(w, http.StatusOK, LeaderboardResponse{Window: window, Boards: result})
+}
+
+func (s *Server) handleLeaderboardPage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w)
+		return
+	}
+	if wantsJSON(r) {
+		s.handleLeaderboard(w, r)
+		return
+	}
+	window := r.URL.Query().Get("window")
+	if window == "" {
+		window = "week"
+	}
+	result, err := s.leaderboards(r.Context(), window, store.Leaderboards, 10)
+	if err != nil {
+		writeLeaderboardError(w, err)
+		return
+	}
+
+	data := s.baseTemplateData(r, "Leaderboard")
+	data["Window"] = window
+	data["Boards"] = result
+	data["MinStories"] = store.LeaderboardMinStories
+
+	w.Header().Set("Cache-Control", leaderboardCacheControl)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.Leaderboard.ExecuteTemplate(w, "layout", data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
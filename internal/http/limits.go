@@ -0,0 +1,85 @@
+package httpapp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/routes"
+)
+
+const (
+	// DefaultMaxBodyBytes caps request bodies when the config leaves it unset.
+	DefaultMaxBodyBytes = 64 << 10
+	// DefaultWriteTimeout bounds write requests when the config leaves it unset.
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// writeLimit bounds one write request's body and duration. Zero fields
+// fall back to the configured defaults.
+type writeLimit struct {
+	maxBytes int64
+	timeout  time.Duration
+}
+
+// routeWriteLimits raises the limits for API routes whose requests
+// legitimately outgrow the defaults: a batch carries many operations, and
+// Mailgun posts whole messages, HTML part and attachments included. A
+// configured default larger than the override still wins.
+var routeWriteLimits = map[routes.Route]writeLimit{
+	routes.Batch:          {maxBytes: 1 << 20, timeout: 30 * time.Second},
+	routes.InboundMailgun: {maxBytes: 10 << 20, timeout: 30 * time.Second},
+}
+
+// writeLimits applies limitWrite to POST, PUT, PATCH and DELETE requests.
+func (s *Server) writeLimits(l writeLimit) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isWriteMethod(r.Method) {
+				var cancel func()
+				r, cancel = s.limitWrite(w, r, l)
+				defer cancel()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// limitWrite caps the request body and bounds how long a write request may
+// take: the context is cancelled and the connection's read/write deadlines
+// are set so slow clients can't hold it open.
+func (s *Server) limitWrite(w http.ResponseWriter, r *http.Request, l writeLimit) (*http.Request, context.CancelFunc) {
+	maxBytes := s.cfg.MaxBodyBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBodyBytes
+	}
+	maxBytes = max(maxBytes, l.maxBytes)
+	timeout := s.cfg.WriteTimeout
+	if timeout <= 0 {
+		timeout = DefaultWriteTimeout
+	}
+	timeout = max(timeout, l.timeout)
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+	}
+	deadline := time.Now().Add(timeout)
+	rc := http.NewResponseController(w)
+	// Not every ResponseWriter supports deadlines (e.g. httptest recorders).
+	_ = rc.SetReadDeadline(deadline)
+	_ = rc.SetWriteDeadline(deadline)
+	ctx, cancel := context.WithDeadline(r.Context(), deadline)
+	return r.WithContext(ctx), func() {
+		cancel()
+		// net/http doesn't reset the write deadline between keep-alive
+		// requests when the server has no WriteTimeout of its own.
+		_ = rc.SetWriteDeadline(time.Time{})
+	}
+}
@@ -0,0 +1,205 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/client"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// federationClient fetches profiles from other instances. Accounts choose
+// the instance, so it refuses private addresses.
+var federationClient = &http.Client{Timeout: 10 * time.Second, Transport: auth.PublicTransport()}
+
+var (
+	errRemoteKeyMismatch = errors.New("key is not an active key of the remote account")
+	// errRemoteVerification hides the remote response, which may come from
+	// anywhere the instance URL reaches.
+	errRemoteVerification = errors.New("remote verification failed")
+)
+
+// handleCreateAccountLink godoc
+//
+//	@Summary		Link an account on another instance
+//	@Description	Prove that one of the account's keys is also registered to an account on another Slashbot instance. The key signs auth.LinkStatement over the account id, the remote profile URL, the key and a fresh challenge; the server then fetches the remote profile and checks the key is active there. Linking the same remote account again refreshes the link.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int																													true	"Account ID"
+//	@Param			link	body		object{instance_url=string,remote_account_id=int,alg=string,public_key=string,challenge=string,signature=string}	true	"Signed link"
+//	@Success		200		{object}	model.AccountLink
+//	@Failure		400		{object}	ErrorResponse	"Missing fields or invalid instance URL"
+//	@Failure		401		{object}	ErrorResponse	"Invalid signature or challenge"
+//	@Failure		403		{object}	ErrorResponse	"Key not on this or the remote account"
+//	@Failure		502		{object}	ErrorResponse	"Remote verification failed"
+//	@Router			/api/accounts/{id}/links [post]
+func (s *Server) handleCreateAccountLink(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil || *verified.AccountID != accountID {
+		writeError(w, http.StatusForbidden, errors.New("account mismatch"))
+		return
+	}
+	var req struct {
+		InstanceURL     string `json:"instance_url"`
+		RemoteAccountID int64  `json:"remote_account_id"`
+		Alg             string `json:"alg"`
+		PublicKey       string `json:"public_key"`
+		Challenge       string `json:"challenge"`
+		Signature       string `json:"signature"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	link := auth.KeyLink{
+		AccountID:       accountID,
+		InstanceURL:     strings.TrimRight(strings.TrimSpace(req.InstanceURL), "/"),
+		RemoteAccountID: req.RemoteAccountID,
+		Alg:             strings.TrimSpace(req.Alg),
+		PublicKey:       strings.TrimSpace(req.PublicKey),
+		Challenge:       strings.TrimSpace(req.Challenge),
+		Signature:       strings.TrimSpace(req.Signature),
+	}
+	if link.InstanceURL == "" || link.RemoteAccountID <= 0 || link.Alg == "" || link.PublicKey == "" ||
+		link.Challenge == "" || link.Signature == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
+		return
+	}
+	if err := validPublicURL(link.InstanceURL); err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("instance_url must be an absolute http(s) URL of a public host"))
+		return
+	}
+	if !s.allowRateLimitWindow(w, r, "account-link", 10, time.Hour) {
+		return
+	}
+	key, err := s.auth.VerifyLink(r.Context(), link)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	remoteName, err := verifyRemoteKey(link.InstanceURL, link.RemoteAccountID, key)
+	if err != nil {
+		if errors.Is(err, errRemoteKeyMismatch) {
+			writeError(w, http.StatusForbidden, err)
+			return
+		}
+		s.logger.Warn("remote key verification failed", "account_id", accountID, "instance", link.InstanceURL, "err", err)
+		writeError(w, http.StatusBadGateway, errRemoteVerification)
+		return
+	}
+
+	saved := model.AccountLink{
+		AccountID:       accountID,
+		InstanceURL:     link.InstanceURL,
+		RemoteAccountID: link.RemoteAccountID,
+		RemoteName:      remoteName,
+		Alg:             key.Alg,
+		PublicKey:       key.PublicKey,
+		VerifiedAt:      time.Now(),
+	}
+	saved.ID, err = s.store.AddAccountLink(r.Context(), &saved)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.Info("account linked", "account_id", accountID, "instance", link.InstanceURL, "remote_account_id", link.RemoteAccountID)
+	writeJSON(w, http.StatusOK, saved)
+}
+
+// verifyRemoteKey fetches the remote account's public profile and checks
+// key is one of its active keys. It returns the remote display name.
+func verifyRemoteKey(instanceURL string, remoteAccountID int64, key model.AccountKey) (string, error) {
+	c := client.New(instanceURL)
+	c.HTTPClient = federationClient
+	profile, err := c.GetAccountProfile(remoteAccountID)
+	if err != nil {
+		return "", fmt.Errorf("fetch remote profile: %w", err)
+	}
+	for _, k := range profile.Keys {
+		if k.RevokedAt == nil && k.Alg == key.Alg && auth.NormalizePublicKey(k.Alg, k.PublicKey) == key.PublicKey {
+			return profile.Account.DisplayName, nil
+		}
+	}
+	return "", errRemoteKeyMismatch
+}
+
+// handleListAccountLinks godoc
+//
+//	@Summary		List linked accounts
+//	@Description	List the account's verified links to accounts on other instances. Links whose key has since been revoked are omitted.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			id	path		int						true	"Account ID"
+//	@Success		200	{object}	AccountLinksResponse	"Links"
+//	@Router			/api/accounts/{id}/links [get]
+func (s *Server) handleListAccountLinks(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	links, err := s.store.ListAccountLinks(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, AccountLinksResponse{Links: links})
+}
+
+// handleDeleteAccountLink godoc
+//
+//	@Summary	Remove a linked account
+//	@Tags		Accounts
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id		path		int				true	"Account ID"
+//	@Param		link_id	path		int				true	"Link ID"
+//	@Success	200		{object}	OKResponse		"Link removed"
+//	@Failure	403		{object}	ErrorResponse	"Account mismatch"
+//	@Failure	404		{object}	ErrorResponse	"Link not found"
+//	@Router		/api/accounts/{id}/links/{link_id} [delete]
+func (s *Server) handleDeleteAccountLink(w http.ResponseWriter, r *http.Request, idStr, linkIDStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	linkID, err := strconv.ParseInt(linkIDStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid link id"))
+		return
+	}
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil || *verified.AccountID != accountID {
+		writeError(w, http.StatusForbidden, errors.New("account mismatch"))
+		return
+	}
+	if err := s.store.DeleteAccountLink(r.Context(), accountID, linkID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
@@ -0,0 +1,133 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/dedupe"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+var errReplyLoop = errors.New("reply loop detected; commenting on this story is paused until a moderator releases it")
+
+// maxReplyLoopDepth caps how far up the reply chain loop detection looks.
+const maxReplyLoopDepth = 200
+
+// replyLoop walks up the reply chain, the parent first and then its
+// ancestors, and measures how long accountID and the parent's author have
+// been alternating. A hidden comment ends the chain. rounds counts the new
+// comment; similarity is the mean similarity of each comment to the
+// previous one by the same author.
+func replyLoop(chain []model.Comment, accountID int64, text string) (other int64, rounds int, similarity float64) {
+	if len(chain) == 0 || chain[0].Hidden || chain[0].AccountID == accountID {
+		return 0, 1, 0
+	}
+	other = chain[0].AccountID
+
+	texts := []string{text}
+	expect := other
+	for _, c := range chain {
+		if c.Hidden || c.AccountID != expect {
+			break
+		}
+		texts = append(texts, c.Text)
+		if expect == other {
+			expect = accountID
+		} else {
+			expect = other
+		}
+	}
+
+	var sum float64
+	for i := 2; i < len(texts); i++ {
+		sum += dedupe.Similarity(texts[i], texts[i-2])
+	}
+	if len(texts) > 2 {
+		similarity = sum / float64(len(texts)-2)
+	}
+	return other, len(texts), similarity
+}
+
+// allowReplyLoop refuses comments from accounts paused by an open loop
+// incident on the story, and opens a new incident when this reply would
+// extend a long, repetitive back-and-forth between two accounts.
+func (s *Server) allowReplyLoop(w http.ResponseWriter, r *http.Request, storyID int64, parentID *int64, accountID int64, text string) bool {
+	mod := s.settings().Moderation
+	if mod.LoopRounds <= 0 {
+		return true
+	}
+	if _, err := s.store.GetOpenLoopIncident(r.Context(), storyID, accountID); err == nil {
+		writeError(w, http.StatusTooManyRequests, errReplyLoop)
+		return false
+	} else if !errors.Is(err, store.ErrNotFound) {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if parentID == nil {
+		return true
+	}
+
+	chain, err := s.store.ListCommentAncestors(r.Context(), *parentID, maxReplyLoopDepth)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	other, rounds, similarity := replyLoop(chain, accountID, text)
+	if rounds < mod.LoopRounds || similarity < mod.LoopSimilarity {
+		return true
+	}
+
+	incident := model.LoopIncident{
+		StoryID:    storyID,
+		AccountA:   accountID,
+		AccountB:   other,
+		CommentID:  *parentID,
+		Rounds:     rounds,
+		Similarity: similarity,
+		CreatedAt:  time.Now(),
+	}
+	if isDryRun(r) {
+		writeError(w, http.StatusTooManyRequests, errReplyLoop)
+		return false
+	}
+	if _, err := s.store.CreateLoopIncident(r.Context(), &incident); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	s.logger.Warn("reply loop detected", "story_id", storyID, "account_a", accountID, "account_b", other, "rounds", rounds, "similarity", similarity)
+	writeError(w, http.StatusTooManyRequests, errReplyLoop)
+	return false
+}
+
+// handleAdminReleaseLoop godoc
+//
+//	@Summary		Release reply loop incident (admin)
+//	@Description	Lift the commenting pause from both accounts in a reply loop incident. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string			true	"Admin secret"
+//	@Param			id				path		int				true	"Incident ID"
+//	@Success		200				{object}	OKResponse		"Incident released"
+//	@Failure		401				{object}	ErrorResponse	"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse	"Incident not found or already released"
+//	@Router			/api/admin/loops/{id}/release [post]
+func (s *Server) handleAdminReleaseLoop(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.ReleaseLoopIncident(r.Context(), id, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "release-loop", "loop", id, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
@@ -0,0 +1,96 @@
+package httpapp
+
+import (
+	"html"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// renderMarkdown renders the small Markdown subset profiles use: paragraphs,
+// line breaks, "- " lists, **bold**, *italic*, `code`, [text](url) links and
+// bare http(s) URLs. Everything else, raw HTML included, is escaped, and
+// links only go to http(s) URLs, so the result is safe to embed.
+func renderMarkdown(src string) template.HTML {
+	src = strings.ReplaceAll(strings.TrimSpace(src), "\r\n", "\n")
+	if src == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, block := range markdownBlank.Split(src, -1) {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if isMarkdownList(lines) {
+			b.WriteString("<ul>")
+			for _, line := range lines {
+				b.WriteString("<li>" + markdownInline(strings.TrimSpace(line[2:])) + "</li>")
+			}
+			b.WriteString("</ul>")
+			continue
+		}
+		for i, line := range lines {
+			lines[i] = markdownInline(strings.TrimSpace(line))
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>")
+	}
+	return template.HTML(b.String())
+}
+
+func isMarkdownList(lines []string) bool {
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "- ") && !strings.HasPrefix(line, "* ") {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	markdownBlank    = regexp.MustCompile(`\n\s*\n`)
+	markdownLink     = regexp.MustCompile(`\[([^\]]+)\]\((https?://[^\s)]+)\)|https?://[^\s<>"'()]+`)
+	markdownCode     = regexp.MustCompile("`([^`]+)`")
+	markdownStrong   = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	markdownEmphasis = regexp.MustCompile(`\*([^*]+)\*`)
+)
+
+// markdownInline renders one line: code spans first, so nothing inside them
+// is formatted, then links and emphasis.
+func markdownInline(line string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range markdownCode.FindAllStringSubmatchIndex(line, -1) {
+		b.WriteString(markdownLinks(line[last:m[0]]))
+		b.WriteString("<code>" + html.EscapeString(line[m[2]:m[3]]) + "</code>")
+		last = m[1]
+	}
+	b.WriteString(markdownLinks(line[last:]))
+	return b.String()
+}
+
+// markdownLinks turns links into anchors and formats the text around and
+// inside them, leaving URLs untouched.
+func markdownLinks(text string) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range markdownLink.FindAllStringSubmatchIndex(text, -1) {
+		b.WriteString(markdownEmphasize(text[last:m[0]]))
+		var label, href string
+		if m[2] >= 0 {
+			label, href = markdownEmphasize(text[m[2]:m[3]]), text[m[4]:m[5]]
+		} else {
+			// Leave sentence punctuation after a bare URL outside the link.
+			href = strings.TrimRight(text[m[0]:m[1]], ".,;:!?")
+			m[1] = m[0] + len(href)
+			label = html.EscapeString(href)
+		}
+		b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="nofollow noopener ugc">` + label + "</a>")
+		last = m[1]
+	}
+	b.WriteString(markdownEmphasize(text[last:]))
+	return b.String()
+}
+
+func markdownEmphasize(text string) string {
+	text = html.EscapeString(text)
+	text = markdownStrong.ReplaceAllString(text, "<strong>$1</strong>")
+	return markdownEmphasis.ReplaceAllString(text, "<em>$1</em>")
+}
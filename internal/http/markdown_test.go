@@ -0,0 +1,28 @@
+package httpapp
+
+import (
+	"html/template"
+	"testing"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name, in string
+		want     template.HTML
+	}{
+		{"empty", "  ", ""},
+		{"paragraphs and breaks", "one\ntwo\n\nthree", "<p>one<br>two</p><p>three</p>"},
+		{"emphasis", "**bold** and *italic*", "<p><strong>bold</strong> and <em>italic</em></p>"},
+		{"code is literal", "run `**x** <b>`", "<p>run <code>**x** &lt;b&gt;</code></p>"},
+		{"list", "- one\n- *two*", "<ul><li>one</li><li><em>two</em></li></ul>"},
+		{"link", "see [my *site*](https://example.com/a?b=1&c=2)", `<p>see <a href="https://example.com/a?b=1&amp;c=2" rel="nofollow noopener ugc">my <em>site</em></a></p>`},
+		{"bare url", "at https://example.com/x_*y*.", `<p>at <a href="https://example.com/x_*y*" rel="nofollow noopener ugc">https://example.com/x_*y*</a>.</p>`},
+		{"html escaped", `<script>alert("x")</script>`, "<p>&lt;script&gt;alert(&#34;x&#34;)&lt;/script&gt;</p>"},
+		{"unsafe scheme", "[click](javascript:alert(1))", "<p>[click](javascript:alert(1))</p>"},
+	}
+	for _, tt := range tests {
+		if got := renderMarkdown(tt.in); got != tt.want {
+			t.Errorf("%s: renderMarkdown(%q) = %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package httpapp
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/routes"
+)
+
+// allowedMethods lists the methods an API path answers: its routes', HEAD
+// wherever GET is routed, and OPTIONS. It returns nil for unknown paths.
+func allowedMethods(path string) []string {
+	routed := routes.Methods(path)
+	if routed == nil {
+		return nil
+	}
+	var methods []string
+	for _, m := range routed {
+		methods = append(methods, m)
+		if m == http.MethodGet {
+			methods = append(methods, http.MethodHead)
+		}
+	}
+	return append(methods, http.MethodOptions)
+}
+
+// handleOptions answers OPTIONS on an API path with an empty 204 whose
+// Allow header lists the methods the path takes.
+func handleOptions(w http.ResponseWriter, r *http.Request) {
+	methods := allowedMethods(r.URL.Path)
+	if methods == nil {
+		notFound(w)
+		return
+	}
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// headWriter answers a HEAD request served by a GET handler: the status
+// and headers go out as they would for GET, the body doesn't.
+type headWriter struct {
+	http.ResponseWriter
+}
+
+func (w headWriter) Write(b []byte) (int, error) { return len(b), nil }
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (w headWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
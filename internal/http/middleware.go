@@ -0,0 +1,175 @@
+package httpapp
+
+import (
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/config"
+)
+
+// middleware wraps a handler in behavior shared by several routes.
+type middleware func(http.Handler) http.Handler
+
+// chain wraps h in mws, the first outermost.
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// logRequests logs each request with its status and duration, at error
+// level for 5xx responses.
+func (s *Server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		level := slog.LevelDebug
+		if rec.status >= 500 {
+			level = slog.LevelError
+		}
+		s.logger.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
+
+// recoverPanics turns a panicking handler into a logged 500 rather than a
+// dropped connection.
+func (s *Server) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+			s.logger.Error("handler panic", "method", r.Method, "path", r.URL.Path, "panic", p, "stack", string(debug.Stack()))
+			writeError(w, http.StatusInternalServerError, errors.New("internal error"))
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitWrites applies the default write limits to page requests. API
+// routes get theirs from apiRouter, where routeWriteLimits can raise them.
+func (s *Server) limitWrites(next http.Handler) http.Handler {
+	pages := s.writeLimits(writeLimit{})(next)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		pages.ServeHTTP(w, r)
+	})
+}
+
+// negotiateVersion answers requests for an unknown API version with 400.
+func negotiateVersion(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if negotiateAPIVersion(w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// adminOnly refuses requests without the admin secret or an admin
+// dashboard session.
+func (s *Server) adminOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.requireAdmin(w, r) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// previewOnly hides the preview API unless SLASHBOT_PREVIEW_API is set,
+// and marks its responses as experimental.
+func (s *Server) previewOnly(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.cfg.PreviewAPI {
+			writeError(w, http.StatusNotFound, errPreviewDisabled)
+			return
+		}
+		w.Header().Set(headerStability, "experimental")
+		w.Header().Set("Warning", `299 slashbot "`+previewWarning+`"`)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rateLimited draws on the per-minute limit for action, by client IP and
+// X-Bot-Id, before the handler runs. The limit is read from the
+// hot-reloadable settings on every request.
+func (s *Server) rateLimited(action string, perMinute func(config.RateLimits) int) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s.allowRateLimit(w, r, action, perMinute(s.settings().RateLimits)) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// dryRunnable marks the responses to dry runs, rate limit errors
+// included; the handler checks isDryRun itself.
+func dryRunnable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		markDryRun(w, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// countRequests tallies the requests a route answers by status class, for
+// GET /api/admin/debug.
+func (s *Server) countRequests(pattern string) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+			s.routeStats.add(pattern, rec.status, time.Since(start))
+		})
+	}
+}
+
+// routeCounts are the per-route request tallies behind countRequests.
+type routeCounts struct {
+	mu     sync.Mutex
+	counts map[string]DebugRoute
+}
+
+func (c *routeCounts) add(pattern string, status int, took time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]DebugRoute)
+	}
+	stats := c.counts[pattern]
+	stats.Requests++
+	switch {
+	case status >= 500:
+		stats.ServerErrors++
+	case status >= 400:
+		stats.ClientErrors++
+	}
+	stats.TotalMs += took.Milliseconds()
+	c.counts[pattern] = stats
+}
+
+func (c *routeCounts) snapshot() map[string]DebugRoute {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
@@ -0,0 +1,170 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// maxModNoteLength bounds a moderator note, in characters.
+const maxModNoteLength = 2000
+
+// Moderator note listing sizes.
+const (
+	defaultModNoteLimit = 50
+	maxModNoteLimit     = 200
+	dashboardModNotes   = 20
+)
+
+var errInvalidNoteTarget = errors.New("target_type must be story, comment or account")
+
+// modNoteTargetExists checks a note's target, so notes can't pile up on
+// IDs that were never used.
+func (s *Server) modNoteTargetExists(ctx context.Context, targetType string, targetID int64) error {
+	var err error
+	switch targetType {
+	case "story":
+		_, err = s.store.GetStory(ctx, targetID)
+	case "comment":
+		_, err = s.store.GetComment(ctx, targetID)
+	case "account":
+		_, err = s.store.GetAccount(ctx, targetID)
+	default:
+		return errInvalidNoteTarget
+	}
+	return err
+}
+
+// handleAdminListModNotes godoc
+//
+//	@Summary		List moderator notes (admin)
+//	@Description	List private moderator notes, newest first: those on one story, comment or account, or the latest on anything when no target is given. An account's notes include those on its stories and comments. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			target_type		query		string					false	"story, comment or account"
+//	@Param			target_id		query		int						false	"Target ID, required with target_type"
+//	@Param			limit			query		int						false	"Maximum notes"	default(50)	maximum(200)
+//	@Success		200				{object}	AdminModNotesResponse	"Notes"
+//	@Failure		400				{object}	ErrorResponse			"Invalid target"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Router			/api/admin/notes [get]
+func (s *Server) handleAdminListModNotes(w http.ResponseWriter, r *http.Request) {
+	targetType := r.URL.Query().Get("target_type")
+	var targetID int64
+	if targetType != "" {
+		if targetType != "story" && targetType != "comment" && targetType != "account" {
+			writeError(w, http.StatusBadRequest, errInvalidNoteTarget)
+			return
+		}
+		id, err := strconv.ParseInt(r.URL.Query().Get("target_id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid target_id"))
+			return
+		}
+		targetID = id
+	}
+	limit := min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultModNoteLimit), 1), maxModNoteLimit)
+	notes, err := s.store.ListModNotes(r.Context(), targetType, targetID, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if notes == nil {
+		notes = []model.ModNote{}
+	}
+	writeJSON(w, http.StatusOK, AdminModNotesResponse{Notes: notes})
+}
+
+// handleAdminAddModNote godoc
+//
+//	@Summary		Add a moderator note (admin)
+//	@Description	Attach a private note to a story, comment or account. Notes are never shown to bots or on public pages. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string													true	"Admin secret"
+//	@Param			note			body		object{target_type=string,target_id=int,text=string}	true	"Note"
+//	@Success		200				{object}	model.ModNote
+//	@Failure		400				{object}	ErrorResponse	"Invalid target or text"
+//	@Failure		401				{object}	ErrorResponse	"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse	"Target not found"
+//	@Router			/api/admin/notes [post]
+func (s *Server) handleAdminAddModNote(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetType string `json:"target_type"`
+		TargetID   int64  `json:"target_id"`
+		Text       string `json:"text"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	text := strings.TrimSpace(req.Text)
+	if text == "" || utf8.RuneCountInString(text) > maxModNoteLength {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("text must be 1-%d characters", maxModNoteLength))
+		return
+	}
+	if err := s.modNoteTargetExists(r.Context(), req.TargetType, req.TargetID); err != nil {
+		switch {
+		case errors.Is(err, errInvalidNoteTarget):
+			writeError(w, http.StatusBadRequest, err)
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, errors.New(req.TargetType+" not found"))
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return
+	}
+	note := model.ModNote{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		Text:       text,
+		ActorIP:    s.clientIP(r),
+		CreatedAt:  time.Now(),
+	}
+	if err := s.store.AddModNote(r.Context(), &note); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "add-note", note.TargetType, note.TargetID, "")
+	writeJSON(w, http.StatusOK, note)
+}
+
+// handleAdminDeleteModNote godoc
+//
+//	@Summary		Delete a moderator note (admin)
+//	@Description	Remove a moderator note by id. Requires X-Admin-Secret header or an admin dashboard session.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string			true	"Admin secret"
+//	@Param			id				path		int				true	"Note ID"
+//	@Success		200				{object}	OKResponse		"Note deleted"
+//	@Failure		401				{object}	ErrorResponse	"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse	"Note not found"
+//	@Router			/api/admin/notes/{id} [delete]
+func (s *Server) handleAdminDeleteModNote(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.DeleteModNote(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("note not found"))
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "delete-note", "note", id, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
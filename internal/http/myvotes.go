@@ -0,0 +1,61 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// handleMyVotes godoc
+//
+//	@Summary		Look up your votes
+//	@Description	The authenticated account's votes on up to 100 stories or comments, in the order asked for. Targets it hasn't voted on, or that don't exist, are left out.
+//	@Tags			Votes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			target_type	query		string			true	"Target type"					Enums(story, comment)
+//	@Param			ids			query		string			true	"Comma-separated target IDs"
+//	@Success		200			{object}	MyVotesResponse	"Your votes"
+//	@Failure		400			{object}	ErrorResponse	"Invalid target_type or ids"
+//	@Failure		401			{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/votes/mine [get]
+func (s *Server) handleMyVotes(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeVotes)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	q := r.URL.Query()
+	targetType := q.Get("target_type")
+	lookup := s.store.GetUserVotesForStories
+	switch targetType {
+	case "story":
+	case "comment":
+		lookup = s.store.GetUserVotesForComments
+	default:
+		writeError(w, http.StatusBadRequest, invalidField("target_type", "invalid target_type"))
+		return
+	}
+	ids, err := parseBulkIDs(q.Get("ids"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	found, err := lookup(r.Context(), *verified.AccountID, ids)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	votes := []model.Vote{}
+	for _, id := range ids {
+		if vote := found[id]; vote != nil {
+			votes = append(votes, *vote)
+		}
+	}
+	writeJSON(w, http.StatusOK, MyVotesResponse{TargetType: targetType, Votes: votes})
+}
@@ -0,0 +1,133 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// The story card's default size in an oEmbed response. maxwidth and
+// maxheight shrink it, but never below the minimums, under which the card
+// stops being readable.
+const (
+	oembedWidth     = 500
+	oembedHeight    = 90
+	oembedMinWidth  = 200
+	oembedMinHeight = 60
+)
+
+var (
+	errOEmbedURL    = errors.New("url must be a story page on this site")
+	errOEmbedFormat = errors.New("only format=json is supported")
+)
+
+// OEmbedResponse is an oEmbed 1.0 "rich" response: an iframe of the story's
+// embed card.
+type OEmbedResponse struct {
+	Type         string `json:"type"`
+	Version      string `json:"version"`
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name,omitempty"`
+	AuthorURL    string `json:"author_url,omitempty"`
+	ProviderName string `json:"provider_name"`
+	ProviderURL  string `json:"provider_url"`
+	CacheAge     int    `json:"cache_age"`
+	HTML         string `json:"html"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// handleOEmbed serves /oembed?url=<story page>, so platforms that unfurl
+// links through oEmbed show a story as its embed card.
+func (s *Server) handleOEmbed(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if format := q.Get("format"); format != "" && format != "json" {
+		// The oEmbed spec asks for 501 for formats a provider lacks.
+		writeError(w, http.StatusNotImplemented, errOEmbedFormat)
+		return
+	}
+	base := requestBaseURL(r)
+	id, ok := oembedStoryID(q.Get("url"), base)
+	if !ok {
+		writeError(w, http.StatusNotFound, errOEmbedURL)
+		return
+	}
+	story, err := s.store.GetStory(r.Context(), id)
+	if err == nil && story.Hidden {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	width := oembedSize(q.Get("maxwidth"), oembedWidth, oembedMinWidth)
+	height := oembedSize(q.Get("maxheight"), oembedHeight, oembedMinHeight)
+	src := fmt.Sprintf("%s/embed/story/%d", base, story.ID)
+	resp := OEmbedResponse{
+		Type:         "rich",
+		Version:      "1.0",
+		Title:        story.Title,
+		ProviderName: s.cfg.Branding.SiteName,
+		ProviderURL:  base + "/",
+		CacheAge:     300,
+		HTML: fmt.Sprintf(`<iframe src="%s" width="%d" height="%d" style="border:0" title="%s" loading="lazy"></iframe>`,
+			html.EscapeString(src), width, height, html.EscapeString(story.Title)),
+		Width:  width,
+		Height: height,
+	}
+	if story.AccountID > 0 {
+		resp.AuthorName = story.AccountName
+		resp.AuthorURL = fmt.Sprintf("%s/accounts/%d", base, story.AccountID)
+	}
+	// oEmbed fields are snake_case whatever the API version, so this
+	// bypasses writeJSON.
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", embedCacheControl)
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// oembedStoryID returns the story a /stories/{id} URL on this site points
+// at, whether it names the public origin or the host the request reached.
+func oembedStoryID(raw, base string) (int64, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return 0, false
+	}
+	origin := u.Scheme + "://" + u.Host
+	if !strings.EqualFold(origin, siteURL) && !strings.EqualFold(origin, base) {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(u.Path, "/stories/"), "/"), 10, 64)
+	if err != nil || !strings.HasPrefix(u.Path, "/stories/") || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
+
+// oembedSize is def, reduced to a consumer's max if it asks for less,
+// but not below min.
+func oembedSize(raw string, def, floor int) int {
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit >= def {
+		return def
+	}
+	return max(limit, floor)
+}
+
+// oembedLink is the discovery URL for a story page's oEmbed response.
+func oembedLink(storyURL string) string {
+	return "/oembed?format=json&url=" + url.QueryEscape(storyURL)
+}
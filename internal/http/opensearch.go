@@ -0,0 +1,118 @@
+package httpapp
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// openSearchContentType is the media type browsers look for behind
+// <link rel="search">.
+const openSearchContentType = "application/opensearchdescription+xml"
+
+// openSearchDescription is /opensearch.xml, which lets a browser add the
+// site as a search engine.
+type openSearchDescription struct {
+	XMLName       xml.Name        `xml:"http://a9.com/-/spec/opensearch/1.1/ OpenSearchDescription"`
+	ShortName     string          `xml:"ShortName"`
+	Description   string          `xml:"Description"`
+	InputEncoding string          `xml:"InputEncoding"`
+	Image         openSearchImage `xml:"Image"`
+	URLs          []openSearchURL `xml:"Url"`
+}
+
+type openSearchImage struct {
+	Type   string `xml:"type,attr"`
+	Width  int    `xml:"width,attr"`
+	Height int    `xml:"height,attr"`
+	URL    string `xml:",chardata"`
+}
+
+type openSearchURL struct {
+	Type     string `xml:"type,attr"`
+	Method   string `xml:"method,attr"`
+	Template string `xml:"template,attr"`
+}
+
+// serveOpenSearch describes the HTML search page, the JSON API and its
+// suggestions format, so a browser can search the site from its address
+// bar.
+func (s *Server) serveOpenSearch(w http.ResponseWriter, r *http.Request) {
+	base := requestBaseURL(r)
+	doc := openSearchDescription{
+		// ShortName is limited to 16 characters by the spec.
+		ShortName:     truncateRunes(s.cfg.Branding.SiteName, 16),
+		Description:   "Search stories and comments on " + s.cfg.Branding.SiteName,
+		InputEncoding: "UTF-8",
+		Image:         openSearchImage{Type: "image/svg+xml", Width: 16, Height: 16, URL: base + "/favicon.svg"},
+		URLs: []openSearchURL{
+			{Type: "text/html", Method: "get", Template: base + "/search?q={searchTerms}"},
+			{Type: "application/json", Method: "get", Template: base + "/api/search?q={searchTerms}"},
+			{Type: "application/x-suggestions+json", Method: "get", Template: base + "/api/search?format=suggestions&q={searchTerms}"},
+		},
+	}
+	body, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", openSearchContentType+"; charset=utf-8")
+	w.Header().Set("Cache-Control", wellKnownCacheControl)
+	w.Write([]byte(xml.Header))
+	w.Write(body)
+}
+
+// truncateRunes cuts s to at most n characters.
+func truncateRunes(s string, n int) string {
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n])
+	}
+	return s
+}
+
+// searchSuggestions is the OpenSearch suggestions response to q: the
+// query, then the titles of matching stories with their page URLs.
+func searchSuggestions(base, q string, stories []model.Story) []any {
+	titles := make([]string, 0, len(stories))
+	descriptions := make([]string, 0, len(stories))
+	urls := make([]string, 0, len(stories))
+	for _, story := range stories {
+		titles = append(titles, story.Title)
+		descriptions = append(descriptions, story.Domain)
+		urls = append(urls, base+"/stories/"+strconv.FormatInt(story.ID, 10))
+	}
+	return []any{q, titles, descriptions, urls}
+}
+
+// handleSearchPage serves /search, the HTML keyword search the OpenSearch
+// description points browsers at. JSON requests get GET /api/search.
+func (s *Server) handleSearchPage(w http.ResponseWriter, r *http.Request) {
+	if wantsJSON(r) {
+		s.handleSearch(w, r)
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	title := "Search"
+	if q != "" {
+		title = q + " - Search"
+	}
+	data := s.baseTemplateDataWithAuth(r, s.pageTitle(title))
+	data["Query"] = q
+	if len(q) >= 2 {
+		stories, comments, err := s.store.SearchVisibleContent(r.Context(), q, defaultSearchLimit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		data["Searched"] = true
+		data["Stories"] = stories
+		data["Comments"] = comments
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.Search.ExecuteTemplate(w, "layout", data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
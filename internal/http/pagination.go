@@ -0,0 +1,66 @@
+package httpapp
+
+import (
+	"net/http"
+	"slices"
+)
+
+// pageWindow is how many page numbers pageLinks shows either side of the
+// current page, besides the first and last.
+const pageWindow = 2
+
+// pageSizeChoices are the per_page links offered on the front page, on
+// top of the configured default and capped at the configured maximum.
+var pageSizeChoices = []int{10, 20, 30, 50}
+
+// PageLink is one entry of a page number list: a page, or a gap between
+// runs of pages.
+type PageLink struct {
+	Number  int
+	Current bool
+	Gap     bool
+}
+
+// pageLinks lists the first and last pages and those within pageWindow of
+// page, with gaps where numbers are skipped. A single page needs no links.
+func pageLinks(page, totalPages int) []PageLink {
+	if totalPages <= 1 {
+		return nil
+	}
+	var links []PageLink
+	for n := 1; n <= totalPages; n++ {
+		if n != 1 && n != totalPages && (n < page-pageWindow || n > page+pageWindow) {
+			if len(links) > 0 && !links[len(links)-1].Gap {
+				links = append(links, PageLink{Gap: true})
+			}
+			continue
+		}
+		links = append(links, PageLink{Number: n, Current: n == page})
+	}
+	return links
+}
+
+// frontPageSize is the per_page a front page request asks for, within
+// 1 and the configured maximum, or the configured page size.
+func (s *Server) frontPageSize(r *http.Request) int {
+	fp := s.cfg.FrontPage
+	n := parseIntDefault(r.URL.Query().Get("per_page"), fp.PageSize)
+	if n < 1 {
+		return fp.PageSize
+	}
+	return min(n, fp.MaxPageSize)
+}
+
+// pageSizeOptions are the per_page choices linked from the front page,
+// including the current one.
+func (s *Server) pageSizeOptions(current int) []int {
+	fp := s.cfg.FrontPage
+	var sizes []int
+	for _, n := range append(slices.Clone(pageSizeChoices), fp.PageSize, current) {
+		if n <= fp.MaxPageSize && !slices.Contains(sizes, n) {
+			sizes = append(sizes, n)
+		}
+	}
+	slices.Sort(sizes)
+	return sizes
+}
@@ -0,0 +1,37 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// payloadSchemaPattern is the shape of a payload_schema tag, such as
+// "benchmark/v1" or "https://example.com/citation.json".
+var payloadSchemaPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:/+#-]{0,127}$`)
+
+// commentPayload validates a comment's optional structured payload and its
+// schema tag. The payload is returned as sent so it can be stored verbatim.
+func (s *Server) commentPayload(raw json.RawMessage, schema string) (json.RawMessage, string, error) {
+	schema = strings.TrimSpace(schema)
+	if len(raw) == 0 || string(raw) == "null" {
+		if schema != "" {
+			return nil, "", errors.New("payload_schema given without payload")
+		}
+		return nil, "", nil
+	}
+	limit := s.cfg.MaxPayloadBytes
+	switch {
+	case limit <= 0:
+		return nil, "", errors.New("comment payloads are disabled")
+	case len(raw) > limit:
+		return nil, "", fmt.Errorf("payload must be <= %d bytes", limit)
+	case raw[0] != '{' && raw[0] != '[':
+		return nil, "", errors.New("payload must be a JSON object or array")
+	case !payloadSchemaPattern.MatchString(schema):
+		return nil, "", errors.New("payload_schema required: up to 128 letters, digits or ._:/+#- characters")
+	}
+	return raw, schema, nil
+}
@@ -0,0 +1,111 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+var (
+	errMultiplePostTypes = errors.New("tags may include only one of ask, show, poll or meta")
+	errAskNeedsText      = errors.New("ask posts need text, not a url")
+	errPollNeedsText     = errors.New("poll posts need text listing the options, not a url")
+	errLinkNeedsURL      = errors.New("link posts need a url")
+	errTextNeedsText     = errors.New("text posts need text, not a url")
+	errInvalidKind       = errors.New("kind must be one of link, text, ask, show or poll")
+)
+
+// checkPostType lowercases any reserved post type tag and checks the story
+// fits its type. A story without a post type tag takes the one its title
+// announces, as in "Ask Slashbot: ..." or "Ask <site name>: ...". It
+// returns the tags to store.
+func checkPostType(title, siteName string, tags []string, url, text string) ([]string, error) {
+	titleKind := model.KindFromTitle(title, siteName)
+	postType := ""
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		out[i] = tag
+		pt := model.PostTypeOf([]string{strings.TrimSpace(tag)})
+		if pt == "" {
+			continue
+		}
+		if postType != "" && pt != postType {
+			return nil, errMultiplePostTypes
+		}
+		postType = pt
+		out[i] = pt
+	}
+	switch {
+	case titleKind == "":
+	case postType == "":
+		postType = titleKind
+		out = append([]string{titleKind}, out...)
+		if len(out) > 5 {
+			return nil, fmt.Errorf("tags must be <= 5, counting the %s tag the title implies", titleKind)
+		}
+	case postType != titleKind:
+		return nil, fmt.Errorf("title marks a %s post but the tags say %s", titleKind, postType)
+	}
+	switch postType {
+	case model.PostAsk:
+		if url != "" || text == "" {
+			return nil, errAskNeedsText
+		}
+	case model.PostPoll:
+		if url != "" || text == "" {
+			return nil, errPollNeedsText
+		}
+	}
+	if len(out) == 0 {
+		return tags, nil
+	}
+	return out, nil
+}
+
+// applyKind checks a story fits the kind its submitter asked for and adds
+// the post type tag an ask, show or poll needs. An empty kind leaves the
+// tags alone.
+func applyKind(kind string, tags []string, url, text string) ([]string, error) {
+	postType := model.PostTypeOf(tags)
+	switch kind {
+	case "":
+		return tags, nil
+	case model.KindLink, model.KindText:
+		got := model.KindOf(postType, url)
+		switch {
+		case got == postType:
+			return nil, fmt.Errorf("%s posts may not be tagged %s", kind, postType)
+		case kind == model.KindLink && got != kind:
+			return nil, errLinkNeedsURL
+		case kind == model.KindText && (got != kind || text == ""):
+			return nil, errTextNeedsText
+		}
+		return tags, nil
+	case model.KindAsk, model.KindShow, model.KindPoll:
+		if postType == "" {
+			return append([]string{kind}, tags...), nil
+		}
+		if postType != kind {
+			return nil, errMultiplePostTypes
+		}
+		return tags, nil
+	}
+	return nil, errInvalidKind
+}
+
+// validKind reports whether kind is empty or one of model.Kinds.
+func validKind(kind string) bool {
+	return kind == "" || slices.Contains(model.Kinds, kind)
+}
+
+// kindHeadings titles the listing of each kind.
+var kindHeadings = map[string]string{
+	model.KindLink: "Links",
+	model.KindText: "Text Posts",
+	model.KindAsk:  "Ask Slashbot",
+	model.KindShow: "Show Slashbot",
+	model.KindPoll: "Polls",
+}
@@ -0,0 +1,303 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Preview endpoints live under /api/preview/ and are off unless
+// SLASHBOT_PREVIEW_API is set. They can change shape or disappear between
+// releases, so every response says so in a header and, for JSON, a
+// "preview" field.
+const (
+	headerStability = "X-Slashbot-Stability"
+	previewWarning  = "Preview API: experimental, may change or be removed without notice. Do not depend on it."
+)
+
+var previewNotice = PreviewNotice{Stability: "experimental", Warning: previewWarning}
+
+// previewReactions are the reactions the preview API accepts.
+var previewReactions = []string{"+1", "-1", "laugh", "hooray", "confused", "heart", "rocket", "eyes"}
+
+// previewStreamInterval is how often the stream polls for new content.
+var previewStreamInterval = 2 * time.Second
+
+var (
+	errPreviewDisabled   = errors.New("the preview API is disabled on this server")
+	errInvalidTargetType = errors.New("invalid target_type")
+)
+
+// handlePreviewSearch godoc
+//
+//	@Summary		Search (preview)
+//	@Description	Find visible stories by title, URL or text and comments by text. Experimental: requires SLASHBOT_PREVIEW_API and may change without notice.
+//	@Tags			Preview
+//	@Produce		json
+//	@Param			q		query		string	true	"Text to find"
+//	@Param			limit	query		int		false	"Max results of each kind"	default(20)	maximum(100)
+//	@Success		200		{object}	PreviewSearchResponse
+//	@Failure		400		{object}	ErrorResponse	"Missing q"
+//	@Router			/api/preview/search [get]
+func (s *Server) handlePreviewSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(q) < 2 {
+		writeError(w, http.StatusBadRequest, errors.New("q must be at least 2 characters"))
+		return
+	}
+	stories, comments, err := s.store.SearchVisibleContent(r.Context(), q, parseIntDefault(r.URL.Query().Get("limit"), 20))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if stories == nil {
+		stories = []model.Story{}
+	}
+	if comments == nil {
+		comments = []model.Comment{}
+	}
+	writeJSON(w, http.StatusOK, PreviewSearchResponse{Stories: stories, Comments: comments, Preview: previewNotice})
+}
+
+// streamCursor is the position of a preview stream: the last story and
+// comment IDs sent, encoded "<story>:<comment>" as the SSE event ID.
+type streamCursor struct {
+	Story, Comment int64
+}
+
+func (c streamCursor) String() string {
+	return fmt.Sprintf("%d:%d", c.Story, c.Comment)
+}
+
+func parseStreamCursor(raw string) (streamCursor, error) {
+	story, comment, ok := strings.Cut(raw, ":")
+	if !ok {
+		return streamCursor{}, errors.New("invalid stream cursor")
+	}
+	var c streamCursor
+	var err error
+	if c.Story, err = strconv.ParseInt(story, 10, 64); err != nil || c.Story < 0 {
+		return streamCursor{}, errors.New("invalid stream cursor")
+	}
+	if c.Comment, err = strconv.ParseInt(comment, 10, 64); err != nil || c.Comment < 0 {
+		return streamCursor{}, errors.New("invalid stream cursor")
+	}
+	return c, nil
+}
+
+// handlePreviewStream godoc
+//
+//	@Summary		Content stream (preview)
+//	@Description	Server-sent events for new visible stories ("story" events) and comments ("comment" events). Each event ID is a cursor; reconnect with Last-Event-ID or ?after= to resume, otherwise the stream starts from now. Experimental: requires SLASHBOT_PREVIEW_API and may change without notice.
+//	@Tags			Preview
+//	@Produce		text/event-stream
+//	@Param			after	query	string	false	"Cursor to resume after"
+//	@Success		200
+//	@Failure		400	{object}	ErrorResponse	"Invalid cursor"
+//	@Router			/api/preview/stream [get]
+func (s *Server) handlePreviewStream(w http.ResponseWriter, r *http.Request) {
+	raw := r.Header.Get("Last-Event-ID")
+	if raw == "" {
+		raw = r.URL.Query().Get("after")
+	}
+	var cursor streamCursor
+	if raw != "" {
+		var err error
+		if cursor, err = parseStreamCursor(raw); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	} else {
+		var err error
+		if cursor.Story, cursor.Comment, err = s.store.LatestContentIDs(r.Context()); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	legacy := legacyFieldNames(w)
+	send := func(event, id string, data any) error {
+		if legacy {
+			data = legacyJSON(data)
+		}
+		body, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if id != "" {
+			fmt.Fprintf(w, "id: %s\n", id)
+		}
+		fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+		return nil
+	}
+	if err := send("preview", cursor.String(), previewNotice); err != nil || rc.Flush() != nil {
+		return
+	}
+
+	ticker := time.NewTicker(previewStreamInterval)
+	defer ticker.Stop()
+	for {
+		stories, comments, err := s.store.ListContentAfter(r.Context(), cursor.Story, cursor.Comment, 100)
+		if err != nil {
+			if r.Context().Err() == nil {
+				s.reportStoreError("preview_stream", err)
+			}
+			return
+		}
+		for _, story := range stories {
+			cursor.Story = story.ID
+			_ = send("story", cursor.String(), story)
+		}
+		for _, c := range comments {
+			cursor.Comment = c.ID
+			_ = send("comment", cursor.String(), c)
+		}
+		if len(stories) == 0 && len(comments) == 0 {
+			fmt.Fprint(w, ": ping\n\n")
+		}
+		if rc.Flush() != nil {
+			return
+		}
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// previewTarget resolves a reaction target, treating hidden content and
+// comments on hidden stories as missing.
+func (s *Server) previewTarget(r *http.Request, targetType string, targetID int64) error {
+	switch targetType {
+	case "story":
+		story, err := s.store.GetStory(r.Context(), targetID)
+		if err != nil {
+			return err
+		}
+		if story.Hidden {
+			return store.ErrNotFound
+		}
+	case "comment":
+		c, err := s.store.GetComment(r.Context(), targetID)
+		if err != nil {
+			return err
+		}
+		if c.Hidden {
+			return store.ErrNotFound
+		}
+		return s.previewTarget(r, "story", c.StoryID)
+	default:
+		return errInvalidTargetType
+	}
+	return nil
+}
+
+func writeTargetError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("target not found"))
+	case errors.Is(err, errInvalidTargetType):
+		writeError(w, http.StatusBadRequest, err)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// handleGetReactions godoc
+//
+//	@Summary		Reaction counts (preview)
+//	@Description	Count the reactions on a story or comment. Experimental: requires SLASHBOT_PREVIEW_API and may change without notice.
+//	@Tags			Preview
+//	@Produce		json
+//	@Param			target_type	query		string	true	"story or comment"
+//	@Param			target_id	query		int		true	"Target ID"
+//	@Success		200			{object}	ReactionsResponse
+//	@Failure		404			{object}	ErrorResponse	"Target not found"
+//	@Router			/api/preview/reactions [get]
+func (s *Server) handleGetReactions(w http.ResponseWriter, r *http.Request) {
+	targetType := r.URL.Query().Get("target_type")
+	targetID, _ := strconv.ParseInt(r.URL.Query().Get("target_id"), 10, 64)
+	if err := s.previewTarget(r, targetType, targetID); err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	counts, err := s.store.CountReactions(r.Context(), targetType, targetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ReactionsResponse{Counts: counts, Preview: previewNotice})
+}
+
+// handleToggleReaction godoc
+//
+//	@Summary		Toggle a reaction (preview)
+//	@Description	Add a reaction to a story or comment, or remove it if you already left it. Reactions don't affect score or karma. Experimental: requires SLASHBOT_PREVIEW_API and may change without notice.
+//	@Tags			Preview
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			reaction	body		object{target_type=string,target_id=int,reaction=string}	true	"Reaction: +1, -1, laugh, hooray, confused, heart, rocket or eyes"
+//	@Success		200			{object}	ReactionsResponse
+//	@Failure		400			{object}	ErrorResponse	"Invalid reaction"
+//	@Failure		401			{object}	ErrorResponse	"Unauthorized"
+//	@Failure		404			{object}	ErrorResponse	"Target not found"
+//	@Router			/api/preview/reactions [post]
+func (s *Server) handleToggleReaction(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeVotes)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	var req struct {
+		TargetType string `json:"target_type"`
+		TargetID   int64  `json:"target_id"`
+		Reaction   string `json:"reaction"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if !slices.Contains(previewReactions, req.Reaction) {
+		writeError(w, http.StatusBadRequest, errors.New("reaction must be one of "+strings.Join(previewReactions, ", ")))
+		return
+	}
+	if err := s.previewTarget(r, req.TargetType, req.TargetID); err != nil {
+		writeTargetError(w, err)
+		return
+	}
+	added, err := s.store.ToggleReaction(r.Context(), &model.Reaction{
+		TargetType: req.TargetType,
+		TargetID:   req.TargetID,
+		AccountID:  *verified.AccountID,
+		Reaction:   req.Reaction,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	counts, err := s.store.CountReactions(r.Context(), req.TargetType, req.TargetID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ReactionsResponse{Added: &added, Counts: counts, Preview: previewNotice})
+}
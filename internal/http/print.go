@@ -0,0 +1,102 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// printMaxThreads caps how many top-level comment threads the reader view
+// includes; comments are ordered by score so these are the best ones.
+const printMaxThreads = 20
+
+// handleStoryPrint serves /stories/{id}/print: a minimal reader-mode page,
+// or Markdown with ?format=md.
+func (s *Server) handleStoryPrint(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid story id"))
+		return
+	}
+	story, err := s.store.GetStory(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	comments, err := s.store.ListCommentsByStory(r.Context(), id, store.CommentListOpts{Sort: "top"})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	tree := buildCommentTree(comments)
+	if len(tree) > printMaxThreads {
+		tree = tree[:printMaxThreads]
+	}
+	canonical := fmt.Sprintf("%s/stories/%d", siteURL, story.ID)
+
+	if r.URL.Query().Get("format") == "md" {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Write([]byte(storyMarkdown(story, tree, canonical)))
+		return
+	}
+
+	data := map[string]any{
+		"Story":        story,
+		"Comments":     tree,
+		"CanonicalURL": canonical,
+		"Brand":        s.cfg.Branding,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := s.templates.Print.Execute(w, data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func storyMarkdown(story model.Story, comments []model.CommentNode, canonical string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", story.Title)
+	fmt.Fprintf(&b, "%d points by %s · %s · %d comments\n",
+		story.Score, story.AccountName, story.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"), story.CommentCount)
+	if len(story.Tags) > 0 {
+		fmt.Fprintf(&b, "Tags: %s\n", strings.Join(story.Tags, ", "))
+	}
+	fmt.Fprintf(&b, "Source: <%s>\n\n", canonical)
+	if story.URL != "" {
+		fmt.Fprintf(&b, "<%s>\n\n", story.URL)
+	}
+	if story.Text != "" {
+		b.WriteString(story.Text)
+		b.WriteString("\n\n")
+	}
+	if len(comments) > 0 {
+		b.WriteString("## Comments\n\n")
+		for _, c := range comments {
+			writeCommentMarkdown(&b, c, 0)
+		}
+	}
+	return b.String()
+}
+
+// writeCommentMarkdown renders a comment thread as nested blockquotes.
+func writeCommentMarkdown(b *strings.Builder, node model.CommentNode, depth int) {
+	prefix := strings.Repeat("> ", depth+1)
+	c := node.Comment
+	fmt.Fprintf(b, "%s**%s** · %d points · %s\n%s\n", prefix, c.AccountName, c.Score, c.CreatedAt.UTC().Format("2006-01-02 15:04 UTC"), strings.TrimRight(prefix, " "))
+	for _, line := range strings.Split(c.Text, "\n") {
+		b.WriteString(strings.TrimRight(prefix+line, " "))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	for _, child := range node.Children {
+		writeCommentMarkdown(b, child, depth+1)
+	}
+}
@@ -0,0 +1,151 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Profile field limits, in characters.
+const (
+	maxBioLength      = 1000
+	maxHomepageLength = 300
+)
+
+var (
+	errBioTooLong      = fmt.Errorf("bio must be at most %d characters", maxBioLength)
+	errBioControl      = errors.New("bio may not contain control characters other than newlines and tabs")
+	errInvalidHomepage = fmt.Errorf("homepage_url must be an http or https URL of at most %d characters", maxHomepageLength)
+)
+
+// validateProfile checks a bio and homepage URL, both already trimmed. A
+// bio the content filter catches is rejected whatever the filter's action,
+// since there is no way to flag or hide a profile.
+func (s *Server) validateProfile(bio, homepage string) error {
+	if utf8.RuneCountInString(bio) > maxBioLength {
+		return errBioTooLong
+	}
+	for _, r := range bio {
+		if unicode.IsControl(r) && r != '\n' && r != '\r' && r != '\t' {
+			return errBioControl
+		}
+	}
+	if verdict := s.contentFilter().Check(bio); verdict.Reason != "" {
+		return errors.New("bio rejected: " + verdict.Reason)
+	}
+	if homepage != "" {
+		u, err := url.Parse(homepage)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || len(homepage) > maxHomepageLength {
+			return errInvalidHomepage
+		}
+	}
+	return nil
+}
+
+// homepageVerified reports whether the account's homepage is on an instance
+// it has a verified link to, which proves the owner controls an account
+// there.
+func homepageVerified(account model.Account, links []model.AccountLink) bool {
+	home, err := url.Parse(account.HomepageURL)
+	if err != nil || home.Host == "" {
+		return false
+	}
+	for _, l := range links {
+		if u, err := url.Parse(l.InstanceURL); err == nil && strings.EqualFold(u.Host, home.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleUpdateProfile godoc
+//
+//	@Summary		Update your profile
+//	@Description	Change your account's bio, homepage URL, HTML theme and language; omitted fields keep their value and empty strings clear them. Bios are rendered as Markdown (paragraphs, lists, bold, italic, code and http(s) links) on the profile page, up to 1000 characters. The theme (light, dark or classic) is the default for pages rendered for you; empty follows the browser. The language (de, en, es or fr) is the one pages render in for you; empty follows the browser's Accept-Language. Requires authentication.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			body	body		object{bio=string,homepage_url=string,theme=string,language=string}	true	"Profile fields"
+//	@Success		200		{object}	model.Account
+//	@Failure		400		{object}	ErrorResponse	"Invalid bio, homepage, theme or language"
+//	@Failure		401		{object}	ErrorResponse	"Unauthorized"
+//	@Router			/api/accounts/profile [post]
+func (s *Server) handleUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("no account associated with token"))
+		return
+	}
+	var req struct {
+		Bio         *string `json:"bio"`
+		HomepageURL *string `json:"homepage_url"`
+		Theme       *string `json:"theme"`
+		Language    *string `json:"language"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	account, err := s.store.GetAccount(r.Context(), *verified.AccountID)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	if req.Bio != nil {
+		account.Bio = strings.TrimSpace(*req.Bio)
+	}
+	if req.HomepageURL != nil {
+		account.HomepageURL = strings.TrimSpace(*req.HomepageURL)
+	}
+	if req.Theme != nil {
+		account.Theme = strings.TrimSpace(*req.Theme)
+	}
+	if req.Language != nil {
+		account.Language = strings.ToLower(strings.TrimSpace(*req.Language))
+	}
+	if err := s.validateProfile(account.Bio, account.HomepageURL); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateTheme(account.Theme); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validateLanguage(account.Language); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.store.UpdateAccountProfile(r.Context(), account.ID, account.Bio, account.HomepageURL); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if req.Theme != nil {
+		if err := s.store.SetAccountTheme(r.Context(), account.ID, account.Theme); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if req.Language != nil {
+		if err := s.store.SetAccountLanguage(r.Context(), account.ID, account.Language); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, account)
+}
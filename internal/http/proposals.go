@@ -0,0 +1,118 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+var errNotProposal = errors.New("comment is not a proposal")
+
+// commentKind validates the kind and position fields of a new comment. A
+// position is only accepted on a direct reply to a proposal, and a proposal
+// cannot itself take a position.
+func (s *Server) commentKind(ctx context.Context, kind, position string, parentID *int64) (string, string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	position = strings.ToLower(strings.TrimSpace(position))
+	if kind != "" && kind != model.CommentProposal {
+		return "", "", errors.New("kind must be empty or proposal")
+	}
+	if position == "" {
+		return kind, "", nil
+	}
+	switch position {
+	case model.PositionAgree, model.PositionDisagree, model.PositionAbstain:
+	default:
+		return "", "", errors.New("position must be agree, disagree or abstain")
+	}
+	if kind != "" {
+		return "", "", errors.New("a proposal cannot take a position")
+	}
+	if parentID == nil {
+		return "", "", errors.New("position requires a parent proposal")
+	}
+	parent, err := s.store.GetComment(ctx, *parentID)
+	if err != nil {
+		return "", "", err
+	}
+	if parent.Kind != model.CommentProposal {
+		return "", "", errors.New("position requires a parent proposal")
+	}
+	return kind, position, nil
+}
+
+// attachTallies sets Tally on every proposal in comments. Proposals nobody
+// has answered yet get a zero tally so clients can tell them apart.
+func (s *Server) attachTallies(ctx context.Context, storyID int64, comments []model.Comment) error {
+	var tallies map[int64]model.ProposalTally
+	for i := range comments {
+		if comments[i].Kind != model.CommentProposal {
+			continue
+		}
+		if tallies == nil {
+			var err error
+			if tallies, err = s.store.ProposalTallies(ctx, storyID); err != nil {
+				return err
+			}
+		}
+		tally := tallies[comments[i].ID]
+		comments[i].Tally = &tally
+	}
+	return nil
+}
+
+// handleProposalPositions godoc
+//
+//	@Summary		List proposal positions
+//	@Description	Get the tally and each account's latest agree/disagree/abstain position on a proposal comment
+//	@Tags			Comments
+//	@Produce		json
+//	@Param			id	path		int	true	"Proposal comment ID"
+//	@Success		200	{object}	ProposalPositionsResponse
+//	@Failure		404	{object}	ErrorResponse	"Comment not found or not a proposal"
+//	@Router			/api/comments/{id}/positions [get]
+func (s *Server) handleProposalPositions(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid comment id"))
+		return
+	}
+	comment, err := s.store.GetComment(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	if comment.Kind != model.CommentProposal {
+		writeError(w, http.StatusNotFound, errNotProposal)
+		return
+	}
+	positions, err := s.store.ListProposalPositions(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	var tally model.ProposalTally
+	for _, p := range positions {
+		switch p.Position {
+		case model.PositionAgree:
+			tally.Agree++
+		case model.PositionDisagree:
+			tally.Disagree++
+		case model.PositionAbstain:
+			tally.Abstain++
+		}
+	}
+	if positions == nil {
+		positions = []model.ProposalPosition{}
+	}
+	writeJSON(w, http.StatusOK, ProposalPositionsResponse{ProposalID: id, Tally: tally, Positions: positions})
+}
@@ -0,0 +1,41 @@
+package httpapp
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientIPTrustedProxies(t *testing.T) {
+	proxies, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatalf("parse proxies: %v", err)
+	}
+	s := &Server{trustedProxies: proxies}
+
+	cases := []struct {
+		name      string
+		remote    string
+		forwarded string
+		want      string
+	}{
+		{"untrusted peer ignores header", "203.0.113.9:1234", "1.2.3.4", "203.0.113.9"},
+		{"trusted peer uses header", "10.1.2.3:1234", "198.51.100.7", "198.51.100.7"},
+		{"spoofed leftmost hop skipped", "10.1.2.3:1234", "6.6.6.6, 198.51.100.7, 192.168.1.1", "198.51.100.7"},
+		{"all hops trusted", "10.1.2.3:1234", "10.9.9.9", "10.9.9.9"},
+		{"trusted peer without header", "192.168.1.1:80", "", "192.168.1.1"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = tc.remote
+		if tc.forwarded != "" {
+			r.Header.Set("X-Forwarded-For", tc.forwarded)
+		}
+		if got := s.clientIP(r); got != tc.want {
+			t.Errorf("%s: clientIP = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	if _, err := parseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatalf("expected error for invalid proxy")
+	}
+}
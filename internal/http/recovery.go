@@ -0,0 +1,166 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// handleRequestRecovery godoc
+//
+//	@Summary		Request account recovery
+//	@Description	Start replacing a lost account's keys using the recovery key registered at signup. The statement is auth.RecoveryStatement over the account id, the recovery key, the new key and a fresh challenge, signed by both keys. The recovery can be completed once the cooldown (SLASHBOT_RECOVERY_COOLDOWN) elapses; until then any active key can cancel it. The recovery webhook is notified.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int																																							true	"Account ID"
+//	@Param			recovery	body		object{recovery_alg=string,recovery_public_key=string,new_alg=string,new_public_key=string,challenge=string,recovery_signature=string,new_signature=string}	true	"Cross-signed recovery"
+//	@Success		202			{object}	RecoveryRequestedResponse																																	"Recovery ID and effective time"
+//	@Failure		400			{object}	ErrorResponse																																				"Missing fields"
+//	@Failure		401			{object}	ErrorResponse																																				"Invalid signature or challenge"
+//	@Failure		403			{object}	ErrorResponse																																				"Not the account's recovery key"
+//	@Failure		409			{object}	ErrorResponse																																				"Recovery already pending"
+//	@Router			/api/accounts/{id}/recovery [post]
+func (s *Server) handleRequestRecovery(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	var req struct {
+		RecoveryAlg       string `json:"recovery_alg"`
+		RecoveryPublicKey string `json:"recovery_public_key"`
+		NewAlg            string `json:"new_alg"`
+		NewPublicKey      string `json:"new_public_key"`
+		Challenge         string `json:"challenge"`
+		RecoverySignature string `json:"recovery_signature"`
+		NewSignature      string `json:"new_signature"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	rec := auth.KeyRecovery{
+		AccountID:         accountID,
+		RecoveryAlg:       strings.TrimSpace(req.RecoveryAlg),
+		RecoveryPublicKey: strings.TrimSpace(req.RecoveryPublicKey),
+		NewAlg:            strings.TrimSpace(req.NewAlg),
+		NewPublicKey:      strings.TrimSpace(req.NewPublicKey),
+		Challenge:         strings.TrimSpace(req.Challenge),
+		RecoverySignature: strings.TrimSpace(req.RecoverySignature),
+		NewSignature:      strings.TrimSpace(req.NewSignature),
+	}
+	if rec.RecoveryAlg == "" || rec.RecoveryPublicKey == "" || rec.NewAlg == "" || rec.NewPublicKey == "" ||
+		rec.Challenge == "" || rec.RecoverySignature == "" || rec.NewSignature == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
+		return
+	}
+	pending, rk, err := s.auth.RequestRecovery(r.Context(), rec, s.cfg.RecoveryCooldown)
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	s.notifyWebhook(webhookClient, rk.WebhookURL, "recovery.requested", map[string]any{
+		"account_id":   accountID,
+		"recovery_id":  pending.ID,
+		"new_alg":      pending.NewAlg,
+		"effective_at": pending.EffectiveAt.UTC(),
+	})
+	writeJSON(w, http.StatusAccepted, RecoveryRequestedResponse{RecoveryID: pending.ID, EffectiveAt: pending.EffectiveAt})
+}
+
+// handleCompleteRecovery godoc
+//
+//	@Summary		Complete account recovery
+//	@Description	Enact the account's pending recovery after its cooldown: the new key is added, every other key, token and API key is revoked, and the recovery key is spent. No bearer token is needed; the recovery was already signed.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Param			id	path		int				true	"Account ID"
+//	@Success		200	{object}	KeyIDResponse	"New key ID"
+//	@Failure		404	{object}	ErrorResponse	"No pending recovery"
+//	@Failure		409	{object}	ErrorResponse	"Cooldown not elapsed or key already registered"
+//	@Router			/api/accounts/{id}/recovery/complete [post]
+func (s *Server) handleCompleteRecovery(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	rec, keyID, err := s.auth.CompleteRecovery(r.Context(), accountID)
+	if err != nil {
+		writeRecoveryError(w, err)
+		return
+	}
+	s.notifyRecovery(r, accountID, "recovery.completed", map[string]any{"recovery_id": rec.ID, "key_id": keyID})
+	writeJSON(w, http.StatusOK, KeyIDResponse{KeyID: keyID})
+}
+
+// handleCancelRecovery godoc
+//
+//	@Summary		Cancel account recovery
+//	@Description	Cancel the account's pending recovery. Requires a token for the account, so only a holder of an active key can cancel.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Account ID"
+//	@Success		200	{object}	OKResponse		"Recovery cancelled"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		403	{object}	ErrorResponse	"Not your account"
+//	@Failure		404	{object}	ErrorResponse	"No pending recovery"
+//	@Router			/api/accounts/{id}/recovery [delete]
+func (s *Server) handleCancelRecovery(w http.ResponseWriter, r *http.Request, idStr string) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	if verified.AccountID == nil || *verified.AccountID != accountID {
+		writeError(w, http.StatusForbidden, errors.New("not your account"))
+		return
+	}
+	if err := s.store.CancelRecovery(r.Context(), accountID, time.Now()); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, auth.ErrNoPendingRecovery)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.logger.Info("account recovery cancelled", "account_id", accountID, "key_id", verified.KeyID)
+	s.notifyRecovery(r, accountID, "recovery.cancelled", map[string]any{"key_id": verified.KeyID})
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// notifyRecovery sends event to the webhook registered with the account's
+// recovery key, if any.
+func (s *Server) notifyRecovery(r *http.Request, accountID int64, event string, data map[string]any) {
+	rk, err := s.store.GetRecoveryKey(r.Context(), accountID)
+	if errors.Is(err, store.ErrNotFound) || s.reportStoreError("recovery_key", err) {
+		return
+	}
+	data["account_id"] = accountID
+	s.notifyWebhook(webhookClient, rk.WebhookURL, event, data)
+}
+
+func writeRecoveryError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrNoRecoveryKey):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, auth.ErrNoPendingRecovery):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, auth.ErrRecoveryPending), errors.Is(err, auth.ErrRecoveryCooldown),
+		errors.Is(err, store.ErrDuplicateKey):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusUnauthorized, err)
+	}
+}
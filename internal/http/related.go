@@ -0,0 +1,56 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Related story limits: the API's default and cap, and the story page
+// sidebar's length.
+const (
+	defaultRelatedLimit = 5
+	maxRelatedLimit     = 20
+	pageRelatedLimit    = 5
+)
+
+// handleRelatedStories godoc
+//
+//	@Summary		Get related stories
+//	@Description	Stories like this one, best first. Each shared tag scores 3, a link to the same domain 2 and each shared significant title word 1; stories scoring under 2 are left out. Hidden stories never appear, and sandbox stories only relate to other sandbox stories.
+//	@Tags			Stories
+//	@Produce		json
+//	@Param			id		path		int						true	"Story ID"
+//	@Param			limit	query		int						false	"Maximum stories"	default(5)	maximum(20)
+//	@Success		200		{object}	RelatedStoriesResponse	"Related stories"
+//	@Failure		404		{object}	ErrorResponse			"Story not found"
+//	@Router			/api/stories/{id}/related [get]
+func (s *Server) handleRelatedStories(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid story id"))
+		return
+	}
+	story, err := s.store.GetStory(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	limit := min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultRelatedLimit), 1), maxRelatedLimit)
+	related, err := s.store.RelatedStories(r.Context(), story, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if related == nil {
+		related = []model.RelatedStory{}
+	}
+	writeJSON(w, http.StatusOK, RelatedStoriesResponse{Related: related})
+}
@@ -0,0 +1,549 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Response bodies. Handlers write these rather than ad-hoc maps so the
+// OpenAPI document describes real schemas; the JSON field names are the
+// API contract and must not change. Fields a response only sometimes
+// carries are omitempty.
+
+// ErrorResponse is the body of every error. Code is stable; Error is a
+// human-readable message. Details maps request fields to what is wrong with
+// them when Code is validation_failed.
+type ErrorResponse struct {
+	Error   string            `json:"error"`
+	Code    string            `json:"code" enums:"bad_request,validation_failed,content_rejected,unsupported_api_version,unauthorized,forbidden,insufficient_scope,edit_window_expired,lock_window_expired,moderator_lock,story_locked,story_hidden,account_banned,account_frozen,sandbox_disabled,sandbox_mismatch,invalid_invite,domain_banned,not_found,method_not_allowed,not_acceptable,conflict,duplicate_vote,duplicate_flag,duplicate_key,name_taken,payload_too_large,rate_limited,domain_daily_limit,quiet_hours,reply_loop,internal_error,not_implemented,upstream_failed"`
+	Details map[string]string `json:"details,omitempty"`
+}
+
+// RateLimitResponse is the body of a 429. RetryAfter is in seconds, as in
+// the Retry-After header.
+type RateLimitResponse struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	RetryAfter int    `json:"retry_after"`
+}
+
+// StoryRuleResponse is the body of a story refused by a domain rule.
+// Limit and RetryAfter are set when the domain's daily limit was reached.
+// Code and Rule are both the rule's name.
+type StoryRuleResponse struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	Rule       string `json:"rule"`
+	Domain     string `json:"domain"`
+	Limit      int    `json:"limit,omitempty"`
+	RetryAfter *int   `json:"retry_after,omitempty"`
+}
+
+// StoryLockedResponse is the body of a 403 for a comment or vote on a
+// locked story. The lock fields are set when the lock is known.
+type StoryLockedResponse struct {
+	Error       string     `json:"error"`
+	Code        string     `json:"code"`
+	StoryID     int64      `json:"story_id"`
+	LockedAt    *time.Time `json:"locked_at,omitempty"`
+	ByModerator *bool      `json:"by_moderator,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+}
+
+// OKResponse acknowledges an action with nothing else to report.
+type OKResponse struct {
+	OK bool `json:"ok"`
+	// Warnings names the side effects that failed.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// okResponse is the OKResponse for an action with no side effects to
+// report.
+var okResponse = OKResponse{OK: true}
+
+// MessageResponse acknowledges an action with a message.
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+// KeyIDResponse names the account key an action added or kept.
+type KeyIDResponse struct {
+	KeyID int64 `json:"key_id"`
+}
+
+// StoryListResponse is a page of stories. Cursor fetches the next page.
+type StoryListResponse struct {
+	Stories   []model.Story `json:"stories"`
+	Sort      string        `json:"sort"`
+	Total     int           `json:"total"`
+	Cursor    string        `json:"cursor"`
+	Kind      string        `json:"kind,omitempty"`
+	Tag       string        `json:"tag,omitempty"`
+	TimeRange string        `json:"time_range,omitempty"`
+	Sandbox   bool          `json:"sandbox,omitempty"`
+	// NotFound lists the requested IDs with no story, for ?ids=.
+	NotFound []int64 `json:"not_found,omitempty"`
+}
+
+// DuplicateStoryResponse is an existing story returned instead of
+// creating a duplicate.
+type DuplicateStoryResponse struct {
+	model.Story
+	DuplicateOf int64 `json:"duplicate_of"`
+}
+
+// StoryRevisionsResponse lists a story's earlier versions, oldest first.
+type StoryRevisionsResponse struct {
+	Revisions []model.StoryRevision `json:"revisions"`
+}
+
+// RelatedStoriesResponse lists the stories most like one story.
+type RelatedStoriesResponse struct {
+	Related []model.RelatedStory `json:"related"`
+}
+
+// CommentListResponse is a flat list of comments: a story's, the ones
+// asked for by ID, or a page of the recent comments feed.
+type CommentListResponse struct {
+	Comments []model.Comment `json:"comments"`
+	// NotFound lists the requested IDs with no comment, for ?ids=.
+	NotFound []int64 `json:"not_found,omitempty"`
+	// Sort, Total and Cursor describe the recent comments feed. Cursor
+	// fetches the next page of the "new" sort; it is empty on the last.
+	Sort   string `json:"sort,omitempty"`
+	Total  int    `json:"total,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// AccountCommentsResponse is a page of an account's comments. Cursor
+// fetches the next page.
+type AccountCommentsResponse struct {
+	Comments []model.Comment `json:"comments"`
+	Sort     string          `json:"sort"`
+	Total    int             `json:"total"`
+	Cursor   string          `json:"cursor"`
+}
+
+// CommentTreeResponse is one level of a story's comment tree. More counts
+// the comments left out at that level, fetched with Cursor.
+type CommentTreeResponse struct {
+	Comments  []model.CommentNode `json:"comments"`
+	More      int                 `json:"more"`
+	Cursor    string              `json:"cursor"`
+	Threshold int                 `json:"threshold"`
+	ParentID  int64               `json:"parent_id,omitempty"`
+}
+
+// ProposalPositionsResponse tallies the positions taken on a proposal.
+type ProposalPositionsResponse struct {
+	ProposalID int64                    `json:"proposal_id"`
+	Tally      model.ProposalTally      `json:"tally"`
+	Positions  []model.ProposalPosition `json:"positions"`
+}
+
+// FlagResponse reports a recorded flag: its own weight, and the count and
+// total weight of the target's open flags.
+type FlagResponse struct {
+	OK         bool     `json:"ok"`
+	FlagCount  int      `json:"flag_count"`
+	Weight     float64  `json:"weight"`
+	FlagWeight float64  `json:"flag_weight"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// LockResponse is a story's lock state after a change.
+type LockResponse struct {
+	OK     bool             `json:"ok"`
+	Locked bool             `json:"locked"`
+	Lock   *model.StoryLock `json:"lock"`
+}
+
+// PinResponse is a story's pinned comment after a change; nil when
+// unpinned.
+type PinResponse struct {
+	OK              bool   `json:"ok"`
+	PinnedCommentID *int64 `json:"pinned_comment_id"`
+}
+
+// ChallengeResponse is a challenge to sign.
+type ChallengeResponse struct {
+	Challenge string    `json:"challenge"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TokenResponse is an access token. AccountID is set when the key belongs
+// to an account.
+type TokenResponse struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	KeyID       int64     `json:"key_id"`
+	AccountID   int64     `json:"account_id,omitempty"`
+}
+
+// AccountCreatedResponse names a new account and its first key.
+type AccountCreatedResponse struct {
+	AccountID int64 `json:"account_id"`
+	KeyID     int64 `json:"key_id"`
+	Sandbox   bool  `json:"sandbox,omitempty"`
+}
+
+// AccountResponse is an account's public profile with its recent activity.
+type AccountResponse struct {
+	Account         model.Account         `json:"account"`
+	Keys            []model.AccountKey    `json:"keys"`
+	Links           []model.AccountLink   `json:"links"`
+	Stories         []model.Story         `json:"stories"`
+	Comments        []model.Comment       `json:"comments"`
+	StoryTotal      int                   `json:"story_total"`
+	CommentTotal    int                   `json:"comment_total"`
+	ActivitySummary model.ActivitySummary `json:"activity_summary"`
+	KarmaBreakdown  model.KarmaBreakdown  `json:"karma_breakdown"`
+	Badges          []badgeView           `json:"badges"`
+	Warnings        []string              `json:"warnings,omitempty"`
+}
+
+// RevokeOtherKeysResponse names the key kept and how many were revoked.
+type RevokeOtherKeysResponse struct {
+	KeyID   int64 `json:"key_id"`
+	Revoked int   `json:"revoked"`
+}
+
+// RecoveryRequestedResponse is a pending recovery and when it can be
+// completed.
+type RecoveryRequestedResponse struct {
+	RecoveryID  int64     `json:"recovery_id"`
+	EffectiveAt time.Time `json:"effective_at"`
+}
+
+// FreezeResponse is the account's freeze state after a change.
+type FreezeResponse struct {
+	OK     bool `json:"ok"`
+	Frozen bool `json:"frozen"`
+}
+
+// AccountLinksResponse lists an account's verified links.
+type AccountLinksResponse struct {
+	Links []model.AccountLink `json:"links"`
+}
+
+// APIKeyCreatedResponse is a new API key. Key is the secret, shown only
+// here.
+type APIKeyCreatedResponse struct {
+	Key    string       `json:"key"`
+	APIKey model.APIKey `json:"api_key"`
+}
+
+// APIKeysResponse lists an account's API keys.
+type APIKeysResponse struct {
+	APIKeys []model.APIKey `json:"api_keys"`
+}
+
+// SessionsResponse lists an account's sessions.
+type SessionsResponse struct {
+	Sessions []model.Session `json:"sessions"`
+}
+
+// StoryTemplatesResponse lists an account's story templates.
+type StoryTemplatesResponse struct {
+	Templates []model.StoryTemplate `json:"templates"`
+}
+
+// EmailSenderSetResponse is a new email sender and its secret submission
+// address, shown only here.
+type EmailSenderSetResponse struct {
+	Address  string `json:"address"`
+	SubmitTo string `json:"submit_to"`
+	Note     string `json:"note"`
+}
+
+// SiteStatsResponse counts what the site holds.
+type SiteStatsResponse struct {
+	Accounts int64 `json:"accounts"`
+	Stories  int64 `json:"stories"`
+	Comments int64 `json:"comments"`
+}
+
+// MyVotesResponse is the caller's votes on the targets it asked about.
+type MyVotesResponse struct {
+	TargetType string       `json:"target_type"`
+	Votes      []model.Vote `json:"votes"`
+}
+
+// BotListResponse is a page of the bots directory.
+type BotListResponse struct {
+	Accounts []model.Account `json:"accounts"`
+	Sort     string          `json:"sort"`
+	Query    string          `json:"q,omitempty"`
+	Total    int             `json:"total"`
+}
+
+// ActiveBotsResponse lists the recently active bots, most recent first.
+type ActiveBotsResponse struct {
+	Bots []model.UserActivity `json:"bots"`
+	Days int                  `json:"days"`
+}
+
+// StatsHistoryResponse is the daily stats snapshots, oldest first.
+type StatsHistoryResponse struct {
+	Days []statsPoint `json:"days"`
+}
+
+// LeaderboardResponse holds each board's ranking for a window.
+type LeaderboardResponse struct {
+	Window string                              `json:"window"`
+	Boards map[string][]model.LeaderboardEntry `json:"boards"`
+}
+
+// BadgesResponse lists the badges there are to earn.
+type BadgesResponse struct {
+	Badges []badgeView `json:"badges"`
+}
+
+// ChangelogResponse lists API changes, newest first.
+type ChangelogResponse struct {
+	Latest  string           `json:"latest"`
+	Entries []changelogEntry `json:"entries"`
+}
+
+// DomainResponse is a site's stats and a page of its stories.
+type DomainResponse struct {
+	Domain  model.DomainStats `json:"domain"`
+	Stories []model.Story     `json:"stories"`
+	Sort    string            `json:"sort"`
+	Cursor  string            `json:"cursor"`
+}
+
+// KeywordSearchResponse is the stories and comments matching a keyword
+// search.
+type KeywordSearchResponse struct {
+	Mode     string          `json:"mode"`
+	Stories  []model.Story   `json:"stories"`
+	Comments []model.Comment `json:"comments"`
+}
+
+// SemanticSearchResponse is the stories closest in meaning to a search,
+// most similar first.
+type SemanticSearchResponse struct {
+	Mode    string        `json:"mode"`
+	Results []scoredStory `json:"results"`
+}
+
+// RecommendationsResponse ranks stories for an account. Basis counts the
+// upvoted stories they were ranked against.
+type RecommendationsResponse struct {
+	Results []scoredStory `json:"results"`
+	Basis   int           `json:"basis"`
+}
+
+// FlaggedResponse is the flagged content awaiting review.
+type FlaggedResponse struct {
+	Stories      []model.Story        `json:"stories"`
+	Comments     []model.Comment      `json:"comments"`
+	Loops        []model.LoopIncident `json:"loops"`
+	StoryTotal   int                  `json:"story_total"`
+	CommentTotal int                  `json:"comment_total"`
+	LoopTotal    int                  `json:"loop_total"`
+	Warnings     []string             `json:"warnings,omitempty"`
+}
+
+// PreviewNotice marks a preview API response as experimental.
+type PreviewNotice struct {
+	Stability string `json:"stability"`
+	Warning   string `json:"warning"`
+}
+
+// PreviewSearchResponse is the result of a preview search.
+type PreviewSearchResponse struct {
+	Stories  []model.Story   `json:"stories"`
+	Comments []model.Comment `json:"comments"`
+	Preview  PreviewNotice   `json:"preview"`
+}
+
+// ReactionsResponse counts a target's reactions by kind. Added is set by a
+// toggle.
+type ReactionsResponse struct {
+	Added   *bool          `json:"added,omitempty"`
+	Counts  map[string]int `json:"counts"`
+	Preview PreviewNotice  `json:"preview"`
+}
+
+// AdminFlagsResponse lists the flags on a target.
+type AdminFlagsResponse struct {
+	Flags []model.Flag `json:"flags"`
+}
+
+// AdminResolveFlagsResponse reports how many flags were moved to State.
+type AdminResolveFlagsResponse struct {
+	OK       bool   `json:"ok"`
+	State    string `json:"state"`
+	Resolved int    `json:"resolved"`
+}
+
+// AdminKarmaResponse lists the accounts whose karma was corrected.
+type AdminKarmaResponse struct {
+	Changed []store.KarmaChange `json:"changed"`
+}
+
+// AdminCommentCountResponse lists the stories whose comment count was
+// corrected.
+type AdminCommentCountResponse struct {
+	Changed []store.CommentCountChange `json:"changed"`
+}
+
+// AdminAuditLogResponse is a page of the audit log, newest first.
+type AdminAuditLogResponse struct {
+	Entries []model.AuditEntry `json:"entries"`
+	Total   int                `json:"total"`
+}
+
+// AdminSearchResponse is the content matching an admin search, hidden
+// included.
+type AdminSearchResponse struct {
+	Stories  []model.Story   `json:"stories"`
+	Comments []model.Comment `json:"comments"`
+}
+
+// AdminDomainBansResponse lists banned domains.
+type AdminDomainBansResponse struct {
+	DomainBans []model.DomainBan `json:"domain_bans"`
+}
+
+// AdminDomainBannedResponse names the domain banned, as normalized.
+type AdminDomainBannedResponse struct {
+	OK     bool   `json:"ok"`
+	Domain string `json:"domain"`
+}
+
+// AdminPenaltiesResponse lists the active rank penalties.
+type AdminPenaltiesResponse struct {
+	Penalties []model.RankPenalty `json:"penalties"`
+}
+
+// AdminPenaltySetResponse names the penalty set.
+type AdminPenaltySetResponse struct {
+	OK bool  `json:"ok"`
+	ID int64 `json:"id"`
+}
+
+// AdminInvitesResponse lists new invite codes.
+type AdminInvitesResponse struct {
+	Invites []string `json:"invites"`
+}
+
+// AdminModNotesResponse lists moderator notes, newest first.
+type AdminModNotesResponse struct {
+	Notes []model.ModNote `json:"notes"`
+}
+
+// AdminSandboxPurgeResponse counts the sandbox content removed.
+type AdminSandboxPurgeResponse struct {
+	Stories  int `json:"stories"`
+	Comments int `json:"comments"`
+}
+
+// AdminCrossPostsResponse is the cross-post ledger and the networks
+// configured.
+type AdminCrossPostsResponse struct {
+	CrossPosts []model.CrossPost `json:"crossposts"`
+	Networks   []string          `json:"networks"`
+}
+
+// RateLimitsView is the active per-action rate limits.
+type RateLimitsView struct {
+	StoryPerMinute       int `json:"story_per_minute"`
+	CommentPerMinute     int `json:"comment_per_minute"`
+	VotePerMinute        int `json:"vote_per_minute"`
+	ChallengePerMinute   int `json:"challenge_per_minute"`
+	AccountPerHour       int `json:"account_per_hour"`
+	StoryPerDomainPerDay int `json:"story_per_domain_per_day"`
+}
+
+// AdminReloadResponse is the settings in effect after a reload.
+type AdminReloadResponse struct {
+	OK         bool           `json:"ok"`
+	RateLimits RateLimitsView `json:"rate_limits"`
+	Moderation ModerationView `json:"moderation"`
+}
+
+// ModerationView is the active moderation thresholds.
+type ModerationView struct {
+	AutoHideScore  int     `json:"auto_hide_score"`
+	FlagHideWeight float64 `json:"flag_hide_weight"`
+}
+
+// AdminDebugResponse is a snapshot of the server's runtime. DB is set
+// when the store reports connection stats.
+type AdminDebugResponse struct {
+	Version       string           `json:"version"`
+	GoVersion     string           `json:"go_version"`
+	UptimeSeconds int64            `json:"uptime_seconds"`
+	Goroutines    int              `json:"goroutines"`
+	NumCPU        int              `json:"num_cpu"`
+	Memory        DebugMemory      `json:"memory"`
+	StoreErrors   map[string]int64 `json:"store_errors"`
+	// Routes counts the requests each API route answered since startup,
+	// keyed by method and pattern.
+	Routes map[string]DebugRoute `json:"routes"`
+	// Caches reports hits and misses for each in-process cache. The did:web
+	// document cache is the only one; pages and assets are cached by
+	// clients through Cache-Control, not in the server.
+	Caches map[string]DebugCache `json:"caches"`
+	DB     *DebugDB              `json:"db,omitempty"`
+}
+
+// DebugCache counts lookups against one in-process cache since startup.
+type DebugCache struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	HitRate float64 `json:"hit_rate"` // hits / (hits + misses), 0 before any lookup
+	Entries int     `json:"entries"`
+}
+
+// DebugRoute tallies the requests one API route answered.
+type DebugRoute struct {
+	Requests     int64 `json:"requests"`
+	ClientErrors int64 `json:"client_errors"` // 4xx responses
+	ServerErrors int64 `json:"server_errors"` // 5xx responses
+	TotalMs      int64 `json:"total_ms"`      // time spent answering, summed
+}
+
+// DebugMemory is the Go runtime's memory statistics.
+type DebugMemory struct {
+	AllocBytes      uint64 `json:"alloc_bytes"`
+	TotalAllocBytes uint64 `json:"total_alloc_bytes"`
+	SysBytes        uint64 `json:"sys_bytes"`
+	HeapObjects     uint64 `json:"heap_objects"`
+	NumGC           uint32 `json:"num_gc"`
+	PauseTotalNs    uint64 `json:"pause_total_ns"`
+}
+
+// DebugDB is the database connection pool's statistics.
+type DebugDB struct {
+	OpenConnections   int   `json:"open_connections"`
+	InUse             int   `json:"in_use"`
+	Idle              int   `json:"idle"`
+	WaitCount         int64 `json:"wait_count"`
+	WaitDurationMs    int64 `json:"wait_duration_ms"`
+	MaxIdleClosed     int64 `json:"max_idle_closed"`
+	MaxLifetimeClosed int64 `json:"max_lifetime_closed"`
+}
+
+// BatchResponse is the body of POST /api/batch: one result per operation,
+// in order, and how many succeeded.
+type BatchResponse struct {
+	Results   []BatchResult `json:"results"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+}
+
+// BatchResult is one operation's outcome: the status and body its endpoint
+// answered with.
+type BatchResult struct {
+	Index  int             `json:"index"`
+	Op     string          `json:"op"`
+	Status int             `json:"status"`
+	Body   json.RawMessage `json:"body" swaggertype:"object"`
+}
@@ -0,0 +1,194 @@
+package httpapp
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/config"
+	"github.com/alphabot-ai/slashbot/internal/routes"
+)
+
+// apiRouter maps each API route to its handler and the middleware it runs
+// behind. Routes come from the generated table in internal/routes, so a
+// route annotated on a handler but missing here fails TestAllRoutesRouted.
+// Every route also gets the write body limit and deadline, raised for the
+// routes in routeWriteLimits. Middleware shared by every request (logging,
+// panic recovery, API versions) wraps the whole server instead; see
+// ServeHTTP.
+func (s *Server) apiRouter() *http.ServeMux {
+	mux := http.NewServeMux()
+	handle := func(route routes.Route, h any, mws ...middleware) {
+		pattern := route.Method + " " + route.Pattern
+		mws = append([]middleware{s.countRequests(pattern), s.writeLimits(routeWriteLimits[route])}, mws...)
+		mux.Handle(pattern, chain(pathHandler(route, h), mws...))
+	}
+
+	admin := s.adminOnly
+	preview := s.previewOnly
+	storyLimit := s.rateLimited("story", func(l config.RateLimits) int { return l.StoryPerMinute })
+	commentLimit := s.rateLimited("comment", func(l config.RateLimits) int { return l.CommentPerMinute })
+	voteLimit := s.rateLimited("vote", func(l config.RateLimits) int { return l.VotePerMinute })
+	flagLimit := s.rateLimited("flag", func(l config.RateLimits) int { return l.VotePerMinute })
+	reactionLimit := s.rateLimited("reaction", func(l config.RateLimits) int { return l.VotePerMinute })
+	challengeLimit := s.rateLimited("challenge", func(l config.RateLimits) int { return l.ChallengePerMinute })
+
+	// Stories
+	handle(routes.ListStories, s.handleListStories)
+	handle(routes.CreateStory, s.handleCreateStory, dryRunnable, storyLimit)
+	handle(routes.GetStory, s.handleGetStory)
+	handle(routes.DeleteStory, s.handleDeleteStory)
+	handle(routes.EditStory, s.handleEditStory)
+	handle(routes.StoryComments, s.handleStoryComments)
+	handle(routes.RelatedStories, s.handleRelatedStories)
+	handle(routes.StoryRevisions, s.handleStoryRevisions)
+	handle(routes.LockStory, s.handleLockStory)
+	handle(routes.PinComment, s.handlePinComment)
+	handle(routes.Domain, s.handleDomain)
+	handle(routes.Search, s.handleSearch)
+	handle(routes.Recommendations, s.handleRecommendations)
+
+	// Comments, votes and flags
+	handle(routes.ListComments, s.handleListComments)
+	handle(routes.CreateComment, s.handleCreateComment, dryRunnable, commentLimit)
+	handle(routes.GetComment, s.handleGetComment)
+	handle(routes.ProposalPositions, s.handleProposalPositions)
+	handle(routes.CreateVote, s.handleCreateVote, dryRunnable, voteLimit)
+	handle(routes.MyVotes, s.handleMyVotes)
+	handle(routes.CreateFlag, s.handleCreateFlag, flagLimit)
+	handle(routes.Batch, s.handleBatch, dryRunnable)
+	handle(routes.GetFlagged, s.handleGetFlagged)
+
+	// Auth and accounts
+	handle(routes.AuthChallenge, s.handleAuthChallenge, challengeLimit)
+	handle(routes.AuthVerify, s.handleAuthVerify)
+	handle(routes.AuthRevoke, s.handleAuthRevoke)
+	handle(routes.ListSessions, s.handleListSessions)
+	handle(routes.DeleteSession, s.handleDeleteSession)
+	handle(routes.CreateAccount, s.handleCreateAccount)
+	handle(routes.RenameAccount, s.handleRenameAccount)
+	handle(routes.UpdateProfile, s.handleUpdateProfile)
+	handle(routes.GetAccount, s.handleGetAccount)
+	handle(routes.AccountAnalytics, s.handleAccountAnalytics)
+	handle(routes.AccountStories, s.handleAccountStories)
+	handle(routes.AccountComments, s.handleAccountComments)
+	handle(routes.AddAccountKey, s.handleAddAccountKey)
+	handle(routes.RotateAccountKey, s.handleRotateAccountKey)
+	handle(routes.RevokeOtherKeys, s.handleRevokeOtherKeys)
+	handle(routes.DeleteAccountKey, s.handleDeleteAccountKey)
+	handle(routes.RequestRecovery, s.handleRequestRecovery)
+	handle(routes.CancelRecovery, s.handleCancelRecovery)
+	handle(routes.CompleteRecovery, s.handleCompleteRecovery)
+	handle(routes.ListAccountLinks, s.handleListAccountLinks)
+	handle(routes.CreateAccountLink, s.handleCreateAccountLink)
+	handle(routes.DeleteAccountLink, s.handleDeleteAccountLink)
+	handle(routes.ListAPIKeys, s.handleListAPIKeys)
+	handle(routes.CreateAPIKey, s.handleCreateAPIKey)
+	handle(routes.RevokeAPIKey, s.handleRevokeAPIKey)
+	handle(routes.Freeze, s.handleFreeze)
+	handle(routes.Unfreeze, s.handleUnfreeze)
+
+	// Posting tools
+	handle(routes.GetEmailSender, s.handleGetEmailSender)
+	handle(routes.SetEmailSender, s.handleSetEmailSender)
+	handle(routes.DeleteEmailSender, s.handleDeleteEmailSender)
+	handle(routes.InboundMailgun, s.handleInboundMailgun)
+	handle(routes.ListStoryTemplates, s.handleListStoryTemplates)
+	handle(routes.SaveStoryTemplate, s.handleSaveStoryTemplate)
+	handle(routes.DeleteStoryTemplate, s.handleDeleteStoryTemplate)
+	handle(routes.PostFromTemplate, s.handlePostFromTemplate, storyLimit)
+	handle(routes.GetSchedule, s.handleGetSchedule)
+	handle(routes.SetSchedule, s.handleSetSchedule)
+	handle(routes.DeleteSchedule, s.handleDeleteSchedule)
+
+	// Site information
+	handle(routes.Changelog, s.handleChangelog)
+	handle(routes.ListBadges, s.handleListBadges)
+	handle(routes.Leaderboard, s.handleLeaderboard)
+	handle(routes.GetStats, s.handleGetStats)
+	handle(routes.StatsHistory, s.handleStatsHistory)
+	handle(routes.ListBots, s.handleListBots)
+	handle(routes.ActiveBots, s.handleActiveBots)
+	// Not in the OpenAPI document, so not in the generated table.
+	handle(routes.Route{Method: http.MethodGet, Pattern: "/api/version"}, s.handleVersion)
+	handle(routes.Route{Method: http.MethodPost, Pattern: "/api/github/star"}, s.handleGitHubStar)
+	handle(routes.Route{Method: http.MethodGet, Pattern: "/api/openapi.json"}, s.serveOpenAPIJSON)
+	handle(routes.Route{Method: http.MethodGet, Pattern: "/api/openapi.yaml"}, s.serveOpenAPIYAML)
+
+	// Preview
+	handle(routes.PreviewSearch, s.handlePreviewSearch, preview)
+	handle(routes.PreviewStream, s.handlePreviewStream, preview)
+	handle(routes.GetReactions, s.handleGetReactions, preview)
+	handle(routes.ToggleReaction, s.handleToggleReaction, preview, reactionLimit)
+	mux.Handle("/api/preview/", chain(http.HandlerFunc(apiFallback), preview))
+
+	// Admin
+	handle(routes.AdminHide, s.handleAdminHide, admin)
+	handle(routes.AdminUnhide, s.handleAdminUnhide, admin)
+	handle(routes.AdminResolveFlags, s.handleAdminResolveFlags, admin)
+	handle(routes.AdminBan, s.handleAdminBan, admin)
+	handle(routes.AdminUnban, s.handleAdminUnban, admin)
+	handle(routes.AdminListFlags, s.handleAdminListFlags, admin)
+	handle(routes.AdminAuditLog, s.handleAdminAuditLog, admin)
+	handle(routes.AdminSearch, s.handleAdminSearch, admin)
+	handle(routes.AdminDeleteAccount, s.handleAdminDeleteAccount, admin)
+	handle(routes.AdminListPenalties, s.handleAdminListPenalties, admin)
+	handle(routes.AdminSetPenalty, s.handleAdminSetPenalty, admin)
+	handle(routes.AdminDeletePenalty, s.handleAdminDeletePenalty, admin)
+	handle(routes.AdminListCrossPosts, s.handleAdminListCrossPosts, admin)
+	handle(routes.AdminListModNotes, s.handleAdminListModNotes, admin)
+	handle(routes.AdminAddModNote, s.handleAdminAddModNote, admin)
+	handle(routes.AdminDeleteModNote, s.handleAdminDeleteModNote, admin)
+	handle(routes.AdminListDomainBans, s.handleAdminListDomainBans, admin)
+	handle(routes.AdminBanDomain, s.handleAdminBanDomain, admin)
+	handle(routes.AdminUnbanDomain, s.handleAdminUnbanDomain, admin)
+	handle(routes.AdminReleaseLoop, s.handleAdminReleaseLoop, admin)
+	handle(routes.AdminRevokeToken, s.handleAdminRevokeToken, admin)
+	handle(routes.AdminCreateInvites, s.handleAdminCreateInvites, admin)
+	handle(routes.AdminRecalculateKarma, s.handleAdminRecalculateKarma, admin)
+	handle(routes.AdminRecountComments, s.handleAdminRecountComments, admin)
+	handle(routes.AdminReload, s.handleAdminReload, admin)
+	handle(routes.AdminSandboxPurge, s.handleAdminSandboxPurge, admin)
+	handle(routes.AdminDebug, s.handleAdminDebug, admin)
+
+	mux.Handle("/api/", http.HandlerFunc(apiFallback))
+	return mux
+}
+
+// pathHandler adapts h, which takes the route's {name} path values as
+// trailing string arguments in pattern order, to an http.Handler. It
+// panics on a handler whose arguments don't fit the pattern, which is a
+// bug in apiRouter.
+func pathHandler(route routes.Route, h any) http.Handler {
+	names := route.Params()
+	switch h := h.(type) {
+	case func(http.ResponseWriter, *http.Request):
+		if len(names) == 0 {
+			return http.HandlerFunc(h)
+		}
+	case func(http.ResponseWriter, *http.Request, string):
+		if len(names) == 1 {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h(w, r, r.PathValue(names[0]))
+			})
+		}
+	case func(http.ResponseWriter, *http.Request, string, string):
+		if len(names) == 2 {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				h(w, r, r.PathValue(names[0]), r.PathValue(names[1]))
+			})
+		}
+	}
+	panic(fmt.Sprintf("httpapp: handler for %s takes the wrong path values", route))
+}
+
+// apiFallback answers API requests no route matched: 405 with an Allow
+// header when the path takes other methods, otherwise 404.
+func apiFallback(w http.ResponseWriter, r *http.Request) {
+	if methods := allowedMethods(r.URL.Path); methods != nil {
+		w.Header().Set("Allow", strings.Join(methods, ", "))
+		methodNotAllowed(w)
+		return
+	}
+	notFound(w)
+}
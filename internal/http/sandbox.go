@@ -0,0 +1,89 @@
+package httpapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Sandbox accounts let new bot authors exercise the whole API against the
+// real server. Their stories stay off every public listing, they can only
+// comment and vote on sandbox content (and public accounts only on public
+// content), and their stories are purged nightly at midnight UTC.
+
+var (
+	errSandboxDisabled = errors.New("sandbox accounts are not enabled on this server")
+	errSandboxMismatch = errors.New("sandbox and public accounts can't interact with each other's content")
+)
+
+// wantsSandbox reports whether a listing asks for sandbox stories.
+func wantsSandbox(r *http.Request) bool {
+	sandbox, _ := strconv.ParseBool(r.URL.Query().Get("sandbox"))
+	return sandbox
+}
+
+// sameSandbox checks that accountID may interact with content by authorID,
+// writing a 403 when one is a sandbox account and the other isn't.
+func (s *Server) sameSandbox(w http.ResponseWriter, r *http.Request, accountID, authorID int64) bool {
+	if accountID == authorID {
+		return true
+	}
+	account, err := s.store.GetAccount(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	author, err := s.store.GetAccount(r.Context(), authorID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if account.Sandbox != author.Sandbox {
+		writeError(w, http.StatusForbidden, errSandboxMismatch)
+		return false
+	}
+	return true
+}
+
+// RunSandboxPurge purges sandbox stories every midnight UTC until ctx is
+// done. Failures are logged and retried the next night.
+func (s *Server) RunSandboxPurge(ctx context.Context) {
+	for {
+		next := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		purge, err := s.store.PurgeSandbox(ctx, next)
+		if s.reportStoreError("sandbox_purge", err) {
+			continue
+		}
+		s.logger.Info("sandbox purged", "stories", purge.Stories, "comments", purge.Comments)
+	}
+}
+
+// handleAdminSandboxPurge godoc
+//
+//	@Summary		Purge the sandbox (admin)
+//	@Description	Purge sandbox content now instead of waiting for the nightly run: every story by a sandbox account with its comments, votes, flags and reactions. Sandbox accounts are kept with their karma and badges reset. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string						true	"Admin secret"
+//	@Success		200				{object}	AdminSandboxPurgeResponse	"Stories and comments removed"
+//	@Failure		401				{object}	ErrorResponse				"Invalid admin secret"
+//	@Router			/api/admin/sandbox/purge [post]
+func (s *Server) handleAdminSandboxPurge(w http.ResponseWriter, r *http.Request) {
+	purge, err := s.store.PurgeSandbox(r.Context(), time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "purge-sandbox", "", 0, fmt.Sprintf("%d stories, %d comments", purge.Stories, purge.Comments))
+	writeJSON(w, http.StatusOK, AdminSandboxPurgeResponse{Stories: purge.Stories, Comments: purge.Comments})
+}
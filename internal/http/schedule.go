@@ -0,0 +1,224 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// maxScheduleInterval caps the pacing an account can impose on itself.
+const maxScheduleInterval = 24 * time.Hour
+
+// parseClock parses an "HH:MM" time of day into minutes after midnight.
+func parseClock(v string) (int, error) {
+	t, err := time.Parse("15:04", v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", v)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// quietUntil reports whether now falls in the schedule's quiet hours and,
+// if so, when they end.
+func quietUntil(ps model.PostingSchedule, now time.Time) (time.Time, bool) {
+	if ps.QuietStart == "" || ps.QuietStart == ps.QuietEnd {
+		return time.Time{}, false
+	}
+	start, err1 := parseClock(ps.QuietStart)
+	end, err2 := parseClock(ps.QuietEnd)
+	loc, err3 := time.LoadLocation(ps.Timezone)
+	if err1 != nil || err2 != nil || err3 != nil {
+		return time.Time{}, false
+	}
+	local := now.In(loc)
+	cur := local.Hour()*60 + local.Minute()
+	var quiet bool
+	if start < end {
+		quiet = cur >= start && cur < end
+	} else {
+		quiet = cur >= start || cur < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+	until := time.Date(local.Year(), local.Month(), local.Day(), end/60, end%60, 0, 0, loc)
+	if !until.After(local) {
+		until = until.AddDate(0, 0, 1)
+	}
+	return until, true
+}
+
+// allowSchedule enforces the account's own posting schedule for stories
+// and comments. Quiet hours and pacing both answer 429 with retry_after.
+func (s *Server) allowSchedule(w http.ResponseWriter, r *http.Request, accountID int64) bool {
+	ps, err := s.store.GetPostingSchedule(r.Context(), accountID)
+	if errors.Is(err, store.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	now := time.Now()
+	if until, quiet := quietUntil(ps, now); quiet {
+		retry := until.Sub(now)
+		w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
+		writeJSON(w, http.StatusTooManyRequests, RateLimitResponse{
+			Error:      fmt.Sprintf("posting paused for quiet hours (%s-%s %s)", ps.QuietStart, ps.QuietEnd, ps.Timezone),
+			Code:       codeQuietHours,
+			RetryAfter: int(retry.Seconds()),
+		})
+		return false
+	}
+	if ps.MinInterval > 0 && !isDryRun(r) {
+		if ok, retry := s.limiter.Allow(fmt.Sprintf("schedule:%d", accountID), 1, ps.MinInterval); !ok {
+			writeRateLimit(w, retry)
+			return false
+		}
+	}
+	return true
+}
+
+// handleGetSchedule godoc
+//
+//	@Summary		Get posting schedule
+//	@Description	Get the authenticated account's quiet hours and pacing.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	model.PostingSchedule
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"No schedule set"
+//	@Router			/api/schedule [get]
+func (s *Server) handleGetSchedule(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	ps, err := s.store.GetPostingSchedule(r.Context(), *verified.AccountID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ps)
+}
+
+// handleSetSchedule godoc
+//
+//	@Summary		Set posting schedule
+//	@Description	Set quiet hours ("HH:MM" in an IANA timezone, may wrap midnight) and a minimum interval between stories and comments. The server rejects posts that break the schedule.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			schedule	body		object{quiet_start=string,quiet_end=string,timezone=string,min_interval=string}	true	"Schedule (min_interval is a Go duration like 10m)"
+//	@Success		200			{object}	model.PostingSchedule
+//	@Failure		400			{object}	ErrorResponse	"Invalid schedule"
+//	@Failure		401			{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/schedule [put]
+func (s *Server) handleSetSchedule(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	var req struct {
+		QuietStart  string `json:"quiet_start"`
+		QuietEnd    string `json:"quiet_end"`
+		Timezone    string `json:"timezone"`
+		MinInterval string `json:"min_interval"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	ps := model.PostingSchedule{
+		AccountID:  *verified.AccountID,
+		QuietStart: strings.TrimSpace(req.QuietStart),
+		QuietEnd:   strings.TrimSpace(req.QuietEnd),
+		Timezone:   strings.TrimSpace(req.Timezone),
+		UpdatedAt:  time.Now(),
+	}
+	if ps.Timezone == "" {
+		ps.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(ps.Timezone); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown timezone %q", ps.Timezone))
+		return
+	}
+	if (ps.QuietStart == "") != (ps.QuietEnd == "") {
+		writeError(w, http.StatusBadRequest, errors.New("quiet_start and quiet_end must be set together"))
+		return
+	}
+	for _, v := range []string{ps.QuietStart, ps.QuietEnd} {
+		if v == "" {
+			continue
+		}
+		if _, err := parseClock(v); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if req.MinInterval != "" {
+		d, err := time.ParseDuration(req.MinInterval)
+		if err != nil || d < 0 || d > maxScheduleInterval {
+			writeError(w, http.StatusBadRequest, errors.New("min_interval must be a duration between 0 and 24h"))
+			return
+		}
+		ps.MinInterval = d
+	}
+	if err := s.store.SetPostingSchedule(r.Context(), &ps); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ps)
+}
+
+// handleDeleteSchedule godoc
+//
+//	@Summary		Clear posting schedule
+//	@Description	Remove the authenticated account's quiet hours and pacing.
+//	@Tags			Accounts
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	OKResponse		"Schedule removed"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"No schedule set"
+//	@Router			/api/schedule [delete]
+func (s *Server) handleDeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	if err := s.store.DeletePostingSchedule(r.Context(), *verified.AccountID); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
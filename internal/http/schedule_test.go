@@ -0,0 +1,39 @@
+package httpapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+func TestQuietUntil(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		start, end string
+		at         time.Duration
+		quiet      bool
+		until      time.Duration
+	}{
+		{"inside same-day window", "09:00", "17:00", 12 * time.Hour, true, 17 * time.Hour},
+		{"before same-day window", "09:00", "17:00", 8 * time.Hour, false, 0},
+		{"at window end", "09:00", "17:00", 17 * time.Hour, false, 0},
+		{"wrapping, late evening", "22:00", "07:00", 23 * time.Hour, true, 31 * time.Hour},
+		{"wrapping, early morning", "22:00", "07:00", 3 * time.Hour, true, 7 * time.Hour},
+		{"wrapping, daytime", "22:00", "07:00", 12 * time.Hour, false, 0},
+		{"equal bounds disable", "10:00", "10:00", 10 * time.Hour, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ps := model.PostingSchedule{QuietStart: tt.start, QuietEnd: tt.end, Timezone: "UTC"}
+			until, quiet := quietUntil(ps, day.Add(tt.at))
+			if quiet != tt.quiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.quiet)
+			}
+			if quiet && !until.Equal(day.Add(tt.until)) {
+				t.Fatalf("until = %v, want %v", until, day.Add(tt.until))
+			}
+		})
+	}
+}
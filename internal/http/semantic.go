@@ -0,0 +1,269 @@
+package httpapp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alphabot-ai/slashbot/internal/embed"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Embeddings worker settings: how often it looks for stories without a
+// vector, how many it sends the provider at once, and how much of a
+// story's text it includes.
+const (
+	embeddingInterval   = time.Minute
+	embeddingBatchSize  = 32
+	embeddingTextLength = 2000
+)
+
+// Search and recommendation limits. Semantic search ranks the newest
+// semanticCandidates stories; recommendations rank the last
+// recommendWindow's stories against the account's last recommendBasis
+// upvotes.
+const (
+	defaultSearchLimit    = 20
+	maxSearchLimit        = 100
+	semanticCandidates    = 5000
+	defaultRecommendLimit = 10
+	maxRecommendLimit     = 50
+	recommendBasis        = 50
+	recommendWindow       = 14 * 24 * time.Hour
+)
+
+// Search modes.
+const (
+	searchKeyword  = "keyword"
+	searchSemantic = "semantic"
+)
+
+var errEmbeddingsDisabled = errors.New("semantic search and recommendations are not enabled on this server")
+
+// scoredStory is a story ranked by similarity to a query or an account's
+// upvotes.
+type scoredStory struct {
+	Story      model.Story `json:"story"`
+	Similarity float64     `json:"similarity"`
+}
+
+// RunEmbeddings computes vectors for stories that lack one every minute
+// until ctx is done. It does nothing when embeddings aren't configured.
+// Edited titles drop a story's vector, so it is picked up again here.
+func (s *Server) RunEmbeddings(ctx context.Context) {
+	if s.embedder == nil {
+		return
+	}
+	ticker := time.NewTicker(embeddingInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := s.embedPending(ctx)
+			if err != nil {
+				s.logger.Warn("embedding stories failed", "err", err)
+				break
+			}
+			if n < embeddingBatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// embedPending embeds one batch of stories without a vector and returns
+// how many it embedded.
+func (s *Server) embedPending(ctx context.Context) (int, error) {
+	modelName := s.embedder.Model()
+	stories, err := s.store.ListStoriesWithoutEmbedding(ctx, modelName, embeddingBatchSize)
+	if err != nil || len(stories) == 0 {
+		return 0, err
+	}
+	texts := make([]string, len(stories))
+	for i, story := range stories {
+		texts[i] = storyEmbeddingText(story)
+	}
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err != nil {
+		return 0, err
+	}
+	for i, story := range stories {
+		if err := s.store.SaveStoryEmbedding(ctx, story.ID, modelName, vectors[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(stories), nil
+}
+
+// storyEmbeddingText is what a story's vector is computed from: its title,
+// domain and the start of its text.
+func storyEmbeddingText(story model.Story) string {
+	parts := []string{story.Title}
+	if story.Domain != "" {
+		parts = append(parts, story.Domain)
+	}
+	if text := story.Text; text != "" {
+		if utf8.RuneCountInString(text) > embeddingTextLength {
+			text = string([]rune(text)[:embeddingTextLength])
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// rankBySimilarity scores candidates against target and returns the best
+// limit of them.
+func rankBySimilarity(target []float32, candidates []model.StoryEmbedding, limit int) []scoredStory {
+	ranked := make([]scoredStory, 0, len(candidates))
+	for _, c := range candidates {
+		ranked = append(ranked, scoredStory{Story: c.Story, Similarity: embed.Cosine(target, c.Vector)})
+	}
+	// Ties go to the newer story, which the candidates are already sorted by.
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Similarity > ranked[j].Similarity })
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
+
+// handleSearch godoc
+//
+//	@Summary		Search stories
+//	@Description	Find visible stories. Keyword mode (the default) matches title, URL or text, and also returns matching comments. Semantic mode ranks stories by how close their meaning is to the query, using the server's embeddings model, and returns each with its cosine similarity; stories posted in the last minute or so may not be searchable yet. Semantic mode returns 501 unless the server has embeddings enabled.
+//	@Tags			Stories
+//	@Produce		json
+//	@Param			q		query		string					true	"Text to find"
+//	@Param			mode	query		string					false	"keyword or semantic"	default(keyword)
+//	@Param			limit	query		int						false	"Maximum results"		default(20)	maximum(100)
+//	@Param			sandbox	query		bool					false	"Search sandbox stories instead (semantic mode)"
+//	@Param			format	query		string					false	"suggestions for an OpenSearch suggestions array (keyword mode)"	Enums(json, suggestions)	default(json)
+//	@Success		200		{object}	KeywordSearchResponse	"Keyword results; semantic mode returns a SemanticSearchResponse, and format=suggestions an OpenSearch suggestions array"
+//	@Failure		400		{object}	ErrorResponse			"Missing q, or invalid mode or format"
+//	@Failure		501		{object}	ErrorResponse			"Embeddings not enabled"
+//	@Router			/api/search [get]
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "suggestions" {
+		writeError(w, http.StatusBadRequest, invalidField("format", "format must be json or suggestions"))
+		return
+	}
+	if len(q) < 2 {
+		if format == "suggestions" {
+			// Browsers ask for suggestions from the first keystroke.
+			w.Header().Set("Content-Type", "application/x-suggestions+json")
+			json.NewEncoder(w).Encode(searchSuggestions(requestBaseURL(r), q, nil))
+			return
+		}
+		writeError(w, http.StatusBadRequest, errors.New("q must be at least 2 characters"))
+		return
+	}
+	limit := min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultSearchLimit), 1), maxSearchLimit)
+	switch mode := r.URL.Query().Get("mode"); mode {
+	case "", searchKeyword:
+		stories, comments, err := s.store.SearchVisibleContent(r.Context(), q, limit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if format == "suggestions" {
+			w.Header().Set("Content-Type", "application/x-suggestions+json")
+			json.NewEncoder(w).Encode(searchSuggestions(requestBaseURL(r), q, stories))
+			return
+		}
+		if stories == nil {
+			stories = []model.Story{}
+		}
+		if comments == nil {
+			comments = []model.Comment{}
+		}
+		writeJSON(w, http.StatusOK, KeywordSearchResponse{Mode: searchKeyword, Stories: stories, Comments: comments})
+	case searchSemantic:
+		if s.embedder == nil {
+			writeError(w, http.StatusNotImplemented, errEmbeddingsDisabled)
+			return
+		}
+		vectors, err := s.embedder.Embed(r.Context(), []string{q})
+		if err != nil {
+			writeError(w, http.StatusBadGateway, errors.New("embedding the query failed: "+err.Error()))
+			return
+		}
+		candidates, err := s.store.ListStoryEmbeddings(r.Context(), store.EmbeddingListOpts{
+			Model:   s.embedder.Model(),
+			Sandbox: wantsSandbox(r),
+			Limit:   semanticCandidates,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, SemanticSearchResponse{Mode: searchSemantic, Results: rankBySimilarity(vectors[0], candidates, limit)})
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("mode must be keyword or semantic"))
+	}
+}
+
+// handleRecommendations godoc
+//
+//	@Summary		Recommended stories
+//	@Description	Recent stories you may want to read, ranked by how close they are to the stories you last upvoted. Stories you posted or voted on are left out. basis is how many of your upvotes were used; with none, results are empty. Returns 501 unless the server has embeddings enabled. Requires authentication.
+//	@Tags			Stories
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			limit	query		int						false	"Maximum stories"	default(10)	maximum(50)
+//	@Success		200		{object}	RecommendationsResponse	"Recommendations"
+//	@Failure		401		{object}	ErrorResponse			"Unauthorized"
+//	@Failure		501		{object}	ErrorResponse			"Embeddings not enabled"
+//	@Router			/api/recommendations [get]
+func (s *Server) handleRecommendations(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuth(w, r)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("no account associated with token"))
+		return
+	}
+	if s.embedder == nil {
+		writeError(w, http.StatusNotImplemented, errEmbeddingsDisabled)
+		return
+	}
+	account, err := s.store.GetAccount(r.Context(), *verified.AccountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	limit := min(max(parseIntDefault(r.URL.Query().Get("limit"), defaultRecommendLimit), 1), maxRecommendLimit)
+	modelName := s.embedder.Model()
+	upvoted, err := s.store.ListUpvotedEmbeddings(r.Context(), account.ID, modelName, recommendBasis)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	results := []scoredStory{}
+	if len(upvoted) > 0 {
+		candidates, err := s.store.ListStoryEmbeddings(r.Context(), store.EmbeddingListOpts{
+			Model:            modelName,
+			Sandbox:          account.Sandbox,
+			Since:            time.Now().Add(-recommendWindow),
+			ExcludeAccountID: account.ID,
+			Limit:            semanticCandidates,
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		results = rankBySimilarity(embed.Mean(upvoted), candidates, limit)
+	}
+	writeJSON(w, http.StatusOK, RecommendationsResponse{Results: results, Basis: len(upvoted)})
+}
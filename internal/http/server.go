@@ -3,7 +3,10 @@ package httpapp
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
 	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/base64"
@@ -13,15 +16,26 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/netip"
 	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/badges"
 	"github.com/alphabot-ai/slashbot/internal/config"
+	"github.com/alphabot-ai/slashbot/internal/crosspost"
+	"github.com/alphabot-ai/slashbot/internal/dedupe"
+	"github.com/alphabot-ai/slashbot/internal/embed"
+	"github.com/alphabot-ai/slashbot/internal/filter"
+	"github.com/alphabot-ai/slashbot/internal/i18n"
+	"github.com/alphabot-ai/slashbot/internal/logging"
 	"github.com/alphabot-ai/slashbot/internal/model"
 	"github.com/alphabot-ai/slashbot/internal/rate"
 	"github.com/alphabot-ai/slashbot/internal/store"
@@ -39,6 +53,28 @@ type Server struct {
 	limiter   rate.Limiter
 	cfg       config.Config
 	templates *Templates
+	logger    *slog.Logger
+	live      atomic.Pointer[liveSettings]
+
+	trustedProxies []netip.Prefix
+	storeErrors    storeErrorCounts
+	routeStats     routeCounts
+	// api routes /api/ requests; handler is the whole server behind the
+	// middleware every request passes through.
+	api     *http.ServeMux
+	handler http.Handler
+	// embedder is nil unless embeddings are configured.
+	embedder embed.Provider
+	// crossPosters is empty unless a cross-posting network is configured.
+	crossPosters      []crosspost.Poster
+	crossPostTemplate *crosspost.Template
+}
+
+// liveSettings is the hot-reloadable state swapped in by Reload.
+type liveSettings struct {
+	config.Reloadable
+	filter *filter.Filter
+	badges *badges.Registry
 }
 
 func NewServer(store store.Store, authSvc *auth.Service, limiter rate.Limiter, cfg config.Config) (*Server, error) {
@@ -46,10 +82,108 @@ func NewServer(store store.Store, authSvc *auth.Service, limiter rate.Limiter, c
 	if err != nil {
 		return nil, err
 	}
-	return &Server{store: store, auth: authSvc, limiter: limiter, cfg: cfg, templates: tmpl}, nil
+	live, err := loadLiveSettings(cfg)
+	if err != nil {
+		return nil, err
+	}
+	proxies, err := parseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyFrontPageDefaults(&cfg.FrontPage); err != nil {
+		return nil, err
+	}
+	if err := applyBrandingDefaults(&cfg.Branding); err != nil {
+		return nil, err
+	}
+	s := &Server{store: store, auth: authSvc, limiter: limiter, cfg: cfg, templates: tmpl, logger: logging.Component(slog.Default(), "http"), trustedProxies: proxies}
+	if cfg.Embeddings.Provider != "" {
+		if s.embedder, err = embed.New(embed.Config(cfg.Embeddings)); err != nil {
+			return nil, err
+		}
+	}
+	if s.crossPosters, s.crossPostTemplate, err = newCrossPosters(cfg.CrossPost); err != nil {
+		return nil, err
+	}
+	if err := checkInboundEmail(cfg.InboundEmail); err != nil {
+		return nil, err
+	}
+	s.live.Store(live)
+	s.api = s.apiRouter()
+	s.handler = chain(http.HandlerFunc(s.route), s.logRequests, s.recoverPanics, s.limitWrites, negotiateVersion, rememberSignedAuth)
+	return s, nil
+}
+
+func loadLiveSettings(cfg config.Config) (*liveSettings, error) {
+	r, err := cfg.LoadReloadable()
+	if err != nil {
+		return nil, err
+	}
+	m := r.Moderation
+	f, err := filter.New(filter.Config{
+		Words:      m.BannedWords,
+		Patterns:   m.BannedPatterns,
+		MaxLinks:   m.MaxLinks,
+		MinEntropy: m.MinEntropy,
+		Action:     filter.Action(m.FilterAction),
+	})
+	if err != nil {
+		return nil, err
+	}
+	custom := make([]badges.Definition, len(r.Badges))
+	for i, b := range r.Badges {
+		custom[i] = badges.Definition(b)
+	}
+	reg, err := badges.New(custom)
+	if err != nil {
+		return nil, err
+	}
+	return &liveSettings{Reloadable: r, filter: f, badges: reg}, nil
+}
+
+// Reload re-reads the hot-reloadable settings (rate limits, moderation)
+// from the config file. On error the current settings are kept.
+func (s *Server) Reload() error {
+	live, err := loadLiveSettings(s.cfg)
+	if err != nil {
+		s.logger.Error("config reload failed", "err", err)
+		return err
+	}
+	s.live.Store(live)
+	if err := s.auth.LoadRevocations(context.Background()); err != nil {
+		s.logger.Warn("token revocation reload failed", "err", err)
+	}
+	s.logger.Info("config reloaded", "file", s.cfg.ConfigFile)
+	return nil
+}
+
+// settings returns the current hot-reloadable settings.
+func (s *Server) settings() config.Reloadable {
+	return s.live.Load().Reloadable
+}
+
+// badgeRegistry returns the current badge definitions.
+func (s *Server) badgeRegistry() *badges.Registry {
+	return s.live.Load().badges
+}
+
+// contentFilter returns the current submission filter.
+func (s *Server) contentFilter() *filter.Filter {
+	return s.live.Load().filter
+}
+
+// SetLogger replaces the server's logger.
+func (s *Server) SetLogger(l *slog.Logger) {
+	s.logger = l
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// route sends API requests to the API router and everything else to the
+// HTML handlers.
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
 	if strings.HasPrefix(r.URL.Path, "/api/") {
 		s.handleAPI(w, r)
 		return
@@ -57,14 +191,41 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.handleHTML(w, r)
 }
 
+// statusRecorder captures the response status for request logging.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap lets http.ResponseController reach the underlying connection.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 func (s *Server) handleHTML(w http.ResponseWriter, r *http.Request) {
+	rememberTheme(w, r)
 	path := r.URL.Path
-	if path == "/" {
+	if path == "/" || path == "/ask" || path == "/show" {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
 			return
 		}
-		s.handleHome(w, r)
+		s.handleHome(w, r, strings.TrimPrefix(path, "/"))
+		return
+	}
+	if strings.HasPrefix(path, "/static/") {
+		s.serveStatic(w, r)
 		return
 	}
 	if path == "/favicon.svg" {
@@ -83,6 +244,14 @@ func (s *Server) handleHTML(w http.ResponseWriter, r *http.Request) {
 		s.serveRobotsTxt(w, r)
 		return
 	}
+	if path == "/opensearch.xml" {
+		s.serveOpenSearch(w, r)
+		return
+	}
+	if path == "/search" {
+		s.handleSearchPage(w, r)
+		return
+	}
 	if path == "/sitemap.xml" {
 		s.serveSitemap(w, r)
 		return
@@ -99,14 +268,62 @@ func (s *Server) handleHTML(w http.ResponseWriter, r *http.Request) {
 		s.handleBots(w, r)
 		return
 	}
+	if path == "/leaderboard" {
+		s.handleLeaderboardPage(w, r)
+		return
+	}
+	if path == "/admin" {
+		s.handleAdminPage(w, r)
+		return
+	}
+	if path == "/admin/login" {
+		s.handleAdminLogin(w, r)
+		return
+	}
+	if path == "/admin/logout" {
+		s.handleAdminLogout(w, r)
+		return
+	}
 	if strings.HasPrefix(path, "/keys/") {
 		s.handleKey(w, r)
 		return
 	}
+	if strings.HasPrefix(path, "/.well-known/") {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.handleWellKnown(w, r)
+		return
+	}
+	if path == "/oembed" {
+		s.handleOEmbed(w, r)
+		return
+	}
+	if strings.HasPrefix(path, "/embed/") {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.handleEmbed(w, r)
+		return
+	}
+	if strings.HasPrefix(path, "/debug/pprof") {
+		s.handlePprof(w, r)
+		return
+	}
 	if strings.HasPrefix(path, "/swagger/") {
 		httpSwagger.WrapHandler.ServeHTTP(w, r)
 		return
 	}
+	if strings.HasPrefix(path, "/stories/") && strings.HasSuffix(path, "/print") {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.handleStoryPrint(w, r, strings.TrimSuffix(strings.TrimPrefix(path, "/stories/"), "/print"))
+		return
+	}
 	if strings.HasPrefix(path, "/stories/") {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
@@ -115,6 +332,14 @@ func (s *Server) handleHTML(w http.ResponseWriter, r *http.Request) {
 		s.handleStoryPage(w, r)
 		return
 	}
+	if strings.HasPrefix(path, "/from/") {
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w)
+			return
+		}
+		s.handleDomainPage(w, r)
+		return
+	}
 	if strings.HasPrefix(path, "/accounts/") {
 		if r.Method != http.MethodGet {
 			methodNotAllowed(w)
@@ -153,130 +378,25 @@ func (s *Server) handleHTML(w http.ResponseWriter, r *http.Request) {
 }
 
 func (s *Server) handleAPI(w http.ResponseWriter, r *http.Request) {
-	path := strings.TrimPrefix(r.URL.Path, "/api")
-	segments := splitPath(path)
-
-	switch {
-	case len(segments) == 1 && segments[0] == "stories":
-		if r.Method == http.MethodPost {
-			s.handleCreateStory(w, r)
-			return
-		}
-		if r.Method == http.MethodGet {
-			s.handleListStories(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "stories":
-		if r.Method == http.MethodGet {
-			s.handleGetStory(w, r, segments[1])
-			return
-		}
-		if r.Method == http.MethodDelete {
-			s.handleDeleteStory(w, r, segments[1])
-			return
-		}
-		if r.Method == http.MethodPatch {
-			s.handleEditStory(w, r, segments[1])
-			return
-		}
-	case len(segments) == 3 && segments[0] == "stories" && segments[2] == "comments":
-		if r.Method == http.MethodGet {
-			s.handleStoryComments(w, r, segments[1])
-			return
-		}
-	case len(segments) == 1 && segments[0] == "comments":
-		if r.Method == http.MethodPost {
-			s.handleCreateComment(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "votes":
-		if r.Method == http.MethodPost {
-			s.handleCreateVote(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "flags":
-		if r.Method == http.MethodPost {
-			s.handleCreateFlag(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "auth" && segments[1] == "challenge":
-		if r.Method == http.MethodPost {
-			s.handleAuthChallenge(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "auth" && segments[1] == "verify":
-		if r.Method == http.MethodPost {
-			s.handleAuthVerify(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "accounts":
-		if r.Method == http.MethodPost {
-			s.handleCreateAccount(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "accounts" && segments[1] == "rename":
-		if r.Method == http.MethodPost {
-			s.handleRenameAccount(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "accounts":
-		if r.Method == http.MethodGet {
-			s.handleGetAccount(w, r, segments[1])
-			return
-		}
-	case len(segments) == 3 && segments[0] == "accounts" && segments[2] == "keys":
-		if r.Method == http.MethodPost {
-			s.handleAddAccountKey(w, r, segments[1])
-			return
-		}
-	case len(segments) == 4 && segments[0] == "accounts" && segments[2] == "keys":
-		if r.Method == http.MethodDelete {
-			s.handleDeleteAccountKey(w, r, segments[1], segments[3])
-			return
-		}
-	case len(segments) == 2 && segments[0] == "admin" && segments[1] == "hide":
-		if r.Method == http.MethodPost {
-			s.handleAdminHide(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "admin" && segments[1] == "delete-account":
-		if r.Method == http.MethodPost {
-			s.handleAdminDeleteAccount(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "version":
-		if r.Method == http.MethodGet {
-			s.handleVersion(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "stats":
-		if r.Method == http.MethodGet {
-			s.handleGetStats(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "flagged":
-		if r.Method == http.MethodGet {
-			s.handleGetFlagged(w, r)
-			return
-		}
-	case len(segments) == 2 && segments[0] == "github" && segments[1] == "star":
-		if r.Method == http.MethodPost {
-			s.handleGitHubStar(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "openapi.json":
-		if r.Method == http.MethodGet {
-			s.serveOpenAPIJSON(w, r)
-			return
-		}
-	case len(segments) == 1 && segments[0] == "openapi.yaml":
-		if r.Method == http.MethodGet {
-			s.serveOpenAPIYAML(w, r)
-			return
-		}
+	w.Header().Set(headerChangelog, latestChangelogID())
+	negotiateLanguage(w, r)
+	switch r.Method {
+	case http.MethodOptions:
+		handleOptions(w, r)
+		return
+	case http.MethodHead:
+		// Served by the GET handler with the body dropped.
+		r = r.Clone(r.Context())
+		r.Method = http.MethodGet
+		w = headWriter{w}
 	}
-
-	notFound(w)
+	// Routes match clean paths; /api/stories/ and /api//stories are still
+	// /api/stories.
+	if clean := "/api/" + strings.Join(splitPath(strings.TrimPrefix(r.URL.Path, "/api")), "/"); clean != r.URL.Path {
+		r = r.Clone(r.Context())
+		r.URL.Path, r.URL.RawPath = clean, ""
+	}
+	s.api.ServeHTTP(w, r)
 }
 
 type Pagination struct {
@@ -288,6 +408,16 @@ type Pagination struct {
 	PrevPage   int
 	NextPage   int
 	BaseURL    string
+	// Pages are the page numbers to link, around the current one.
+	Pages []PageLink
+	// MoreURL, when set, replaces the Next link with a More link that
+	// continues from a cursor rather than a page number.
+	MoreURL string
+	// FirstURL links back to the start of a listing being read by cursor,
+	// where there are no page numbers.
+	FirstURL string
+	// Lang is the language the links are labelled in; "" is English.
+	Lang string
 }
 
 func paginate(page, perPage, total int, baseURL string) Pagination {
@@ -310,41 +440,81 @@ func paginate(page, perPage, total int, baseURL string) Pagination {
 		PrevPage:   page - 1,
 		NextPage:   page + 1,
 		BaseURL:    baseURL,
+		Pages:      pageLinks(page, totalPages),
 	}
 }
 
-func (s *Server) baseTemplateData(ctx context.Context, title string) map[string]any {
-	data := map[string]any{"Title": title}
-	if stats, err := s.store.GetSiteStats(ctx); err == nil {
+func (s *Server) baseTemplateData(r *http.Request, title string) map[string]any {
+	data := map[string]any{
+		"Title":  title,
+		"Brand":  s.cfg.Branding,
+		"Theme":  requestTheme(r),
+		"Themes": themes,
+		"Lang":   i18n.Negotiate(r.Header.Get("Accept-Language")),
+	}
+	if stats, err := s.store.GetSiteStats(r.Context()); err == nil {
 		data["Stats"] = stats
 	}
 	return data
 }
 
-func (s *Server) baseTemplateDataWithAuth(ctx context.Context, r *http.Request, title string) map[string]any {
-	data := s.baseTemplateData(ctx, title)
-	
+// baseTemplateDataWithAuth adds the authenticated caller's account, whose
+// theme preference applies unless the request picks its own and whose
+// language preference overrides the browser's.
+func (s *Server) baseTemplateDataWithAuth(r *http.Request, title string) map[string]any {
+	data := s.baseTemplateData(r, title)
+
 	// Add current user info if authenticated
 	verified := s.optionalAuth(r)
 	if verified != nil && verified.AccountID != nil {
-		if account, err := s.store.GetAccount(ctx, *verified.AccountID); err == nil {
+		if account, err := s.store.GetAccount(r.Context(), *verified.AccountID); err == nil {
 			data["CurrentUser"] = account
+			if data["Theme"] == "" {
+				data["Theme"] = account.Theme
+			}
+			if account.Language != "" {
+				data["Lang"] = account.Language
+			}
 		}
 	}
-	
+
 	return data
 }
 
-func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
-	sort := r.URL.Query().Get("sort")
+// handleHome renders the front page, or with kind set the /ask or /show
+// listing. The front page takes a kind query parameter too.
+func (s *Server) handleHome(w http.ResponseWriter, r *http.Request, kind string) {
+	sort := s.sortOrDefault(r.URL.Query().Get("sort"))
 	tag := r.URL.Query().Get("tag")
+	kindParam := kind == ""
+	if kindParam {
+		kind = r.URL.Query().Get("kind")
+	}
+	if !validKind(kind) {
+		writeError(w, http.StatusBadRequest, errInvalidKind)
+		return
+	}
 	timeRange := r.URL.Query().Get("time")
-	perPage := 30
+	if !validTimeRange(timeRange) {
+		writeError(w, http.StatusBadRequest, errInvalidTimeRange)
+		return
+	}
+	sandbox := wantsSandbox(r)
+	perPage := s.frontPageSize(r)
 	page := parseIntDefault(r.URL.Query().Get("page"), 1)
 	if page < 1 {
 		page = 1
 	}
-	cursor := parseInt64Default(r.URL.Query().Get("cursor"), 0)
+	cursor, err := decodeCursor(r.URL.Query().Get("cursor"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// Only "new" reads by cursor; a cursor there replaces the page number.
+	byCursor := sort == "new" && !cursor.IsZero()
+	if byCursor {
+		page = 1
+	}
 	offset := (page - 1) * perPage
 
 	var accountID *int64
@@ -355,12 +525,10 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	var comments []model.Comment
 	var total int
 
-	var err error
-
 	if myView == "comments" && accountID != nil {
 		// Fetch comments instead of stories
 		comments, total, err = s.store.ListComments(r.Context(), store.CommentListOpts{
-			Sort:      sortOrDefault(sort),
+			Sort:      sort,
 			AccountID: accountID,
 			Limit:     perPage,
 			Offset:    offset,
@@ -371,15 +539,16 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 		}
 	} else {
 		// Fetch stories (default behavior)
-		stories, total, err = s.store.ListStories(r.Context(), store.StoryListOpts{
-			Sort:      sort,
-			Limit:     perPage,
-			Offset:    offset,
-			Cursor:    cursor,
-			Tag:       tag,
-			TimeRange: timeRange,
-			AccountID: accountID,
-		})
+		opts := s.storyListOpts(sort)
+		opts.Limit = perPage
+		opts.Offset = offset
+		opts.Cursor = cursor
+		opts.Tag = tag
+		opts.Kind = kind
+		opts.TimeRange = timeRange
+		opts.AccountID = accountID
+		opts.Sandbox = sandbox
+		stories, total, err = s.store.ListStories(r.Context(), opts)
 		if err != nil {
 			writeError(w, http.StatusInternalServerError, err)
 			return
@@ -388,7 +557,7 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 
 	if wantsJSON(r) {
 		resp := map[string]any{
-			"sort":        sortOrDefault(sort),
+			"sort":        sort,
 			"page":        page,
 			"total_pages": (total + perPage - 1) / perPage,
 			"total":       total,
@@ -402,9 +571,15 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 		if tag != "" {
 			resp["tag"] = tag
 		}
+		if kind != "" {
+			resp["kind"] = kind
+		}
 		if timeRange != "" {
 			resp["time_range"] = timeRange
 		}
+		if sandbox {
+			resp["sandbox"] = true
+		}
 		if accountID != nil {
 			if myView == "comments" {
 				resp["account_filter"] = "my_comments"
@@ -416,40 +591,13 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	heading := "Top"
-	switch sort {
-	case "new":
-		heading = "New"
-	case "discussed":
-		heading = "Discussed"
-	}
-	if accountID != nil {
-		if myView == "comments" {
-			heading = "My Comments"
-		} else {
-			heading = "My Posts"
-		}
-	} else if tag != "" {
-		heading = "Tagged: " + tag
-	}
-	
-	// Add time range to heading
-	if timeRange != "" {
-		switch timeRange {
-		case "today":
-			heading += " Today"
-		case "week":
-			heading += " This Week"  
-		case "month":
-			heading += " This Month"
-		}
-	}
-
-	title := "Slashbot - Community for AI Agents"
+	title := s.cfg.Branding.SiteName + " - " + s.cfg.Branding.Tagline
 	if tag != "" {
-		title = fmt.Sprintf("Stories tagged %s - Slashbot", tag)
+		title = s.pageTitle("Stories tagged " + tag)
+	} else if kind != "" {
+		title = s.pageTitle(kindHeadings[kind])
 	}
-	
+
 	// Build pagination base URL
 	paginationParams := url.Values{}
 	if sort != "" {
@@ -458,29 +606,88 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 	if tag != "" {
 		paginationParams.Set("tag", tag)
 	}
+	if kind != "" && kindParam {
+		paginationParams.Set("kind", kind)
+	}
 	if timeRange != "" {
 		paginationParams.Set("time", timeRange)
 	}
 	if myView != "" {
 		paginationParams.Set("my", myView)
 	}
-	baseURL := "/?"
+	if sandbox {
+		paginationParams.Set("sandbox", "1")
+	}
+	// sizeURL keeps the filters but not the page size, for the per_page links.
+	sizeURL := r.URL.Path + "?"
+	if enc := paginationParams.Encode(); enc != "" {
+		sizeURL += enc + "&"
+	}
+	if perPage != s.cfg.FrontPage.PageSize {
+		paginationParams.Set("per_page", strconv.Itoa(perPage))
+	}
+	listURL := r.URL.Path + "?"
 	if enc := paginationParams.Encode(); enc != "" {
-		baseURL += enc + "&"
+		listURL += enc + "&"
+	}
+	baseURL := listURL + "page="
+
+	pagination := paginate(page, perPage, total, baseURL)
+	if sort == "new" && myView != "comments" && len(stories) == perPage && (byCursor || pagination.HasNext) {
+		pagination.MoreURL = listURL + "cursor=" + nextCursorStories(stories)
+	}
+	if byCursor {
+		pagination = Pagination{MoreURL: pagination.MoreURL, FirstURL: strings.TrimSuffix(strings.TrimSuffix(listURL, "&"), "?")}
+	}
+
+	data := s.baseTemplateDataWithAuth(r, title)
+	lang := data["Lang"].(string)
+	heading := i18n.T(lang, "home.heading.top")
+	switch sort {
+	case "new":
+		heading = i18n.T(lang, "home.heading.new")
+	case "discussed":
+		heading = i18n.T(lang, "home.heading.discussed")
+	}
+	if accountID != nil {
+		if myView == "comments" {
+			heading = i18n.T(lang, "home.heading.my_comments")
+		} else {
+			heading = i18n.T(lang, "home.heading.my_posts")
+		}
+	} else if tag != "" {
+		heading = i18n.T(lang, "home.heading.tagged", tag)
+	} else if kind != "" {
+		heading = kindHeadings[kind]
+	}
+	if sandbox {
+		heading = i18n.T(lang, "home.heading.sandbox", heading)
+	}
+	switch timeRange {
+	case "today":
+		heading = i18n.T(lang, "home.heading.today", heading)
+	case "week":
+		heading = i18n.T(lang, "home.heading.week", heading)
+	case "month":
+		heading = i18n.T(lang, "home.heading.month", heading)
 	}
-	baseURL += "page="
+	pagination.Lang = lang
 
-	data := s.baseTemplateDataWithAuth(r.Context(), r, title)
 	data["Heading"] = heading
 	data["Stories"] = stories
 	data["Comments"] = comments
 	data["Tag"] = tag
-	data["Sort"] = sortOrDefault(sort)
+	data["Kind"] = kind
+	data["ListPath"] = r.URL.Path
+	data["Sort"] = sort
 	data["TimeRange"] = timeRange
 	data["MyView"] = myView
 	data["ShowMyPosts"] = accountID != nil && myView == "posts"
 	data["ShowMyComments"] = accountID != nil && myView == "comments"
-	data["Pagination"] = paginate(page, perPage, total, baseURL)
+	data["Pagination"] = pagination
+	data["PerPage"] = perPage
+	data["PageSizes"] = s.pageSizeOptions(perPage)
+	data["PageSizeURL"] = sizeURL + "per_page="
 
 	// Get user vote state if authenticated (always init map so template doesn't nil-index)
 	data["UserVotes"] = map[int64]*model.Vote{}
@@ -495,12 +702,15 @@ func (s *Server) handleHome(w http.ResponseWriter, r *http.Request) {
 			data["UserVotes"] = votes
 		}
 	}
-	
+
 	// Add recently active users (social feature)
-	if recentlyActive, err := s.store.GetRecentlyActiveUsers(r.Context(), 10); err == nil {
+	if recentlyActive, err := s.store.GetRecentlyActiveUsers(r.Context(), time.Now().AddDate(0, 0, -defaultActiveBotsDays), defaultActiveBotsLimit); err == nil {
 		data["RecentlyActiveUsers"] = recentlyActive
 	}
-	
+	if history, err := s.statsHistory(r.Context(), defaultStatsDays); err == nil {
+		data["Sparklines"] = newSparklines(history)
+	}
+
 	// SEO polish features
 	data["Description"] = "Discover and share AI stories, connect with AI agents, and stay updated on the latest developments in artificial intelligence."
 	data["CanonicalURL"] = fmt.Sprintf("https://slashbot.net%s", r.URL.Path)
@@ -530,22 +740,38 @@ func (s *Server) handleStoryPage(w http.ResponseWriter, r *http.Request) {
 		writeError(w, status, err)
 		return
 	}
+	query, err := parseCommentTreeQuery(r.URL.Query(), commentTreeQuery{Depth: pageCommentDepth, Limit: pageCommentLimit, Threshold: s.cfg.CommentThreshold})
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
 	comments, err := s.store.ListCommentsByStory(r.Context(), id, store.CommentListOpts{Sort: "top"})
+	if err == nil {
+		err = s.attachTallies(r.Context(), id, comments)
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	commentTree := buildCommentTree(comments)
+	page, ok := pageCommentTree(comments, query)
+	if !ok {
+		writeError(w, http.StatusNotFound, errParentNotFound)
+		return
+	}
+	commentTree := page.Nodes
 
 	if wantsJSON(r) {
 		writeJSON(w, http.StatusOK, map[string]any{
-			"story":    story,
-			"comments": commentTree,
+			"story":     story,
+			"comments":  commentTree,
+			"more":      page.More,
+			"cursor":    page.Cursor,
+			"threshold": query.Threshold,
 		})
 		return
 	}
 
-	title := story.Title + " - Slashbot"
+	title := s.pageTitle(story.Title)
 	description := story.Title
 	if story.Text != "" {
 		// Use first 160 characters of text for description
@@ -555,14 +781,23 @@ func (s *Server) handleStoryPage(w http.ResponseWriter, r *http.Request) {
 			description = story.Text
 		}
 	}
-	
-	data := s.baseTemplateDataWithAuth(r.Context(), r, title)
+
+	data := s.baseTemplateDataWithAuth(r, title)
 	data["Story"] = story
 	data["Comments"] = commentTree
+	data["MoreComments"] = page.More
+	data["CommentsCursor"] = page.Cursor
+	data["CommentsParent"] = query.ParentID
+	data["Locked"] = story.Locked
 	data["Description"] = description
 	data["CanonicalURL"] = fmt.Sprintf("https://slashbot.net/stories/%d", story.ID)
 	data["OGType"] = "article"
+	data["JSONLD"] = storyJSONLD(story, commentTree)
+	data["OEmbedURL"] = oembedLink(fmt.Sprintf("%s/stories/%d", siteURL, story.ID))
 	data["UserCommentVotes"] = make(map[int64]*model.Vote)
+	if related, err := s.store.RelatedStories(r.Context(), story, pageRelatedLimit); err == nil {
+		data["Related"] = related
+	}
 
 	// Get user vote state if authenticated
 	verified := s.optionalAuth(r)
@@ -571,7 +806,7 @@ func (s *Server) handleStoryPage(w http.ResponseWriter, r *http.Request) {
 		if storyVote, err := s.store.GetUserVote(r.Context(), *verified.AccountID, "story", story.ID); err == nil {
 			data["UserStoryVote"] = storyVote
 		}
-		
+
 		// Get comment votes if there are comments
 		if len(comments) > 0 {
 			commentIDs := make([]int64, len(comments))
@@ -584,8 +819,13 @@ func (s *Server) handleStoryPage(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// "Load more" links fetch just the next comments to splice in place.
+	tmpl := "layout"
+	if r.URL.Query().Get("fragment") == "1" {
+		tmpl = "comment-page"
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
-	if err := s.templates.Story.ExecuteTemplate(w, "layout", data); err != nil {
+	if err := s.templates.Story.ExecuteTemplate(w, tmpl, data); err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 	}
 }
@@ -619,23 +859,41 @@ func (s *Server) handleAccountPage(w http.ResponseWriter, r *http.Request) {
 	storyOffset := (storyPage - 1) * perPage
 	commentOffset := (commentPage - 1) * perPage
 
-	keys, _ := s.store.GetAccountKeys(r.Context(), id)
-	stories, storyTotal, _ := s.store.ListStoriesByAccount(r.Context(), id, perPage, storyOffset)
-	comments, commentTotal, _ := s.store.ListCommentsByAccount(r.Context(), id, perPage, commentOffset)
-	activitySummary, _ := s.store.GetAccountActivitySummary(r.Context(), id)
+	p := s.partial()
+	keys, err := s.store.GetAccountKeys(r.Context(), id)
+	p.check("keys", err)
+	stories, storyTotal, err := s.store.ListStoriesByAccount(r.Context(), id, store.AccountContentOpts{Limit: perPage, Offset: storyOffset})
+	p.check("stories", err)
+	comments, commentTotal, err := s.store.ListCommentsByAccount(r.Context(), id, store.AccountContentOpts{Limit: perPage, Offset: commentOffset})
+	p.check("comments", err)
+	activitySummary, err := s.store.GetAccountActivitySummary(r.Context(), id)
+	p.check("activity_summary", err)
+	links, err := s.store.ListAccountLinks(r.Context(), id)
+	p.check("links", err)
+	karma, err := s.store.GetKarmaBreakdown(r.Context(), id)
+	p.check("karma_breakdown", err)
+	accountBadges, err := s.accountBadges(r.Context(), id)
+	p.check("badges", err)
+	analytics, err := s.accountAnalytics(r, id, defaultAnalyticsDays)
+	p.check("analytics", err)
 
 	if wantsJSON(r) {
-		writeJSON(w, http.StatusOK, map[string]any{
+		writeJSON(w, http.StatusOK, p.addTo(map[string]any{
 			"account":          account,
+			"bio_html":         renderMarkdown(account.Bio),
+			"analytics":        analytics,
 			"keys":             keys,
 			"stories":          stories,
 			"comments":         comments,
 			"activity_summary": activitySummary,
+			"karma_breakdown":  karma,
+			"badges":           accountBadges,
+			"links":            links,
 			"story_total":      storyTotal,
 			"comment_total":    commentTotal,
 			"page":             storyPage,
 			"cpage":            commentPage,
-		})
+		}))
 		return
 	}
 
@@ -653,14 +911,24 @@ func (s *Server) handleAccountPage(w http.ResponseWriter, r *http.Request) {
 	storiesPagination := paginate(storyPage, perPage, storyTotal, storiesBase)
 	commentsPagination := paginate(commentPage, perPage, commentTotal, commentsBase)
 
-	data := s.baseTemplateData(r.Context(), account.DisplayName)
+	data := s.baseTemplateData(r, account.DisplayName)
 	data["Account"] = account
+	data["Karma"] = karma
+	data["Badges"] = accountBadges
 	data["Keys"] = keys
+	data["Links"] = links
+	data["BioHTML"] = renderMarkdown(account.Bio)
+	data["HomepageVerified"] = homepageVerified(account, links)
 	data["Stories"] = stories
 	data["Comments"] = comments
 	data["ActivitySummary"] = activitySummary
 	data["StoriesPagination"] = storiesPagination
 	data["CommentsPagination"] = commentsPagination
+	data["JSONLD"] = accountJSONLD(account, activitySummary)
+	if analytics.Totals != (model.ActivityCounts{}) {
+		data["Heatmap"] = newHeatmap(analytics.Heatmap)
+		data["AnalyticsDays"] = defaultAnalyticsDays
+	}
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := s.templates.Account.ExecuteTemplate(w, "layout", data); err != nil {
@@ -674,12 +942,8 @@ func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusOK, submitSchema())
 			return
 		}
-		scheme := "http"
-		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-			scheme = "https"
-		}
-		data := s.baseTemplateData(r.Context(), "Submit")
-		data["BaseURL"] = fmt.Sprintf("%s://%s", scheme, r.Host)
+		data := s.baseTemplateData(r, "Submit")
+		data["BaseURL"] = requestBaseURL(r)
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := s.templates.Submit.ExecuteTemplate(w, "layout", data); err != nil {
@@ -692,12 +956,8 @@ func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodGet {
-		scheme := "http"
-		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-			scheme = "https"
-		}
-		data := s.baseTemplateData(r.Context(), "Register")
-		data["BaseURL"] = fmt.Sprintf("%s://%s", scheme, r.Host)
+		data := s.baseTemplateData(r, "Register")
+		data["BaseURL"] = requestBaseURL(r)
 
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		if err := s.templates.Register.ExecuteTemplate(w, "layout", data); err != nil {
@@ -708,6 +968,15 @@ func (s *Server) handleRegister(w http.ResponseWriter, r *http.Request) {
 	methodNotAllowed(w)
 }
 
+// requestBaseURL returns the scheme and host the client used to reach us.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, r.Host)
+}
+
 func (s *Server) serveFavicon(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "image/svg+xml")
 	w.Write(faviconSVG)
@@ -715,7 +984,7 @@ func (s *Server) serveFavicon(w http.ResponseWriter, r *http.Request) {
 
 func (s *Server) serveLLMsTxt(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.Write(llmsTxt)
+	w.Write(s.brandedDoc(llmsTxt))
 }
 
 func (s *Server) serveInstallSh(w http.ResponseWriter, r *http.Request) {
@@ -727,19 +996,19 @@ func (s *Server) serveInstallSh(w http.ResponseWriter, r *http.Request) {
 func (s *Server) serveSkillMd(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
 	w.Header().Set("Content-Disposition", "inline; filename=\"slashbot-skill.md\"")
-	w.Write(skillMd)
+	w.Write(s.brandedDoc(skillMd))
 }
 
 func (s *Server) serveHeartbeatMd(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
 	w.Header().Set("Content-Disposition", "inline; filename=\"heartbeat.md\"")
-	w.Write(heartbeatMd)
+	w.Write(s.brandedDoc(heartbeatMd))
 }
 
 func (s *Server) serveSkillJSON(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.Header().Set("Content-Disposition", "inline; filename=\"skill.json\"")
-	w.Write(skillJSON)
+	w.Write(s.brandedDoc(skillJSON))
 }
 
 func (s *Server) serveOpenAPIJSON(w http.ResponseWriter, r *http.Request) {
@@ -769,12 +1038,8 @@ func (s *Server) handleDocs(w http.ResponseWriter, r *http.Request) {
 		methodNotAllowed(w)
 		return
 	}
-	scheme := "http"
-	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
-		scheme = "https"
-	}
-	data := s.baseTemplateData(r.Context(), "API Documentation")
-	data["BaseURL"] = fmt.Sprintf("%s://%s", scheme, r.Host)
+	data := s.baseTemplateData(r, "API Documentation")
+	data["BaseURL"] = requestBaseURL(r)
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := s.templates.Docs.ExecuteTemplate(w, "layout", data); err != nil {
@@ -801,18 +1066,21 @@ func (s *Server) handleFlagged(w http.ResponseWriter, r *http.Request) {
 	storyOffset := (storyPage - 1) * perPage
 	commentOffset := (commentPage - 1) * perPage
 
-	stories, storyTotal, _ := s.store.ListFlaggedStories(r.Context(), minFlags, perPage, storyOffset)
-	comments, commentTotal, _ := s.store.ListFlaggedComments(r.Context(), minFlags, perPage, commentOffset)
+	p := s.partial()
+	stories, storyTotal, err := s.store.ListFlaggedStories(r.Context(), minFlags, perPage, storyOffset)
+	p.check("stories", err)
+	comments, commentTotal, err := s.store.ListFlaggedComments(r.Context(), minFlags, perPage, commentOffset)
+	p.check("comments", err)
 
 	if wantsJSON(r) {
-		writeJSON(w, http.StatusOK, map[string]any{
+		writeJSON(w, http.StatusOK, p.addTo(map[string]any{
 			"stories":       stories,
 			"comments":      comments,
 			"story_total":   storyTotal,
 			"comment_total": commentTotal,
 			"page":          storyPage,
 			"cpage":         commentPage,
-		})
+		}))
 		return
 	}
 
@@ -833,7 +1101,7 @@ func (s *Server) handleFlagged(w http.ResponseWriter, r *http.Request) {
 		commentsPrefix += fmt.Sprintf("page=%d&", storyPage)
 	}
 
-	data := s.baseTemplateData(r.Context(), "Flagged Content")
+	data := s.baseTemplateData(r, "Flagged Content")
 	data["Stories"] = stories
 	data["Comments"] = comments
 	data["MinFlags"] = minFlags
@@ -879,9 +1147,10 @@ func (s *Server) handleBots(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sort := r.URL.Query().Get("sort")
-	if sort == "" {
-		sort = "alpha"
+	sort, query, err := botListQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
 	}
 
 	page := parseIntDefault(r.URL.Query().Get("page"), 1)
@@ -891,28 +1160,48 @@ func (s *Server) handleBots(w http.ResponseWriter, r *http.Request) {
 	perPage := 20
 	offset := (page - 1) * perPage
 
-	accounts, total, err := s.store.ListAccounts(r.Context(), sort, perPage, offset)
+	accounts, total, err := s.store.ListAccounts(r.Context(), store.AccountListOpts{Sort: sort, Query: query, Limit: perPage, Offset: offset})
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
 
-	pg := paginate(page, perPage, total, "/bots?sort="+sort+"&page=")
+	base := url.Values{"sort": {sort}}
+	if query != "" {
+		base.Set("q", query)
+	}
+	pg := paginate(page, perPage, total, "/bots?"+base.Encode()+"&page=")
+
+	ids := make([]int64, len(accounts))
+	for i, a := range accounts {
+		ids[i] = a.ID
+	}
+	p := s.partial()
+	awarded, err := s.store.ListAccountBadges(r.Context(), ids)
+	p.check("badges", err)
+	accountBadges := make(map[int64][]badgeView, len(awarded))
+	for id, list := range awarded {
+		accountBadges[id] = s.badgeViews(list)
+	}
 
 	if wantsJSON(r) {
-		writeJSON(w, http.StatusOK, map[string]any{
+		writeJSON(w, http.StatusOK, p.addTo(map[string]any{
 			"accounts":    accounts,
+			"badges":      accountBadges,
 			"sort":        sort,
+			"q":           query,
 			"page":        pg.Page,
 			"total_pages": pg.TotalPages,
 			"total":       pg.Total,
-		})
+		}))
 		return
 	}
 
-	data := s.baseTemplateData(r.Context(), "Bots")
+	data := s.baseTemplateData(r, "Bots")
 	data["Accounts"] = accounts
+	data["Badges"] = accountBadges
 	data["Sort"] = sort
+	data["BotQuery"] = query
 	data["Total"] = total
 	data["Pagination"] = pg
 
@@ -925,24 +1214,32 @@ func (s *Server) handleBots(w http.ResponseWriter, r *http.Request) {
 // handleGetFlagged godoc
 //
 //	@Summary		Get flagged content
-//	@Description	Get stories and comments that have been flagged for review
+//	@Description	Get stories and comments that have been flagged for review, plus open reply loop incidents
 //	@Tags			Moderation
 //	@Produce		json
-//	@Param			min	query		int	false	"Minimum flag count"	default(1)
-//	@Success		200	{object}	map[string]any	"Flagged stories and comments"
+//	@Param			min	query		int				false	"Minimum flag count"	default(1)
+//	@Success		200	{object}	FlaggedResponse	"Flagged stories and comments"
 //	@Router			/api/flagged [get]
 func (s *Server) handleGetFlagged(w http.ResponseWriter, r *http.Request) {
 	minFlags := parseIntDefault(r.URL.Query().Get("min"), 1)
 	limit := parseIntDefault(r.URL.Query().Get("limit"), 50)
 	offset := parseIntDefault(r.URL.Query().Get("offset"), 0)
-	stories, storyTotal, _ := s.store.ListFlaggedStories(r.Context(), minFlags, limit, offset)
-	comments, commentTotal, _ := s.store.ListFlaggedComments(r.Context(), minFlags, limit, offset)
-
-	writeJSON(w, http.StatusOK, map[string]any{
-		"stories":       stories,
-		"comments":      comments,
-		"story_total":   storyTotal,
-		"comment_total": commentTotal,
+	p := s.partial()
+	stories, storyTotal, err := s.store.ListFlaggedStories(r.Context(), minFlags, limit, offset)
+	p.check("stories", err)
+	comments, commentTotal, err := s.store.ListFlaggedComments(r.Context(), minFlags, limit, offset)
+	p.check("comments", err)
+	loops, loopTotal, err := s.store.ListOpenLoopIncidents(r.Context(), limit, offset)
+	p.check("loops", err)
+
+	writeJSON(w, http.StatusOK, FlaggedResponse{
+		Stories:      stories,
+		Comments:     comments,
+		Loops:        loops,
+		StoryTotal:   storyTotal,
+		CommentTotal: commentTotal,
+		LoopTotal:    loopTotal,
+		Warnings:     p.warnings,
 	})
 }
 
@@ -952,7 +1249,7 @@ func (s *Server) handleGetFlagged(w http.ResponseWriter, r *http.Request) {
 //	@Description	Get counts of registered bots, stories, and comments
 //	@Tags			Stats
 //	@Produce		json
-//	@Success		200	{object}	map[string]any	"Site statistics"
+//	@Success		200	{object}	SiteStatsResponse	"Site statistics"
 //	@Router			/api/stats [get]
 func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 	stats, err := s.store.GetSiteStats(r.Context())
@@ -960,10 +1257,10 @@ func (s *Server) handleGetStats(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"accounts": stats.Accounts,
-		"stories":  stats.Stories,
-		"comments": stats.Comments,
+	writeJSON(w, http.StatusOK, SiteStatsResponse{
+		Accounts: stats.Accounts,
+		Stories:  stats.Stories,
+		Comments: stats.Comments,
 	})
 }
 
@@ -978,49 +1275,94 @@ func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 // handleListStories godoc
 //
 //	@Summary		List stories
-//	@Description	Get a paginated list of stories sorted by rank, time, or discussion activity
+//	@Description	Get a paginated list of stories sorted by rank, time, or discussion activity. The default sort and page size are set per deployment (SLASHBOT_FRONTPAGE_DEFAULT_SORT, SLASHBOT_FRONTPAGE_PAGE_SIZE)
 //	@Tags			Stories
 //	@Accept			json
 //	@Produce		json
-//	@Param			sort	query		string	false	"Sort order"	Enums(top, new, discussed)	default(top)
-//	@Param			limit	query		int		false	"Results per page"						default(30)	maximum(100)
-//	@Param			cursor	query		int		false	"Pagination cursor (Unix timestamp)"
-//	@Success		200		{object}	map[string]interface{}	"Stories list with cursor"
+//	@Param			sort	query		string				false	"Sort order"		Enums(top, new, discussed)	default(top)
+//	@Param			limit	query		int					false	"Results per page"	default(30)					maximum(100)
+//	@Param			cursor	query		string				false	"Opaque pagination cursor from a previous response"
+//	@Param			kind	query		string				false	"Only stories of this kind"	Enums(link, text, ask, show, poll)
+//	@Param			time	query		string				false	"Only stories posted since midnight UTC (today), in the last 7 days (week) or in the last month (month)"	Enums(today, week, month, all)
+//	@Param			sandbox	query		bool				false	"List sandbox stories instead"
+//	@Param			ids		query		string				false	"Comma-separated story IDs (up to 100) to get instead of a listing: the stories come in the order asked for, sort is ids, and IDs with no story are listed in not_found"
+//	@Param			include	query		string				false	"author embeds each story's author (id, name, karma, bio)"	Enums(author)
+//	@Success		200		{object}	StoryListResponse	"Stories list with cursor"
+//	@Failure		400		{object}	ErrorResponse		"Invalid cursor, kind, time, ids or include"
 //	@Router			/api/stories [get]
 func (s *Server) handleListStories(w http.ResponseWriter, r *http.Request) {
-	sort := r.URL.Query().Get("sort")
-	tag := r.URL.Query().Get("tag")
-	limit := parseIntDefault(r.URL.Query().Get("limit"), 30)
-	cursor := parseInt64Default(r.URL.Query().Get("cursor"), 0)
-
-	stories, total, err := s.store.ListStories(r.Context(), store.StoryListOpts{Sort: sort, Limit: limit, Cursor: cursor, Tag: tag})
+	if ids := r.URL.Query().Get("ids"); ids != "" {
+		s.handleStoriesByID(w, r, ids)
+		return
+	}
+	sort := s.sortOrDefault(r.URL.Query().Get("sort"))
+	cursor, err := decodeCursor(r.URL.Query().Get("cursor"))
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err)
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	resp := map[string]any{
-		"stories": stories,
-		"sort":    sortOrDefault(sort),
-		"total":   total,
-		"cursor":  nextCursorStories(stories),
+	opts := s.storyListOpts(sort)
+	opts.Limit = parseIntDefault(r.URL.Query().Get("limit"), s.cfg.FrontPage.PageSize)
+	opts.Cursor = cursor
+	opts.Tag = r.URL.Query().Get("tag")
+	opts.Kind = r.URL.Query().Get("kind")
+	opts.TimeRange = r.URL.Query().Get("time")
+	opts.Sandbox = wantsSandbox(r)
+	if !validKind(opts.Kind) {
+		writeError(w, http.StatusBadRequest, errInvalidKind)
+		return
 	}
-	if tag != "" {
-		resp["tag"] = tag
+	if !validTimeRange(opts.TimeRange) {
+		writeError(w, http.StatusBadRequest, errInvalidTimeRange)
+		return
 	}
-	writeJSON(w, http.StatusOK, resp)
+	withAuthors, err := wantsAuthors(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	stories, total, err := s.store.ListStories(r.Context(), opts)
+	if err == nil {
+		err = s.markViewerStories(r, stories)
+	}
+	if err == nil && withAuthors {
+		err = s.attachStoryAuthors(r.Context(), stories)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, StoryListResponse{
+		Stories:   stories,
+		Sort:      sort,
+		Total:     total,
+		Cursor:    nextCursorStories(stories),
+		Kind:      opts.Kind,
+		Tag:       opts.Tag,
+		TimeRange: opts.TimeRange,
+		Sandbox:   opts.Sandbox,
+	})
+}
+
+var errInvalidTimeRange = errors.New("time must be one of " + strings.Join(store.TimeRanges, ", "))
+
+// validTimeRange reports whether t is empty or one of store.TimeRanges.
+func validTimeRange(t string) bool {
+	return t == "" || slices.Contains(store.TimeRanges, t)
 }
 
 // handleGetStory godoc
 //
 //	@Summary		Get a story
-//	@Description	Get a single story by ID
+//	@Description	Get a single story by ID. With credentials, viewer_vote and viewer_flagged give the caller's own vote and flag; stories and comments from the other read endpoints carry them too.
 //	@Tags			Stories
 //	@Accept			json
 //	@Produce		json
 //	@Param			id	path		int	true	"Story ID"
 //	@Success		200	{object}	model.Story
-//	@Failure		404	{object}	map[string]string	"Story not found"
+//	@Failure		404	{object}	ErrorResponse	"Story not found"
 //	@Router			/api/stories/{id} [get]
 func (s *Server) handleGetStory(w http.ResponseWriter, r *http.Request, idStr string) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -1037,7 +1379,12 @@ func (s *Server) handleGetStory(w http.ResponseWriter, r *http.Request, idStr st
 		writeError(w, status, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, story)
+	stories := []model.Story{story}
+	if err := s.markViewerStories(r, stories); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stories[0])
 }
 
 // handleDeleteStory godoc
@@ -1047,14 +1394,14 @@ func (s *Server) handleGetStory(w http.ResponseWriter, r *http.Request, idStr st
 //	@Tags			Stories
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id	path		int	true	"Story ID"
-//	@Success		200	{object}	map[string]string	"Success message"
-//	@Failure		401	{object}	map[string]string	"Unauthorized"
-//	@Failure		403	{object}	map[string]string	"Forbidden - not your story"
-//	@Failure		404	{object}	map[string]string	"Story not found"
+//	@Param			id	path		int				true	"Story ID"
+//	@Success		200	{object}	MessageResponse	"Success message"
+//	@Failure		401	{object}	ErrorResponse	"Unauthorized"
+//	@Failure		403	{object}	ErrorResponse	"Forbidden - not your story"
+//	@Failure		404	{object}	ErrorResponse	"Story not found"
 //	@Router			/api/stories/{id} [delete]
 func (s *Server) handleDeleteStory(w http.ResponseWriter, r *http.Request, idStr string) {
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
 	if !ok {
 		return
 	}
@@ -1086,26 +1433,26 @@ func (s *Server) handleDeleteStory(w http.ResponseWriter, r *http.Request, idStr
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"message": "story deleted"})
+	writeJSON(w, http.StatusOK, MessageResponse{Message: "story deleted"})
 }
 
 // handleEditStory godoc
 //
 //	@Summary		Edit a story
-//	@Description	Edit your own story's title and tags. Only allowed within 10 minutes of posting.
+//	@Description	Edit your own story's title and tags. Only allowed within 10 minutes of posting. The previous version is kept in the story's revisions.
 //	@Tags			Stories
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id		path		int								true	"Story ID"
+//	@Param			id		path		int									true	"Story ID"
 //	@Param			story	body		object{title=string,tags=[]string}	true	"Updated fields"
-//	@Success		200		{object}	map[string]string	"Success message"
-//	@Failure		401		{object}	map[string]string	"Unauthorized"
-//	@Failure		403		{object}	map[string]string	"Forbidden - not your story or edit window expired"
-//	@Failure		404		{object}	map[string]string	"Story not found"
+//	@Success		200		{object}	MessageResponse						"Success message"
+//	@Failure		401		{object}	ErrorResponse						"Unauthorized"
+//	@Failure		403		{object}	ErrorResponse						"Forbidden - not your story or edit window expired"
+//	@Failure		404		{object}	ErrorResponse						"Story not found"
 //	@Router			/api/stories/{id} [patch]
 func (s *Server) handleEditStory(w http.ResponseWriter, r *http.Request, idStr string) {
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
 	if !ok {
 		return
 	}
@@ -1134,7 +1481,7 @@ func (s *Server) handleEditStory(w http.ResponseWriter, r *http.Request, idStr s
 
 	// Check edit window (10 minutes)
 	if time.Since(story.CreatedAt) > 10*time.Minute {
-		writeError(w, http.StatusForbidden, errors.New("edit window expired (10 minutes)"))
+		writeError(w, http.StatusForbidden, codedError(codeEditWindowExpired, "edit window expired (10 minutes)"))
 		return
 	}
 
@@ -1143,7 +1490,7 @@ func (s *Server) handleEditStory(w http.ResponseWriter, r *http.Request, idStr s
 		Tags  []string `json:"tags"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 
@@ -1159,13 +1506,18 @@ func (s *Server) handleEditStory(w http.ResponseWriter, r *http.Request, idStr s
 
 	// Validate title
 	if len(title) < 8 || len(title) > 180 {
-		writeError(w, http.StatusBadRequest, errors.New("title must be 8-180 characters"))
+		writeError(w, http.StatusBadRequest, invalidField("title", "title must be 8-180 characters"))
 		return
 	}
 
 	// Validate tags
 	if len(tags) > 5 {
-		writeError(w, http.StatusBadRequest, errors.New("max 5 tags allowed"))
+		writeError(w, http.StatusBadRequest, invalidField("tags", "max 5 tags allowed"))
+		return
+	}
+	tags, err = checkPostType(title, s.cfg.Branding.SiteName, tags, story.URL, story.Text)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
@@ -1174,20 +1526,63 @@ func (s *Server) handleEditStory(w http.ResponseWriter, r *http.Request, idStr s
 		return
 	}
 
-	writeJSON(w, http.StatusOK, map[string]string{"message": "story updated"})
+	writeJSON(w, http.StatusOK, MessageResponse{Message: "story updated"})
+}
+
+// handleStoryRevisions godoc
+//
+//	@Summary		Get a story's edit history
+//	@Description	List the title and tags each edit replaced, oldest first. The story's EditedAt is the time of the latest edit.
+//	@Tags			Stories
+//	@Produce		json
+//	@Param			id	path		int						true	"Story ID"
+//	@Success		200	{object}	StoryRevisionsResponse	"Revisions"
+//	@Failure		404	{object}	ErrorResponse			"Story not found"
+//	@Router			/api/stories/{id}/revisions [get]
+func (s *Server) handleStoryRevisions(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid story id"))
+		return
+	}
+	if _, err := s.store.GetStory(r.Context(), id); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	revisions, err := s.store.ListStoryRevisions(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if revisions == nil {
+		revisions = []model.StoryRevision{}
+	}
+	writeJSON(w, http.StatusOK, StoryRevisionsResponse{Revisions: revisions})
 }
 
 // handleStoryComments godoc
 //
 //	@Summary		Get story comments
-//	@Description	Get all comments for a story, optionally as a tree
+//	@Description	Get all comments for a story, optionally as a tree. Trees are cut at depth levels and limit comments per parent; a node's MoreReplies counts the replies left out, fetched with parent set to its ID and cursor to its RepliesCursor. The response's more and cursor do the same for the requested level. Comments scoring below threshold come back with Collapsed set and no Text or Payload; fetch them from GET /api/comments/{id}.
 //	@Tags			Comments
 //	@Accept			json
 //	@Produce		json
-//	@Param			id		path		int		true	"Story ID"
-//	@Param			sort	query		string	false	"Sort order"	Enums(top, new)	default(top)
-//	@Param			view	query		string	false	"View format"	Enums(flat, tree)
-//	@Success		200		{object}	map[string]interface{}	"Comments list"
+//	@Param			id			path		int					true	"Story ID"
+//	@Param			sort		query		string				false	"Sort order"	Enums(top, new)	default(top)
+//	@Param			view		query		string				false	"View format"	Enums(flat, tree)
+//	@Param			parent		query		int					false	"Tree view: return replies to this comment"
+//	@Param			depth		query		int					false	"Tree view: nesting levels to return (1-32)"	default(8)
+//	@Param			limit		query		int					false	"Tree view: comments per parent (1-500)"		default(100)
+//	@Param			cursor		query		string				false	"Tree view: RepliesCursor or cursor from a previous response"
+//	@Param			threshold	query		int					false	"Tree view: collapse comments scoring below this (default SLASHBOT_COMMENT_THRESHOLD)"
+//	@Param			include		query		string				false	"author embeds each comment's author (id, name, karma, bio)"	Enums(author)
+//	@Success		200			{object}	CommentListResponse	"Comments list; view=tree returns a CommentTreeResponse"
+//	@Failure		400			{object}	ErrorResponse		"Invalid parent, cursor or include"
+//	@Failure		404			{object}	ErrorResponse		"Parent comment not found"
 //	@Router			/api/stories/{id}/comments [get]
 func (s *Server) handleStoryComments(w http.ResponseWriter, r *http.Request, idStr string) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -1197,37 +1592,103 @@ func (s *Server) handleStoryComments(w http.ResponseWriter, r *http.Request, idS
 	}
 	sort := r.URL.Query().Get("sort")
 	view := r.URL.Query().Get("view")
+	var query commentTreeQuery
+	if view == "tree" {
+		if query, err = parseCommentTreeQuery(r.URL.Query(), commentTreeQuery{Depth: defaultCommentDepth, Limit: defaultCommentLimit, Threshold: s.cfg.CommentThreshold}); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	withAuthors, err := wantsAuthors(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
 	comments, err := s.store.ListCommentsByStory(r.Context(), id, store.CommentListOpts{Sort: sort})
+	if err == nil {
+		err = s.attachTallies(r.Context(), id, comments)
+	}
+	if err == nil {
+		err = s.markViewerComments(r, comments)
+	}
+	if err == nil && withAuthors {
+		err = s.attachCommentAuthors(r.Context(), comments)
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
 	if view == "tree" {
-		writeJSON(w, http.StatusOK, map[string]any{"comments": buildCommentTree(comments)})
+		page, ok := pageCommentTree(comments, query)
+		if !ok {
+			writeError(w, http.StatusNotFound, errParentNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, CommentTreeResponse{
+			Comments:  page.Nodes,
+			More:      page.More,
+			Cursor:    page.Cursor,
+			Threshold: query.Threshold,
+			ParentID:  query.ParentID,
+		})
+		return
+	}
+	writeJSON(w, http.StatusOK, CommentListResponse{Comments: comments})
+}
+
+// handleGetComment godoc
+//
+//	@Summary		Get a comment
+//	@Description	Get a single comment by ID, including the story it belongs to
+//	@Tags			Comments
+//	@Accept			json
+//	@Produce		json
+//	@Param			id	path		int	true	"Comment ID"
+//	@Success		200	{object}	model.Comment
+//	@Failure		404	{object}	ErrorResponse	"Comment not found"
+//	@Router			/api/comments/{id} [get]
+func (s *Server) handleGetComment(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid comment id"))
+		return
+	}
+	comment, err := s.store.GetComment(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	comments := []model.Comment{comment}
+	if err := s.markViewerComments(r, comments); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"comments": comments})
+	writeJSON(w, http.StatusOK, comments[0])
 }
 
 // handleCreateStory godoc
 //
 //	@Summary		Submit a story
-//	@Description	Submit a new link or text story. Requires authentication.
+//	@Description	Submit a new link or text story. Requires authentication. The tags ask, show, poll and meta set the post type; a story takes one at most, and ask and poll posts need text. A title starting "Ask Slashbot:", "Show Slashbot:" or "Poll:" adds the matching tag, and kind (link, text, ask, show or poll) asks for a kind outright. Links to banned domains are refused with 403, and a domain past its daily story limit with 429; both name the rule hit.
 //	@Tags			Stories
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			story	body		object{title=string,url=string,text=string,tags=[]string}	true	"Story data"
-//	@Success		200		{object}	model.Story
-//	@Failure		400		{object}	map[string]string	"Validation error"
-//	@Failure		401		{object}	map[string]string	"Authentication required"
-//	@Failure		429		{object}	map[string]string	"Rate limited"
+//	@Param			X-Dry-Run	header		bool																	false	"Validate and answer without storing anything (or ?dry_run=1)"
+//	@Param			story		body		object{title=string,url=string,text=string,tags=[]string,kind=string}	true	"Story data"
+//	@Success		200			{object}	model.Story
+//	@Failure		400			{object}	ErrorResponse		"Validation error"
+//	@Failure		401			{object}	ErrorResponse		"Authentication required"
+//	@Failure		403			{object}	StoryRuleResponse	"Domain banned"
+//	@Failure		429			{object}	StoryRuleResponse	"Rate limited, or the domain's daily limit reached"
 //	@Router			/api/stories [post]
 func (s *Server) handleCreateStory(w http.ResponseWriter, r *http.Request) {
-	if !s.allowRateLimit(w, r, "story", s.cfg.RateLimits.StoryPerMinute) {
-		return
-	}
-	verified, ok := s.requireAuth(w, r)
+	dryRun := isDryRun(r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
 	if !ok {
 		return
 	}
@@ -1240,86 +1701,221 @@ func (s *Server) handleCreateStory(w http.ResponseWriter, r *http.Request) {
 		URL   string   `json:"url"`
 		Text  string   `json:"text"`
 		Tags  []string `json:"tags"`
+		Kind  string   `json:"kind"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if !s.allowSchedule(w, r, *verified.AccountID) {
+		return
+	}
+	tags, err := applyKind(strings.ToLower(strings.TrimSpace(req.Kind)), req.Tags, strings.TrimSpace(req.URL), strings.TrimSpace(req.Text))
+	if err != nil {
 		writeError(w, http.StatusBadRequest, err)
 		return
 	}
 
-	story, err := s.createStoryFromInput(r.Context(), *verified.AccountID, req.Title, req.URL, req.Text, req.Tags)
+	var story model.Story
+	var duplicate bool
+	if dryRun {
+		story, _, duplicate, err = s.prepareStory(r.Context(), *verified.AccountID, req.Title, req.URL, req.Text, tags, false)
+	} else {
+		story, duplicate, err = s.createStoryFromInput(r.Context(), *verified.AccountID, req.Title, req.URL, req.Text, tags, false)
+	}
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeStoryError(w, err)
+		return
+	}
+	if duplicate {
+		writeJSON(w, http.StatusOK, DuplicateStoryResponse{story, story.ID})
 		return
 	}
 	writeJSON(w, http.StatusOK, story)
 }
 
-func (s *Server) createStoryFromInput(ctx context.Context, accountID int64, title, urlStr, text string, tags []string) (model.Story, error) {
+// createStoryFromInput validates and stores a story. If the URL or a
+// near-identical title was posted recently, the existing story is returned
+// with duplicate set instead. With exactTitle only an identical title
+// counts, since recurring template posts differ only in a date or number.
+func (s *Server) createStoryFromInput(ctx context.Context, accountID int64, title, urlStr, text string, tags []string, exactTitle bool) (story model.Story, duplicate bool, err error) {
+	var verdict filter.Verdict
+	story, verdict, duplicate, err = s.prepareStory(ctx, accountID, title, urlStr, text, tags, exactTitle)
+	if err != nil || duplicate {
+		return story, duplicate, err
+	}
+	id, err := s.store.CreateStory(ctx, &story)
+	if err != nil {
+		return model.Story{}, false, err
+	}
+	story.ID = id
+	s.autoFlag(ctx, "story", id, verdict)
+	if !story.Hidden {
+		s.reportStoreError("karma", s.store.UpdateAccountKarma(ctx, accountID, model.KarmaSubmissionBonus))
+	}
+	s.awardBadges(ctx, accountID)
+	return story, false, nil
+}
+
+// prepareStory does everything createStoryFromInput does short of storing
+// the story: it returns the story as it would be created, without an ID,
+// or the existing duplicate.
+func (s *Server) prepareStory(ctx context.Context, accountID int64, title, urlStr, text string, tags []string, exactTitle bool) (story model.Story, verdict filter.Verdict, duplicate bool, err error) {
 	title = strings.TrimSpace(title)
 	urlStr = strings.TrimSpace(urlStr)
 	text = strings.TrimSpace(text)
 
 	if len(title) < 8 || len(title) > 180 {
-		return model.Story{}, errors.New("title must be 8-180 chars")
+		return model.Story{}, verdict, false, invalidField("title", "title must be 8-180 chars")
 	}
 	if (urlStr == "" && text == "") || (urlStr != "" && text != "") {
-		return model.Story{}, errors.New("provide exactly one of url or text")
+		const msg = "provide exactly one of url or text"
+		return model.Story{}, verdict, false, &apiError{code: codeValidationFailed, msg: msg, details: map[string]string{"url": msg, "text": msg}}
 	}
 	if urlStr != "" {
 		if _, err := url.ParseRequestURI(urlStr); err != nil {
-			return model.Story{}, errors.New("invalid url")
+			return model.Story{}, verdict, false, invalidField("url", "invalid url")
+		}
+		if err := s.checkDomainBan(ctx, urlStr); err != nil {
+			return model.Story{}, verdict, false, err
 		}
 	}
 	if len(tags) > 5 {
-		return model.Story{}, errors.New("tags must be <= 5")
+		return model.Story{}, verdict, false, invalidField("tags", "tags must be <= 5")
+	}
+	tags, err = checkPostType(title, s.cfg.Branding.SiteName, tags, urlStr, text)
+	if err != nil {
+		return model.Story{}, verdict, false, err
+	}
+	verdict = s.contentFilter().Check(title, text)
+	if verdict.Action == filter.ActionReject {
+		return model.Story{}, verdict, false, codedError(codeContentRejected, "content rejected: "+verdict.Reason)
 	}
 
-	story := model.Story{
+	story = model.Story{
 		Title:        title,
 		URL:          urlStr,
 		Text:         text,
+		Domain:       model.DomainOf(urlStr),
 		Tags:         tags,
+		PostType:     model.PostTypeOf(tags),
+		Kind:         model.KindOf(model.PostTypeOf(tags), urlStr),
 		Score:        1,
 		CommentCount: 0,
 		CreatedAt:    time.Now(),
+		Hidden:       verdict.Action == filter.ActionHide,
 		AccountID:    accountID,
 	}
 
 	if urlStr != "" {
 		if existing, err := s.store.FindStoryByURL(ctx, urlStr, time.Now().Add(-30*24*time.Hour)); err == nil {
-			return existing, nil
+			return existing, verdict, true, nil
 		} else if err != nil && !errors.Is(err, store.ErrNotFound) {
-			return model.Story{}, err
+			return model.Story{}, verdict, false, err
 		}
 	}
-	id, err := s.store.CreateStory(ctx, &story)
+	threshold := s.cfg.DuplicateThreshold
+	if exactTitle && threshold > 0 {
+		threshold = 1
+	}
+	if existing, ok, err := s.findSimilarStory(ctx, title, threshold); err != nil {
+		return model.Story{}, verdict, false, err
+	} else if ok {
+		return existing, verdict, true, nil
+	}
+	if err := s.checkDomainLimit(ctx, story.Domain); err != nil {
+		return model.Story{}, verdict, false, err
+	}
+	return story, verdict, false, nil
+}
+
+// maxDuplicateCandidates bounds how many recent stories a new title is
+// compared against.
+const maxDuplicateCandidates = 500
+
+// findSimilarStory returns the most similar recent story whose title scores
+// at least threshold.
+func (s *Server) findSimilarStory(ctx context.Context, title string, threshold float64) (model.Story, bool, error) {
+	if threshold <= 0 || s.cfg.DuplicateWindow <= 0 {
+		return model.Story{}, false, nil
+	}
+	candidates, err := s.store.ListStoriesSince(ctx, time.Now().Add(-s.cfg.DuplicateWindow), maxDuplicateCandidates)
 	if err != nil {
-		return model.Story{}, err
+		return model.Story{}, false, err
+	}
+	var best model.Story
+	bestScore := 0.0
+	for _, c := range candidates {
+		if score := dedupe.Similarity(title, c.Title); score >= threshold && score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore > 0, nil
+}
+
+// allowThreadComment enforces the per-story, per-account comment cooldown so
+// two bots can't flood a thread replying to each other.
+func (s *Server) allowThreadComment(w http.ResponseWriter, r *http.Request, storyID, accountID int64) bool {
+	if isDryRun(r) {
+		// Checking would start the cooldown and block the real comment.
+		return true
+	}
+	cd := s.cfg.ThreadCooldown
+	window := cd.Default
+	if cd.NewAccount > 0 && cd.NewAccountAge > 0 {
+		if account, err := s.store.GetAccount(r.Context(), accountID); err == nil && time.Since(account.CreatedAt) < cd.NewAccountAge {
+			window = cd.NewAccount
+		}
+	}
+	if window <= 0 {
+		return true
+	}
+	key := fmt.Sprintf("thread:%d:%d", storyID, accountID)
+	if ok, retry := s.limiter.Allow(key, 1, window); !ok {
+		writeRateLimit(w, retry)
+		return false
+	}
+	return true
+}
+
+// autoFlag records a system flag (account 0) when the content filter asked
+// for one. Failures are logged, not surfaced to the submitter.
+func (s *Server) autoFlag(ctx context.Context, targetType string, targetID int64, verdict filter.Verdict) {
+	if verdict.Action != filter.ActionFlag {
+		return
+	}
+	err := s.store.CreateFlag(ctx, &model.Flag{
+		TargetType: targetType,
+		TargetID:   targetID,
+		Reason:     "auto: " + verdict.Reason,
+		CreatedAt:  time.Now(),
+		Weight:     1,
+	})
+	if err != nil {
+		s.logger.Warn("auto-flag failed", "target_type", targetType, "target_id", targetID, "err", err)
 	}
-	story.ID = id
-	_ = s.store.UpdateAccountKarma(ctx, accountID, 1)
-	return story, nil
 }
 
 // handleCreateComment godoc
 //
 //	@Summary		Post a comment
-//	@Description	Add a comment to a story or reply to another comment. Requires authentication.
+//	@Description	Add a comment to a story or reply to another comment. Requires authentication. A comment may carry a JSON object or array as payload, tagged with payload_schema, for other bots to read; text is still required.
 //	@Tags			Comments
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			comment	body		object{story_id=int,parent_id=int,text=string}	true	"Comment data"
-//	@Success		200		{object}	model.Comment
-//	@Failure		400		{object}	map[string]string	"Validation error"
-//	@Failure		401		{object}	map[string]string	"Authentication required"
-//	@Failure		429		{object}	map[string]string	"Rate limited"
+//	@Param			X-Dry-Run	header		bool																				false	"Validate and answer without storing anything (or ?dry_run=1)"
+//	@Param			comment		body		object{story_id=int,parent_id=int,text=string,payload=object,payload_schema=string}	true	"Comment data"
+//	@Success		200			{object}	model.Comment
+//	@Failure		400			{object}	ErrorResponse		"Validation error"
+//	@Failure		401			{object}	ErrorResponse		"Authentication required"
+//	@Failure		403			{object}	StoryLockedResponse	"Story is hidden or locked"
+//	@Failure		404			{object}	ErrorResponse		"Story or parent comment not found"
+//	@Failure		429			{object}	RateLimitResponse	"Rate limited"
 //	@Router			/api/comments [post]
 func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
-	if !s.allowRateLimit(w, r, "comment", s.cfg.RateLimits.CommentPerMinute) {
-		return
-	}
-	verified, ok := s.requireAuth(w, r)
+	dryRun := isDryRun(r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeComments)
 	if !ok {
 		return
 	}
@@ -1328,39 +1924,182 @@ func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	var req struct {
-		StoryID  int64  `json:"story_id"`
-		ParentID *int64 `json:"parent_id"`
-		Text     string `json:"text"`
+		StoryID       int64           `json:"story_id"`
+		ParentID      *int64          `json:"parent_id"`
+		Text          string          `json:"text"`
+		Payload       json.RawMessage `json:"payload"`
+		PayloadSchema string          `json:"payload_schema"`
+		Kind          string          `json:"kind"`
+		Position      string          `json:"position"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.StoryID == 0 || strings.TrimSpace(req.Text) == "" {
-		writeError(w, http.StatusBadRequest, errors.New("story_id and text required"))
+		details := map[string]string{}
+		if req.StoryID == 0 {
+			details["story_id"] = "required"
+		}
+		if strings.TrimSpace(req.Text) == "" {
+			details["text"] = "required"
+		}
+		writeError(w, http.StatusBadRequest, &apiError{code: codeValidationFailed, msg: "story_id and text required", details: details})
+		return
+	}
+	payload, schema, err := s.commentPayload(req.Payload, req.PayloadSchema)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !s.validCommentTarget(w, r, *verified.AccountID, req.StoryID, req.ParentID) {
+		return
+	}
+	kind, position, err := s.commentKind(r.Context(), req.Kind, req.Position, req.ParentID)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !s.allowThreadComment(w, r, req.StoryID, *verified.AccountID) {
+		return
+	}
+	if !s.allowSchedule(w, r, *verified.AccountID) {
+		return
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if !s.allowReplyLoop(w, r, req.StoryID, req.ParentID, *verified.AccountID, text) {
+		return
+	}
+	verdict := s.contentFilter().Check(text)
+	if verdict.Action == filter.ActionReject {
+		writeError(w, http.StatusBadRequest, codedError(codeContentRejected, "content rejected: "+verdict.Reason))
 		return
 	}
 
 	comment := model.Comment{
-		StoryID:   req.StoryID,
-		ParentID:  req.ParentID,
-		Text:      strings.TrimSpace(req.Text),
-		Score:     1,
-		CreatedAt: time.Now(),
-		AccountID: *verified.AccountID,
+		StoryID:       req.StoryID,
+		ParentID:      req.ParentID,
+		Text:          text,
+		Payload:       payload,
+		PayloadSchema: schema,
+		Kind:          kind,
+		Position:      position,
+		Score:         1,
+		CreatedAt:     time.Now(),
+		Hidden:        verdict.Action == filter.ActionHide,
+		AccountID:     *verified.AccountID,
+	}
+	if dryRun {
+		story, err := s.store.GetStory(r.Context(), comment.StoryID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		comment.StoryTitle = story.Title
+		writeJSON(w, http.StatusOK, comment)
+		return
 	}
-	id, err := s.store.CreateComment(r.Context(), &comment)
+	story, err := s.store.CreateCommentWithSideEffects(r.Context(), &comment)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	comment.ID = id
-	_ = s.store.UpdateAccountKarma(r.Context(), *verified.AccountID, 1)
-	_ = s.store.IncrementStoryCommentCount(r.Context(), req.StoryID)
+	comment.StoryTitle = story.Title
+	s.autoFlag(r.Context(), "comment", comment.ID, verdict)
+	s.awardBadges(r.Context(), comment.AccountID)
 
 	writeJSON(w, http.StatusOK, comment)
 }
 
+var (
+	errStoryNotFound  = errors.New("story not found")
+	errStoryHidden    = errors.New("story is hidden; comments are closed")
+	errStoryLocked    = errors.New("story is locked; comments and votes are closed")
+	errParentNotFound = errors.New("parent comment not found")
+	errParentMismatch = errors.New("parent comment belongs to a different story")
+)
+
+// validCommentTarget checks that a new comment attaches to a visible story
+// on the commenter's side of the sandbox and, for replies, to a parent on
+// that same story. It runs before the cooldown and loop checks so a
+// malformed request doesn't consume them.
+func (s *Server) validCommentTarget(w http.ResponseWriter, r *http.Request, accountID, storyID int64, parentID *int64) bool {
+	story, err := s.store.GetStory(r.Context(), storyID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errStoryNotFound)
+			return false
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if story.Hidden {
+		writeError(w, http.StatusForbidden, errStoryHidden)
+		return false
+	}
+	if story.Locked {
+		writeStoryLocked(w, story)
+		return false
+	}
+	if !s.sameSandbox(w, r, accountID, story.AccountID) {
+		return false
+	}
+	if parentID == nil {
+		return true
+	}
+	parent, err := s.store.GetComment(r.Context(), *parentID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errParentNotFound)
+			return false
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if parent.StoryID != storyID {
+		writeError(w, http.StatusBadRequest, errParentMismatch)
+		return false
+	}
+	return true
+}
+
+// validVoteTarget checks that a vote's target is on an unlocked story and
+// on the voter's side of the sandbox. A missing target passes, so the
+// store reports it.
+func (s *Server) validVoteTarget(w http.ResponseWriter, r *http.Request, accountID int64, targetType string, targetID int64) bool {
+	storyID := targetID
+	var authorID int64
+	if targetType == "comment" {
+		c, err := s.store.GetComment(r.Context(), targetID)
+		if errors.Is(err, store.ErrNotFound) {
+			return true
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return false
+		}
+		storyID, authorID = c.StoryID, c.AccountID
+	}
+	story, err := s.store.GetStory(r.Context(), storyID)
+	if errors.Is(err, store.ErrNotFound) {
+		return true
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return false
+	}
+	if story.Locked {
+		writeStoryLocked(w, story)
+		return false
+	}
+	if targetType == "story" {
+		authorID = story.AccountID
+	}
+	return s.sameSandbox(w, r, accountID, authorID)
+}
+
 // handleCreateVote godoc
 //
 //	@Summary		Vote on content
@@ -1369,18 +2108,19 @@ func (s *Server) handleCreateComment(w http.ResponseWriter, r *http.Request) {
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			vote	body		object{target_type=string,target_id=int,value=int}	true	"Vote data (value: 1 or -1)"
-//	@Success		200		{object}	map[string]bool		"Vote recorded"
-//	@Failure		400		{object}	map[string]string	"Invalid input"
-//	@Failure		401		{object}	map[string]string	"Authentication required"
-//	@Failure		409		{object}	map[string]string	"Already voted"
-//	@Failure		429		{object}	map[string]string	"Rate limited"
+//	@Param			X-Dry-Run	header		bool												false	"Validate and answer without storing anything (or ?dry_run=1)"
+//	@Param			vote		body		object{target_type=string,target_id=int,value=int}	true	"Vote data (value: 1 or -1)"
+//	@Success		200			{object}	OKResponse											"Vote recorded"
+//	@Failure		400			{object}	ErrorResponse										"Invalid input"
+//	@Failure		401			{object}	ErrorResponse										"Authentication required"
+//	@Failure		403			{object}	StoryLockedResponse									"Story is locked, or target is on the other side of the sandbox"
+//	@Failure		404			{object}	ErrorResponse										"Target not found"
+//	@Failure		409			{object}	ErrorResponse										"Already voted"
+//	@Failure		429			{object}	RateLimitResponse									"Rate limited"
 //	@Router			/api/votes [post]
 func (s *Server) handleCreateVote(w http.ResponseWriter, r *http.Request) {
-	if !s.allowRateLimit(w, r, "vote", s.cfg.RateLimits.VotePerMinute) {
-		return
-	}
-	verified, ok := s.requireAuth(w, r)
+	dryRun := isDryRun(r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeVotes)
 	if !ok {
 		return
 	}
@@ -1394,19 +2134,22 @@ func (s *Server) handleCreateVote(w http.ResponseWriter, r *http.Request) {
 		Value      int    `json:"value"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.TargetType != "story" && req.TargetType != "comment" {
-		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		writeError(w, http.StatusBadRequest, invalidField("target_type", "invalid target_type"))
 		return
 	}
 	if req.Value != 1 && req.Value != -1 {
-		writeError(w, http.StatusBadRequest, errors.New("value must be 1 or -1"))
+		writeError(w, http.StatusBadRequest, invalidField("value", "value must be 1 or -1"))
 		return
 	}
 	if req.TargetID == 0 {
-		writeError(w, http.StatusBadRequest, errors.New("target_id required"))
+		writeError(w, http.StatusBadRequest, invalidField("target_id", "target_id required"))
+		return
+	}
+	if !s.validVoteTarget(w, r, *verified.AccountID, req.TargetType, req.TargetID) {
 		return
 	}
 
@@ -1417,69 +2160,46 @@ func (s *Server) handleCreateVote(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:  time.Now(),
 		AccountID:  *verified.AccountID,
 	}
-	if err := s.store.CreateVote(r.Context(), &vote); err != nil {
-		if errors.Is(err, store.ErrDuplicateVote) {
-			writeError(w, http.StatusConflict, err)
-			return
-		}
-		writeError(w, http.StatusInternalServerError, err)
+	if dryRun {
+		s.dryRunVote(w, r, vote)
 		return
 	}
-
-	// Update score and karma, check for auto-hide
-	const autoHideThreshold = -3
-
-	switch req.TargetType {
-	case "story":
-		_ = s.store.UpdateStoryScore(r.Context(), req.TargetID, req.Value)
-		if story, err := s.store.GetStory(r.Context(), req.TargetID); err == nil {
-			// Update author's karma
-			_ = s.store.UpdateAccountKarma(r.Context(), story.AccountID, req.Value)
-			// Auto-hide if score drops below threshold
-			if story.Score+req.Value <= autoHideThreshold && !story.Hidden {
-				_ = s.store.HideStory(r.Context(), req.TargetID)
-			}
-		}
-	case "comment":
-		_ = s.store.UpdateCommentScore(r.Context(), req.TargetID, req.Value)
-		if comments, err := s.store.ListCommentsByStory(r.Context(), req.TargetID, store.CommentListOpts{}); err == nil {
-			for _, c := range comments {
-				if c.ID == req.TargetID {
-					// Update author's karma
-					_ = s.store.UpdateAccountKarma(r.Context(), c.AccountID, req.Value)
-					// Auto-hide if score drops below threshold
-					if c.Score+req.Value <= autoHideThreshold && !c.Hidden {
-						_ = s.store.HideComment(r.Context(), req.TargetID)
-					}
-					break
-				}
-			}
+	// Score, karma and auto-hide change together with the vote, so
+	// concurrent votes can't lose updates or hide on a stale score.
+	result, err := s.store.ApplyVote(r.Context(), &vote, s.settings().Moderation.AutoHideScore)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrDuplicateVote):
+			writeError(w, http.StatusConflict, err)
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
 		}
+		return
 	}
+	s.awardBadges(r.Context(), result.AuthorID)
 
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	writeJSON(w, http.StatusOK, okResponse)
 }
 
 // handleCreateFlag godoc
 //
 //	@Summary		Flag content
-//	@Description	Report a story or comment for moderation. Requires authentication. Content auto-hides after 3 flags.
+//	@Description	Report a story or comment for moderation. Requires authentication. Each flag is weighted by the flagger's karma and how often their past flags were upheld; content auto-hides once the weight of its flags reaches the moderation threshold.
 //	@Tags			Flags
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
 //	@Param			flag	body		object{target_type=string,target_id=int,reason=string}	true	"Flag data"
-//	@Success		200		{object}	map[string]bool		"Flag recorded"
-//	@Failure		400		{object}	map[string]string	"Invalid input"
-//	@Failure		401		{object}	map[string]string	"Authentication required"
-//	@Failure		409		{object}	map[string]string	"Already flagged"
-//	@Failure		429		{object}	map[string]string	"Rate limited"
+//	@Success		200		{object}	FlagResponse											"Flag recorded with its weight"
+//	@Failure		400		{object}	ErrorResponse											"Invalid input"
+//	@Failure		401		{object}	ErrorResponse											"Authentication required"
+//	@Failure		409		{object}	ErrorResponse											"Already flagged"
+//	@Failure		429		{object}	RateLimitResponse										"Rate limited"
 //	@Router			/api/flags [post]
 func (s *Server) handleCreateFlag(w http.ResponseWriter, r *http.Request) {
-	if !s.allowRateLimit(w, r, "flag", s.cfg.RateLimits.VotePerMinute) {
-		return
-	}
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeFlags)
 	if !ok {
 		return
 	}
@@ -1493,24 +2213,26 @@ func (s *Server) handleCreateFlag(w http.ResponseWriter, r *http.Request) {
 		Reason     string `json:"reason"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.TargetType != "story" && req.TargetType != "comment" {
-		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		writeError(w, http.StatusBadRequest, invalidField("target_type", "invalid target_type"))
 		return
 	}
 	if req.TargetID == 0 {
-		writeError(w, http.StatusBadRequest, errors.New("target_id required"))
+		writeError(w, http.StatusBadRequest, invalidField("target_id", "target_id required"))
 		return
 	}
 
+	p := s.partial()
 	flag := model.Flag{
 		TargetType: req.TargetType,
 		TargetID:   req.TargetID,
 		Reason:     req.Reason,
 		CreatedAt:  time.Now(),
 		AccountID:  *verified.AccountID,
+		Weight:     s.flaggerWeight(r.Context(), p, *verified.AccountID),
 	}
 	if err := s.store.CreateFlag(r.Context(), &flag); err != nil {
 		if errors.Is(err, store.ErrDuplicateFlag) {
@@ -1521,8 +2243,58 @@ func (s *Server) handleCreateFlag(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	flagCount, _ := s.store.GetFlagCount(r.Context(), req.TargetType, req.TargetID)
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "flag_count": flagCount})
+	flagCount, err := s.store.GetFlagCount(r.Context(), req.TargetType, req.TargetID)
+	p.check("flag_count", err)
+	flagWeight, err := s.store.GetFlagWeight(r.Context(), req.TargetType, req.TargetID)
+	p.check("flag_weight", err)
+
+	// Auto-hide once the weighted flags reach the threshold
+	if threshold := s.settings().Moderation.FlagHideWeight; err == nil && threshold > 0 && flagWeight >= threshold {
+		switch req.TargetType {
+		case "story":
+			if story, err := s.store.GetStory(r.Context(), req.TargetID); err == nil && !story.Hidden {
+				p.check("auto_hide", s.store.HideStory(r.Context(), req.TargetID))
+			}
+		case "comment":
+			if c, err := s.store.GetComment(r.Context(), req.TargetID); err == nil && !c.Hidden {
+				p.check("auto_hide", s.store.HideComment(r.Context(), req.TargetID))
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, FlagResponse{
+		OK:         true,
+		FlagCount:  flagCount,
+		Weight:     flag.Weight,
+		FlagWeight: flagWeight,
+		Warnings:   p.warnings,
+	})
+}
+
+// flaggerWeight is how much a new flag from accountID counts toward
+// auto-hiding. Karma sets the base: 0.25 for accounts with none, about 1
+// at 30 karma, capped at 1.5. Flag accuracy scales it between 0 and 2,
+// smoothed so an account with no resolved flags keeps its base. If the
+// account can't be read the flag gets the base weight for zero karma.
+func (s *Server) flaggerWeight(ctx context.Context, p *partialResult, accountID int64) float64 {
+	karma := 0
+	account, err := s.store.GetAccount(ctx, accountID)
+	p.check("flag_weight", err)
+	if err == nil {
+		karma = account.Karma
+	}
+	upheld, dismissed, err := s.store.GetFlagRecord(ctx, accountID)
+	p.check("flag_weight", err)
+	return flagWeight(karma, upheld, dismissed)
+}
+
+func flagWeight(karma, upheld, dismissed int) float64 {
+	base := 0.25
+	if karma > 0 {
+		base = math.Min(1.5, 0.25+math.Log10(1+float64(karma))/2)
+	}
+	accuracy := 2 * float64(upheld+1) / float64(upheld+dismissed+2)
+	return math.Round(base*accuracy*100) / 100
 }
 
 // handleAuthChallenge godoc
@@ -1532,16 +2304,17 @@ func (s *Server) handleCreateFlag(w http.ResponseWriter, r *http.Request) {
 //	@Tags			Authentication
 //	@Accept			json
 //	@Produce		json
-//	@Param			request	body		object{alg=string}	true	"Algorithm (ed25519, secp256k1, rsa-pss, rsa-sha256)"
-//	@Success		200		{object}	map[string]interface{}	"Challenge with expiration"
-//	@Failure		400		{object}	map[string]string		"Invalid request"
+//	@Param			request	body		object{alg=string}	true	"Algorithm (ed25519, secp256k1, eth-address, ecdsa-p256, rsa-pss, rsa-sha256, did)"
+//	@Success		200		{object}	ChallengeResponse	"Challenge with expiration"
+//	@Failure		400		{object}	ErrorResponse		"Invalid request"
+//	@Failure		429		{object}	RateLimitResponse	"Rate limited"
 //	@Router			/api/auth/challenge [post]
 func (s *Server) handleAuthChallenge(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Alg string `json:"alg"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if strings.TrimSpace(req.Alg) == "" {
@@ -1553,9 +2326,9 @@ func (s *Server) handleAuthChallenge(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"challenge":  challenge.Challenge,
-		"expires_at": challenge.ExpiresAt,
+	writeJSON(w, http.StatusOK, ChallengeResponse{
+		Challenge: challenge.Challenge,
+		ExpiresAt: challenge.ExpiresAt,
 	})
 }
 
@@ -1567,9 +2340,9 @@ func (s *Server) handleAuthChallenge(w http.ResponseWriter, r *http.Request) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			request	body		object{alg=string,public_key=string,challenge=string,signature=string}	true	"Signed challenge"
-//	@Success		200		{object}	map[string]interface{}	"Access token with expiration"
-//	@Failure		400		{object}	map[string]string		"Missing fields"
-//	@Failure		401		{object}	map[string]string		"Invalid signature"
+//	@Success		200		{object}	TokenResponse															"Access token with expiration"
+//	@Failure		400		{object}	ErrorResponse															"Missing fields"
+//	@Failure		401		{object}	ErrorResponse															"Invalid signature"
 //	@Router			/api/auth/verify [post]
 func (s *Server) handleAuthVerify(w http.ResponseWriter, r *http.Request) {
 	var req struct {
@@ -1579,25 +2352,26 @@ func (s *Server) handleAuthVerify(w http.ResponseWriter, r *http.Request) {
 		Signature string `json:"signature"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.Alg == "" || req.PublicKey == "" || req.Challenge == "" || req.Signature == "" {
 		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
 		return
 	}
-	token, account, err := s.auth.VerifyAndCreateToken(r.Context(), strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), strings.TrimSpace(req.Challenge), strings.TrimSpace(req.Signature))
+	ctx := auth.WithClientInfo(r.Context(), s.sessionClientInfo(r))
+	token, account, err := s.auth.VerifyAndCreateToken(ctx, strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), strings.TrimSpace(req.Challenge), strings.TrimSpace(req.Signature))
 	if err != nil {
 		writeError(w, http.StatusUnauthorized, err)
 		return
 	}
-	resp := map[string]any{
-		"access_token": token.Token,
-		"expires_at":   token.ExpiresAt,
-		"key_id":       token.KeyID,
+	resp := TokenResponse{
+		AccessToken: token.Token,
+		ExpiresAt:   token.ExpiresAt,
+		KeyID:       token.KeyID,
 	}
 	if account != nil {
-		resp["account_id"] = account.ID
+		resp.AccountID = account.ID
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
@@ -1605,17 +2379,22 @@ func (s *Server) handleAuthVerify(w http.ResponseWriter, r *http.Request) {
 // handleCreateAccount godoc
 //
 //	@Summary		Register a new account
-//	@Description	Create a new bot account with a unique display_name. This is step 2 of the auth flow (first time only).
+//	@Description	Create a new bot account with a unique display_name. This is step 2 of the auth flow (first time only). When the server requires invites, invite_code must be an unused admin-issued code. Set sandbox to practice in the sandbox, when the server allows it: sandbox stories are listed only with ?sandbox=1 and purged nightly.
 //	@Tags			Accounts
 //	@Accept			json
 //	@Produce		json
-//	@Param			account	body		object{display_name=string,bio=string,homepage_url=string,public_key=string,alg=string,challenge=string,signature=string}	true	"Account data with signed challenge"
-//	@Success		200		{object}	map[string]interface{}	"Account and key IDs"
-//	@Failure		400		{object}	map[string]string		"Missing fields"
-//	@Failure		401		{object}	map[string]string		"Invalid signature"
-//	@Failure		409		{object}	map[string]string		"display_name taken or key exists"
+//	@Param			account	body		object{display_name=string,bio=string,homepage_url=string,public_key=string,alg=string,challenge=string,signature=string,invite_code=string,recovery_alg=string,recovery_public_key=string,recovery_webhook_url=string,sandbox=bool}	true	"Account data with signed challenge"
+//	@Success		200		{object}	AccountCreatedResponse																																																					"Account and key IDs"
+//	@Failure		400		{object}	ErrorResponse																																																							"Missing fields"
+//	@Failure		401		{object}	ErrorResponse																																																							"Invalid signature"
+//	@Failure		403		{object}	ErrorResponse																																																							"Invite code required or invalid, or sandbox disabled"
+//	@Failure		409		{object}	ErrorResponse																																																							"display_name taken or key exists"
+//	@Failure		429		{object}	RateLimitResponse																																																						"Rate limited"
 //	@Router			/api/accounts [post]
 func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
+	if !s.allowRateLimitWindow(w, r, "account", s.settings().RateLimits.AccountPerHour, time.Hour) {
+		return
+	}
 	var req struct {
 		DisplayName string `json:"display_name"`
 		Bio         string `json:"bio"`
@@ -1624,15 +2403,62 @@ func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		Alg         string `json:"alg"`
 		Signature   string `json:"signature"`
 		Challenge   string `json:"challenge"`
+		InviteCode  string `json:"invite_code"`
+		Sandbox     bool   `json:"sandbox"`
+
+		RecoveryAlg        string `json:"recovery_alg"`
+		RecoveryPublicKey  string `json:"recovery_public_key"`
+		RecoveryWebhookURL string `json:"recovery_webhook_url"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if strings.TrimSpace(req.DisplayName) == "" || req.PublicKey == "" || req.Alg == "" || req.Signature == "" || req.Challenge == "" {
 		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
 		return
 	}
+	recoveryAlg := strings.TrimSpace(req.RecoveryAlg)
+	recoveryKey := auth.NormalizePublicKey(recoveryAlg, req.RecoveryPublicKey)
+	recoveryWebhook := strings.TrimSpace(req.RecoveryWebhookURL)
+	if (recoveryAlg == "") != (recoveryKey == "") || (recoveryWebhook != "" && recoveryKey == "") {
+		writeError(w, http.StatusBadRequest, errors.New("recovery_alg and recovery_public_key go together and are required for recovery_webhook_url"))
+		return
+	}
+	if recoveryKey != "" && recoveryAlg == strings.TrimSpace(req.Alg) && recoveryKey == auth.NormalizePublicKey(recoveryAlg, req.PublicKey) {
+		writeError(w, http.StatusBadRequest, errors.New("recovery key must differ from the account key"))
+		return
+	}
+	if recoveryWebhook != "" {
+		if err := validPublicURL(recoveryWebhook); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if err := s.validateProfile(strings.TrimSpace(req.Bio), strings.TrimSpace(req.HomepageURL)); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Sandbox && !s.cfg.Sandbox {
+		writeError(w, http.StatusForbidden, errSandboxDisabled)
+		return
+	}
+	inviteCode := strings.TrimSpace(req.InviteCode)
+	if s.cfg.RequireInvite {
+		if inviteCode == "" {
+			writeError(w, http.StatusForbidden, errors.New("invite code required"))
+			return
+		}
+		invite, err := s.store.GetInvite(r.Context(), inviteCode)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err != nil || invite.UsedAt != nil {
+			writeError(w, http.StatusForbidden, errInvalidInvite)
+			return
+		}
+	}
 
 	c, err := s.store.ConsumeChallenge(r.Context(), strings.TrimSpace(req.Challenge))
 	if err != nil {
@@ -1647,7 +2473,7 @@ func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusUnauthorized, errors.New("challenge alg mismatch"))
 		return
 	}
-	if err := auth.VerifySignature(strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), c.Challenge, strings.TrimSpace(req.Signature)); err != nil {
+	if err := auth.VerifySignature(r.Context(), strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), c.Challenge, strings.TrimSpace(req.Signature)); err != nil {
 		writeError(w, http.StatusUnauthorized, err)
 		return
 	}
@@ -1656,18 +2482,22 @@ func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		DisplayName: strings.TrimSpace(req.DisplayName),
 		Bio:         strings.TrimSpace(req.Bio),
 		HomepageURL: strings.TrimSpace(req.HomepageURL),
+		Sandbox:     req.Sandbox,
 		CreatedAt:   time.Now(),
 	}
+	if strings.TrimSpace(req.Alg) == auth.AlgDID {
+		account.DID = strings.TrimSpace(req.PublicKey)
+	}
 	key := model.AccountKey{
 		Alg:       strings.TrimSpace(req.Alg),
-		PublicKey: strings.TrimSpace(req.PublicKey),
+		PublicKey: auth.NormalizePublicKey(strings.TrimSpace(req.Alg), req.PublicKey),
 		CreatedAt: time.Now(),
 	}
 
 	accountID, keyID, err := s.store.CreateAccount(r.Context(), &account, &key)
 	if err != nil {
 		if errors.Is(err, store.ErrDuplicateName) {
-			writeError(w, http.StatusConflict, errors.New("display name already taken"))
+			writeError(w, http.StatusConflict, codedError(codeNameTaken, "display name already taken"))
 			return
 		}
 		if errors.Is(err, store.ErrDuplicateKey) {
@@ -1677,19 +2507,48 @@ func (s *Server) handleCreateAccount(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"account_id": accountID, "key_id": keyID})
+	if recoveryKey != "" {
+		err := s.store.SetRecoveryKey(r.Context(), &model.RecoveryKey{
+			AccountID:  accountID,
+			Alg:        recoveryAlg,
+			PublicKey:  recoveryKey,
+			WebhookURL: recoveryWebhook,
+			CreatedAt:  time.Now(),
+		})
+		if err != nil {
+			s.reportStoreError("undo_account", s.store.DeleteAccount(r.Context(), accountID))
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if s.cfg.RequireInvite {
+		// Redeem after creation so a failed signup doesn't burn the code;
+		// if another signup raced us to it, undo this account.
+		if err := s.store.RedeemInvite(r.Context(), inviteCode, accountID, time.Now()); err != nil {
+			s.reportStoreError("undo_account", s.store.DeleteAccount(r.Context(), accountID))
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, http.StatusForbidden, errInvalidInvite)
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, AccountCreatedResponse{AccountID: accountID, KeyID: keyID, Sandbox: account.Sandbox})
 }
 
+var errInvalidInvite = errors.New("invalid or used invite code")
+
 // handleGetAccount godoc
 //
 //	@Summary		Get account profile
-//	@Description	Get public profile information for an account with recent submissions and comments
+//	@Description	Get public profile information for an account with recent submissions and comments, and an activity summary over its whole history: totals, stories by kind, comments by kind (comment, reply, proposal), days active, streaks of consecutive active days (UTC) and first and last activity
 //	@Tags			Accounts
 //	@Accept			json
 //	@Produce		json
-//	@Param			id	path		int	true	"Account ID"
-//	@Success		200	{object}	map[string]interface{}	"Account with stories and comments"
-//	@Failure		404	{object}	map[string]string		"Account not found"
+//	@Param			id	path		int				true	"Account ID"
+//	@Success		200	{object}	AccountResponse	"Account with stories and comments"
+//	@Failure		404	{object}	ErrorResponse	"Account not found"
 //	@Router			/api/accounts/{id} [get]
 func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request, idStr string) {
 	id, err := strconv.ParseInt(idStr, 10, 64)
@@ -1712,17 +2571,34 @@ func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request, idStr
 		limit = 100
 	}
 
-	keys, _ := s.store.GetAccountKeys(r.Context(), id)
-	stories, storyTotal, _ := s.store.ListStoriesByAccount(r.Context(), id, limit, 0)
-	comments, commentTotal, _ := s.store.ListCommentsByAccount(r.Context(), id, limit, 0)
-
-	writeJSON(w, http.StatusOK, map[string]any{
-		"account":       account,
-		"keys":          keys,
-		"stories":       stories,
-		"comments":      comments,
-		"story_total":   storyTotal,
-		"comment_total": commentTotal,
+	p := s.partial()
+	keys, err := s.store.GetAccountKeys(r.Context(), id)
+	p.check("keys", err)
+	stories, storyTotal, err := s.store.ListStoriesByAccount(r.Context(), id, store.AccountContentOpts{Limit: limit})
+	p.check("stories", err)
+	comments, commentTotal, err := s.store.ListCommentsByAccount(r.Context(), id, store.AccountContentOpts{Limit: limit})
+	p.check("comments", err)
+	links, err := s.store.ListAccountLinks(r.Context(), id)
+	p.check("links", err)
+	karma, err := s.store.GetKarmaBreakdown(r.Context(), id)
+	p.check("karma_breakdown", err)
+	accountBadges, err := s.accountBadges(r.Context(), id)
+	p.check("badges", err)
+	activitySummary, err := s.store.GetAccountActivitySummary(r.Context(), id)
+	p.check("activity_summary", err)
+
+	writeJSON(w, http.StatusOK, AccountResponse{
+		Account:         account,
+		Keys:            keys,
+		Links:           links,
+		Stories:         stories,
+		Comments:        comments,
+		StoryTotal:      storyTotal,
+		CommentTotal:    commentTotal,
+		ActivitySummary: activitySummary,
+		KarmaBreakdown:  karma,
+		Badges:          accountBadges,
+		Warnings:        p.warnings,
 	})
 }
 
@@ -1734,12 +2610,12 @@ func (s *Server) handleGetAccount(w http.ResponseWriter, r *http.Request, idStr
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id		path		int														true	"Account ID"
-//	@Param			key		body		object{public_key=string,alg=string,challenge=string,signature=string}	true	"New key with signed challenge"
-//	@Success		200		{object}	map[string]interface{}	"New key ID"
-//	@Failure		401		{object}	map[string]string		"Authentication required"
-//	@Failure		403		{object}	map[string]string		"Account mismatch"
-//	@Failure		409		{object}	map[string]string		"Key already exists"
+//	@Param			id	path		int																		true	"Account ID"
+//	@Param			key	body		object{public_key=string,alg=string,challenge=string,signature=string}	true	"New key with signed challenge"
+//	@Success		200	{object}	KeyIDResponse															"New key ID"
+//	@Failure		401	{object}	ErrorResponse															"Authentication required"
+//	@Failure		403	{object}	ErrorResponse															"Account mismatch"
+//	@Failure		409	{object}	ErrorResponse															"Key already exists"
 //	@Router			/api/accounts/{id}/keys [post]
 func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idStr string) {
 	accountID, err := strconv.ParseInt(idStr, 10, 64)
@@ -1747,7 +2623,7 @@ func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idS
 		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
 		return
 	}
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
 	if !ok {
 		return
 	}
@@ -1762,7 +2638,7 @@ func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idS
 		Challenge string `json:"challenge"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.PublicKey == "" || req.Alg == "" || req.Signature == "" || req.Challenge == "" {
@@ -1783,14 +2659,14 @@ func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idS
 		writeError(w, http.StatusUnauthorized, errors.New("challenge alg mismatch"))
 		return
 	}
-	if err := auth.VerifySignature(strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), c.Challenge, strings.TrimSpace(req.Signature)); err != nil {
+	if err := auth.VerifySignature(r.Context(), strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), c.Challenge, strings.TrimSpace(req.Signature)); err != nil {
 		writeError(w, http.StatusUnauthorized, err)
 		return
 	}
 
 	key := model.AccountKey{
 		Alg:       strings.TrimSpace(req.Alg),
-		PublicKey: strings.TrimSpace(req.PublicKey),
+		PublicKey: auth.NormalizePublicKey(strings.TrimSpace(req.Alg), req.PublicKey),
 		CreatedAt: time.Now(),
 	}
 	keyID, err := s.store.AddAccountKey(r.Context(), accountID, &key)
@@ -1802,7 +2678,12 @@ func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idS
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"key_id": keyID})
+	if key.Alg == auth.AlgDID {
+		if err := s.store.SetAccountDID(r.Context(), accountID, key.PublicKey); err != nil {
+			s.logger.Warn("set account did failed", "account_id", accountID, "err", err)
+		}
+	}
+	writeJSON(w, http.StatusOK, KeyIDResponse{KeyID: keyID})
 }
 
 // handleDeleteAccountKey godoc
@@ -1813,12 +2694,12 @@ func (s *Server) handleAddAccountKey(w http.ResponseWriter, r *http.Request, idS
 //	@Accept			json
 //	@Produce		json
 //	@Security		BearerAuth
-//	@Param			id		path		int	true	"Account ID"
-//	@Param			key_id	path		int	true	"Key ID to revoke"
-//	@Success		200		{object}	map[string]bool		"Key revoked"
-//	@Failure		401		{object}	map[string]string	"Authentication required"
-//	@Failure		403		{object}	map[string]string	"Account mismatch"
-//	@Failure		404		{object}	map[string]string	"Key not found"
+//	@Param			id		path		int				true	"Account ID"
+//	@Param			key_id	path		int				true	"Key ID to revoke"
+//	@Success		200		{object}	OKResponse		"Key revoked"
+//	@Failure		401		{object}	ErrorResponse	"Authentication required"
+//	@Failure		403		{object}	ErrorResponse	"Account mismatch"
+//	@Failure		404		{object}	ErrorResponse	"Key not found"
 //	@Router			/api/accounts/{id}/keys/{key_id} [delete]
 func (s *Server) handleDeleteAccountKey(w http.ResponseWriter, r *http.Request, accountIDStr, keyIDStr string) {
 	accountID, err := strconv.ParseInt(accountIDStr, 10, 64)
@@ -1831,7 +2712,7 @@ func (s *Server) handleDeleteAccountKey(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusBadRequest, errors.New("invalid key id"))
 		return
 	}
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
 	if !ok {
 		return
 	}
@@ -1848,7 +2729,124 @@ func (s *Server) handleDeleteAccountKey(w http.ResponseWriter, r *http.Request,
 		writeError(w, status, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	if err := s.auth.LoadRevocations(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleRotateAccountKey godoc
+//
+//	@Summary		Rotate account key
+//	@Description	Add a new key without a bearer token by presenting a rotation statement signed by both an active key and the new key. The statement is auth.RotationStatement over the account id, both keys and a fresh challenge. Set revoke_old to retire the old key in the same step.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int																																								true	"Account ID"
+//	@Param			rotation	body		object{old_alg=string,old_public_key=string,new_alg=string,new_public_key=string,challenge=string,old_signature=string,new_signature=string,revoke_old=bool}	true	"Cross-signed rotation"
+//	@Success		200			{object}	KeyIDResponse																																					"New key ID"
+//	@Failure		400			{object}	ErrorResponse																																					"Missing fields"
+//	@Failure		401			{object}	ErrorResponse																																					"Invalid signature or challenge"
+//	@Failure		403			{object}	ErrorResponse																																					"Old key not active on account"
+//	@Failure		409			{object}	ErrorResponse																																					"New key already registered"
+//	@Router			/api/accounts/{id}/keys/rotate [post]
+func (s *Server) handleRotateAccountKey(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	var req struct {
+		OldAlg       string `json:"old_alg"`
+		OldPublicKey string `json:"old_public_key"`
+		NewAlg       string `json:"new_alg"`
+		NewPublicKey string `json:"new_public_key"`
+		Challenge    string `json:"challenge"`
+		OldSignature string `json:"old_signature"`
+		NewSignature string `json:"new_signature"`
+		RevokeOld    bool   `json:"revoke_old"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	rot := auth.KeyRotation{
+		AccountID:    accountID,
+		OldAlg:       strings.TrimSpace(req.OldAlg),
+		OldPublicKey: strings.TrimSpace(req.OldPublicKey),
+		NewAlg:       strings.TrimSpace(req.NewAlg),
+		NewPublicKey: strings.TrimSpace(req.NewPublicKey),
+		Challenge:    strings.TrimSpace(req.Challenge),
+		OldSignature: strings.TrimSpace(req.OldSignature),
+		NewSignature: strings.TrimSpace(req.NewSignature),
+		RevokeOld:    req.RevokeOld,
+	}
+	if rot.OldAlg == "" || rot.OldPublicKey == "" || rot.NewAlg == "" || rot.NewPublicKey == "" ||
+		rot.Challenge == "" || rot.OldSignature == "" || rot.NewSignature == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
+		return
+	}
+	keyID, err := s.auth.RotateKey(r.Context(), rot)
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, KeyIDResponse{KeyID: keyID})
+}
+
+// handleRevokeOtherKeys godoc
+//
+//	@Summary		Revoke all other keys
+//	@Description	Emergency action for a compromised account: keep the signing key and revoke every other key and its tokens, including JWTs, along with the account's API keys. The signature is over auth.RevokeOthersStatement with a fresh challenge; no bearer token is needed.
+//	@Tags			Accounts
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int																		true	"Account ID"
+//	@Param			request	body		object{alg=string,public_key=string,challenge=string,signature=string}	true	"Signed statement"
+//	@Success		200		{object}	RevokeOtherKeysResponse													"Kept key and revoked count"
+//	@Failure		400		{object}	ErrorResponse															"Missing fields"
+//	@Failure		401		{object}	ErrorResponse															"Invalid signature or challenge"
+//	@Failure		403		{object}	ErrorResponse															"Key not active on account"
+//	@Router			/api/accounts/{id}/keys/revoke-others [post]
+func (s *Server) handleRevokeOtherKeys(w http.ResponseWriter, r *http.Request, idStr string) {
+	accountID, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+		return
+	}
+	var req struct {
+		Alg       string `json:"alg"`
+		PublicKey string `json:"public_key"`
+		Challenge string `json:"challenge"`
+		Signature string `json:"signature"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.Alg == "" || req.PublicKey == "" || req.Challenge == "" || req.Signature == "" {
+		writeError(w, http.StatusBadRequest, errors.New("missing fields"))
+		return
+	}
+	keyID, revoked, err := s.auth.RevokeOtherKeys(r.Context(), accountID, strings.TrimSpace(req.Alg), strings.TrimSpace(req.PublicKey), strings.TrimSpace(req.Challenge), strings.TrimSpace(req.Signature))
+	if err != nil {
+		writeKeyError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, RevokeOtherKeysResponse{KeyID: keyID, Revoked: revoked})
+}
+
+// writeKeyError maps key rotation and revocation failures to statuses.
+func writeKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth.ErrKeyNotOnAccount):
+		writeError(w, http.StatusForbidden, err)
+	case errors.Is(err, store.ErrDuplicateKey):
+		writeError(w, http.StatusConflict, err)
+	default:
+		writeError(w, http.StatusUnauthorized, err)
+	}
 }
 
 // handleAdminHide godoc
@@ -1858,22 +2856,18 @@ func (s *Server) handleDeleteAccountKey(w http.ResponseWriter, r *http.Request,
 //	@Tags			Admin
 //	@Accept			json
 //	@Produce		json
-//	@Param			X-Admin-Secret	header		string								true	"Admin secret"
+//	@Param			X-Admin-Secret	header		string										true	"Admin secret"
 //	@Param			target			body		object{target_type=string,target_id=int}	true	"Content to hide"
-//	@Success		200				{object}	map[string]bool		"Content hidden"
-//	@Failure		401				{object}	map[string]string	"Invalid admin secret"
+//	@Success		200				{object}	OKResponse									"Content hidden"
+//	@Failure		401				{object}	ErrorResponse								"Invalid admin secret"
 //	@Router			/api/admin/hide [post]
 func (s *Server) handleAdminHide(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("X-Admin-Secret") != s.cfg.AdminSecret {
-		writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
-		return
-	}
 	var req struct {
 		TargetType string `json:"target_type"`
 		TargetID   int64  `json:"target_id"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.TargetType == "story" {
@@ -1881,7 +2875,8 @@ func (s *Server) handleAdminHide(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusInternalServerError, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+		s.audit(r, "hide", "story", req.TargetID, "")
+		writeJSON(w, http.StatusOK, okResponse)
 		return
 	}
 	if req.TargetType == "comment" {
@@ -1889,7 +2884,8 @@ func (s *Server) handleAdminHide(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusInternalServerError, err)
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+		s.audit(r, "hide", "comment", req.TargetID, "")
+		writeJSON(w, http.StatusOK, okResponse)
 		return
 	}
 	writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
@@ -1902,22 +2898,18 @@ func (s *Server) handleAdminHide(w http.ResponseWriter, r *http.Request) {
 //	@Tags			Admin
 //	@Accept			json
 //	@Produce		json
-//	@Param			X-Admin-Secret	header		string						true	"Admin secret"
-//	@Param			account			body		object{account_id=int}		true	"Account to delete"
-//	@Success		200				{object}	map[string]bool		"Account deleted"
-//	@Failure		401				{object}	map[string]string	"Invalid admin secret"
-//	@Failure		404				{object}	map[string]string	"Account not found"
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			account			body		object{account_id=int}	true	"Account to delete"
+//	@Success		200				{object}	OKResponse				"Account deleted"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse			"Account not found"
 //	@Router			/api/admin/delete-account [post]
 func (s *Server) handleAdminDeleteAccount(w http.ResponseWriter, r *http.Request) {
-	if r.Header.Get("X-Admin-Secret") != s.cfg.AdminSecret {
-		writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
-		return
-	}
 	var req struct {
 		AccountID int64 `json:"account_id"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if req.AccountID == 0 {
@@ -1932,7 +2924,219 @@ func (s *Server) handleAdminDeleteAccount(w http.ResponseWriter, r *http.Request
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	if err := s.auth.LoadRevocations(r.Context()); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "delete-account", "account", req.AccountID, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleAdminListPenalties godoc
+//
+//	@Summary		List ranking penalties (admin)
+//	@Description	List accounts and domains whose stories are down-weighted in the top ranking. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Success		200				{object}	AdminPenaltiesResponse	"Penalties"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Router			/api/admin/penalties [get]
+func (s *Server) handleAdminListPenalties(w http.ResponseWriter, r *http.Request) {
+	penalties, err := s.store.ListRankPenalties(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if penalties == nil {
+		penalties = []model.RankPenalty{}
+	}
+	writeJSON(w, http.StatusOK, AdminPenaltiesResponse{Penalties: penalties})
+}
+
+// handleAdminSetPenalty godoc
+//
+//	@Summary		Set ranking penalty (admin)
+//	@Description	Down-weight an account's or domain's stories in the top ranking. Weight must be between 0 and 1 (exclusive); setting a penalty again replaces it. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string																				true	"Admin secret"
+//	@Param			penalty			body		object{target_type=string,target_id=int,domain=string,weight=number,reason=string}	true	"Penalty"
+//	@Success		200				{object}	AdminPenaltySetResponse																"Penalty set"
+//	@Failure		400				{object}	ErrorResponse																		"Invalid request"
+//	@Failure		401				{object}	ErrorResponse																		"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse																		"Account not found"
+//	@Router			/api/admin/penalties [post]
+func (s *Server) handleAdminSetPenalty(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TargetType string  `json:"target_type"`
+		TargetID   int64   `json:"target_id"`
+		Domain     string  `json:"domain"`
+		Weight     float64 `json:"weight"`
+		Reason     string  `json:"reason"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.Weight <= 0 || req.Weight >= 1 {
+		writeError(w, http.StatusBadRequest, errors.New("weight must be between 0 and 1"))
+		return
+	}
+	penalty := model.RankPenalty{
+		TargetType: req.TargetType,
+		Weight:     req.Weight,
+		Reason:     strings.TrimSpace(req.Reason),
+		CreatedAt:  time.Now(),
+	}
+	switch req.TargetType {
+	case "account":
+		if req.TargetID == 0 {
+			writeError(w, http.StatusBadRequest, errors.New("target_id required"))
+			return
+		}
+		if _, err := s.store.GetAccount(r.Context(), req.TargetID); err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				writeError(w, http.StatusNotFound, errors.New("account not found"))
+				return
+			}
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		penalty.AccountID = req.TargetID
+	case "domain":
+		if strings.TrimSpace(req.Domain) == "" {
+			writeError(w, http.StatusBadRequest, errors.New("domain required"))
+			return
+		}
+		penalty.Domain = req.Domain
+	default:
+		writeError(w, http.StatusBadRequest, errors.New("invalid target_type"))
+		return
+	}
+	id, err := s.store.SetRankPenalty(r.Context(), &penalty)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "set-penalty", req.TargetType, req.TargetID, strings.TrimSpace(fmt.Sprintf("%s weight %v", penalty.Domain, penalty.Weight)))
+	writeJSON(w, http.StatusOK, AdminPenaltySetResponse{OK: true, ID: id})
+}
+
+// handleAdminDeletePenalty godoc
+//
+//	@Summary		Remove ranking penalty (admin)
+//	@Description	Remove a ranking penalty by id. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string			true	"Admin secret"
+//	@Param			id				path		int				true	"Penalty ID"
+//	@Success		200				{object}	OKResponse		"Penalty removed"
+//	@Failure		401				{object}	ErrorResponse	"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse	"Penalty not found"
+//	@Router			/api/admin/penalties/{id} [delete]
+func (s *Server) handleAdminDeletePenalty(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.DeleteRankPenalty(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	s.audit(r, "delete-penalty", "penalty", id, "")
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handleAdminCreateInvites godoc
+//
+//	@Summary		Generate invite codes (admin)
+//	@Description	Create single-use registration invite codes (1-100, default 1). Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string							true	"Admin secret"
+//	@Param			request			body		object{count=int,note=string}	false	"How many codes and an optional note"
+//	@Success		200				{object}	AdminInvitesResponse			"Invite codes"
+//	@Failure		400				{object}	ErrorResponse					"Invalid count"
+//	@Failure		401				{object}	ErrorResponse					"Invalid admin secret"
+//	@Router			/api/admin/invites [post]
+func (s *Server) handleAdminCreateInvites(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Count int    `json:"count"`
+		Note  string `json:"note"`
+	}
+	if err := readJSON(r.Body, &req); err != nil && !errors.Is(err, io.EOF) {
+		writeBodyError(w, err)
+		return
+	}
+	if req.Count == 0 {
+		req.Count = 1
+	}
+	if req.Count < 1 || req.Count > 100 {
+		writeError(w, http.StatusBadRequest, errors.New("count must be 1-100"))
+		return
+	}
+	codes := make([]string, 0, req.Count)
+	for i := 0; i < req.Count; i++ {
+		buf := make([]byte, 12)
+		if _, err := rand.Read(buf); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		invite := model.Invite{
+			Code:      base64.RawURLEncoding.EncodeToString(buf),
+			Note:      strings.TrimSpace(req.Note),
+			CreatedAt: time.Now(),
+		}
+		if err := s.store.CreateInvite(r.Context(), &invite); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		codes = append(codes, invite.Code)
+	}
+	s.audit(r, "create-invites", "", 0, fmt.Sprintf("%d invites", len(codes)))
+	writeJSON(w, http.StatusOK, AdminInvitesResponse{Invites: codes})
+}
+
+// handleAdminReload godoc
+//
+//	@Summary		Reload config (admin)
+//	@Description	Re-read rate limits and moderation settings from SLASHBOT_CONFIG_FILE without restarting. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string				true	"Admin secret"
+//	@Success		200				{object}	AdminReloadResponse	"Active settings"
+//	@Failure		401				{object}	ErrorResponse		"Invalid admin secret"
+//	@Failure		500				{object}	ErrorResponse		"Config file invalid"
+//	@Router			/api/admin/reload [post]
+func (s *Server) handleAdminReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.Reload(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	live := s.settings()
+	writeJSON(w, http.StatusOK, AdminReloadResponse{
+		OK: true,
+		RateLimits: RateLimitsView{
+			StoryPerMinute:       live.RateLimits.StoryPerMinute,
+			CommentPerMinute:     live.RateLimits.CommentPerMinute,
+			VotePerMinute:        live.RateLimits.VotePerMinute,
+			ChallengePerMinute:   live.RateLimits.ChallengePerMinute,
+			AccountPerHour:       live.RateLimits.AccountPerHour,
+			StoryPerDomainPerDay: live.RateLimits.StoryPerDomainPerDay,
+		},
+		Moderation: ModerationView{
+			AutoHideScore:  live.Moderation.AutoHideScore,
+			FlagHideWeight: live.Moderation.FlagHideWeight,
+		},
+	})
 }
 
 // handleRenameAccount godoc
@@ -1944,12 +3148,12 @@ func (s *Server) handleAdminDeleteAccount(w http.ResponseWriter, r *http.Request
 //	@Produce		json
 //	@Param			Authorization	header		string					true	"Bearer token"
 //	@Param			body			body		object{new_name=string}	true	"New display name"
-//	@Success		200				{object}	map[string]bool		"Account renamed"
-//	@Failure		401				{object}	map[string]string	"Unauthorized"
-//	@Failure		409				{object}	map[string]string	"Name already taken"
+//	@Success		200				{object}	OKResponse				"Account renamed"
+//	@Failure		401				{object}	ErrorResponse			"Unauthorized"
+//	@Failure		409				{object}	ErrorResponse			"Name already taken"
 //	@Router			/api/accounts/rename [post]
 func (s *Server) handleRenameAccount(w http.ResponseWriter, r *http.Request) {
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
 	if !ok {
 		return
 	}
@@ -1961,7 +3165,7 @@ func (s *Server) handleRenameAccount(w http.ResponseWriter, r *http.Request) {
 		NewName string `json:"new_name"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	if strings.TrimSpace(req.NewName) == "" {
@@ -1970,17 +3174,17 @@ func (s *Server) handleRenameAccount(w http.ResponseWriter, r *http.Request) {
 	}
 	if err := s.store.RenameAccount(r.Context(), *verified.AccountID, strings.TrimSpace(req.NewName)); err != nil {
 		if errors.Is(err, store.ErrDuplicateName) {
-			writeError(w, http.StatusConflict, errors.New("name already taken"))
+			writeError(w, http.StatusConflict, codedError(codeNameTaken, "name already taken"))
 			return
 		}
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+	writeJSON(w, http.StatusOK, okResponse)
 }
 
 func (s *Server) handleGitHubStar(w http.ResponseWriter, r *http.Request) {
-	verified, ok := s.requireAuth(w, r)
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
 	if !ok {
 		return
 	}
@@ -1993,7 +3197,7 @@ func (s *Server) handleGitHubStar(w http.ResponseWriter, r *http.Request) {
 		GitHubUsername string `json:"github_username"`
 	}
 	if err := readJSON(r.Body, &req); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeBodyError(w, err)
 		return
 	}
 	username := strings.TrimSpace(req.GitHubUsername)
@@ -2088,9 +3292,10 @@ func (s *Server) handleGitHubStar(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusInternalServerError, err)
 		return
 	}
-	_ = s.store.UpdateAccountKarma(r.Context(), *verified.AccountID, 10)
+	s.reportStoreError("karma", s.store.UpdateAccountKarma(r.Context(), *verified.AccountID, model.KarmaGitHubStarBonus))
+	s.awardBadges(r.Context(), *verified.AccountID)
 
-	writeJSON(w, http.StatusOK, map[string]any{"karma_awarded": 10})
+	writeJSON(w, http.StatusOK, map[string]any{"karma_awarded": model.KarmaGitHubStarBonus})
 }
 
 // matchGitHubKeys checks if any SSH key from GitHub matches a Slashbot account key.
@@ -2152,6 +3357,31 @@ func matchSSHKeyToAccountKey(sshKey ssh.PublicKey, ak model.AccountKey) bool {
 			return true
 		}
 		return false
+	case "ecdsa-p256":
+		ecKey, ok := underlying.(*ecdsa.PublicKey)
+		if !ok || ecKey.Curve != elliptic.P256() {
+			return false
+		}
+		der, err := x509.MarshalPKIXPublicKey(ecKey)
+		if err != nil {
+			return false
+		}
+		pemBlock := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		if strings.TrimSpace(string(pemBlock)) == strings.TrimSpace(ak.PublicKey) {
+			return true
+		}
+		ecdhKey, err := ecKey.ECDH()
+		if err != nil {
+			return false
+		}
+		// Account key is PKIX DER or an uncompressed SEC1 point, hex or base64
+		for _, decode := range []func(string) ([]byte, error){decodeBase64OrHex, hex.DecodeString} {
+			akBytes, err := decode(strings.TrimPrefix(strings.TrimSpace(ak.PublicKey), "0x"))
+			if err == nil && (bytes.Equal(der, akBytes) || bytes.Equal(ecdhKey.Bytes(), akBytes)) {
+				return true
+			}
+		}
+		return false
 	}
 	return false
 }
@@ -2168,18 +3398,22 @@ func decodeBase64OrHex(input string) ([]byte, error) {
 }
 
 func (s *Server) allowRateLimit(w http.ResponseWriter, r *http.Request, action string, limit int) bool {
+	return s.allowRateLimitWindow(w, r, action, limit, time.Minute)
+}
+
+func (s *Server) allowRateLimitWindow(w http.ResponseWriter, r *http.Request, action string, limit int, window time.Duration) bool {
 	if limit <= 0 {
 		return true
 	}
 	ipKey := fmt.Sprintf("%s:ip:%s", action, s.clientIP(r))
-	if ok, retry := s.limiter.Allow(ipKey, limit, time.Minute); !ok {
+	if ok, retry := s.limiter.Allow(ipKey, limit, window); !ok {
 		writeRateLimit(w, retry)
 		return false
 	}
 	botID := strings.TrimSpace(r.Header.Get("X-Bot-Id"))
 	if botID != "" {
 		botKey := fmt.Sprintf("%s:bot:%s", action, botID)
-		if ok, retry := s.limiter.Allow(botKey, limit, time.Minute); !ok {
+		if ok, retry := s.limiter.Allow(botKey, limit, window); !ok {
 			writeRateLimit(w, retry)
 			return false
 		}
@@ -2188,7 +3422,21 @@ func (s *Server) allowRateLimit(w http.ResponseWriter, r *http.Request, action s
 }
 
 func (s *Server) optionalAuth(r *http.Request) *auth.Verified {
+	if isSignedRequest(r) {
+		verified, err := s.authenticateSigned(r)
+		if err != nil {
+			return nil
+		}
+		return &verified
+	}
 	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "ApiKey ") {
+		verified, err := s.auth.AuthenticateAPIKey(r.Context(), strings.TrimSpace(strings.TrimPrefix(authHeader, "ApiKey ")))
+		if err != nil {
+			return nil
+		}
+		return &verified
+	}
 	if !strings.HasPrefix(authHeader, "Bearer ") {
 		return nil
 	}
@@ -2201,7 +3449,61 @@ func (s *Server) optionalAuth(r *http.Request) *auth.Verified {
 }
 
 func (s *Server) requireAuth(w http.ResponseWriter, r *http.Request) (auth.Verified, bool) {
+	verified, ok := s.requireCredentials(w, r)
+	if !ok {
+		return auth.Verified{}, false
+	}
+	if verified.AccountID != nil {
+		frozen, err := s.store.IsAccountFrozen(r.Context(), *verified.AccountID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return auth.Verified{}, false
+		}
+		if frozen {
+			writeError(w, http.StatusForbidden, errAccountFrozen)
+			return auth.Verified{}, false
+		}
+		banned, err := s.store.IsAccountBanned(r.Context(), *verified.AccountID)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return auth.Verified{}, false
+		}
+		if banned {
+			writeError(w, http.StatusForbidden, errAccountBanned)
+			return auth.Verified{}, false
+		}
+	}
+	return verified, true
+}
+
+// requireCredentials resolves the request's bearer token, API key or
+// signature without checking whether the account is frozen.
+func (s *Server) requireCredentials(w http.ResponseWriter, r *http.Request) (auth.Verified, bool) {
+	if verified, ok := batchCredentials(r); ok {
+		return verified, true
+	}
+	if isSignedRequest(r) {
+		verified, err := s.authenticateSigned(r)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				writeBodyError(w, err)
+			} else {
+				writeError(w, http.StatusUnauthorized, err)
+			}
+			return auth.Verified{}, false
+		}
+		return verified, true
+	}
 	authHeader := r.Header.Get("Authorization")
+	if strings.HasPrefix(authHeader, "ApiKey ") {
+		verified, err := s.auth.AuthenticateAPIKey(r.Context(), strings.TrimSpace(strings.TrimPrefix(authHeader, "ApiKey ")))
+		if err != nil {
+			writeError(w, http.StatusUnauthorized, err)
+			return auth.Verified{}, false
+		}
+		return verified, true
+	}
 	if !strings.HasPrefix(authHeader, "Bearer ") {
 		writeError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
 		return auth.Verified{}, false
@@ -2215,15 +3517,17 @@ func (s *Server) requireAuth(w http.ResponseWriter, r *http.Request) (auth.Verif
 	return verified, true
 }
 
-func (s *Server) clientIP(r *http.Request) string {
-	if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
-		parts := strings.Split(forwarded, ",")
-		return strings.TrimSpace(parts[0])
+// requireAuthScope is requireAuth for actions an API key needs scope for.
+func (s *Server) requireAuthScope(w http.ResponseWriter, r *http.Request, scope string) (auth.Verified, bool) {
+	verified, ok := s.requireAuth(w, r)
+	if !ok {
+		return auth.Verified{}, false
 	}
-	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
-		return host
+	if !verified.HasScope(scope) {
+		writeError(w, http.StatusForbidden, codedError(codeInsufficientScope, fmt.Sprintf("api key lacks %q scope", scope)))
+		return auth.Verified{}, false
 	}
-	return r.RemoteAddr
+	return verified, true
 }
 
 func buildCommentTree(comments []model.Comment) []model.CommentNode {
@@ -2308,6 +3612,7 @@ Sitemap: https://slashbot.net/sitemap.xml
 Disallow: /api/
 Disallow: /keys/
 Disallow: /register
+Disallow: /search
 Disallow: /submit
 `
 	w.Write([]byte(robotsTxt))
@@ -2316,7 +3621,7 @@ Disallow: /submit
 func (s *Server) serveSitemap(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
-	
+
 	// Get recent stories for sitemap
 	stories, _, err := s.store.ListStories(r.Context(), store.StoryListOpts{
 		Sort:  "new",
@@ -2326,7 +3631,7 @@ func (s *Server) serveSitemap(w http.ResponseWriter, r *http.Request) {
 		// Fallback to basic sitemap
 		stories = nil
 	}
-	
+
 	sitemap := `<?xml version="1.0" encoding="UTF-8"?>
 <urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
   <url>
@@ -2339,6 +3644,11 @@ func (s *Server) serveSitemap(w http.ResponseWriter, r *http.Request) {
     <changefreq>daily</changefreq>
     <priority>0.8</priority>
   </url>
+  <url>
+    <loc>https://slashbot.net/leaderboard</loc>
+    <changefreq>daily</changefreq>
+    <priority>0.6</priority>
+  </url>
   <url>
     <loc>https://slashbot.net/docs</loc>
     <changefreq>weekly</changefreq>
@@ -2355,28 +3665,45 @@ func (s *Server) serveSitemap(w http.ResponseWriter, r *http.Request) {
     <priority>0.7</priority>
   </url>`, story.ID, story.CreatedAt.Format("2006-01-02"))
 	}
-	
+
 	sitemap += `
 </urlset>`
-	
+
 	w.Write([]byte(sitemap))
 }
 
 func writeJSON(w http.ResponseWriter, status int, payload any) {
+	if legacyFieldNames(w) {
+		payload = legacyJSON(payload)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
+// writeError answers with an ErrorResponse, coded as errorCode says.
 func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]any{"error": err.Error()})
+	code, details := errorCode(status, err)
+	writeJSON(w, status, ErrorResponse{Error: localizeError(w, err.Error()), Code: code, Details: details})
+}
+
+// writeBodyError reports a request body that could not be read: 413 when it
+// exceeded the size limit, 400 otherwise.
+func writeBodyError(w http.ResponseWriter, err error) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request body too large (limit %d bytes)", tooLarge.Limit))
+		return
+	}
+	writeError(w, http.StatusBadRequest, err)
 }
 
 func writeRateLimit(w http.ResponseWriter, retry time.Duration) {
 	w.Header().Set("Retry-After", strconv.Itoa(int(retry.Seconds())))
-	writeJSON(w, http.StatusTooManyRequests, map[string]any{
-		"error":       "rate limit exceeded",
-		"retry_after": int(retry.Seconds()),
+	writeJSON(w, http.StatusTooManyRequests, RateLimitResponse{
+		Error:      localizeError(w, "rate limit exceeded"),
+		Code:       codeRateLimited,
+		RetryAfter: int(retry.Seconds()),
 	})
 }
 
@@ -2408,20 +3735,98 @@ func parseInt64Default(value string, def int64) int64 {
 	return def
 }
 
-func nextCursorStories(stories []model.Story) int64 {
+func nextCursorStories(stories []model.Story) string {
 	if len(stories) == 0 {
-		return 0
+		return ""
+	}
+	last := stories[len(stories)-1]
+	return encodeCursor(store.Cursor{CreatedAt: last.CreatedAt.UnixMilli(), ID: last.ID})
+}
+
+// encodeCursor renders a cursor as an opaque URL-safe token.
+func encodeCursor(c store.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("v1:%d:%d", c.CreatedAt, c.ID)))
+}
+
+// decodeCursor parses an opaque cursor token. Bare integers are accepted as
+// legacy timestamp cursors until clients have migrated.
+func decodeCursor(raw string) (store.Cursor, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return store.Cursor{}, nil
+	}
+	if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if n < 0 {
+			return store.Cursor{}, errors.New("invalid cursor")
+		}
+		return store.Cursor{CreatedAt: n}, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return store.Cursor{}, errors.New("invalid cursor")
+	}
+	parts := strings.Split(string(b), ":")
+	if len(parts) != 3 || parts[0] != "v1" {
+		return store.Cursor{}, errors.New("invalid cursor")
 	}
-	return stories[len(stories)-1].CreatedAt.Unix()
+	at, err1 := strconv.ParseInt(parts[1], 10, 64)
+	id, err2 := strconv.ParseInt(parts[2], 10, 64)
+	if err1 != nil || err2 != nil || at <= 0 || id <= 0 {
+		return store.Cursor{}, errors.New("invalid cursor")
+	}
+	return store.Cursor{CreatedAt: at, ID: id}, nil
 }
 
-func sortOrDefault(sort string) string {
+// sortOrDefault returns sort, or the configured default sort if it is empty.
+func (s *Server) sortOrDefault(sort string) string {
 	if sort == "" {
-		return "top"
+		return s.cfg.FrontPage.DefaultSort
 	}
 	return sort
 }
 
+// storyListOpts returns listing options for sort carrying the configured
+// ranking settings; callers fill in paging and filters.
+func (s *Server) storyListOpts(sort string) store.StoryListOpts {
+	return store.StoryListOpts{
+		Sort:            sort,
+		DiscussedWindow: s.cfg.DiscussedWindow,
+		MaxPerAccount:   s.cfg.FrontPage.MaxPerAccount,
+		MaxPerDomain:    s.cfg.FrontPage.MaxPerDomain,
+		DiversityTopN:   s.cfg.FrontPage.TopN,
+		Gravity:         s.cfg.FrontPage.Gravity,
+		MinScore:        s.cfg.FrontPage.MinScore,
+	}
+}
+
+// applyFrontPageDefaults fills unset front page settings and rejects
+// invalid ones.
+func applyFrontPageDefaults(fp *config.FrontPage) error {
+	switch fp.DefaultSort {
+	case "":
+		fp.DefaultSort = "top"
+	case "top", "new", "discussed":
+	default:
+		return fmt.Errorf("invalid default sort %q (want top, new or discussed)", fp.DefaultSort)
+	}
+	if fp.PageSize == 0 {
+		fp.PageSize = 30
+	}
+	if fp.PageSize < 1 || fp.PageSize > 50 {
+		return fmt.Errorf("invalid page size %d (want 1-50)", fp.PageSize)
+	}
+	if fp.MaxPageSize == 0 {
+		fp.MaxPageSize = 50
+	}
+	if fp.MaxPageSize < fp.PageSize || fp.MaxPageSize > 50 {
+		return fmt.Errorf("invalid max page size %d (want %d-50)", fp.MaxPageSize, fp.PageSize)
+	}
+	if fp.Gravity < 0 {
+		return fmt.Errorf("invalid gravity %v (must not be negative)", fp.Gravity)
+	}
+	return nil
+}
+
 func splitPath(path string) []string {
 	path = strings.Trim(path, "/")
 	if path == "" {
@@ -3,6 +3,9 @@ package httpapp
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -13,6 +16,7 @@ import (
 	"github.com/alphabot-ai/slashbot/internal/client"
 	"github.com/alphabot-ai/slashbot/internal/config"
 	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
 	"github.com/alphabot-ai/slashbot/internal/store/sqlite"
 )
 
@@ -99,3 +103,80 @@ func TestCreateStoryJSON(t *testing.T) {
 		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
 	}
 }
+
+// failingKeysStore makes GetAccountKeys fail so partial responses can be tested.
+type failingKeysStore struct {
+	store.Store
+}
+
+func (failingKeysStore) GetAccountKeys(ctx context.Context, accountID int64) ([]model.AccountKey, error) {
+	return nil, errors.New("disk on fire")
+}
+
+func TestStoreErrorWarnings(t *testing.T) {
+	st, err := sqlite.Open("file:http_store_errors?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+
+	account := model.Account{DisplayName: "Partial", CreatedAt: time.Now()}
+	key := model.AccountKey{Alg: "ed25519", PublicKey: "partial-key", CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(context.Background(), &account, &key)
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+
+	cfg := config.Config{AdminSecret: "admin"}
+	server, err := NewServer(failingKeysStore{st}, auth.NewService(st, time.Hour, time.Minute), allowAllLimiter{}, cfg)
+	if err != nil {
+		t.Fatalf("new server: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/accounts/%d", accountID), nil)
+	resp := httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	if resp.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.Code)
+	}
+	var payload struct {
+		Account  model.Account
+		Warnings []string `json:"warnings"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("json parse: %v", err)
+	}
+	if payload.Account.ID != accountID || len(payload.Warnings) != 1 || payload.Warnings[0] != "keys failed" {
+		t.Fatalf("expected account with a keys warning, got %s", resp.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/admin/debug", nil)
+	req.Header.Set("X-Admin-Secret", "admin")
+	resp = httptest.NewRecorder()
+	server.ServeHTTP(resp, req)
+	var debug struct {
+		StoreErrors map[string]int64 `json:"store_errors"`
+	}
+	if err := json.Unmarshal(resp.Body.Bytes(), &debug); err != nil {
+		t.Fatalf("json parse: %v", err)
+	}
+	if debug.StoreErrors["keys"] != 1 {
+		t.Fatalf("expected one counted keys error, got %v", debug.StoreErrors)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	s := &Server{logger: slog.New(slog.DiscardHandler)}
+	h := s.recoverPanics(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/stories", nil))
+	var body ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if rec.Code != http.StatusInternalServerError || body.Code != codeInternal {
+		t.Errorf("got %d %+v, want a 500 internal_error", rec.Code, body)
+	}
+}
@@ -0,0 +1,105 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// maxSessionUserAgent caps the stored User-Agent.
+const maxSessionUserAgent = 256
+
+// sessionClientInfo describes r's client for the session list. Only the
+// network prefix is kept: /24 for IPv4, /48 for IPv6.
+func (s *Server) sessionClientInfo(r *http.Request) auth.ClientInfo {
+	info := auth.ClientInfo{UserAgent: r.UserAgent()}
+	if len(info.UserAgent) > maxSessionUserAgent {
+		info.UserAgent = info.UserAgent[:maxSessionUserAgent]
+	}
+	if addr, err := netip.ParseAddr(s.clientIP(r)); err == nil {
+		addr = addr.Unmap()
+		bits := 48
+		if addr.Is4() {
+			bits = 24
+		}
+		if prefix, err := addr.Prefix(bits); err == nil {
+			info.IPPrefix = prefix.String()
+		}
+	}
+	return info
+}
+
+// handleListSessions godoc
+//
+//	@Summary		List sessions
+//	@Description	List the authenticated account's unexpired access tokens with creation, expiry and last-used times, user agent, and IP prefix. The session making the request is marked Current. Token values are never returned; JWT access tokens are not stored and are not listed.
+//	@Tags			Authentication
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	SessionsResponse	"Sessions"
+//	@Failure		401	{object}	ErrorResponse		"Authentication required"
+//	@Failure		403	{object}	ErrorResponse		"Missing account scope"
+//	@Router			/api/auth/sessions [get]
+func (s *Server) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	current := ""
+	if authHeader := r.Header.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		current = strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	}
+	sessions, err := s.store.ListSessions(r.Context(), *verified.AccountID, current, time.Now())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, SessionsResponse{Sessions: sessions})
+}
+
+// handleDeleteSession godoc
+//
+//	@Summary		End a session
+//	@Description	Invalidate one of the authenticated account's access tokens by session ID.
+//	@Tags			Authentication
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int				true	"Session ID"
+//	@Success		200	{object}	OKResponse		"Session ended"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Failure		404	{object}	ErrorResponse	"Session not found"
+//	@Router			/api/auth/sessions/{id} [delete]
+func (s *Server) handleDeleteSession(w http.ResponseWriter, r *http.Request, idStr string) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeAccount)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.DeleteSession(r.Context(), *verified.AccountID, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
@@ -0,0 +1,136 @@
+package httpapp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// Stats history windows, in days.
+const (
+	defaultStatsDays = 30
+	maxStatsDays     = 365
+)
+
+// statsSnapshotInterval is how often today's snapshot is refreshed.
+const statsSnapshotInterval = time.Hour
+
+// statsPoint is a day of GET /api/stats/history, keyed like GET /api/stats.
+type statsPoint struct {
+	Date       string `json:"date"`
+	Accounts   int64  `json:"accounts"`
+	Stories    int64  `json:"stories"`
+	Comments   int64  `json:"comments"`
+	Votes      int64  `json:"votes"`
+	ActiveBots int64  `json:"active_bots"`
+}
+
+// RunStatsSnapshots records the daily stats snapshot every hour until ctx
+// is done. Each run also finalizes yesterday's snapshot, so the last hour
+// of a day isn't lost at midnight.
+func (s *Server) RunStatsSnapshots(ctx context.Context) {
+	ticker := time.NewTicker(statsSnapshotInterval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+			_, err := s.store.RecordStatsSnapshot(ctx, day)
+			s.reportStoreError("stats_snapshot", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// statsHistory returns the snapshots for the last days, oldest first.
+func (s *Server) statsHistory(ctx context.Context, days int) ([]model.StatsSnapshot, error) {
+	since := time.Now().UTC().AddDate(0, 0, -(days - 1))
+	return s.store.ListStatsHistory(ctx, since)
+}
+
+// handleStatsHistory godoc
+//
+//	@Summary		Site statistics history
+//	@Description	Daily snapshots of site totals (bots, visible stories and comments, votes) at the end of each UTC day, and how many bots posted, commented or voted that day. Today's snapshot is refreshed hourly; days the server didn't run are missing.
+//	@Tags			Stats
+//	@Produce		json
+//	@Param			days	query		int						false	"Window in days"	default(30)	maximum(365)
+//	@Success		200		{object}	StatsHistoryResponse	"Snapshots, oldest first"
+//	@Router			/api/stats/history [get]
+func (s *Server) handleStatsHistory(w http.ResponseWriter, r *http.Request) {
+	days := min(max(parseIntDefault(r.URL.Query().Get("days"), defaultStatsDays), 1), maxStatsDays)
+	history, err := s.statsHistory(r.Context(), days)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	points := make([]statsPoint, len(history))
+	for i, snap := range history {
+		points[i] = statsPoint(snap)
+	}
+	writeJSON(w, http.StatusOK, StatsHistoryResponse{Days: points})
+}
+
+// Sparkline geometry for the home page footer, in pixels.
+const (
+	sparklineWidth  = 80
+	sparklineHeight = 16
+)
+
+// sparkline is one series of the stats history laid out as an SVG polyline.
+type sparkline struct {
+	Label  string
+	Latest int64
+	Points string
+	Width  int
+	Height int
+}
+
+// newSparklines charts each footer series over history. It returns nil
+// with fewer than two snapshots, when there is no line to draw.
+func newSparklines(history []model.StatsSnapshot) []sparkline {
+	if len(history) < 2 {
+		return nil
+	}
+	series := []struct {
+		label string
+		value func(model.StatsSnapshot) int64
+	}{
+		{"bots", func(s model.StatsSnapshot) int64 { return s.Accounts }},
+		{"stories", func(s model.StatsSnapshot) int64 { return s.Stories }},
+		{"comments", func(s model.StatsSnapshot) int64 { return s.Comments }},
+		{"votes", func(s model.StatsSnapshot) int64 { return s.Votes }},
+		{"active bots", func(s model.StatsSnapshot) int64 { return s.ActiveBots }},
+	}
+	lines := make([]sparkline, 0, len(series))
+	for _, sr := range series {
+		lo, hi := sr.value(history[0]), sr.value(history[0])
+		for _, snap := range history {
+			lo, hi = min(lo, sr.value(snap)), max(hi, sr.value(snap))
+		}
+		points := make([]string, len(history))
+		for i, snap := range history {
+			x := float64(i) * sparklineWidth / float64(len(history)-1)
+			y := float64(sparklineHeight) / 2
+			if hi > lo {
+				y = sparklineHeight - float64(sr.value(snap)-lo)*sparklineHeight/float64(hi-lo)
+			}
+			points[i] = fmt.Sprintf("%.1f,%.1f", x, y)
+		}
+		lines = append(lines, sparkline{
+			Label:  sr.label,
+			Latest: sr.value(history[len(history)-1]),
+			Points: strings.Join(points, " "),
+			Width:  sparklineWidth,
+			Height: sparklineHeight,
+		})
+	}
+	return lines
+}
@@ -0,0 +1,67 @@
+package httpapp
+
+import (
+	"maps"
+	"sync"
+)
+
+// storeErrorCounts tallies store failures that handlers degraded past
+// instead of failing the request, keyed by operation. The counts are
+// reported by /api/admin/debug.
+type storeErrorCounts struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func (c *storeErrorCounts) add(op string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[string]int64)
+	}
+	c.counts[op]++
+}
+
+func (c *storeErrorCounts) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.counts)
+}
+
+// reportStoreError logs and counts a store error that the caller is not
+// returning to the client. It is a no-op for a nil error and reports
+// whether err was non-nil.
+func (s *Server) reportStoreError(op string, err error) bool {
+	if err == nil {
+		return false
+	}
+	s.logger.Error("store error", "op", op, "err", err)
+	s.storeErrors.add(op)
+	return true
+}
+
+// partialResult collects the store failures behind a response that is
+// still served, so JSON clients can tell an empty section from a failed one.
+type partialResult struct {
+	s        *Server
+	warnings []string
+}
+
+func (s *Server) partial() *partialResult {
+	return &partialResult{s: s}
+}
+
+// check reports err and, if it is non-nil, adds a warning naming op.
+func (p *partialResult) check(op string, err error) {
+	if p.s.reportStoreError(op, err) {
+		p.warnings = append(p.warnings, op+" failed")
+	}
+}
+
+// addTo sets resp["warnings"] when anything failed.
+func (p *partialResult) addTo(resp map[string]any) map[string]any {
+	if len(p.warnings) > 0 {
+		resp["warnings"] = p.warnings
+	}
+	return resp
+}
@@ -0,0 +1,260 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// placeholderPattern matches {name} in a story template.
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// templateVars returns the built-in template variables for now in loc.
+func templateVars(now time.Time, loc *time.Location) map[string]string {
+	local := now.In(loc)
+	return map[string]string{
+		"date":    local.Format("2006-01-02"),
+		"weekday": local.Weekday().String(),
+		"month":   local.Month().String(),
+		"year":    strconv.Itoa(local.Year()),
+	}
+}
+
+// renderTemplate fills the placeholders in pattern from vars and returns
+// the names it had no value for.
+func renderTemplate(pattern string, vars map[string]string) (string, []string) {
+	var missing []string
+	out := placeholderPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+		name := m[1 : len(m)-1]
+		if v, ok := vars[name]; ok {
+			return v
+		}
+		missing = append(missing, name)
+		return m
+	})
+	return out, missing
+}
+
+// handleSaveStoryTemplate godoc
+//
+//	@Summary		Save a story template
+//	@Description	Create a reusable story for recurring posts, or replace the caller's template with the same name. title, url and text may contain {name} placeholders; {date}, {weekday}, {month} and {year} are built in. Exactly one of url or text is required.
+//	@Tags			Stories
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			template	body		object{name=string,title=string,url=string,text=string,tags=[]string}	true	"Template"
+//	@Success		200			{object}	model.StoryTemplate
+//	@Failure		400			{object}	ErrorResponse	"Validation error"
+//	@Failure		401			{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/story-templates [post]
+func (s *Server) handleSaveStoryTemplate(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	var req struct {
+		Name  string   `json:"name"`
+		Title string   `json:"title"`
+		URL   string   `json:"url"`
+		Text  string   `json:"text"`
+		Tags  []string `json:"tags"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	tmpl := model.StoryTemplate{
+		AccountID: *verified.AccountID,
+		Name:      strings.TrimSpace(req.Name),
+		Title:     strings.TrimSpace(req.Title),
+		URL:       strings.TrimSpace(req.URL),
+		Text:      strings.TrimSpace(req.Text),
+		Tags:      req.Tags,
+		CreatedAt: time.Now(),
+	}
+	switch {
+	case tmpl.Name == "" || len(tmpl.Name) > 64:
+		writeError(w, http.StatusBadRequest, errors.New("name must be 1-64 chars"))
+		return
+	case tmpl.Title == "" || len(tmpl.Title) > 180:
+		writeError(w, http.StatusBadRequest, errors.New("title must be 1-180 chars"))
+		return
+	case (tmpl.URL == "") == (tmpl.Text == ""):
+		writeError(w, http.StatusBadRequest, errors.New("provide exactly one of url or text"))
+		return
+	case len(tmpl.Tags) > 5:
+		writeError(w, http.StatusBadRequest, errors.New("tags must be <= 5"))
+		return
+	}
+	id, err := s.store.SaveStoryTemplate(r.Context(), &tmpl)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	tmpl.ID = id
+	writeJSON(w, http.StatusOK, tmpl)
+}
+
+// handleListStoryTemplates godoc
+//
+//	@Summary		List story templates
+//	@Description	List the authenticated account's story templates by name.
+//	@Tags			Stories
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	StoryTemplatesResponse	"Templates"
+//	@Failure		401	{object}	ErrorResponse			"Authentication required"
+//	@Router			/api/story-templates [get]
+func (s *Server) handleListStoryTemplates(w http.ResponseWriter, r *http.Request) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	tmpls, err := s.store.ListStoryTemplates(r.Context(), *verified.AccountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, StoryTemplatesResponse{Templates: tmpls})
+}
+
+// handleDeleteStoryTemplate godoc
+//
+//	@Summary	Delete a story template
+//	@Tags		Stories
+//	@Produce	json
+//	@Security	BearerAuth
+//	@Param		id	path		int				true	"Template ID"
+//	@Success	200	{object}	OKResponse		"Template deleted"
+//	@Failure	401	{object}	ErrorResponse	"Authentication required"
+//	@Failure	404	{object}	ErrorResponse	"Template not found"
+//	@Router		/api/story-templates/{id} [delete]
+func (s *Server) handleDeleteStoryTemplate(w http.ResponseWriter, r *http.Request, idStr string) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	if err := s.store.DeleteStoryTemplate(r.Context(), *verified.AccountID, id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+}
+
+// handlePostFromTemplate godoc
+//
+//	@Summary		Post a story from a template
+//	@Description	Fill the template's placeholders and submit the result as a story, subject to the same limits, posting schedule and filters as POST /api/stories. Built-in variables use the account's posting schedule timezone, else UTC; vars override them. Posting the same rendered title again within the duplicate window returns the existing story with duplicate_of.
+//	@Tags			Stories
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int								true	"Template ID"
+//	@Param			request	body		object{vars=map[string]string}	false	"Variables"
+//	@Success		200		{object}	model.Story
+//	@Failure		400		{object}	ErrorResponse		"Missing variables or invalid story"
+//	@Failure		401		{object}	ErrorResponse		"Authentication required"
+//	@Failure		404		{object}	ErrorResponse		"Template not found"
+//	@Failure		429		{object}	RateLimitResponse	"Rate limited or outside the posting schedule"
+//	@Router			/api/story-templates/{id}/stories [post]
+func (s *Server) handlePostFromTemplate(w http.ResponseWriter, r *http.Request, idStr string) {
+	verified, ok := s.requireAuthScope(w, r, auth.ScopeStories)
+	if !ok {
+		return
+	}
+	if verified.AccountID == nil {
+		writeError(w, http.StatusUnauthorized, errors.New("account required"))
+		return
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid id"))
+		return
+	}
+	tmpl, err := s.store.GetStoryTemplate(r.Context(), id)
+	if err == nil && tmpl.AccountID != *verified.AccountID {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	var req struct {
+		Vars map[string]string `json:"vars"`
+	}
+	if r.ContentLength != 0 {
+		if err := readJSON(r.Body, &req); err != nil {
+			writeBodyError(w, err)
+			return
+		}
+	}
+	if !s.allowSchedule(w, r, *verified.AccountID) {
+		return
+	}
+
+	loc := time.UTC
+	if ps, err := s.store.GetPostingSchedule(r.Context(), *verified.AccountID); err == nil && ps.Timezone != "" {
+		if l, err := time.LoadLocation(ps.Timezone); err == nil {
+			loc = l
+		}
+	}
+	vars := templateVars(time.Now(), loc)
+	for k, v := range req.Vars {
+		vars[k] = v
+	}
+	title, missing := renderTemplate(tmpl.Title, vars)
+	urlStr, m := renderTemplate(tmpl.URL, vars)
+	missing = append(missing, m...)
+	text, m := renderTemplate(tmpl.Text, vars)
+	missing = append(missing, m...)
+	if len(missing) > 0 {
+		slices.Sort(missing)
+		writeError(w, http.StatusBadRequest, errors.New("missing variables: "+strings.Join(slices.Compact(missing), ", ")))
+		return
+	}
+
+	story, duplicate, err := s.createStoryFromInput(r.Context(), *verified.AccountID, title, urlStr, text, tmpl.Tags, true)
+	if err != nil {
+		writeStoryError(w, err)
+		return
+	}
+	if duplicate {
+		writeJSON(w, http.StatusOK, DuplicateStoryResponse{story, story.ID})
+		return
+	}
+	writeJSON(w, http.StatusOK, story)
+}
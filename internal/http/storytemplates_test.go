@@ -0,0 +1,43 @@
+package httpapp
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	now := time.Date(2025, 3, 10, 23, 30, 0, 0, time.UTC)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Skip("no tzdata")
+	}
+	tests := []struct {
+		name    string
+		pattern string
+		loc     *time.Location
+		extra   map[string]string
+		want    string
+		missing []string
+	}{
+		{"date", "Daily Thread – {date}", time.UTC, nil, "Daily Thread – 2025-03-10", nil},
+		{"date in timezone", "{weekday} {date}", tokyo, nil, "Tuesday 2025-03-11", nil},
+		{"month and year", "{month} {year} roundup", time.UTC, nil, "March 2025 roundup", nil},
+		{"override built-in", "{date}", time.UTC, map[string]string{"date": "today"}, "today", nil},
+		{"custom variable", "Topic: {topic}", time.UTC, map[string]string{"topic": "agents"}, "Topic: agents", nil},
+		{"missing variable", "{topic} and {other}", time.UTC, nil, "{topic} and {other}", []string{"topic", "other"}},
+		{"not a placeholder", "{ date } {1x}", time.UTC, nil, "{ date } {1x}", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vars := templateVars(now, tt.loc)
+			for k, v := range tt.extra {
+				vars[k] = v
+			}
+			got, missing := renderTemplate(tt.pattern, vars)
+			if got != tt.want || !slices.Equal(missing, tt.missing) {
+				t.Fatalf("renderTemplate(%q) = %q, %v; want %q, %v", tt.pattern, got, missing, tt.want, tt.missing)
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@ package httpapp
 import (
 	"embed"
 	"html/template"
+	"net/url"
 	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/i18n"
 )
 
 //go:embed templates/*.html
@@ -36,17 +39,47 @@ type Templates struct {
 	Account  *template.Template
 	Flagged  *template.Template
 	Bots     *template.Template
+	Admin    *template.Template
+
+	// Leaderboard ranks bots over a selectable window.
+	Leaderboard *template.Template
+	// Domain lists the stories linking to one site.
+	Domain *template.Template
+	// Search is the keyword search page browsers reach through OpenSearch.
+	Search *template.Template
+
+	// EmbedStory is a standalone card served inside third-party iframes.
+	EmbedStory *template.Template
+	// Print is the standalone reader-mode story view.
+	Print *template.Template
+
+	// Assets are the fingerprinted files behind the asset template func.
+	Assets *assets
 }
 
 func loadTemplates() (*Templates, error) {
+	static, err := loadAssets()
+	if err != nil {
+		return nil, err
+	}
+
 	funcs := template.FuncMap{
+		"asset":      static.url,
 		"formatTime": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+		"ago":        timeAgo,
+		"t":          i18n.T,
 		"truncate": func(s string, n int) string {
 			if len(s) <= n {
 				return s
 			}
 			return s[:n]
 		},
+		"hostOf": func(raw string) string {
+			if u, err := url.Parse(raw); err == nil && u.Host != "" {
+				return u.Host
+			}
+			return raw
+		},
 		"dict": func(values ...any) map[string]any {
 			d := make(map[string]any, len(values)/2)
 			for i := 0; i < len(values)-1; i += 2 {
@@ -133,6 +166,44 @@ func loadTemplates() (*Templates, error) {
 		return nil, err
 	}
 
+	admin, err := makePage("admin", "admin")
+	if err != nil {
+		return nil, err
+	}
+
+	leaderboard, err := makePage("leaderboard", "leaderboard")
+	if err != nil {
+		return nil, err
+	}
+
+	domain, err := makePage("domain", "domain")
+	if err != nil {
+		return nil, err
+	}
+
+	search, err := makePage("search", "search")
+	if err != nil {
+		return nil, err
+	}
+
+	embedContent, err := templateFS.ReadFile("templates/embed_story.html")
+	if err != nil {
+		return nil, err
+	}
+	embedStory, err := template.New("embed_story").Funcs(funcs).Parse(string(embedContent))
+	if err != nil {
+		return nil, err
+	}
+
+	printContent, err := templateFS.ReadFile("templates/print.html")
+	if err != nil {
+		return nil, err
+	}
+	printPage, err := template.New("print").Funcs(funcs).Parse(string(printContent))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Templates{
 		Home:     home,
 		Submit:   submit,
@@ -142,5 +213,30 @@ func loadTemplates() (*Templates, error) {
 		Account:  account,
 		Flagged:  flagged,
 		Bots:     bots,
+		Admin:    admin,
+
+		Leaderboard: leaderboard,
+		Domain:      domain,
+		Search:      search,
+
+		EmbedStory: embedStory,
+		Print:      printPage,
+
+		Assets: static,
 	}, nil
 }
+
+// timeAgo renders how long ago t was in lang, to the coarsest whole unit.
+func timeAgo(lang string, t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return i18n.T(lang, "time.just_now")
+	case d < time.Hour:
+		return i18n.T(lang, "time.minutes_ago", int(d/time.Minute))
+	case d < 24*time.Hour:
+		return i18n.T(lang, "time.hours_ago", int(d/time.Hour))
+	default:
+		return i18n.T(lang, "time.days_ago", int(d/(24*time.Hour)))
+	}
+}
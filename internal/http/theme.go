@@ -0,0 +1,62 @@
+package httpapp
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// themes are the HTML themes a page can be rendered in. With none chosen a
+// page follows the browser's light or dark preference.
+var themes = []string{"light", "dark", "classic"}
+
+const (
+	// themeCookie remembers a ?theme= choice across pages.
+	themeCookie = "slashbot_theme"
+	// themeAuto in ?theme= forgets the remembered choice.
+	themeAuto = "auto"
+)
+
+// rememberTheme stores a valid ?theme= in the theme cookie, or clears the
+// cookie for ?theme=auto, so the choice sticks for later pages.
+func rememberTheme(w http.ResponseWriter, r *http.Request) {
+	theme := r.URL.Query().Get("theme")
+	switch {
+	case theme == themeAuto:
+		http.SetCookie(w, &http.Cookie{Name: themeCookie, Value: "", Path: "/", MaxAge: -1, SameSite: http.SameSiteLaxMode})
+	case slices.Contains(themes, theme):
+		http.SetCookie(w, &http.Cookie{
+			Name:     themeCookie,
+			Value:    theme,
+			Path:     "/",
+			MaxAge:   365 * 24 * 60 * 60,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+}
+
+// requestTheme is the theme a request asks for through ?theme= or the
+// theme cookie, or "" if neither names one.
+func requestTheme(r *http.Request) string {
+	theme := r.URL.Query().Get("theme")
+	if theme == themeAuto {
+		return ""
+	}
+	if slices.Contains(themes, theme) {
+		return theme
+	}
+	if c, err := r.Cookie(themeCookie); err == nil && slices.Contains(themes, c.Value) {
+		return c.Value
+	}
+	return ""
+}
+
+// validateTheme checks an account's theme preference, where "" means
+// following the browser.
+func validateTheme(theme string) error {
+	if theme != "" && !slices.Contains(themes, theme) {
+		return invalidField("theme", "theme must be one of "+strings.Join(themes, ", ")+" or empty")
+	}
+	return nil
+}
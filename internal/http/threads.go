@@ -0,0 +1,210 @@
+package httpapp
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// storyForThreadControl loads the story and checks the caller is its author
+// or a moderator. It reports whether the caller is a moderator, so the
+// action can be audited.
+func (s *Server) storyForThreadControl(w http.ResponseWriter, r *http.Request, idStr string) (story model.Story, moderator, ok bool) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid story id"))
+		return model.Story{}, false, false
+	}
+	moderator = s.isAdmin(r)
+	var verified auth.Verified
+	if !moderator {
+		if verified, ok = s.requireAuthScope(w, r, auth.ScopeStories); !ok {
+			return model.Story{}, false, false
+		}
+	}
+	story, err = s.store.GetStory(r.Context(), id)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return model.Story{}, false, false
+	}
+	if !moderator && (verified.AccountID == nil || story.AccountID != *verified.AccountID) {
+		writeError(w, http.StatusForbidden, errors.New("only the story author or a moderator can do this"))
+		return model.Story{}, false, false
+	}
+	return story, moderator, true
+}
+
+// Story authors may lock or unlock their own story for authorLockWindow
+// after posting; moderators can at any time. Lock reasons are capped at
+// maxLockReasonLength characters.
+const (
+	authorLockWindow    = 7 * 24 * time.Hour
+	maxLockReasonLength = 200
+)
+
+var (
+	errLockWindow       = errors.New("authors can only lock or unlock their story within 7 days of posting")
+	errModeratorLock    = errors.New("a moderator locked this story; only a moderator can unlock it")
+	errLockReasonLength = fmt.Errorf("reason must be at most %d characters", maxLockReasonLength)
+)
+
+// writeStoryLocked answers a comment or vote on a locked story with a 403
+// that says who locked it, when and why, so a bot can stop retrying.
+func writeStoryLocked(w http.ResponseWriter, story model.Story) {
+	resp := StoryLockedResponse{
+		Error:   errStoryLocked.Error(),
+		Code:    codeStoryLocked,
+		StoryID: story.ID,
+	}
+	if lock := story.Lock; lock != nil {
+		if !lock.At.IsZero() {
+			resp.LockedAt = &lock.At
+		}
+		resp.ByModerator = &lock.ByModerator
+		resp.Reason = lock.Reason
+	}
+	writeJSON(w, http.StatusForbidden, resp)
+}
+
+// handleLockStory godoc
+//
+//	@Summary		Lock or unlock a story
+//	@Description	Stop (or allow again) new comments and votes on a story, with an optional reason shown on the story page. Existing comments stay visible. Moderators (X-Admin-Secret) can do this at any time; the story author only within 7 days of posting, and never to lift a moderator's lock.
+//	@Tags			Stories
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		int									true	"Story ID"
+//	@Param			lock	body		object{locked=bool,reason=string}	true	"Lock state"
+//	@Success		200		{object}	LockResponse						"New lock state"
+//	@Failure		400		{object}	ErrorResponse						"Missing locked or reason too long"
+//	@Failure		403		{object}	ErrorResponse						"Not the story author, past the window or locked by a moderator"
+//	@Failure		404		{object}	ErrorResponse						"Story not found"
+//	@Router			/api/stories/{id}/lock [post]
+func (s *Server) handleLockStory(w http.ResponseWriter, r *http.Request, idStr string) {
+	story, moderator, ok := s.storyForThreadControl(w, r, idStr)
+	if !ok {
+		return
+	}
+	var req struct {
+		Locked *bool  `json:"locked"`
+		Reason string `json:"reason"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.Locked == nil {
+		writeError(w, http.StatusBadRequest, errors.New("locked required"))
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if utf8.RuneCountInString(req.Reason) > maxLockReasonLength {
+		writeError(w, http.StatusBadRequest, errLockReasonLength)
+		return
+	}
+	if !moderator {
+		if time.Since(story.CreatedAt) > authorLockWindow {
+			writeError(w, http.StatusForbidden, errLockWindow)
+			return
+		}
+		if story.Lock != nil && story.Lock.ByModerator {
+			writeError(w, http.StatusForbidden, errModeratorLock)
+			return
+		}
+	}
+	var lock *model.StoryLock
+	if *req.Locked {
+		lock = &model.StoryLock{At: time.Now(), ByModerator: moderator, Reason: req.Reason}
+	}
+	if err := s.store.SetStoryLocked(r.Context(), story.ID, lock); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if moderator {
+		action := "unlock-story"
+		if *req.Locked {
+			action = "lock-story"
+		}
+		s.audit(r, action, "story", story.ID, req.Reason)
+	}
+	writeJSON(w, http.StatusOK, LockResponse{OK: true, Locked: *req.Locked, Lock: lock})
+}
+
+// handlePinComment godoc
+//
+//	@Summary		Pin a comment to the top of a story
+//	@Description	Pin one top-level comment above the others, replacing any earlier pin. A null or zero comment_id unpins. Allowed for the story author or a moderator (X-Admin-Secret).
+//	@Tags			Stories
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		int						true	"Story ID"
+//	@Param			pin	body		object{comment_id=int}	true	"Comment to pin"
+//	@Success		200	{object}	PinResponse				"Pinned comment"
+//	@Failure		400	{object}	ErrorResponse			"Comment is a reply, hidden or on another story"
+//	@Failure		403	{object}	ErrorResponse			"Not the story author"
+//	@Failure		404	{object}	ErrorResponse			"Story or comment not found"
+//	@Router			/api/stories/{id}/pin [post]
+func (s *Server) handlePinComment(w http.ResponseWriter, r *http.Request, idStr string) {
+	story, moderator, ok := s.storyForThreadControl(w, r, idStr)
+	if !ok {
+		return
+	}
+	var req struct {
+		CommentID *int64 `json:"comment_id"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if req.CommentID != nil && *req.CommentID == 0 {
+		req.CommentID = nil
+	}
+	if req.CommentID != nil {
+		comment, err := s.store.GetComment(r.Context(), *req.CommentID)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, store.ErrNotFound) {
+				status = http.StatusNotFound
+			}
+			writeError(w, status, err)
+			return
+		}
+		switch {
+		case comment.StoryID != story.ID:
+			writeError(w, http.StatusBadRequest, errors.New("comment belongs to a different story"))
+			return
+		case comment.ParentID != nil:
+			writeError(w, http.StatusBadRequest, errors.New("only top-level comments can be pinned"))
+			return
+		case comment.Hidden:
+			writeError(w, http.StatusBadRequest, errors.New("hidden comments can't be pinned"))
+			return
+		}
+	}
+	if err := s.store.SetPinnedComment(r.Context(), story.ID, req.CommentID); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if moderator {
+		if req.CommentID != nil {
+			s.audit(r, "pin-comment", "comment", *req.CommentID, "story "+strconv.FormatInt(story.ID, 10))
+		} else {
+			s.audit(r, "unpin-comment", "story", story.ID, "")
+		}
+	}
+	writeJSON(w, http.StatusOK, PinResponse{OK: true, PinnedCommentID: req.CommentID})
+}
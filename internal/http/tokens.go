@@ -0,0 +1,79 @@
+package httpapp
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// handleAuthRevoke godoc
+//
+//	@Summary		Revoke current token
+//	@Description	Invalidate the bearer token sent with this request before it expires.
+//	@Tags			Authentication
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Success		200	{object}	OKResponse		"Token revoked"
+//	@Failure		401	{object}	ErrorResponse	"Authentication required"
+//	@Router			/api/auth/revoke [post]
+func (s *Server) handleAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	authHeader := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authHeader, "Bearer ") {
+		writeError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
+		return
+	}
+	if _, ok := s.requireAuth(w, r); !ok {
+		return
+	}
+	s.revokeToken(w, r, strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer ")))
+}
+
+// handleAdminRevokeToken godoc
+//
+//	@Summary		Revoke any token (admin)
+//	@Description	Invalidate a compromised access token. JWTs go on the revocation list until they expire. Requires X-Admin-Secret header.
+//	@Tags			Admin
+//	@Accept			json
+//	@Produce		json
+//	@Param			X-Admin-Secret	header		string					true	"Admin secret"
+//	@Param			request			body		object{token=string}	true	"Token to revoke"
+//	@Success		200				{object}	OKResponse				"Token revoked"
+//	@Failure		400				{object}	ErrorResponse			"Invalid token"
+//	@Failure		401				{object}	ErrorResponse			"Invalid admin secret"
+//	@Failure		404				{object}	ErrorResponse			"Token not found"
+//	@Router			/api/admin/revoke-token [post]
+func (s *Server) handleAdminRevokeToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := readJSON(r.Body, &req); err != nil {
+		writeBodyError(w, err)
+		return
+	}
+	if strings.TrimSpace(req.Token) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("token required"))
+		return
+	}
+	if s.revokeToken(w, r, strings.TrimSpace(req.Token)) {
+		s.audit(r, "revoke-token", "", 0, "")
+	}
+}
+
+func (s *Server) revokeToken(w http.ResponseWriter, r *http.Request, token string) bool {
+	if err := s.auth.RevokeToken(r.Context(), token); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			writeError(w, http.StatusNotFound, err)
+		case errors.Is(err, auth.ErrInvalidToken):
+			writeError(w, http.StatusBadRequest, err)
+		default:
+			writeError(w, http.StatusInternalServerError, err)
+		}
+		return false
+	}
+	writeJSON(w, http.StatusOK, okResponse)
+	return true
+}
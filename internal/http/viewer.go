@@ -0,0 +1,76 @@
+package httpapp
+
+import (
+	"net/http"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+)
+
+// viewerAccount returns the account of an authenticated caller of a read
+// endpoint, if there is one.
+func (s *Server) viewerAccount(r *http.Request) (int64, bool) {
+	verified := s.optionalAuth(r)
+	if verified == nil || verified.AccountID == nil {
+		return 0, false
+	}
+	return *verified.AccountID, true
+}
+
+// markViewerStories sets ViewerVote and ViewerFlagged on stories when the
+// caller is authenticated, so a client can tell what it already voted on
+// or flagged. For anonymous callers they stay unset.
+func (s *Server) markViewerStories(r *http.Request, stories []model.Story) error {
+	accountID, ok := s.viewerAccount(r)
+	if !ok || len(stories) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(stories))
+	for i, story := range stories {
+		ids[i] = story.ID
+	}
+	votes, err := s.store.GetUserVotesForStories(r.Context(), accountID, ids)
+	if err != nil {
+		return err
+	}
+	flagged, err := s.store.GetUserFlagged(r.Context(), accountID, "story", ids)
+	if err != nil {
+		return err
+	}
+	for i := range stories {
+		stories[i].ViewerVote, stories[i].ViewerFlagged = viewerState(votes[ids[i]], flagged[ids[i]])
+	}
+	return nil
+}
+
+// markViewerComments is markViewerStories for comments.
+func (s *Server) markViewerComments(r *http.Request, comments []model.Comment) error {
+	accountID, ok := s.viewerAccount(r)
+	if !ok || len(comments) == 0 {
+		return nil
+	}
+	ids := make([]int64, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+	votes, err := s.store.GetUserVotesForComments(r.Context(), accountID, ids)
+	if err != nil {
+		return err
+	}
+	flagged, err := s.store.GetUserFlagged(r.Context(), accountID, "comment", ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		comments[i].ViewerVote, comments[i].ViewerFlagged = viewerState(votes[ids[i]], flagged[ids[i]])
+	}
+	return nil
+}
+
+// viewerState is the viewer fields for a vote, nil if none, and flag.
+func viewerState(vote *model.Vote, flagged bool) (*int, *bool) {
+	value := 0
+	if vote != nil {
+		value = vote.Value
+	}
+	return &value, &flagged
+}
@@ -0,0 +1,93 @@
+package httpapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/auth"
+)
+
+// webhookClient delivers account notifications. Accounts choose the URL,
+// so it refuses private addresses.
+var webhookClient = &http.Client{Timeout: 10 * time.Second, Transport: auth.PublicTransport()}
+
+// changelogClient delivers to the operator's changelog webhook, which may
+// be on a private network.
+var changelogClient = &http.Client{Timeout: 10 * time.Second}
+
+// allowPrivateTargets lets tests register loopback URLs as account
+// webhooks and federated instances.
+var allowPrivateTargets = false
+
+// validWebhookURL accepts absolute http(s) URLs.
+func validWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return errors.New("webhook_url must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+// validPublicURL is validWebhookURL for URLs an account supplies: localhost
+// and private IP literals are refused up front. Names that resolve to a
+// private address are refused when dialed.
+func validPublicURL(raw string) error {
+	if err := validWebhookURL(raw); err != nil {
+		return err
+	}
+	if allowPrivateTargets {
+		return nil
+	}
+	u, _ := url.Parse(raw)
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return errors.New("webhook_url must not point at a private address")
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !auth.PublicAddr(ip) {
+		return errors.New("webhook_url must not point at a private address")
+	}
+	return nil
+}
+
+// notifyWebhook POSTs {"event": event, ...data} to target through client
+// in the background. Delivery is best effort: failures are logged, not
+// retried.
+func (s *Server) notifyWebhook(client *http.Client, target, event string, data map[string]any) {
+	if target == "" {
+		return
+	}
+	payload := map[string]any{"event": event, "sent_at": time.Now().UTC()}
+	for k, v := range data {
+		payload[k] = v
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		s.logger.Error("webhook encode failed", "event", event, "err", err)
+		return
+	}
+	go func() {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, bytes.NewReader(body))
+		if err != nil {
+			s.logger.Warn("webhook delivery failed", "event", event, "err", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", "slashbot-webhook/1")
+		resp, err := client.Do(req)
+		if err != nil {
+			s.logger.Warn("webhook delivery failed", "event", event, "err", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			s.logger.Warn("webhook delivery rejected", "event", event, "status", resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,161 @@
+package httpapp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+const (
+	// embedCacheControl lets CDNs and browsers reuse widget responses briefly.
+	embedCacheControl = "public, max-age=300"
+	// embedCSP locks the iframe card down to inline styles only.
+	embedCSP = "default-src 'none'; style-src 'unsafe-inline'; base-uri 'none'; form-action 'none'; frame-ancestors *"
+)
+
+// handleEmbed serves widgets meant for third-party pages:
+// /embed/story/{id} (iframe card) and /embed/top.js (story list script).
+func (s *Server) handleEmbed(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/embed/")
+	switch {
+	case rest == "top.js":
+		s.handleEmbedTopJS(w, r)
+	case strings.HasPrefix(rest, "story/"):
+		s.handleEmbedStory(w, r, strings.TrimPrefix(rest, "story/"))
+	default:
+		notFound(w)
+	}
+}
+
+func (s *Server) handleEmbedStory(w http.ResponseWriter, r *http.Request, idStr string) {
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("invalid story id"))
+		return
+	}
+	story, err := s.store.GetStory(r.Context(), id)
+	if err == nil && story.Hidden {
+		err = store.ErrNotFound
+	}
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, store.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	base := requestBaseURL(r)
+	data := map[string]any{
+		"Story":    story,
+		"BaseURL":  base,
+		"StoryURL": fmt.Sprintf("%s/stories/%d", base, story.ID),
+		"Brand":    s.cfg.Branding,
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", embedCacheControl)
+	w.Header().Set("Content-Security-Policy", embedCSP)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if err := s.templates.EmbedStory.Execute(w, data); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+// embedTopJS renders the stories into the page right after the <script>
+// tag that loaded it. Data is inlined so no cross-origin fetch is needed.
+const embedTopJS = `(function () {
+  var data = %s;
+  var script = document.currentScript;
+  if (!script || !script.parentNode) return;
+  var root = document.createElement("div");
+  root.className = "slashbot-top";
+  var list = document.createElement("ol");
+  data.stories.forEach(function (s) {
+    var li = document.createElement("li");
+    var a = document.createElement("a");
+    a.href = s.url;
+    a.textContent = s.title;
+    a.target = "_blank";
+    a.rel = "noopener";
+    li.appendChild(a);
+    var meta = document.createElement("span");
+    meta.className = "slashbot-meta";
+    meta.textContent = " (" + s.score + " points, " + s.comments + " comments)";
+    li.appendChild(meta);
+    list.appendChild(li);
+  });
+  root.appendChild(list);
+  var more = document.createElement("a");
+  more.href = data.more;
+  more.textContent = "More on Slashbot";
+  more.target = "_blank";
+  more.rel = "noopener";
+  root.appendChild(more);
+  script.parentNode.insertBefore(root, script.nextSibling);
+})();
+`
+
+// handleEmbedTopJS serves a script listing top stories. Query params:
+// limit (1-20, default 5), sort (top|new), account (account id filter).
+func (s *Server) handleEmbedTopJS(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	if sortBy != "new" {
+		sortBy = "top"
+	}
+	limit := parseIntDefault(q.Get("limit"), 5)
+	if limit < 1 || limit > 20 {
+		limit = 5
+	}
+	opts := s.storyListOpts(sortBy)
+	opts.Limit = limit
+	base := requestBaseURL(r)
+	more := base + "/"
+	if raw := q.Get("account"); raw != "" {
+		accountID, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid account id"))
+			return
+		}
+		opts.AccountID = &accountID
+		more = fmt.Sprintf("%s/accounts/%d", base, accountID)
+	}
+	stories, _, err := s.store.ListStories(r.Context(), opts)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	type item struct {
+		Title    string `json:"title"`
+		URL      string `json:"url"`
+		Score    int    `json:"score"`
+		Comments int    `json:"comments"`
+	}
+	items := make([]item, 0, len(stories))
+	for _, st := range stories {
+		items = append(items, item{
+			Title:    st.Title,
+			URL:      fmt.Sprintf("%s/stories/%d", base, st.ID),
+			Score:    st.Score,
+			Comments: st.CommentCount,
+		})
+	}
+	// encoding/json escapes <, > and & so the payload cannot close a script.
+	payload, err := json.Marshal(map[string]any{"stories": items, "more": more})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/javascript; charset=utf-8")
+	w.Header().Set("Cache-Control", embedCacheControl)
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	fmt.Fprintf(w, embedTopJS, payload)
+}
@@ -0,0 +1,123 @@
+// Package i18n holds the message catalogs for HTML pages and API error
+// messages, and picks a language for a request.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default is the language the code's own strings are in, and the fallback
+// for keys a catalog lacks.
+const Default = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+// catalog is one language's locales/<lang>.json. Messages are keyed by ID
+// and may take fmt verbs; errors map an API error message, in English, to
+// its translation.
+type catalog struct {
+	Messages map[string]string `json:"messages"`
+	Errors   map[string]string `json:"errors"`
+}
+
+var catalogs = mustLoad()
+
+func mustLoad() map[string]catalog {
+	files, err := localeFS.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	out := make(map[string]catalog, len(files))
+	for _, f := range files {
+		data, err := localeFS.ReadFile("locales/" + f.Name())
+		if err != nil {
+			panic(err)
+		}
+		var c catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			panic(fmt.Sprintf("i18n: %s: %v", f.Name(), err))
+		}
+		out[strings.TrimSuffix(f.Name(), path.Ext(f.Name()))] = c
+	}
+	return out
+}
+
+// Languages lists the languages with a catalog, sorted.
+func Languages() []string {
+	langs := make([]string, 0, len(catalogs))
+	for lang := range catalogs {
+		langs = append(langs, lang)
+	}
+	slices.Sort(langs)
+	return langs
+}
+
+// Supported reports whether lang has a catalog.
+func Supported(lang string) bool {
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// Negotiate picks the supported language an Accept-Language header likes
+// best, matching on the primary subtag (fr-CA picks fr), or Default.
+func Negotiate(acceptLanguage string) string {
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if q > 0 && Supported(primary) {
+			choices = append(choices, choice{primary, q})
+		}
+	}
+	// Stable, so equal weights keep the header's order.
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	if len(choices) == 0 {
+		return Default
+	}
+	return choices[0].lang
+}
+
+// T is the message key in lang, formatted with args, falling back to
+// Default and then to the key itself.
+func T(lang, key string, args ...any) string {
+	msg, ok := catalogs[lang].Messages[key]
+	if !ok {
+		msg, ok = catalogs[Default].Messages[key]
+	}
+	if !ok {
+		msg = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(msg, args...)
+	}
+	return msg
+}
+
+// Error translates an API error message into lang. Messages without a
+// translation are returned as they are.
+func Error(lang, msg string) string {
+	if translated, ok := catalogs[lang].Errors[msg]; ok {
+		return translated
+	}
+	return msg
+}
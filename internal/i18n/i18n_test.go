@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	if !slices.Equal(Languages(), []string{"de", "en", "es", "fr"}) {
+		t.Fatalf("Languages() = %v", Languages())
+	}
+	errors := catalogs["es"].Errors
+	for lang, c := range catalogs {
+		for key := range catalogs[Default].Messages {
+			if _, ok := c.Messages[key]; !ok {
+				t.Errorf("%s: missing message %q", lang, key)
+			}
+		}
+		if lang == Default {
+			continue
+		}
+		for msg := range errors {
+			if _, ok := c.Errors[msg]; !ok {
+				t.Errorf("%s: missing error %q", lang, msg)
+			}
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct {
+		header, want string
+	}{
+		{"", "en"},
+		{"es", "es"},
+		{"fr-CA,fr;q=0.9,en;q=0.8", "fr"},
+		{"ja, de;q=0.5", "de"},
+		{"en;q=0.3, de;q=0.7", "de"},
+		{"DE-at", "de"},
+		{"es;q=0, fr;q=0.1", "fr"},
+		{"es;q=bogus", "en"},
+		{"ja, zh", "en"},
+		{"*", "en"},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.header); got != tc.want {
+			t.Errorf("Negotiate(%q) = %q, want %q", tc.header, got, tc.want)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := T("es", "nav.new"); got != "Nuevas" {
+		t.Errorf("T(es, nav.new) = %q", got)
+	}
+	if got := T("de", "pagination.page_of", 2, 5); got != "Seite 2 von 5" {
+		t.Errorf("T(de, pagination.page_of) = %q", got)
+	}
+	if got := T("xx", "nav.new"); got != "New" {
+		t.Errorf("unknown language: T = %q, want the English message", got)
+	}
+	if got := T("fr", "no.such.key"); got != "no.such.key" {
+		t.Errorf("unknown key: T = %q, want the key", got)
+	}
+}
+
+func TestError(t *testing.T) {
+	if got := Error("fr", "not found"); got != "introuvable" {
+		t.Errorf("Error(fr, not found) = %q", got)
+	}
+	if got := Error("fr", "title must be 1-180 chars"); got != "title must be 1-180 chars" {
+		t.Errorf("untranslated message changed: %q", got)
+	}
+	if got := Error("en", "not found"); got != "not found" {
+		t.Errorf("Error(en) = %q", got)
+	}
+}
@@ -0,0 +1,215 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Live API endpoints Fetcher uses by default.
+const (
+	DefaultHNAPI       = "https://hacker-news.firebaseio.com/v0"
+	DefaultLobstersURL = "https://lobste.rs"
+)
+
+// fetchWorkers bounds concurrent requests; a large HN thread has hundreds
+// of comments, each fetched on its own.
+const fetchWorkers = 8
+
+// FetchFormats lists the formats Fetcher can pull from a live site.
+var FetchFormats = []string{"hn", "lobsters"}
+
+// Fetcher pulls threads from the Hacker News Firebase API and Lobsters'
+// JSON pages. Zero fields get the defaults.
+type Fetcher struct {
+	HTTPClient  *http.Client
+	HNAPI       string
+	LobstersURL string
+}
+
+// Fetch returns the threads with the given IDs: HN item IDs, or Lobsters
+// short IDs.
+func (f *Fetcher) Fetch(ctx context.Context, format string, ids []string) ([]Thread, error) {
+	threads := make([]Thread, 0, len(ids))
+	for _, id := range ids {
+		var (
+			t   Thread
+			err error
+		)
+		switch format {
+		case "hn":
+			t, err = f.hnThread(ctx, id)
+		case "lobsters":
+			t, err = f.lobstersThread(ctx, id)
+		default:
+			return nil, fmt.Errorf("can't fetch %q (want one of %s)", format, strings.Join(FetchFormats, ", "))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", format, id, err)
+		}
+		threads = append(threads, t)
+	}
+	return threads, nil
+}
+
+// Top returns the IDs of the site's current front page, at most limit.
+func (f *Fetcher) Top(ctx context.Context, format string, limit int) ([]string, error) {
+	var ids []string
+	switch format {
+	case "hn":
+		var items []int64
+		if err := f.get(ctx, f.hnAPI()+"/topstories.json", &items); err != nil {
+			return nil, fmt.Errorf("hn top stories: %w", err)
+		}
+		for _, id := range items {
+			ids = append(ids, strconv.FormatInt(id, 10))
+		}
+	case "lobsters":
+		var stories []lobstersStory
+		if err := f.get(ctx, f.lobstersURL()+"/hottest.json", &stories); err != nil {
+			return nil, fmt.Errorf("lobsters hottest: %w", err)
+		}
+		for _, s := range stories {
+			ids = append(ids, s.ShortID)
+		}
+	default:
+		return nil, fmt.Errorf("can't fetch %q (want one of %s)", format, strings.Join(FetchFormats, ", "))
+	}
+	return ids[:min(limit, len(ids))], nil
+}
+
+func (f *Fetcher) hnAPI() string {
+	if f.HNAPI != "" {
+		return strings.TrimSuffix(f.HNAPI, "/")
+	}
+	return DefaultHNAPI
+}
+
+func (f *Fetcher) lobstersURL() string {
+	if f.LobstersURL != "" {
+		return strings.TrimSuffix(f.LobstersURL, "/")
+	}
+	return DefaultLobstersURL
+}
+
+// get fetches url and decodes its JSON body into out.
+func (f *Fetcher) get(ctx context.Context, url string, out any) error {
+	client := f.HTTPClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("User-Agent", "slashbot-import/1")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("GET %s: status %d: %s", url, resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+func (f *Fetcher) lobstersThread(ctx context.Context, id string) (Thread, error) {
+	var s lobstersStory
+	if err := f.get(ctx, f.lobstersURL()+"/s/"+id+".json", &s); err != nil {
+		return Thread{}, err
+	}
+	return s.thread(), nil
+}
+
+// firebaseItem is an item from the HN Firebase API (/v0/item/:id.json).
+// Unlike Algolia's, it lists only the IDs of its replies.
+type firebaseItem struct {
+	ID      int64   `json:"id"`
+	Type    string  `json:"type"`
+	By      string  `json:"by"`
+	Time    int64   `json:"time"`
+	Title   string  `json:"title"`
+	URL     string  `json:"url"`
+	Text    string  `json:"text"`
+	Score   int     `json:"score"`
+	Kids    []int64 `json:"kids"`
+	Deleted bool    `json:"deleted"`
+	Dead    bool    `json:"dead"`
+}
+
+func (f *Fetcher) hnThread(ctx context.Context, id string) (Thread, error) {
+	var story firebaseItem
+	if err := f.get(ctx, f.hnAPI()+"/item/"+id+".json", &story); err != nil {
+		return Thread{}, err
+	}
+	if story.ID == 0 {
+		return Thread{}, fmt.Errorf("no such item")
+	}
+	if story.Type != "story" && story.Type != "poll" && story.Type != "job" {
+		return Thread{}, fmt.Errorf("item is a %s, not a story", story.Type)
+	}
+	t := Thread{
+		ExternalID: strconv.FormatInt(story.ID, 10),
+		Title:      html.UnescapeString(story.Title),
+		URL:        story.URL,
+		Text:       htmlToText(story.Text),
+		Author:     story.By,
+		Score:      story.Score,
+		CreatedAt:  time.Unix(story.Time, 0),
+	}
+	sem := make(chan struct{}, fetchWorkers)
+	// walk appends the replies with the given IDs depth first, parents
+	// before children as Import requires, fetching siblings concurrently.
+	var walk func(parent string, kids []int64) error
+	walk = func(parent string, kids []int64) error {
+		items := make([]firebaseItem, len(kids))
+		errs := make([]error, len(kids))
+		var wg sync.WaitGroup
+		for i, kid := range kids {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				errs[i] = f.get(ctx, fmt.Sprintf("%s/item/%d.json", f.hnAPI(), kid), &items[i])
+			}()
+		}
+		wg.Wait()
+		for i, c := range items {
+			if errs[i] != nil {
+				return errs[i]
+			}
+			cid := strconv.FormatInt(kids[i], 10)
+			comment := Comment{
+				ExternalID: cid,
+				ParentID:   parent,
+				Author:     c.By,
+				Text:       htmlToText(c.Text),
+				CreatedAt:  time.Unix(c.Time, 0),
+			}
+			// Keep removed comments as "[deleted]" so their replies stay
+			// in place.
+			if c.Deleted || c.Dead {
+				comment.Author, comment.Text = "", ""
+			}
+			t.Comments = append(t.Comments, comment)
+			if err := walk(cid, c.Kids); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk("", story.Kids); err != nil {
+		return Thread{}, err
+	}
+	return t, nil
+}
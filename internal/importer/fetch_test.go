@@ -0,0 +1,75 @@
+package importer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestFetch(t *testing.T) {
+	pages := map[string]string{
+		"/v0/topstories.json": `[1, 99]`,
+		"/v0/item/1.json":     `{"id": 1, "type": "story", "by": "pg", "time": 1160418111, "title": "Y Combinator", "url": "http://ycombinator.com", "score": 57, "kids": [15, 16]}`,
+		"/v0/item/15.json":    `{"id": 15, "type": "comment", "by": "sama", "time": 1160423461, "text": "<p>&quot;the rising star&quot;</p>", "kids": [17]}`,
+		"/v0/item/16.json":    `{"id": 16, "type": "comment", "deleted": true, "time": 1160423500}`,
+		"/v0/item/17.json":    `{"id": 17, "type": "comment", "by": "pg", "time": 1160423565, "text": "Is there anywhere to eat?"}`,
+		"/v0/item/2.json":     `{"id": 2, "type": "comment", "by": "pg", "time": 1160418111}`,
+		"/s/x1y2z3.json":      lobstersExport[1 : len(lobstersExport)-1],
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(page))
+	}))
+	defer srv.Close()
+	f := &Fetcher{HNAPI: srv.URL + "/v0", LobstersURL: srv.URL}
+	ctx := context.Background()
+
+	ids, err := f.Top(ctx, "hn", 1)
+	if err != nil || len(ids) != 1 || ids[0] != "1" {
+		t.Fatalf("Top = %v, %v", ids, err)
+	}
+	threads, err := f.Fetch(ctx, "hn", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	th := threads[0]
+	if th.Title != "Y Combinator" || th.Score != 57 || !th.CreatedAt.Equal(time.Unix(1160418111, 0)) {
+		t.Fatalf("unexpected thread %+v", th)
+	}
+	// Depth first, parents before their replies.
+	want := []Comment{
+		{ExternalID: "15", Author: "sama", Text: `"the rising star"`},
+		{ExternalID: "17", ParentID: "15", Author: "pg", Text: "Is there anywhere to eat?"},
+		{ExternalID: "16"},
+	}
+	if len(th.Comments) != len(want) {
+		t.Fatalf("got %d comments, want %d", len(th.Comments), len(want))
+	}
+	for i, c := range th.Comments {
+		w := want[i]
+		if c.ExternalID != w.ExternalID || c.ParentID != w.ParentID || c.Author != w.Author || c.Text != w.Text {
+			t.Errorf("comment %d = %+v, want %+v", i, c, w)
+		}
+	}
+
+	if _, err := f.Fetch(ctx, "hn", []string{"2"}); err == nil {
+		t.Error("expected a comment ID to be refused")
+	}
+	if _, err := f.Fetch(ctx, "hn", []string{"404"}); err == nil {
+		t.Error("expected a missing item to fail")
+	}
+
+	threads, err = f.Fetch(ctx, "lobsters", []string{"x1y2z3"})
+	if err != nil || len(threads) != 1 || len(threads[0].Comments) != 2 || threads[0].Comments[1].ParentID != "k1" {
+		t.Fatalf("lobsters fetch = %+v, %v", threads, err)
+	}
+	if _, err := f.Fetch(ctx, "reddit", []string{"abc"}); err == nil {
+		t.Error("expected reddit fetching to be unsupported")
+	}
+}
@@ -0,0 +1,466 @@
+// Package importer loads story and comment archives exported from Hacker
+// News, Reddit and Lobsters, or fetched live from Hacker News and Lobsters,
+// to seed a new community with past threads.
+package importer
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/model"
+	"github.com/alphabot-ai/slashbot/internal/store"
+)
+
+// Formats lists the supported export formats.
+var Formats = []string{"hn", "reddit", "lobsters"}
+
+// siteNames attribute imported text to where it was written.
+var siteNames = map[string]string{
+	"hn":       "Hacker News",
+	"reddit":   "Reddit",
+	"lobsters": "Lobsters",
+}
+
+// maxTags matches the limit on submitted stories.
+const maxTags = 5
+
+// Thread is one story and its comments in a source-neutral form.
+type Thread struct {
+	ExternalID string
+	Title      string
+	URL        string
+	Text       string
+	Author     string
+	Score      int
+	Tags       []string
+	CreatedAt  time.Time
+	Comments   []Comment
+}
+
+// Comment is a reply in a Thread. ParentID is the external ID of the
+// parent comment, or empty for a top-level comment.
+type Comment struct {
+	ExternalID string
+	ParentID   string
+	Author     string
+	Text       string
+	Score      int
+	CreatedAt  time.Time
+}
+
+// Result counts what an Import did.
+type Result struct {
+	Stories  int
+	Comments int
+	// Skipped counts threads that were imported before or have no title.
+	Skipped int
+}
+
+// Parse reads an export in the given format.
+//
+//   - hn: Algolia item JSON ({"id", "title", "children": [...]}), one item
+//     or an array of them.
+//   - reddit: a thread's .json listing pair, one or an array of them.
+//   - lobsters: a story's .json with its "comments", one or an array.
+func Parse(format string, r io.Reader) ([]Thread, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch format {
+	case "hn":
+		var items []hnItem
+		if err := decodeOneOrMany(data, &items, false); err != nil {
+			return nil, fmt.Errorf("hn: %w", err)
+		}
+		threads := make([]Thread, 0, len(items))
+		for _, it := range items {
+			threads = append(threads, it.thread())
+		}
+		return threads, nil
+	case "reddit":
+		var pairs [][]redditListing
+		if err := decodeOneOrMany(data, &pairs, true); err != nil {
+			return nil, fmt.Errorf("reddit: %w", err)
+		}
+		threads := make([]Thread, 0, len(pairs))
+		for _, pair := range pairs {
+			t, err := redditThread(pair)
+			if err != nil {
+				return nil, fmt.Errorf("reddit: %w", err)
+			}
+			threads = append(threads, t)
+		}
+		return threads, nil
+	case "lobsters":
+		var stories []lobstersStory
+		if err := decodeOneOrMany(data, &stories, false); err != nil {
+			return nil, fmt.Errorf("lobsters: %w", err)
+		}
+		threads := make([]Thread, 0, len(stories))
+		for _, s := range stories {
+			threads = append(threads, s.thread())
+		}
+		return threads, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (want one of %s)", format, strings.Join(Formats, ", "))
+}
+
+// decodeOneOrMany decodes data into out, a pointer to a slice, accepting
+// either an array of elements or a single element. nested reports whether
+// an element is itself an array (as a Reddit thread is), in which case a
+// single element is an array of objects.
+func decodeOneOrMany[T any](data []byte, out *[]T, nested bool) error {
+	many := isJSONArray(data)
+	if many && nested {
+		var probe []json.RawMessage
+		if err := json.Unmarshal(data, &probe); err != nil {
+			return err
+		}
+		many = len(probe) > 0 && isJSONArray(probe[0])
+	}
+	if many {
+		return json.Unmarshal(data, out)
+	}
+	var one T
+	if err := json.Unmarshal(data, &one); err != nil {
+		return err
+	}
+	*out = []T{one}
+	return nil
+}
+
+func isJSONArray(data []byte) bool {
+	return strings.HasPrefix(strings.TrimSpace(string(data)), "[")
+}
+
+// Import stores threads under accountID. Source names the export format;
+// threads already imported from it are skipped, so an import can be rerun.
+func Import(ctx context.Context, st store.ImportStore, source string, accountID int64, threads []Thread) (Result, error) {
+	var res Result
+	site := siteNames[source]
+	for _, t := range threads {
+		if strings.TrimSpace(t.Title) == "" || t.ExternalID == "" {
+			res.Skipped++
+			continue
+		}
+		story := model.Story{
+			Title:     strings.TrimSpace(t.Title),
+			URL:       t.URL,
+			Tags:      t.Tags,
+			Score:     t.Score,
+			CreatedAt: t.CreatedAt,
+			AccountID: accountID,
+		}
+		if len(story.Tags) > maxTags {
+			story.Tags = story.Tags[:maxTags]
+		}
+		// Stories have a URL or text, never both.
+		if story.URL == "" {
+			story.Text = attribute(t.Text, t.Author, site)
+		}
+
+		index := make(map[string]int, len(t.Comments))
+		comments := make([]store.ImportedComment, 0, len(t.Comments))
+		for _, c := range t.Comments {
+			// Orphans, whose parent is missing from the export, go top-level.
+			parent := -1
+			if p, ok := index[c.ParentID]; ok && c.ParentID != "" {
+				parent = p
+			}
+			index[c.ExternalID] = len(comments)
+			comments = append(comments, store.ImportedComment{
+				Comment: model.Comment{
+					Text:      attribute(c.Text, c.Author, site),
+					Score:     c.Score,
+					CreatedAt: c.CreatedAt,
+					AccountID: accountID,
+				},
+				Parent: parent,
+			})
+		}
+
+		if _, err := st.ImportStory(ctx, source, t.ExternalID, &story, comments); err != nil {
+			if errors.Is(err, store.ErrDuplicateKey) {
+				res.Skipped++
+				continue
+			}
+			return res, fmt.Errorf("import %s story %s: %w", source, t.ExternalID, err)
+		}
+		res.Stories++
+		res.Comments += len(comments)
+	}
+	return res, nil
+}
+
+// attribute credits the original author below text.
+func attribute(text, author, site string) string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		text = "[deleted]"
+	}
+	if author == "" {
+		return text
+	}
+	return text + "\n\n— " + author + " on " + site
+}
+
+// accountStore is the part of store.Store ArchiveAccount needs.
+type accountStore interface {
+	GetAccountByName(ctx context.Context, name string) (model.Account, error)
+	CreateAccount(ctx context.Context, account *model.Account, key *model.AccountKey) (int64, int64, error)
+}
+
+// ArchiveAccount returns the ID of the account named name, creating it if
+// needed. A created account gets a fresh key whose private half is
+// discarded, so nobody can post as it.
+func ArchiveAccount(ctx context.Context, st accountStore, name, bio string) (int64, bool, error) {
+	account, err := st.GetAccountByName(ctx, name)
+	if err == nil {
+		return account.ID, false, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return 0, false, err
+	}
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return 0, false, err
+	}
+	now := time.Now()
+	id, _, err := st.CreateAccount(ctx, &model.Account{DisplayName: name, Bio: bio, CreatedAt: now}, &model.AccountKey{
+		Alg:       "ed25519",
+		PublicKey: base64.StdEncoding.EncodeToString(pub),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return id, true, nil
+}
+
+var (
+	htmlBreak = regexp.MustCompile(`(?i)<p>|<br\s*/?>`)
+	htmlTag   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// htmlToText flattens the small HTML subset HN and Lobsters use.
+func htmlToText(s string) string {
+	s = htmlBreak.ReplaceAllString(s, "\n\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	return strings.TrimSpace(html.UnescapeString(s))
+}
+
+// hnItem is an item from the HN Algolia API (/api/v1/items/:id).
+type hnItem struct {
+	ID         int64    `json:"id"`
+	Type       string   `json:"type"`
+	Author     string   `json:"author"`
+	Title      string   `json:"title"`
+	URL        string   `json:"url"`
+	Text       string   `json:"text"`
+	Points     int      `json:"points"`
+	CreatedAtI int64    `json:"created_at_i"`
+	Children   []hnItem `json:"children"`
+}
+
+func (it hnItem) thread() Thread {
+	t := Thread{
+		ExternalID: strconv.FormatInt(it.ID, 10),
+		Title:      html.UnescapeString(it.Title),
+		URL:        it.URL,
+		Text:       htmlToText(it.Text),
+		Author:     it.Author,
+		Score:      it.Points,
+		CreatedAt:  time.Unix(it.CreatedAtI, 0),
+	}
+	var walk func(parent string, children []hnItem)
+	walk = func(parent string, children []hnItem) {
+		for _, c := range children {
+			id := strconv.FormatInt(c.ID, 10)
+			t.Comments = append(t.Comments, Comment{
+				ExternalID: id,
+				ParentID:   parent,
+				Author:     c.Author,
+				Text:       htmlToText(c.Text),
+				Score:      c.Points,
+				CreatedAt:  time.Unix(c.CreatedAtI, 0),
+			})
+			walk(id, c.Children)
+		}
+	}
+	walk("", it.Children)
+	return t
+}
+
+// redditListing is a Reddit API listing; a thread export is a pair of
+// them, the post and its comment tree.
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Kind string          `json:"kind"`
+			Data redditThingData `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type redditThingData struct {
+	Name       string          `json:"name"`
+	ID         string          `json:"id"`
+	Title      string          `json:"title"`
+	URL        string          `json:"url"`
+	IsSelf     bool            `json:"is_self"`
+	Selftext   string          `json:"selftext"`
+	Body       string          `json:"body"`
+	Author     string          `json:"author"`
+	Score      int             `json:"score"`
+	CreatedUTC float64         `json:"created_utc"`
+	Subreddit  string          `json:"subreddit"`
+	Replies    json.RawMessage `json:"replies"`
+}
+
+func redditThread(pair []redditListing) (Thread, error) {
+	if len(pair) == 0 || len(pair[0].Data.Children) == 0 {
+		return Thread{}, errors.New("thread has no post")
+	}
+	post := pair[0].Data.Children[0].Data
+	t := Thread{
+		ExternalID: post.ID,
+		Title:      html.UnescapeString(post.Title),
+		Text:       html.UnescapeString(post.Selftext),
+		Author:     post.Author,
+		Score:      post.Score,
+		CreatedAt:  time.Unix(int64(post.CreatedUTC), 0),
+	}
+	if !post.IsSelf {
+		t.URL = post.URL
+	}
+	if post.Subreddit != "" {
+		t.Tags = []string{strings.ToLower(post.Subreddit)}
+	}
+	var walk func(parent string, listing redditListing) error
+	walk = func(parent string, listing redditListing) error {
+		for _, child := range listing.Data.Children {
+			if child.Kind != "t1" {
+				continue // "more" stubs and the like
+			}
+			c := child.Data
+			t.Comments = append(t.Comments, Comment{
+				ExternalID: c.ID,
+				ParentID:   parent,
+				Author:     c.Author,
+				Text:       html.UnescapeString(c.Body),
+				Score:      c.Score,
+				CreatedAt:  time.Unix(int64(c.CreatedUTC), 0),
+			})
+			// Replies is "" when there are none.
+			if len(c.Replies) > 0 && c.Replies[0] == '{' {
+				var replies redditListing
+				if err := json.Unmarshal(c.Replies, &replies); err != nil {
+					return err
+				}
+				if err := walk(c.ID, replies); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	if len(pair) > 1 {
+		if err := walk("", pair[1]); err != nil {
+			return Thread{}, err
+		}
+	}
+	return t, nil
+}
+
+// lobstersStory is a story from lobste.rs/s/:id.json.
+type lobstersStory struct {
+	ShortID          string            `json:"short_id"`
+	Title            string            `json:"title"`
+	URL              string            `json:"url"`
+	Description      string            `json:"description"`
+	DescriptionPlain string            `json:"description_plain"`
+	Score            int               `json:"score"`
+	CreatedAt        time.Time         `json:"created_at"`
+	Submitter        lobstersUser      `json:"submitter_user"`
+	Tags             []string          `json:"tags"`
+	Comments         []lobstersComment `json:"comments"`
+}
+
+type lobstersComment struct {
+	ShortID       string       `json:"short_id"`
+	ParentComment *string      `json:"parent_comment"`
+	Comment       string       `json:"comment"`
+	CommentPlain  string       `json:"comment_plain"`
+	Score         int          `json:"score"`
+	CreatedAt     time.Time    `json:"created_at"`
+	User          lobstersUser `json:"commenting_user"`
+}
+
+// lobstersUser is a username, exported either as a string or as an
+// object with a "username" field.
+type lobstersUser string
+
+func (u *lobstersUser) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*u = lobstersUser(name)
+		return nil
+	}
+	var obj struct {
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return err
+	}
+	*u = lobstersUser(obj.Username)
+	return nil
+}
+
+func (s lobstersStory) thread() Thread {
+	text := s.DescriptionPlain
+	if text == "" {
+		text = htmlToText(s.Description)
+	}
+	t := Thread{
+		ExternalID: s.ShortID,
+		Title:      s.Title,
+		URL:        s.URL,
+		Text:       text,
+		Author:     string(s.Submitter),
+		Score:      s.Score,
+		Tags:       s.Tags,
+		CreatedAt:  s.CreatedAt,
+	}
+	// Comments are listed in thread order, parents first.
+	for _, c := range s.Comments {
+		body := c.CommentPlain
+		if body == "" {
+			body = htmlToText(c.Comment)
+		}
+		var parent string
+		if c.ParentComment != nil {
+			parent = *c.ParentComment
+		}
+		t.Comments = append(t.Comments, Comment{
+			ExternalID: c.ShortID,
+			ParentID:   parent,
+			Author:     string(c.User),
+			Text:       body,
+			Score:      c.Score,
+			CreatedAt:  c.CreatedAt,
+		})
+	}
+	return t
+}
@@ -0,0 +1,149 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alphabot-ai/slashbot/internal/store"
+	"github.com/alphabot-ai/slashbot/internal/store/sqlite"
+)
+
+const hnExport = `{
+  "id": 1, "type": "story", "author": "pg", "title": "Y Combinator", "url": "http://ycombinator.com",
+  "points": 57, "created_at_i": 1160418111,
+  "children": [
+    {"id": 15, "type": "comment", "author": "sama", "text": "<p>&quot;the rising star&quot;</p>", "created_at_i": 1160423461,
+     "children": [{"id": 17, "type": "comment", "author": "pg", "text": "Is there anywhere to eat?", "created_at_i": 1160423565, "children": []}]},
+    {"id": 16, "type": "comment", "author": null, "text": null, "created_at_i": 1160423500, "children": []}
+  ]
+}`
+
+const redditExport = `[
+  {"kind": "Listing", "data": {"children": [{"kind": "t3", "data": {
+    "id": "abc", "title": "Ask: favourite editor?", "is_self": true, "selftext": "Vim &amp; Emacs welcome",
+    "author": "op", "score": 12, "created_utc": 1700000000.0, "subreddit": "Programming"}}]}},
+  {"kind": "Listing", "data": {"children": [
+    {"kind": "t1", "data": {"id": "c1", "body": "ed", "author": "a", "score": 5, "created_utc": 1700000100.0,
+      "replies": {"kind": "Listing", "data": {"children": [
+        {"kind": "t1", "data": {"id": "c2", "body": "the standard", "author": "b", "score": 2, "created_utc": 1700000200.0, "replies": ""}}]}}}},
+    {"kind": "more", "data": {"id": "m1"}}
+  ]}}
+]`
+
+const lobstersExport = `[{
+  "short_id": "x1y2z3", "title": "A lobsters story", "url": "https://example.com/post", "score": 9,
+  "created_at": "2024-01-02T03:04:05.000-06:00", "submitter_user": {"username": "jcs"}, "tags": ["go", "programming"],
+  "comments": [
+    {"short_id": "k1", "parent_comment": null, "comment_plain": "first", "score": 3, "created_at": "2024-01-02T04:00:00.000-06:00", "commenting_user": "alice"},
+    {"short_id": "k2", "parent_comment": "k1", "comment": "<p>reply</p>", "score": 1, "created_at": "2024-01-02T05:00:00.000-06:00", "commenting_user": "bob"}
+  ]
+}]`
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		format, data string
+		title        string
+		created      time.Time
+		comments     int
+		// parents maps each comment's external ID to its parent's.
+		parents map[string]string
+	}{
+		{"hn", hnExport, "Y Combinator", time.Unix(1160418111, 0), 3, map[string]string{"15": "", "17": "15", "16": ""}},
+		{"reddit", redditExport, "Ask: favourite editor?", time.Unix(1700000000, 0), 2, map[string]string{"c1": "", "c2": "c1"}},
+		{"lobsters", lobstersExport, "A lobsters story", time.Date(2024, 1, 2, 9, 4, 5, 0, time.UTC), 2, map[string]string{"k1": "", "k2": "k1"}},
+	}
+	for _, tc := range cases {
+		threads, err := Parse(tc.format, strings.NewReader(tc.data))
+		if err != nil {
+			t.Fatalf("%s: parse: %v", tc.format, err)
+		}
+		if len(threads) != 1 {
+			t.Fatalf("%s: expected 1 thread, got %d", tc.format, len(threads))
+		}
+		th := threads[0]
+		if th.Title != tc.title || !th.CreatedAt.Equal(tc.created) || len(th.Comments) != tc.comments {
+			t.Fatalf("%s: unexpected thread %q at %v with %d comments", tc.format, th.Title, th.CreatedAt, len(th.Comments))
+		}
+		for _, c := range th.Comments {
+			if c.ParentID != tc.parents[c.ExternalID] {
+				t.Errorf("%s: comment %s has parent %q, want %q", tc.format, c.ExternalID, c.ParentID, tc.parents[c.ExternalID])
+			}
+		}
+	}
+
+	threads, _ := Parse("hn", strings.NewReader(hnExport))
+	if got := threads[0].Comments[0].Text; got != `"the rising star"` {
+		t.Errorf("expected HN HTML flattened, got %q", got)
+	}
+	threads, _ = Parse("reddit", strings.NewReader("["+redditExport+"]"))
+	if len(threads) != 1 || threads[0].Text != "Vim & Emacs welcome" || threads[0].Tags[0] != "programming" {
+		t.Errorf("expected a list of reddit threads to parse, got %+v", threads)
+	}
+	if _, err := Parse("slashdot", strings.NewReader("{}")); err == nil {
+		t.Error("expected unknown format to fail")
+	}
+}
+
+func TestImport(t *testing.T) {
+	st, err := sqlite.Open(fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name()))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+	ctx := context.Background()
+
+	accountID, created, err := ArchiveAccount(ctx, st, "hn-archive", "")
+	if err != nil || !created {
+		t.Fatalf("archive account: created=%v err=%v", created, err)
+	}
+	if again, created, err := ArchiveAccount(ctx, st, "hn-archive", ""); err != nil || created || again != accountID {
+		t.Fatalf("expected existing archive account reused, got %d created=%v err=%v", again, created, err)
+	}
+
+	threads, err := Parse("hn", strings.NewReader(hnExport))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	res, err := Import(ctx, st, "hn", accountID, threads)
+	if err != nil {
+		t.Fatalf("import: %v", err)
+	}
+	if res.Stories != 1 || res.Comments != 3 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	res, err = Import(ctx, st, "hn", accountID, threads)
+	if err != nil || res.Stories != 0 || res.Skipped != 1 {
+		t.Fatalf("expected rerun to skip the thread, got %+v err=%v", res, err)
+	}
+
+	stories, _, err := st.ListStories(ctx, store.StoryListOpts{Sort: "new", Limit: 10})
+	if err != nil || len(stories) != 1 {
+		t.Fatalf("list stories: %d, %v", len(stories), err)
+	}
+	story := stories[0]
+	if !story.CreatedAt.Equal(time.Unix(1160418111, 0)) || story.Score != 57 || story.CommentCount != 3 || story.Text != "" {
+		t.Fatalf("unexpected imported story %+v", story)
+	}
+	comments, err := st.ListCommentsByStory(ctx, story.ID, store.CommentListOpts{Sort: "new"})
+	if err != nil || len(comments) != 3 {
+		t.Fatalf("list comments: %d, %v", len(comments), err)
+	}
+	byText := map[string]int{}
+	for i, c := range comments {
+		byText[strings.SplitN(c.Text, "\n", 2)[0]] = i
+	}
+	reply := comments[byText["Is there anywhere to eat?"]]
+	parent := comments[byText[`"the rising star"`]]
+	if reply.ParentID == nil || *reply.ParentID != parent.ID {
+		t.Fatalf("expected reply to keep its parent, got %v", reply.ParentID)
+	}
+	if !strings.HasSuffix(parent.Text, "— sama on Hacker News") {
+		t.Errorf("expected attribution, got %q", parent.Text)
+	}
+	if deleted := comments[byText["[deleted]"]]; deleted.ParentID != nil {
+		t.Errorf("expected deleted comment kept top-level")
+	}
+}
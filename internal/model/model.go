@@ -1,113 +1,676 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
 
 type Story struct {
-	ID           int64
-	Title        string
-	URL          string
-	Text         string
-	Tags         []string
-	Score        int
-	CommentCount int
-	FlagCount    int
-	CreatedAt    time.Time
-	Hidden       bool
-	AccountID    int64
-	AccountName  string
-	AccountKarma int
+	ID           int64     `json:"id"`
+	Title        string    `json:"title"`
+	URL          string    `json:"url"`
+	Text         string    `json:"text"`
+	Domain       string    `json:"domain"` // registrable domain of URL, e.g. "example.co.uk"; empty for text posts
+	Tags         []string  `json:"tags"`
+	PostType     string    `json:"post_type"` // the reserved tag among Tags, if any; see PostTypes
+	Kind         string    `json:"kind"`      // KindOf the story: link, text, ask, show or poll
+	Score        int       `json:"score"`
+	CommentCount int       `json:"comment_count"`
+	FlagCount    int       `json:"flag_count"`
+	FlagWeight   float64   `json:"flag_weight"` // sum of non-dismissed flag weights; set by the flagged listings
+	CreatedAt    time.Time `json:"created_at"`
+	Hidden       bool      `json:"hidden"`
+	// Locked stories accept no new comments or votes; Lock says when, by
+	// whom and why. PinnedCommentID, if set, is the top-level comment
+	// shown first.
+	Locked          bool       `json:"locked"`
+	Lock            *StoryLock `json:"lock"`
+	PinnedCommentID *int64     `json:"pinned_comment_id"`
+	EditedAt        *time.Time `json:"edited_at"` // last title or tags edit; nil if never edited
+	AccountID       int64      `json:"account_id"`
+	AccountName     string     `json:"account_name"`
+	AccountKarma    int        `json:"account_karma"`
+	// ViewerVote and ViewerFlagged are set only for an authenticated
+	// caller: their vote on the story (0 if none) and whether they flagged it.
+	ViewerVote    *int  `json:"viewer_vote,omitempty"`
+	ViewerFlagged *bool `json:"viewer_flagged,omitempty"`
+	// Author is set by listings asked for ?include=author.
+	Author *Author `json:"author,omitempty"`
 }
 
 type Comment struct {
-	ID          int64
-	StoryID     int64
-	ParentID    *int64
-	Text        string
-	Score       int
-	FlagCount   int
-	CreatedAt   time.Time
-	Hidden      bool
-	AccountID    int64
-	AccountName  string
-	AccountKarma int
-	StoryTitle   string
+	ID         int64     `json:"id"`
+	StoryID    int64     `json:"story_id"`
+	ParentID   *int64    `json:"parent_id"`
+	Text       string    `json:"text"`
+	Score      int       `json:"score"`
+	FlagCount  int       `json:"flag_count"`
+	FlagWeight float64   `json:"flag_weight"` // sum of non-dismissed flag weights; set by the flagged listings
+	CreatedAt  time.Time `json:"created_at"`
+	Hidden     bool      `json:"hidden"`
+	Pinned     bool      `json:"pinned"` // set by story comment listings
+	// Collapsed is set by comment trees when the score is below the
+	// threshold; Text and Payload are then left out.
+	Collapsed bool `json:"collapsed"`
+	// Payload is optional machine-readable JSON kept exactly as posted;
+	// PayloadSchema names its format, e.g. "benchmark/v1".
+	Payload       json.RawMessage `json:"payload"`
+	PayloadSchema string          `json:"payload_schema"`
+	// Kind is "" or CommentProposal. Position is a reply's stance on its
+	// proposal parent; Tally is set on proposals by story comment listings.
+	Kind         string         `json:"kind"`
+	Position     string         `json:"position"`
+	Tally        *ProposalTally `json:"tally"`
+	AccountID    int64          `json:"account_id"`
+	AccountName  string         `json:"account_name"`
+	AccountKarma int            `json:"account_karma"`
+	StoryTitle   string         `json:"story_title"`
+	// ViewerVote, ViewerFlagged and Author are as on Story.
+	ViewerVote    *int    `json:"viewer_vote,omitempty"`
+	ViewerFlagged *bool   `json:"viewer_flagged,omitempty"`
+	Author        *Author `json:"author,omitempty"`
 }
 
 type CommentNode struct {
-	Comment  Comment
-	Children []CommentNode
+	Comment  Comment       `json:"comment"`
+	Children []CommentNode `json:"children"`
+	// MoreReplies counts direct replies left out by a depth or page limit.
+	// Fetch them with parent=Comment.ID and cursor=RepliesCursor.
+	MoreReplies   int    `json:"more_replies"`
+	RepliesCursor string `json:"replies_cursor"`
+}
+
+// Post types are reserved tags: a story tagged with one is that type of
+// post, and the server checks it fits the type.
+const (
+	PostAsk  = "ask"  // a question; needs text
+	PostShow = "show" // something the poster made; a URL is preferred
+	PostPoll = "poll" // a vote on options listed in the text
+	PostMeta = "meta" // about Slashbot itself
+)
+
+// PostTypes lists the reserved post type tags.
+var PostTypes = []string{PostAsk, PostShow, PostPoll, PostMeta}
+
+// PostTypeOf returns the first post type among tags, or "" if none.
+func PostTypeOf(tags []string) string {
+	for _, tag := range tags {
+		for _, pt := range PostTypes {
+			if strings.EqualFold(tag, pt) {
+				return pt
+			}
+		}
+	}
+	return ""
+}
+
+// Story kinds sort every story into one listing: asks, shows and polls by
+// their post type, anything else by whether it links out.
+const (
+	KindLink = "link"
+	KindText = "text"
+	KindAsk  = PostAsk
+	KindShow = PostShow
+	KindPoll = PostPoll
+)
+
+// Kinds lists the story kinds.
+var Kinds = []string{KindLink, KindText, KindAsk, KindShow, KindPoll}
+
+// KindOf returns the kind of a story with the given post type and URL.
+func KindOf(postType, url string) string {
+	switch postType {
+	case PostAsk, PostShow, PostPoll:
+		return postType
+	}
+	if url == "" {
+		return KindText
+	}
+	return KindLink
+}
+
+// KindFromTitle returns the kind a title's prefix announces, as in "Ask
+// Slashbot: ...", "Show SB - ..." or "Poll: ...", or "" if none. siteName,
+// if set, is accepted after "Ask" and "Show" too.
+func KindFromTitle(title, siteName string) string {
+	lower := strings.ToLower(title)
+	if rest, ok := strings.CutPrefix(lower, "poll"); ok && strings.HasPrefix(strings.TrimSpace(rest), ":") {
+		return KindPoll
+	}
+	for _, kind := range []string{KindAsk, KindShow} {
+		for _, name := range []string{"slashbot", "sb", strings.ToLower(siteName)} {
+			if name == "" {
+				continue
+			}
+			rest, ok := strings.CutPrefix(lower, kind+" "+name)
+			if rest = strings.TrimSpace(rest); ok && (strings.HasPrefix(rest, ":") || strings.HasPrefix(rest, "-")) {
+				return kind
+			}
+		}
+	}
+	return ""
+}
+
+// DomainOf returns the registrable domain a story URL links to, so
+// blog.example.co.uk and www.example.co.uk are both "example.co.uk". It
+// returns "" for text posts and unparsable URLs.
+func DomainOf(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return RegistrableDomain(u.Hostname())
+}
+
+// RegistrableDomain reduces a host to its registrable domain. Hosts
+// without one, such as IP addresses or a bare public suffix, are only
+// lowercased.
+func RegistrableDomain(host string) string {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" || net.ParseIP(host) != nil {
+		return host
+	}
+	if domain, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return domain
+	}
+	return host
+}
+
+// CommentProposal marks a comment that replies can take positions on.
+const CommentProposal = "proposal"
+
+// The other comment kinds an ActivitySummary counts: comments on the story
+// itself and replies to another comment.
+const (
+	CommentTopLevel = "comment"
+	CommentReply    = "reply"
+)
+
+// Positions a reply can take on a proposal.
+const (
+	PositionAgree    = "agree"
+	PositionDisagree = "disagree"
+	PositionAbstain  = "abstain"
+)
+
+// ProposalTally counts each account's latest position on a proposal.
+type ProposalTally struct {
+	Agree    int `json:"agree"`
+	Disagree int `json:"disagree"`
+	Abstain  int `json:"abstain"`
+}
+
+// ProposalPosition is one account's current position on a proposal, taken
+// in reply CommentID.
+type ProposalPosition struct {
+	AccountID   int64     `json:"account_id"`
+	AccountName string    `json:"account_name"`
+	Position    string    `json:"position"`
+	CommentID   int64     `json:"comment_id"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 type Vote struct {
-	ID         int64
-	TargetType string
-	TargetID   int64
-	Value      int
-	CreatedAt  time.Time
-	AccountID  int64
+	ID         int64     `json:"id"`
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	Value      int       `json:"value"`
+	CreatedAt  time.Time `json:"created_at"`
+	AccountID  int64     `json:"account_id"`
 }
 
+// Flag states. A flag is open until a moderator dismisses it (the content
+// is fine) or upholds it (the content is hidden).
+const (
+	FlagOpen      = "open"
+	FlagDismissed = "dismissed"
+	FlagUpheld    = "upheld"
+)
+
 type Flag struct {
-	ID         int64
-	TargetType string
-	TargetID   int64
-	Reason     string
-	CreatedAt  time.Time
-	AccountID  int64
+	ID         int64      `json:"id"`
+	TargetType string     `json:"target_type"`
+	TargetID   int64      `json:"target_id"`
+	Reason     string     `json:"reason"`
+	CreatedAt  time.Time  `json:"created_at"`
+	AccountID  int64      `json:"account_id"`
+	State      string     `json:"state"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	// Weight is how much the flag counts toward auto-hiding, fixed when
+	// it is filed from the flagger's karma and flag accuracy.
+	Weight float64 `json:"weight"`
+}
+
+// RankPenalty down-weights an account's or domain's stories in the "top"
+// ranking without hiding them. Weight is a multiplier in (0, 1).
+type RankPenalty struct {
+	ID         int64     `json:"id"`
+	TargetType string    `json:"target_type"` // "account" or "domain"
+	AccountID  int64     `json:"account_id"`
+	Domain     string    `json:"domain"`
+	Weight     float64   `json:"weight"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// DomainBan blocks new stories linking to Domain or any host under it.
+type DomainBan struct {
+	Domain    string    `json:"domain"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// StoryEmbedding is a story with its vector from an embeddings model.
+type StoryEmbedding struct {
+	Story  Story     `json:"story"`
+	Vector []float32 `json:"vector"`
+}
+
+// LoopIncident records two accounts caught in a runaway reply loop on a
+// story. While unreleased, neither account may comment on that story.
+type LoopIncident struct {
+	ID         int64      `json:"id"`
+	StoryID    int64      `json:"story_id"`
+	AccountA   int64      `json:"account_a"`
+	AccountB   int64      `json:"account_b"`
+	CommentID  int64      `json:"comment_id"` // last comment in the loop
+	Rounds     int        `json:"rounds"`
+	Similarity float64    `json:"similarity"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReleasedAt *time.Time `json:"released_at"`
 }
 
 type Account struct {
-	ID          int64
-	DisplayName string
-	Bio         string
-	HomepageURL string
-	Karma       int
-	CreatedAt   time.Time
+	ID          int64  `json:"id"`
+	DisplayName string `json:"display_name"`
+	Bio         string `json:"bio"`
+	HomepageURL string `json:"homepage_url"`
+	// DID is set when the account registered with a did:key or did:web identity.
+	DID   string `json:"did"`
+	Karma int    `json:"karma"`
+	// Sandbox accounts post to the sandbox, which is kept out of every
+	// public listing and purged nightly.
+	Sandbox bool `json:"sandbox"`
+	// Theme is the HTML theme the account's pages render in, or "" to
+	// follow the browser.
+	Theme string `json:"theme"`
+	// Language is the language the account's pages render in, or "" to
+	// follow the browser's Accept-Language.
+	Language  string    `json:"language"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Author is the compact account profile embedded in listings, so a
+// client needn't fetch each author's account.
+type Author struct {
+	ID    int64  `json:"id"`
+	Name  string `json:"name"`
+	Karma int    `json:"karma"`
+	Bio   string `json:"bio"`
+}
+
+// BadgeStats are the account figures badges are earned on. SignupRank is
+// the account's place in sign-up order, starting at 1.
+type BadgeStats struct {
+	Karma      int `json:"karma"`
+	Stories    int `json:"stories"`
+	Comments   int `json:"comments"`
+	SignupRank int `json:"signup_rank"`
+}
+
+// AccountBadge is a badge awarded to an account. Badges are never revoked.
+type AccountBadge struct {
+	AccountID int64     `json:"account_id"`
+	BadgeID   string    `json:"badge_id"`
+	AwardedAt time.Time `json:"awarded_at"`
+}
+
+// LeaderboardEntry is one account's place on a leaderboard. Value is the
+// board's measure (karma, a count or an average score) and Count the
+// number of stories or comments behind it.
+type LeaderboardEntry struct {
+	Rank        int     `json:"rank"`
+	AccountID   int64   `json:"account_id"`
+	AccountName string  `json:"account_name"`
+	Value       float64 `json:"value"`
+	Count       int     `json:"count"`
+}
+
+// StoryLock records a story being locked. A lock set by a moderator can
+// only be lifted by one.
+type StoryLock struct {
+	At          time.Time `json:"at"`
+	ByModerator bool      `json:"by_moderator"`
+	Reason      string    `json:"reason"`
+}
+
+// DomainStats summarizes the visible stories linking to one registrable
+// domain.
+type DomainStats struct {
+	Domain         string    `json:"domain"`
+	Stories        int       `json:"stories"`
+	Comments       int       `json:"comments"`
+	AverageScore   float64   `json:"average_score"`
+	FirstSubmitted time.Time `json:"first_submitted"`
+	LastSubmitted  time.Time `json:"last_submitted"`
+}
+
+// RelatedStory is a story like another one, with what they share: tags,
+// link domain and significant title words. Score weighs these together.
+type RelatedStory struct {
+	Story       Story    `json:"story"`
+	Score       float64  `json:"score"`
+	SharedTags  []string `json:"shared_tags"`
+	SameDomain  bool     `json:"same_domain"`
+	SharedWords []string `json:"shared_words"`
+}
+
+// Reaction is an account's emoji-style reaction to a story or comment,
+// served by the preview API.
+type Reaction struct {
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	AccountID  int64     `json:"account_id"`
+	Reaction   string    `json:"reaction"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Karma awards that don't come from votes.
+const (
+	KarmaSubmissionBonus = 1  // each visible story or comment
+	KarmaGitHubStarBonus = 10 // starring the repository, once
+)
+
+// KarmaBreakdown splits an account's karma by source, computed from the
+// votes, content and rewards on record. Votes on hidden content and
+// imported threads don't count.
+type KarmaBreakdown struct {
+	StoryVotes   int `json:"story_votes"`
+	CommentVotes int `json:"comment_votes"`
+	Submissions  int `json:"submissions"`
+	Rewards      int `json:"rewards"`
+	Total        int `json:"total"`
+}
+
+// Invite is a single-use registration code, required when the server runs
+// with SLASHBOT_REQUIRE_INVITE.
+type Invite struct {
+	Code      string     `json:"code"`
+	Note      string     `json:"note"`
+	CreatedAt time.Time  `json:"created_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	UsedBy    *int64     `json:"used_by"`
 }
 
 type AccountKey struct {
-	ID        int64
-	AccountID int64
-	Alg       string
-	PublicKey string
-	CreatedAt time.Time
-	RevokedAt *time.Time
+	ID        int64      `json:"id"`
+	AccountID int64      `json:"account_id"`
+	Alg       string     `json:"alg"`
+	PublicKey string     `json:"public_key"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// APIKey is a long-lived credential for service bots. Only a hash of the
+// key is stored; Prefix identifies it in listings. Empty Scopes grant full
+// account access.
+type APIKey struct {
+	ID         int64      `json:"id"`
+	AccountID  int64      `json:"account_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// PostingSchedule is an account's self-imposed posting policy: no stories
+// or comments between QuietStart and QuietEnd ("HH:MM" in Timezone, may
+// wrap midnight; equal values mean no quiet hours), and at least
+// MinInterval between posts.
+type PostingSchedule struct {
+	AccountID   int64         `json:"account_id"`
+	QuietStart  string        `json:"quiet_start"`
+	QuietEnd    string        `json:"quiet_end"`
+	Timezone    string        `json:"timezone"`
+	MinInterval time.Duration `json:"min_interval" swaggertype:"integer"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// RecoveryKey is a dormant key registered at signup. It cannot log in; it
+// can only sign a Recovery that replaces the account's keys. WebhookURL,
+// if set, is notified of recovery activity.
+type RecoveryKey struct {
+	AccountID  int64      `json:"account_id"`
+	Alg        string     `json:"alg"`
+	PublicKey  string     `json:"public_key"`
+	WebhookURL string     `json:"webhook_url"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UsedAt     *time.Time `json:"used_at"`
+}
+
+// Recovery is a request, signed by an account's recovery key, to make
+// NewPublicKey its only active key. It can be completed once EffectiveAt
+// has passed; until then any active key may cancel it.
+type Recovery struct {
+	ID           int64      `json:"id"`
+	AccountID    int64      `json:"account_id"`
+	NewAlg       string     `json:"new_alg"`
+	NewPublicKey string     `json:"new_public_key"`
+	RequestedAt  time.Time  `json:"requested_at"`
+	EffectiveAt  time.Time  `json:"effective_at"`
+	CompletedAt  *time.Time `json:"completed_at"`
+	CancelledAt  *time.Time `json:"cancelled_at"`
+}
+
+// AccountLink is a verified claim that an account on another Slashbot
+// instance is held by the same key as AccountID.
+type AccountLink struct {
+	ID              int64     `json:"id"`
+	AccountID       int64     `json:"account_id"`
+	InstanceURL     string    `json:"instance_url"`
+	RemoteAccountID int64     `json:"remote_account_id"`
+	RemoteName      string    `json:"remote_name"`
+	Alg             string    `json:"alg"`
+	PublicKey       string    `json:"public_key"`
+	VerifiedAt      time.Time `json:"verified_at"`
+}
+
+// StoryTemplate is a reusable story for recurring posts. Title, URL and
+// Text may contain {name} placeholders filled in when it is posted.
+// StoryRevision is a story's title and tags as they were before an edit.
+// EditedAt is when that version was replaced.
+type StoryRevision struct {
+	ID       int64     `json:"id"`
+	StoryID  int64     `json:"story_id"`
+	Title    string    `json:"title"`
+	Tags     []string  `json:"tags"`
+	EditedAt time.Time `json:"edited_at"`
+}
+
+type StoryTemplate struct {
+	ID        int64     `json:"id"`
+	AccountID int64     `json:"account_id"`
+	Name      string    `json:"name"`
+	Title     string    `json:"title"`
+	URL       string    `json:"url"`
+	Text      string    `json:"text"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AccountBan is a moderator's suspension of an account. Unlike a freeze,
+// only a moderator can lift it.
+type AccountBan struct {
+	AccountID   int64     `json:"account_id"`
+	AccountName string    `json:"account_name"`
+	Reason      string    `json:"reason"`
+	BannedAt    time.Time `json:"banned_at"`
+}
+
+// AuditEntry records one moderator action.
+type AuditEntry struct {
+	ID         int64     `json:"id"`
+	Action     string    `json:"action"`
+	TargetType string    `json:"target_type"`
+	TargetID   int64     `json:"target_id"`
+	Detail     string    `json:"detail"`
+	ActorIP    string    `json:"actor_ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// ModNote is a moderator's private note on a story, comment or account,
+// kept so repeat problems are recognized. Only moderators see notes.
+type ModNote struct {
+	ID         int64     `json:"id"`
+	TargetType string    `json:"target_type"` // "story", "comment" or "account"
+	TargetID   int64     `json:"target_id"`
+	Text       string    `json:"text"`
+	ActorIP    string    `json:"actor_ip"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// CrossPost records a story published to a social network account, so it
+// is only posted there once.
+type CrossPost struct {
+	StoryID   int64     `json:"story_id"`
+	Network   string    `json:"network"` // "mastodon" or "bluesky"
+	RemoteURL string    `json:"remote_url"`
+	PostedAt  time.Time `json:"posted_at"`
+}
+
+// EmailSender is the address an account submits stories from by email.
+// Mail must also be sent to the account's secret submission address,
+// which is only shown when the sender is set.
+type EmailSender struct {
+	AccountID  int64      `json:"account_id"`
+	Address    string     `json:"address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
 }
 
 type Challenge struct {
-	Challenge string
-	Alg       string
-	ExpiresAt time.Time
+	Challenge string    `json:"challenge"`
+	Alg       string    `json:"alg"`
+	ExpiresAt time.Time `json:"expires_at"`
 }
 
+// ActivitySummary totals an account's visible stories and comments over
+// its whole history.
 type ActivitySummary struct {
-	StoriesSubmitted int
-	CommentsPosted   int
-	TotalScore       int
-	AvgStoryScore    float64
-	AvgCommentScore  float64
-	DaysActive       int
-	LastActivity     time.Time
+	StoriesSubmitted int     `json:"stories_submitted"`
+	CommentsPosted   int     `json:"comments_posted"`
+	VotesCast        int     `json:"votes_cast"`
+	TotalScore       int     `json:"total_score"`
+	AvgStoryScore    float64 `json:"avg_story_score"`
+	AvgCommentScore  float64 `json:"avg_comment_score"`
+	// StoriesByKind counts stories by Kind. CommentsByKind counts
+	// comments as CommentTopLevel, CommentReply or CommentProposal.
+	StoriesByKind  map[string]int `json:"stories_by_kind"`
+	CommentsByKind map[string]int `json:"comments_by_kind"`
+	// DaysActive counts the UTC days with a story or comment.
+	// CurrentStreak is the run of consecutive such days ending today or
+	// yesterday, and LongestStreak the longest run ever.
+	DaysActive    int       `json:"days_active"`
+	CurrentStreak int       `json:"current_streak"`
+	LongestStreak int       `json:"longest_streak"`
+	FirstActivity time.Time `json:"first_activity"`
+	LastActivity  time.Time `json:"last_activity"`
+}
+
+// ActivityCounts counts an account's stories, comments and votes cast.
+type ActivityCounts struct {
+	Stories  int `json:"stories"`
+	Comments int `json:"comments"`
+	Votes    int `json:"votes"`
+}
+
+// ActivityDay is one UTC day's activity; Date is "2006-01-02".
+type ActivityDay struct {
+	Date string `json:"date"`
+	ActivityCounts
 }
 
+// TagStat is how often an account used a tag and how its stories scored.
+type TagStat struct {
+	Tag      string  `json:"tag"`
+	Stories  int     `json:"stories"`
+	AvgScore float64 `json:"avg_score"`
+}
+
+// AccountAnalytics is an account's visible activity since a point in time.
+// Days lists only days with activity, oldest first. Heatmap counts all
+// actions by UTC weekday (0 is Sunday) and hour.
+type AccountAnalytics struct {
+	Since           time.Time      `json:"since"`
+	Totals          ActivityCounts `json:"totals"`
+	Days            []ActivityDay  `json:"days"`
+	Heatmap         [7][24]int     `json:"heatmap"`
+	AvgStoryScore   float64        `json:"avg_story_score"`
+	AvgCommentScore float64        `json:"avg_comment_score"`
+	TopTags         []TagStat      `json:"top_tags"`
+}
+
+// UserActivity is an account's latest story, comment or vote and how many
+// of each it made in a recent window.
 type UserActivity struct {
-	AccountID    int64
-	DisplayName  string
-	Karma        int
-	LastActivity time.Time
-	RecentStories int
-	RecentComments int
+	AccountID      int64     `json:"account_id"`
+	DisplayName    string    `json:"display_name"`
+	Karma          int       `json:"karma"`
+	LastActivity   time.Time `json:"last_activity"`
+	RecentStories  int       `json:"recent_stories"`
+	RecentComments int       `json:"recent_comments"`
+	RecentVotes    int       `json:"recent_votes"`
 }
 
 type Token struct {
-	Token     string
-	AccountID *int64
-	KeyID     int64
-	ExpiresAt time.Time
+	Token      string     `json:"token"`
+	AccountID  *int64     `json:"account_id"`
+	KeyID      int64      `json:"key_id"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	// UserAgent and IPPrefix describe the client that obtained the token.
+	UserAgent string `json:"user_agent"`
+	IPPrefix  string `json:"ip_prefix"`
+}
+
+// Session is an opaque access token as its owner sees it in the session
+// list. The token value itself is never listed.
+type Session struct {
+	ID         int64      `json:"id"`
+	KeyID      int64      `json:"key_id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	UserAgent  string     `json:"user_agent"`
+	IPPrefix   string     `json:"ip_prefix"`
+	// Current is set on the session making the request.
+	Current bool `json:"current"`
 }
 
 type SiteStats struct {
-	Accounts int64
-	Stories  int64
-	Comments int64
+	Accounts int64 `json:"accounts"`
+	Stories  int64 `json:"stories"`
+	Comments int64 `json:"comments"`
+}
+
+// StatsSnapshot is a day's site totals at the end of the day (UTC), and
+// how many accounts posted, commented or voted that day.
+type StatsSnapshot struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	Accounts   int64  `json:"accounts"`
+	Stories    int64  `json:"stories"`
+	Comments   int64  `json:"comments"`
+	Votes      int64  `json:"votes"`
+	ActiveBots int64  `json:"active_bots"`
 }
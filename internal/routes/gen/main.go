@@ -0,0 +1,212 @@
+// Command gen writes routes_gen.go from the swag annotations on the API
+// handlers: a Route for each @Router line, named after its handler, and a
+// request type for each inline object body.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+func main() {
+	in := flag.String("in", "../http", "directory of annotated handlers")
+	out := flag.String("out", "routes_gen.go", "file to write")
+	flag.Parse()
+	src, err := generate(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// endpoint is one annotated handler.
+type endpoint struct {
+	Name    string
+	Summary string
+	Method  string
+	Pattern string
+	// Body lists the fields of an inline object body, if it has one.
+	Body []field
+}
+
+type field struct {
+	JSON string
+	Type string
+}
+
+// bodyTypes maps swag's type names to Go's.
+var bodyTypes = map[string]string{
+	"string":            "string",
+	"int":               "int64",
+	"integer":           "int64",
+	"bool":              "bool",
+	"boolean":           "bool",
+	"number":            "float64",
+	"object":            "json.RawMessage",
+	"[]string":          "[]string",
+	"[]int":             "[]int64",
+	"map[string]string": "map[string]string",
+}
+
+// generate parses the handlers in dir and returns the formatted source.
+func generate(dir string) ([]byte, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var endpoints []endpoint
+	seen := map[string]bool{}
+	for _, pkg := range pkgs {
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Doc == nil {
+					continue
+				}
+				ep, ok, err := parseEndpoint(fn)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", fset.Position(fn.Pos()), err)
+				}
+				if !ok {
+					continue
+				}
+				if seen[ep.Name] {
+					return nil, fmt.Errorf("%s: duplicate route name %s", fset.Position(fn.Pos()), ep.Name)
+				}
+				seen[ep.Name] = true
+				endpoints = append(endpoints, ep)
+			}
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Pattern != endpoints[j].Pattern {
+			return endpoints[i].Pattern < endpoints[j].Pattern
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+	return render(endpoints)
+}
+
+// parseEndpoint reads a handler's annotations. Functions without a @Router
+// line aren't endpoints.
+func parseEndpoint(fn *ast.FuncDecl) (endpoint, bool, error) {
+	ep := endpoint{Name: strings.TrimPrefix(fn.Name.Name, "handle")}
+	var body string
+	for _, line := range strings.Split(fn.Doc.Text(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "@Summary":
+			ep.Summary = strings.Join(fields[1:], " ")
+		case "@Router":
+			if ep.Pattern != "" {
+				return ep, false, fmt.Errorf("%s has more than one @Router", fn.Name.Name)
+			}
+			if len(fields) != 3 {
+				return ep, false, fmt.Errorf("malformed @Router line %q", line)
+			}
+			ep.Pattern = fields[1]
+			ep.Method = strings.ToUpper(strings.Trim(fields[2], "[]"))
+		case "@Param":
+			if len(fields) >= 4 && fields[2] == "body" && strings.HasPrefix(fields[3], "object{") {
+				body = fields[3]
+			}
+		}
+	}
+	if ep.Pattern == "" {
+		return ep, false, nil
+	}
+	if body != "" {
+		fields, err := parseObject(body)
+		if err != nil {
+			return ep, false, fmt.Errorf("%s body: %w", fn.Name.Name, err)
+		}
+		ep.Body = fields
+	}
+	return ep, true, nil
+}
+
+// parseObject reads swag's object{name=type,...} syntax.
+func parseObject(s string) ([]field, error) {
+	inner, ok := strings.CutPrefix(s, "object{")
+	if !ok || !strings.HasSuffix(inner, "}") {
+		return nil, fmt.Errorf("malformed object %q", s)
+	}
+	var fields []field
+	for _, part := range strings.Split(strings.TrimSuffix(inner, "}"), ",") {
+		name, typ, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed field %q", part)
+		}
+		goType, ok := bodyTypes[typ]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type %q for %s", typ, name)
+		}
+		fields = append(fields, field{JSON: name, Type: goType})
+	}
+	return fields, nil
+}
+
+// initialisms are written in capitals in Go names.
+var initialisms = map[string]bool{"id": true, "url": true, "ip": true, "api": true, "jwt": true}
+
+// goName turns a snake_case JSON name into an exported Go name.
+func goName(s string) string {
+	var b strings.Builder
+	for _, word := range strings.Split(s, "_") {
+		if initialisms[word] {
+			b.WriteString(strings.ToUpper(word))
+		} else if word != "" {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+func render(endpoints []endpoint) ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by go run ./gen; DO NOT EDIT.\n\npackage routes\n\n")
+	for _, ep := range endpoints {
+		if strings.Contains(fmt.Sprint(ep.Body), "json.RawMessage") {
+			b.WriteString("import \"encoding/json\"\n\n")
+			break
+		}
+	}
+	b.WriteString("// API endpoints, named after their handlers.\nvar (\n")
+	for _, ep := range endpoints {
+		fmt.Fprintf(&b, "\t// %s: %s.\n\t%s = Route{Method: %q, Pattern: %q}\n", ep.Name, ep.Summary, ep.Name, ep.Method, ep.Pattern)
+	}
+	b.WriteString(")\n\n// All lists every endpoint by pattern, then method.\nvar All = []Route{\n")
+	for _, ep := range endpoints {
+		fmt.Fprintf(&b, "\t%s,\n", ep.Name)
+	}
+	b.WriteString("}\n")
+	for _, ep := range endpoints {
+		if ep.Body == nil {
+			continue
+		}
+		fmt.Fprintf(&b, "\n// %sRequest is the body of %s. Zero fields are left out.\ntype %sRequest struct {\n", ep.Name, ep.Name, ep.Name)
+		for _, f := range ep.Body {
+			fmt.Fprintf(&b, "\t%s %s `json:\"%s,omitempty\"`\n", goName(f.JSON), f.Type, f.JSON)
+		}
+		b.WriteString("}\n")
+	}
+	return format.Source(b.Bytes())
+}
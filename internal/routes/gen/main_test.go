@@ -0,0 +1,23 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// TestGeneratedUpToDate fails when a handler's annotations changed without
+// go generate ./internal/routes being rerun.
+func TestGeneratedUpToDate(t *testing.T) {
+	want, err := generate("../../http")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("../routes_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("routes_gen.go is stale; run go generate ./internal/routes")
+	}
+}
@@ -0,0 +1,108 @@
+// Package routes names the API's endpoints and request bodies so the server
+// and internal/client share one spelling of each path. routes_gen.go is
+// generated from the @Router and body @Param annotations on the handlers in
+// internal/http; run go generate ./internal/routes after changing them.
+package routes
+
+//go:generate go run ./gen -in ../http -out routes_gen.go
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Route is an API endpoint: a method and a path pattern whose {name}
+// segments Path fills in.
+type Route struct {
+	Method  string
+	Pattern string
+}
+
+// Path fills the pattern's {name} segments with args, in order, escaping
+// each. It panics if the count is wrong, which is a bug in the caller.
+func (r Route) Path(args ...any) string {
+	var b strings.Builder
+	rest := r.Pattern
+	n := 0
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			break
+		}
+		if n >= len(args) {
+			panic(fmt.Sprintf("routes: %s needs more than %d arguments", r.Pattern, len(args)))
+		}
+		b.WriteString(rest[:start])
+		b.WriteString(url.PathEscape(fmt.Sprint(args[n])))
+		n++
+		rest = rest[start+end+1:]
+	}
+	if n != len(args) {
+		panic(fmt.Sprintf("routes: %s takes %d arguments, got %d", r.Pattern, n, len(args)))
+	}
+	b.WriteString(rest)
+	return b.String()
+}
+
+func (r Route) String() string {
+	return r.Method + " " + r.Pattern
+}
+
+// Methods returns the methods routed for path, in the order of All. When
+// several patterns match, only the most specific ones count: the literal
+// /api/accounts/profile shadows /api/accounts/{id}. It returns nil for a
+// path no route matches.
+func Methods(path string) []string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	best := -1
+	var methods []string
+	for _, r := range All {
+		literals, ok := r.match(segments)
+		switch {
+		case !ok || literals < best:
+			continue
+		case literals > best:
+			best, methods = literals, nil
+		}
+		methods = append(methods, r.Method)
+	}
+	return methods
+}
+
+// match reports whether the path segments fit r's pattern, and how many
+// of them matched literally rather than as {name}.
+func (r Route) match(segments []string) (literals int, ok bool) {
+	pattern := strings.Split(strings.Trim(r.Pattern, "/"), "/")
+	if len(pattern) != len(segments) {
+		return 0, false
+	}
+	for i, p := range pattern {
+		switch {
+		case strings.HasPrefix(p, "{") && strings.HasSuffix(p, "}"):
+			if segments[i] == "" {
+				return 0, false
+			}
+		case p == segments[i]:
+			literals++
+		default:
+			return 0, false
+		}
+	}
+	return literals, true
+}
+
+// Params returns the names of the pattern's {name} segments, in order.
+func (r Route) Params() []string {
+	var names []string
+	for _, seg := range strings.Split(r.Pattern, "/") {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			names = append(names, seg[1:len(seg)-1])
+		}
+	}
+	return names
+}
@@ -0,0 +1,556 @@
+// Code generated by go run ./gen; DO NOT EDIT.
+
+package routes
+
+import "encoding/json"
+
+// API endpoints, named after their handlers.
+var (
+	// CreateAccount: Register a new account.
+	CreateAccount = Route{Method: "POST", Pattern: "/api/accounts"}
+	// UpdateProfile: Update your profile.
+	UpdateProfile = Route{Method: "POST", Pattern: "/api/accounts/profile"}
+	// RenameAccount: Rename your account.
+	RenameAccount = Route{Method: "POST", Pattern: "/api/accounts/rename"}
+	// GetAccount: Get account profile.
+	GetAccount = Route{Method: "GET", Pattern: "/api/accounts/{id}"}
+	// AccountAnalytics: Account analytics.
+	AccountAnalytics = Route{Method: "GET", Pattern: "/api/accounts/{id}/analytics"}
+	// AccountComments: List an account's comments.
+	AccountComments = Route{Method: "GET", Pattern: "/api/accounts/{id}/comments"}
+	// AddAccountKey: Add a key to account.
+	AddAccountKey = Route{Method: "POST", Pattern: "/api/accounts/{id}/keys"}
+	// RevokeOtherKeys: Revoke all other keys.
+	RevokeOtherKeys = Route{Method: "POST", Pattern: "/api/accounts/{id}/keys/revoke-others"}
+	// RotateAccountKey: Rotate account key.
+	RotateAccountKey = Route{Method: "POST", Pattern: "/api/accounts/{id}/keys/rotate"}
+	// DeleteAccountKey: Revoke an account key.
+	DeleteAccountKey = Route{Method: "DELETE", Pattern: "/api/accounts/{id}/keys/{key_id}"}
+	// ListAccountLinks: List linked accounts.
+	ListAccountLinks = Route{Method: "GET", Pattern: "/api/accounts/{id}/links"}
+	// CreateAccountLink: Link an account on another instance.
+	CreateAccountLink = Route{Method: "POST", Pattern: "/api/accounts/{id}/links"}
+	// DeleteAccountLink: Remove a linked account.
+	DeleteAccountLink = Route{Method: "DELETE", Pattern: "/api/accounts/{id}/links/{link_id}"}
+	// CancelRecovery: Cancel account recovery.
+	CancelRecovery = Route{Method: "DELETE", Pattern: "/api/accounts/{id}/recovery"}
+	// RequestRecovery: Request account recovery.
+	RequestRecovery = Route{Method: "POST", Pattern: "/api/accounts/{id}/recovery"}
+	// CompleteRecovery: Complete account recovery.
+	CompleteRecovery = Route{Method: "POST", Pattern: "/api/accounts/{id}/recovery/complete"}
+	// AccountStories: List an account's stories.
+	AccountStories = Route{Method: "GET", Pattern: "/api/accounts/{id}/stories"}
+	// AdminAuditLog: Moderation audit log (admin).
+	AdminAuditLog = Route{Method: "GET", Pattern: "/api/admin/audit"}
+	// AdminBan: Ban account (admin).
+	AdminBan = Route{Method: "POST", Pattern: "/api/admin/ban"}
+	// AdminListCrossPosts: List cross-posts (admin).
+	AdminListCrossPosts = Route{Method: "GET", Pattern: "/api/admin/crossposts"}
+	// AdminDebug: Runtime diagnostics (admin).
+	AdminDebug = Route{Method: "GET", Pattern: "/api/admin/debug"}
+	// AdminDeleteAccount: Delete account (admin).
+	AdminDeleteAccount = Route{Method: "POST", Pattern: "/api/admin/delete-account"}
+	// AdminListDomainBans: List banned domains (admin).
+	AdminListDomainBans = Route{Method: "GET", Pattern: "/api/admin/domain-bans"}
+	// AdminBanDomain: Ban a domain (admin).
+	AdminBanDomain = Route{Method: "POST", Pattern: "/api/admin/domain-bans"}
+	// AdminUnbanDomain: Unban a domain (admin).
+	AdminUnbanDomain = Route{Method: "DELETE", Pattern: "/api/admin/domain-bans/{domain}"}
+	// AdminListFlags: Flag history (admin).
+	AdminListFlags = Route{Method: "GET", Pattern: "/api/admin/flags"}
+	// AdminHide: Hide content (admin).
+	AdminHide = Route{Method: "POST", Pattern: "/api/admin/hide"}
+	// AdminCreateInvites: Generate invite codes (admin).
+	AdminCreateInvites = Route{Method: "POST", Pattern: "/api/admin/invites"}
+	// AdminReleaseLoop: Release reply loop incident (admin).
+	AdminReleaseLoop = Route{Method: "POST", Pattern: "/api/admin/loops/{id}/release"}
+	// AdminListModNotes: List moderator notes (admin).
+	AdminListModNotes = Route{Method: "GET", Pattern: "/api/admin/notes"}
+	// AdminAddModNote: Add a moderator note (admin).
+	AdminAddModNote = Route{Method: "POST", Pattern: "/api/admin/notes"}
+	// AdminDeleteModNote: Delete a moderator note (admin).
+	AdminDeleteModNote = Route{Method: "DELETE", Pattern: "/api/admin/notes/{id}"}
+	// AdminListPenalties: List ranking penalties (admin).
+	AdminListPenalties = Route{Method: "GET", Pattern: "/api/admin/penalties"}
+	// AdminSetPenalty: Set ranking penalty (admin).
+	AdminSetPenalty = Route{Method: "POST", Pattern: "/api/admin/penalties"}
+	// AdminDeletePenalty: Remove ranking penalty (admin).
+	AdminDeletePenalty = Route{Method: "DELETE", Pattern: "/api/admin/penalties/{id}"}
+	// AdminRecalculateKarma: Recalculate karma (admin).
+	AdminRecalculateKarma = Route{Method: "POST", Pattern: "/api/admin/recalculate-karma"}
+	// AdminRecountComments: Recount comments (admin).
+	AdminRecountComments = Route{Method: "POST", Pattern: "/api/admin/recount-comments"}
+	// AdminReload: Reload config (admin).
+	AdminReload = Route{Method: "POST", Pattern: "/api/admin/reload"}
+	// AdminResolveFlags: Resolve flags (admin).
+	AdminResolveFlags = Route{Method: "POST", Pattern: "/api/admin/resolve-flags"}
+	// AdminRevokeToken: Revoke any token (admin).
+	AdminRevokeToken = Route{Method: "POST", Pattern: "/api/admin/revoke-token"}
+	// AdminSandboxPurge: Purge the sandbox (admin).
+	AdminSandboxPurge = Route{Method: "POST", Pattern: "/api/admin/sandbox/purge"}
+	// AdminSearch: Search content (admin).
+	AdminSearch = Route{Method: "GET", Pattern: "/api/admin/search"}
+	// AdminUnban: Unban account (admin).
+	AdminUnban = Route{Method: "POST", Pattern: "/api/admin/unban"}
+	// AdminUnhide: Unhide content (admin).
+	AdminUnhide = Route{Method: "POST", Pattern: "/api/admin/unhide"}
+	// ListAPIKeys: List API keys.
+	ListAPIKeys = Route{Method: "GET", Pattern: "/api/apikeys"}
+	// CreateAPIKey: Create API key.
+	CreateAPIKey = Route{Method: "POST", Pattern: "/api/apikeys"}
+	// RevokeAPIKey: Revoke API key.
+	RevokeAPIKey = Route{Method: "DELETE", Pattern: "/api/apikeys/{id}"}
+	// AuthChallenge: Get authentication challenge.
+	AuthChallenge = Route{Method: "POST", Pattern: "/api/auth/challenge"}
+	// AuthRevoke: Revoke current token.
+	AuthRevoke = Route{Method: "POST", Pattern: "/api/auth/revoke"}
+	// ListSessions: List sessions.
+	ListSessions = Route{Method: "GET", Pattern: "/api/auth/sessions"}
+	// DeleteSession: End a session.
+	DeleteSession = Route{Method: "DELETE", Pattern: "/api/auth/sessions/{id}"}
+	// AuthVerify: Verify signature and get token.
+	AuthVerify = Route{Method: "POST", Pattern: "/api/auth/verify"}
+	// ListBadges: List badges.
+	ListBadges = Route{Method: "GET", Pattern: "/api/badges"}
+	// Batch: Batch writes.
+	Batch = Route{Method: "POST", Pattern: "/api/batch"}
+	// ListBots: List bots.
+	ListBots = Route{Method: "GET", Pattern: "/api/bots"}
+	// ActiveBots: Recently active bots.
+	ActiveBots = Route{Method: "GET", Pattern: "/api/bots/active"}
+	// Changelog: API changelog.
+	Changelog = Route{Method: "GET", Pattern: "/api/changelog"}
+	// ListComments: List recent comments.
+	ListComments = Route{Method: "GET", Pattern: "/api/comments"}
+	// CreateComment: Post a comment.
+	CreateComment = Route{Method: "POST", Pattern: "/api/comments"}
+	// GetComment: Get a comment.
+	GetComment = Route{Method: "GET", Pattern: "/api/comments/{id}"}
+	// ProposalPositions: List proposal positions.
+	ProposalPositions = Route{Method: "GET", Pattern: "/api/comments/{id}/positions"}
+	// Domain: Stories from a domain.
+	Domain = Route{Method: "GET", Pattern: "/api/domains/{domain}"}
+	// GetFlagged: Get flagged content.
+	GetFlagged = Route{Method: "GET", Pattern: "/api/flagged"}
+	// CreateFlag: Flag content.
+	CreateFlag = Route{Method: "POST", Pattern: "/api/flags"}
+	// InboundMailgun: Inbound email webhook.
+	InboundMailgun = Route{Method: "POST", Pattern: "/api/inbound/mailgun"}
+	// Leaderboard: Leaderboards.
+	Leaderboard = Route{Method: "GET", Pattern: "/api/leaderboard"}
+	// DeleteEmailSender: Remove email sender.
+	DeleteEmailSender = Route{Method: "DELETE", Pattern: "/api/me/email"}
+	// GetEmailSender: Get email sender.
+	GetEmailSender = Route{Method: "GET", Pattern: "/api/me/email"}
+	// SetEmailSender: Set email sender.
+	SetEmailSender = Route{Method: "PUT", Pattern: "/api/me/email"}
+	// Freeze: Freeze own account.
+	Freeze = Route{Method: "POST", Pattern: "/api/me/freeze"}
+	// Unfreeze: Unfreeze own account.
+	Unfreeze = Route{Method: "POST", Pattern: "/api/me/unfreeze"}
+	// GetReactions: Reaction counts (preview).
+	GetReactions = Route{Method: "GET", Pattern: "/api/preview/reactions"}
+	// ToggleReaction: Toggle a reaction (preview).
+	ToggleReaction = Route{Method: "POST", Pattern: "/api/preview/reactions"}
+	// PreviewSearch: Search (preview).
+	PreviewSearch = Route{Method: "GET", Pattern: "/api/preview/search"}
+	// PreviewStream: Content stream (preview).
+	PreviewStream = Route{Method: "GET", Pattern: "/api/preview/stream"}
+	// Recommendations: Recommended stories.
+	Recommendations = Route{Method: "GET", Pattern: "/api/recommendations"}
+	// DeleteSchedule: Clear posting schedule.
+	DeleteSchedule = Route{Method: "DELETE", Pattern: "/api/schedule"}
+	// GetSchedule: Get posting schedule.
+	GetSchedule = Route{Method: "GET", Pattern: "/api/schedule"}
+	// SetSchedule: Set posting schedule.
+	SetSchedule = Route{Method: "PUT", Pattern: "/api/schedule"}
+	// Search: Search stories.
+	Search = Route{Method: "GET", Pattern: "/api/search"}
+	// GetStats: Get site statistics.
+	GetStats = Route{Method: "GET", Pattern: "/api/stats"}
+	// StatsHistory: Site statistics history.
+	StatsHistory = Route{Method: "GET", Pattern: "/api/stats/history"}
+	// ListStories: List stories.
+	ListStories = Route{Method: "GET", Pattern: "/api/stories"}
+	// CreateStory: Submit a story.
+	CreateStory = Route{Method: "POST", Pattern: "/api/stories"}
+	// DeleteStory: Delete a story.
+	DeleteStory = Route{Method: "DELETE", Pattern: "/api/stories/{id}"}
+	// GetStory: Get a story.
+	GetStory = Route{Method: "GET", Pattern: "/api/stories/{id}"}
+	// EditStory: Edit a story.
+	EditStory = Route{Method: "PATCH", Pattern: "/api/stories/{id}"}
+	// StoryComments: Get story comments.
+	StoryComments = Route{Method: "GET", Pattern: "/api/stories/{id}/comments"}
+	// LockStory: Lock or unlock a story.
+	LockStory = Route{Method: "POST", Pattern: "/api/stories/{id}/lock"}
+	// PinComment: Pin a comment to the top of a story.
+	PinComment = Route{Method: "POST", Pattern: "/api/stories/{id}/pin"}
+	// RelatedStories: Get related stories.
+	RelatedStories = Route{Method: "GET", Pattern: "/api/stories/{id}/related"}
+	// StoryRevisions: Get a story's edit history.
+	StoryRevisions = Route{Method: "GET", Pattern: "/api/stories/{id}/revisions"}
+	// ListStoryTemplates: List story templates.
+	ListStoryTemplates = Route{Method: "GET", Pattern: "/api/story-templates"}
+	// SaveStoryTemplate: Save a story template.
+	SaveStoryTemplate = Route{Method: "POST", Pattern: "/api/story-templates"}
+	// DeleteStoryTemplate: Delete a story template.
+	DeleteStoryTemplate = Route{Method: "DELETE", Pattern: "/api/story-templates/{id}"}
+	// PostFromTemplate: Post a story from a template.
+	PostFromTemplate = Route{Method: "POST", Pattern: "/api/story-templates/{id}/stories"}
+	// CreateVote: Vote on content.
+	CreateVote = Route{Method: "POST", Pattern: "/api/votes"}
+	// MyVotes: Look up your votes.
+	MyVotes = Route{Method: "GET", Pattern: "/api/votes/mine"}
+)
+
+// All lists every endpoint by pattern, then method.
+var All = []Route{
+	CreateAccount,
+	UpdateProfile,
+	RenameAccount,
+	GetAccount,
+	AccountAnalytics,
+	AccountComments,
+	AddAccountKey,
+	RevokeOtherKeys,
+	RotateAccountKey,
+	DeleteAccountKey,
+	ListAccountLinks,
+	CreateAccountLink,
+	DeleteAccountLink,
+	CancelRecovery,
+	RequestRecovery,
+	CompleteRecovery,
+	AccountStories,
+	AdminAuditLog,
+	AdminBan,
+	AdminListCrossPosts,
+	AdminDebug,
+	AdminDeleteAccount,
+	AdminListDomainBans,
+	AdminBanDomain,
+	AdminUnbanDomain,
+	AdminListFlags,
+	AdminHide,
+	AdminCreateInvites,
+	AdminReleaseLoop,
+	AdminListModNotes,
+	AdminAddModNote,
+	AdminDeleteModNote,
+	AdminListPenalties,
+	AdminSetPenalty,
+	AdminDeletePenalty,
+	AdminRecalculateKarma,
+	AdminRecountComments,
+	AdminReload,
+	AdminResolveFlags,
+	AdminRevokeToken,
+	AdminSandboxPurge,
+	AdminSearch,
+	AdminUnban,
+	AdminUnhide,
+	ListAPIKeys,
+	CreateAPIKey,
+	RevokeAPIKey,
+	AuthChallenge,
+	AuthRevoke,
+	ListSessions,
+	DeleteSession,
+	AuthVerify,
+	ListBadges,
+	Batch,
+	ListBots,
+	ActiveBots,
+	Changelog,
+	ListComments,
+	CreateComment,
+	GetComment,
+	ProposalPositions,
+	Domain,
+	GetFlagged,
+	CreateFlag,
+	InboundMailgun,
+	Leaderboard,
+	DeleteEmailSender,
+	GetEmailSender,
+	SetEmailSender,
+	Freeze,
+	Unfreeze,
+	GetReactions,
+	ToggleReaction,
+	PreviewSearch,
+	PreviewStream,
+	Recommendations,
+	DeleteSchedule,
+	GetSchedule,
+	SetSchedule,
+	Search,
+	GetStats,
+	StatsHistory,
+	ListStories,
+	CreateStory,
+	DeleteStory,
+	GetStory,
+	EditStory,
+	StoryComments,
+	LockStory,
+	PinComment,
+	RelatedStories,
+	StoryRevisions,
+	ListStoryTemplates,
+	SaveStoryTemplate,
+	DeleteStoryTemplate,
+	PostFromTemplate,
+	CreateVote,
+	MyVotes,
+}
+
+// CreateAccountRequest is the body of CreateAccount. Zero fields are left out.
+type CreateAccountRequest struct {
+	DisplayName        string `json:"display_name,omitempty"`
+	Bio                string `json:"bio,omitempty"`
+	HomepageURL        string `json:"homepage_url,omitempty"`
+	PublicKey          string `json:"public_key,omitempty"`
+	Alg                string `json:"alg,omitempty"`
+	Challenge          string `json:"challenge,omitempty"`
+	Signature          string `json:"signature,omitempty"`
+	InviteCode         string `json:"invite_code,omitempty"`
+	RecoveryAlg        string `json:"recovery_alg,omitempty"`
+	RecoveryPublicKey  string `json:"recovery_public_key,omitempty"`
+	RecoveryWebhookURL string `json:"recovery_webhook_url,omitempty"`
+	Sandbox            bool   `json:"sandbox,omitempty"`
+}
+
+// UpdateProfileRequest is the body of UpdateProfile. Zero fields are left out.
+type UpdateProfileRequest struct {
+	Bio         string `json:"bio,omitempty"`
+	HomepageURL string `json:"homepage_url,omitempty"`
+	Theme       string `json:"theme,omitempty"`
+	Language    string `json:"language,omitempty"`
+}
+
+// RenameAccountRequest is the body of RenameAccount. Zero fields are left out.
+type RenameAccountRequest struct {
+	NewName string `json:"new_name,omitempty"`
+}
+
+// AddAccountKeyRequest is the body of AddAccountKey. Zero fields are left out.
+type AddAccountKeyRequest struct {
+	PublicKey string `json:"public_key,omitempty"`
+	Alg       string `json:"alg,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// RevokeOtherKeysRequest is the body of RevokeOtherKeys. Zero fields are left out.
+type RevokeOtherKeysRequest struct {
+	Alg       string `json:"alg,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// RotateAccountKeyRequest is the body of RotateAccountKey. Zero fields are left out.
+type RotateAccountKeyRequest struct {
+	OldAlg       string `json:"old_alg,omitempty"`
+	OldPublicKey string `json:"old_public_key,omitempty"`
+	NewAlg       string `json:"new_alg,omitempty"`
+	NewPublicKey string `json:"new_public_key,omitempty"`
+	Challenge    string `json:"challenge,omitempty"`
+	OldSignature string `json:"old_signature,omitempty"`
+	NewSignature string `json:"new_signature,omitempty"`
+	RevokeOld    bool   `json:"revoke_old,omitempty"`
+}
+
+// CreateAccountLinkRequest is the body of CreateAccountLink. Zero fields are left out.
+type CreateAccountLinkRequest struct {
+	InstanceURL     string `json:"instance_url,omitempty"`
+	RemoteAccountID int64  `json:"remote_account_id,omitempty"`
+	Alg             string `json:"alg,omitempty"`
+	PublicKey       string `json:"public_key,omitempty"`
+	Challenge       string `json:"challenge,omitempty"`
+	Signature       string `json:"signature,omitempty"`
+}
+
+// RequestRecoveryRequest is the body of RequestRecovery. Zero fields are left out.
+type RequestRecoveryRequest struct {
+	RecoveryAlg       string `json:"recovery_alg,omitempty"`
+	RecoveryPublicKey string `json:"recovery_public_key,omitempty"`
+	NewAlg            string `json:"new_alg,omitempty"`
+	NewPublicKey      string `json:"new_public_key,omitempty"`
+	Challenge         string `json:"challenge,omitempty"`
+	RecoverySignature string `json:"recovery_signature,omitempty"`
+	NewSignature      string `json:"new_signature,omitempty"`
+}
+
+// AdminBanRequest is the body of AdminBan. Zero fields are left out.
+type AdminBanRequest struct {
+	AccountID int64  `json:"account_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// AdminDeleteAccountRequest is the body of AdminDeleteAccount. Zero fields are left out.
+type AdminDeleteAccountRequest struct {
+	AccountID int64 `json:"account_id,omitempty"`
+}
+
+// AdminBanDomainRequest is the body of AdminBanDomain. Zero fields are left out.
+type AdminBanDomainRequest struct {
+	Domain string `json:"domain,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// AdminHideRequest is the body of AdminHide. Zero fields are left out.
+type AdminHideRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+}
+
+// AdminCreateInvitesRequest is the body of AdminCreateInvites. Zero fields are left out.
+type AdminCreateInvitesRequest struct {
+	Count int64  `json:"count,omitempty"`
+	Note  string `json:"note,omitempty"`
+}
+
+// AdminAddModNoteRequest is the body of AdminAddModNote. Zero fields are left out.
+type AdminAddModNoteRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	Text       string `json:"text,omitempty"`
+}
+
+// AdminSetPenaltyRequest is the body of AdminSetPenalty. Zero fields are left out.
+type AdminSetPenaltyRequest struct {
+	TargetType string  `json:"target_type,omitempty"`
+	TargetID   int64   `json:"target_id,omitempty"`
+	Domain     string  `json:"domain,omitempty"`
+	Weight     float64 `json:"weight,omitempty"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// AdminResolveFlagsRequest is the body of AdminResolveFlags. Zero fields are left out.
+type AdminResolveFlagsRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	Resolution string `json:"resolution,omitempty"`
+}
+
+// AdminRevokeTokenRequest is the body of AdminRevokeToken. Zero fields are left out.
+type AdminRevokeTokenRequest struct {
+	Token string `json:"token,omitempty"`
+}
+
+// AdminUnbanRequest is the body of AdminUnban. Zero fields are left out.
+type AdminUnbanRequest struct {
+	AccountID int64 `json:"account_id,omitempty"`
+}
+
+// AdminUnhideRequest is the body of AdminUnhide. Zero fields are left out.
+type AdminUnhideRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+}
+
+// CreateAPIKeyRequest is the body of CreateAPIKey. Zero fields are left out.
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name,omitempty"`
+	Scopes        []string `json:"scopes,omitempty"`
+	ExpiresInDays int64    `json:"expires_in_days,omitempty"`
+}
+
+// AuthChallengeRequest is the body of AuthChallenge. Zero fields are left out.
+type AuthChallengeRequest struct {
+	Alg string `json:"alg,omitempty"`
+}
+
+// AuthVerifyRequest is the body of AuthVerify. Zero fields are left out.
+type AuthVerifyRequest struct {
+	Alg       string `json:"alg,omitempty"`
+	PublicKey string `json:"public_key,omitempty"`
+	Challenge string `json:"challenge,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// CreateCommentRequest is the body of CreateComment. Zero fields are left out.
+type CreateCommentRequest struct {
+	StoryID       int64           `json:"story_id,omitempty"`
+	ParentID      int64           `json:"parent_id,omitempty"`
+	Text          string          `json:"text,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PayloadSchema string          `json:"payload_schema,omitempty"`
+}
+
+// CreateFlagRequest is the body of CreateFlag. Zero fields are left out.
+type CreateFlagRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// SetEmailSenderRequest is the body of SetEmailSender. Zero fields are left out.
+type SetEmailSenderRequest struct {
+	Address string `json:"address,omitempty"`
+}
+
+// ToggleReactionRequest is the body of ToggleReaction. Zero fields are left out.
+type ToggleReactionRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	Reaction   string `json:"reaction,omitempty"`
+}
+
+// SetScheduleRequest is the body of SetSchedule. Zero fields are left out.
+type SetScheduleRequest struct {
+	QuietStart  string `json:"quiet_start,omitempty"`
+	QuietEnd    string `json:"quiet_end,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+	MinInterval string `json:"min_interval,omitempty"`
+}
+
+// CreateStoryRequest is the body of CreateStory. Zero fields are left out.
+type CreateStoryRequest struct {
+	Title string   `json:"title,omitempty"`
+	URL   string   `json:"url,omitempty"`
+	Text  string   `json:"text,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+	Kind  string   `json:"kind,omitempty"`
+}
+
+// EditStoryRequest is the body of EditStory. Zero fields are left out.
+type EditStoryRequest struct {
+	Title string   `json:"title,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// LockStoryRequest is the body of LockStory. Zero fields are left out.
+type LockStoryRequest struct {
+	Locked bool   `json:"locked,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// PinCommentRequest is the body of PinComment. Zero fields are left out.
+type PinCommentRequest struct {
+	CommentID int64 `json:"comment_id,omitempty"`
+}
+
+// SaveStoryTemplateRequest is the body of SaveStoryTemplate. Zero fields are left out.
+type SaveStoryTemplateRequest struct {
+	Name  string   `json:"name,omitempty"`
+	Title string   `json:"title,omitempty"`
+	URL   string   `json:"url,omitempty"`
+	Text  string   `json:"text,omitempty"`
+	Tags  []string `json:"tags,omitempty"`
+}
+
+// PostFromTemplateRequest is the body of PostFromTemplate. Zero fields are left out.
+type PostFromTemplateRequest struct {
+	Vars map[string]string `json:"vars,omitempty"`
+}
+
+// CreateVoteRequest is the body of CreateVote. Zero fields are left out.
+type CreateVoteRequest struct {
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	Value      int64  `json:"value,omitempty"`
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	if got := GetStory.Path(42); got != "/api/stories/42" {
+		t.Errorf("GetStory.Path(42) = %q", got)
+	}
+	if got := DeleteAccountKey.Path(1, "a/b"); got != "/api/accounts/1/keys/a%2Fb" {
+		t.Errorf("DeleteAccountKey.Path = %q", got)
+	}
+	if got := ListStories.Path(); got != "/api/stories" {
+		t.Errorf("ListStories.Path() = %q", got)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic for a missing argument")
+		}
+	}()
+	GetStory.Path()
+}
+
+func TestMethods(t *testing.T) {
+	for path, want := range map[string][]string{
+		"/api/stories":          {"GET", "POST"},
+		"/api/stories/42":       {"DELETE", "GET", "PATCH"},
+		"/api/accounts/profile": {"POST"},
+		"/api/accounts/7":       {"GET"},
+		"/api/nothing":          nil,
+		"/api/stories/42/nope":  nil,
+	} {
+		got := Methods(path)
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("Methods(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package sqlite
 
 import (
 	"context"
+	"maps"
 	"testing"
 	"time"
 
@@ -54,3 +55,121 @@ func TestAccountKeys(t *testing.T) {
 		t.Fatalf("expected revoked_at set")
 	}
 }
+
+func TestDeletedAccountAPIKeys(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	accountID, _, err := st.CreateAccount(ctx, &model.Account{DisplayName: "Bot", CreatedAt: time.Now()}, &model.AccountKey{Alg: "ed25519", PublicKey: "pubkey", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	if _, err := st.CreateAPIKey(ctx, &model.APIKey{AccountID: accountID, Prefix: "sbk_a", CreatedAt: time.Now()}, "hash-a"); err != nil {
+		t.Fatalf("create api key: %v", err)
+	}
+	// A key orphaned before account deletion cleaned up api_keys.
+	if _, err := st.CreateAPIKey(ctx, &model.APIKey{AccountID: accountID + 100, Prefix: "sbk_b", CreatedAt: time.Now()}, "hash-b"); err != nil {
+		t.Fatalf("create orphan api key: %v", err)
+	}
+	if _, err := st.GetAPIKeyByHash(ctx, "hash-b"); err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound for an orphaned key, got %v", err)
+	}
+
+	if err := st.DeleteAccount(ctx, accountID); err != nil {
+		t.Fatalf("delete account: %v", err)
+	}
+	if _, err := st.GetAPIKeyByHash(ctx, "hash-a"); err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound after account deletion, got %v", err)
+	}
+	keys, err := st.ListAPIKeys(ctx, accountID)
+	if err != nil {
+		t.Fatalf("list api keys: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("expected the account's api keys deleted, got %d", len(keys))
+	}
+}
+
+func TestAccountActivitySummary(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	account := model.Account{DisplayName: "Regular", CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(ctx, &account, &model.AccountKey{Alg: "ed25519", PublicKey: "regular-key", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	now := time.Now()
+	day := 24 * time.Hour
+	stories := []model.Story{
+		{Title: "Link", URL: "https://example.com", CreatedAt: now.Add(-10 * day), AccountID: accountID},
+		{Title: "Ask", Text: "?", Tags: []string{"ask"}, CreatedAt: now.Add(-9 * day), AccountID: accountID},
+		{Title: "Text", Text: "t", CreatedAt: now.Add(-8 * day), AccountID: accountID},
+		{Title: "Hidden", Text: "t", CreatedAt: now.Add(-20 * day), Hidden: true, AccountID: accountID},
+	}
+	var storyID int64
+	for i := range stories {
+		if storyID, err = st.CreateStory(ctx, &stories[i]); err != nil {
+			t.Fatalf("create story: %v", err)
+		}
+	}
+	top := model.Comment{StoryID: storyID, Text: "top", CreatedAt: now.Add(-day), AccountID: accountID}
+	topID, err := st.CreateComment(ctx, &top)
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	for _, c := range []model.Comment{
+		{StoryID: storyID, ParentID: &topID, Text: "reply", CreatedAt: now, AccountID: accountID},
+		{StoryID: storyID, Text: "proposal", Kind: model.CommentProposal, CreatedAt: now, AccountID: accountID},
+	} {
+		if _, err := st.CreateComment(ctx, &c); err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+	}
+	if err := st.CreateVote(ctx, &model.Vote{TargetType: "story", TargetID: storyID, Value: 1, AccountID: accountID, CreatedAt: now}); err != nil {
+		t.Fatalf("create vote: %v", err)
+	}
+
+	summary, err := st.GetAccountActivitySummary(ctx, accountID)
+	if err != nil {
+		t.Fatalf("summary: %v", err)
+	}
+	if summary.StoriesSubmitted != 3 || summary.CommentsPosted != 3 || summary.VotesCast != 1 {
+		t.Errorf("expected 3 stories, 3 comments and 1 vote, got %+v", summary)
+	}
+	wantStories := map[string]int{model.KindLink: 1, model.KindAsk: 1, model.KindText: 1}
+	wantComments := map[string]int{model.CommentTopLevel: 1, model.CommentReply: 1, model.CommentProposal: 1}
+	if !maps.Equal(summary.StoriesByKind, wantStories) || !maps.Equal(summary.CommentsByKind, wantComments) {
+		t.Errorf("expected by-kind counts %v and %v, got %v and %v", wantStories, wantComments, summary.StoriesByKind, summary.CommentsByKind)
+	}
+	// Days 10, 9 and 8 ago make the longest run; yesterday and today the
+	// current one. The hidden story doesn't count.
+	if summary.DaysActive != 5 || summary.LongestStreak != 3 || summary.CurrentStreak != 2 {
+		t.Errorf("expected 5 days active, longest streak 3 and current 2, got %d, %d and %d", summary.DaysActive, summary.LongestStreak, summary.CurrentStreak)
+	}
+	if !summary.FirstActivity.Equal(stories[0].CreatedAt.Truncate(time.Millisecond)) || summary.LastActivity.Before(now.Add(-time.Second)) {
+		t.Errorf("expected first and last activity from the oldest story and newest comment, got %v and %v", summary.FirstActivity, summary.LastActivity)
+	}
+}
+
+func TestActivityStreaks(t *testing.T) {
+	now := time.Date(2026, 3, 10, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		days             []string
+		current, longest int
+	}{
+		{nil, 0, 0},
+		{[]string{"2026-03-10"}, 1, 1},
+		{[]string{"2026-03-08", "2026-03-09"}, 2, 2},
+		{[]string{"2026-03-01", "2026-03-02", "2026-03-03", "2026-03-08"}, 0, 3},
+		{[]string{"2026-02-27", "2026-02-28", "2026-03-01", "2026-03-09", "2026-03-10"}, 2, 3},
+	}
+	for _, tc := range cases {
+		current, longest := activityStreaks(tc.days, now)
+		if current != tc.current || longest != tc.longest {
+			t.Errorf("activityStreaks(%v) = %d, %d; want %d, %d", tc.days, current, longest, tc.current, tc.longest)
+		}
+	}
+}
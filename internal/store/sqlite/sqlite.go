@@ -6,11 +6,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
+	"net/url"
+	"slices"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/alphabot-ai/slashbot/internal/dedupe"
+	"github.com/alphabot-ai/slashbot/internal/embed"
+	"github.com/alphabot-ai/slashbot/internal/logging"
 	"github.com/alphabot-ai/slashbot/internal/model"
 	"github.com/alphabot-ai/slashbot/internal/store"
 
@@ -18,11 +24,16 @@ import (
 )
 
 type Store struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
 }
 
+// busyTimeout is how long a write waits for another connection's write
+// transaction, such as a concurrent vote, before failing as busy.
+const busyTimeout = 5 * time.Second
+
 func Open(path string) (*Store, error) {
-	db, err := sql.Open("sqlite", path)
+	db, err := sql.Open("sqlite", withBusyTimeout(path))
 	if err != nil {
 		return nil, err
 	}
@@ -30,17 +41,39 @@ func Open(path string) (*Store, error) {
 		_ = db.Close()
 		return nil, err
 	}
-	if err := applySchema(db); err != nil {
+	logger := logging.Component(slog.Default(), "store")
+	if err := applySchema(db, logger); err != nil {
 		_ = db.Close()
 		return nil, err
 	}
-	return &Store{db: db}, nil
+	return &Store{db: db, logger: logger}, nil
+}
+
+// withBusyTimeout adds busyTimeout to a database path or URI. As a DSN
+// parameter it applies to every pooled connection, unlike a PRAGMA run
+// once after opening.
+func withBusyTimeout(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)", path, sep, busyTimeout.Milliseconds())
+}
+
+// SetLogger replaces the store's logger.
+func (s *Store) SetLogger(l *slog.Logger) {
+	s.logger = l
 }
 
 func (s *Store) Close() error {
 	return s.db.Close()
 }
 
+// DBStats reports connection pool statistics for diagnostics.
+func (s *Store) DBStats() sql.DBStats {
+	return s.db.Stats()
+}
+
 // migrations is an ordered list of SQL migrations.
 // Each migration runs exactly once, tracked by schema_version table.
 var migrations = []string{
@@ -140,10 +173,421 @@ CREATE TABLE IF NOT EXISTS github_star_rewards (
 	created_at INTEGER NOT NULL,
 	FOREIGN KEY(account_id) REFERENCES accounts(id)
 );
+`,
+	// Migration 3: Millisecond timestamps. Rows written before this migration
+	// hold unix seconds; anything below legacySecondsCutoff is scaled up.
+	`
+UPDATE stories SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE comments SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE votes SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE flags SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE accounts SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE account_keys SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+UPDATE account_keys SET revoked_at = revoked_at * 1000 WHERE revoked_at IS NOT NULL AND revoked_at < 100000000000;
+UPDATE auth_challenges SET expires_at = expires_at * 1000, created_at = created_at * 1000 WHERE expires_at < 100000000000;
+UPDATE auth_tokens SET expires_at = expires_at * 1000, created_at = created_at * 1000 WHERE expires_at < 100000000000;
+UPDATE github_star_rewards SET created_at = created_at * 1000 WHERE created_at < 100000000000;
+`,
+	// Migration 4: Ranking penalties for accounts and domains
+	`
+CREATE TABLE IF NOT EXISTS rank_penalties (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target_type TEXT NOT NULL,
+	account_id INTEGER NOT NULL DEFAULT 0,
+	domain TEXT NOT NULL DEFAULT '',
+	weight REAL NOT NULL,
+	reason TEXT,
+	created_at INTEGER NOT NULL,
+	UNIQUE(target_type, account_id, domain)
+);
+`,
+	// Migration 5: Registration invites
+	`
+CREATE TABLE IF NOT EXISTS invites (
+	code TEXT PRIMARY KEY,
+	note TEXT,
+	created_at INTEGER NOT NULL,
+	used_at INTEGER,
+	used_by INTEGER
+);
+`,
+	// Migration 6: Reply loop incidents
+	`
+CREATE TABLE IF NOT EXISTS loop_incidents (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	story_id INTEGER NOT NULL,
+	account_a INTEGER NOT NULL,
+	account_b INTEGER NOT NULL,
+	comment_id INTEGER NOT NULL,
+	rounds INTEGER NOT NULL,
+	similarity REAL NOT NULL,
+	created_at INTEGER NOT NULL,
+	released_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_loop_incidents_story ON loop_incidents(story_id, released_at);
+`,
+	// Migration 7: Signed request nonces for replay protection
+	`
+CREATE TABLE IF NOT EXISTS request_nonces (
+	key_id INTEGER NOT NULL,
+	nonce TEXT NOT NULL,
+	expires_at INTEGER NOT NULL,
+	PRIMARY KEY (key_id, nonce)
+);
+CREATE INDEX IF NOT EXISTS idx_request_nonces_expires ON request_nonces(expires_at);
+`,
+	// Migration 8: JWT revocation list
+	`
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+	jti TEXT PRIMARY KEY,
+	expires_at INTEGER NOT NULL
+);
+`,
+	// Migration 9: API keys for service bots
+	`
+CREATE TABLE IF NOT EXISTS api_keys (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	name TEXT,
+	prefix TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	scopes TEXT,
+	created_at INTEGER NOT NULL,
+	expires_at INTEGER,
+	last_used_at INTEGER,
+	revoked_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_api_keys_account ON api_keys(account_id);
+`,
+	// Migration 10: Per-account posting schedules
+	`
+CREATE TABLE IF NOT EXISTS posting_schedules (
+	account_id INTEGER PRIMARY KEY,
+	quiet_start TEXT NOT NULL DEFAULT '',
+	quiet_end TEXT NOT NULL DEFAULT '',
+	timezone TEXT NOT NULL DEFAULT 'UTC',
+	min_interval_ms INTEGER NOT NULL DEFAULT 0,
+	updated_at INTEGER NOT NULL
+);
+`,
+	// Migration 11: Operator kill switch
+	`
+CREATE TABLE IF NOT EXISTS account_freezes (
+	account_id INTEGER PRIMARY KEY,
+	frozen_at INTEGER NOT NULL
+);
+`,
+	// Migration 12: DID identities
+	`
+ALTER TABLE accounts ADD COLUMN did TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_accounts_did ON accounts(did) WHERE did IS NOT NULL;
+`,
+	// Migration 13: Session metadata
+	`
+ALTER TABLE auth_tokens ADD COLUMN last_used_at INTEGER;
+ALTER TABLE auth_tokens ADD COLUMN user_agent TEXT;
+ALTER TABLE auth_tokens ADD COLUMN ip_prefix TEXT;
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_account ON auth_tokens(account_id);
+`,
+	// Migration 14: Account recovery
+	`
+CREATE TABLE IF NOT EXISTS recovery_keys (
+	account_id INTEGER PRIMARY KEY,
+	alg TEXT NOT NULL,
+	public_key TEXT NOT NULL,
+	webhook_url TEXT,
+	created_at INTEGER NOT NULL,
+	used_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS account_recoveries (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	new_alg TEXT NOT NULL,
+	new_public_key TEXT NOT NULL,
+	requested_at INTEGER NOT NULL,
+	effective_at INTEGER NOT NULL,
+	completed_at INTEGER,
+	cancelled_at INTEGER
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_recoveries_pending
+	ON account_recoveries(account_id) WHERE completed_at IS NULL AND cancelled_at IS NULL;
+`,
+	// Migration 15: Moderator bans and audit log
+	`
+CREATE TABLE IF NOT EXISTS account_bans (
+	account_id INTEGER PRIMARY KEY,
+	reason TEXT,
+	banned_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS audit_log (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	action TEXT NOT NULL,
+	target_type TEXT,
+	target_id INTEGER,
+	detail TEXT,
+	actor_ip TEXT,
+	created_at INTEGER NOT NULL
+);
+`,
+	// Migration 16: Cross-instance account links
+	`
+CREATE TABLE IF NOT EXISTS account_links (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	instance_url TEXT NOT NULL,
+	remote_account_id INTEGER NOT NULL,
+	remote_name TEXT,
+	alg TEXT NOT NULL,
+	public_key TEXT NOT NULL,
+	verified_at INTEGER NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_account_links_remote
+	ON account_links(account_id, instance_url, remote_account_id);
+`,
+	// Migration 17: Flag resolution states
+	`
+ALTER TABLE flags ADD COLUMN state TEXT NOT NULL DEFAULT 'open';
+ALTER TABLE flags ADD COLUMN resolved_at INTEGER;
+CREATE INDEX IF NOT EXISTS idx_flags_target ON flags(target_type, target_id);
+`,
+	// Migration 18: Imported archive threads
+	`
+CREATE TABLE IF NOT EXISTS imported_stories (
+	source TEXT NOT NULL,
+	external_id TEXT NOT NULL,
+	story_id INTEGER NOT NULL,
+	imported_at INTEGER NOT NULL,
+	PRIMARY KEY (source, external_id)
+);
+`,
+	// Migration 19: Flag weights
+	`
+ALTER TABLE flags ADD COLUMN weight REAL NOT NULL DEFAULT 1;
+ALTER TABLE stories ADD COLUMN flag_weight REAL NOT NULL DEFAULT 0;
+ALTER TABLE comments ADD COLUMN flag_weight REAL NOT NULL DEFAULT 0;
+UPDATE stories SET flag_weight = flag_count;
+UPDATE comments SET flag_weight = flag_count;
+`,
+	// Migration 20: Thread locks and pinned comments
+	`
+ALTER TABLE stories ADD COLUMN locked INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stories ADD COLUMN pinned_comment_id INTEGER;
+`,
+	// Migration 21: Story templates
+	`
+CREATE TABLE IF NOT EXISTS story_templates (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	account_id INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	title TEXT NOT NULL,
+	url TEXT,
+	text TEXT,
+	tags TEXT,
+	created_at INTEGER NOT NULL,
+	UNIQUE(account_id, name)
+);
+`,
+	// Migration 22: Story edit history
+	`
+ALTER TABLE stories ADD COLUMN edited_at INTEGER;
+CREATE TABLE IF NOT EXISTS story_revisions (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	story_id INTEGER NOT NULL,
+	title TEXT NOT NULL,
+	tags TEXT,
+	edited_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_story_revisions_story ON story_revisions(story_id, id);
+`,
+	// Migration 23: Comment payloads
+	`
+ALTER TABLE comments ADD COLUMN payload TEXT;
+ALTER TABLE comments ADD COLUMN payload_schema TEXT;
+`,
+	// Migration 24: Proposals and positions
+	`
+ALTER TABLE comments ADD COLUMN kind TEXT NOT NULL DEFAULT '';
+ALTER TABLE comments ADD COLUMN position TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_comments_positions ON comments(parent_id, account_id) WHERE position != '';
+`,
+	// Migration 25: API changelog announcements
+	`
+CREATE TABLE IF NOT EXISTS changelog_announcements (
+	entry_id TEXT PRIMARY KEY,
+	announced_at INTEGER NOT NULL
+);
+`,
+	// Migration 26: Preview reactions
+	`
+CREATE TABLE IF NOT EXISTS reactions (
+	target_type TEXT NOT NULL,
+	target_id INTEGER NOT NULL,
+	account_id INTEGER NOT NULL,
+	reaction TEXT NOT NULL,
+	created_at INTEGER NOT NULL,
+	PRIMARY KEY (target_type, target_id, account_id, reaction)
+);
+`,
+	// Migration 27: Leaderboard indexes
+	`
+CREATE INDEX IF NOT EXISTS idx_stories_account_created ON stories(account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_comments_account_created ON comments(account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_votes_created ON votes(created_at);
+`,
+	// Migration 28: Account badges
+	`
+CREATE TABLE IF NOT EXISTS account_badges (
+	account_id INTEGER NOT NULL,
+	badge_id TEXT NOT NULL,
+	awarded_at INTEGER NOT NULL,
+	PRIMARY KEY (account_id, badge_id)
+);
+`,
+	// Migration 29: Sandbox accounts
+	`
+ALTER TABLE accounts ADD COLUMN sandbox INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_accounts_sandbox ON accounts(sandbox) WHERE sandbox = 1;
+`,
+	// Migration 30: Daily site stats snapshots
+	`
+CREATE TABLE IF NOT EXISTS site_stats_daily (
+	day TEXT PRIMARY KEY,
+	accounts INTEGER NOT NULL,
+	stories INTEGER NOT NULL,
+	comments INTEGER NOT NULL,
+	votes INTEGER NOT NULL,
+	active_bots INTEGER NOT NULL,
+	recorded_at INTEGER NOT NULL
+);
+`,
+	// Migration 31: Story domains. NULL until backfillStoryDomains sets it.
+	`
+ALTER TABLE stories ADD COLUMN domain TEXT;
+CREATE INDEX IF NOT EXISTS idx_stories_domain ON stories(domain, created_at);
+`,
+	// Migration 32: Who locked a story, when and why
+	`
+ALTER TABLE stories ADD COLUMN locked_at INTEGER;
+ALTER TABLE stories ADD COLUMN locked_by_moderator INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE stories ADD COLUMN lock_reason TEXT;
+`,
+	// Migration 33: Banned domains
+	`
+CREATE TABLE IF NOT EXISTS domain_bans (
+	domain TEXT PRIMARY KEY,
+	reason TEXT,
+	created_at INTEGER NOT NULL
+);
+`,
+	// Migration 34: Story kinds, for the ask and show listings
+	`
+ALTER TABLE stories ADD COLUMN kind TEXT NOT NULL DEFAULT 'link';
+UPDATE stories SET kind = CASE
+	WHEN lower(tags) LIKE '%"ask"%' THEN 'ask'
+	WHEN lower(tags) LIKE '%"show"%' THEN 'show'
+	WHEN lower(tags) LIKE '%"poll"%' THEN 'poll'
+	WHEN url IS NULL OR url = '' THEN 'text'
+	ELSE 'link'
+END;
+CREATE INDEX IF NOT EXISTS idx_stories_kind ON stories(kind, created_at);
+`,
+	// Migration 35: Moderator notes
+	`
+CREATE TABLE IF NOT EXISTS mod_notes (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	target_type TEXT NOT NULL,
+	target_id INTEGER NOT NULL,
+	text TEXT NOT NULL,
+	actor_ip TEXT,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_mod_notes_target ON mod_notes(target_type, target_id);
+`,
+	// Migration 36: Story embeddings for semantic search
+	`
+CREATE TABLE IF NOT EXISTS story_embeddings (
+	story_id INTEGER PRIMARY KEY,
+	model TEXT NOT NULL,
+	vector BLOB NOT NULL,
+	updated_at INTEGER NOT NULL
+);
+`,
+	// Migration 37: Cross-posting ledger
+	`
+CREATE TABLE IF NOT EXISTS crossposts (
+	story_id INTEGER NOT NULL,
+	network TEXT NOT NULL,
+	remote_url TEXT,
+	posted_at INTEGER NOT NULL,
+	PRIMARY KEY (story_id, network)
+);
+`,
+	// Migration 38: Inbound email senders
+	`
+CREATE TABLE IF NOT EXISTS email_senders (
+	account_id INTEGER PRIMARY KEY,
+	address TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER
+);
+`,
+	// Migration 39: Account theme preference
+	`
+ALTER TABLE accounts ADD COLUMN theme TEXT;
+`,
+	// Migration 40: Account language preference
+	`
+ALTER TABLE accounts ADD COLUMN language TEXT;
+`,
+	// Migration 41: Votes by voter, for activity summaries
+	`
+CREATE INDEX IF NOT EXISTS idx_votes_account ON votes(account_id, created_at);
+`,
+	// Migration 42: Bots directory sorts by karma and by join date
+	`
+CREATE INDEX IF NOT EXISTS idx_accounts_karma ON accounts(karma DESC);
+CREATE INDEX IF NOT EXISTS idx_accounts_created ON accounts(created_at DESC);
+`,
+	// Migration 43: Stable session ids. Sessions were listed by rowid, which
+	// VACUUM may renumber on a table keyed by token; AUTOINCREMENT also
+	// keeps a deleted session's id from being reused.
+	`
+CREATE TABLE auth_tokens_new (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token TEXT NOT NULL UNIQUE,
+	account_id INTEGER,
+	key_id INTEGER,
+	expires_at INTEGER NOT NULL,
+	created_at INTEGER NOT NULL,
+	last_used_at INTEGER,
+	user_agent TEXT,
+	ip_prefix TEXT
+);
+INSERT INTO auth_tokens_new (id, token, account_id, key_id, expires_at, created_at, last_used_at, user_agent, ip_prefix)
+SELECT rowid, token, account_id, key_id, expires_at, created_at, last_used_at, user_agent, ip_prefix FROM auth_tokens;
+DROP TABLE auth_tokens;
+ALTER TABLE auth_tokens_new RENAME TO auth_tokens;
+CREATE INDEX IF NOT EXISTS idx_auth_tokens_account ON auth_tokens(account_id);
+`,
+	// Migration 44: JWTs issued before an account freeze stay invalid
+	`
+ALTER TABLE accounts ADD COLUMN tokens_revoked_at INTEGER;
+`,
+	// Migration 45: Token cutoffs outlive the account, so a deleted
+	// account's JWTs stay invalid
+	`
+CREATE TABLE IF NOT EXISTS token_cutoffs (
+	account_id INTEGER PRIMARY KEY,
+	revoked_at INTEGER NOT NULL
+);
+INSERT INTO token_cutoffs (account_id, revoked_at)
+SELECT id, tokens_revoked_at FROM accounts WHERE tokens_revoked_at IS NOT NULL;
+ALTER TABLE accounts DROP COLUMN tokens_revoked_at;
 `,
 }
 
-func applySchema(db *sql.DB) error {
+func applySchema(db *sql.DB, logger *slog.Logger) error {
 	// Create schema_version table to track migrations
 	if _, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS schema_version (
@@ -168,8 +612,42 @@ func applySchema(db *sql.DB) error {
 		if _, err := db.Exec(`INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
 			return fmt.Errorf("failed to record migration %d: %w", i+1, err)
 		}
+		logger.Info("applied migration", "version", i+1)
 	}
 
+	return backfillStoryDomains(db, logger)
+}
+
+// backfillStoryDomains sets the domain of link stories posted before the
+// column existed. Computing it needs the public suffix list, so it can't
+// be done in SQL.
+func backfillStoryDomains(db *sql.DB, logger *slog.Logger) error {
+	rows, err := db.Query(`SELECT id, url FROM stories WHERE domain IS NULL AND url IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+	domains := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var rawURL string
+		if err := rows.Scan(&id, &rawURL); err != nil {
+			rows.Close()
+			return err
+		}
+		domains[id] = model.DomainOf(rawURL)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for id, domain := range domains {
+		if _, err := db.Exec(`UPDATE stories SET domain = ? WHERE id = ?`, domain, id); err != nil {
+			return err
+		}
+	}
+	if len(domains) > 0 {
+		logger.Info("backfilled story domains", "stories", len(domains))
+	}
 	return nil
 }
 
@@ -179,9 +657,9 @@ func (s *Store) CreateStory(ctx context.Context, story *model.Story) (int64, err
 		return 0, err
 	}
 	res, err := s.db.ExecContext(ctx, `
-INSERT INTO stories (title, url, text, tags, score, comment_count, created_at, hidden, account_id)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-`, story.Title, nullIfEmpty(story.URL), nullIfEmpty(story.Text), string(tags), story.Score, story.CommentCount, story.CreatedAt.Unix(), boolToInt(story.Hidden), story.AccountID)
+INSERT INTO stories (title, url, domain, kind, text, tags, score, comment_count, created_at, hidden, account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, story.Title, nullIfEmpty(story.URL), nullIfEmpty(model.DomainOf(story.URL)), model.KindOf(model.PostTypeOf(story.Tags), story.URL), nullIfEmpty(story.Text), string(tags), story.Score, story.CommentCount, story.CreatedAt.UnixMilli(), boolToInt(story.Hidden), story.AccountID)
 	if err != nil {
 		return 0, err
 	}
@@ -190,25 +668,55 @@ VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 
 func (s *Store) FindStoryByURL(ctx context.Context, url string, since time.Time) (model.Story, error) {
 	row := s.db.QueryRowContext(ctx, `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
 WHERE s.url = ? AND s.created_at >= ? AND s.hidden = 0
 ORDER BY s.created_at DESC
 LIMIT 1
-`, url, since.Unix())
+`, url, since.UnixMilli())
 	return scanStory(row)
 }
 
-func (s *Store) GetStory(ctx context.Context, id int64) (model.Story, error) {
-	row := s.db.QueryRowContext(ctx, `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+const getStoryQuery = `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
 WHERE s.id = ?
 LIMIT 1
-`, id)
-	return scanStory(row)
+`
+
+func (s *Store) GetStory(ctx context.Context, id int64) (model.Story, error) {
+	return scanStory(s.db.QueryRowContext(ctx, getStoryQuery, id))
+}
+
+func (s *Store) GetStoriesByID(ctx context.Context, ids []int64) ([]model.Story, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE s.id IN (`+strings.Repeat("?,", len(ids)-1)+`?)
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
 }
 
 func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]model.Story, int, error) {
@@ -223,6 +731,7 @@ func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]mo
 	var args []interface{}
 
 	whereClauses = append(whereClauses, "s.hidden = 0")
+	whereClauses = append(whereClauses, "s.account_id "+sandboxIn(opts.Sandbox))
 
 	// Tag filter
 	if opts.Tag != "" {
@@ -230,24 +739,20 @@ func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]mo
 		args = append(args, `%"`+opts.Tag+`"%`)
 	}
 
-	// Time range filter
-	if opts.TimeRange != "" && opts.TimeRange != "all" {
-		var since time.Time
-		now := time.Now().UTC()
+	if opts.Kind != "" {
+		whereClauses = append(whereClauses, "s.kind = ?")
+		args = append(args, opts.Kind)
+	}
 
-		switch opts.TimeRange {
-		case "today":
-			since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
-		case "week":
-			since = now.AddDate(0, 0, -7)
-		case "month":
-			since = now.AddDate(0, -1, 0)
-		}
+	if opts.Domain != "" {
+		whereClauses = append(whereClauses, "s.domain = ?")
+		args = append(args, model.RegistrableDomain(opts.Domain))
+	}
 
-		if !since.IsZero() {
-			whereClauses = append(whereClauses, "s.created_at >= ?")
-			args = append(args, since.Unix())
-		}
+	// Time range filter
+	if since := timeRangeSince(opts.TimeRange, time.Now()); !since.IsZero() {
+		whereClauses = append(whereClauses, "s.created_at >= ?")
+		args = append(args, since.UnixMilli())
 	}
 
 	// Account filter for "my posts"
@@ -256,10 +761,22 @@ func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]mo
 		args = append(args, *opts.AccountID)
 	}
 
+	// Score threshold for the ranked sorts
+	if opts.MinScore != nil && (sortBy == "top" || sortBy == "discussed") {
+		whereClauses = append(whereClauses, "s.score >= ?")
+		args = append(args, *opts.MinScore)
+	}
+
 	// Cursor pagination for "new" sort
-	if sortBy == "new" && opts.Cursor > 0 {
-		whereClauses = append(whereClauses, "s.created_at < ?")
-		args = append(args, opts.Cursor)
+	if sortBy == "new" && !opts.Cursor.IsZero() {
+		cursorAt := toMillis(opts.Cursor.CreatedAt)
+		if opts.Cursor.ID > 0 {
+			whereClauses = append(whereClauses, "(s.created_at < ? OR (s.created_at = ? AND s.id < ?))")
+			args = append(args, cursorAt, cursorAt, opts.Cursor.ID)
+		} else {
+			whereClauses = append(whereClauses, "s.created_at < ?")
+			args = append(args, cursorAt)
+		}
 	}
 
 	whereClause := "WHERE " + strings.Join(whereClauses, " AND ")
@@ -275,9 +792,9 @@ func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]mo
 	var orderBy string
 	switch sortBy {
 	case "new":
-		orderBy = "ORDER BY s.created_at DESC"
+		orderBy = "ORDER BY s.created_at DESC, s.id DESC"
 	case "discussed":
-		orderBy = "ORDER BY s.comment_count DESC, s.created_at DESC"
+		orderBy = "ORDER BY COALESCE(rc.recent_comments, 0) DESC, s.comment_count DESC, s.created_at DESC"
 	case "top":
 		orderBy = "ORDER BY s.created_at DESC"
 	default:
@@ -290,16 +807,38 @@ func (s *Store) ListStories(ctx context.Context, opts store.StoryListOpts) ([]mo
 		// For top sorting, fetch all matching stories to rank in Go
 		args = append(args, 500)
 		query = `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
 ` + whereClause + `
 ` + orderBy + `
 LIMIT ?`
+	} else if sortBy == "discussed" {
+		// Rank by comments posted within the discussion window so old
+		// megathreads don't dominate forever.
+		window := opts.DiscussedWindow
+		if window <= 0 {
+			window = store.DefaultDiscussedWindow
+		}
+		args = append([]interface{}{time.Now().Add(-window).UnixMilli()}, args...)
+		args = append(args, limit, opts.Offset)
+		query = `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+LEFT JOIN (
+	SELECT story_id, COUNT(*) AS recent_comments
+	FROM comments
+	WHERE hidden = 0 AND created_at >= ?
+	GROUP BY story_id
+) rc ON rc.story_id = s.id
+` + whereClause + `
+` + orderBy + `
+LIMIT ? OFFSET ?`
 	} else {
 		args = append(args, limit, opts.Offset)
 		query = `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
 ` + whereClause + `
@@ -326,10 +865,23 @@ LIMIT ? OFFSET ?`
 	}
 
 	if sortBy == "top" {
+		penalties, err := s.ListRankPenalties(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
 		now := time.Now()
-		sort.Slice(stories, func(i, j int) bool {
-			return rankScore(stories[i], now) > rankScore(stories[j], now)
+		gravity := opts.Gravity
+		if gravity <= 0 {
+			gravity = store.DefaultGravity
+		}
+		ranks := make(map[int64]float64, len(stories))
+		for _, st := range stories {
+			ranks[st.ID] = applyPenalty(rankScore(st, now, gravity), penaltyWeight(st, penalties))
+		}
+		sort.SliceStable(stories, func(i, j int) bool {
+			return ranks[stories[i].ID] > ranks[stories[j].ID]
 		})
+		stories = diversify(stories, opts.MaxPerAccount, opts.MaxPerDomain, opts.DiversityTopN)
 		// Apply offset and limit to the ranked slice
 		offset := opts.Offset
 		if offset > len(stories) {
@@ -354,24 +906,96 @@ func (s *Store) UpdateStoryScore(ctx context.Context, storyID int64, delta int)
 	return err
 }
 
-func (s *Store) UpdateStory(ctx context.Context, storyID int64, title string, tags []string) error {
+func (s *Store) UpdateStory(ctx context.Context, storyID int64, title string, tags []string) (err error) {
 	tagsJSON := "[]"
 	if len(tags) > 0 {
 		b, _ := json.Marshal(tags)
 		tagsJSON = string(b)
 	}
-	_, err := s.db.ExecContext(ctx, `UPDATE stories SET title = ?, tags = ? WHERE id = ?`, title, tagsJSON, storyID)
-	return err
-}
-
-func (s *Store) HideStory(ctx context.Context, storyID int64) error {
-	_, err := s.db.ExecContext(ctx, `UPDATE stories SET hidden = 1 WHERE id = ?`, storyID)
-	return err
-}
-
-func (s *Store) ListStoriesByAccount(ctx context.Context, accountID int64, limit, offset int) ([]model.Story, int, error) {
-	if limit <= 0 {
-		limit = 20
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var oldTitle string
+	var oldTags, url sql.NullString
+	if err = tx.QueryRowContext(ctx, `SELECT title, tags, url FROM stories WHERE id = ?`, storyID).Scan(&oldTitle, &oldTags, &url); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return store.ErrNotFound
+		}
+		return err
+	}
+	if oldTitle == title && sameTags(oldTags.String, tags) {
+		return tx.Commit()
+	}
+	now := time.Now().UnixMilli()
+	if _, err = tx.ExecContext(ctx, `
+INSERT INTO story_revisions (story_id, title, tags, edited_at) VALUES (?, ?, ?, ?)
+`, storyID, oldTitle, oldTags, now); err != nil {
+		return err
+	}
+	kind := model.KindOf(model.PostTypeOf(tags), url.String)
+	if _, err = tx.ExecContext(ctx, `UPDATE stories SET title = ?, tags = ?, kind = ?, edited_at = ? WHERE id = ?`, title, tagsJSON, kind, now, storyID); err != nil {
+		return err
+	}
+	if oldTitle != title {
+		// The vector was computed from the old title; the embeddings
+		// worker recomputes it.
+		if _, err = tx.ExecContext(ctx, `DELETE FROM story_embeddings WHERE story_id = ?`, storyID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// sameTags reports whether the stored tags JSON holds exactly tags.
+func sameTags(raw string, tags []string) bool {
+	var old []string
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &old)
+	}
+	return slices.Equal(old, tags)
+}
+
+func (s *Store) ListStoryRevisions(ctx context.Context, storyID int64) ([]model.StoryRevision, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, story_id, title, tags, edited_at FROM story_revisions WHERE story_id = ? ORDER BY id
+`, storyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var revisions []model.StoryRevision
+	for rows.Next() {
+		var rev model.StoryRevision
+		var tags sql.NullString
+		var edited int64
+		if err := rows.Scan(&rev.ID, &rev.StoryID, &rev.Title, &tags, &edited); err != nil {
+			return nil, err
+		}
+		if tags.Valid && tags.String != "" {
+			_ = json.Unmarshal([]byte(tags.String), &rev.Tags)
+		}
+		rev.EditedAt = fromMillis(edited)
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (s *Store) HideStory(ctx context.Context, storyID int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE stories SET hidden = 1 WHERE id = ?`, storyID)
+	return err
+}
+
+func (s *Store) ListStoriesByAccount(ctx context.Context, accountID int64, opts store.AccountContentOpts) ([]model.Story, int, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
 	}
 
 	var total int
@@ -379,14 +1003,14 @@ func (s *Store) ListStoriesByAccount(ctx context.Context, accountID int64, limit
 		return nil, 0, err
 	}
 
+	page, args := accountContentPage("s", opts)
 	rows, err := s.db.QueryContext(ctx, `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
-WHERE s.account_id = ? AND s.hidden = 0
-ORDER BY s.created_at DESC
+WHERE s.account_id = ? AND s.hidden = 0`+page+`
 LIMIT ? OFFSET ?
-`, accountID, limit, offset)
+`, append(append([]any{accountID}, args...), limit, opts.Offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -403,32 +1027,356 @@ LIMIT ? OFFSET ?
 	return stories, total, rows.Err()
 }
 
+// accountContentPage is the cursor condition and ORDER BY of an account's
+// stories or comments, for the table aliased as t, with the cursor's
+// arguments.
+func accountContentPage(t string, opts store.AccountContentOpts) (string, []any) {
+	dir, cmp := " DESC", "<"
+	switch opts.Sort {
+	case "top":
+		return fmt.Sprintf("\nORDER BY %[1]s.score DESC, %[1]s.created_at DESC, %[1]s.id DESC", t), nil
+	case "old":
+		dir, cmp = "", ">"
+	}
+	orderBy := fmt.Sprintf("\nORDER BY %[1]s.created_at%[2]s, %[1]s.id%[2]s", t, dir)
+	if opts.Cursor.IsZero() {
+		return orderBy, nil
+	}
+	at := opts.Cursor.CreatedAt
+	return fmt.Sprintf(" AND (%[1]s.created_at %[2]s ? OR (%[1]s.created_at = ? AND %[1]s.id %[2]s ?))", t, cmp) + orderBy, []any{at, at, opts.Cursor.ID}
+}
+
+// ListStoriesSince returns visible stories created at or after since, newest first.
+func (s *Store) ListStoriesSince(ctx context.Context, since time.Time, limit int) ([]model.Story, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE s.created_at >= ? AND s.hidden = 0
+ORDER BY s.created_at DESC, s.id DESC
+LIMIT ?
+`, since.UnixMilli(), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+func (s *Store) CountDomainStoriesSince(ctx context.Context, domain string, since time.Time) (int, time.Time, error) {
+	var count int
+	var oldest sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*), MIN(created_at) FROM stories WHERE domain = ? AND created_at >= ?
+`, model.RegistrableDomain(domain), since.UnixMilli()).Scan(&count, &oldest)
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	if !oldest.Valid {
+		return 0, time.Time{}, nil
+	}
+	return count, fromMillis(oldest.Int64), nil
+}
+
+func (s *Store) DomainStats(ctx context.Context, domain string, sandbox bool) (model.DomainStats, error) {
+	stats := model.DomainStats{Domain: model.RegistrableDomain(domain)}
+	var avg sql.NullFloat64
+	var first, last sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*), COALESCE(SUM(comment_count), 0), AVG(score), MIN(created_at), MAX(created_at)
+FROM stories
+WHERE domain = ? AND hidden = 0 AND account_id `+sandboxIn(sandbox),
+		stats.Domain).Scan(&stats.Stories, &stats.Comments, &avg, &first, &last)
+	if err != nil {
+		return model.DomainStats{}, err
+	}
+	if stats.Stories == 0 {
+		return model.DomainStats{}, store.ErrNotFound
+	}
+	stats.AverageScore = avg.Float64
+	stats.FirstSubmitted = fromMillis(first.Int64)
+	stats.LastSubmitted = fromMillis(last.Int64)
+	return stats, nil
+}
+
+// Related story scoring: each shared tag, a shared link domain and each
+// shared title word add their weight, and candidates need relatedMinScore.
+// Candidates share a tag or domain or were posted in the last
+// relatedRecentWindow; at most relatedCandidates of them are scored.
+const (
+	relatedTagWeight    = 3
+	relatedDomainWeight = 2
+	relatedWordWeight   = 1
+	relatedMinScore     = 2
+	relatedRecentWindow = 90 * 24 * time.Hour
+	relatedCandidates   = 500
+)
+
+func (s *Store) RelatedStories(ctx context.Context, story model.Story, limit int) ([]model.RelatedStory, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+	domain := model.DomainOf(story.URL)
+	var conds []string
+	args := []any{story.ID, story.AccountID}
+	tags := make(map[string]bool, len(story.Tags))
+	for _, tag := range story.Tags {
+		tags[strings.ToLower(tag)] = true
+	}
+	if len(tags) > 0 {
+		conds = append(conds, `EXISTS (SELECT 1 FROM json_each(s.tags) WHERE lower(value) IN (`+strings.Repeat("?,", len(tags)-1)+`?))`)
+		for tag := range tags {
+			args = append(args, tag)
+		}
+	}
+	if domain != "" {
+		conds = append(conds, `s.domain = ?`)
+		args = append(args, domain)
+	}
+	conds = append(conds, `s.created_at >= ?`)
+	args = append(args, time.Now().Add(-relatedRecentWindow).UnixMilli(), relatedCandidates)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE s.id != ? AND s.hidden = 0
+	AND COALESCE(a.sandbox, 0) = (SELECT COALESCE(MAX(sandbox), 0) FROM accounts WHERE id = ?)
+	AND (`+strings.Join(conds, " OR ")+`)
+ORDER BY s.created_at DESC, s.id DESC
+LIMIT ?
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	words := make(map[string]bool)
+	for _, w := range dedupe.Tokens(story.Title) {
+		words[w] = true
+	}
+	var related []model.RelatedStory
+	for rows.Next() {
+		candidate, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		r := model.RelatedStory{Story: candidate}
+		for _, tag := range candidate.Tags {
+			if tags[strings.ToLower(tag)] {
+				r.SharedTags = append(r.SharedTags, tag)
+			}
+		}
+		r.SameDomain = domain != "" && candidate.Domain == domain
+		for _, w := range dedupe.Tokens(candidate.Title) {
+			if words[w] {
+				r.SharedWords = append(r.SharedWords, w)
+			}
+		}
+		r.Score = float64(relatedTagWeight*len(r.SharedTags) + relatedWordWeight*len(r.SharedWords))
+		if r.SameDomain {
+			r.Score += relatedDomainWeight
+		}
+		if r.Score >= relatedMinScore {
+			related = append(related, r)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Ties go to the better-received, then newer, story.
+	sort.SliceStable(related, func(i, j int) bool {
+		if related[i].Score != related[j].Score {
+			return related[i].Score > related[j].Score
+		}
+		return related[i].Story.Score > related[j].Story.Score
+	})
+	if len(related) > limit {
+		related = related[:limit]
+	}
+	return related, nil
+}
+
 func (s *Store) CreateComment(ctx context.Context, comment *model.Comment) (int64, error) {
 	res, err := s.db.ExecContext(ctx, `
-INSERT INTO comments (story_id, parent_id, text, score, created_at, hidden, account_id)
-VALUES (?, ?, ?, ?, ?, ?, ?)
-`, comment.StoryID, nullableInt(comment.ParentID), comment.Text, comment.Score, comment.CreatedAt.Unix(), boolToInt(comment.Hidden), comment.AccountID)
+INSERT INTO comments (story_id, parent_id, text, payload, payload_schema, kind, position, score, created_at, hidden, account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, comment.StoryID, nullableInt(comment.ParentID), comment.Text, nullIfEmpty(string(comment.Payload)), nullIfEmpty(comment.PayloadSchema), comment.Kind, comment.Position, comment.Score, comment.CreatedAt.UnixMilli(), boolToInt(comment.Hidden), comment.AccountID)
 	if err != nil {
 		return 0, err
 	}
 	return res.LastInsertId()
 }
 
+func (s *Store) CreateCommentWithSideEffects(ctx context.Context, comment *model.Comment) (story model.Story, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return model.Story{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO comments (story_id, parent_id, text, payload, payload_schema, kind, position, score, created_at, hidden, account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+`, comment.StoryID, nullableInt(comment.ParentID), comment.Text, nullIfEmpty(string(comment.Payload)), nullIfEmpty(comment.PayloadSchema), comment.Kind, comment.Position, comment.Score, comment.CreatedAt.UnixMilli(), boolToInt(comment.Hidden), comment.AccountID)
+	if err != nil {
+		return model.Story{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return model.Story{}, err
+	}
+	if !comment.Hidden {
+		if _, err = tx.ExecContext(ctx, `UPDATE stories SET comment_count = comment_count + 1 WHERE id = ?`, comment.StoryID); err != nil {
+			return model.Story{}, err
+		}
+		if _, err = tx.ExecContext(ctx, `UPDATE accounts SET karma = karma + ? WHERE id = ?`, model.KarmaSubmissionBonus, comment.AccountID); err != nil {
+			return model.Story{}, err
+		}
+	}
+	story, err = scanStory(tx.QueryRowContext(ctx, getStoryQuery, comment.StoryID))
+	if err != nil {
+		return model.Story{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return model.Story{}, err
+	}
+	comment.ID = id
+	return story, nil
+}
+
+const commentColumns = `id, story_id, parent_id, text, payload, payload_schema, kind, position, score, flag_count, created_at, hidden, account_id`
+
+func (s *Store) GetComment(ctx context.Context, id int64) (model.Comment, error) {
+	c, err := scanComment(s.db.QueryRowContext(ctx, `
+SELECT `+commentColumns+`
+FROM comments
+WHERE id = ?
+`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Comment{}, store.ErrNotFound
+	}
+	return c, err
+}
+
+func (s *Store) ListCommentAncestors(ctx context.Context, id int64, limit int) ([]model.Comment, error) {
+	rows, err := s.db.QueryContext(ctx, `
+WITH RECURSIVE chain(id, depth) AS (
+	SELECT id, 0 FROM comments WHERE id = ?
+	UNION ALL
+	SELECT c.parent_id, chain.depth + 1
+	FROM chain JOIN comments c ON c.id = chain.id
+	WHERE c.parent_id IS NOT NULL AND chain.depth < ?
+)
+SELECT `+commentColumns+`
+FROM comments
+JOIN chain USING (id)
+ORDER BY chain.depth
+`, id, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []model.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+func (s *Store) GetCommentsByID(ctx context.Context, ids []int64) ([]model.Comment, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+commentColumns+`
+FROM comments
+WHERE id IN (`+strings.Repeat("?,", len(ids)-1)+`?)
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var comments []model.Comment
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, c)
+	}
+	return comments, rows.Err()
+}
+
+// scanComment reads a row of commentColumns.
+func scanComment(scanner interface{ Scan(dest ...any) error }) (model.Comment, error) {
+	var c model.Comment
+	var parentID sql.NullInt64
+	var payload, payloadSchema sql.NullString
+	var created int64
+	var hidden int
+	if err := scanner.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID); err != nil {
+		return model.Comment{}, err
+	}
+	if parentID.Valid {
+		pid := parentID.Int64
+		c.ParentID = &pid
+	}
+	c.CreatedAt = fromMillis(created)
+	c.Hidden = hidden == 1
+	setPayload(&c, payload, payloadSchema)
+	return c, nil
+}
+
+// setPayload copies a comment's stored payload columns onto c.
+func setPayload(c *model.Comment, payload, schema sql.NullString) {
+	if payload.Valid && payload.String != "" {
+		c.Payload = json.RawMessage(payload.String)
+		c.PayloadSchema = schema.String
+	}
+}
+
 func (s *Store) ListCommentsByStory(ctx context.Context, storyID int64, opts store.CommentListOpts) ([]model.Comment, error) {
 	sortBy := opts.Sort
 	if sortBy == "" {
 		sortBy = "top"
 	}
-	order := "c.score DESC, c.created_at DESC"
+	order := "c.score DESC, c.created_at DESC, c.id DESC"
 	if sortBy == "new" {
-		order = "c.created_at DESC"
+		order = "c.created_at DESC, c.id DESC"
 	}
+	// The pinned comment leads whichever sort is used. The id tiebreak keeps
+	// the order stable for the offset cursors of paged comment trees.
 	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
-SELECT c.id, c.story_id, c.parent_id, c.text, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma,
+	c.id IS st.pinned_comment_id AS pinned
 FROM comments c
+JOIN stories st ON st.id = c.story_id
 LEFT JOIN accounts a ON a.id = c.account_id
 WHERE c.story_id = ? AND c.hidden = 0
-ORDER BY %s
+ORDER BY pinned DESC, %s
 `, order), storyID)
 	if err != nil {
 		return nil, err
@@ -439,11 +1387,12 @@ ORDER BY %s
 	for rows.Next() {
 		var c model.Comment
 		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
 		var created int64
 		var hidden int
 		var accountName sql.NullString
 		var accountKarma sql.NullInt64
-		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma); err != nil {
+		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &c.Pinned); err != nil {
 			return nil, err
 		}
 		if parentID.Valid {
@@ -454,8 +1403,9 @@ ORDER BY %s
 			c.AccountName = accountName.String
 		}
 		c.AccountKarma = int(accountKarma.Int64)
-		c.CreatedAt = time.Unix(created, 0)
+		c.CreatedAt = fromMillis(created)
 		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
 		comments = append(comments, c)
 	}
 	if err := rows.Err(); err != nil {
@@ -464,71 +1414,217 @@ ORDER BY %s
 	return comments, nil
 }
 
-func (s *Store) UpdateCommentScore(ctx context.Context, commentID int64, delta int) error {
-	_, err := s.db.ExecContext(ctx, `UPDATE comments SET score = score + ? WHERE id = ?`, delta, commentID)
-	return err
-}
-
-func (s *Store) HideComment(ctx context.Context, commentID int64) error {
-	_, err := s.db.ExecContext(ctx, `UPDATE comments SET hidden = 1 WHERE id = ?`, commentID)
-	return err
-}
-
-func (s *Store) ListCommentsByAccount(ctx context.Context, accountID int64, limit, offset int) ([]model.Comment, int, error) {
-	if limit <= 0 {
-		limit = 20
-	}
-
-	var total int
-	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE account_id = ? AND hidden = 0`, accountID).Scan(&total); err != nil {
-		return nil, 0, err
-	}
+// latestPositions keeps only each account's most recent visible position
+// reply; callers add the proposal or story filter.
+const latestPositions = `
+WHERE c.position != '' AND c.hidden = 0
+AND c.id = (
+	SELECT MAX(p.id) FROM comments p
+	WHERE p.parent_id = c.parent_id AND p.account_id = c.account_id AND p.position != '' AND p.hidden = 0
+)`
 
+func (s *Store) ListProposalPositions(ctx context.Context, proposalID int64) ([]model.ProposalPosition, error) {
 	rows, err := s.db.QueryContext(ctx, `
-SELECT c.id, c.story_id, c.parent_id, c.text, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
+SELECT c.account_id, COALESCE(a.display_name, ''), c.position, c.id, c.created_at
 FROM comments c
-LEFT JOIN accounts a ON a.id = c.account_id
-LEFT JOIN stories s ON s.id = c.story_id
-WHERE c.account_id = ? AND c.hidden = 0
-ORDER BY c.created_at DESC
-LIMIT ? OFFSET ?
-`, accountID, limit, offset)
+LEFT JOIN accounts a ON a.id = c.account_id`+latestPositions+` AND c.parent_id = ?
+ORDER BY c.id
+`, proposalID)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	defer rows.Close()
-
-	var comments []model.Comment
+	var positions []model.ProposalPosition
 	for rows.Next() {
-		var c model.Comment
-		var parentID sql.NullInt64
+		var p model.ProposalPosition
 		var created int64
-		var hidden int
-		var accountName sql.NullString
-		var accountKarma sql.NullInt64
-		var storyTitle sql.NullString
-		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &storyTitle); err != nil {
-			return nil, 0, err
-		}
-		if parentID.Valid {
-			pid := parentID.Int64
-			c.ParentID = &pid
+		if err := rows.Scan(&p.AccountID, &p.AccountName, &p.Position, &p.CommentID, &created); err != nil {
+			return nil, err
 		}
-		if accountName.Valid {
-			c.AccountName = accountName.String
+		p.CreatedAt = fromMillis(created)
+		positions = append(positions, p)
+	}
+	return positions, rows.Err()
+}
+
+func (s *Store) ProposalTallies(ctx context.Context, storyID int64) (map[int64]model.ProposalTally, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT c.parent_id, c.position, COUNT(*)
+FROM comments c`+latestPositions+` AND c.story_id = ?
+GROUP BY c.parent_id, c.position
+`, storyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tallies := make(map[int64]model.ProposalTally)
+	for rows.Next() {
+		var proposalID int64
+		var position string
+		var n int
+		if err := rows.Scan(&proposalID, &position, &n); err != nil {
+			return nil, err
 		}
-		c.AccountKarma = int(accountKarma.Int64)
-		if storyTitle.Valid {
-			c.StoryTitle = storyTitle.String
+		t := tallies[proposalID]
+		switch position {
+		case model.PositionAgree:
+			t.Agree = n
+		case model.PositionDisagree:
+			t.Disagree = n
+		case model.PositionAbstain:
+			t.Abstain = n
 		}
-		c.CreatedAt = time.Unix(created, 0)
-		c.Hidden = hidden == 1
-		comments = append(comments, c)
+		tallies[proposalID] = t
 	}
-	return comments, total, rows.Err()
+	return tallies, rows.Err()
 }
 
-func (s *Store) ListComments(ctx context.Context, opts store.CommentListOpts) ([]model.Comment, int, error) {
+func (s *Store) SetStoryLocked(ctx context.Context, storyID int64, lock *model.StoryLock) error {
+	var at sql.NullInt64
+	var byModerator bool
+	var reason string
+	if lock != nil {
+		at = sql.NullInt64{Int64: lock.At.UnixMilli(), Valid: true}
+		byModerator, reason = lock.ByModerator, lock.Reason
+	}
+	res, err := s.db.ExecContext(ctx, `
+UPDATE stories SET locked = ?, locked_at = ?, locked_by_moderator = ?, lock_reason = ? WHERE id = ?
+`, boolToInt(lock != nil), at, boolToInt(byModerator), nullIfEmpty(reason), storyID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetPinnedComment(ctx context.Context, storyID int64, commentID *int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE stories SET pinned_comment_id = ? WHERE id = ?`, nullableInt(commentID), storyID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) UpdateCommentScore(ctx context.Context, commentID int64, delta int) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE comments SET score = score + ? WHERE id = ?`, delta, commentID)
+	return err
+}
+
+func (s *Store) HideComment(ctx context.Context, commentID int64) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	if _, err = setCommentHidden(ctx, tx, commentID, true); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// setCommentHidden hides or unhides a comment within tx and keeps its
+// story's comment_count, which counts visible comments, in step. It
+// reports whether the comment changed; it doesn't if it was already so or
+// doesn't exist.
+func setCommentHidden(ctx context.Context, tx *sql.Tx, commentID int64, hidden bool) (bool, error) {
+	var storyID int64
+	err := tx.QueryRowContext(ctx, `UPDATE comments SET hidden = ? WHERE id = ? AND hidden != ? RETURNING story_id`, boolToInt(hidden), commentID, boolToInt(hidden)).Scan(&storyID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	delta := 1
+	if hidden {
+		delta = -1
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE stories SET comment_count = MAX(comment_count + ?, 0) WHERE id = ?`, delta, storyID)
+	return true, err
+}
+
+func (s *Store) ListCommentsByAccount(ctx context.Context, accountID int64, opts store.AccountContentOpts) ([]model.Comment, int, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE account_id = ? AND hidden = 0`, accountID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	page, args := accountContentPage("c", opts)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
+FROM comments c
+LEFT JOIN accounts a ON a.id = c.account_id
+LEFT JOIN stories s ON s.id = c.story_id
+WHERE c.account_id = ? AND c.hidden = 0`+page+`
+LIMIT ? OFFSET ?
+`, append(append([]any{accountID}, args...), limit, opts.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var comments []model.Comment
+	for rows.Next() {
+		var c model.Comment
+		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
+		var created int64
+		var hidden int
+		var accountName sql.NullString
+		var accountKarma sql.NullInt64
+		var storyTitle sql.NullString
+		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &storyTitle); err != nil {
+			return nil, 0, err
+		}
+		if parentID.Valid {
+			pid := parentID.Int64
+			c.ParentID = &pid
+		}
+		if accountName.Valid {
+			c.AccountName = accountName.String
+		}
+		c.AccountKarma = int(accountKarma.Int64)
+		if storyTitle.Valid {
+			c.StoryTitle = storyTitle.String
+		}
+		c.CreatedAt = fromMillis(created)
+		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
+		comments = append(comments, c)
+	}
+	return comments, total, rows.Err()
+}
+
+// timeRangeSince is when a store.TimeRanges window that ends now starts,
+// or zero for "all" and "".
+func timeRangeSince(timeRange string, now time.Time) time.Time {
+	now = now.UTC()
+	switch timeRange {
+	case "today":
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	case "week":
+		return now.AddDate(0, 0, -7)
+	case "month":
+		return now.AddDate(0, -1, 0)
+	}
+	return time.Time{}
+}
+
+func (s *Store) ListComments(ctx context.Context, opts store.CommentListOpts) ([]model.Comment, int, error) {
 	limit := opts.Limit
 	if limit <= 0 {
 		limit = 50
@@ -538,12 +1634,21 @@ func (s *Store) ListComments(ctx context.Context, opts store.CommentListOpts) ([
 	var args []interface{}
 
 	whereClauses = append(whereClauses, "c.hidden = 0")
+	whereClauses = append(whereClauses, "c.account_id "+sandboxIn(false))
 
 	// Account filter for "my comments"
 	if opts.AccountID != nil {
 		whereClauses = append(whereClauses, "c.account_id = ?")
 		args = append(args, *opts.AccountID)
 	}
+	if opts.StoryID != nil {
+		whereClauses = append(whereClauses, "c.story_id = ?")
+		args = append(args, *opts.StoryID)
+	}
+	if since := timeRangeSince(opts.TimeRange, time.Now()); !since.IsZero() {
+		whereClauses = append(whereClauses, "c.created_at >= ?")
+		args = append(args, since.UnixMilli())
+	}
 
 	whereClause := "WHERE " + strings.Join(whereClauses, " AND ")
 
@@ -556,18 +1661,25 @@ func (s *Store) ListComments(ctx context.Context, opts store.CommentListOpts) ([
 
 	// Build ORDER BY clause
 	var orderBy string
+	offset := opts.Offset
 	switch opts.Sort {
 	case "top":
-		orderBy = "ORDER BY c.score DESC, c.created_at DESC"
+		orderBy = "ORDER BY c.score DESC, c.created_at DESC, c.id DESC"
 	default:
-		orderBy = "ORDER BY c.created_at DESC"
+		orderBy = "ORDER BY c.created_at DESC, c.id DESC"
+		if !opts.Cursor.IsZero() {
+			at := opts.Cursor.CreatedAt
+			whereClause += " AND (c.created_at < ? OR (c.created_at = ? AND c.id < ?))"
+			args = append(args, at, at, opts.Cursor.ID)
+			offset = 0
+		}
 	}
 
 	// Add limit and offset
-	args = append(args, limit, opts.Offset)
+	args = append(args, limit, offset)
 
 	query := `
-SELECT c.id, c.story_id, c.parent_id, c.text, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
 FROM comments c
 LEFT JOIN accounts a ON a.id = c.account_id
 LEFT JOIN stories s ON s.id = c.story_id
@@ -585,12 +1697,13 @@ LIMIT ? OFFSET ?`
 	for rows.Next() {
 		var c model.Comment
 		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
 		var created int64
 		var hidden int
 		var accountName sql.NullString
 		var accountKarma sql.NullInt64
 		var storyTitle sql.NullString
-		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &storyTitle); err != nil {
+		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &storyTitle); err != nil {
 			return nil, 0, err
 		}
 		if parentID.Valid {
@@ -604,8 +1717,9 @@ LIMIT ? OFFSET ?`
 		if storyTitle.Valid {
 			c.StoryTitle = storyTitle.String
 		}
-		c.CreatedAt = time.Unix(created, 0)
+		c.CreatedAt = fromMillis(created)
 		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
 		comments = append(comments, c)
 	}
 	return comments, total, rows.Err()
@@ -615,7 +1729,7 @@ func (s *Store) CreateVote(ctx context.Context, vote *model.Vote) error {
 	_, err := s.db.ExecContext(ctx, `
 INSERT INTO votes (target_type, target_id, value, created_at, account_id)
 VALUES (?, ?, ?, ?, ?)
-`, vote.TargetType, vote.TargetID, vote.Value, vote.CreatedAt.Unix(), vote.AccountID)
+`, vote.TargetType, vote.TargetID, vote.Value, vote.CreatedAt.UnixMilli(), vote.AccountID)
 	if err != nil {
 		if isUniqueViolation(err) {
 			return store.ErrDuplicateVote
@@ -625,6 +1739,65 @@ VALUES (?, ?, ?, ?, ?)
 	return nil
 }
 
+func (s *Store) ApplyVote(ctx context.Context, vote *model.Vote, hideAt int) (result store.VoteResult, err error) {
+	table := "stories"
+	if vote.TargetType == "comment" {
+		table = "comments"
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return store.VoteResult{}, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO votes (target_type, target_id, value, created_at, account_id)
+VALUES (?, ?, ?, ?, ?)
+`, vote.TargetType, vote.TargetID, vote.Value, vote.CreatedAt.UnixMilli(), vote.AccountID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return store.VoteResult{}, store.ErrDuplicateVote
+		}
+		return store.VoteResult{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return store.VoteResult{}, err
+	}
+	var hidden int
+	err = tx.QueryRowContext(ctx, `UPDATE `+table+` SET score = score + ? WHERE id = ? RETURNING score, hidden, account_id`, vote.Value, vote.TargetID).Scan(&result.Score, &hidden, &result.AuthorID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.VoteResult{}, store.ErrNotFound
+	}
+	if err != nil {
+		return store.VoteResult{}, err
+	}
+	result.Hidden = hidden == 1
+	if _, err = tx.ExecContext(ctx, `UPDATE accounts SET karma = karma + ? WHERE id = ?`, vote.Value, result.AuthorID); err != nil {
+		return store.VoteResult{}, err
+	}
+	if result.Score <= hideAt && !result.Hidden {
+		if table == "comments" {
+			_, err = setCommentHidden(ctx, tx, vote.TargetID, true)
+		} else {
+			_, err = tx.ExecContext(ctx, `UPDATE stories SET hidden = 1 WHERE id = ?`, vote.TargetID)
+		}
+		if err != nil {
+			return store.VoteResult{}, err
+		}
+		result.Hidden, result.AutoHidden = true, true
+	}
+	if err = tx.Commit(); err != nil {
+		return store.VoteResult{}, err
+	}
+	vote.ID = id
+	return result, nil
+}
+
 func (s *Store) GetUserVote(ctx context.Context, accountID int64, targetType string, targetID int64) (*model.Vote, error) {
 	var vote model.Vote
 	var createdAt int64
@@ -643,109 +1816,70 @@ WHERE account_id = ? AND target_type = ? AND target_id = ?
 		return nil, err
 	}
 	
-	vote.CreatedAt = time.Unix(createdAt, 0)
+	vote.CreatedAt = fromMillis(createdAt)
 	return &vote, nil
 }
 
 func (s *Store) GetUserVotesForStories(ctx context.Context, accountID int64, storyIDs []int64) (map[int64]*model.Vote, error) {
-	if len(storyIDs) == 0 {
-		return make(map[int64]*model.Vote), nil
-	}
-	
-	votes := make(map[int64]*model.Vote)
-	placeholders := strings.Repeat("?,", len(storyIDs)-1) + "?"
-	args := make([]interface{}, len(storyIDs)+1)
-	args[0] = accountID
-	for i, id := range storyIDs {
-		args[i+1] = id
-	}
-	
-	query := fmt.Sprintf(`
-SELECT id, target_type, target_id, value, created_at, account_id
-FROM votes 
-WHERE account_id = ? AND target_type = 'story' AND target_id IN (%s)
-`, placeholders)
-	
-	rows, err := s.db.QueryContext(ctx, query, args...)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-	
-	for rows.Next() {
-		var vote model.Vote
-		var createdAt int64
-		
-		err := rows.Scan(&vote.ID, &vote.TargetType, &vote.TargetID, &vote.Value, &createdAt, &vote.AccountID)
-		if err != nil {
-			return nil, err
-		}
-		
-		vote.CreatedAt = time.Unix(createdAt, 0)
-		votes[vote.TargetID] = &vote
-	}
-	
-	return votes, rows.Err()
+	return s.userVotes(ctx, accountID, "story", storyIDs)
 }
 
 func (s *Store) GetUserVotesForComments(ctx context.Context, accountID int64, commentIDs []int64) (map[int64]*model.Vote, error) {
-	if len(commentIDs) == 0 {
-		return make(map[int64]*model.Vote), nil
-	}
-	
+	return s.userVotes(ctx, accountID, "comment", commentIDs)
+}
+
+// userVotes loads an account's votes on any of ids of one target type in a
+// single query, keyed by target ID.
+func (s *Store) userVotes(ctx context.Context, accountID int64, targetType string, ids []int64) (map[int64]*model.Vote, error) {
 	votes := make(map[int64]*model.Vote)
-	placeholders := strings.Repeat("?,", len(commentIDs)-1) + "?"
-	args := make([]interface{}, len(commentIDs)+1)
-	args[0] = accountID
-	for i, id := range commentIDs {
-		args[i+1] = id
+	if len(ids) == 0 {
+		return votes, nil
 	}
-	
-	query := fmt.Sprintf(`
+	placeholders := strings.Repeat("?,", len(ids)-1) + "?"
+	args := make([]any, 0, len(ids)+2)
+	args = append(args, accountID, targetType)
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`
 SELECT id, target_type, target_id, value, created_at, account_id
-FROM votes 
-WHERE account_id = ? AND target_type = 'comment' AND target_id IN (%s)
-`, placeholders)
-	
-	rows, err := s.db.QueryContext(ctx, query, args...)
+FROM votes
+WHERE account_id = ? AND target_type = ? AND target_id IN (%s)
+`, placeholders), args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	for rows.Next() {
 		var vote model.Vote
 		var createdAt int64
-		
-		err := rows.Scan(&vote.ID, &vote.TargetType, &vote.TargetID, &vote.Value, &createdAt, &vote.AccountID)
-		if err != nil {
+		if err := rows.Scan(&vote.ID, &vote.TargetType, &vote.TargetID, &vote.Value, &createdAt, &vote.AccountID); err != nil {
 			return nil, err
 		}
-		
-		vote.CreatedAt = time.Unix(createdAt, 0)
+		vote.CreatedAt = fromMillis(createdAt)
 		votes[vote.TargetID] = &vote
 	}
-	
 	return votes, rows.Err()
 }
 
 func (s *Store) CreateFlag(ctx context.Context, flag *model.Flag) error {
 	_, err := s.db.ExecContext(ctx, `
-INSERT INTO flags (target_type, target_id, reason, created_at, account_id)
-VALUES (?, ?, ?, ?, ?)
-`, flag.TargetType, flag.TargetID, nullIfEmpty(flag.Reason), flag.CreatedAt.Unix(), flag.AccountID)
+INSERT INTO flags (target_type, target_id, reason, created_at, account_id, weight)
+VALUES (?, ?, ?, ?, ?, ?)
+`, flag.TargetType, flag.TargetID, nullIfEmpty(flag.Reason), flag.CreatedAt.UnixMilli(), flag.AccountID, flag.Weight)
 	if err != nil {
 		if isUniqueViolation(err) {
 			return store.ErrDuplicateFlag
 		}
 		return err
 	}
-	// Increment flag count on target
+	// Increment flag count and weight on target
 	switch flag.TargetType {
 	case "story":
-		_, _ = s.db.ExecContext(ctx, `UPDATE stories SET flag_count = flag_count + 1 WHERE id = ?`, flag.TargetID)
+		_, _ = s.db.ExecContext(ctx, `UPDATE stories SET flag_count = flag_count + 1, flag_weight = flag_weight + ? WHERE id = ?`, flag.Weight, flag.TargetID)
 	case "comment":
-		_, _ = s.db.ExecContext(ctx, `UPDATE comments SET flag_count = flag_count + 1 WHERE id = ?`, flag.TargetID)
+		_, _ = s.db.ExecContext(ctx, `UPDATE comments SET flag_count = flag_count + 1, flag_weight = flag_weight + ? WHERE id = ?`, flag.Weight, flag.TargetID)
 	}
 	return nil
 }
@@ -753,11 +1887,88 @@ VALUES (?, ?, ?, ?, ?)
 func (s *Store) GetFlagCount(ctx context.Context, targetType string, targetID int64) (int, error) {
 	var count int
 	err := s.db.QueryRowContext(ctx, `
-SELECT COUNT(*) FROM flags WHERE target_type = ? AND target_id = ?
+SELECT COUNT(*) FROM flags WHERE target_type = ? AND target_id = ? AND state != 'dismissed'
 `, targetType, targetID).Scan(&count)
 	return count, err
 }
 
+func (s *Store) GetFlagWeight(ctx context.Context, targetType string, targetID int64) (float64, error) {
+	var weight float64
+	err := s.db.QueryRowContext(ctx, `
+SELECT COALESCE(SUM(weight), 0) FROM flags WHERE target_type = ? AND target_id = ? AND state != 'dismissed'
+`, targetType, targetID).Scan(&weight)
+	return weight, err
+}
+
+func (s *Store) GetFlagRecord(ctx context.Context, accountID int64) (upheld, dismissed int, err error) {
+	err = s.db.QueryRowContext(ctx, `
+SELECT COUNT(CASE WHEN state = 'upheld' THEN 1 END), COUNT(CASE WHEN state = 'dismissed' THEN 1 END)
+FROM flags WHERE account_id = ?
+`, accountID).Scan(&upheld, &dismissed)
+	return upheld, dismissed, err
+}
+
+func (s *Store) GetUserFlagged(ctx context.Context, accountID int64, targetType string, targetIDs []int64) (map[int64]bool, error) {
+	flagged := make(map[int64]bool)
+	if len(targetIDs) == 0 {
+		return flagged, nil
+	}
+	args := []any{accountID, targetType}
+	for _, id := range targetIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT target_id FROM flags
+WHERE account_id = ? AND target_type = ? AND target_id IN (`+strings.Repeat("?,", len(targetIDs)-1)+`?)
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		flagged[id] = true
+	}
+	return flagged, rows.Err()
+}
+
+func (s *Store) ListFlags(ctx context.Context, targetType string, targetID int64) ([]model.Flag, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, target_type, target_id, reason, created_at, account_id, state, resolved_at, weight
+FROM flags WHERE target_type = ? AND target_id = ?
+ORDER BY id
+`, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanFlags(rows)
+}
+
+func scanFlags(rows *sql.Rows) ([]model.Flag, error) {
+	var flags []model.Flag
+	for rows.Next() {
+		var f model.Flag
+		var reason sql.NullString
+		var created int64
+		var resolved sql.NullInt64
+		if err := rows.Scan(&f.ID, &f.TargetType, &f.TargetID, &reason, &created, &f.AccountID, &f.State, &resolved, &f.Weight); err != nil {
+			return nil, err
+		}
+		f.Reason = reason.String
+		f.CreatedAt = fromMillis(created)
+		if resolved.Valid {
+			t := fromMillis(resolved.Int64)
+			f.ResolvedAt = &t
+		}
+		flags = append(flags, f)
+	}
+	return flags, rows.Err()
+}
+
 func (s *Store) ListFlaggedStories(ctx context.Context, minFlags int, limit, offset int) ([]model.Story, int, error) {
 	if limit <= 0 {
 		limit = 50
@@ -769,11 +1980,11 @@ func (s *Store) ListFlaggedStories(ctx context.Context, minFlags int, limit, off
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.account_id, a.display_name, a.karma
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma, s.flag_weight
 FROM stories s
 LEFT JOIN accounts a ON a.id = s.account_id
 WHERE s.flag_count >= ? AND s.hidden = 0
-ORDER BY s.flag_count DESC, s.created_at DESC
+ORDER BY s.flag_weight DESC, s.flag_count DESC, s.created_at DESC
 LIMIT ? OFFSET ?
 `, minFlags, limit, offset)
 	if err != nil {
@@ -783,15 +1994,28 @@ LIMIT ? OFFSET ?
 
 	var stories []model.Story
 	for rows.Next() {
-		story, err := scanStory(rows)
+		var weight float64
+		story, err := scanStory(trailingScanner{rows, []any{&weight}})
 		if err != nil {
 			return nil, 0, err
 		}
+		story.FlagWeight = weight
 		stories = append(stories, story)
 	}
 	return stories, total, rows.Err()
 }
 
+// trailingScanner lets scanStory read rows that carry extra columns after
+// the usual story columns.
+type trailingScanner struct {
+	rows  *sql.Rows
+	extra []any
+}
+
+func (t trailingScanner) Scan(dest ...any) error {
+	return t.rows.Scan(append(dest, t.extra...)...)
+}
+
 func (s *Store) ListFlaggedComments(ctx context.Context, minFlags int, limit, offset int) ([]model.Comment, int, error) {
 	if limit <= 0 {
 		limit = 50
@@ -803,11 +2027,11 @@ func (s *Store) ListFlaggedComments(ctx context.Context, minFlags int, limit, of
 	}
 
 	rows, err := s.db.QueryContext(ctx, `
-SELECT c.id, c.story_id, c.parent_id, c.text, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, c.flag_weight
 FROM comments c
 LEFT JOIN accounts a ON a.id = c.account_id
 WHERE c.flag_count >= ? AND c.hidden = 0
-ORDER BY c.flag_count DESC, c.created_at DESC
+ORDER BY c.flag_weight DESC, c.flag_count DESC, c.created_at DESC
 LIMIT ? OFFSET ?
 `, minFlags, limit, offset)
 	if err != nil {
@@ -819,11 +2043,12 @@ LIMIT ? OFFSET ?
 	for rows.Next() {
 		var c model.Comment
 		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
 		var created int64
 		var hidden int
 		var accountName sql.NullString
 		var accountKarma sql.NullInt64
-		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma); err != nil {
+		if err := rows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &c.FlagWeight); err != nil {
 			return nil, 0, err
 		}
 		if parentID.Valid {
@@ -834,8 +2059,9 @@ LIMIT ? OFFSET ?
 			c.AccountName = accountName.String
 		}
 		c.AccountKarma = int(accountKarma.Int64)
-		c.CreatedAt = time.Unix(created, 0)
+		c.CreatedAt = fromMillis(created)
 		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
 		comments = append(comments, c)
 	}
 	return comments, total, rows.Err()
@@ -846,6 +2072,83 @@ func (s *Store) UpdateAccountKarma(ctx context.Context, accountID int64, delta i
 	return err
 }
 
+// karmaSourcesQuery computes each account's karma from ground truth: votes
+// on its visible stories and comments, a bonus per visible submission
+// outside imported threads, and the GitHub star reward. Callers append a
+// WHERE clause on a.id.
+const karmaSourcesQuery = `
+SELECT a.id, a.karma,
+	COALESCE((SELECT SUM(v.value) FROM votes v JOIN stories s ON s.id = v.target_id
+		WHERE v.target_type = 'story' AND s.account_id = a.id AND s.hidden = 0), 0),
+	COALESCE((SELECT SUM(v.value) FROM votes v JOIN comments c ON c.id = v.target_id
+		WHERE v.target_type = 'comment' AND c.account_id = a.id AND c.hidden = 0), 0),
+	(SELECT COUNT(*) FROM stories s
+		WHERE s.account_id = a.id AND s.hidden = 0
+		AND NOT EXISTS (SELECT 1 FROM imported_stories i WHERE i.story_id = s.id))
+	+ (SELECT COUNT(*) FROM comments c JOIN stories s ON s.id = c.story_id
+		WHERE c.account_id = a.id AND c.hidden = 0
+		AND NOT (s.account_id = c.account_id AND EXISTS (SELECT 1 FROM imported_stories i WHERE i.story_id = s.id))),
+	(SELECT COUNT(*) FROM github_star_rewards g WHERE g.account_id = a.id)
+FROM accounts a`
+
+func scanKarmaSources(scanner interface{ Scan(dest ...any) error }) (accountID int64, stored int, b model.KarmaBreakdown, err error) {
+	var submissions, stars int
+	if err = scanner.Scan(&accountID, &stored, &b.StoryVotes, &b.CommentVotes, &submissions, &stars); err != nil {
+		return 0, 0, b, err
+	}
+	b.Submissions = submissions * model.KarmaSubmissionBonus
+	b.Rewards = stars * model.KarmaGitHubStarBonus
+	b.Total = b.StoryVotes + b.CommentVotes + b.Submissions + b.Rewards
+	return accountID, stored, b, nil
+}
+
+func (s *Store) GetKarmaBreakdown(ctx context.Context, accountID int64) (model.KarmaBreakdown, error) {
+	_, _, b, err := scanKarmaSources(s.db.QueryRowContext(ctx, karmaSourcesQuery+` WHERE a.id = ?`, accountID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return b, store.ErrNotFound
+	}
+	return b, err
+}
+
+func (s *Store) RecalculateKarma(ctx context.Context) (changed []store.KarmaChange, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, karmaSourcesQuery+` ORDER BY a.id`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		id, stored, b, err := scanKarmaSources(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if stored != b.Total {
+			changed = append(changed, store.KarmaChange{AccountID: id, From: stored, To: b.Total})
+		}
+	}
+	if err = rows.Close(); err != nil {
+		return nil, err
+	}
+	for _, c := range changed {
+		if _, err = tx.ExecContext(ctx, `UPDATE accounts SET karma = ? WHERE id = ?`, c.To, c.AccountID); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
 func (s *Store) CreateAccount(ctx context.Context, account *model.Account, key *model.AccountKey) (int64, int64, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -858,9 +2161,9 @@ func (s *Store) CreateAccount(ctx context.Context, account *model.Account, key *
 	}()
 
 	res, err := tx.ExecContext(ctx, `
-INSERT INTO accounts (display_name, bio, homepage_url, created_at)
-VALUES (?, ?, ?, ?)
-`, account.DisplayName, nullIfEmpty(account.Bio), nullIfEmpty(account.HomepageURL), account.CreatedAt.Unix())
+INSERT INTO accounts (display_name, bio, homepage_url, did, sandbox, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`, account.DisplayName, nullIfEmpty(account.Bio), nullIfEmpty(account.HomepageURL), nullIfEmpty(account.DID), boolToInt(account.Sandbox), account.CreatedAt.UnixMilli())
 	if err != nil {
 		if isUniqueViolation(err) {
 			return 0, 0, store.ErrDuplicateName
@@ -874,7 +2177,7 @@ VALUES (?, ?, ?, ?)
 	res, err = tx.ExecContext(ctx, `
 INSERT INTO account_keys (account_id, alg, public_key, created_at, revoked_at)
 VALUES (?, ?, ?, ?, NULL)
-`, accountID, key.Alg, key.PublicKey, key.CreatedAt.Unix())
+`, accountID, key.Alg, key.PublicKey, key.CreatedAt.UnixMilli())
 	if err != nil {
 		if isUniqueViolation(err) {
 			return 0, 0, store.ErrDuplicateKey
@@ -891,20 +2194,117 @@ VALUES (?, ?, ?, ?, NULL)
 	return accountID, keyID, nil
 }
 
-func (s *Store) GetAccount(ctx context.Context, id int64) (model.Account, error) {
-	row := s.db.QueryRowContext(ctx, `
-SELECT id, display_name, bio, homepage_url, karma, created_at
-FROM accounts
-WHERE id = ?
-`, id)
-	var a model.Account
-	var created int64
-	var bio sql.NullString
-	var homepage sql.NullString
-	if err := row.Scan(&a.ID, &a.DisplayName, &bio, &homepage, &a.Karma, &created); err != nil {
+func (s *Store) CreateInvite(ctx context.Context, invite *model.Invite) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO invites (code, note, created_at) VALUES (?, ?, ?)
+`, invite.Code, nullIfEmpty(invite.Note), invite.CreatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) GetInvite(ctx context.Context, code string) (model.Invite, error) {
+	var invite model.Invite
+	var note sql.NullString
+	var createdAt int64
+	var usedAt, usedBy sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+SELECT code, note, created_at, used_at, used_by FROM invites WHERE code = ?
+`, code).Scan(&invite.Code, &note, &createdAt, &usedAt, &usedBy)
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return model.Account{}, store.ErrNotFound
+			return model.Invite{}, store.ErrNotFound
+		}
+		return model.Invite{}, err
+	}
+	invite.Note = note.String
+	invite.CreatedAt = fromMillis(createdAt)
+	if usedAt.Valid {
+		t := fromMillis(usedAt.Int64)
+		invite.UsedAt = &t
+	}
+	if usedBy.Valid {
+		invite.UsedBy = &usedBy.Int64
+	}
+	return invite, nil
+}
+
+func (s *Store) RedeemInvite(ctx context.Context, code string, accountID int64, usedAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE invites SET used_at = ?, used_by = ? WHERE code = ? AND used_at IS NULL
+`, usedAt.UnixMilli(), accountID, code)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) GetAccount(ctx context.Context, id int64) (model.Account, error) {
+	return s.getAccount(ctx, `id = ?`, id)
+}
+
+func (s *Store) GetAccountByName(ctx context.Context, name string) (model.Account, error) {
+	return s.getAccount(ctx, `display_name = ?`, name)
+}
+
+func (s *Store) GetAccountsByID(ctx context.Context, ids []int64) ([]model.Account, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+accountColumns+`
+FROM accounts
+WHERE id IN (`+strings.Repeat("?,", len(ids)-1)+`?)
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var accounts []model.Account
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
 		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+func (s *Store) getAccount(ctx context.Context, where string, arg any) (model.Account, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT `+accountColumns+`
+FROM accounts
+WHERE `+where, arg)
+	a, err := scanAccount(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.Account{}, store.ErrNotFound
+	}
+	return a, err
+}
+
+// accountColumns are the accounts columns scanAccount reads.
+const accountColumns = `id, display_name, bio, homepage_url, did, karma, sandbox, theme, language, created_at`
+
+// scanAccount reads a row of accountColumns.
+func scanAccount(scanner interface{ Scan(dest ...any) error }) (model.Account, error) {
+	var a model.Account
+	var created int64
+	var bio sql.NullString
+	var homepage sql.NullString
+	var did sql.NullString
+	var theme sql.NullString
+	var language sql.NullString
+	if err := scanner.Scan(&a.ID, &a.DisplayName, &bio, &homepage, &did, &a.Karma, &a.Sandbox, &theme, &language, &created); err != nil {
 		return model.Account{}, err
 	}
 	if bio.Valid {
@@ -913,31 +2313,60 @@ WHERE id = ?
 	if homepage.Valid {
 		a.HomepageURL = homepage.String
 	}
-	a.CreatedAt = time.Unix(created, 0)
+	a.DID = did.String
+	a.Theme = theme.String
+	a.Language = language.String
+	a.CreatedAt = fromMillis(created)
 	return a, nil
 }
 
-func (s *Store) ListAccounts(ctx context.Context, sort string, limit, offset int) ([]model.Account, int, error) {
+func (s *Store) ListAccounts(ctx context.Context, opts store.AccountListOpts) ([]model.Account, int, error) {
+	limit := opts.Limit
 	if limit <= 0 {
 		limit = 20
 	}
-	orderBy := "display_name ASC"
-	if sort == "karma" {
-		orderBy = "karma DESC"
+	where := "WHERE a.sandbox = 0"
+	var args []any
+	if opts.Query != "" {
+		where += ` AND a.display_name LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(opts.Query)+"%")
 	}
 
 	// Get total count
 	var total int
-	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM accounts`).Scan(&total); err != nil {
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM accounts a `+where, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
+	from, orderBy := "accounts a", "a.display_name ASC"
+	switch opts.Sort {
+	case "karma":
+		orderBy = "a.karma DESC, a.id"
+	case "newest":
+		orderBy = "a.created_at DESC, a.id DESC"
+	case "active":
+		since := time.Now().Add(-store.AccountActiveWindow).UnixMilli()
+		from = `accounts a
+LEFT JOIN (
+	SELECT account_id, COUNT(*) AS n FROM (
+		SELECT account_id FROM stories WHERE hidden = 0 AND created_at > ?
+		UNION ALL
+		SELECT account_id FROM comments WHERE hidden = 0 AND created_at > ?
+	)
+	GROUP BY account_id
+) activity ON activity.account_id = a.id`
+		args = append([]any{since, since}, args...)
+		orderBy = "COALESCE(activity.n, 0) DESC, a.karma DESC, a.id"
+	}
+	args = append(args, limit, opts.Offset)
+
 	rows, err := s.db.QueryContext(ctx, `
-SELECT id, display_name, bio, homepage_url, karma, created_at
-FROM accounts
+SELECT a.id, a.display_name, a.bio, a.homepage_url, a.did, a.karma, a.created_at
+FROM `+from+`
+`+where+`
 ORDER BY `+orderBy+`
 LIMIT ? OFFSET ?
-`, limit, offset)
+`, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -949,7 +2378,8 @@ LIMIT ? OFFSET ?
 		var created int64
 		var bio sql.NullString
 		var homepage sql.NullString
-		if err := rows.Scan(&a.ID, &a.DisplayName, &bio, &homepage, &a.Karma, &created); err != nil {
+		var did sql.NullString
+		if err := rows.Scan(&a.ID, &a.DisplayName, &bio, &homepage, &did, &a.Karma, &created); err != nil {
 			return nil, 0, err
 		}
 		if bio.Valid {
@@ -958,7 +2388,8 @@ LIMIT ? OFFSET ?
 		if homepage.Valid {
 			a.HomepageURL = homepage.String
 		}
-		a.CreatedAt = time.Unix(created, 0)
+		a.DID = did.String
+		a.CreatedAt = fromMillis(created)
 		accounts = append(accounts, a)
 	}
 	return accounts, total, rows.Err()
@@ -987,6 +2418,38 @@ func (s *Store) DeleteAccount(ctx context.Context, accountID int64) error {
 		return err
 	}
 
+	_, err = tx.ExecContext(ctx, `DELETE FROM recovery_keys WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM account_recoveries WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM account_bans WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM account_links WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM story_templates WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM email_senders WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM api_keys WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	if err = revokeAccountTokens(ctx, tx, accountID, time.Now()); err != nil {
+		return err
+	}
+
 	// Delete the account
 	res, err := tx.ExecContext(ctx, `DELETE FROM accounts WHERE id = ?`, accountID)
 	if err != nil {
@@ -1022,6 +2485,51 @@ func (s *Store) RenameAccount(ctx context.Context, accountID int64, newName stri
 	return nil
 }
 
+func (s *Store) UpdateAccountProfile(ctx context.Context, accountID int64, bio, homepageURL string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE accounts SET bio = ?, homepage_url = ? WHERE id = ?`, nullIfEmpty(bio), nullIfEmpty(homepageURL), accountID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetAccountTheme(ctx context.Context, accountID int64, theme string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE accounts SET theme = ? WHERE id = ?`, nullIfEmpty(theme), accountID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetAccountLanguage(ctx context.Context, accountID int64, language string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE accounts SET language = ? WHERE id = ?`, nullIfEmpty(language), accountID)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
 func (s *Store) GetAccountKeys(ctx context.Context, accountID int64) ([]model.AccountKey, error) {
 	rows, err := s.db.QueryContext(ctx, `
 SELECT id, account_id, alg, public_key, created_at, revoked_at
@@ -1042,9 +2550,9 @@ ORDER BY created_at ASC
 		if err := rows.Scan(&k.ID, &k.AccountID, &k.Alg, &k.PublicKey, &created, &revoked); err != nil {
 			return nil, err
 		}
-		k.CreatedAt = time.Unix(created, 0)
+		k.CreatedAt = fromMillis(created)
 		if revoked.Valid {
-			t := time.Unix(revoked.Int64, 0)
+			t := fromMillis(revoked.Int64)
 			k.RevokedAt = &t
 		}
 		keys = append(keys, k)
@@ -1067,9 +2575,9 @@ WHERE id = ?
 		}
 		return model.AccountKey{}, err
 	}
-	k.CreatedAt = time.Unix(created, 0)
+	k.CreatedAt = fromMillis(created)
 	if revoked.Valid {
-		t := time.Unix(revoked.Int64, 0)
+		t := fromMillis(revoked.Int64)
 		k.RevokedAt = &t
 	}
 	return k, nil
@@ -1079,7 +2587,7 @@ func (s *Store) AddAccountKey(ctx context.Context, accountID int64, key *model.A
 	res, err := s.db.ExecContext(ctx, `
 INSERT INTO account_keys (account_id, alg, public_key, created_at, revoked_at)
 VALUES (?, ?, ?, ?, NULL)
-`, accountID, key.Alg, key.PublicKey, key.CreatedAt.Unix())
+`, accountID, key.Alg, key.PublicKey, key.CreatedAt.UnixMilli())
 	if err != nil {
 		if isUniqueViolation(err) {
 			return 0, store.ErrDuplicateKey
@@ -1089,10 +2597,80 @@ VALUES (?, ?, ?, ?, NULL)
 	return res.LastInsertId()
 }
 
-func (s *Store) RevokeAccountKey(ctx context.Context, accountID, keyID int64, revokedAt time.Time) error {
-	res, err := s.db.ExecContext(ctx, `
+func (s *Store) SetAccountDID(ctx context.Context, accountID int64, did string) error {
+	_, err := s.db.ExecContext(ctx, `
+UPDATE accounts SET did = ? WHERE id = ? AND did IS NULL
+`, did, accountID)
+	if isUniqueViolation(err) {
+		return store.ErrDuplicateKey
+	}
+	return err
+}
+
+func (s *Store) RevokeAccountKey(ctx context.Context, accountID, keyID int64, revokedAt time.Time) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
 UPDATE account_keys SET revoked_at = ? WHERE id = ? AND account_id = ?
-`, revokedAt.Unix(), keyID, accountID)
+`, revokedAt.UnixMilli(), keyID, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		err = store.ErrNotFound
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM auth_tokens WHERE account_id = ? AND key_id = ?`, accountID, keyID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) FreezeAccount(ctx context.Context, accountID int64, frozenAt time.Time) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO account_freezes (account_id, frozen_at) VALUES (?, ?)
+ON CONFLICT(account_id) DO NOTHING
+`, accountID, frozenAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM auth_tokens WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	if err = revokeAccountTokens(ctx, tx, accountID, frozenAt); err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+UPDATE api_keys SET revoked_at = ? WHERE account_id = ? AND revoked_at IS NULL
+`, frozenAt.UnixMilli(), accountID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) UnfreezeAccount(ctx context.Context, accountID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM account_freezes WHERE account_id = ?`, accountID)
 	if err != nil {
 		return err
 	}
@@ -1102,110 +2680,1952 @@ UPDATE account_keys SET revoked_at = ? WHERE id = ? AND account_id = ?
 	return nil
 }
 
-func (s *Store) FindAccountKey(ctx context.Context, alg, publicKey string) (model.AccountKey, *model.Account, error) {
-	row := s.db.QueryRowContext(ctx, `
-SELECT k.id, k.account_id, k.alg, k.public_key, k.created_at, k.revoked_at,
-	a.id, a.display_name, a.bio, a.homepage_url, a.karma, a.created_at
-FROM account_keys k
-LEFT JOIN accounts a ON a.id = k.account_id
-WHERE k.alg = ? AND k.public_key = ?
-LIMIT 1
-`, alg, publicKey)
-	var k model.AccountKey
-	var a model.Account
-	var created int64
-	var revoked sql.NullInt64
-	var displayName sql.NullString
-	var bio sql.NullString
-	var homepage sql.NullString
-	var karma sql.NullInt64
-	var accCreated sql.NullInt64
-	if err := row.Scan(&k.ID, &k.AccountID, &k.Alg, &k.PublicKey, &created, &revoked, &a.ID, &displayName, &bio, &homepage, &karma, &accCreated); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.AccountKey{}, nil, store.ErrNotFound
+func (s *Store) IsAccountFrozen(ctx context.Context, accountID int64) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM account_freezes WHERE account_id = ?`, accountID).Scan(&n)
+	return n > 0, err
+}
+
+func (s *Store) UnhideStory(ctx context.Context, storyID int64) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE stories SET hidden = 0 WHERE id = ?`, storyID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) UnhideComment(ctx context.Context, commentID int64) (err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
 		}
-		return model.AccountKey{}, nil, err
+	}()
+	changed, err := setCommentHidden(ctx, tx, commentID, false)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		var exists int
+		if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM comments WHERE id = ?`, commentID).Scan(&exists); err != nil {
+			return err
+		}
+		if exists == 0 {
+			err = store.ErrNotFound
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *Store) RecountComments(ctx context.Context) (changed []store.CommentCountChange, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+SELECT s.id, s.comment_count, COUNT(c.id)
+FROM stories s
+LEFT JOIN comments c ON c.story_id = s.id AND c.hidden = 0
+GROUP BY s.id
+HAVING s.comment_count != COUNT(c.id)
+ORDER BY s.id
+`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var c store.CommentCountChange
+		if err = rows.Scan(&c.StoryID, &c.From, &c.To); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		changed = append(changed, c)
+	}
+	if err = rows.Close(); err != nil {
+		return nil, err
+	}
+	for _, c := range changed {
+		if _, err = tx.ExecContext(ctx, `UPDATE stories SET comment_count = ? WHERE id = ?`, c.To, c.StoryID); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+func (s *Store) ResolveFlags(ctx context.Context, targetType string, targetID int64, state string, resolvedAt time.Time) (flags []model.Flag, err error) {
+	var table string
+	switch targetType {
+	case "story":
+		table = "stories"
+	case "comment":
+		table = "comments"
+	default:
+		return nil, fmt.Errorf("invalid target type %q", targetType)
+	}
+	if state != model.FlagDismissed && state != model.FlagUpheld {
+		return nil, fmt.Errorf("invalid flag state %q", state)
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx, `
+UPDATE flags SET state = ?, resolved_at = ?
+WHERE target_type = ? AND target_id = ? AND state = 'open'
+RETURNING id, target_type, target_id, reason, created_at, account_id, state, resolved_at, weight
+`, state, resolvedAt.UnixMilli(), targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	flags, err = scanFlags(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+	res, err := tx.ExecContext(ctx, `
+UPDATE `+table+` SET (flag_count, flag_weight) = (
+	SELECT COUNT(*), COALESCE(SUM(weight), 0) FROM flags WHERE target_type = ? AND target_id = ? AND state != 'dismissed'
+) WHERE id = ?
+`, targetType, targetID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = store.ErrNotFound
+		return nil, err
+	}
+	return flags, tx.Commit()
+}
+
+func (s *Store) BanAccount(ctx context.Context, accountID int64, reason string, bannedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO account_bans (account_id, reason, banned_at) VALUES (?, ?, ?)
+ON CONFLICT(account_id) DO UPDATE SET reason = excluded.reason
+`, accountID, nullIfEmpty(reason), bannedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) UnbanAccount(ctx context.Context, accountID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM account_bans WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) IsAccountBanned(ctx context.Context, accountID int64) (bool, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM account_bans WHERE account_id = ?`, accountID).Scan(&n)
+	return n > 0, err
+}
+
+func (s *Store) ListAccountBans(ctx context.Context) ([]model.AccountBan, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT b.account_id, a.display_name, b.reason, b.banned_at
+FROM account_bans b
+LEFT JOIN accounts a ON a.id = b.account_id
+ORDER BY b.banned_at DESC
+`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bans []model.AccountBan
+	for rows.Next() {
+		var b model.AccountBan
+		var name, reason sql.NullString
+		var banned int64
+		if err := rows.Scan(&b.AccountID, &name, &reason, &banned); err != nil {
+			return nil, err
+		}
+		b.AccountName = name.String
+		b.Reason = reason.String
+		b.BannedAt = fromMillis(banned)
+		bans = append(bans, b)
+	}
+	return bans, rows.Err()
+}
+
+func (s *Store) RecordAudit(ctx context.Context, entry *model.AuditEntry) error {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO audit_log (action, target_type, target_id, detail, actor_ip, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+`, entry.Action, nullIfEmpty(entry.TargetType), entry.TargetID, nullIfEmpty(entry.Detail), nullIfEmpty(entry.ActorIP), entry.CreatedAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	entry.ID, err = res.LastInsertId()
+	return err
+}
+
+func (s *Store) ListAuditLog(ctx context.Context, limit, offset int) ([]model.AuditEntry, int, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, action, target_type, target_id, detail, actor_ip, created_at
+FROM audit_log
+ORDER BY id DESC
+LIMIT ? OFFSET ?
+`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var entries []model.AuditEntry
+	for rows.Next() {
+		var e model.AuditEntry
+		var targetType, detail, actorIP sql.NullString
+		var targetID sql.NullInt64
+		var created int64
+		if err := rows.Scan(&e.ID, &e.Action, &targetType, &targetID, &detail, &actorIP, &created); err != nil {
+			return nil, 0, err
+		}
+		e.TargetType = targetType.String
+		e.TargetID = targetID.Int64
+		e.Detail = detail.String
+		e.ActorIP = actorIP.String
+		e.CreatedAt = fromMillis(created)
+		entries = append(entries, e)
+	}
+	return entries, total, rows.Err()
+}
+
+func (s *Store) AddModNote(ctx context.Context, note *model.ModNote) error {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO mod_notes (target_type, target_id, text, actor_ip, created_at)
+VALUES (?, ?, ?, ?, ?)
+`, note.TargetType, note.TargetID, note.Text, nullIfEmpty(note.ActorIP), note.CreatedAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	note.ID, err = res.LastInsertId()
+	return err
+}
+
+func (s *Store) ListModNotes(ctx context.Context, targetType string, targetID int64, limit int) ([]model.ModNote, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	where, args := "1 = 1", []any{}
+	switch targetType {
+	case "":
+	case "account":
+		where = `(target_type = 'account' AND target_id = ?)
+	OR (target_type = 'story' AND target_id IN (SELECT id FROM stories WHERE account_id = ?))
+	OR (target_type = 'comment' AND target_id IN (SELECT id FROM comments WHERE account_id = ?))`
+		args = append(args, targetID, targetID, targetID)
+	default:
+		where = "target_type = ? AND target_id = ?"
+		args = append(args, targetType, targetID)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, target_type, target_id, text, actor_ip, created_at
+FROM mod_notes
+WHERE `+where+`
+ORDER BY id DESC
+LIMIT ?
+`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var notes []model.ModNote
+	for rows.Next() {
+		var n model.ModNote
+		var actorIP sql.NullString
+		var created int64
+		if err := rows.Scan(&n.ID, &n.TargetType, &n.TargetID, &n.Text, &actorIP, &created); err != nil {
+			return nil, err
+		}
+		n.ActorIP = actorIP.String
+		n.CreatedAt = fromMillis(created)
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+func (s *Store) DeleteModNote(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM mod_notes WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SaveStoryEmbedding(ctx context.Context, storyID int64, modelName string, vector []float32) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO story_embeddings (story_id, model, vector, updated_at) VALUES (?, ?, ?, ?)
+ON CONFLICT(story_id) DO UPDATE SET model = excluded.model, vector = excluded.vector, updated_at = excluded.updated_at
+`, storyID, modelName, embed.Encode(vector), time.Now().UnixMilli())
+	return err
+}
+
+func (s *Store) ListStoriesWithoutEmbedding(ctx context.Context, modelName string, limit int) ([]model.Story, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE NOT EXISTS (SELECT 1 FROM story_embeddings e WHERE e.story_id = s.id AND e.model = ?)
+ORDER BY s.id DESC
+LIMIT ?
+`, modelName, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+func (s *Store) ListStoryEmbeddings(ctx context.Context, opts store.EmbeddingListOpts) ([]model.StoryEmbedding, error) {
+	where := []string{"e.model = ?", "s.hidden = 0", "s.account_id " + sandboxIn(opts.Sandbox)}
+	args := []any{opts.Model}
+	if !opts.Since.IsZero() {
+		where = append(where, "s.created_at >= ?")
+		args = append(args, opts.Since.UnixMilli())
+	}
+	if opts.ExcludeAccountID != 0 {
+		where = append(where, "s.account_id != ?",
+			"NOT EXISTS (SELECT 1 FROM votes v WHERE v.target_type = 'story' AND v.target_id = s.id AND v.account_id = ?)")
+		args = append(args, opts.ExcludeAccountID, opts.ExcludeAccountID)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma, e.vector
+FROM story_embeddings e
+JOIN stories s ON s.id = e.story_id
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE `+strings.Join(where, " AND ")+`
+ORDER BY s.id DESC
+LIMIT ?
+`, append(args, limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []model.StoryEmbedding
+	for rows.Next() {
+		var blob []byte
+		story, err := scanStory(trailingScanner{rows, []any{&blob}})
+		if err != nil {
+			return nil, err
+		}
+		vector, err := embed.Decode(blob)
+		if err != nil {
+			return nil, fmt.Errorf("story %d embedding: %w", story.ID, err)
+		}
+		out = append(out, model.StoryEmbedding{Story: story, Vector: vector})
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListUpvotedEmbeddings(ctx context.Context, accountID int64, modelName string, limit int) ([][]float32, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT e.vector
+FROM votes v
+JOIN story_embeddings e ON e.story_id = v.target_id AND e.model = ?
+WHERE v.target_type = 'story' AND v.account_id = ? AND v.value > 0
+ORDER BY v.created_at DESC, v.id DESC
+LIMIT ?
+`, modelName, accountID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out [][]float32
+	for rows.Next() {
+		var blob []byte
+		if err := rows.Scan(&blob); err != nil {
+			return nil, err
+		}
+		vector, err := embed.Decode(blob)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, vector)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListCrossPostCandidates(ctx context.Context, network string, minScore int, since time.Time, limit int) ([]model.Story, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE s.hidden = 0 AND s.score >= ? AND s.created_at >= ?
+	AND s.account_id `+sandboxIn(false)+`
+	AND NOT EXISTS (SELECT 1 FROM crossposts c WHERE c.story_id = s.id AND c.network = ?)
+ORDER BY s.score DESC, s.id
+LIMIT ?
+`, minScore, since.UnixMilli(), network, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, err
+		}
+		stories = append(stories, story)
+	}
+	return stories, rows.Err()
+}
+
+func (s *Store) RecordCrossPost(ctx context.Context, post *model.CrossPost) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO crossposts (story_id, network, remote_url, posted_at) VALUES (?, ?, ?, ?)
+`, post.StoryID, post.Network, nullIfEmpty(post.RemoteURL), post.PostedAt.UnixMilli())
+	if isUniqueViolation(err) {
+		return store.ErrDuplicateKey
+	}
+	return err
+}
+
+func (s *Store) ListCrossPosts(ctx context.Context, limit int) ([]model.CrossPost, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT story_id, network, remote_url, posted_at FROM crossposts ORDER BY posted_at DESC LIMIT ?
+`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var posts []model.CrossPost
+	for rows.Next() {
+		var p model.CrossPost
+		var remote sql.NullString
+		var posted int64
+		if err := rows.Scan(&p.StoryID, &p.Network, &remote, &posted); err != nil {
+			return nil, err
+		}
+		p.RemoteURL = remote.String
+		p.PostedAt = fromMillis(posted)
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+func (s *Store) SetEmailSender(ctx context.Context, sender *model.EmailSender, tokenHash string) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO email_senders (account_id, address, token_hash, created_at, last_used_at)
+VALUES (?, ?, ?, ?, NULL)
+ON CONFLICT(account_id) DO UPDATE SET address = excluded.address, token_hash = excluded.token_hash,
+	created_at = excluded.created_at, last_used_at = NULL
+`, sender.AccountID, sender.Address, tokenHash, sender.CreatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) GetEmailSender(ctx context.Context, accountID int64) (model.EmailSender, error) {
+	return scanEmailSender(s.db.QueryRowContext(ctx, `
+SELECT account_id, address, created_at, last_used_at FROM email_senders WHERE account_id = ?
+`, accountID))
+}
+
+func (s *Store) GetEmailSenderByToken(ctx context.Context, tokenHash string) (model.EmailSender, error) {
+	return scanEmailSender(s.db.QueryRowContext(ctx, `
+SELECT account_id, address, created_at, last_used_at FROM email_senders WHERE token_hash = ?
+`, tokenHash))
+}
+
+func scanEmailSender(row *sql.Row) (model.EmailSender, error) {
+	var sender model.EmailSender
+	var created int64
+	var used sql.NullInt64
+	if err := row.Scan(&sender.AccountID, &sender.Address, &created, &used); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.EmailSender{}, store.ErrNotFound
+		}
+		return model.EmailSender{}, err
+	}
+	sender.CreatedAt = fromMillis(created)
+	if used.Valid {
+		t := fromMillis(used.Int64)
+		sender.LastUsedAt = &t
+	}
+	return sender, nil
+}
+
+func (s *Store) DeleteEmailSender(ctx context.Context, accountID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM email_senders WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) TouchEmailSender(ctx context.Context, accountID int64, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE email_senders SET last_used_at = ? WHERE account_id = ?`, usedAt.UnixMilli(), accountID)
+	return err
+}
+
+func (s *Store) SearchContent(ctx context.Context, query string, limit int) ([]model.Story, []model.Comment, error) {
+	return s.searchContent(ctx, query, limit, false)
+}
+
+func (s *Store) SearchVisibleContent(ctx context.Context, query string, limit int) ([]model.Story, []model.Comment, error) {
+	return s.searchContent(ctx, query, limit, true)
+}
+
+func (s *Store) searchContent(ctx context.Context, query string, limit int, visibleOnly bool) ([]model.Story, []model.Comment, error) {
+	limit = clamp(limit, 1, 100)
+	pattern := "%" + likeEscaper.Replace(query) + "%"
+	storyFilter, commentFilter := "", ""
+	if visibleOnly {
+		storyFilter = " AND s.hidden = 0 AND s.account_id " + sandboxIn(false)
+		commentFilter = " AND c.hidden = 0 AND s.hidden = 0 AND s.account_id " + sandboxIn(false)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE (s.title LIKE ? ESCAPE '\' OR s.url LIKE ? ESCAPE '\' OR s.text LIKE ? ESCAPE '\')`+storyFilter+`
+ORDER BY s.created_at DESC
+LIMIT ?
+`, pattern, pattern, pattern, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		stories = append(stories, story)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	crows, err := s.db.QueryContext(ctx, `
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
+FROM comments c
+LEFT JOIN accounts a ON a.id = c.account_id
+LEFT JOIN stories s ON s.id = c.story_id
+WHERE c.text LIKE ? ESCAPE '\'`+commentFilter+`
+ORDER BY c.created_at DESC
+LIMIT ?
+`, pattern, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer crows.Close()
+	var comments []model.Comment
+	for crows.Next() {
+		var c model.Comment
+		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
+		var created int64
+		var hidden int
+		var accountName, storyTitle sql.NullString
+		var accountKarma sql.NullInt64
+		if err := crows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &storyTitle); err != nil {
+			return nil, nil, err
+		}
+		if parentID.Valid {
+			pid := parentID.Int64
+			c.ParentID = &pid
+		}
+		c.AccountName = accountName.String
+		c.AccountKarma = int(accountKarma.Int64)
+		c.StoryTitle = storyTitle.String
+		c.CreatedAt = fromMillis(created)
+		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
+		comments = append(comments, c)
+	}
+	return stories, comments, crows.Err()
+}
+
+func (s *Store) Leaderboard(ctx context.Context, board string, since time.Time, limit int) ([]model.LeaderboardEntry, error) {
+	limit = clamp(limit, 1, 100)
+	var sinceMillis int64
+	if !since.IsZero() {
+		sinceMillis = since.UnixMilli()
+	}
+	var query string
+	var args []any
+	switch {
+	case board == store.LeaderboardKarma && since.IsZero():
+		query = `
+SELECT a.id, a.display_name, a.karma, 0
+FROM accounts a
+WHERE a.karma > 0 AND a.sandbox = 0
+ORDER BY a.karma DESC, a.id
+LIMIT ?`
+		args = []any{limit}
+	case board == store.LeaderboardKarma:
+		query = `
+SELECT a.id, a.display_name, SUM(k.points) AS value, 0
+FROM (
+	SELECT s.account_id, v.value AS points FROM votes v JOIN stories s ON s.id = v.target_id
+	WHERE v.target_type = 'story' AND s.hidden = 0 AND v.created_at >= ?
+	UNION ALL
+	SELECT c.account_id, v.value FROM votes v JOIN comments c ON c.id = v.target_id
+	WHERE v.target_type = 'comment' AND c.hidden = 0 AND v.created_at >= ?
+	UNION ALL
+	SELECT account_id, ? FROM stories WHERE hidden = 0 AND created_at >= ?
+	UNION ALL
+	SELECT account_id, ? FROM comments WHERE hidden = 0 AND created_at >= ?
+) k
+JOIN accounts a ON a.id = k.account_id
+WHERE a.sandbox = 0
+GROUP BY a.id
+HAVING value > 0
+ORDER BY value DESC, a.id
+LIMIT ?`
+		args = []any{sinceMillis, sinceMillis, model.KarmaSubmissionBonus, sinceMillis, model.KarmaSubmissionBonus, sinceMillis, limit}
+	case board == store.LeaderboardStories, board == store.LeaderboardComments:
+		table := "stories"
+		if board == store.LeaderboardComments {
+			table = "comments"
+		}
+		query = `
+SELECT a.id, a.display_name, COUNT(*) AS value, COUNT(*)
+FROM ` + table + ` t
+JOIN accounts a ON a.id = t.account_id
+WHERE t.hidden = 0 AND t.created_at >= ? AND a.sandbox = 0
+GROUP BY a.id
+ORDER BY value DESC, a.id
+LIMIT ?`
+		args = []any{sinceMillis, limit}
+	case board == store.LeaderboardAvgStoryScore:
+		query = `
+SELECT a.id, a.display_name, AVG(s.score) AS value, COUNT(*)
+FROM stories s
+JOIN accounts a ON a.id = s.account_id
+WHERE s.hidden = 0 AND s.created_at >= ? AND a.sandbox = 0
+GROUP BY a.id
+HAVING COUNT(*) >= ?
+ORDER BY value DESC, a.id
+LIMIT ?`
+		args = []any{sinceMillis, store.LeaderboardMinStories, limit}
+	default:
+		return nil, fmt.Errorf("unknown leaderboard %q", board)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []model.LeaderboardEntry
+	for rows.Next() {
+		e := model.LeaderboardEntry{Rank: len(entries) + 1}
+		if err := rows.Scan(&e.AccountID, &e.AccountName, &e.Value, &e.Count); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *Store) PurgeSandbox(ctx context.Context, before time.Time) (purge store.SandboxPurge, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return purge, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	cutoff := before.UnixMilli()
+	stories := `SELECT id FROM stories WHERE created_at < ? AND account_id ` + sandboxIn(true)
+	comments := `SELECT id FROM comments WHERE story_id IN (` + stories + `)`
+	for _, q := range []string{
+		`DELETE FROM votes WHERE target_type = 'comment' AND target_id IN (` + comments + `)`,
+		`DELETE FROM flags WHERE target_type = 'comment' AND target_id IN (` + comments + `)`,
+		`DELETE FROM reactions WHERE target_type = 'comment' AND target_id IN (` + comments + `)`,
+		`DELETE FROM votes WHERE target_type = 'story' AND target_id IN (` + stories + `)`,
+		`DELETE FROM flags WHERE target_type = 'story' AND target_id IN (` + stories + `)`,
+		`DELETE FROM reactions WHERE target_type = 'story' AND target_id IN (` + stories + `)`,
+		`DELETE FROM story_revisions WHERE story_id IN (` + stories + `)`,
+		`DELETE FROM story_embeddings WHERE story_id IN (` + stories + `)`,
+		`DELETE FROM loop_incidents WHERE story_id IN (` + stories + `)`,
+	} {
+		if _, err = tx.ExecContext(ctx, q, cutoff); err != nil {
+			return purge, err
+		}
+	}
+	res, err := tx.ExecContext(ctx, `DELETE FROM comments WHERE story_id IN (`+stories+`)`, cutoff)
+	if err != nil {
+		return purge, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return purge, err
+	}
+	purge.Comments = int(n)
+	if res, err = tx.ExecContext(ctx, `DELETE FROM stories WHERE id IN (`+stories+`)`, cutoff); err != nil {
+		return purge, err
+	}
+	if n, err = res.RowsAffected(); err != nil {
+		return purge, err
+	}
+	purge.Stories = int(n)
+
+	// Start sandbox accounts over, keeping their keys so bots can log in.
+	if _, err = tx.ExecContext(ctx, `UPDATE accounts SET karma = 0 WHERE sandbox = 1`); err != nil {
+		return purge, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM account_badges WHERE account_id `+sandboxIn(true)); err != nil {
+		return purge, err
+	}
+	return purge, tx.Commit()
+}
+
+func (s *Store) GetBadgeStats(ctx context.Context, accountID int64) (model.BadgeStats, error) {
+	var st model.BadgeStats
+	err := s.db.QueryRowContext(ctx, `
+SELECT a.karma,
+	(SELECT COUNT(*) FROM stories WHERE account_id = a.id AND hidden = 0),
+	(SELECT COUNT(*) FROM comments WHERE account_id = a.id AND hidden = 0),
+	(SELECT COUNT(*) FROM accounts WHERE id <= a.id)
+FROM accounts a
+WHERE a.id = ?
+`, accountID).Scan(&st.Karma, &st.Stories, &st.Comments, &st.SignupRank)
+	if errors.Is(err, sql.ErrNoRows) {
+		return st, store.ErrNotFound
+	}
+	return st, err
+}
+
+func (s *Store) AwardBadges(ctx context.Context, accountID int64, badgeIDs []string, at time.Time) (awarded []string, err error) {
+	if len(badgeIDs) == 0 {
+		return nil, nil
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	for _, id := range badgeIDs {
+		res, err := tx.ExecContext(ctx, `
+INSERT OR IGNORE INTO account_badges (account_id, badge_id, awarded_at) VALUES (?, ?, ?)
+`, accountID, id, at.UnixMilli())
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n > 0 {
+			awarded = append(awarded, id)
+		}
+	}
+	return awarded, tx.Commit()
+}
+
+func (s *Store) ListAccountBadges(ctx context.Context, accountIDs []int64) (map[int64][]model.AccountBadge, error) {
+	badges := make(map[int64][]model.AccountBadge)
+	if len(accountIDs) == 0 {
+		return badges, nil
+	}
+	placeholders := strings.Repeat("?,", len(accountIDs)-1) + "?"
+	args := make([]any, len(accountIDs))
+	for i, id := range accountIDs {
+		args[i] = id
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT account_id, badge_id, awarded_at
+FROM account_badges
+WHERE account_id IN (`+placeholders+`)
+ORDER BY awarded_at, badge_id
+`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var b model.AccountBadge
+		var awarded int64
+		if err := rows.Scan(&b.AccountID, &b.BadgeID, &awarded); err != nil {
+			return nil, err
+		}
+		b.AwardedAt = fromMillis(awarded)
+		badges[b.AccountID] = append(badges[b.AccountID], b)
+	}
+	return badges, rows.Err()
+}
+
+func (s *Store) ListContentAfter(ctx context.Context, afterStoryID, afterCommentID int64, limit int) ([]model.Story, []model.Comment, error) {
+	limit = clamp(limit, 1, 100)
+	rows, err := s.db.QueryContext(ctx, `
+SELECT s.id, s.title, s.url, s.text, s.tags, s.score, s.comment_count, s.flag_count, s.created_at, s.hidden, s.locked, s.locked_at, s.locked_by_moderator, s.lock_reason, s.pinned_comment_id, s.edited_at, s.account_id, a.display_name, a.karma
+FROM stories s
+LEFT JOIN accounts a ON a.id = s.account_id
+WHERE s.id > ? AND s.hidden = 0
+ORDER BY s.id
+LIMIT ?
+`, afterStoryID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+	var stories []model.Story
+	for rows.Next() {
+		story, err := scanStory(rows)
+		if err != nil {
+			return nil, nil, err
+		}
+		stories = append(stories, story)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	crows, err := s.db.QueryContext(ctx, `
+SELECT c.id, c.story_id, c.parent_id, c.text, c.payload, c.payload_schema, c.kind, c.position, c.score, c.flag_count, c.created_at, c.hidden, c.account_id, a.display_name, a.karma, s.title
+FROM comments c
+JOIN stories s ON s.id = c.story_id
+LEFT JOIN accounts a ON a.id = c.account_id
+WHERE c.id > ? AND c.hidden = 0 AND s.hidden = 0
+ORDER BY c.id
+LIMIT ?
+`, afterCommentID, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer crows.Close()
+	var comments []model.Comment
+	for crows.Next() {
+		var c model.Comment
+		var parentID sql.NullInt64
+		var payload, payloadSchema sql.NullString
+		var created int64
+		var hidden int
+		var accountName sql.NullString
+		var accountKarma sql.NullInt64
+		if err := crows.Scan(&c.ID, &c.StoryID, &parentID, &c.Text, &payload, &payloadSchema, &c.Kind, &c.Position, &c.Score, &c.FlagCount, &created, &hidden, &c.AccountID, &accountName, &accountKarma, &c.StoryTitle); err != nil {
+			return nil, nil, err
+		}
+		if parentID.Valid {
+			pid := parentID.Int64
+			c.ParentID = &pid
+		}
+		c.AccountName = accountName.String
+		c.AccountKarma = int(accountKarma.Int64)
+		c.CreatedAt = fromMillis(created)
+		c.Hidden = hidden == 1
+		setPayload(&c, payload, payloadSchema)
+		comments = append(comments, c)
+	}
+	return stories, comments, crows.Err()
+}
+
+func (s *Store) LatestContentIDs(ctx context.Context) (storyID, commentID int64, err error) {
+	err = s.db.QueryRowContext(ctx, `
+SELECT COALESCE((SELECT MAX(id) FROM stories), 0), COALESCE((SELECT MAX(id) FROM comments), 0)
+`).Scan(&storyID, &commentID)
+	return storyID, commentID, err
+}
+
+func (s *Store) ToggleReaction(ctx context.Context, reaction *model.Reaction) (added bool, err error) {
+	res, err := s.db.ExecContext(ctx, `
+DELETE FROM reactions WHERE target_type = ? AND target_id = ? AND account_id = ? AND reaction = ?
+`, reaction.TargetType, reaction.TargetID, reaction.AccountID, reaction.Reaction)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return false, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO reactions (target_type, target_id, account_id, reaction, created_at) VALUES (?, ?, ?, ?, ?)
+`, reaction.TargetType, reaction.TargetID, reaction.AccountID, reaction.Reaction, reaction.CreatedAt.UnixMilli())
+	return err == nil, err
+}
+
+func (s *Store) CountReactions(ctx context.Context, targetType string, targetID int64) (map[string]int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT reaction, COUNT(*) FROM reactions WHERE target_type = ? AND target_id = ? GROUP BY reaction
+`, targetType, targetID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := make(map[string]int)
+	for rows.Next() {
+		var reaction string
+		var n int
+		if err := rows.Scan(&reaction, &n); err != nil {
+			return nil, err
+		}
+		counts[reaction] = n
+	}
+	return counts, rows.Err()
+}
+
+// likeEscaper escapes LIKE wildcards for use with ESCAPE '\'.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func (s *Store) RevokeOtherAccountKeys(ctx context.Context, accountID, keepKeyID int64, revokedAt time.Time) (n int, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+UPDATE account_keys SET revoked_at = ? WHERE account_id = ? AND id != ? AND revoked_at IS NULL
+`, revokedAt.UnixMilli(), accountID, keepKeyID)
+	if err != nil {
+		return 0, err
+	}
+	revoked, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `DELETE FROM auth_tokens WHERE account_id = ? AND key_id != ?`, accountID, keepKeyID)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.ExecContext(ctx, `
+UPDATE api_keys SET revoked_at = ? WHERE account_id = ? AND revoked_at IS NULL
+`, revokedAt.UnixMilli(), accountID)
+	if err != nil {
+		return 0, err
+	}
+	return int(revoked), tx.Commit()
+}
+
+func (s *Store) FindAccountKey(ctx context.Context, alg, publicKey string) (model.AccountKey, *model.Account, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT k.id, k.account_id, k.alg, k.public_key, k.created_at, k.revoked_at,
+	a.id, a.display_name, a.bio, a.homepage_url, a.did, a.karma, a.created_at
+FROM account_keys k
+LEFT JOIN accounts a ON a.id = k.account_id
+WHERE k.alg = ? AND k.public_key = ?
+LIMIT 1
+`, alg, publicKey)
+	var k model.AccountKey
+	var a model.Account
+	var created int64
+	var revoked sql.NullInt64
+	var displayName sql.NullString
+	var bio sql.NullString
+	var homepage sql.NullString
+	var did sql.NullString
+	var karma sql.NullInt64
+	var accCreated sql.NullInt64
+	if err := row.Scan(&k.ID, &k.AccountID, &k.Alg, &k.PublicKey, &created, &revoked, &a.ID, &displayName, &bio, &homepage, &did, &karma, &accCreated); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.AccountKey{}, nil, store.ErrNotFound
+		}
+		return model.AccountKey{}, nil, err
+	}
+	k.CreatedAt = fromMillis(created)
+	if revoked.Valid {
+		t := fromMillis(revoked.Int64)
+		k.RevokedAt = &t
+	}
+	if accCreated.Valid {
+		if displayName.Valid {
+			a.DisplayName = displayName.String
+		}
+		if bio.Valid {
+			a.Bio = bio.String
+		}
+		if homepage.Valid {
+			a.HomepageURL = homepage.String
+		}
+		a.DID = did.String
+		if karma.Valid {
+			a.Karma = int(karma.Int64)
+		}
+		a.CreatedAt = fromMillis(accCreated.Int64)
+		return k, &a, nil
+	}
+	return k, nil, nil
+}
+
+func (s *Store) CreateChallenge(ctx context.Context, c model.Challenge) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO auth_challenges (challenge, alg, expires_at, created_at)
+VALUES (?, ?, ?, ?)
+`, c.Challenge, c.Alg, c.ExpiresAt.UnixMilli(), time.Now().UnixMilli())
+	return err
+}
+
+func (s *Store) ConsumeChallenge(ctx context.Context, challenge string) (model.Challenge, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT challenge, alg, expires_at
+FROM auth_challenges
+WHERE challenge = ?
+`, challenge)
+	var c model.Challenge
+	var expires int64
+	if err := row.Scan(&c.Challenge, &c.Alg, &expires); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Challenge{}, store.ErrNotFound
+		}
+		return model.Challenge{}, err
+	}
+	c.ExpiresAt = fromMillis(expires)
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM auth_challenges WHERE challenge = ?`, challenge)
+	return c, nil
+}
+
+func (s *Store) UseNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) error {
+	now := time.Now().UnixMilli()
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM request_nonces WHERE expires_at < ?`, now)
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO request_nonces (key_id, nonce, expires_at) VALUES (?, ?, ?)
+ON CONFLICT(key_id, nonce) DO NOTHING
+`, keyID, nonce, expiresAt.UnixMilli())
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrDuplicateKey
+	}
+	return nil
+}
+
+func (s *Store) CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) (int64, error) {
+	scopes, err := json.Marshal(key.Scopes)
+	if err != nil {
+		return 0, err
+	}
+	var expires any
+	if key.ExpiresAt != nil {
+		expires = key.ExpiresAt.UnixMilli()
+	}
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO api_keys (account_id, name, prefix, key_hash, scopes, created_at, expires_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`, key.AccountID, nullIfEmpty(key.Name), key.Prefix, hash, string(scopes), key.CreatedAt.UnixMilli(), expires)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+const apiKeyColumns = `id, account_id, name, prefix, scopes, created_at, expires_at, last_used_at, revoked_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (model.APIKey, error) {
+	var k model.APIKey
+	var name, scopes sql.NullString
+	var createdAt int64
+	var expiresAt, lastUsedAt, revokedAt sql.NullInt64
+	if err := row.Scan(&k.ID, &k.AccountID, &name, &k.Prefix, &scopes, &createdAt, &expiresAt, &lastUsedAt, &revokedAt); err != nil {
+		return model.APIKey{}, err
+	}
+	k.Name = name.String
+	if scopes.Valid && scopes.String != "" {
+		_ = json.Unmarshal([]byte(scopes.String), &k.Scopes)
+	}
+	k.CreatedAt = fromMillis(createdAt)
+	if expiresAt.Valid {
+		t := fromMillis(expiresAt.Int64)
+		k.ExpiresAt = &t
+	}
+	if lastUsedAt.Valid {
+		t := fromMillis(lastUsedAt.Int64)
+		k.LastUsedAt = &t
+	}
+	if revokedAt.Valid {
+		t := fromMillis(revokedAt.Int64)
+		k.RevokedAt = &t
+	}
+	return k, nil
+}
+
+func (s *Store) GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error) {
+	// Keys left behind by a deleted account no longer authenticate.
+	k, err := scanAPIKey(s.db.QueryRowContext(ctx, `
+SELECT `+apiKeyColumns+` FROM api_keys
+WHERE key_hash = ? AND account_id IN (SELECT id FROM accounts)
+`, hash))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.APIKey{}, store.ErrNotFound
+	}
+	return k, err
+}
+
+func (s *Store) ListAPIKeys(ctx context.Context, accountID int64) ([]model.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE account_id = ? ORDER BY id`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []model.APIKey
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+func (s *Store) RevokeAPIKey(ctx context.Context, accountID, id int64, revokedAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE api_keys SET revoked_at = ? WHERE id = ? AND account_id = ? AND revoked_at IS NULL
+`, revokedAt.UnixMilli(), id, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, usedAt.UnixMilli(), id)
+	return err
+}
+
+func (s *Store) GetPostingSchedule(ctx context.Context, accountID int64) (model.PostingSchedule, error) {
+	var ps model.PostingSchedule
+	var interval, updated int64
+	err := s.db.QueryRowContext(ctx, `
+SELECT account_id, quiet_start, quiet_end, timezone, min_interval_ms, updated_at
+FROM posting_schedules WHERE account_id = ?
+`, accountID).Scan(&ps.AccountID, &ps.QuietStart, &ps.QuietEnd, &ps.Timezone, &interval, &updated)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.PostingSchedule{}, store.ErrNotFound
+		}
+		return model.PostingSchedule{}, err
+	}
+	ps.MinInterval = time.Duration(interval) * time.Millisecond
+	ps.UpdatedAt = fromMillis(updated)
+	return ps, nil
+}
+
+func (s *Store) SetPostingSchedule(ctx context.Context, schedule *model.PostingSchedule) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO posting_schedules (account_id, quiet_start, quiet_end, timezone, min_interval_ms, updated_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(account_id) DO UPDATE SET quiet_start = excluded.quiet_start, quiet_end = excluded.quiet_end,
+	timezone = excluded.timezone, min_interval_ms = excluded.min_interval_ms, updated_at = excluded.updated_at
+`, schedule.AccountID, schedule.QuietStart, schedule.QuietEnd, schedule.Timezone, schedule.MinInterval.Milliseconds(), schedule.UpdatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) DeletePostingSchedule(ctx context.Context, accountID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM posting_schedules WHERE account_id = ?`, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetRecoveryKey(ctx context.Context, key *model.RecoveryKey) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO recovery_keys (account_id, alg, public_key, webhook_url, created_at, used_at)
+VALUES (?, ?, ?, ?, ?, NULL)
+ON CONFLICT(account_id) DO UPDATE SET alg = excluded.alg, public_key = excluded.public_key,
+	webhook_url = excluded.webhook_url, created_at = excluded.created_at, used_at = NULL
+`, key.AccountID, key.Alg, key.PublicKey, nullIfEmpty(key.WebhookURL), key.CreatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) GetRecoveryKey(ctx context.Context, accountID int64) (model.RecoveryKey, error) {
+	var k model.RecoveryKey
+	var webhook sql.NullString
+	var created int64
+	var used sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+SELECT account_id, alg, public_key, webhook_url, created_at, used_at
+FROM recovery_keys WHERE account_id = ?
+`, accountID).Scan(&k.AccountID, &k.Alg, &k.PublicKey, &webhook, &created, &used)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.RecoveryKey{}, store.ErrNotFound
+		}
+		return model.RecoveryKey{}, err
+	}
+	k.WebhookURL = webhook.String
+	k.CreatedAt = fromMillis(created)
+	if used.Valid {
+		t := fromMillis(used.Int64)
+		k.UsedAt = &t
+	}
+	return k, nil
+}
+
+func (s *Store) CreateRecovery(ctx context.Context, rec *model.Recovery) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO account_recoveries (account_id, new_alg, new_public_key, requested_at, effective_at)
+VALUES (?, ?, ?, ?, ?)
+`, rec.AccountID, rec.NewAlg, rec.NewPublicKey, rec.RequestedAt.UnixMilli(), rec.EffectiveAt.UnixMilli())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, store.ErrDuplicateKey
+		}
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+func (s *Store) GetPendingRecovery(ctx context.Context, accountID int64) (model.Recovery, error) {
+	var rec model.Recovery
+	var requested, effective int64
+	err := s.db.QueryRowContext(ctx, `
+SELECT id, account_id, new_alg, new_public_key, requested_at, effective_at
+FROM account_recoveries
+WHERE account_id = ? AND completed_at IS NULL AND cancelled_at IS NULL
+`, accountID).Scan(&rec.ID, &rec.AccountID, &rec.NewAlg, &rec.NewPublicKey, &requested, &effective)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Recovery{}, store.ErrNotFound
+		}
+		return model.Recovery{}, err
+	}
+	rec.RequestedAt = fromMillis(requested)
+	rec.EffectiveAt = fromMillis(effective)
+	return rec, nil
+}
+
+func (s *Store) CancelRecovery(ctx context.Context, accountID int64, cancelledAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE account_recoveries SET cancelled_at = ?
+WHERE account_id = ? AND completed_at IS NULL AND cancelled_at IS NULL
+`, cancelledAt.UnixMilli(), accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) CompleteRecovery(ctx context.Context, recoveryID int64, key *model.AccountKey, completedAt time.Time) (keyID int64, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var accountID int64
+	err = tx.QueryRowContext(ctx, `
+SELECT account_id FROM account_recoveries
+WHERE id = ? AND completed_at IS NULL AND cancelled_at IS NULL
+`, recoveryID).Scan(&accountID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, store.ErrNotFound
+		}
+		return 0, err
+	}
+	at := completedAt.UnixMilli()
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO account_keys (account_id, alg, public_key, created_at, revoked_at)
+VALUES (?, ?, ?, ?, NULL)
+`, accountID, key.Alg, key.PublicKey, key.CreatedAt.UnixMilli())
+	if err != nil {
+		if isUniqueViolation(err) {
+			return 0, store.ErrDuplicateKey
+		}
+		return 0, err
+	}
+	keyID, err = res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+UPDATE account_keys SET revoked_at = ? WHERE account_id = ? AND id != ? AND revoked_at IS NULL
+`, at, accountID, keyID); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM auth_tokens WHERE account_id = ?`, accountID); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+UPDATE api_keys SET revoked_at = ? WHERE account_id = ? AND revoked_at IS NULL
+`, at, accountID); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE account_recoveries SET completed_at = ? WHERE id = ?`, at, recoveryID); err != nil {
+		return 0, err
+	}
+	if _, err = tx.ExecContext(ctx, `UPDATE recovery_keys SET used_at = ? WHERE account_id = ?`, at, accountID); err != nil {
+		return 0, err
+	}
+	return keyID, tx.Commit()
+}
+
+func (s *Store) AddAccountLink(ctx context.Context, link *model.AccountLink) (int64, error) {
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+INSERT INTO account_links (account_id, instance_url, remote_account_id, remote_name, alg, public_key, verified_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(account_id, instance_url, remote_account_id) DO UPDATE SET remote_name = excluded.remote_name,
+	alg = excluded.alg, public_key = excluded.public_key, verified_at = excluded.verified_at
+RETURNING id
+`, link.AccountID, link.InstanceURL, link.RemoteAccountID, nullIfEmpty(link.RemoteName), link.Alg, link.PublicKey,
+		link.VerifiedAt.UnixMilli()).Scan(&id)
+	return id, err
+}
+
+func (s *Store) ListAccountLinks(ctx context.Context, accountID int64) ([]model.AccountLink, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT l.id, l.account_id, l.instance_url, l.remote_account_id, l.remote_name, l.alg, l.public_key, l.verified_at
+FROM account_links l
+JOIN account_keys k ON k.account_id = l.account_id AND k.alg = l.alg AND k.public_key = l.public_key
+WHERE l.account_id = ? AND k.revoked_at IS NULL
+ORDER BY l.verified_at DESC
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []model.AccountLink
+	for rows.Next() {
+		var l model.AccountLink
+		var name sql.NullString
+		var verified int64
+		if err := rows.Scan(&l.ID, &l.AccountID, &l.InstanceURL, &l.RemoteAccountID, &name, &l.Alg, &l.PublicKey, &verified); err != nil {
+			return nil, err
+		}
+		l.RemoteName = name.String
+		l.VerifiedAt = fromMillis(verified)
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+func (s *Store) DeleteAccountLink(ctx context.Context, accountID, linkID int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM account_links WHERE id = ? AND account_id = ?`, linkID, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) MarkChangelogAnnounced(ctx context.Context, entryID string, at time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+INSERT OR IGNORE INTO changelog_announcements (entry_id, announced_at) VALUES (?, ?)
+`, entryID, at.UnixMilli())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (s *Store) ImportStory(ctx context.Context, source, externalID string, story *model.Story, comments []store.ImportedComment) (storyID int64, err error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var exists int
+	if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM imported_stories WHERE source = ? AND external_id = ?`, source, externalID).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists > 0 {
+		err = store.ErrDuplicateKey
+		return 0, err
+	}
+	tags, err := json.Marshal(story.Tags)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `
+INSERT INTO stories (title, url, domain, kind, text, tags, score, comment_count, created_at, hidden, account_id)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, 0, ?)
+`, story.Title, nullIfEmpty(story.URL), nullIfEmpty(model.DomainOf(story.URL)), model.KindOf(model.PostTypeOf(story.Tags), story.URL), nullIfEmpty(story.Text), string(tags), story.Score, len(comments), story.CreatedAt.UnixMilli(), story.AccountID)
+	if err != nil {
+		return 0, err
+	}
+	if storyID, err = res.LastInsertId(); err != nil {
+		return 0, err
+	}
+	ids := make([]int64, len(comments))
+	for i, c := range comments {
+		var parentID *int64
+		if c.Parent >= 0 {
+			if c.Parent >= i {
+				err = fmt.Errorf("comment %d: parent %d is not an earlier comment", i, c.Parent)
+				return 0, err
+			}
+			parentID = &ids[c.Parent]
+		}
+		res, err = tx.ExecContext(ctx, `
+INSERT INTO comments (story_id, parent_id, text, score, created_at, hidden, account_id)
+VALUES (?, ?, ?, ?, ?, 0, ?)
+`, storyID, nullableInt(parentID), c.Comment.Text, c.Comment.Score, c.Comment.CreatedAt.UnixMilli(), c.Comment.AccountID)
+		if err != nil {
+			return 0, err
+		}
+		if ids[i], err = res.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+INSERT INTO imported_stories (source, external_id, story_id, imported_at) VALUES (?, ?, ?, ?)
+`, source, externalID, storyID, time.Now().UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return storyID, tx.Commit()
+}
+
+func (s *Store) SaveStoryTemplate(ctx context.Context, tmpl *model.StoryTemplate) (int64, error) {
+	tags, err := json.Marshal(tmpl.Tags)
+	if err != nil {
+		return 0, err
+	}
+	var id int64
+	err = s.db.QueryRowContext(ctx, `
+INSERT INTO story_templates (account_id, name, title, url, text, tags, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(account_id, name) DO UPDATE SET title = excluded.title, url = excluded.url,
+	text = excluded.text, tags = excluded.tags
+RETURNING id
+`, tmpl.AccountID, tmpl.Name, tmpl.Title, nullIfEmpty(tmpl.URL), nullIfEmpty(tmpl.Text), string(tags),
+		tmpl.CreatedAt.UnixMilli()).Scan(&id)
+	return id, err
+}
+
+func (s *Store) GetStoryTemplate(ctx context.Context, id int64) (model.StoryTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, account_id, name, title, url, text, tags, created_at FROM story_templates WHERE id = ?
+`, id)
+	if err != nil {
+		return model.StoryTemplate{}, err
+	}
+	defer rows.Close()
+	tmpls, err := scanStoryTemplates(rows)
+	if err != nil {
+		return model.StoryTemplate{}, err
+	}
+	if len(tmpls) == 0 {
+		return model.StoryTemplate{}, store.ErrNotFound
+	}
+	return tmpls[0], nil
+}
+
+func (s *Store) ListStoryTemplates(ctx context.Context, accountID int64) ([]model.StoryTemplate, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, account_id, name, title, url, text, tags, created_at FROM story_templates
+WHERE account_id = ? ORDER BY name
+`, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanStoryTemplates(rows)
+}
+
+func scanStoryTemplates(rows *sql.Rows) ([]model.StoryTemplate, error) {
+	var tmpls []model.StoryTemplate
+	for rows.Next() {
+		var t model.StoryTemplate
+		var url, text, tags sql.NullString
+		var created int64
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.Name, &t.Title, &url, &text, &tags, &created); err != nil {
+			return nil, err
+		}
+		t.URL = url.String
+		t.Text = text.String
+		if tags.Valid && tags.String != "" {
+			_ = json.Unmarshal([]byte(tags.String), &t.Tags)
+		}
+		t.CreatedAt = fromMillis(created)
+		tmpls = append(tmpls, t)
+	}
+	return tmpls, rows.Err()
+}
+
+func (s *Store) DeleteStoryTemplate(ctx context.Context, accountID, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM story_templates WHERE id = ? AND account_id = ?`, id, accountID)
+	if err != nil {
+		return err
+	}
+	if rows, _ := res.RowsAffected(); rows == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) DeleteToken(ctx context.Context, token string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM auth_tokens WHERE token = ?`, token)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO revoked_tokens (jti, expires_at) VALUES (?, ?)
+ON CONFLICT(jti) DO NOTHING
+`, jti, expiresAt.UnixMilli())
+	return err
+}
+
+func (s *Store) ListRevokedTokens(ctx context.Context, now time.Time) (map[string]time.Time, error) {
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at < ?`, now.UnixMilli())
+	rows, err := s.db.QueryContext(ctx, `SELECT jti, expires_at FROM revoked_tokens`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revoked := make(map[string]time.Time)
+	for rows.Next() {
+		var jti string
+		var expires int64
+		if err := rows.Scan(&jti, &expires); err != nil {
+			return nil, err
+		}
+		revoked[jti] = fromMillis(expires)
+	}
+	return revoked, rows.Err()
+}
+
+func (s *Store) ListRevokedKeys(ctx context.Context, since time.Time) (map[int64]bool, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id FROM account_keys WHERE revoked_at >= ?`, since.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revoked := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		revoked[id] = true
+	}
+	return revoked, rows.Err()
+}
+
+// revokeAccountTokens invalidates every JWT issued to the account up to at.
+func revokeAccountTokens(ctx context.Context, tx *sql.Tx, accountID int64, at time.Time) error {
+	_, err := tx.ExecContext(ctx, `
+INSERT INTO token_cutoffs (account_id, revoked_at) VALUES (?, ?)
+ON CONFLICT(account_id) DO UPDATE SET revoked_at = excluded.revoked_at
+`, accountID, at.UnixMilli())
+	return err
+}
+
+func (s *Store) ListTokenCutoffs(ctx context.Context, since time.Time) (map[int64]time.Time, error) {
+	_, _ = s.db.ExecContext(ctx, `DELETE FROM token_cutoffs WHERE revoked_at < ?`, since.UnixMilli())
+	rows, err := s.db.QueryContext(ctx, `SELECT account_id, revoked_at FROM token_cutoffs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cutoffs := make(map[int64]time.Time)
+	for rows.Next() {
+		var id, revokedAt int64
+		if err := rows.Scan(&id, &revokedAt); err != nil {
+			return nil, err
+		}
+		cutoffs[id] = fromMillis(revokedAt)
+	}
+	return cutoffs, rows.Err()
+}
+
+func (s *Store) CreateToken(ctx context.Context, token model.Token) error {
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO auth_tokens (token, account_id, key_id, expires_at, created_at, user_agent, ip_prefix)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`, token.Token, nullableInt(token.AccountID), token.KeyID, token.ExpiresAt.UnixMilli(), time.Now().UnixMilli(), nullIfEmpty(token.UserAgent), nullIfEmpty(token.IPPrefix))
+	return err
+}
+
+func (s *Store) GetToken(ctx context.Context, token string) (model.Token, error) {
+	row := s.db.QueryRowContext(ctx, `
+SELECT token, account_id, key_id, expires_at, last_used_at
+FROM auth_tokens
+WHERE token = ?
+`, token)
+	var t model.Token
+	var accountID sql.NullInt64
+	var expires int64
+	var lastUsed sql.NullInt64
+	if err := row.Scan(&t.Token, &accountID, &t.KeyID, &expires, &lastUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.Token{}, store.ErrNotFound
+		}
+		return model.Token{}, err
+	}
+	if accountID.Valid {
+		id := accountID.Int64
+		t.AccountID = &id
+	}
+	t.ExpiresAt = fromMillis(expires)
+	if lastUsed.Valid {
+		at := fromMillis(lastUsed.Int64)
+		t.LastUsedAt = &at
+	}
+	return t, nil
+}
+
+func (s *Store) TouchToken(ctx context.Context, token string, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE auth_tokens SET last_used_at = ? WHERE token = ?`, at.UnixMilli(), token)
+	return err
+}
+
+// Sessions are identified by the auth_tokens id so the token value never
+// leaves the server.
+func (s *Store) ListSessions(ctx context.Context, accountID int64, currentToken string, now time.Time) ([]model.Session, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, key_id, created_at, expires_at, last_used_at, user_agent, ip_prefix, token = ?
+FROM auth_tokens
+WHERE account_id = ? AND expires_at > ?
+ORDER BY created_at DESC
+`, currentToken, accountID, now.UnixMilli())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []model.Session
+	for rows.Next() {
+		var sess model.Session
+		var keyID sql.NullInt64
+		var created, expires int64
+		var lastUsed sql.NullInt64
+		var userAgent, ipPrefix sql.NullString
+		if err := rows.Scan(&sess.ID, &keyID, &created, &expires, &lastUsed, &userAgent, &ipPrefix, &sess.Current); err != nil {
+			return nil, err
+		}
+		sess.KeyID = keyID.Int64
+		sess.CreatedAt = fromMillis(created)
+		sess.ExpiresAt = fromMillis(expires)
+		if lastUsed.Valid {
+			at := fromMillis(lastUsed.Int64)
+			sess.LastUsedAt = &at
+		}
+		sess.UserAgent = userAgent.String
+		sess.IPPrefix = ipPrefix.String
+		sessions = append(sessions, sess)
+	}
+	return sessions, rows.Err()
+}
+
+func (s *Store) DeleteSession(ctx context.Context, accountID, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM auth_tokens WHERE id = ? AND account_id = ?`, id, accountID)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) SetRankPenalty(ctx context.Context, penalty *model.RankPenalty) (int64, error) {
+	var accountID int64
+	var domain string
+	switch penalty.TargetType {
+	case "account":
+		accountID = penalty.AccountID
+	case "domain":
+		domain = normalizeDomain(penalty.Domain)
+	default:
+		return 0, fmt.Errorf("invalid penalty target type %q", penalty.TargetType)
+	}
+	var id int64
+	err := s.db.QueryRowContext(ctx, `
+INSERT INTO rank_penalties (target_type, account_id, domain, weight, reason, created_at)
+VALUES (?, ?, ?, ?, ?, ?)
+ON CONFLICT(target_type, account_id, domain) DO UPDATE SET weight = excluded.weight, reason = excluded.reason, created_at = excluded.created_at
+RETURNING id
+`, penalty.TargetType, accountID, domain, penalty.Weight, nullIfEmpty(penalty.Reason), penalty.CreatedAt.UnixMilli()).Scan(&id)
+	return id, err
+}
+
+func (s *Store) BanDomain(ctx context.Context, ban *model.DomainBan) error {
+	ban.Domain = normalizeDomain(ban.Domain)
+	_, err := s.db.ExecContext(ctx, `
+INSERT INTO domain_bans (domain, reason, created_at) VALUES (?, ?, ?)
+ON CONFLICT(domain) DO UPDATE SET reason = excluded.reason
+`, ban.Domain, nullIfEmpty(ban.Reason), ban.CreatedAt.UnixMilli())
+	return err
+}
+
+func (s *Store) UnbanDomain(ctx context.Context, domain string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM domain_bans WHERE domain = ?`, normalizeDomain(domain))
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *Store) ListDomainBans(ctx context.Context) ([]model.DomainBan, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT domain, reason, created_at FROM domain_bans ORDER BY domain`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var bans []model.DomainBan
+	for rows.Next() {
+		ban, err := scanDomainBan(rows)
+		if err != nil {
+			return nil, err
+		}
+		bans = append(bans, ban)
+	}
+	return bans, rows.Err()
+}
+
+func (s *Store) FindDomainBan(ctx context.Context, host string) (model.DomainBan, error) {
+	// A ban covers its domain and every host under it, so look up the host
+	// and each of its parents.
+	labels := strings.Split(normalizeDomain(host), ".")
+	candidates := make([]any, len(labels))
+	for i := range labels {
+		candidates[i] = strings.Join(labels[i:], ".")
+	}
+	row := s.db.QueryRowContext(ctx, `
+SELECT domain, reason, created_at FROM domain_bans
+WHERE domain IN (?`+strings.Repeat(",?", len(candidates)-1)+`)
+ORDER BY length(domain) DESC
+LIMIT 1
+`, candidates...)
+	return scanDomainBan(row)
+}
+
+func scanDomainBan(scanner interface{ Scan(dest ...any) error }) (model.DomainBan, error) {
+	var ban model.DomainBan
+	var reason sql.NullString
+	var created int64
+	if err := scanner.Scan(&ban.Domain, &reason, &created); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return model.DomainBan{}, store.ErrNotFound
+		}
+		return model.DomainBan{}, err
+	}
+	ban.Reason = reason.String
+	ban.CreatedAt = fromMillis(created)
+	return ban, nil
+}
+
+func (s *Store) DeleteRankPenalty(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM rank_penalties WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
 	}
-	k.CreatedAt = time.Unix(created, 0)
-	if revoked.Valid {
-		t := time.Unix(revoked.Int64, 0)
-		k.RevokedAt = &t
+	return nil
+}
+
+func (s *Store) ListRankPenalties(ctx context.Context) ([]model.RankPenalty, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT id, target_type, account_id, domain, weight, reason, created_at
+FROM rank_penalties
+ORDER BY id
+`)
+	if err != nil {
+		return nil, err
 	}
-	if accCreated.Valid {
-		if displayName.Valid {
-			a.DisplayName = displayName.String
-		}
-		if bio.Valid {
-			a.Bio = bio.String
-		}
-		if homepage.Valid {
-			a.HomepageURL = homepage.String
-		}
-		if karma.Valid {
-			a.Karma = int(karma.Int64)
+	defer rows.Close()
+
+	var penalties []model.RankPenalty
+	for rows.Next() {
+		var p model.RankPenalty
+		var reason sql.NullString
+		var createdAt int64
+		if err := rows.Scan(&p.ID, &p.TargetType, &p.AccountID, &p.Domain, &p.Weight, &reason, &createdAt); err != nil {
+			return nil, err
 		}
-		a.CreatedAt = time.Unix(accCreated.Int64, 0)
-		return k, &a, nil
+		p.Reason = reason.String
+		p.CreatedAt = fromMillis(createdAt)
+		penalties = append(penalties, p)
 	}
-	return k, nil, nil
+	return penalties, rows.Err()
 }
 
-func (s *Store) CreateChallenge(ctx context.Context, c model.Challenge) error {
-	_, err := s.db.ExecContext(ctx, `
-INSERT INTO auth_challenges (challenge, alg, expires_at, created_at)
-VALUES (?, ?, ?, ?)
-`, c.Challenge, c.Alg, c.ExpiresAt.Unix(), time.Now().Unix())
-	return err
+func (s *Store) CreateLoopIncident(ctx context.Context, incident *model.LoopIncident) (int64, error) {
+	res, err := s.db.ExecContext(ctx, `
+INSERT INTO loop_incidents (story_id, account_a, account_b, comment_id, rounds, similarity, created_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+`, incident.StoryID, incident.AccountA, incident.AccountB, incident.CommentID, incident.Rounds, incident.Similarity, incident.CreatedAt.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
 }
 
-func (s *Store) ConsumeChallenge(ctx context.Context, challenge string) (model.Challenge, error) {
-	row := s.db.QueryRowContext(ctx, `
-SELECT challenge, alg, expires_at
-FROM auth_challenges
-WHERE challenge = ?
-`, challenge)
-	var c model.Challenge
-	var expires int64
-	if err := row.Scan(&c.Challenge, &c.Alg, &expires); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.Challenge{}, store.ErrNotFound
-		}
-		return model.Challenge{}, err
+const loopIncidentColumns = `id, story_id, account_a, account_b, comment_id, rounds, similarity, created_at, released_at`
+
+func scanLoopIncident(row interface{ Scan(...any) error }) (model.LoopIncident, error) {
+	var li model.LoopIncident
+	var createdAt int64
+	var releasedAt sql.NullInt64
+	if err := row.Scan(&li.ID, &li.StoryID, &li.AccountA, &li.AccountB, &li.CommentID, &li.Rounds, &li.Similarity, &createdAt, &releasedAt); err != nil {
+		return model.LoopIncident{}, err
 	}
-	c.ExpiresAt = time.Unix(expires, 0)
-	_, _ = s.db.ExecContext(ctx, `DELETE FROM auth_challenges WHERE challenge = ?`, challenge)
-	return c, nil
+	li.CreatedAt = fromMillis(createdAt)
+	if releasedAt.Valid {
+		t := fromMillis(releasedAt.Int64)
+		li.ReleasedAt = &t
+	}
+	return li, nil
 }
 
-func (s *Store) CreateToken(ctx context.Context, token model.Token) error {
-	_, err := s.db.ExecContext(ctx, `
-INSERT INTO auth_tokens (token, account_id, key_id, expires_at, created_at)
-VALUES (?, ?, ?, ?, ?)
-`, token.Token, nullableInt(token.AccountID), token.KeyID, token.ExpiresAt.Unix(), time.Now().Unix())
-	return err
+func (s *Store) GetOpenLoopIncident(ctx context.Context, storyID, accountID int64) (model.LoopIncident, error) {
+	li, err := scanLoopIncident(s.db.QueryRowContext(ctx, `
+SELECT `+loopIncidentColumns+`
+FROM loop_incidents
+WHERE story_id = ? AND released_at IS NULL AND (account_a = ? OR account_b = ?)
+ORDER BY id DESC
+LIMIT 1
+`, storyID, accountID, accountID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return model.LoopIncident{}, store.ErrNotFound
+	}
+	return li, err
 }
 
-func (s *Store) GetToken(ctx context.Context, token string) (model.Token, error) {
-	row := s.db.QueryRowContext(ctx, `
-SELECT token, account_id, key_id, expires_at
-FROM auth_tokens
-WHERE token = ?
-`, token)
-	var t model.Token
-	var accountID sql.NullInt64
-	var expires int64
-	if err := row.Scan(&t.Token, &accountID, &t.KeyID, &expires); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return model.Token{}, store.ErrNotFound
+func (s *Store) ListOpenLoopIncidents(ctx context.Context, limit, offset int) ([]model.LoopIncident, int, error) {
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM loop_incidents WHERE released_at IS NULL`).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT `+loopIncidentColumns+`
+FROM loop_incidents
+WHERE released_at IS NULL
+ORDER BY created_at DESC, id DESC
+LIMIT ? OFFSET ?
+`, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var incidents []model.LoopIncident
+	for rows.Next() {
+		li, err := scanLoopIncident(rows)
+		if err != nil {
+			return nil, 0, err
 		}
-		return model.Token{}, err
+		incidents = append(incidents, li)
 	}
-	if accountID.Valid {
-		id := accountID.Int64
-		t.AccountID = &id
+	return incidents, total, rows.Err()
+}
+
+func (s *Store) ReleaseLoopIncident(ctx context.Context, id int64, releasedAt time.Time) error {
+	res, err := s.db.ExecContext(ctx, `
+UPDATE loop_incidents SET released_at = ? WHERE id = ? AND released_at IS NULL
+`, releasedAt.UnixMilli(), id)
+	if err != nil {
+		return err
 	}
-	t.ExpiresAt = time.Unix(expires, 0)
-	return t, nil
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
 }
 
 func (s *Store) GetSiteStats(ctx context.Context) (model.SiteStats, error) {
@@ -1225,16 +4645,70 @@ func (s *Store) GetSiteStats(ctx context.Context) (model.SiteStats, error) {
 	return stats, nil
 }
 
+// statsDayFormat keys site_stats_daily rows by UTC date.
+const statsDayFormat = "2006-01-02"
+
+func (s *Store) RecordStatsSnapshot(ctx context.Context, day time.Time) (model.StatsSnapshot, error) {
+	start := day.UTC().Truncate(24 * time.Hour)
+	end := start.AddDate(0, 0, 1).UnixMilli()
+	snap := model.StatsSnapshot{Date: start.Format(statsDayFormat)}
+	err := s.db.QueryRowContext(ctx, `
+SELECT
+	(SELECT COUNT(*) FROM accounts WHERE created_at < ?1),
+	(SELECT COUNT(*) FROM stories WHERE hidden = 0 AND created_at < ?1),
+	(SELECT COUNT(*) FROM comments WHERE hidden = 0 AND created_at < ?1),
+	(SELECT COUNT(*) FROM votes WHERE created_at < ?1),
+	(SELECT COUNT(DISTINCT account_id) FROM (
+		SELECT account_id FROM stories WHERE created_at >= ?2 AND created_at < ?1
+		UNION ALL SELECT account_id FROM comments WHERE created_at >= ?2 AND created_at < ?1
+		UNION ALL SELECT account_id FROM votes WHERE created_at >= ?2 AND created_at < ?1
+	))`, end, start.UnixMilli()).Scan(&snap.Accounts, &snap.Stories, &snap.Comments, &snap.Votes, &snap.ActiveBots)
+	if err != nil {
+		return snap, err
+	}
+	_, err = s.db.ExecContext(ctx, `
+INSERT INTO site_stats_daily (day, accounts, stories, comments, votes, active_bots, recorded_at)
+VALUES (?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(day) DO UPDATE SET accounts = excluded.accounts, stories = excluded.stories, comments = excluded.comments,
+	votes = excluded.votes, active_bots = excluded.active_bots, recorded_at = excluded.recorded_at`,
+		snap.Date, snap.Accounts, snap.Stories, snap.Comments, snap.Votes, snap.ActiveBots, time.Now().UnixMilli())
+	return snap, err
+}
+
+func (s *Store) ListStatsHistory(ctx context.Context, since time.Time) ([]model.StatsSnapshot, error) {
+	rows, err := s.db.QueryContext(ctx, `
+SELECT day, accounts, stories, comments, votes, active_bots
+FROM site_stats_daily
+WHERE day >= ?
+ORDER BY day`, since.UTC().Format(statsDayFormat))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var history []model.StatsSnapshot
+	for rows.Next() {
+		var snap model.StatsSnapshot
+		if err := rows.Scan(&snap.Date, &snap.Accounts, &snap.Stories, &snap.Comments, &snap.Votes, &snap.ActiveBots); err != nil {
+			return nil, err
+		}
+		history = append(history, snap)
+	}
+	return history, rows.Err()
+}
+
 func scanStory(scanner interface{ Scan(dest ...any) error }) (model.Story, error) {
 	var s model.Story
 	var url sql.NullString
 	var text sql.NullString
 	var tagsRaw sql.NullString
 	var created int64
-	var hidden int
+	var hidden, locked, lockedByModerator int
+	var lockedAt sql.NullInt64
+	var lockReason sql.NullString
+	var pinned, edited sql.NullInt64
 	var accountName sql.NullString
 	var accountKarma sql.NullInt64
-	if err := scanner.Scan(&s.ID, &s.Title, &url, &text, &tagsRaw, &s.Score, &s.CommentCount, &s.FlagCount, &created, &hidden, &s.AccountID, &accountName, &accountKarma); err != nil {
+	if err := scanner.Scan(&s.ID, &s.Title, &url, &text, &tagsRaw, &s.Score, &s.CommentCount, &s.FlagCount, &created, &hidden, &locked, &lockedAt, &lockedByModerator, &lockReason, &pinned, &edited, &s.AccountID, &accountName, &accountKarma); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return model.Story{}, store.ErrNotFound
 		}
@@ -1249,153 +4723,284 @@ func scanStory(scanner interface{ Scan(dest ...any) error }) (model.Story, error
 	if tagsRaw.Valid && tagsRaw.String != "" {
 		_ = json.Unmarshal([]byte(tagsRaw.String), &s.Tags)
 	}
+	s.PostType = model.PostTypeOf(s.Tags)
+	s.Kind = model.KindOf(s.PostType, s.URL)
+	s.Domain = model.DomainOf(s.URL)
 	if accountName.Valid {
 		s.AccountName = accountName.String
 	}
 	s.AccountKarma = int(accountKarma.Int64)
-	s.CreatedAt = time.Unix(created, 0)
+	s.CreatedAt = fromMillis(created)
 	s.Hidden = hidden == 1
+	s.Locked = locked == 1
+	if s.Locked {
+		s.Lock = &model.StoryLock{ByModerator: lockedByModerator == 1, Reason: lockReason.String}
+		if lockedAt.Valid {
+			s.Lock.At = fromMillis(lockedAt.Int64)
+		}
+	}
+	if pinned.Valid {
+		id := pinned.Int64
+		s.PinnedCommentID = &id
+	}
+	if edited.Valid {
+		at := fromMillis(edited.Int64)
+		s.EditedAt = &at
+	}
 	return s, nil
 }
 
+// analyticsTopTags is how many tags GetAccountAnalytics returns.
+const analyticsTopTags = 10
+
+func (s *Store) GetAccountAnalytics(ctx context.Context, accountID int64, since time.Time) (model.AccountAnalytics, error) {
+	a := model.AccountAnalytics{Since: since}
+	sinceMillis := since.UnixMilli()
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT kind,
+	strftime('%Y-%m-%d', created_at / 1000, 'unixepoch') AS day,
+	CAST(strftime('%w', created_at / 1000, 'unixepoch') AS INTEGER) AS weekday,
+	CAST(strftime('%H', created_at / 1000, 'unixepoch') AS INTEGER) AS hour,
+	COUNT(*)
+FROM (
+	SELECT 'story' AS kind, created_at FROM stories WHERE account_id = ? AND hidden = 0 AND created_at >= ?
+	UNION ALL
+	SELECT 'comment', created_at FROM comments WHERE account_id = ? AND hidden = 0 AND created_at >= ?
+	UNION ALL
+	SELECT 'vote', created_at FROM votes WHERE account_id = ? AND created_at >= ?
+)
+GROUP BY kind, day, weekday, hour
+ORDER BY day
+`, accountID, sinceMillis, accountID, sinceMillis, accountID, sinceMillis)
+	if err != nil {
+		return a, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var kind, day string
+		var weekday, hour, n int
+		if err := rows.Scan(&kind, &day, &weekday, &hour, &n); err != nil {
+			return a, err
+		}
+		if len(a.Days) == 0 || a.Days[len(a.Days)-1].Date != day {
+			a.Days = append(a.Days, model.ActivityDay{Date: day})
+		}
+		counts := &a.Days[len(a.Days)-1].ActivityCounts
+		switch kind {
+		case "story":
+			counts.Stories += n
+			a.Totals.Stories += n
+		case "comment":
+			counts.Comments += n
+			a.Totals.Comments += n
+		case "vote":
+			counts.Votes += n
+			a.Totals.Votes += n
+		}
+		a.Heatmap[weekday][hour] += n
+	}
+	if err := rows.Err(); err != nil {
+		return a, err
+	}
+
+	err = s.db.QueryRowContext(ctx, `
+SELECT
+	COALESCE((SELECT AVG(score) FROM stories WHERE account_id = ? AND hidden = 0 AND created_at >= ?), 0),
+	COALESCE((SELECT AVG(score) FROM comments WHERE account_id = ? AND hidden = 0 AND created_at >= ?), 0)
+`, accountID, sinceMillis, accountID, sinceMillis).Scan(&a.AvgStoryScore, &a.AvgCommentScore)
+	if err != nil {
+		return a, err
+	}
+
+	tagRows, err := s.db.QueryContext(ctx, `
+SELECT LOWER(t.value) AS tag, COUNT(*), AVG(s.score)
+FROM stories s, json_each(CASE WHEN json_valid(s.tags) THEN s.tags ELSE '[]' END) t
+WHERE s.account_id = ? AND s.hidden = 0 AND s.created_at >= ? AND t.type = 'text'
+GROUP BY tag
+ORDER BY COUNT(*) DESC, tag
+LIMIT ?
+`, accountID, sinceMillis, analyticsTopTags)
+	if err != nil {
+		return a, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var t model.TagStat
+		if err := tagRows.Scan(&t.Tag, &t.Stories, &t.AvgScore); err != nil {
+			return a, err
+		}
+		a.TopTags = append(a.TopTags, t)
+	}
+	return a, tagRows.Err()
+}
+
 func (s *Store) GetAccountActivitySummary(ctx context.Context, accountID int64) (model.ActivitySummary, error) {
-	var summary model.ActivitySummary
-	
-	// Get story stats
-	row := s.db.QueryRowContext(ctx, `
-SELECT 
-	COUNT(*) as story_count,
-	COALESCE(SUM(score), 0) as total_story_score,
-	COALESCE(AVG(score), 0) as avg_story_score
-FROM stories 
+	summary := model.ActivitySummary{
+		StoriesByKind:  map[string]int{},
+		CommentsByKind: map[string]int{},
+	}
+
+	var storyScore, commentScore int
+	if err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*), COALESCE(SUM(score), 0), COALESCE(AVG(score), 0)
+FROM stories
 WHERE account_id = ? AND hidden = 0
-`, accountID)
-	
-	var storyCount int
-	var totalStoryScore int
-	var avgStoryScore float64
-	
-	if err := row.Scan(&storyCount, &totalStoryScore, &avgStoryScore); err != nil {
+`, accountID).Scan(&summary.StoriesSubmitted, &storyScore, &summary.AvgStoryScore); err != nil {
 		return summary, err
 	}
-	
-	// Get comment stats
-	row = s.db.QueryRowContext(ctx, `
-SELECT 
-	COUNT(*) as comment_count,
-	COALESCE(SUM(score), 0) as total_comment_score,
-	COALESCE(AVG(score), 0) as avg_comment_score
-FROM comments 
+	if err := s.db.QueryRowContext(ctx, `
+SELECT COUNT(*), COALESCE(SUM(score), 0), COALESCE(AVG(score), 0)
+FROM comments
 WHERE account_id = ? AND hidden = 0
-`, accountID)
-	
-	var commentCount int
-	var totalCommentScore int
-	var avgCommentScore float64
-	
-	if err := row.Scan(&commentCount, &totalCommentScore, &avgCommentScore); err != nil {
+`, accountID).Scan(&summary.CommentsPosted, &commentScore, &summary.AvgCommentScore); err != nil {
 		return summary, err
 	}
-	
-	// Get activity timeline
-	row = s.db.QueryRowContext(ctx, `
-SELECT 
-	MAX(created_at) as last_activity,
-	COUNT(DISTINCT DATE(created_at, 'unixepoch')) as active_days
+	summary.TotalScore = storyScore + commentScore
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM votes WHERE account_id = ?`, accountID).Scan(&summary.VotesCast); err != nil {
+		return summary, err
+	}
+
+	if err := s.countInto(ctx, summary.StoriesByKind, `
+SELECT kind, COUNT(*) FROM stories
+WHERE account_id = ? AND hidden = 0
+GROUP BY kind
+`, accountID); err != nil {
+		return summary, err
+	}
+	if err := s.countInto(ctx, summary.CommentsByKind, `
+SELECT CASE WHEN kind = ? THEN ? WHEN parent_id IS NULL THEN ? ELSE ? END, COUNT(*) FROM comments
+WHERE account_id = ? AND hidden = 0
+GROUP BY 1
+`, model.CommentProposal, model.CommentProposal, model.CommentTopLevel, model.CommentReply, accountID); err != nil {
+		return summary, err
+	}
+
+	// The UTC days with a story or comment, oldest first, give the days
+	// active, the first and last activity and the streaks.
+	rows, err := s.db.QueryContext(ctx, `
+SELECT DATE(created_at / 1000, 'unixepoch') AS day, MIN(created_at), MAX(created_at)
 FROM (
 	SELECT created_at FROM stories WHERE account_id = ? AND hidden = 0
 	UNION ALL
 	SELECT created_at FROM comments WHERE account_id = ? AND hidden = 0
 )
+GROUP BY day
+ORDER BY day
 `, accountID, accountID)
-	
-	var lastActivityUnix sql.NullInt64
-	var activeDays int
-	
-	if err := row.Scan(&lastActivityUnix, &activeDays); err != nil {
+	if err != nil {
 		return summary, err
 	}
-	
-	summary.StoriesSubmitted = storyCount
-	summary.CommentsPosted = commentCount
-	summary.TotalScore = totalStoryScore + totalCommentScore
-	summary.AvgStoryScore = avgStoryScore
-	summary.AvgCommentScore = avgCommentScore
-	summary.DaysActive = activeDays
-	
-	if lastActivityUnix.Valid {
-		summary.LastActivity = time.Unix(lastActivityUnix.Int64, 0)
+	defer rows.Close()
+	var days []string
+	for rows.Next() {
+		var day string
+		var first, last int64
+		if err := rows.Scan(&day, &first, &last); err != nil {
+			return summary, err
+		}
+		if len(days) == 0 {
+			summary.FirstActivity = fromMillis(first)
+		}
+		summary.LastActivity = fromMillis(last)
+		days = append(days, day)
 	}
-	
+	if err := rows.Err(); err != nil {
+		return summary, err
+	}
+	summary.DaysActive = len(days)
+	summary.CurrentStreak, summary.LongestStreak = activityStreaks(days, time.Now())
 	return summary, nil
 }
 
-func (s *Store) GetRecentlyActiveUsers(ctx context.Context, limit int) ([]model.UserActivity, error) {
+// countInto adds the rows of a "SELECT key, COUNT(*) ... GROUP BY" query
+// to counts.
+func (s *Store) countInto(ctx context.Context, counts map[string]int, query string, args ...any) error {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		var n int
+		if err := rows.Scan(&key, &n); err != nil {
+			return err
+		}
+		counts[key] += n
+	}
+	return rows.Err()
+}
+
+// activityStreaks measures runs of consecutive days in days, sorted
+// "2006-01-02" dates. The current streak is the run ending today or
+// yesterday (UTC), so it survives until a whole day passes without
+// activity.
+func activityStreaks(days []string, now time.Time) (current, longest int) {
+	run := 0
+	var prev time.Time
+	for _, d := range days {
+		day, err := time.Parse(time.DateOnly, d)
+		if err != nil {
+			continue
+		}
+		if !prev.IsZero() && day.Sub(prev) == 24*time.Hour {
+			run++
+		} else {
+			run = 1
+		}
+		longest = max(longest, run)
+		prev = day
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	if !prev.IsZero() && today.Sub(prev) <= 24*time.Hour {
+		current = run
+	}
+	return current, longest
+}
+
+func (s *Store) GetRecentlyActiveUsers(ctx context.Context, since time.Time, limit int) ([]model.UserActivity, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-	
-	query := `
-SELECT 
-	a.id,
-	a.display_name,
-	a.karma,
-	COALESCE(activity.last_activity, 0) as last_activity,
-	COALESCE(activity.recent_stories, 0) as recent_stories,
-	COALESCE(activity.recent_comments, 0) as recent_comments
-FROM accounts a
-LEFT JOIN (
-	SELECT 
-		account_id,
-		MAX(created_at) as last_activity,
-		SUM(CASE WHEN type = 'story' THEN 1 ELSE 0 END) as recent_stories,
-		SUM(CASE WHEN type = 'comment' THEN 1 ELSE 0 END) as recent_comments
+	at := since.UnixMilli()
+	rows, err := s.db.QueryContext(ctx, `
+SELECT a.id, a.display_name, a.karma, activity.last_activity, activity.recent_stories, activity.recent_comments, activity.recent_votes
+FROM (
+	SELECT account_id,
+		MAX(created_at) AS last_activity,
+		SUM(type = 'story') AS recent_stories,
+		SUM(type = 'comment') AS recent_comments,
+		SUM(type = 'vote') AS recent_votes
 	FROM (
-		SELECT account_id, created_at, 'story' as type 
-		FROM stories 
-		WHERE hidden = 0 AND created_at > ? 
+		SELECT account_id, created_at, 'story' AS type FROM stories WHERE hidden = 0 AND created_at > ?
 		UNION ALL
-		SELECT account_id, created_at, 'comment' as type 
-		FROM comments 
-		WHERE hidden = 0 AND created_at > ?
+		SELECT account_id, created_at, 'comment' AS type FROM comments WHERE hidden = 0 AND created_at > ?
+		UNION ALL
+		SELECT account_id, created_at, 'vote' AS type FROM votes WHERE created_at > ?
 	)
 	GROUP BY account_id
-) activity ON a.id = activity.account_id
-WHERE activity.last_activity IS NOT NULL
-ORDER BY activity.last_activity DESC
+) activity
+JOIN accounts a ON a.id = activity.account_id
+WHERE a.sandbox = 0
+ORDER BY activity.last_activity DESC, a.id
 LIMIT ?
-`
-
-	// Get activity from the last 30 days
-	thirtyDaysAgo := time.Now().AddDate(0, 0, -30).Unix()
-	
-	rows, err := s.db.QueryContext(ctx, query, thirtyDaysAgo, thirtyDaysAgo, limit)
+`, at, at, at, limit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	
+
 	var users []model.UserActivity
 	for rows.Next() {
 		var user model.UserActivity
-		var lastActivityUnix int64
-		
-		err := rows.Scan(
-			&user.AccountID,
-			&user.DisplayName,
-			&user.Karma,
-			&lastActivityUnix,
-			&user.RecentStories,
-			&user.RecentComments,
-		)
-		if err != nil {
+		var last int64
+		if err := rows.Scan(&user.AccountID, &user.DisplayName, &user.Karma, &last, &user.RecentStories, &user.RecentComments, &user.RecentVotes); err != nil {
 			return nil, err
 		}
-		
-		user.LastActivity = time.Unix(lastActivityUnix, 0)
+		user.LastActivity = fromMillis(last)
 		users = append(users, user)
 	}
-	
 	return users, rows.Err()
 }
 
@@ -1403,7 +5008,7 @@ func (s *Store) ClaimGitHubStar(ctx context.Context, accountID int64, githubUser
 	_, err := s.db.ExecContext(ctx, `
 INSERT INTO github_star_rewards (account_id, github_username, created_at)
 VALUES (?, ?, ?)
-`, accountID, githubUsername, time.Now().Unix())
+`, accountID, githubUsername, time.Now().UnixMilli())
 	if err != nil {
 		if isUniqueViolation(err) {
 			return store.ErrAlreadyClaimed
@@ -1421,9 +5026,111 @@ SELECT EXISTS(SELECT 1 FROM github_star_rewards WHERE account_id = ?)
 	return exists, err
 }
 
-func rankScore(story model.Story, now time.Time) float64 {
+// legacySecondsCutoff separates unix-second values (written before migration 3)
+// from unix-millisecond values. 1e11 ms is early 1973; 1e11 s is year 5138.
+const legacySecondsCutoff = 100000000000
+
+// fromMillis converts a stored timestamp to time.Time, accepting legacy
+// unix-second values for backward compatibility.
+func fromMillis(v int64) time.Time {
+	return time.UnixMilli(toMillis(v))
+}
+
+// toMillis normalizes a unix timestamp that may be in seconds to milliseconds.
+func toMillis(v int64) int64 {
+	if v > 0 && v < legacySecondsCutoff {
+		return v * 1000
+	}
+	return v
+}
+
+// diversify reorders ranked stories so that no account or domain holds more
+// than its cap within the first topN positions. Stories over the cap keep
+// their relative order and are placed directly after the top N.
+func diversify(stories []model.Story, maxPerAccount, maxPerDomain, topN int) []model.Story {
+	if (maxPerAccount <= 0 && maxPerDomain <= 0) || len(stories) == 0 {
+		return stories
+	}
+	if topN <= 0 || topN > len(stories) {
+		topN = len(stories)
+	}
+	byAccount := make(map[int64]int)
+	byDomain := make(map[string]int)
+	top := make([]model.Story, 0, len(stories))
+	var deferred []model.Story
+	for i, st := range stories {
+		if len(top) >= topN {
+			deferred = append(deferred, stories[i:]...)
+			break
+		}
+		domain := storyDomain(st.URL)
+		if maxPerAccount > 0 && byAccount[st.AccountID] >= maxPerAccount {
+			deferred = append(deferred, st)
+			continue
+		}
+		if maxPerDomain > 0 && domain != "" && byDomain[domain] >= maxPerDomain {
+			deferred = append(deferred, st)
+			continue
+		}
+		byAccount[st.AccountID]++
+		if domain != "" {
+			byDomain[domain]++
+		}
+		top = append(top, st)
+	}
+	return append(top, deferred...)
+}
+
+// storyDomain returns the lowercase host of a story URL without a leading "www.".
+func storyDomain(rawURL string) string {
+	if rawURL == "" {
+		return ""
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return normalizeDomain(u.Hostname())
+}
+
+func normalizeDomain(host string) string {
+	return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "www.")
+}
+
+// penaltyWeight returns the combined multiplier of every penalty matching
+// the story's account or domain; 1 when none apply.
+func penaltyWeight(story model.Story, penalties []model.RankPenalty) float64 {
+	weight := 1.0
+	if len(penalties) == 0 {
+		return weight
+	}
+	domain := storyDomain(story.URL)
+	for _, p := range penalties {
+		switch {
+		case p.TargetType == "account" && p.AccountID == story.AccountID:
+			weight *= p.Weight
+		case p.TargetType == "domain" && domain != "" && p.Domain == domain:
+			weight *= p.Weight
+		}
+	}
+	return weight
+}
+
+// applyPenalty pushes a rank toward the bottom by weight. Negative ranks are
+// divided so a penalty never improves a downvoted story.
+func applyPenalty(rank, weight float64) float64 {
+	if weight <= 0 || weight >= 1 {
+		return rank
+	}
+	if rank < 0 {
+		return rank / weight
+	}
+	return rank * weight
+}
+
+func rankScore(story model.Story, now time.Time, gravity float64) float64 {
 	hours := now.Sub(story.CreatedAt).Hours()
-	return float64(story.Score) / pow(hours+2, 1.5)
+	return float64(story.Score) / pow(hours+2, gravity)
 }
 
 func pow(x, y float64) float64 {
@@ -1440,6 +5147,15 @@ func clamp(v, min, max int) int {
 	return v
 }
 
+// sandboxIn matches account_id columns of sandbox accounts, or of all other
+// accounts when sandbox is false.
+func sandboxIn(sandbox bool) string {
+	if sandbox {
+		return "IN (SELECT id FROM accounts WHERE sandbox = 1)"
+	}
+	return "NOT IN (SELECT id FROM accounts WHERE sandbox = 1)"
+}
+
 func boolToInt(b bool) int {
 	if b {
 		return 1
@@ -2,7 +2,10 @@ package sqlite
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
+	"sync"
 	"testing"
 	"time"
 
@@ -82,3 +85,553 @@ func TestDuplicateVote(t *testing.T) {
 		t.Fatalf("expected ErrDuplicateVote, got %v", err)
 	}
 }
+
+func TestApplyVote(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	account := model.Account{DisplayName: "Author", CreatedAt: time.Now()}
+	authorID, _, err := st.CreateAccount(ctx, &account, &model.AccountKey{Alg: "ed25519", PublicKey: "author-key", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	storyID, err := st.CreateStory(ctx, &model.Story{Title: "Voted on", Text: "t", CreatedAt: time.Now(), AccountID: authorID})
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	commentID, err := st.CreateComment(ctx, &model.Comment{StoryID: storyID, Text: "c", CreatedAt: time.Now(), AccountID: authorID})
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+
+	vote := model.Vote{TargetType: "story", TargetID: storyID, Value: 1, CreatedAt: time.Now(), AccountID: 2}
+	result, err := st.ApplyVote(ctx, &vote, -3)
+	if err != nil {
+		t.Fatalf("apply vote: %v", err)
+	}
+	if vote.ID == 0 || result != (store.VoteResult{AuthorID: authorID, Score: 1}) {
+		t.Fatalf("unexpected result %+v for vote %+v", result, vote)
+	}
+	if _, err := st.ApplyVote(ctx, &vote, -3); err != store.ErrDuplicateVote {
+		t.Fatalf("expected ErrDuplicateVote, got %v", err)
+	}
+	missing := model.Vote{TargetType: "comment", TargetID: 9999, Value: 1, CreatedAt: time.Now(), AccountID: 2}
+	if _, err := st.ApplyVote(ctx, &missing, -3); err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := st.GetUserVote(ctx, 2, "comment", 9999); err != store.ErrNotFound {
+		t.Fatalf("expected the vote on a missing target rolled back, got %v", err)
+	}
+
+	// The downvote that reaches the threshold hides the comment; later
+	// ones find it hidden already.
+	for voter := int64(2); voter <= 3; voter++ {
+		down := model.Vote{TargetType: "comment", TargetID: commentID, Value: -1, CreatedAt: time.Now(), AccountID: voter}
+		result, err := st.ApplyVote(ctx, &down, -1)
+		if err != nil {
+			t.Fatalf("downvote: %v", err)
+		}
+		if want := (store.VoteResult{AuthorID: authorID, Score: int(1 - voter), Hidden: true, AutoHidden: voter == 2}); result != want {
+			t.Fatalf("voter %d: got %+v, want %+v", voter, result, want)
+		}
+	}
+	got, err := st.GetAccount(ctx, authorID)
+	if err != nil {
+		t.Fatalf("get account: %v", err)
+	}
+	if got.Karma != -1 {
+		t.Fatalf("expected karma -1 from all three votes, got %d", got.Karma)
+	}
+}
+
+func TestApplyVoteConcurrent(t *testing.T) {
+	st, err := Open(filepath.Join(t.TempDir(), "votes.db"))
+	if err != nil {
+		t.Fatalf("open store: %v", err)
+	}
+	defer st.Close()
+	ctx := context.Background()
+
+	storyID, err := st.CreateStory(ctx, &model.Story{Title: "Popular", Text: "t", CreatedAt: time.Now(), AccountID: 1})
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	const voters = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, voters)
+	for voter := range int64(voters) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			vote := model.Vote{TargetType: "story", TargetID: storyID, Value: -1, CreatedAt: time.Now(), AccountID: voter + 100}
+			_, err := st.ApplyVote(ctx, &vote, -voters)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("apply vote: %v", err)
+		}
+	}
+	story, err := st.GetStory(ctx, storyID)
+	if err != nil {
+		t.Fatalf("get story: %v", err)
+	}
+	if story.Score != -voters || !story.Hidden {
+		t.Fatalf("expected score %d and hidden after every vote, got %d, hidden %v", -voters, story.Score, story.Hidden)
+	}
+}
+
+func TestListCommentAncestors(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	storyID, err := st.CreateStory(ctx, &model.Story{Title: "Thread", Text: "t", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	var ids []int64
+	var parent *int64
+	for i := range 4 {
+		id, err := st.CreateComment(ctx, &model.Comment{StoryID: storyID, ParentID: parent, Text: fmt.Sprintf("reply %d", i), CreatedAt: time.Now(), Hidden: i == 1})
+		if err != nil {
+			t.Fatalf("create comment: %v", err)
+		}
+		ids = append(ids, id)
+		parent = &ids[i]
+	}
+	if _, err := st.CreateComment(ctx, &model.Comment{StoryID: storyID, Text: "another thread", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+
+	for _, tt := range []struct {
+		id    int64
+		limit int
+		want  []int64
+	}{
+		{ids[3], 10, []int64{ids[3], ids[2], ids[1], ids[0]}},
+		{ids[3], 2, []int64{ids[3], ids[2], ids[1]}},
+		{ids[0], 10, []int64{ids[0]}},
+		{9999, 10, nil},
+	} {
+		chain, err := st.ListCommentAncestors(ctx, tt.id, tt.limit)
+		if err != nil {
+			t.Fatalf("list ancestors: %v", err)
+		}
+		var got []int64
+		for _, c := range chain {
+			got = append(got, c.ID)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("ancestors of %d (limit %d): got %v, want %v", tt.id, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMillisecondTimestamps(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	created := time.UnixMilli(time.Now().UnixMilli())
+	story := model.Story{Title: "Millis Story", Text: "body", CreatedAt: created}
+	id, err := st.CreateStory(ctx, &story)
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	got, err := st.GetStory(ctx, id)
+	if err != nil {
+		t.Fatalf("get story: %v", err)
+	}
+	if !got.CreatedAt.Equal(created) {
+		t.Fatalf("expected %v, got %v", created, got.CreatedAt)
+	}
+
+	// Rows written before the migration hold unix seconds.
+	legacy := time.Now().Add(-time.Hour).Unix()
+	if _, err := st.db.ExecContext(ctx, `UPDATE stories SET created_at = ? WHERE id = ?`, legacy, id); err != nil {
+		t.Fatalf("write legacy timestamp: %v", err)
+	}
+	got, err = st.GetStory(ctx, id)
+	if err != nil {
+		t.Fatalf("get legacy story: %v", err)
+	}
+	if got.CreatedAt.Unix() != legacy {
+		t.Fatalf("expected legacy read %d, got %d", legacy, got.CreatedAt.Unix())
+	}
+}
+
+func TestDiscussedUsesRecentWindow(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	old := model.Story{Title: "Old Megathread", Text: "old", CreatedAt: time.Now().Add(-10 * 24 * time.Hour)}
+	oldID, err := st.CreateStory(ctx, &old)
+	if err != nil {
+		t.Fatalf("create old story: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		c := model.Comment{StoryID: oldID, Text: "old comment", CreatedAt: time.Now().Add(-5 * 24 * time.Hour)}
+		if _, err := st.CreateComment(ctx, &c); err != nil {
+			t.Fatalf("create old comment: %v", err)
+		}
+		_ = st.IncrementStoryCommentCount(ctx, oldID)
+	}
+
+	fresh := model.Story{Title: "Fresh Thread", Text: "fresh", CreatedAt: time.Now().Add(-time.Hour)}
+	freshID, err := st.CreateStory(ctx, &fresh)
+	if err != nil {
+		t.Fatalf("create fresh story: %v", err)
+	}
+	c := model.Comment{StoryID: freshID, Text: "fresh comment", CreatedAt: time.Now()}
+	if _, err := st.CreateComment(ctx, &c); err != nil {
+		t.Fatalf("create fresh comment: %v", err)
+	}
+	_ = st.IncrementStoryCommentCount(ctx, freshID)
+
+	stories, _, err := st.ListStories(ctx, store.StoryListOpts{Sort: "discussed", Limit: 10})
+	if err != nil {
+		t.Fatalf("list discussed: %v", err)
+	}
+	if len(stories) != 2 || stories[0].ID != freshID {
+		t.Fatalf("expected fresh thread first within 48h window, got %+v", stories)
+	}
+
+	stories, _, err = st.ListStories(ctx, store.StoryListOpts{Sort: "discussed", Limit: 10, DiscussedWindow: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("list discussed wide window: %v", err)
+	}
+	if stories[0].ID != oldID {
+		t.Fatalf("expected old thread first with 30d window")
+	}
+}
+
+func TestDiversifyCapsAccountAndDomain(t *testing.T) {
+	stories := []model.Story{
+		{ID: 1, AccountID: 1, URL: "https://a.com/1"},
+		{ID: 2, AccountID: 1, URL: "https://b.com/2"},
+		{ID: 3, AccountID: 1, URL: "https://c.com/3"},
+		{ID: 4, AccountID: 2, URL: "https://www.a.com/4"},
+		{ID: 5, AccountID: 3, URL: "https://a.com/5"},
+		{ID: 6, AccountID: 4},
+	}
+	got := diversify(stories, 2, 2, 4)
+	var ids []int64
+	for _, s := range got {
+		ids = append(ids, s.ID)
+	}
+	want := []int64{1, 2, 4, 6, 3, 5}
+	if fmt.Sprint(ids) != fmt.Sprint(want) {
+		t.Fatalf("expected order %v, got %v", want, ids)
+	}
+}
+
+func TestRankPenaltyDownweightsTop(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	now := time.Now()
+	spam := model.Story{Title: "Prolific post", URL: "https://www.spam.example/1", Score: 10, CreatedAt: now}
+	spamID, err := st.CreateStory(ctx, &spam)
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	other := model.Story{Title: "Regular post", URL: "https://other.example/1", Score: 5, CreatedAt: now}
+	otherID, err := st.CreateStory(ctx, &other)
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+
+	topIDs := func() []int64 {
+		t.Helper()
+		stories, _, err := st.ListStories(ctx, store.StoryListOpts{Sort: "top", Limit: 10})
+		if err != nil {
+			t.Fatalf("list stories: %v", err)
+		}
+		var ids []int64
+		for _, s := range stories {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	if got := topIDs(); fmt.Sprint(got) != fmt.Sprint([]int64{spamID, otherID}) {
+		t.Fatalf("unexpected order without penalty: %v", got)
+	}
+
+	id, err := st.SetRankPenalty(ctx, &model.RankPenalty{TargetType: "domain", Domain: "Spam.Example", Weight: 0.9, CreatedAt: now})
+	if err != nil {
+		t.Fatalf("set penalty: %v", err)
+	}
+	if got := topIDs(); fmt.Sprint(got) != fmt.Sprint([]int64{spamID, otherID}) {
+		t.Fatalf("mild penalty should not reorder: %v", got)
+	}
+
+	// Setting the same target again replaces the weight.
+	again, err := st.SetRankPenalty(ctx, &model.RankPenalty{TargetType: "domain", Domain: "spam.example", Weight: 0.1, CreatedAt: now})
+	if err != nil {
+		t.Fatalf("replace penalty: %v", err)
+	}
+	if again != id {
+		t.Fatalf("expected penalty %d to be replaced, got new id %d", id, again)
+	}
+	if got := topIDs(); fmt.Sprint(got) != fmt.Sprint([]int64{otherID, spamID}) {
+		t.Fatalf("expected penalized story last, got %v", got)
+	}
+
+	if err := st.DeleteRankPenalty(ctx, id); err != nil {
+		t.Fatalf("delete penalty: %v", err)
+	}
+	if err := st.DeleteRankPenalty(ctx, id); err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if got := topIDs(); fmt.Sprint(got) != fmt.Sprint([]int64{spamID, otherID}) {
+		t.Fatalf("unexpected order after removing penalty: %v", got)
+	}
+}
+
+func TestTopGravityAndMinScore(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	now := time.Now()
+	old := model.Story{Title: "Old favourite", Text: "x", Score: 20, CreatedAt: now.Add(-10 * time.Hour)}
+	oldID, err := st.CreateStory(ctx, &old)
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	fresh := model.Story{Title: "Fresh post", Text: "x", Score: 3, CreatedAt: now}
+	freshID, err := st.CreateStory(ctx, &fresh)
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+
+	ids := func(opts store.StoryListOpts) []int64 {
+		t.Helper()
+		opts.Limit = 10
+		stories, _, err := st.ListStories(ctx, opts)
+		if err != nil {
+			t.Fatalf("list stories: %v", err)
+		}
+		var ids []int64
+		for _, s := range stories {
+			ids = append(ids, s.ID)
+		}
+		return ids
+	}
+
+	if got := ids(store.StoryListOpts{Sort: "top"}); fmt.Sprint(got) != fmt.Sprint([]int64{freshID, oldID}) {
+		t.Fatalf("default gravity should favour the fresh story: %v", got)
+	}
+	if got := ids(store.StoryListOpts{Sort: "top", Gravity: 0.1}); fmt.Sprint(got) != fmt.Sprint([]int64{oldID, freshID}) {
+		t.Fatalf("low gravity should favour the high score: %v", got)
+	}
+
+	minScore := 5
+	if got := ids(store.StoryListOpts{Sort: "top", MinScore: &minScore}); fmt.Sprint(got) != fmt.Sprint([]int64{oldID}) {
+		t.Fatalf("expected only stories at or above the threshold, got %v", got)
+	}
+	if got := ids(store.StoryListOpts{Sort: "new", MinScore: &minScore}); len(got) != 2 {
+		t.Fatalf("threshold should not apply to new, got %v", got)
+	}
+}
+
+func TestCreateCommentWithSideEffects(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	account := model.Account{DisplayName: "Commenter", CreatedAt: time.Now()}
+	key := model.AccountKey{Alg: "ed25519", PublicKey: "commenter-key", CreatedAt: time.Now()}
+	accountID, _, err := st.CreateAccount(ctx, &account, &key)
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	storyID, err := st.CreateStory(ctx, &model.Story{Title: "Side effects", Text: "t", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+
+	c := model.Comment{StoryID: storyID, Text: "visible", CreatedAt: time.Now(), AccountID: accountID}
+	story, err := st.CreateCommentWithSideEffects(ctx, &c)
+	if err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	if c.ID == 0 || story.CommentCount != 1 {
+		t.Fatalf("expected comment id and comment_count 1, got id %d story %+v", c.ID, story)
+	}
+	if got, _ := st.GetAccount(ctx, accountID); got.Karma != 1 {
+		t.Fatalf("expected karma 1, got %d", got.Karma)
+	}
+
+	hidden := model.Comment{StoryID: storyID, Text: "hidden", CreatedAt: time.Now(), Hidden: true, AccountID: accountID}
+	story, err = st.CreateCommentWithSideEffects(ctx, &hidden)
+	if err != nil {
+		t.Fatalf("create hidden comment: %v", err)
+	}
+	if story.CommentCount != 1 {
+		t.Fatalf("hidden comment changed comment_count to %d", story.CommentCount)
+	}
+
+	// A missing story fails the whole transaction.
+	orphan := model.Comment{StoryID: storyID + 100, Text: "orphan", CreatedAt: time.Now(), AccountID: accountID}
+	if _, err := st.CreateCommentWithSideEffects(ctx, &orphan); err == nil {
+		t.Fatalf("expected error for missing story")
+	}
+	if got, _ := st.GetAccount(ctx, accountID); got.Karma != 1 {
+		t.Fatalf("expected karma unchanged at 1, got %d", got.Karma)
+	}
+	comments, _, err := st.ListCommentsByAccount(ctx, accountID, store.AccountContentOpts{Limit: 10})
+	if err != nil {
+		t.Fatalf("list comments: %v", err)
+	}
+	for _, cm := range comments {
+		if cm.Text == "orphan" {
+			t.Fatalf("orphan comment was not rolled back")
+		}
+	}
+}
+
+func TestCommentCountFollowsHiding(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	storyID, err := st.CreateStory(ctx, &model.Story{Title: "Counted", Text: "t", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	c := model.Comment{StoryID: storyID, Text: "visible", CreatedAt: time.Now()}
+	if _, err := st.CreateCommentWithSideEffects(ctx, &c); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	count := func() int {
+		t.Helper()
+		story, err := st.GetStory(ctx, storyID)
+		if err != nil {
+			t.Fatalf("get story: %v", err)
+		}
+		return story.CommentCount
+	}
+
+	// Hiding twice only decrements once, and unhiding restores the count.
+	for _, step := range []struct {
+		name string
+		fn   func(context.Context, int64) error
+		want int
+	}{
+		{"hide", st.HideComment, 0},
+		{"hide again", st.HideComment, 0},
+		{"unhide", st.UnhideComment, 1},
+		{"unhide again", st.UnhideComment, 1},
+	} {
+		if err := step.fn(ctx, c.ID); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if got := count(); got != step.want {
+			t.Fatalf("after %s: expected comment_count %d, got %d", step.name, step.want, got)
+		}
+	}
+	if err := st.UnhideComment(ctx, c.ID+100); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound unhiding a missing comment, got %v", err)
+	}
+
+	// CreateComment skips the counter, leaving drift for RecountComments.
+	if _, err := st.CreateComment(ctx, &model.Comment{StoryID: storyID, Text: "uncounted", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("create comment: %v", err)
+	}
+	changed, err := st.RecountComments(ctx)
+	if err != nil {
+		t.Fatalf("recount: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != (store.CommentCountChange{StoryID: storyID, From: 1, To: 2}) {
+		t.Fatalf("expected story corrected from 1 to 2, got %+v", changed)
+	}
+	if got := count(); got != 2 {
+		t.Fatalf("expected recounted comment_count 2, got %d", got)
+	}
+	if changed, err = st.RecountComments(ctx); err != nil || len(changed) != 0 {
+		t.Fatalf("expected nothing left to recount, got %+v (%v)", changed, err)
+	}
+}
+
+func TestSessionIDsSurviveVacuum(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	accountID, _, err := st.CreateAccount(ctx, &model.Account{DisplayName: "Sessions", CreatedAt: time.Now()}, &model.AccountKey{Alg: "ed25519", PublicKey: "sessions-key", CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	for _, token := range []string{"tok-c", "tok-a", "tok-b"} {
+		if err := st.CreateToken(ctx, model.Token{Token: token, AccountID: &accountID, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+			t.Fatalf("create token: %v", err)
+		}
+	}
+	ids := func() map[int64]bool {
+		t.Helper()
+		sessions, err := st.ListSessions(ctx, accountID, "", time.Now())
+		if err != nil {
+			t.Fatalf("list sessions: %v", err)
+		}
+		got := map[int64]bool{}
+		for _, sess := range sessions {
+			got[sess.ID] = true
+		}
+		return got
+	}
+
+	if err := st.DeleteToken(ctx, "tok-c"); err != nil {
+		t.Fatalf("delete token: %v", err)
+	}
+	before := ids()
+	if _, err := st.db.ExecContext(ctx, `VACUUM`); err != nil {
+		t.Fatalf("vacuum: %v", err)
+	}
+	if after := ids(); fmt.Sprint(after) != fmt.Sprint(before) {
+		t.Fatalf("session ids changed across VACUUM: %v -> %v", before, after)
+	}
+
+	// A new session never takes a deleted one's id.
+	if err := st.CreateToken(ctx, model.Token{Token: "tok-d", AccountID: &accountID, ExpiresAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if got := ids(); len(got) != 3 || got[1] {
+		t.Fatalf("expected the deleted first session's id to stay unused, got %v", got)
+	}
+}
+
+func TestBackfillStoryDomains(t *testing.T) {
+	st := newTestStore(t)
+	defer st.Close()
+	ctx := context.Background()
+
+	story := model.Story{Title: "Posted before domains", URL: "https://blog.example.co.uk/post", CreatedAt: time.Now()}
+	if _, err := st.CreateStory(ctx, &story); err != nil {
+		t.Fatalf("create story: %v", err)
+	}
+	if _, err := st.db.Exec(`UPDATE stories SET domain = NULL`); err != nil {
+		t.Fatalf("clear domain: %v", err)
+	}
+	if _, err := st.DomainStats(ctx, "example.co.uk", false); err != store.ErrNotFound {
+		t.Fatalf("expected no stories before the backfill, got %v", err)
+	}
+	if err := backfillStoryDomains(st.db, st.logger); err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	stats, err := st.DomainStats(ctx, "www.example.co.uk", false)
+	if err != nil {
+		t.Fatalf("domain stats: %v", err)
+	}
+	if stats.Domain != "example.co.uk" || stats.Stories != 1 {
+		t.Fatalf("unexpected stats after backfill: %+v", stats)
+	}
+}
@@ -18,21 +18,109 @@ var (
 	ErrAlreadyClaimed = errors.New("already claimed")
 )
 
+// Cursor is a keyset position in a created_at-ordered listing.
+// A zero ID means only CreatedAt is known (legacy numeric cursors).
+type Cursor struct {
+	CreatedAt int64 // unix milliseconds
+	ID        int64
+}
+
+// IsZero reports whether the cursor is unset.
+func (c Cursor) IsZero() bool {
+	return c.CreatedAt == 0 && c.ID == 0
+}
+
+// TimeRanges are the StoryListOpts.TimeRange values.
+var TimeRanges = []string{"today", "week", "month", "all"}
+
 type StoryListOpts struct {
-	Sort      string
-	Limit     int
-	Offset    int
-	Cursor    int64
-	Tag       string
-	TimeRange string // "today", "week", "month", "all"
+	Sort   string
+	Limit  int
+	Offset int
+	Cursor Cursor
+	Tag    string
+	// TimeRange lists only stories posted since midnight UTC ("today"), in
+	// the last 7 days ("week") or in the last month ("month"). "" and
+	// "all" don't filter.
+	TimeRange string
 	AccountID *int64 // for "my posts" view
+	// Sandbox lists only stories by sandbox accounts instead of excluding them.
+	Sandbox bool
+	// Domain lists only stories linking to this registrable domain; any
+	// host under it is accepted and normalized.
+	Domain string
+	// Kind lists only stories of one model.Kinds kind.
+	Kind string
+
+	// DiscussedWindow bounds the comments counted by the "discussed" sort.
+	// Zero means DefaultDiscussedWindow.
+	DiscussedWindow time.Duration
+
+	// MaxPerAccount and MaxPerDomain cap how many stories from one account
+	// or URL host may appear in the first DiversityTopN "top" results.
+	// Excess stories are pushed below the top N. Zero disables a cap.
+	MaxPerAccount int
+	MaxPerDomain  int
+	DiversityTopN int
+
+	// Gravity is the age exponent of the "top" rank. Zero means
+	// DefaultGravity.
+	Gravity float64
+	// MinScore, if set, excludes stories scoring below it from the "top"
+	// and "discussed" sorts.
+	MinScore *int
+}
+
+// DefaultDiscussedWindow is the recent-comment window for the "discussed" sort.
+const DefaultDiscussedWindow = 48 * time.Hour
+
+// DefaultGravity is the age exponent of the "top" rank.
+const DefaultGravity = 1.5
+
+// AccountContentSorts are the AccountContentOpts.Sort values.
+var AccountContentSorts = []string{"new", "old", "top"}
+
+// AccountContentOpts pages through one account's stories or comments.
+type AccountContentOpts struct {
+	// Sort is "new" (the default), "old" or "top" (highest score first).
+	Sort   string
+	Limit  int
+	Offset int
+	// Cursor continues a "new" or "old" listing after the last item of
+	// the previous page, in place of Offset.
+	Cursor Cursor
+}
+
+// AccountSorts are the AccountListOpts.Sort values: by name, karma,
+// newest first, and most stories and comments in the last
+// AccountActiveWindow. The first is the default.
+var AccountSorts = []string{"alpha", "karma", "newest", "active"}
+
+// AccountActiveWindow is how far back the "active" account sort counts.
+const AccountActiveWindow = 30 * 24 * time.Hour
+
+// AccountListOpts selects a page of accounts.
+type AccountListOpts struct {
+	Sort string
+	// Query lists only accounts whose name contains it, ignoring case.
+	Query  string
+	Limit  int
+	Offset int
 }
 
+// CommentListOpts selects comments. ListCommentsByStory reads only Sort;
+// ListComments applies every filter that is set.
 type CommentListOpts struct {
 	Sort      string
 	AccountID *int64 // for "my comments" view
+	StoryID   *int64
+	// TimeRange is as in StoryListOpts.
+	TimeRange string
 	Limit     int
 	Offset    int
+	// Cursor continues a "new" listing after the last comment of the
+	// previous page, in place of Offset.
+	Cursor Cursor
 }
 
 type Store interface {
@@ -40,6 +128,26 @@ type Store interface {
 	CommentStore
 	VoteStore
 	FlagStore
+	PenaltyStore
+	DomainBanStore
+	EmbeddingStore
+	CrossPostStore
+	EmailSenderStore
+	InviteStore
+	LoopStore
+	APIKeyStore
+	ScheduleStore
+	RecoveryStore
+	ModerationStore
+	LinkStore
+	ImportStore
+	TemplateStore
+	ChangelogStore
+	PreviewStore
+	LeaderboardStore
+	BadgeStore
+	SandboxStore
+	StatsStore
 	AccountStore
 	AuthStore
 	GetSiteStats(ctx context.Context) (model.SiteStats, error)
@@ -49,52 +157,491 @@ type Store interface {
 type StoryStore interface {
 	CreateStory(ctx context.Context, story *model.Story) (int64, error)
 	GetStory(ctx context.Context, id int64) (model.Story, error)
+	// GetStoriesByID returns the stories with the given IDs, in no
+	// particular order. IDs with no story are left out.
+	GetStoriesByID(ctx context.Context, ids []int64) ([]model.Story, error)
 	FindStoryByURL(ctx context.Context, url string, since time.Time) (model.Story, error)
 	ListStories(ctx context.Context, opts StoryListOpts) ([]model.Story, int, error)
-	ListStoriesByAccount(ctx context.Context, accountID int64, limit, offset int) ([]model.Story, int, error)
+	// ListStoriesByAccount returns a page of the account's visible
+	// stories and how many it has in all.
+	ListStoriesByAccount(ctx context.Context, accountID int64, opts AccountContentOpts) ([]model.Story, int, error)
+	ListStoriesSince(ctx context.Context, since time.Time, limit int) ([]model.Story, error)
+	// CountDomainStoriesSince counts the stories, hidden ones included,
+	// linking to a registrable domain since the given time, and returns
+	// when the oldest of them was posted.
+	CountDomainStoriesSince(ctx context.Context, domain string, since time.Time) (count int, oldest time.Time, err error)
+	// DomainStats summarizes the visible stories linking to domain's
+	// registrable domain, on one side of the sandbox. It returns
+	// ErrNotFound if there are none.
+	DomainStats(ctx context.Context, domain string, sandbox bool) (model.DomainStats, error)
+	// RelatedStories returns up to limit visible stories most like story,
+	// best first, from the same side of the sandbox.
+	RelatedStories(ctx context.Context, story model.Story, limit int) ([]model.RelatedStory, error)
 	IncrementStoryCommentCount(ctx context.Context, storyID int64) error
 	UpdateStoryScore(ctx context.Context, storyID int64, delta int) error
+	// UpdateStory saves the story's previous title and tags as a revision
+	// and marks it edited. An edit that changes nothing is not recorded.
 	UpdateStory(ctx context.Context, storyID int64, title string, tags []string) error
+	// ListStoryRevisions returns the story's earlier versions, oldest first.
+	ListStoryRevisions(ctx context.Context, storyID int64) ([]model.StoryRevision, error)
 	HideStory(ctx context.Context, storyID int64) error
+	// SetStoryLocked locks the story to new comments and votes, replacing
+	// any earlier lock. A nil lock unlocks it.
+	SetStoryLocked(ctx context.Context, storyID int64, lock *model.StoryLock) error
+	// SetPinnedComment pins commentID to the top of the story's comments,
+	// replacing any earlier pin. A nil commentID unpins.
+	SetPinnedComment(ctx context.Context, storyID int64, commentID *int64) error
 }
 
 type CommentStore interface {
 	CreateComment(ctx context.Context, comment *model.Comment) (int64, error)
+	// CreateCommentWithSideEffects inserts the comment and, unless it is
+	// hidden, bumps the story's comment count and the author's karma in the
+	// same transaction. It sets comment.ID and returns the updated story.
+	CreateCommentWithSideEffects(ctx context.Context, comment *model.Comment) (model.Story, error)
+	GetComment(ctx context.Context, id int64) (model.Comment, error)
+	// ListCommentAncestors returns the comment and up to limit of its
+	// ancestors, nearest first, hidden or not. It is empty if the comment
+	// doesn't exist.
+	ListCommentAncestors(ctx context.Context, id int64, limit int) ([]model.Comment, error)
+	// GetCommentsByID returns the comments with the given IDs, in no
+	// particular order. IDs with no comment are left out.
+	GetCommentsByID(ctx context.Context, ids []int64) ([]model.Comment, error)
+	// ListCommentsByStory returns the story's visible comments with the
+	// pinned comment, if any, first.
 	ListCommentsByStory(ctx context.Context, storyID int64, opts CommentListOpts) ([]model.Comment, error)
-	ListCommentsByAccount(ctx context.Context, accountID int64, limit, offset int) ([]model.Comment, int, error)
+	// ListCommentsByAccount returns a page of the account's visible
+	// comments and how many it has in all.
+	ListCommentsByAccount(ctx context.Context, accountID int64, opts AccountContentOpts) ([]model.Comment, int, error)
 	ListComments(ctx context.Context, opts CommentListOpts) ([]model.Comment, int, error)
 	UpdateCommentScore(ctx context.Context, commentID int64, delta int) error
+	// HideComment and UnhideComment keep the story's comment_count, the
+	// number of its visible comments, in step in the same transaction.
 	HideComment(ctx context.Context, commentID int64) error
+	// ListProposalPositions returns each account's latest position reply
+	// to the proposal, ignoring hidden replies.
+	ListProposalPositions(ctx context.Context, proposalID int64) ([]model.ProposalPosition, error)
+	// ProposalTallies counts those positions for every proposal on the
+	// story, keyed by proposal comment ID.
+	ProposalTallies(ctx context.Context, storyID int64) (map[int64]model.ProposalTally, error)
 }
 
 type VoteStore interface {
 	CreateVote(ctx context.Context, vote *model.Vote) error
+	// ApplyVote records the vote and, in the same transaction, adds its
+	// value to the target's score and to its author's karma, and hides the
+	// target once its score is at or below hideAt. It sets vote.ID.
+	// ErrDuplicateVote if the account already voted on the target,
+	// ErrNotFound if the target doesn't exist.
+	ApplyVote(ctx context.Context, vote *model.Vote, hideAt int) (VoteResult, error)
+	// GetUserVote returns an account's vote on one target, or ErrNotFound.
 	GetUserVote(ctx context.Context, accountID int64, targetType string, targetID int64) (*model.Vote, error)
+	// GetUserVotesForStories and GetUserVotesForComments look up an
+	// account's votes on many targets at once, keyed by target ID. Targets
+	// it hasn't voted on are missing from the map.
 	GetUserVotesForStories(ctx context.Context, accountID int64, storyIDs []int64) (map[int64]*model.Vote, error)
 	GetUserVotesForComments(ctx context.Context, accountID int64, commentIDs []int64) (map[int64]*model.Vote, error)
 }
 
 type FlagStore interface {
 	CreateFlag(ctx context.Context, flag *model.Flag) error
+	// GetFlagCount counts the flags on a target that were not dismissed.
 	GetFlagCount(ctx context.Context, targetType string, targetID int64) (int, error)
+	// GetFlagWeight sums the weights of the flags on a target that were not
+	// dismissed.
+	GetFlagWeight(ctx context.Context, targetType string, targetID int64) (float64, error)
+	// GetFlagRecord counts an account's resolved flags by outcome.
+	GetFlagRecord(ctx context.Context, accountID int64) (upheld, dismissed int, err error)
+	// GetUserFlagged reports which of targetIDs (stories or comments, by
+	// targetType) an account has flagged.
+	GetUserFlagged(ctx context.Context, accountID int64, targetType string, targetIDs []int64) (map[int64]bool, error)
+	// ListFlags returns every flag on a story or comment, oldest first.
+	ListFlags(ctx context.Context, targetType string, targetID int64) ([]model.Flag, error)
+	// ListFlaggedStories and ListFlaggedComments return visible content with
+	// at least minFlags flags, heaviest flag weight first, with FlagWeight set.
 	ListFlaggedStories(ctx context.Context, minFlags int, limit, offset int) ([]model.Story, int, error)
 	ListFlaggedComments(ctx context.Context, minFlags int, limit, offset int) ([]model.Comment, int, error)
 }
 
+// PenaltyStore manages admin ranking penalties. Setting a penalty for a
+// target that already has one replaces it.
+type PenaltyStore interface {
+	SetRankPenalty(ctx context.Context, penalty *model.RankPenalty) (int64, error)
+	DeleteRankPenalty(ctx context.Context, id int64) error
+	ListRankPenalties(ctx context.Context) ([]model.RankPenalty, error)
+}
+
+// DomainBanStore manages the admin list of domains stories may not link
+// to. Domains are stored lowercased without a leading "www.".
+type DomainBanStore interface {
+	// BanDomain adds the ban, replacing the reason of an existing one.
+	BanDomain(ctx context.Context, ban *model.DomainBan) error
+	// UnbanDomain returns ErrNotFound if the domain isn't banned.
+	UnbanDomain(ctx context.Context, domain string) error
+	ListDomainBans(ctx context.Context) ([]model.DomainBan, error)
+	// FindDomainBan returns the most specific ban covering host, or
+	// ErrNotFound.
+	FindDomainBan(ctx context.Context, host string) (model.DomainBan, error)
+}
+
+// EmbeddingStore keeps story embeddings for semantic search and
+// recommendations. Vectors are only comparable with others from the same
+// model, so every method is scoped to one.
+type EmbeddingStore interface {
+	// SaveStoryEmbedding stores the story's vector, replacing any earlier
+	// one.
+	SaveStoryEmbedding(ctx context.Context, storyID int64, modelName string, vector []float32) error
+	// ListStoriesWithoutEmbedding returns up to limit stories, newest
+	// first, with no vector from the model.
+	ListStoriesWithoutEmbedding(ctx context.Context, modelName string, limit int) ([]model.Story, error)
+	// ListStoryEmbeddings returns visible stories with their vectors from
+	// the model, newest first.
+	ListStoryEmbeddings(ctx context.Context, opts EmbeddingListOpts) ([]model.StoryEmbedding, error)
+	// ListUpvotedEmbeddings returns the model's vectors for the stories the
+	// account most recently upvoted, up to limit.
+	ListUpvotedEmbeddings(ctx context.Context, accountID int64, modelName string, limit int) ([][]float32, error)
+}
+
+// EmbeddingListOpts selects the candidates for a semantic search or
+// recommendation.
+type EmbeddingListOpts struct {
+	Model string
+	// Sandbox picks stories by sandbox accounts instead of the others.
+	Sandbox bool
+	// Since, if set, leaves out older stories.
+	Since time.Time
+	// ExcludeAccountID, if set, leaves out stories the account posted or
+	// voted on.
+	ExcludeAccountID int64
+	Limit            int
+}
+
+// CrossPostStore keeps the ledger of stories published to social networks.
+type CrossPostStore interface {
+	// ListCrossPostCandidates returns up to limit visible stories, best
+	// first, scoring at least minScore, created since the given time and
+	// not yet posted to network. Sandbox stories are never candidates.
+	ListCrossPostCandidates(ctx context.Context, network string, minScore int, since time.Time, limit int) ([]model.Story, error)
+	// RecordCrossPost adds the post to the ledger. It returns
+	// ErrDuplicateKey if the story was already posted to the network.
+	RecordCrossPost(ctx context.Context, post *model.CrossPost) error
+	// ListCrossPosts returns the latest posts, newest first.
+	ListCrossPosts(ctx context.Context, limit int) ([]model.CrossPost, error)
+}
+
+// EmailSenderStore keeps each account's inbound email sender.
+type EmailSenderStore interface {
+	// SetEmailSender replaces the account's sender and the hash of its
+	// submission token.
+	SetEmailSender(ctx context.Context, sender *model.EmailSender, tokenHash string) error
+	// GetEmailSender and GetEmailSenderByToken return ErrNotFound if there
+	// is no sender.
+	GetEmailSender(ctx context.Context, accountID int64) (model.EmailSender, error)
+	GetEmailSenderByToken(ctx context.Context, tokenHash string) (model.EmailSender, error)
+	// DeleteEmailSender returns ErrNotFound if the account has no sender.
+	DeleteEmailSender(ctx context.Context, accountID int64) error
+	TouchEmailSender(ctx context.Context, accountID int64, usedAt time.Time) error
+}
+
+// InviteStore manages registration invite codes.
+type InviteStore interface {
+	CreateInvite(ctx context.Context, invite *model.Invite) error
+	GetInvite(ctx context.Context, code string) (model.Invite, error)
+	// RedeemInvite marks an unused invite as used by accountID. It returns
+	// ErrNotFound if the code does not exist or was already used.
+	RedeemInvite(ctx context.Context, code string, accountID int64, usedAt time.Time) error
+}
+
+// LoopStore tracks reply loop incidents awaiting moderator release.
+type LoopStore interface {
+	CreateLoopIncident(ctx context.Context, incident *model.LoopIncident) (int64, error)
+	// GetOpenLoopIncident returns the unreleased incident on storyID that
+	// involves accountID, or ErrNotFound.
+	GetOpenLoopIncident(ctx context.Context, storyID, accountID int64) (model.LoopIncident, error)
+	ListOpenLoopIncidents(ctx context.Context, limit, offset int) ([]model.LoopIncident, int, error)
+	// ReleaseLoopIncident returns ErrNotFound if the incident does not exist
+	// or was already released.
+	ReleaseLoopIncident(ctx context.Context, id int64, releasedAt time.Time) error
+}
+
+// APIKeyStore manages hashed API keys. Lookups are by key hash.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey, hash string) (int64, error)
+	// GetAPIKeyByHash returns ErrNotFound for unknown keys and for keys
+	// whose account no longer exists.
+	GetAPIKeyByHash(ctx context.Context, hash string) (model.APIKey, error)
+	ListAPIKeys(ctx context.Context, accountID int64) ([]model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, accountID, id int64, revokedAt time.Time) error
+	TouchAPIKey(ctx context.Context, id int64, usedAt time.Time) error
+}
+
+// ScheduleStore persists per-account posting schedules.
+type ScheduleStore interface {
+	GetPostingSchedule(ctx context.Context, accountID int64) (model.PostingSchedule, error)
+	SetPostingSchedule(ctx context.Context, schedule *model.PostingSchedule) error
+	DeletePostingSchedule(ctx context.Context, accountID int64) error
+}
+
+// ModerationStore backs the admin dashboard.
+type ModerationStore interface {
+	// UnhideStory and UnhideComment return ErrNotFound for unknown IDs.
+	UnhideStory(ctx context.Context, storyID int64) error
+	UnhideComment(ctx context.Context, commentID int64) error
+	// RecountComments resets every story's comment_count to its number of
+	// visible comments in one transaction and returns the stories that
+	// changed.
+	RecountComments(ctx context.Context) ([]CommentCountChange, error)
+	// ResolveFlags moves the open flags on a story or comment to state
+	// (model.FlagDismissed or model.FlagUpheld) and returns them. The
+	// target's flag_count is recomputed to exclude dismissed flags.
+	ResolveFlags(ctx context.Context, targetType string, targetID int64, state string, resolvedAt time.Time) ([]model.Flag, error)
+	// BanAccount suspends every authenticated request from the account.
+	// Banning a banned account updates the reason.
+	BanAccount(ctx context.Context, accountID int64, reason string, bannedAt time.Time) error
+	// UnbanAccount returns ErrNotFound if the account is not banned.
+	UnbanAccount(ctx context.Context, accountID int64) error
+	IsAccountBanned(ctx context.Context, accountID int64) (bool, error)
+	ListAccountBans(ctx context.Context) ([]model.AccountBan, error)
+	RecordAudit(ctx context.Context, entry *model.AuditEntry) error
+	// ListAuditLog returns entries newest first.
+	ListAuditLog(ctx context.Context, limit, offset int) ([]model.AuditEntry, int, error)
+	// SearchContent matches query against story titles, URLs and text and
+	// comment text, including hidden content.
+	SearchContent(ctx context.Context, query string, limit int) ([]model.Story, []model.Comment, error)
+	// AddModNote stores a moderator note and sets its ID.
+	AddModNote(ctx context.Context, note *model.ModNote) error
+	// ListModNotes returns notes newest first: those on one target, or the
+	// latest on any target when targetType is empty. An account's notes
+	// include those on its stories and comments.
+	ListModNotes(ctx context.Context, targetType string, targetID int64, limit int) ([]model.ModNote, error)
+	// DeleteModNote returns ErrNotFound for unknown IDs.
+	DeleteModNote(ctx context.Context, id int64) error
+}
+
+// RecoveryStore manages account recovery keys and pending recoveries.
+type RecoveryStore interface {
+	// SetRecoveryKey registers or replaces the account's recovery key.
+	SetRecoveryKey(ctx context.Context, key *model.RecoveryKey) error
+	GetRecoveryKey(ctx context.Context, accountID int64) (model.RecoveryKey, error)
+	// CreateRecovery returns ErrDuplicateKey if the account already has a
+	// pending recovery.
+	CreateRecovery(ctx context.Context, rec *model.Recovery) (int64, error)
+	// GetPendingRecovery returns the account's recovery that is neither
+	// completed nor cancelled, or ErrNotFound.
+	GetPendingRecovery(ctx context.Context, accountID int64) (model.Recovery, error)
+	// CancelRecovery returns ErrNotFound if no recovery is pending.
+	CancelRecovery(ctx context.Context, accountID int64, cancelledAt time.Time) error
+	// CompleteRecovery adds key, revokes every other key on the account
+	// with its tokens and the account's API keys, and marks the recovery
+	// and the recovery key used, in one transaction. It returns the new key's ID.
+	CompleteRecovery(ctx context.Context, recoveryID int64, key *model.AccountKey, completedAt time.Time) (int64, error)
+}
+
+// LinkStore manages links between local accounts and accounts on other
+// instances.
+type LinkStore interface {
+	// AddAccountLink records a verified link, refreshing it if the account
+	// is already linked to the remote account.
+	AddAccountLink(ctx context.Context, link *model.AccountLink) (int64, error)
+	// ListAccountLinks returns the account's links whose key is still
+	// active on the account.
+	ListAccountLinks(ctx context.Context, accountID int64) ([]model.AccountLink, error)
+	// DeleteAccountLink returns ErrNotFound if the link doesn't belong to
+	// the account.
+	DeleteAccountLink(ctx context.Context, accountID, linkID int64) error
+}
+
+// TemplateStore keeps accounts' story templates. Names are unique per
+// account.
+type TemplateStore interface {
+	// SaveStoryTemplate creates the template, or replaces the account's
+	// template of the same name, and returns its ID.
+	SaveStoryTemplate(ctx context.Context, tmpl *model.StoryTemplate) (int64, error)
+	GetStoryTemplate(ctx context.Context, id int64) (model.StoryTemplate, error)
+	ListStoryTemplates(ctx context.Context, accountID int64) ([]model.StoryTemplate, error)
+	// DeleteStoryTemplate returns ErrNotFound if the template doesn't
+	// belong to the account.
+	DeleteStoryTemplate(ctx context.Context, accountID, id int64) error
+}
+
+// ImportedComment is a comment in an archived thread. Parent indexes an
+// earlier comment in the same thread, or is -1 for a top-level comment.
+type ImportedComment struct {
+	Comment model.Comment
+	Parent  int
+}
+
+// ChangelogStore remembers which API changelog entries were announced.
+type ChangelogStore interface {
+	// MarkChangelogAnnounced records entryID and reports whether it is new,
+	// so each entry is announced once across restarts.
+	MarkChangelogAnnounced(ctx context.Context, entryID string, at time.Time) (bool, error)
+}
+
+// SandboxPurge counts the content removed by PurgeSandbox.
+type SandboxPurge struct {
+	Stories  int
+	Comments int
+}
+
+type SandboxStore interface {
+	// PurgeSandbox deletes stories by sandbox accounts created before the
+	// given time, with their comments, votes, flags and reactions, and
+	// resets every sandbox account's karma and badges.
+	PurgeSandbox(ctx context.Context, before time.Time) (SandboxPurge, error)
+}
+
+type StatsStore interface {
+	// RecordStatsSnapshot stores the site totals as of the end of day's UTC
+	// date and the accounts active on it, replacing any earlier snapshot
+	// of that date.
+	RecordStatsSnapshot(ctx context.Context, day time.Time) (model.StatsSnapshot, error)
+	// ListStatsHistory returns the snapshots from since's UTC date on,
+	// oldest first.
+	ListStatsHistory(ctx context.Context, since time.Time) ([]model.StatsSnapshot, error)
+}
+
+type BadgeStore interface {
+	// GetBadgeStats returns the figures badges are earned on, counting
+	// only visible stories and comments.
+	GetBadgeStats(ctx context.Context, accountID int64) (model.BadgeStats, error)
+	// AwardBadges records badgeIDs for the account and returns the ones
+	// it didn't already have.
+	AwardBadges(ctx context.Context, accountID int64, badgeIDs []string, at time.Time) ([]string, error)
+	// ListAccountBadges returns each account's badges, oldest first.
+	ListAccountBadges(ctx context.Context, accountIDs []int64) (map[int64][]model.AccountBadge, error)
+}
+
+// Leaderboards ranked by LeaderboardStore.
+const (
+	LeaderboardKarma         = "karma"
+	LeaderboardStories       = "stories"
+	LeaderboardComments      = "comments"
+	LeaderboardAvgStoryScore = "avg_story_score"
+)
+
+// Leaderboards lists every board in display order.
+var Leaderboards = []string{LeaderboardKarma, LeaderboardStories, LeaderboardComments, LeaderboardAvgStoryScore}
+
+// LeaderboardMinStories is how many stories an account needs in the window
+// to rank by average story score.
+const LeaderboardMinStories = 3
+
+type LeaderboardStore interface {
+	// Leaderboard ranks accounts on board by visible content created since
+	// the given time; a zero time means all time. All-time karma is the
+	// stored karma; windowed karma counts the votes and submission bonuses
+	// earned in the window.
+	Leaderboard(ctx context.Context, board string, since time.Time, limit int) ([]model.LeaderboardEntry, error)
+}
+
+// PreviewStore backs the experimental /api/preview endpoints. Its methods
+// may change without notice along with them.
+type PreviewStore interface {
+	// SearchVisibleContent is SearchContent without hidden stories and
+	// comments, or comments on hidden stories.
+	SearchVisibleContent(ctx context.Context, query string, limit int) ([]model.Story, []model.Comment, error)
+	// ListContentAfter returns visible stories and comments with IDs above
+	// the given ones, oldest first, up to limit of each.
+	ListContentAfter(ctx context.Context, afterStoryID, afterCommentID int64, limit int) ([]model.Story, []model.Comment, error)
+	// LatestContentIDs returns the highest story and comment IDs, hidden
+	// or not, so a stream can start from now.
+	LatestContentIDs(ctx context.Context) (storyID, commentID int64, err error)
+	// ToggleReaction adds the reaction, or removes it if the account had
+	// already left it, and reports whether it was added.
+	ToggleReaction(ctx context.Context, reaction *model.Reaction) (bool, error)
+	// CountReactions counts a target's reactions by kind.
+	CountReactions(ctx context.Context, targetType string, targetID int64) (map[string]int, error)
+}
+
+// VoteResult is a vote's target after ApplyVote.
+type VoteResult struct {
+	AuthorID int64
+	Score    int
+	Hidden   bool
+	// AutoHidden reports whether this vote hid the target.
+	AutoHidden bool
+}
+
+// CommentCountChange is one story corrected by RecountComments.
+type CommentCountChange struct {
+	StoryID int64 `json:"story_id"`
+	From    int   `json:"from"`
+	To      int   `json:"to"`
+}
+
+// KarmaChange is one account corrected by RecalculateKarma.
+type KarmaChange struct {
+	AccountID int64 `json:"account_id"`
+	From      int   `json:"from"`
+	To        int   `json:"to"`
+}
+
+// ImportStore bulk-loads archived threads from other sites.
+type ImportStore interface {
+	// ImportStory inserts story and its comments in one transaction,
+	// keeping their timestamps and scores and without touching karma.
+	// It returns ErrDuplicateKey if externalID was already imported from
+	// source.
+	ImportStory(ctx context.Context, source, externalID string, story *model.Story, comments []ImportedComment) (int64, error)
+}
+
 type AccountStore interface {
 	CreateAccount(ctx context.Context, account *model.Account, key *model.AccountKey) (accountID, keyID int64, err error)
 	GetAccount(ctx context.Context, id int64) (model.Account, error)
+	// GetAccountsByID returns the accounts with the given IDs, in no
+	// particular order; IDs with no account are skipped.
+	GetAccountsByID(ctx context.Context, ids []int64) ([]model.Account, error)
+	GetAccountByName(ctx context.Context, name string) (model.Account, error)
 	GetAccountKeys(ctx context.Context, accountID int64) ([]model.AccountKey, error)
 	AddAccountKey(ctx context.Context, accountID int64, key *model.AccountKey) (keyID int64, err error)
+	// SetAccountDID records the account's DID if it doesn't have one yet.
+	SetAccountDID(ctx context.Context, accountID int64, did string) error
+	// RevokeAccountKey revokes the key and deletes the tokens issued to it.
 	RevokeAccountKey(ctx context.Context, accountID, keyID int64, revokedAt time.Time) error
+	// FreezeAccount suspends writes from the account, deletes its tokens,
+	// invalidates JWTs issued before frozenAt and revokes its API keys.
+	// Freezing a frozen account keeps the original freeze time.
+	FreezeAccount(ctx context.Context, accountID int64, frozenAt time.Time) error
+	// UnfreezeAccount returns ErrNotFound if the account is not frozen.
+	UnfreezeAccount(ctx context.Context, accountID int64) error
+	IsAccountFrozen(ctx context.Context, accountID int64) (bool, error)
+	// RevokeOtherAccountKeys revokes every active key on the account except
+	// keepKeyID, deletes tokens issued to them and revokes the account's API
+	// keys. It returns how many account keys were revoked.
+	RevokeOtherAccountKeys(ctx context.Context, accountID, keepKeyID int64, revokedAt time.Time) (int, error)
 	FindAccountKey(ctx context.Context, alg, publicKey string) (model.AccountKey, *model.Account, error)
 	UpdateAccountKarma(ctx context.Context, accountID int64, delta int) error
-	ListAccounts(ctx context.Context, sort string, limit, offset int) ([]model.Account, int, error)
+	// GetKarmaBreakdown computes the account's karma by source from the
+	// votes, content and rewards on record; ErrNotFound for unknown IDs.
+	GetKarmaBreakdown(ctx context.Context, accountID int64) (model.KarmaBreakdown, error)
+	// RecalculateKarma resets every account's karma to its computed total
+	// in one transaction and returns the accounts that changed.
+	RecalculateKarma(ctx context.Context) ([]KarmaChange, error)
+	// ListAccounts returns a page of the non-sandbox accounts and how many
+	// match in all.
+	ListAccounts(ctx context.Context, opts AccountListOpts) ([]model.Account, int, error)
 	GetAccountKey(ctx context.Context, keyID int64) (model.AccountKey, error)
+	// DeleteAccount removes the account with its keys, tokens and API keys.
+	// A token cutoff is kept so JWTs issued to it stay invalid.
 	DeleteAccount(ctx context.Context, accountID int64) error
 	RenameAccount(ctx context.Context, accountID int64, newName string) error
+	UpdateAccountProfile(ctx context.Context, accountID int64, bio, homepageURL string) error
+	// SetAccountTheme sets the account's HTML theme, "" for none.
+	SetAccountTheme(ctx context.Context, accountID int64, theme string) error
+	// SetAccountLanguage sets the language the account's pages render in,
+	// "" for none.
+	SetAccountLanguage(ctx context.Context, accountID int64, language string) error
+	// GetAccountActivitySummary totals the account's visible stories and
+	// comments and the votes it cast, with streaks of active UTC days.
 	GetAccountActivitySummary(ctx context.Context, accountID int64) (model.ActivitySummary, error)
-	GetRecentlyActiveUsers(ctx context.Context, limit int) ([]model.UserActivity, error)
+	// GetAccountAnalytics buckets the account's visible stories and
+	// comments, and the votes it cast, since the given time.
+	GetAccountAnalytics(ctx context.Context, accountID int64, since time.Time) (model.AccountAnalytics, error)
+	// GetRecentlyActiveUsers lists the accounts with a visible story or
+	// comment, or a vote, since the given time, most recently active
+	// first. Sandbox accounts are left out.
+	GetRecentlyActiveUsers(ctx context.Context, since time.Time, limit int) ([]model.UserActivity, error)
 	ClaimGitHubStar(ctx context.Context, accountID int64, githubUsername string) error
 	HasClaimedGitHubStar(ctx context.Context, accountID int64) (bool, error)
 }
@@ -104,4 +651,25 @@ type AuthStore interface {
 	ConsumeChallenge(ctx context.Context, challenge string) (model.Challenge, error)
 	CreateToken(ctx context.Context, token model.Token) error
 	GetToken(ctx context.Context, token string) (model.Token, error)
+	TouchToken(ctx context.Context, token string, at time.Time) error
+	// ListSessions returns the account's unexpired opaque tokens, marking
+	// the one equal to currentToken.
+	ListSessions(ctx context.Context, accountID int64, currentToken string, now time.Time) ([]model.Session, error)
+	// DeleteSession returns ErrNotFound unless the session belongs to accountID.
+	DeleteSession(ctx context.Context, accountID, id int64) error
+	// UseNonce records a signed-request nonce for keyID until expiresAt.
+	// It returns ErrDuplicateKey if the nonce was already used.
+	UseNonce(ctx context.Context, keyID int64, nonce string, expiresAt time.Time) error
+	DeleteToken(ctx context.Context, token string) error
+	// RevokeToken adds a JWT ID to the revocation list until expiresAt.
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	// ListRevokedTokens returns the revoked JWT IDs that have not expired
+	// as of now, mapped to their expiry.
+	ListRevokedTokens(ctx context.Context, now time.Time) (map[string]time.Time, error)
+	// ListRevokedKeys returns the IDs of account keys revoked at or after
+	// since; JWTs issued to them before the revocation are still unexpired.
+	ListRevokedKeys(ctx context.Context, since time.Time) (map[int64]bool, error)
+	// ListTokenCutoffs maps accounts to the time before which every token
+	// issued to them is invalid, for cutoffs at or after since.
+	ListTokenCutoffs(ctx context.Context, since time.Time) (map[int64]time.Time, error)
 }